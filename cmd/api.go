@@ -3,13 +3,28 @@ package cmd
 import (
 	"os"
 
+	apikey_repository "github.com/celio001/prodify/internal/apikey/repository"
+	apikey_service "github.com/celio001/prodify/internal/apikey/service"
+	audit_repository "github.com/celio001/prodify/internal/audit/repository"
+	audit_service "github.com/celio001/prodify/internal/audit/service"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_oidc "github.com/celio001/prodify/internal/auth/oidc"
+	auth_repository "github.com/celio001/prodify/internal/auth/repository"
 	auth_service "github.com/celio001/prodify/internal/auth/service"
 	"github.com/celio001/prodify/internal/fiber"
+	oauth_repository "github.com/celio001/prodify/internal/oauth/repository"
+	oauth_service "github.com/celio001/prodify/internal/oauth/service"
+	organization_repository "github.com/celio001/prodify/internal/organization/repository"
+	organization_service "github.com/celio001/prodify/internal/organization/service"
 	user_repository "github.com/celio001/prodify/internal/user/repository"
 	user_service "github.com/celio001/prodify/internal/user/service"
 	"github.com/celio001/prodify/pkg/lifecycle"
 	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	"github.com/celio001/prodify/pkg/password-hasher/hasher"
+	"github.com/celio001/prodify/pkg/password-validator/breach"
 	"github.com/celio001/prodify/pkg/postgress"
+	"github.com/celio001/prodify/pkg/storage"
 	"github.com/celio001/prodify/product"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -43,13 +58,63 @@ func ApiExecute(cmd *cobra.Command, args []string) error {
 	}
 	defer connPostgres.Close()
 
+	mail, err := mailer.New()
+	if err != nil {
+		logger.Log.Fatal("failed to configure mailer", zap.String("error", err.Error()))
+	}
+
+	limiter, err := auth_lockout.New(connPostgres)
+	if err != nil {
+		logger.Log.Fatal("failed to configure login lockout", zap.String("error", err.Error()))
+	}
+
+	passwordHasher, err := hasher.New()
+	if err != nil {
+		logger.Log.Fatal("failed to configure password hasher", zap.String("error", err.Error()))
+	}
+
+	breaches, err := breach.New()
+	if err != nil {
+		logger.Log.Fatal("failed to load breached password corpus", zap.String("error", err.Error()))
+	}
+
+	providers, err := auth_oidc.New()
+	if err != nil {
+		logger.Log.Fatal("failed to configure identity providers", zap.String("error", err.Error()))
+	}
+
+	files, err := storage.New()
+	if err != nil {
+		logger.Log.Fatal("failed to configure file storage", zap.String("error", err.Error()))
+	}
+
 	productRepository := product.NewRepository(connPostgres)
 
-	userRepository := user_repository.NewUserRepository(connPostgres)
-	userSvc := user_service.NewUserService(userRepository)
-	authService := auth_service.NewAuthService(userRepository)
+	userRepository := user_repository.NewUserRepository(connPostgres, passwordHasher)
+	tokenRepository := auth_repository.NewTokenRepository(connPostgres)
+	mfaRepository := auth_repository.NewMFARepository(connPostgres)
+	sessionRepository := auth_repository.NewSessionRepository(connPostgres)
+	identityRepository := auth_repository.NewIdentityRepository(connPostgres)
+	invitationRepository := auth_repository.NewInvitationRepository(connPostgres)
+	userSvc := user_service.NewUserService(userRepository, files)
+	authService := auth_service.NewAuthService(userRepository, tokenRepository, mfaRepository, sessionRepository, identityRepository, invitationRepository, mail, limiter, passwordHasher, breaches)
+
+	apiKeyRepository := apikey_repository.NewAPIKeyRepository(connPostgres)
+	apiKeySvc := apikey_service.NewAPIKeyService(apiKeyRepository, userRepository)
+
+	oauthClientRepository := oauth_repository.NewClientRepository(connPostgres)
+	oauthGrantRepository := oauth_repository.NewGrantRepository(connPostgres)
+	oauthSvc := oauth_service.NewOAuthService(oauthClientRepository, oauthGrantRepository, userRepository)
+
+	orgRepository := organization_repository.NewOrganizationRepository(connPostgres)
+	orgSvc := organization_service.NewOrganizationService(orgRepository, userRepository, mail)
+
+	auditRepository := audit_repository.NewAuditRepository(connPostgres)
+	auditSvc := audit_service.NewAuditService(auditRepository)
+	auditRecorder := audit_service.NewRecorder(auditRepository)
+	go auditRecorder.Run()
 
-	s := fiber.CreateServer(productRepository, authService, userSvc)
+	s := fiber.CreateServer(productRepository, authService, userSvc, apiKeySvc, oauthSvc, orgSvc, providers, auditSvc, auditRecorder)
 
 	lifecycle.New(cmd.Context(), "product-api", s.Start, s.Stop)
 
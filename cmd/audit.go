@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	audit_repository "github.com/celio001/prodify/internal/audit/repository"
+	audit_service "github.com/celio001/prodify/internal/audit/service"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/postgress"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var (
+	auditCommand = &cobra.Command{
+		Use:   "audit",
+		Short: "Audit log tools",
+		Long:  "Tools for the tamper-evident audit log",
+	}
+
+	auditVerifyCommand = &cobra.Command{
+		Use:          "verify",
+		Short:        "Verifies the audit hash chain",
+		Long:         "Walks the audit hash chain from the first event, checks the signed checkpoints with AUDIT_VERIFY_KEY (or AUDIT_SIGNING_KEY) and reports the first broken link. Exits with an error when the chain is broken",
+		SilenceUsage: true,
+		RunE:         AuditVerifyExecute,
+	}
+
+	auditKeygenCommand = &cobra.Command{
+		Use:   "keygen",
+		Short: "Generates a key pair for the audit checkpoints",
+		Long:  "Generates an ed25519 key pair for the audit checkpoints, to be set as AUDIT_SIGNING_KEY and AUDIT_VERIFY_KEY",
+		RunE:  AuditKeygenExecute,
+	}
+)
+
+func init() {
+	auditCommand.AddCommand(auditVerifyCommand, auditKeygenCommand)
+	rootCmd.AddCommand(auditCommand)
+}
+
+func AuditVerifyExecute(cmd *cobra.Command, args []string) error {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	logger.Init(env)
+	defer logger.Log.Sync()
+
+	connPostgres, err := postgress.NewInstance()
+	if err != nil {
+		logger.Log.Fatal("failed to connect to Postgres", zap.String("error", err.Error()))
+	}
+	defer connPostgres.Close()
+
+	auditChain, err := audit_service.NewChain(audit_repository.NewAuditRepository(connPostgres))
+	if err != nil {
+		return err
+	}
+
+	report, err := auditChain.Verify()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "chained events: %d\n", report.Events)
+	fmt.Fprintf(out, "events before the chain: %d\n", report.Unchained)
+	fmt.Fprintf(out, "checkpoints: %d\n", report.Checkpoints)
+	if report.Head != "" {
+		fmt.Fprintf(out, "head: %s\n", report.Head)
+	}
+
+	if report.Break == nil {
+		fmt.Fprintln(out, "chain intact")
+		return nil
+	}
+
+	fmt.Fprintln(out, "chain broken")
+	fmt.Fprintf(out, "event: %d\n", report.Break.EventID)
+	if report.Break.PublicID != "" {
+		fmt.Fprintf(out, "event id: %s\n", report.Break.PublicID)
+	}
+	if report.Break.CheckpointID != 0 {
+		fmt.Fprintf(out, "checkpoint: %d\n", report.Break.CheckpointID)
+	}
+	fmt.Fprintf(out, "reason: %s\n", report.Break.Reason)
+
+	return errors.New("audit chain is broken")
+}
+
+func AuditKeygenExecute(cmd *cobra.Command, args []string) error {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	logger.Init(env)
+	defer logger.Log.Sync()
+
+	signingKey, verifyKey, err := audit_service.GenerateKeys()
+	if err != nil {
+		return fmt.Errorf("failed to generate audit keys: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "AUDIT_SIGNING_KEY=%s\nAUDIT_VERIFY_KEY=%s\n", signingKey, verifyKey)
+	return nil
+}
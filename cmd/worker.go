@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	audit_repository "github.com/celio001/prodify/internal/audit/repository"
+	audit_service "github.com/celio001/prodify/internal/audit/service"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	user_erasure "github.com/celio001/prodify/internal/user/erasure"
+	user_repository "github.com/celio001/prodify/internal/user/repository"
+	user_service "github.com/celio001/prodify/internal/user/service"
+	"github.com/celio001/prodify/pkg/lifecycle"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/password-hasher/hasher"
+	"github.com/celio001/prodify/pkg/postgress"
+	"github.com/celio001/prodify/pkg/storage"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	workerCommand = &cobra.Command{
+		Use:   "worker",
+		Short: "Runs the background jobs",
+		Long:  "Runs the background jobs: the erasure of deleted accounts, the signed checkpoints of the audit chain and the purge of expired login lockouts",
+		RunE:  WorkerExecute,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(workerCommand)
+}
+
+func WorkerExecute(cmd *cobra.Command, args []string) error {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "dev"
+	}
+
+	logger.Init(env)
+	defer logger.Log.Sync()
+
+	connPostgres, err := postgress.NewInstance()
+	if err != nil {
+		logger.Log.Fatal("failed to connect to Postgres", zap.String("error", err.Error()))
+	}
+	defer connPostgres.Close()
+
+	passwordHasher, err := hasher.New()
+	if err != nil {
+		logger.Log.Fatal("failed to configure password hasher", zap.String("error", err.Error()))
+	}
+
+	files, err := storage.New()
+	if err != nil {
+		logger.Log.Fatal("failed to configure file storage", zap.String("error", err.Error()))
+	}
+
+	userRepository := user_repository.NewUserRepository(connPostgres, passwordHasher)
+	userSvc := user_service.NewUserService(userRepository, files)
+
+	worker := user_erasure.NewWorker(userSvc)
+
+	auditChain, err := audit_service.NewChain(audit_repository.NewAuditRepository(connPostgres))
+	if err != nil {
+		logger.Log.Fatal("failed to configure audit checkpoints", zap.String("error", err.Error()))
+	}
+
+	// with the memory lockout store the table stays empty and the purge finds nothing
+	lockoutSweeper := auth_lockout.NewSweeper(auth_lockout.NewPostgresStore(connPostgres))
+
+	start := func(ctx context.Context) error {
+		g, gCtx := errgroup.WithContext(ctx)
+		g.Go(func() error { return worker.Start(gCtx) })
+		g.Go(func() error { return auditChain.Start(gCtx) })
+		g.Go(func() error { return lockoutSweeper.Start(gCtx) })
+		return g.Wait()
+	}
+	stop := func(ctx context.Context) error {
+		return errors.Join(worker.Stop(ctx), auditChain.Stop(ctx), lockoutSweeper.Stop(ctx))
+	}
+
+	lifecycle.New(cmd.Context(), "worker", start, stop)
+
+	return nil
+}
@@ -12,6 +12,111 @@ var config = map[string]string{
 	"PORT_POST":     "PORT_POST",
 	"PASSWORD_POST": "PASSWORD_POST",
 	"DB_NAME_POST":  "DB_NAME_POST",
+
+	//app
+	"APP_BASE_URL":      "http://localhost:8080",
+	"HTTP_PROXY_HEADER": "",
+
+	//mailer
+	"MAILER_DRIVER":  "log",
+	"MAILER_FROM":    "no-reply@prodify.local",
+	"MAILER_LOG_DIR": "",
+	"SMTP_HOST":      "localhost",
+	"SMTP_PORT":      "1025",
+	"SMTP_USERNAME":  "",
+	"SMTP_PASSWORD":  "",
+
+	//auth
+	"AUTH_REQUIRE_EMAIL_VERIFICATION": "false",
+	"AUTH_PASSWORD_HISTORY_SIZE":      "5",
+
+	//registration, when self-registration is off accounts are only created by admin invitation
+	"AUTH_ALLOW_SELF_REGISTRATION": "true",
+	"AUTH_INVITATION_TTL_HOURS":    "72",
+
+	//mfa
+	"MFA_ISSUER": "prodify",
+
+	//social login, each provider in OIDC_PROVIDERS also reads
+	//OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and OIDC_<NAME>_ISSUER
+	"OIDC_PROVIDERS":         "",
+	"OIDC_REDIRECT_BASE_URL": "http://localhost:8080/api/v1/auth/oauth",
+
+	//login lockout
+	"AUTH_LOCKOUT_STORE":              "memory",
+	"AUTH_LOCKOUT_FREE_ATTEMPTS":      "3",
+	"AUTH_LOCKOUT_MAX_FAILURES":       "10",
+	"AUTH_LOCKOUT_IP_FREE_ATTEMPTS":   "20",
+	"AUTH_LOCKOUT_IP_MAX_FAILURES":    "100",
+	"AUTH_LOCKOUT_BASE_DELAY_SECONDS": "1",
+	"AUTH_LOCKOUT_DURATION_MINUTES":   "15",
+	"AUTH_LOCKOUT_WINDOW_MINUTES":     "15",
+	//the worker purges expired keys of the postgres store
+	"AUTH_LOCKOUT_PURGE_INTERVAL_MINUTES": "60",
+
+	//magic link
+	"AUTH_MAGIC_LINK_MAX_REQUESTS":   "3",
+	"AUTH_MAGIC_LINK_WINDOW_MINUTES": "15",
+
+	//password strength check, per client IP
+	"AUTH_PASSWORD_STRENGTH_MAX_REQUESTS":   "30",
+	"AUTH_PASSWORD_STRENGTH_WINDOW_MINUTES": "1",
+
+	//forgot password, per email and per client IP
+	"AUTH_PASSWORD_RESET_MAX_REQUESTS":    "3",
+	"AUTH_PASSWORD_RESET_IP_MAX_REQUESTS": "20",
+	"AUTH_PASSWORD_RESET_WINDOW_MINUTES":  "15",
+
+	//password policy
+	"PASSWORD_MIN_ENTROPY":     "60",
+	"PASSWORD_MIN_SCORE":       "3",
+	"PASSWORD_MIN_LENGTH":      "8",
+	"PASSWORD_MAX_LENGTH":      "72",
+	"PASSWORD_REQUIRE_LOWER":   "true",
+	"PASSWORD_REQUIRE_UPPER":   "true",
+	"PASSWORD_REQUIRE_DIGITS":  "true",
+	"PASSWORD_REQUIRE_SPECIAL": "false",
+
+	//personal data erasure, deleted accounts are anonymized after the grace period
+	"USER_ERASURE_GRACE_DAYS":       "30",
+	"USER_ERASURE_INTERVAL_MINUTES": "60",
+	"USER_ERASURE_BATCH_SIZE":       "100",
+
+	//audit log, events are buffered in memory and written in batches
+	"AUDIT_BUFFER_SIZE":       "1024",
+	"AUDIT_BATCH_SIZE":        "100",
+	"AUDIT_FLUSH_INTERVAL_MS": "500",
+	"AUDIT_EXPORT_MAX_ROWS":   "50000",
+
+	//key of the HMAC that stands in for emails without an account in the audit log,
+	//empty falls back to JWT_SECRET
+	"AUDIT_EMAIL_KEY": "",
+
+	//audit chain checkpoints, signed by the worker with the base64 ed25519 seed in
+	//AUDIT_SIGNING_KEY; AUDIT_VERIFY_KEY lets `audit verify` run with the public key only
+	"AUDIT_SIGNING_KEY":                 "",
+	"AUDIT_VERIFY_KEY":                  "",
+	"AUDIT_CHECKPOINT_INTERVAL_MINUTES": "60",
+
+	//file storage, STORAGE_PUBLIC_URL is where clients fetch stored files from
+	"STORAGE_DRIVER":     "local",
+	"STORAGE_LOCAL_DIR":  "uploads",
+	"STORAGE_PUBLIC_URL": "http://localhost:8080/uploads",
+
+	//profile defaults, used until a user saves their own preferences
+	"DEFAULT_LOCALE":   "en",
+	"DEFAULT_TIMEZONE": "UTC",
+	"DEFAULT_CURRENCY": "USD",
+
+	//breached passwords, a Pwned Passwords corpus ordered by hash, empty disables the check
+	"PASSWORD_BREACH_CORPUS": "",
+
+	//password hashing, hashes weaker than this are upgraded on the next login
+	"PASSWORD_HASH_ALGORITHM":     "bcrypt",
+	"PASSWORD_BCRYPT_COST":        "10",
+	"PASSWORD_ARGON2_MEMORY_KIB":  "65536",
+	"PASSWORD_ARGON2_ITERATIONS":  "3",
+	"PASSWORD_ARGON2_PARALLELISM": "2",
 }
 
 func GetString(k string) string {
@@ -31,3 +136,13 @@ func GetInt(k string) int {
 
 	return i
 }
+
+func GetBool(k string) bool {
+	v := GetString(k)
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
@@ -0,0 +1,48 @@
+package apikey_errors
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidExpiry  = errors.New("expiresAt must be in the future")
+)
+
+func CreateAPIKeyValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+
+			switch {
+			case field == "Name":
+				switch tag {
+				case "required":
+					errors[field] = "name is required"
+				case "min":
+					errors[field] = "name must have at least 3 characters"
+				case "max":
+					errors[field] = "name must have at most 100 characters"
+				}
+
+			case field == "Scopes":
+				switch tag {
+				case "required", "min":
+					errors[field] = "at least one scope is required"
+				}
+
+			case strings.HasPrefix(field, "Scopes["):
+				errors["Scopes"] = "invalid scope, allowed: user:read, user:write, product:read, product:write"
+			}
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,185 @@
+package apikey_repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	apikey_errors "github.com/celio001/prodify/internal/apikey/errors"
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	createAPIKeyQuery = `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, public_id, created_at`
+
+	listAPIKeysQuery = `SELECT id, public_id, name, prefix, scopes, expires_at, last_used_at, created_at
+	FROM api_keys
+	WHERE user_id = $1
+	AND revoked_at IS NULL
+	ORDER BY created_at DESC`
+
+	getAPIKeyByPrefixQuery = `SELECT k.id, k.public_id, k.user_id, u.public_id, k.name, k.prefix, k.key_hash, k.scopes,
+	k.expires_at, k.last_used_at, k.revoked_at, k.created_at
+	FROM api_keys k
+	JOIN users u ON u.id = k.user_id
+	WHERE k.prefix = $1
+	AND u.deleted_at IS NULL
+	AND u.is_active`
+
+	revokeAPIKeyQuery = `UPDATE api_keys
+	SET revoked_at = now()
+	WHERE public_id = $1
+	AND user_id = $2
+	AND revoked_at IS NULL`
+
+	touchAPIKeyQuery = `UPDATE api_keys
+	SET last_used_at = $2
+	WHERE id = $1`
+)
+
+type apiKeyRepository struct {
+	Db *sql.DB
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(key apikey_types.APIKey) (*apikey_types.APIKey, error)
+	ListAPIKeys(user_id int64) ([]apikey_types.APIKey, error)
+	GetAPIKeyByPrefix(prefix string) (*apikey_types.APIKey, error)
+	RevokeAPIKey(user_id int64, keyPublicID uuid.UUID) error
+	TouchAPIKey(id int64, usedAt time.Time) error
+}
+
+func NewAPIKeyRepository(Db *sql.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		Db: Db,
+	}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(key apikey_types.APIKey) (*apikey_types.APIKey, error) {
+	ctx := context.Background()
+
+	row := r.Db.QueryRowContext(ctx, createAPIKeyQuery,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt)
+
+	if err := row.Scan(&key.ID, &key.PublicID, &key.CreatedAt); err != nil {
+		logger.Log.Error("error creating api key", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListAPIKeys(user_id int64) ([]apikey_types.APIKey, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, listAPIKeysQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error listing api keys", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []apikey_types.APIKey{}
+	for rows.Next() {
+		var key apikey_types.APIKey
+		var expiresAt, lastUsedAt sql.NullTime
+
+		err := rows.Scan(
+			&key.ID,
+			&key.PublicID,
+			&key.Name,
+			&key.Prefix,
+			pq.Array(&key.Scopes),
+			&expiresAt,
+			&lastUsedAt,
+			&key.CreatedAt)
+		if err != nil {
+			logger.Log.Error("error scanning api key", zap.String("error", err.Error()))
+			return nil, err
+		}
+
+		key.UserID = user_id
+		key.ExpiresAt = nullTime(expiresAt)
+		key.LastUsedAt = nullTime(lastUsedAt)
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) GetAPIKeyByPrefix(prefix string) (*apikey_types.APIKey, error) {
+	ctx := context.Background()
+
+	row := r.Db.QueryRowContext(ctx, getAPIKeyByPrefixQuery, prefix)
+
+	var key apikey_types.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID,
+		&key.PublicID,
+		&key.UserID,
+		&key.UserPublicID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		pq.Array(&key.Scopes),
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&key.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apikey_errors.ErrInvalidAPIKey
+		}
+		logger.Log.Error("error getting api key", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	key.ExpiresAt = nullTime(expiresAt)
+	key.LastUsedAt = nullTime(lastUsedAt)
+	key.RevokedAt = nullTime(revokedAt)
+	return &key, nil
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(user_id int64, keyPublicID uuid.UUID) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, revokeAPIKeyQuery, keyPublicID, user_id)
+	if err != nil {
+		logger.Log.Error("error revoking api key", zap.String("error", err.Error()))
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apikey_errors.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchAPIKey(id int64, usedAt time.Time) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, touchAPIKeyQuery, id, usedAt)
+	if err != nil {
+		logger.Log.Error("error updating api key last use", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
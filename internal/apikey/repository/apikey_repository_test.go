@@ -0,0 +1,217 @@
+package apikey_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	apikey_errors "github.com/celio001/prodify/internal/apikey/errors"
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+	publicID := uuid.New().String()
+
+	tests := []struct {
+		name        string
+		mockError   error
+		expectError bool
+	}{
+		{name: "success"},
+		{name: "database error", mockError: fmt.Errorf("db error"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewAPIKeyRepository(db)
+
+			key := apikey_types.APIKey{
+				UserID:  1,
+				Name:    "ci",
+				Prefix:  "pk_abc",
+				KeyHash: "hash",
+				Scopes:  []string{apikey_types.ScopeUserRead},
+			}
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(createAPIKeyQuery)).
+				WithArgs(int64(1), "ci", "pk_abc", "hash", pq.Array(key.Scopes), nil)
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "created_at"}).AddRow(10, publicID, now))
+			}
+
+			created, err := repo.CreateAPIKey(key)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, created)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(10), created.ID)
+				assert.Equal(t, publicID, created.PublicID)
+				assert.Equal(t, "pk_abc", created.Prefix)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(listAPIKeysQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}).
+			AddRow(1, "a", "ci", "pk_1", "{user:read,user:write}", now, nil, now).
+			AddRow(2, "b", "deploy", "pk_2", "{user:read}", nil, now, now))
+
+	keys, err := repo.ListAPIKeys(1)
+
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, []string{"user:read", "user:write"}, keys[0].Scopes)
+	assert.NotNil(t, keys[0].ExpiresAt)
+	assert.Nil(t, keys[0].LastUsedAt)
+	assert.Nil(t, keys[1].ExpiresAt)
+	assert.NotNil(t, keys[1].LastUsedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectError error
+	}{
+		{
+			name: "success",
+			mockRows: sqlmock.NewRows([]string{"id", "public_id", "user_id", "user_public_id", "name", "prefix", "key_hash", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}).
+				AddRow(1, "a", 2, "u", "ci", "pk_1", "hash", "{user:read}", nil, nil, nil, now),
+		},
+		{
+			name:        "unknown prefix",
+			mockError:   sql.ErrNoRows,
+			expectError: apikey_errors.ErrInvalidAPIKey,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewAPIKeyRepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getAPIKeyByPrefixQuery)).WithArgs("pk_1")
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			key, err := repo.GetAPIKeyByPrefix("pk_1")
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "u", key.UserPublicID)
+				assert.Equal(t, "hash", key.KeyHash)
+				assert.Equal(t, []string{"user:read"}, key.Scopes)
+				assert.Nil(t, key.RevokedAt)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	logger.Init("dev")
+
+	keyID := uuid.New()
+
+	tests := []struct {
+		name        string
+		affected    int64
+		expectError error
+	}{
+		{name: "success", affected: 1},
+		{name: "not found or not owned", affected: 0, expectError: apikey_errors.ErrAPIKeyNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewAPIKeyRepository(db)
+
+			mock.ExpectExec(regexp.QuoteMeta(revokeAPIKeyQuery)).
+				WithArgs(keyID, int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			err = repo.RevokeAPIKey(1, keyID)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepository(db)
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(touchAPIKeyQuery)).
+		WithArgs(int64(1), now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.TouchAPIKey(1, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,47 @@
+package apikey_repository_mock
+
+import (
+	"time"
+
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(key apikey_types.APIKey) (*apikey_types.APIKey, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*apikey_types.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListAPIKeys(userID int64) ([]apikey_types.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]apikey_types.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByPrefix(prefix string) (*apikey_types.APIKey, error) {
+	args := m.Called(prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*apikey_types.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(userID int64, keyPublicID uuid.UUID) error {
+	args := m.Called(userID, keyPublicID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(id int64, usedAt time.Time) error {
+	args := m.Called(id, usedAt)
+	return args.Error(0)
+}
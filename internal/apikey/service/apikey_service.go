@@ -0,0 +1,144 @@
+package apikey_service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	apikey_errors "github.com/celio001/prodify/internal/apikey/errors"
+	apikey_repository "github.com/celio001/prodify/internal/apikey/repository"
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	user_repository "github.com/celio001/prodify/internal/user/repository"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// keys look like pk_<12 hex chars>_<secret>, the part before the second
+	// underscore is the visible prefix used to find the key. The secret is
+	// base64url and may contain underscores itself.
+	keyPrefix       = "pk_"
+	keyPrefixLength = len(keyPrefix) + 12
+
+	// last_used_at is only written when older than this, so a busy key
+	// doesn't cost a write on every request
+	lastUsedResolution = time.Minute
+)
+
+type apiKeyService struct {
+	apiKeyRepo apikey_repository.APIKeyRepository
+	userRepo   user_repository.UserRepository
+}
+
+type APIKeyService interface {
+	CreateAPIKey(userPublicID uuid.UUID, createRequest apikey_types.CreateAPIKeyRequest) (*apikey_types.CreateAPIKeyResponse, error)
+	ListAPIKeys(userPublicID uuid.UUID) ([]apikey_types.APIKey, error)
+	RevokeAPIKey(userPublicID uuid.UUID, keyPublicID uuid.UUID) error
+	AuthenticateAPIKey(rawKey string) (string, []string, error)
+}
+
+func NewAPIKeyService(apiKeyRepo apikey_repository.APIKeyRepository, userRepo user_repository.UserRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(userPublicID uuid.UUID, createRequest apikey_types.CreateAPIKeyRequest) (*apikey_types.CreateAPIKeyResponse, error) {
+	if createRequest.ExpiresAt != nil && !createRequest.ExpiresAt.After(time.Now()) {
+		return nil, apikey_errors.ErrInvalidExpiry
+	}
+
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := newPrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, _, err := pkg_token.Generate()
+	if err != nil {
+		return nil, err
+	}
+	rawKey := prefix + "_" + secret
+
+	key, err := s.apiKeyRepo.CreateAPIKey(apikey_types.APIKey{
+		UserID:    user.ID,
+		Name:      createRequest.Name,
+		Prefix:    prefix,
+		KeyHash:   pkg_token.Hash(rawKey),
+		Scopes:    createRequest.Scopes,
+		ExpiresAt: createRequest.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &apikey_types.CreateAPIKeyResponse{
+		APIKey: *key,
+		Key:    rawKey,
+	}, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(userPublicID uuid.UUID) ([]apikey_types.APIKey, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apiKeyRepo.ListAPIKeys(user.ID)
+}
+
+func (s *apiKeyService) RevokeAPIKey(userPublicID uuid.UUID, keyPublicID uuid.UUID) error {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return err
+	}
+
+	return s.apiKeyRepo.RevokeAPIKey(user.ID, keyPublicID)
+}
+
+// AuthenticateAPIKey returns the owner's public id and the key scopes.
+// Every rejection is reported as ErrInvalidAPIKey.
+func (s *apiKeyService) AuthenticateAPIKey(rawKey string) (string, []string, error) {
+	if !strings.HasPrefix(rawKey, keyPrefix) || len(rawKey) <= keyPrefixLength+1 || rawKey[keyPrefixLength] != '_' {
+		return "", nil, apikey_errors.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetAPIKeyByPrefix(rawKey[:keyPrefixLength])
+	if err != nil {
+		return "", nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(pkg_token.Hash(rawKey))) != 1 {
+		return "", nil, apikey_errors.ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return "", nil, apikey_errors.ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.apiKeyRepo.TouchAPIKey(key.ID, now); err != nil {
+			logger.Log.Error("failed to update api key last use", zap.String("error", err.Error()))
+		}
+	}
+
+	return key.UserPublicID, key.Scopes, nil
+}
+
+func newPrefix() (string, error) {
+	b := make([]byte, (keyPrefixLength-len(keyPrefix))/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(b), nil
+}
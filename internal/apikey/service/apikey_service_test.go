@@ -0,0 +1,237 @@
+package apikey_service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apikey_errors "github.com/celio001/prodify/internal/apikey/errors"
+	apikey_repository_mock "github.com/celio001/prodify/internal/apikey/repository/mock"
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		request     apikey_types.CreateAPIKeyRequest
+		userError   error
+		expectError error
+	}{
+		{
+			name:    "success",
+			request: apikey_types.CreateAPIKeyRequest{Name: "ci", Scopes: []string{apikey_types.ScopeUserRead}},
+		},
+		{
+			name:    "success with expiry",
+			request: apikey_types.CreateAPIKeyRequest{Name: "ci", Scopes: []string{apikey_types.ScopeUserRead}, ExpiresAt: &future},
+		},
+		{
+			name:        "expiry in the past",
+			request:     apikey_types.CreateAPIKeyRequest{Name: "ci", Scopes: []string{apikey_types.ScopeUserRead}, ExpiresAt: &past},
+			expectError: apikey_errors.ErrInvalidExpiry,
+		},
+		{
+			name:        "user not found",
+			request:     apikey_types.CreateAPIKeyRequest{Name: "ci", Scopes: []string{apikey_types.ScopeUserRead}},
+			userError:   user_errors.ErrUserNotFound,
+			expectError: user_errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(user_mock.MockUserRepository)
+			if tt.userError != nil {
+				mockUserRepo.On("GetUserByPublicID", userPublicID).Return(nil, tt.userError)
+			} else {
+				mockUserRepo.On("GetUserByPublicID", userPublicID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+			}
+
+			mockRepo := new(apikey_repository_mock.MockAPIKeyRepository)
+			mockRepo.On("CreateAPIKey", mock.Anything).Return(&apikey_types.APIKey{PublicID: uuid.NewString()}, nil)
+
+			service := NewAPIKeyService(mockRepo, mockUserRepo)
+
+			created, err := service.CreateAPIKey(userPublicID, tt.request)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				mockRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Regexp(t, `^pk_[0-9a-f]{12}_[A-Za-z0-9_-]{43}$`, created.Key)
+
+			stored := mockRepo.Calls[0].Arguments.Get(0).(apikey_types.APIKey)
+			assert.True(t, strings.HasPrefix(created.Key, stored.Prefix+"_"))
+			assert.Equal(t, int64(1), stored.UserID)
+			assert.Equal(t, pkg_token.Hash(created.Key), stored.KeyHash)
+			assert.NotContains(t, stored.KeyHash, created.Key)
+			assert.Equal(t, tt.request.ExpiresAt, stored.ExpiresAt)
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	logger.Init("dev")
+
+	rawKey := "pk_0123456789ab_secret_with-under_scores"
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	recent := now.Add(-10 * time.Second)
+
+	validKey := func() *apikey_types.APIKey {
+		return &apikey_types.APIKey{
+			ID:           1,
+			UserPublicID: "user-1",
+			Prefix:       "pk_0123456789ab",
+			KeyHash:      pkg_token.Hash(rawKey),
+			Scopes:       []string{apikey_types.ScopeUserRead},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		rawKey      string
+		key         func() *apikey_types.APIKey
+		lookupError error
+		expectTouch bool
+		expectError error
+	}{
+		{
+			name:        "valid key",
+			rawKey:      rawKey,
+			key:         validKey,
+			expectTouch: true,
+		},
+		{
+			name:   "recently used key is not touched again",
+			rawKey: rawKey,
+			key: func() *apikey_types.APIKey {
+				k := validKey()
+				k.LastUsedAt = &recent
+				return k
+			},
+		},
+		{
+			name:        "wrong secret",
+			rawKey:      "pk_0123456789ab_other-secret",
+			key:         validKey,
+			expectError: apikey_errors.ErrInvalidAPIKey,
+		},
+		{
+			name:   "revoked key",
+			rawKey: rawKey,
+			key: func() *apikey_types.APIKey {
+				k := validKey()
+				k.RevokedAt = &past
+				return k
+			},
+			expectError: apikey_errors.ErrInvalidAPIKey,
+		},
+		{
+			name:   "expired key",
+			rawKey: rawKey,
+			key: func() *apikey_types.APIKey {
+				k := validKey()
+				k.ExpiresAt = &past
+				return k
+			},
+			expectError: apikey_errors.ErrInvalidAPIKey,
+		},
+		{
+			name:        "unknown prefix",
+			rawKey:      rawKey,
+			lookupError: apikey_errors.ErrInvalidAPIKey,
+			expectError: apikey_errors.ErrInvalidAPIKey,
+		},
+		{
+			name:        "malformed key",
+			rawKey:      "not-a-key",
+			expectError: apikey_errors.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(apikey_repository_mock.MockAPIKeyRepository)
+			if tt.key != nil {
+				mockRepo.On("GetAPIKeyByPrefix", "pk_0123456789ab").Return(tt.key(), nil)
+			} else if tt.lookupError != nil {
+				mockRepo.On("GetAPIKeyByPrefix", "pk_0123456789ab").Return(nil, tt.lookupError)
+			}
+			mockRepo.On("TouchAPIKey", int64(1), mock.Anything).Return(nil)
+
+			service := NewAPIKeyService(mockRepo, new(user_mock.MockUserRepository))
+
+			userID, scopes, err := service.AuthenticateAPIKey(tt.rawKey)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				assert.Empty(t, userID)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-1", userID)
+				assert.Equal(t, []string{apikey_types.ScopeUserRead}, scopes)
+			}
+
+			if tt.expectTouch {
+				mockRepo.AssertCalled(t, "TouchAPIKey", int64(1), mock.Anything)
+			} else {
+				mockRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+	keyID := uuid.New()
+
+	mockUserRepo := new(user_mock.MockUserRepository)
+	mockUserRepo.On("GetUserByPublicID", userPublicID).Return(&user_types.GetUserResponse{ID: 3}, nil)
+
+	mockRepo := new(apikey_repository_mock.MockAPIKeyRepository)
+	mockRepo.On("RevokeAPIKey", int64(3), keyID).Return(apikey_errors.ErrAPIKeyNotFound)
+
+	service := NewAPIKeyService(mockRepo, mockUserRepo)
+
+	assert.ErrorIs(t, service.RevokeAPIKey(userPublicID, keyID), apikey_errors.ErrAPIKeyNotFound)
+}
+
+func TestListAPIKeys(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+
+	mockUserRepo := new(user_mock.MockUserRepository)
+	mockUserRepo.On("GetUserByPublicID", userPublicID).Return(&user_types.GetUserResponse{ID: 3}, nil)
+
+	mockRepo := new(apikey_repository_mock.MockAPIKeyRepository)
+	mockRepo.On("ListAPIKeys", int64(3)).Return([]apikey_types.APIKey{{Name: "ci"}}, nil)
+
+	service := NewAPIKeyService(mockRepo, mockUserRepo)
+
+	keys, err := service.ListAPIKeys(userPublicID)
+
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
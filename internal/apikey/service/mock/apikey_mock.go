@@ -0,0 +1,40 @@
+package apikey_service_mock
+
+import (
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(userPublicID uuid.UUID, createRequest apikey_types.CreateAPIKeyRequest) (*apikey_types.CreateAPIKeyResponse, error) {
+	args := m.Called(userPublicID, createRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*apikey_types.CreateAPIKeyResponse), args.Error(1)
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(userPublicID uuid.UUID) ([]apikey_types.APIKey, error) {
+	args := m.Called(userPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]apikey_types.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(userPublicID uuid.UUID, keyPublicID uuid.UUID) error {
+	args := m.Called(userPublicID, keyPublicID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) AuthenticateAPIKey(rawKey string) (string, []string, error) {
+	args := m.Called(rawKey)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).([]string), args.Error(2)
+}
@@ -0,0 +1,39 @@
+package apikey_types
+
+import "time"
+
+const (
+	ScopeUserRead     = "user:read"
+	ScopeUserWrite    = "user:write"
+	ScopeProductRead  = "product:read"
+	ScopeProductWrite = "product:write"
+)
+
+type APIKey struct {
+	ID         int64      `json:"-"`
+	PublicID   string     `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+
+	// UserPublicID is only filled when the key is looked up for authentication
+	UserPublicID string `json:"-"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,min=3,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=user:read user:write product:read product:write"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey
+	// Key is the full secret, it is only returned once at creation
+	Key string `json:"key"`
+}
@@ -0,0 +1,59 @@
+package audit_errors
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrInvalidDateRange = errors.New("from must be before to")
+	ErrExportTooLarge   = errors.New("too many events to export, narrow the filters")
+
+	ErrEventNotFound      = errors.New("audit event not found")
+	ErrCheckpointNotFound = errors.New("audit checkpoint not found")
+	ErrNoSigningKey       = errors.New("set AUDIT_SIGNING_KEY to sign audit checkpoints")
+	ErrInvalidSigningKey  = errors.New("AUDIT_SIGNING_KEY must be a base64 ed25519 seed of 32 bytes")
+	ErrInvalidVerifyKey   = errors.New("AUDIT_VERIFY_KEY must be a base64 ed25519 public key of 32 bytes")
+	ErrNoVerifyKey        = errors.New("set AUDIT_VERIFY_KEY or AUDIT_SIGNING_KEY to check the checkpoint signatures")
+)
+
+func ListEventsValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+
+			field := fieldErr.Field()
+
+			switch field {
+
+			case "Action", "RequestID":
+				errors[field] = "must have at most 64 characters"
+
+			case "ActorID":
+				errors[field] = "actor_id must be a UUID"
+
+			case "TargetType":
+				errors[field] = "target_type must have at most 32 characters"
+
+			case "TargetID":
+				errors[field] = "target_id must have at most 64 characters"
+
+			case "IP":
+				errors[field] = "ip must be an IP address"
+
+			case "From", "To":
+				errors[field] = "must be an RFC 3339 date, e.g. 2026-01-31T00:00:00Z"
+
+			case "Page":
+				errors[field] = "page must be at least 1"
+
+			case "PageSize":
+				errors[field] = "page_size must be between 1 and 100"
+			}
+		}
+	}
+
+	return errors
+}
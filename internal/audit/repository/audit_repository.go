@@ -0,0 +1,399 @@
+package audit_repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// one writer at a time extends the chain, whichever process it runs in
+	lockAuditChainQuery = `SELECT pg_advisory_xact_lock(hashtext('audit_events'))`
+
+	lastAuditHashQuery = `SELECT COALESCE(hash, '')
+	FROM audit_events
+	ORDER BY id DESC
+	LIMIT 1`
+
+	insertAuditEventQuery = `INSERT INTO audit_events
+	(public_id, action, actor_id, impersonator_id, target_type, target_id, ip, user_agent, request_id, changes, metadata, created_at, prev_hash, hash)
+	VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+	listAuditEventsQuery = `SELECT id, public_id, action, COALESCE(actor_id::text, ''), COALESCE(impersonator_id::text, ''),
+	target_type, target_id, ip, user_agent, request_id, changes, metadata, created_at,
+	COALESCE(prev_hash, ''), COALESCE(hash, '')
+	FROM audit_events`
+
+	countAuditEventsQuery = `SELECT COUNT(*)
+	FROM audit_events`
+
+	chainAuditEventsQuery = listAuditEventsQuery + `
+	WHERE id > $1
+	ORDER BY id
+	LIMIT $2`
+
+	lastAuditEventQuery = listAuditEventsQuery + `
+	ORDER BY id DESC
+	LIMIT 1`
+
+	insertAuditCheckpointQuery = `INSERT INTO audit_checkpoints (event_id, hash, signature, created_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`
+
+	listAuditCheckpointsQuery = `SELECT id, event_id, hash, signature, created_at
+	FROM audit_checkpoints
+	ORDER BY id`
+
+	lastAuditCheckpointQuery = `SELECT id, event_id, hash, signature, created_at
+	FROM audit_checkpoints
+	ORDER BY id DESC
+	LIMIT 1`
+)
+
+type auditRepository struct {
+	Db *sql.DB
+}
+
+// AuditRepository stores the audit log and its signed checkpoints. Both are only ever
+// inserted, the tables refuse updates and deletes.
+type AuditRepository interface {
+	InsertEvents(events []audit_types.Event) error
+	ListEvents(filter audit_types.EventFilter) ([]audit_types.Event, error)
+	CountEvents(filter audit_types.EventFilter) (int64, error)
+	ListChain(afterID int64, limit int) ([]audit_types.Event, error)
+	GetLastEvent() (*audit_types.Event, error)
+	InsertCheckpoint(checkpoint *audit_types.Checkpoint) error
+	ListCheckpoints() ([]audit_types.Checkpoint, error)
+	GetLastCheckpoint() (*audit_types.Checkpoint, error)
+}
+
+func NewAuditRepository(Db *sql.DB) AuditRepository {
+	return &auditRepository{
+		Db: Db,
+	}
+}
+
+// InsertEvents writes a batch of events in one transaction, each one chained to the
+// event before it.
+func (r *auditRepository) InsertEvents(events []audit_types.Event) error {
+	ctx := context.Background()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("error starting audit transaction", zap.String("error", err.Error()))
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockAuditChainQuery); err != nil {
+		logger.Log.Error("error locking audit chain", zap.String("error", err.Error()))
+		return err
+	}
+
+	prevHash := audit_types.GenesisHash
+	err = tx.QueryRowContext(ctx, lastAuditHashQuery).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log.Error("error reading audit chain head", zap.String("error", err.Error()))
+		return err
+	}
+	// the first event after the ones written before the chain existed
+	if prevHash == "" {
+		prevHash = audit_types.GenesisHash
+	}
+
+	for _, event := range events {
+		if err := normalizeEvent(&event); err != nil {
+			return err
+		}
+
+		hash, err := audit_types.ChainHash(prevHash, event)
+		if err != nil {
+			return err
+		}
+
+		changes, err := jsonColumn(event.Changes, len(event.Changes))
+		if err != nil {
+			return err
+		}
+		metadata, err := jsonColumn(event.Metadata, len(event.Metadata))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, insertAuditEventQuery,
+			event.PublicID,
+			event.Action,
+			event.ActorID,
+			event.ImpersonatorID,
+			event.TargetType,
+			event.TargetID,
+			event.IP,
+			event.UserAgent,
+			event.RequestID,
+			changes,
+			metadata,
+			event.CreatedAt,
+			prevHash,
+			hash)
+		if err != nil {
+			logger.Log.Error("error inserting audit event", zap.String("error", err.Error()))
+			return err
+		}
+
+		prevHash = hash
+	}
+
+	return tx.Commit()
+}
+
+// ListEvents returns the events matching filter, newest first. A zero Limit returns
+// all of them.
+func (r *auditRepository) ListEvents(filter audit_types.EventFilter) ([]audit_types.Event, error) {
+	ctx := context.Background()
+
+	where, args := listEventsWhere(filter)
+
+	query := listAuditEventsQuery + where + "\n\tORDER BY created_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf("\n\tLIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := r.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("error listing audit events", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// ListChain returns up to limit events after afterID, in chain order.
+func (r *auditRepository) ListChain(afterID int64, limit int) ([]audit_types.Event, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, chainAuditEventsQuery, afterID, limit)
+	if err != nil {
+		logger.Log.Error("error listing audit chain", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+func (r *auditRepository) GetLastEvent() (*audit_types.Event, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, lastAuditEventQuery)
+	if err != nil {
+		logger.Log.Error("error getting last audit event", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, audit_errors.ErrEventNotFound
+	}
+	return &events[0], nil
+}
+
+func (r *auditRepository) InsertCheckpoint(checkpoint *audit_types.Checkpoint) error {
+	ctx := context.Background()
+
+	err := r.Db.QueryRowContext(ctx, insertAuditCheckpointQuery,
+		checkpoint.EventID,
+		checkpoint.Hash,
+		checkpoint.Signature,
+		checkpoint.CreatedAt).Scan(&checkpoint.ID)
+	if err != nil {
+		logger.Log.Error("error inserting audit checkpoint", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *auditRepository) ListCheckpoints() ([]audit_types.Checkpoint, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, listAuditCheckpointsQuery)
+	if err != nil {
+		logger.Log.Error("error listing audit checkpoints", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := []audit_types.Checkpoint{}
+	for rows.Next() {
+		var checkpoint audit_types.Checkpoint
+		if err := rows.Scan(&checkpoint.ID, &checkpoint.EventID, &checkpoint.Hash, &checkpoint.Signature, &checkpoint.CreatedAt); err != nil {
+			logger.Log.Error("error scanning audit checkpoint", zap.String("error", err.Error()))
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+func (r *auditRepository) GetLastCheckpoint() (*audit_types.Checkpoint, error) {
+	ctx := context.Background()
+
+	var checkpoint audit_types.Checkpoint
+	err := r.Db.QueryRowContext(ctx, lastAuditCheckpointQuery).
+		Scan(&checkpoint.ID, &checkpoint.EventID, &checkpoint.Hash, &checkpoint.Signature, &checkpoint.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, audit_errors.ErrCheckpointNotFound
+		}
+		logger.Log.Error("error getting last audit checkpoint", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func scanEvents(rows *sql.Rows) ([]audit_types.Event, error) {
+	events := []audit_types.Event{}
+	for rows.Next() {
+		var event audit_types.Event
+		var changes, metadata []byte
+		err := rows.Scan(
+			&event.ID,
+			&event.PublicID,
+			&event.Action,
+			&event.ActorID,
+			&event.ImpersonatorID,
+			&event.TargetType,
+			&event.TargetID,
+			&event.IP,
+			&event.UserAgent,
+			&event.RequestID,
+			&changes,
+			&metadata,
+			&event.CreatedAt,
+			&event.PrevHash,
+			&event.Hash)
+		if err != nil {
+			logger.Log.Error("error scanning audit event", zap.String("error", err.Error()))
+			return nil, err
+		}
+
+		if changes != nil {
+			if err := json.Unmarshal(changes, &event.Changes); err != nil {
+				return nil, err
+			}
+		}
+		if metadata != nil {
+			if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *auditRepository) CountEvents(filter audit_types.EventFilter) (int64, error) {
+	ctx := context.Background()
+
+	where, args := listEventsWhere(filter)
+
+	var total int64
+	if err := r.Db.QueryRowContext(ctx, countAuditEventsQuery+where, args...).Scan(&total); err != nil {
+		logger.Log.Error("error counting audit events", zap.String("error", err.Error()))
+		return 0, err
+	}
+	return total, nil
+}
+
+// listEventsWhere turns filter into a WHERE clause, every value is a placeholder.
+func listEventsWhere(filter audit_types.EventFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Action != "" {
+		add("action = $?", filter.Action)
+	}
+	if filter.ActorID != "" {
+		add("(actor_id = $?::uuid OR impersonator_id = $?::uuid)", filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = $?", filter.TargetID)
+	}
+	if filter.IP != "" {
+		add("ip = $?", filter.IP)
+	}
+	if filter.RequestID != "" {
+		add("request_id = $?", filter.RequestID)
+	}
+	if filter.From != nil {
+		add("created_at >= $?", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $?", *filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "\n\tWHERE " + strings.Join(conditions, "\n\tAND "), args
+}
+
+// normalizeEvent puts event in the form it will be read back in, which is what its hash
+// covers: a public id, canonical UUIDs and a UTC time at the database's microseconds.
+func normalizeEvent(event *audit_types.Event) error {
+	if event.PublicID == "" {
+		event.PublicID = uuid.NewString()
+	}
+
+	for _, id := range []*string{&event.PublicID, &event.ActorID, &event.ImpersonatorID} {
+		if *id == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(*id)
+		if err != nil {
+			return err
+		}
+		*id = parsed.String()
+	}
+
+	event.CreatedAt = event.CreatedAt.UTC().Truncate(time.Microsecond)
+	return nil
+}
+
+// jsonColumn encodes value for a JSONB column, empty values are stored as NULL. It is
+// passed as a string, lib/pq would send []byte as bytea.
+func jsonColumn(value any, size int) (sql.NullString, error) {
+	if size == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
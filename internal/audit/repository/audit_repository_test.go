@@ -0,0 +1,298 @@
+package audit_repository
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var eventColumns = []string{"id", "public_id", "action", "actor_id", "impersonator_id", "target_type", "target_id", "ip", "user_agent", "request_id", "changes", "metadata", "created_at", "prev_hash", "hash"}
+
+func TestInsertAuditEvents(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Date(2026, 10, 20, 13, 0, 0, 123456789, time.UTC)
+	stored := now.Truncate(time.Microsecond)
+	actorID := uuid.NewString()
+	firstID, secondID := uuid.NewString(), uuid.NewString()
+
+	events := []audit_types.Event{
+		{PublicID: firstID, Action: audit_types.ActionLoginFailed, IP: "10.0.0.1", Metadata: map[string]string{"email": "bob@example.com"}, CreatedAt: now},
+		{PublicID: secondID, Action: audit_types.ActionProductUpdated, ActorID: strings.ToUpper(actorID), TargetType: audit_types.TargetProduct, TargetID: "p1",
+			Changes: map[string]audit_types.Change{"Price": {Before: 10.0, After: 12.5}}, CreatedAt: now},
+	}
+
+	// the hashes expected for events, chained after head
+	chain := func(head string) []string {
+		first := events[0]
+		first.CreatedAt = stored
+		firstHash, err := audit_types.ChainHash(head, first)
+		assert.NoError(t, err)
+
+		second := events[1]
+		second.ActorID = actorID
+		second.CreatedAt = stored
+		secondHash, err := audit_types.ChainHash(firstHash, second)
+		assert.NoError(t, err)
+
+		return []string{head, firstHash, secondHash}
+	}
+
+	tests := []struct {
+		name     string
+		lastHash []string
+		head     string
+	}{
+		{name: "first event", head: audit_types.GenesisHash},
+		{name: "after events written before the chain", lastHash: []string{""}, head: audit_types.GenesisHash},
+		{name: "continues the chain", lastHash: []string{strings.Repeat("a", 64)}, head: strings.Repeat("a", 64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewAuditRepository(db)
+			hashes := chain(tt.head)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(lockAuditChainQuery)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			rows := sqlmock.NewRows([]string{"hash"})
+			for _, hash := range tt.lastHash {
+				rows.AddRow(hash)
+			}
+			mock.ExpectQuery(regexp.QuoteMeta(lastAuditHashQuery)).WillReturnRows(rows)
+			mock.ExpectExec(regexp.QuoteMeta(insertAuditEventQuery)).
+				WithArgs(firstID, audit_types.ActionLoginFailed, "", "", "", "", "10.0.0.1", "", "", nil, `{"email":"bob@example.com"}`, stored, hashes[0], hashes[1]).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(regexp.QuoteMeta(insertAuditEventQuery)).
+				WithArgs(secondID, audit_types.ActionProductUpdated, actorID, "", audit_types.TargetProduct, "p1", "", "", "", `{"Price":{"before":10,"after":12.5}}`, nil, stored, hashes[1], hashes[2]).
+				WillReturnResult(sqlmock.NewResult(2, 1))
+			mock.ExpectCommit()
+
+			err = repo.InsertEvents(events)
+
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("nothing is written when one insert fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewAuditRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(lockAuditChainQuery)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(lastAuditHashQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+		mock.ExpectExec(regexp.QuoteMeta(insertAuditEventQuery)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertAuditEventQuery)).
+			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		err = repo.InsertEvents(events)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid actor id", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewAuditRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(lockAuditChainQuery)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(lastAuditHashQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+		mock.ExpectRollback()
+
+		err = repo.InsertEvents([]audit_types.Event{{Action: audit_types.ActionUserUpdated, ActorID: "someone", CreatedAt: now}})
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestListAuditEvents(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditRepository(db)
+
+	now := time.Now()
+	from := now.Add(-time.Hour)
+	actorID := uuid.NewString()
+
+	query := listAuditEventsQuery + `
+	WHERE action = $1
+	AND (actor_id = $2::uuid OR impersonator_id = $2::uuid)
+	AND created_at >= $3
+	ORDER BY created_at DESC, id DESC
+	LIMIT $4 OFFSET $5`
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(audit_types.ActionUserUpdated, actorID, from, 20, 40).
+		WillReturnRows(sqlmock.NewRows(eventColumns).
+			AddRow(7, uuid.NewString(), audit_types.ActionUserUpdated, actorID, "", "user", "u1", "10.0.0.1", "curl", "req-1", []byte(`{"name":{"before":"Bob","after":"Robert"}}`), nil, now, "", ""))
+
+	events, err := repo.ListEvents(audit_types.EventFilter{Action: audit_types.ActionUserUpdated, ActorID: actorID, From: &from, Limit: 20, Offset: 40})
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, audit_types.Change{Before: "Bob", After: "Robert"}, events[0].Changes["name"])
+	assert.Nil(t, events[0].Metadata)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountAuditEvents(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(countAuditEventsQuery+"\n\tWHERE target_type = $1\n\tAND target_id = $2")).
+		WithArgs(audit_types.TargetProduct, "p1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	total, err := repo.CountEvents(audit_types.EventFilter{TargetType: audit_types.TargetProduct, TargetID: "p1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAuditChain(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditRepository(db)
+
+	prevHash, hash := strings.Repeat("0", 64), strings.Repeat("b", 64)
+	mock.ExpectQuery(regexp.QuoteMeta(chainAuditEventsQuery)).
+		WithArgs(int64(41), 500).
+		WillReturnRows(sqlmock.NewRows(eventColumns).
+			AddRow(42, uuid.NewString(), audit_types.ActionLoginSucceeded, "", "", "", "", "10.0.0.1", "", "", nil, nil, time.Now(), prevHash, hash))
+
+	events, err := repo.ListChain(41, 500)
+
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, int64(42), events[0].ID)
+		assert.Equal(t, prevHash, events[0].PrevHash)
+		assert.Equal(t, hash, events[0].Hash)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLastAuditEvent_Empty(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(lastAuditEventQuery)).
+		WillReturnRows(sqlmock.NewRows(eventColumns))
+
+	event, err := repo.GetLastEvent()
+
+	assert.Nil(t, event)
+	assert.Equal(t, audit_errors.ErrEventNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditCheckpoints(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+	hash := strings.Repeat("c", 64)
+	columns := []string{"id", "event_id", "hash", "signature", "created_at"}
+
+	t.Run("insert", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewAuditRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(insertAuditCheckpointQuery)).
+			WithArgs(int64(42), hash, "sig", now).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		checkpoint := &audit_types.Checkpoint{EventID: 42, Hash: hash, Signature: "sig", CreatedAt: now}
+		err = repo.InsertCheckpoint(checkpoint)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), checkpoint.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewAuditRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(listAuditCheckpointsQuery)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, 10, hash, "sig-1", now).
+				AddRow(2, 42, hash, "sig-2", now))
+
+		checkpoints, err := repo.ListCheckpoints()
+
+		assert.NoError(t, err)
+		assert.Len(t, checkpoints, 2)
+		assert.Equal(t, int64(42), checkpoints[1].EventID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("none yet", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewAuditRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(lastAuditCheckpointQuery)).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		checkpoint, err := repo.GetLastCheckpoint()
+
+		assert.Nil(t, checkpoint)
+		assert.Equal(t, audit_errors.ErrCheckpointNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,65 @@
+package audit_repository_mock
+
+import (
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) InsertEvents(events []audit_types.Event) error {
+	args := m.Called(events)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) ListEvents(filter audit_types.EventFilter) ([]audit_types.Event, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]audit_types.Event), args.Error(1)
+}
+
+func (m *MockAuditRepository) CountEvents(filter audit_types.EventFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAuditRepository) ListChain(afterID int64, limit int) ([]audit_types.Event, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]audit_types.Event), args.Error(1)
+}
+
+func (m *MockAuditRepository) GetLastEvent() (*audit_types.Event, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit_types.Event), args.Error(1)
+}
+
+func (m *MockAuditRepository) InsertCheckpoint(checkpoint *audit_types.Checkpoint) error {
+	args := m.Called(checkpoint)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) ListCheckpoints() ([]audit_types.Checkpoint, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]audit_types.Checkpoint), args.Error(1)
+}
+
+func (m *MockAuditRepository) GetLastCheckpoint() (*audit_types.Checkpoint, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit_types.Checkpoint), args.Error(1)
+}
@@ -0,0 +1,119 @@
+package audit_service
+
+import (
+	"time"
+
+	"github.com/celio001/prodify/config"
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_repository "github.com/celio001/prodify/internal/audit/repository"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+)
+
+const defaultPageSize = 20
+
+type auditService struct {
+	auditRepo     audit_repository.AuditRepository
+	exportMaxRows int
+}
+
+// AuditService reads the audit log, events are written through a Recorder.
+type AuditService interface {
+	ListEvents(listRequest audit_types.ListEventsRequest) (*audit_types.ListEventsResponse, error)
+	ExportEvents(listRequest audit_types.ListEventsRequest) ([]audit_types.Event, error)
+}
+
+func NewAuditService(auditRepo audit_repository.AuditRepository) AuditService {
+	return &auditService{
+		auditRepo:     auditRepo,
+		exportMaxRows: config.GetInt("AUDIT_EXPORT_MAX_ROWS"),
+	}
+}
+
+func (s *auditService) ListEvents(listRequest audit_types.ListEventsRequest) (*audit_types.ListEventsResponse, error) {
+	page, pageSize := listRequest.Page, listRequest.PageSize
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	filter, err := newEventFilter(listRequest)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	total, err := s.auditRepo.CountEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.auditRepo.ListEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &audit_types.ListEventsResponse{
+		Events:   events,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+// ExportEvents returns every event matching the filters, newest first. Exports larger
+// than AUDIT_EXPORT_MAX_ROWS are refused rather than cut short.
+func (s *auditService) ExportEvents(listRequest audit_types.ListEventsRequest) ([]audit_types.Event, error) {
+	filter, err := newEventFilter(listRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.auditRepo.CountEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+	if total > int64(s.exportMaxRows) {
+		return nil, audit_errors.ErrExportTooLarge
+	}
+
+	return s.auditRepo.ListEvents(filter)
+}
+
+// newEventFilter is listRequest without its paging.
+func newEventFilter(listRequest audit_types.ListEventsRequest) (audit_types.EventFilter, error) {
+	filter := audit_types.EventFilter{
+		Action:     listRequest.Action,
+		ActorID:    listRequest.ActorID,
+		TargetType: listRequest.TargetType,
+		TargetID:   listRequest.TargetID,
+		IP:         listRequest.IP,
+		RequestID:  listRequest.RequestID,
+	}
+
+	var err error
+	if filter.From, err = parseDate(listRequest.From); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseDate(listRequest.To); err != nil {
+		return filter, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, audit_errors.ErrInvalidDateRange
+	}
+	return filter, nil
+}
+
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
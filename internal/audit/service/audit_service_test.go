@@ -0,0 +1,98 @@
+package audit_service
+
+import (
+	"testing"
+	"time"
+
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_repository_mock "github.com/celio001/prodify/internal/audit/repository/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListEvents(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("pages and filters", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+		expected := audit_types.EventFilter{Action: audit_types.ActionLoginFailed, IP: "10.0.0.1", From: &from, Limit: 50, Offset: 50}
+		mockRepo.On("CountEvents", expected).Return(int64(51), nil)
+		mockRepo.On("ListEvents", expected).Return([]audit_types.Event{{Action: audit_types.ActionLoginFailed}}, nil)
+
+		service := NewAuditService(mockRepo)
+
+		response, err := service.ListEvents(audit_types.ListEventsRequest{
+			Action:   audit_types.ActionLoginFailed,
+			IP:       "10.0.0.1",
+			From:     "2026-10-01T00:00:00Z",
+			Page:     2,
+			PageSize: 50,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(51), response.Total)
+		assert.Equal(t, 2, response.Page)
+		assert.Len(t, response.Events, 1)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		mockRepo.On("CountEvents", audit_types.EventFilter{Limit: defaultPageSize}).Return(int64(0), nil)
+		mockRepo.On("ListEvents", audit_types.EventFilter{Limit: defaultPageSize}).Return([]audit_types.Event{}, nil)
+
+		service := NewAuditService(mockRepo)
+
+		response, err := service.ListEvents(audit_types.ListEventsRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, response.Page)
+		assert.Equal(t, defaultPageSize, response.PageSize)
+	})
+
+	t.Run("from after to", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+
+		service := NewAuditService(mockRepo)
+
+		_, err := service.ListEvents(audit_types.ListEventsRequest{From: "2026-10-02T00:00:00Z", To: "2026-10-01T00:00:00Z"})
+
+		assert.Equal(t, audit_errors.ErrInvalidDateRange, err)
+		mockRepo.AssertNotCalled(t, "ListEvents", mock.Anything)
+	})
+}
+
+func TestExportEvents(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("every matching event", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		filter := audit_types.EventFilter{TargetType: audit_types.TargetProduct}
+		mockRepo.On("CountEvents", filter).Return(int64(2), nil)
+		mockRepo.On("ListEvents", filter).Return([]audit_types.Event{{}, {}}, nil)
+
+		service := &auditService{auditRepo: mockRepo, exportMaxRows: 2}
+
+		events, err := service.ExportEvents(audit_types.ListEventsRequest{TargetType: audit_types.TargetProduct})
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("too many events", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		mockRepo.On("CountEvents", audit_types.EventFilter{}).Return(int64(3), nil)
+
+		service := &auditService{auditRepo: mockRepo, exportMaxRows: 2}
+
+		_, err := service.ExportEvents(audit_types.ListEventsRequest{})
+
+		assert.Equal(t, audit_errors.ErrExportTooLarge, err)
+		mockRepo.AssertNotCalled(t, "ListEvents", mock.Anything)
+	})
+}
@@ -0,0 +1,269 @@
+package audit_service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_repository "github.com/celio001/prodify/internal/audit/repository"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const verifyPageSize = 1000
+
+// Chain signs checkpoints of the audit hash chain and verifies it. Every event stores the
+// hash of the one before it, so changing, removing or reordering a stored event breaks
+// the chain from that event on. A checkpoint signs the head of the chain, so rewriting
+// the chain up to a checkpoint, or cutting its tail off, takes the signing key as well.
+type Chain struct {
+	auditRepo  audit_repository.AuditRepository
+	signingKey ed25519.PrivateKey
+	verifyKey  ed25519.PublicKey
+	interval   time.Duration
+	pageSize   int
+}
+
+// NewChain reads the keys from AUDIT_SIGNING_KEY and AUDIT_VERIFY_KEY. Either may be
+// empty: without a signing key no checkpoints are written, and the verify key defaults
+// to the public half of the signing key.
+func NewChain(auditRepo audit_repository.AuditRepository) (*Chain, error) {
+	signingKey, verifyKey, err := parseKeys(config.GetString("AUDIT_SIGNING_KEY"), config.GetString("AUDIT_VERIFY_KEY"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Chain{
+		auditRepo:  auditRepo,
+		signingKey: signingKey,
+		verifyKey:  verifyKey,
+		interval:   time.Duration(config.GetInt("AUDIT_CHECKPOINT_INTERVAL_MINUTES")) * time.Minute,
+		pageSize:   verifyPageSize,
+	}, nil
+}
+
+// GenerateKeys returns a new signing seed and its public key, both base64 encoded, in
+// the form AUDIT_SIGNING_KEY and AUDIT_VERIFY_KEY expect.
+func GenerateKeys() (string, string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(privateKey.Seed()), base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+func parseKeys(signingSeed string, verifyKey string) (ed25519.PrivateKey, ed25519.PublicKey, error) {
+	var privateKey ed25519.PrivateKey
+	var publicKey ed25519.PublicKey
+
+	if signingSeed != "" {
+		seed, err := base64.StdEncoding.DecodeString(signingSeed)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, nil, audit_errors.ErrInvalidSigningKey
+		}
+		privateKey = ed25519.NewKeyFromSeed(seed)
+		publicKey = privateKey.Public().(ed25519.PublicKey)
+	}
+
+	if verifyKey != "" {
+		key, err := base64.StdEncoding.DecodeString(verifyKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, nil, audit_errors.ErrInvalidVerifyKey
+		}
+		publicKey = key
+	}
+
+	return privateKey, publicKey, nil
+}
+
+// Checkpoint signs the current head of the chain. It returns nil when there is nothing
+// new to sign: no chained events yet, or the head is already checkpointed.
+func (c *Chain) Checkpoint() (*audit_types.Checkpoint, error) {
+	if c.signingKey == nil {
+		return nil, audit_errors.ErrNoSigningKey
+	}
+
+	last, err := c.auditRepo.GetLastEvent()
+	if errors.Is(err, audit_errors.ErrEventNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if last.Hash == "" {
+		return nil, nil
+	}
+
+	latest, err := c.auditRepo.GetLastCheckpoint()
+	if err != nil && !errors.Is(err, audit_errors.ErrCheckpointNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.EventID == last.ID {
+		return nil, nil
+	}
+
+	signature := ed25519.Sign(c.signingKey, audit_types.CheckpointMessage(last.ID, last.Hash))
+	checkpoint := &audit_types.Checkpoint{
+		EventID:   last.ID,
+		Hash:      last.Hash,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	if err := c.auditRepo.InsertCheckpoint(checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// Verify walks the whole chain in id order and stops at the first link that doesn't
+// hold. Events written before the chain existed have no hash and are only counted, but
+// once the chain has started every event must be linked.
+func (c *Chain) Verify() (*audit_types.VerifyReport, error) {
+	checkpoints, err := c.auditRepo.ListCheckpoints()
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) > 0 && c.verifyKey == nil {
+		return nil, audit_errors.ErrNoVerifyKey
+	}
+
+	report := &audit_types.VerifyReport{Checkpoints: len(checkpoints)}
+
+	pending := make(map[int64][]audit_types.Checkpoint, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		pending[checkpoint.EventID] = append(pending[checkpoint.EventID], checkpoint)
+	}
+
+	prevHash := audit_types.GenesisHash
+	chained := false
+	var afterID int64
+
+	for {
+		events, err := c.auditRepo.ListChain(afterID, c.pageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			afterID = event.ID
+
+			if event.Hash == "" {
+				if !chained {
+					report.Unchained++
+					continue
+				}
+				report.Break = eventBreak(event, "event has no hash, it was written outside the chain")
+				return report, nil
+			}
+			chained = true
+
+			if event.PrevHash != prevHash {
+				report.Break = eventBreak(event, "previous hash doesn't match the event before it, events were removed, inserted or reordered")
+				return report, nil
+			}
+
+			hash, err := audit_types.ChainHash(prevHash, event)
+			if err != nil {
+				return nil, err
+			}
+			if hash != event.Hash {
+				report.Break = eventBreak(event, "event doesn't match its hash, it was modified")
+				return report, nil
+			}
+
+			for _, checkpoint := range pending[event.ID] {
+				if reason := c.checkCheckpoint(checkpoint); reason != "" {
+					report.Break = checkpointBreak(checkpoint, reason)
+					return report, nil
+				}
+				if checkpoint.Hash != event.Hash {
+					report.Break = checkpointBreak(checkpoint, "chain doesn't match the signed checkpoint, it was rewritten")
+					return report, nil
+				}
+			}
+			delete(pending, event.ID)
+
+			prevHash = event.Hash
+			report.Events++
+			report.Head = event.Hash
+		}
+
+		if len(events) < c.pageSize {
+			break
+		}
+	}
+
+	// a checkpoint left over signed an event that is no longer there
+	var missing *audit_types.Checkpoint
+	for _, list := range pending {
+		for i := range list {
+			if missing == nil || list[i].ID < missing.ID {
+				missing = &list[i]
+			}
+		}
+	}
+	if missing != nil {
+		reason := c.checkCheckpoint(*missing)
+		if reason == "" {
+			reason = "event signed by the checkpoint is missing, the end of the chain was removed"
+		}
+		report.Break = checkpointBreak(*missing, reason)
+	}
+
+	return report, nil
+}
+
+// checkCheckpoint returns why the checkpoint signature doesn't hold, or "" when it does.
+func (c *Chain) checkCheckpoint(checkpoint audit_types.Checkpoint) string {
+	signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	if err != nil || !ed25519.Verify(c.verifyKey, audit_types.CheckpointMessage(checkpoint.EventID, checkpoint.Hash), signature) {
+		return "checkpoint signature is invalid"
+	}
+	return ""
+}
+
+func eventBreak(event audit_types.Event, reason string) *audit_types.ChainBreak {
+	return &audit_types.ChainBreak{EventID: event.ID, PublicID: event.PublicID, Reason: reason}
+}
+
+func checkpointBreak(checkpoint audit_types.Checkpoint, reason string) *audit_types.ChainBreak {
+	return &audit_types.ChainBreak{EventID: checkpoint.EventID, CheckpointID: checkpoint.ID, Reason: reason}
+}
+
+// Start signs a checkpoint right away and then once per interval until ctx is done.
+// Without a signing key it only waits for ctx, so the worker can run either way.
+func (c *Chain) Start(ctx context.Context) error {
+	if c.signingKey == nil {
+		logger.Log.Warn("audit checkpoints are disabled, AUDIT_SIGNING_KEY is not set")
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Checkpoint(); err != nil {
+			logger.Log.Error("failed to write audit checkpoint", zap.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop has nothing to release, Start returns as soon as its context is done.
+func (c *Chain) Stop(ctx context.Context) error {
+	return nil
+}
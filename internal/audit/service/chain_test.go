@@ -0,0 +1,271 @@
+package audit_service
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_repository_mock "github.com/celio001/prodify/internal/audit/repository/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testSeed = base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))
+
+func newTestChain(t *testing.T, mockRepo *audit_repository_mock.MockAuditRepository) *Chain {
+	signingKey, verifyKey, err := parseKeys(testSeed, "")
+	assert.NoError(t, err)
+
+	return &Chain{auditRepo: mockRepo, signingKey: signingKey, verifyKey: verifyKey, interval: time.Hour, pageSize: 2}
+}
+
+// chainEvents builds n linked events with ids from 1, after the given number of events
+// written before the chain existed.
+func chainEvents(t *testing.T, unchained int, n int) []audit_types.Event {
+	events := make([]audit_types.Event, 0, unchained+n)
+	prevHash := audit_types.GenesisHash
+
+	for i := 1; i <= unchained+n; i++ {
+		event := audit_types.Event{
+			ID:        int64(i),
+			PublicID:  fmt.Sprintf("event-%d", i),
+			Action:    audit_types.ActionLoginSucceeded,
+			IP:        "10.0.0.1",
+			CreatedAt: time.Date(2026, 10, 1, 12, i, 0, 0, time.UTC),
+		}
+		if i > unchained {
+			hash, err := audit_types.ChainHash(prevHash, event)
+			assert.NoError(t, err)
+			event.PrevHash, event.Hash = prevHash, hash
+			prevHash = hash
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func sign(chain *Chain, id int64, event audit_types.Event) audit_types.Checkpoint {
+	signature := ed25519.Sign(chain.signingKey, audit_types.CheckpointMessage(event.ID, event.Hash))
+	return audit_types.Checkpoint{ID: id, EventID: event.ID, Hash: event.Hash, Signature: base64.StdEncoding.EncodeToString(signature)}
+}
+
+// expectChain serves events in pages of the chain's page size.
+func expectChain(mockRepo *audit_repository_mock.MockAuditRepository, chain *Chain, events []audit_types.Event) {
+	var afterID int64
+	for start := 0; ; start += chain.pageSize {
+		end := min(start+chain.pageSize, len(events))
+		page := events[start:end]
+		mockRepo.On("ListChain", afterID, chain.pageSize).Return(page, nil).Maybe()
+		if len(page) < chain.pageSize {
+			return
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	signingKey, verifyKey, err := parseKeys(testSeed, "")
+	assert.NoError(t, err)
+	assert.Equal(t, signingKey.Public(), verifyKey)
+
+	_, verifyKey, err = parseKeys("", base64.StdEncoding.EncodeToString(signingKey.Public().(ed25519.PublicKey)))
+	assert.NoError(t, err)
+	assert.Equal(t, signingKey.Public(), verifyKey)
+
+	_, _, err = parseKeys("c2hvcnQ=", "")
+	assert.ErrorIs(t, err, audit_errors.ErrInvalidSigningKey)
+
+	_, _, err = parseKeys("", "not base64!")
+	assert.ErrorIs(t, err, audit_errors.ErrInvalidVerifyKey)
+}
+
+func TestChainCheckpoint(t *testing.T) {
+	t.Run("signs the head of the chain", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		chain := newTestChain(t, mockRepo)
+		events := chainEvents(t, 0, 3)
+
+		mockRepo.On("GetLastEvent").Return(&events[2], nil)
+		mockRepo.On("GetLastCheckpoint").Return(&audit_types.Checkpoint{ID: 1, EventID: 1}, nil)
+		mockRepo.On("InsertCheckpoint", mock.AnythingOfType("*audit_types.Checkpoint")).Return(nil)
+
+		checkpoint, err := chain.Checkpoint()
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), checkpoint.EventID)
+		assert.Equal(t, events[2].Hash, checkpoint.Hash)
+		assert.Empty(t, chain.checkCheckpoint(*checkpoint))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("head already signed", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		chain := newTestChain(t, mockRepo)
+		events := chainEvents(t, 0, 1)
+
+		mockRepo.On("GetLastEvent").Return(&events[0], nil)
+		mockRepo.On("GetLastCheckpoint").Return(&audit_types.Checkpoint{ID: 1, EventID: 1}, nil)
+
+		checkpoint, err := chain.Checkpoint()
+
+		assert.NoError(t, err)
+		assert.Nil(t, checkpoint)
+		mockRepo.AssertNotCalled(t, "InsertCheckpoint", mock.Anything)
+	})
+
+	t.Run("no chained events yet", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		chain := newTestChain(t, mockRepo)
+
+		mockRepo.On("GetLastEvent").Return(nil, audit_errors.ErrEventNotFound)
+
+		checkpoint, err := chain.Checkpoint()
+
+		assert.NoError(t, err)
+		assert.Nil(t, checkpoint)
+		mockRepo.AssertNotCalled(t, "InsertCheckpoint", mock.Anything)
+	})
+
+	t.Run("without a signing key", func(t *testing.T) {
+		chain := &Chain{auditRepo: new(audit_repository_mock.MockAuditRepository)}
+
+		_, err := chain.Checkpoint()
+
+		assert.ErrorIs(t, err, audit_errors.ErrNoSigningKey)
+	})
+}
+
+func TestChainVerify(t *testing.T) {
+	chain := newTestChain(t, nil)
+
+	tests := []struct {
+		name        string
+		events      func([]audit_types.Event) []audit_types.Event
+		checkpoints func([]audit_types.Event) []audit_types.Checkpoint
+		wantBreak   *audit_types.ChainBreak
+	}{
+		{
+			name:   "intact chain",
+			events: func(events []audit_types.Event) []audit_types.Event { return events },
+			checkpoints: func(events []audit_types.Event) []audit_types.Checkpoint {
+				return []audit_types.Checkpoint{sign(chain, 1, events[3]), sign(chain, 2, events[5])}
+			},
+		},
+		{
+			name: "modified event",
+			events: func(events []audit_types.Event) []audit_types.Event {
+				events[3].IP = "10.0.0.2"
+				return events
+			},
+			wantBreak: &audit_types.ChainBreak{EventID: 4, PublicID: "event-4", Reason: "event doesn't match its hash, it was modified"},
+		},
+		{
+			name: "removed event",
+			events: func(events []audit_types.Event) []audit_types.Event {
+				return append(events[:3], events[4:]...)
+			},
+			wantBreak: &audit_types.ChainBreak{EventID: 5, PublicID: "event-5", Reason: "previous hash doesn't match the event before it, events were removed, inserted or reordered"},
+		},
+		{
+			name: "event written outside the chain",
+			events: func(events []audit_types.Event) []audit_types.Event {
+				events[4].PrevHash, events[4].Hash = "", ""
+				return events
+			},
+			wantBreak: &audit_types.ChainBreak{EventID: 5, PublicID: "event-5", Reason: "event has no hash, it was written outside the chain"},
+		},
+		{
+			name: "chain rewritten after a checkpoint",
+			events: func(events []audit_types.Event) []audit_types.Event {
+				events[3].IP = "10.0.0.2"
+				prevHash := events[2].Hash
+				for i := 3; i < len(events); i++ {
+					hash, _ := audit_types.ChainHash(prevHash, events[i])
+					events[i].PrevHash, events[i].Hash = prevHash, hash
+					prevHash = hash
+				}
+				return events
+			},
+			checkpoints: func(events []audit_types.Event) []audit_types.Checkpoint {
+				return []audit_types.Checkpoint{sign(chain, 1, events[4])}
+			},
+			wantBreak: &audit_types.ChainBreak{EventID: 5, CheckpointID: 1, Reason: "chain doesn't match the signed checkpoint, it was rewritten"},
+		},
+		{
+			name: "forged checkpoint",
+			events: func(events []audit_types.Event) []audit_types.Event {
+				return events
+			},
+			checkpoints: func(events []audit_types.Event) []audit_types.Checkpoint {
+				checkpoint := sign(chain, 1, events[3])
+				checkpoint.Signature = sign(chain, 1, events[2]).Signature
+				return []audit_types.Checkpoint{checkpoint}
+			},
+			wantBreak: &audit_types.ChainBreak{EventID: 4, CheckpointID: 1, Reason: "checkpoint signature is invalid"},
+		},
+		{
+			name: "end of the chain removed",
+			events: func(events []audit_types.Event) []audit_types.Event {
+				return events[:4]
+			},
+			checkpoints: func(events []audit_types.Event) []audit_types.Checkpoint {
+				return []audit_types.Checkpoint{sign(chain, 1, events[3]), sign(chain, 2, events[5])}
+			},
+			wantBreak: &audit_types.ChainBreak{EventID: 6, CheckpointID: 2, Reason: "event signed by the checkpoint is missing, the end of the chain was removed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(audit_repository_mock.MockAuditRepository)
+			chain.auditRepo = mockRepo
+
+			original := chainEvents(t, 2, 4)
+			var checkpoints []audit_types.Checkpoint
+			if tt.checkpoints != nil {
+				checkpoints = tt.checkpoints(original)
+			}
+			events := tt.events(append([]audit_types.Event(nil), original...))
+
+			mockRepo.On("ListCheckpoints").Return(checkpoints, nil)
+			expectChain(mockRepo, chain, events)
+
+			report, err := chain.Verify()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBreak, report.Break)
+			assert.Equal(t, int64(2), report.Unchained)
+			assert.Equal(t, len(checkpoints), report.Checkpoints)
+			if tt.wantBreak == nil {
+				assert.Equal(t, int64(4), report.Events)
+				assert.Equal(t, original[5].Hash, report.Head)
+			}
+		})
+	}
+
+	t.Run("checkpoints without a verify key", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		mockRepo.On("ListCheckpoints").Return([]audit_types.Checkpoint{{ID: 1, EventID: 1}}, nil)
+
+		_, err := (&Chain{auditRepo: mockRepo, pageSize: 2}).Verify()
+
+		assert.ErrorIs(t, err, audit_errors.ErrNoVerifyKey)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		chain.auditRepo = mockRepo
+		mockRepo.On("ListCheckpoints").Return(nil, nil)
+		mockRepo.On("ListChain", int64(0), chain.pageSize).Return(nil, errors.New("db error"))
+
+		_, err := chain.Verify()
+
+		assert.EqualError(t, err, "db error")
+	})
+}
@@ -0,0 +1,35 @@
+package audit_service_mock
+
+import (
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) ListEvents(listRequest audit_types.ListEventsRequest) (*audit_types.ListEventsResponse, error) {
+	args := m.Called(listRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit_types.ListEventsResponse), args.Error(1)
+}
+
+func (m *MockAuditService) ExportEvents(listRequest audit_types.ListEventsRequest) ([]audit_types.Event, error) {
+	args := m.Called(listRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]audit_types.Event), args.Error(1)
+}
+
+// MockRecorder keeps the recorded events for tests to inspect.
+type MockRecorder struct {
+	Events []audit_types.Event
+}
+
+func (m *MockRecorder) Record(event audit_types.Event) {
+	m.Events = append(m.Events, event)
+}
@@ -0,0 +1,140 @@
+package audit_service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	audit_repository "github.com/celio001/prodify/internal/audit/repository"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Recorder writes audit events in the background so requests never wait on the
+// database. Events are written in batches, every flushInterval or as soon as a batch
+// is full. An event that can't be stored, because the buffer is full or the write
+// failed, is logged in full instead of being dropped silently.
+type Recorder struct {
+	auditRepo     audit_repository.AuditRepository
+	events        chan audit_types.Event
+	batchSize     int
+	flushInterval time.Duration
+
+	mu      sync.RWMutex
+	stopped bool
+	done    chan struct{}
+}
+
+func NewRecorder(auditRepo audit_repository.AuditRepository) *Recorder {
+	return newRecorder(auditRepo,
+		config.GetInt("AUDIT_BUFFER_SIZE"),
+		config.GetInt("AUDIT_BATCH_SIZE"),
+		time.Duration(config.GetInt("AUDIT_FLUSH_INTERVAL_MS"))*time.Millisecond)
+}
+
+func newRecorder(auditRepo audit_repository.AuditRepository, bufferSize int, batchSize int, flushInterval time.Duration) *Recorder {
+	return &Recorder{
+		auditRepo:     auditRepo,
+		events:        make(chan audit_types.Event, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+}
+
+// Record queues event without blocking. CreatedAt is the time of the call unless set.
+func (r *Recorder) Record(event audit_types.Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.stopped {
+		logLostEvent("audit recorder stopped", event)
+		return
+	}
+
+	select {
+	case r.events <- event:
+	default:
+		logLostEvent("audit buffer full", event)
+	}
+}
+
+// Run writes queued events until Stop is called, then writes what is left. Run it in
+// its own goroutine.
+func (r *Recorder) Run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]audit_types.Event, 0, r.batchSize)
+	for {
+		select {
+		case event, ok := <-r.events:
+			if !ok {
+				r.write(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				r.write(batch)
+				batch = make([]audit_types.Event, 0, r.batchSize)
+			}
+		case <-ticker.C:
+			r.write(batch)
+			batch = make([]audit_types.Event, 0, r.batchSize)
+		}
+	}
+}
+
+// Stop refuses new events and waits for Run to write the queued ones, or for ctx to
+// be done.
+func (r *Recorder) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.stopped {
+		r.stopped = true
+		close(r.events)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Recorder) write(batch []audit_types.Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := r.auditRepo.InsertEvents(batch); err != nil {
+		logger.Log.Error("failed to write audit events", zap.Int("count", len(batch)), zap.String("error", err.Error()))
+		for _, event := range batch {
+			logLostEvent("audit event not stored", event)
+		}
+	}
+}
+
+func logLostEvent(reason string, event audit_types.Event) {
+	logger.Log.Error(reason,
+		zap.String("action", event.Action),
+		zap.String("actor_id", event.ActorID),
+		zap.String("impersonator_id", event.ImpersonatorID),
+		zap.String("target_type", event.TargetType),
+		zap.String("target_id", event.TargetID),
+		zap.String("ip", event.IP),
+		zap.String("user_agent", event.UserAgent),
+		zap.String("request_id", event.RequestID),
+		zap.Any("changes", event.Changes),
+		zap.Any("metadata", event.Metadata),
+		zap.Time("created_at", event.CreatedAt))
+}
@@ -0,0 +1,80 @@
+package audit_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	audit_repository_mock "github.com/celio001/prodify/internal/audit/repository/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecorder(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("writes full batches and the rest on stop", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		mockRepo.On("InsertEvents", mock.MatchedBy(func(events []audit_types.Event) bool { return len(events) == 2 })).Return(nil).Once()
+		mockRepo.On("InsertEvents", mock.MatchedBy(func(events []audit_types.Event) bool { return len(events) == 1 })).Return(nil).Once()
+
+		recorder := newRecorder(mockRepo, 10, 2, time.Hour)
+		go recorder.Run()
+
+		for range 3 {
+			recorder.Record(audit_types.Event{Action: audit_types.ActionLoginSucceeded})
+		}
+
+		assert.NoError(t, recorder.Stop(context.Background()))
+		mockRepo.AssertExpectations(t)
+
+		batch := mockRepo.Calls[0].Arguments.Get(0).([]audit_types.Event)
+		assert.False(t, batch[0].CreatedAt.IsZero())
+	})
+
+	t.Run("flushes on the interval", func(t *testing.T) {
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		written := make(chan struct{})
+		mockRepo.On("InsertEvents", mock.Anything).Return(nil).Run(func(mock.Arguments) { close(written) }).Once()
+
+		recorder := newRecorder(mockRepo, 10, 100, 10*time.Millisecond)
+		go recorder.Run()
+		defer recorder.Stop(context.Background())
+
+		recorder.Record(audit_types.Event{Action: audit_types.ActionLoginSucceeded})
+
+		select {
+		case <-written:
+		case <-time.After(time.Second):
+			t.Fatal("event was not written")
+		}
+	})
+
+	t.Run("logs what it can't store", func(t *testing.T) {
+		core, logs := observer.New(zap.ErrorLevel)
+		logger.Log = zap.New(core)
+		defer logger.Init("dev")
+
+		mockRepo := new(audit_repository_mock.MockAuditRepository)
+		mockRepo.On("InsertEvents", mock.Anything).Return(errors.New("db down"))
+
+		// not running yet, the second event doesn't fit in the buffer
+		recorder := newRecorder(mockRepo, 1, 10, time.Hour)
+		recorder.Record(audit_types.Event{Action: audit_types.ActionLoginFailed, TargetID: "first"})
+		recorder.Record(audit_types.Event{Action: audit_types.ActionLoginFailed, TargetID: "second"})
+
+		go recorder.Run()
+		assert.NoError(t, recorder.Stop(context.Background()))
+		recorder.Record(audit_types.Event{Action: audit_types.ActionLoginFailed, TargetID: "third"})
+
+		assert.Equal(t, "second", logs.FilterMessage("audit buffer full").All()[0].ContextMap()["target_id"])
+		assert.Equal(t, "first", logs.FilterMessage("audit event not stored").All()[0].ContextMap()["target_id"])
+		assert.Equal(t, "third", logs.FilterMessage("audit recorder stopped").All()[0].ContextMap()["target_id"])
+	})
+}
@@ -0,0 +1,127 @@
+package audit_types
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionLoginSucceeded         = "auth.login.succeeded"
+	ActionLoginFailed            = "auth.login.failed"
+	ActionLoginUnlocked          = "auth.login.unlocked"
+	ActionRegistered             = "auth.registered"
+	ActionPasswordChanged        = "auth.password.changed"
+	ActionPasswordResetRequested = "auth.password_reset.requested"
+	ActionPasswordResetCompleted = "auth.password_reset.completed"
+	ActionPasswordResetForced    = "auth.password_reset.forced"
+	ActionUserUpdated            = "user.updated"
+	ActionUserDeleted            = "user.deleted"
+	ActionUserRestored           = "user.restored"
+	ActionProductCreated         = "product.created"
+	ActionProductUpdated         = "product.updated"
+	ActionProductDeleted         = "product.deleted"
+)
+
+// Kinds of records an event can be about.
+const (
+	TargetUser    = "user"
+	TargetProduct = "product"
+)
+
+// Event is one entry of the audit log. ActorID is who made the request, empty when
+// nobody was signed in; ImpersonatorID is the admin behind it when they used an
+// impersonation token.
+type Event struct {
+	ID             int64             `json:"-"`
+	PublicID       string            `json:"id"`
+	Action         string            `json:"action"`
+	ActorID        string            `json:"actorId,omitempty"`
+	ImpersonatorID string            `json:"impersonatorId,omitempty"`
+	TargetType     string            `json:"targetType,omitempty"`
+	TargetID       string            `json:"targetId,omitempty"`
+	IP             string            `json:"ip"`
+	UserAgent      string            `json:"userAgent"`
+	RequestID      string            `json:"requestId"`
+	Changes        map[string]Change `json:"changes,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+	PrevHash       string            `json:"prevHash,omitempty"`
+	Hash           string            `json:"hash,omitempty"`
+}
+
+// Change is the value of one field before and after the action.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Diff lists the fields that differ between two versions of a record, compared by their
+// JSON form. A nil before is a creation and a nil after a deletion, every field is listed.
+func Diff(before, after any) map[string]Change {
+	beforeFields := jsonFields(before)
+	afterFields := jsonFields(after)
+
+	changes := map[string]Change{}
+	for field, value := range beforeFields {
+		if other, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes[field] = Change{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = Change{After: value}
+		}
+	}
+	return changes
+}
+
+func jsonFields(record any) map[string]any {
+	fields := map[string]any{}
+	if record == nil || reflect.ValueOf(record).Kind() == reflect.Ptr && reflect.ValueOf(record).IsNil() {
+		return fields
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+type ListEventsRequest struct {
+	Action     string `query:"action" validate:"omitempty,max=64"`
+	ActorID    string `query:"actor_id" validate:"omitempty,uuid"`
+	TargetType string `query:"target_type" validate:"omitempty,max=32"`
+	TargetID   string `query:"target_id" validate:"omitempty,max=64"`
+	IP         string `query:"ip" validate:"omitempty,ip"`
+	RequestID  string `query:"request_id" validate:"omitempty,max=64"`
+	From       string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Page       int    `query:"page" validate:"omitempty,min=1"`
+	PageSize   int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// EventFilter is ListEventsRequest ready for the repository. ActorID also matches the
+// admin behind an impersonated request.
+type EventFilter struct {
+	Action     string
+	ActorID    string
+	TargetType string
+	TargetID   string
+	IP         string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type ListEventsResponse struct {
+	Events   []Event `json:"events"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"pageSize"`
+	Total    int64   `json:"total"`
+}
@@ -0,0 +1,89 @@
+package audit_types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type record struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+	Note  string  `json:"note,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("update lists changed fields", func(t *testing.T) {
+		changes := Diff(record{Name: "Pen", Price: 2}, record{Name: "Pen", Price: 2.5, Note: "new"})
+
+		assert.Equal(t, map[string]Change{
+			"price": {Before: 2.0, After: 2.5},
+			"note":  {After: "new"},
+		}, changes)
+	})
+
+	t.Run("creation", func(t *testing.T) {
+		changes := Diff(nil, &record{Name: "Pen", Price: 2})
+
+		assert.Equal(t, map[string]Change{
+			"name":  {After: "Pen"},
+			"price": {After: 2.0},
+		}, changes)
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		var after *record
+		changes := Diff(&record{Name: "Pen", Price: 2}, after)
+
+		assert.Equal(t, map[string]Change{
+			"name":  {Before: "Pen"},
+			"price": {Before: 2.0},
+		}, changes)
+	})
+
+	t.Run("nothing changed", func(t *testing.T) {
+		assert.Empty(t, Diff(record{Name: "Pen"}, record{Name: "Pen"}))
+	})
+}
+
+func TestChainHash(t *testing.T) {
+	event := Event{
+		PublicID:  "0b8f3c5e-3f4a-4d3c-9a57-2f1f0c6f1b11",
+		Action:    ActionProductUpdated,
+		ActorID:   "5a1f5c1e-2a7e-4bd4-b7e3-7a3d1c2e9f10",
+		IP:        "10.0.0.1",
+		Changes:   map[string]Change{"price": {Before: 2, After: 2.5}},
+		Metadata:  map[string]string{"org_id": "7"},
+		CreatedAt: time.Date(2026, 10, 1, 12, 0, 0, 123456000, time.FixedZone("BRT", -3*60*60)),
+	}
+
+	hash, err := ChainHash(GenesisHash, event)
+	assert.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	t.Run("same hash once read back from the database", func(t *testing.T) {
+		stored := event
+		stored.Changes = map[string]Change{"price": {Before: float64(2), After: 2.5}}
+		stored.CreatedAt = event.CreatedAt.UTC()
+
+		storedHash, err := ChainHash(GenesisHash, stored)
+		assert.NoError(t, err)
+		assert.Equal(t, hash, storedHash)
+	})
+
+	t.Run("depends on the previous hash", func(t *testing.T) {
+		other, err := ChainHash(hash, event)
+		assert.NoError(t, err)
+		assert.NotEqual(t, hash, other)
+	})
+
+	t.Run("depends on every field", func(t *testing.T) {
+		changed := event
+		changed.Metadata = map[string]string{"org_id": "8"}
+
+		other, err := ChainHash(GenesisHash, changed)
+		assert.NoError(t, err)
+		assert.NotEqual(t, hash, other)
+	})
+}
@@ -2,15 +2,56 @@ package auth_errors
 
 import (
 	"errors"
+	"time"
 
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
 	"github.com/go-playground/validator/v10"
 )
 
 var (
 	ErrMatchDataUser     = errors.New("email or password incorrect")
 	ErrUserAlreadyExists = errors.New("user with this email already exists")
+	ErrInvalidToken      = errors.New("invalid or expired token")
+	ErrEmailNotVerified  = errors.New("email address not verified")
+	ErrSameEmail         = errors.New("new email is the same as the current one")
+	ErrUserInactive      = errors.New("account is deactivated")
+	ErrPasswordReset     = errors.New("password reset required, check your email for a reset link")
+	ErrSessionRevoked    = errors.New("session has been revoked")
+	ErrSessionNotFound   = errors.New("session not found")
+
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	ErrIdentityNotFound        = errors.New("external identity not linked")
+	ErrIdentityEmailUnverified = errors.New("the identity provider did not return a verified email address")
+	ErrIdentityLinkConflict    = errors.New("an account with this email already exists, verify its email before signing in with this provider")
+
+	ErrInvalidCurrentPassword = errors.New("current password is incorrect")
+	ErrTooManyLoginAttempts   = errors.New("too many login attempts, try again later")
+
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+
+	ErrRegistrationDisabled = errors.New("registration is by invitation only")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvalidInvitation    = errors.New("invalid, expired or already used invitation")
+
+	ErrCannotImpersonate = errors.New("admins, including yourself, cannot be impersonated")
 )
 
+// LockoutError is returned while an account or client IP is locked out.
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
 func LoginValidateError(err error) map[string]string {
 	errors := make(map[string]string)
 
@@ -78,8 +119,8 @@ func ResetPasswordValidateError(err error) map[string]string {
 		for _, fieldErr := range validationErrs {
 			field := fieldErr.Field()
 			switch field {
-			case "OldPassword":
-				errors[field] = "Old password is required"
+			case "CurrentPassword":
+				errors[field] = "Current password is required"
 			case "NewPassword":
 				errors[field] = "New password is required"
 			}
@@ -87,3 +128,323 @@ func ResetPasswordValidateError(err error) map[string]string {
 	}
 	return errors
 }
+
+func VerifyEmailValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			switch field {
+			case "Token":
+				errors[field] = "Token is required"
+			}
+		}
+	}
+	return errors
+}
+
+func ResendVerificationValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Email":
+				switch tag {
+				case "required":
+					errors[field] = "Email is required"
+				case "email":
+					errors[field] = "Invalid email format"
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func ForgotPasswordValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Email":
+				switch tag {
+				case "required":
+					errors[field] = "Email is required"
+				case "email":
+					errors[field] = "Invalid email format"
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func UnlockLoginValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Email":
+				switch tag {
+				case "required_without":
+					errors[field] = "Email or IP is required"
+				case "email":
+					errors[field] = "Invalid email format"
+				}
+			case "IP":
+				switch tag {
+				case "required_without":
+					errors[field] = "Email or IP is required"
+				case "ip":
+					errors[field] = "Invalid IP address"
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func ConfirmMFAValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Code":
+				switch tag {
+				case "required":
+					errors[field] = "Code is required"
+				case "len", "numeric":
+					errors[field] = "Code must have 6 digits"
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func MFALoginValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "MFAToken":
+				switch tag {
+				case "required":
+					errors[field] = "MFA token is required"
+				}
+			case "Code":
+				switch tag {
+				case "required":
+					errors[field] = "Code is required"
+				case "max":
+					errors[field] = "Invalid code"
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func ResetMFAValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "UserID":
+				switch tag {
+				case "required":
+					errors[field] = "User ID is required"
+				case "uuid":
+					errors[field] = "Invalid user ID"
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func ConfirmPasswordResetValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			switch field {
+			case "Token":
+				errors[field] = "Token is required"
+			case "NewPassword":
+				errors[field] = "New password is required"
+			}
+		}
+	}
+	return errors
+}
+
+func RefreshTokenValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			switch field {
+			case "RefreshToken":
+				errors[field] = "Refresh token is required"
+			}
+		}
+	}
+	return errors
+}
+
+func MagicLinkValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Email":
+				switch tag {
+				case "required":
+					errors[field] = "Email is required"
+				case "email":
+					errors[field] = "Invalid email format"
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func MagicLinkLoginValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			switch field {
+			case "Token":
+				errors[field] = "Token is required"
+			}
+		}
+	}
+	return errors
+}
+
+func PasswordStrengthValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Password":
+				switch tag {
+				case "required":
+					errors[field] = "Password is required"
+				case "max":
+					errors[field] = "Password must be at most 256 characters"
+				}
+			case "Name":
+				errors[field] = "Name must be at most 50 characters"
+			case "Email":
+				errors[field] = "Email must be at most 254 characters"
+			}
+		}
+	}
+	return errors
+}
+
+func CreateInvitationValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Email":
+				switch tag {
+				case "required":
+					errors[field] = "Email is required"
+				case "email":
+					errors[field] = "Invalid email format"
+				case "max":
+					errors[field] = "Email must be at most 255 characters"
+				}
+			case "Role":
+				errors[field] = "Role must be user or admin"
+			}
+		}
+	}
+	return errors
+}
+
+func ListInvitationsValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			switch field {
+			case "Status":
+				errors[field] = "Status must be pending, accepted, expired or revoked"
+			}
+		}
+	}
+	return errors
+}
+
+func AcceptInvitationValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+			switch field {
+			case "Token":
+				errors[field] = "Token is required"
+			case "Name":
+				switch tag {
+				case "required":
+					errors[field] = "Name is required"
+				case "min":
+					errors[field] = "Name must be at least 3 characters"
+				case "max":
+					errors[field] = "Name must be at most 50 characters"
+				}
+			case "Password":
+				errors[field] = "Password is required"
+			}
+		}
+	}
+	return errors
+}
+
+// PasswordPolicyError lists every policy rule the password broke under the field that carried it.
+func PasswordPolicyError(field string, err *password_errors.PasswordError) map[string][]string {
+	return map[string][]string{
+		field: err.ReasonsList(),
+	}
+}
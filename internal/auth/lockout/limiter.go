@@ -0,0 +1,222 @@
+package auth_lockout
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+)
+
+var ErrUnknownStore = errors.New("unknown lockout store")
+
+// Policy controls how a key backs off. The first FreeAttempts failures cost
+// nothing, each failure after that locks the key for BaseDelay doubled per
+// failure, and from MaxFailures on the key is locked for LockoutDuration.
+// Failures older than Window are forgotten.
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxFailures     int
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+func (p Policy) lockFor(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	if failures >= p.MaxFailures {
+		return p.LockoutDuration
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+	return min(delay, p.LockoutDuration)
+}
+
+// RateLimit allows Limit requests per key within Window. Once it is reached the
+// key is locked until the window has passed.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Limiter tracks failed logins both per account and per client IP, so a single
+// address can't spray many accounts and many addresses can't hammer one account.
+type Limiter struct {
+	store   Store
+	account Policy
+	ip      Policy
+	now     func() time.Time
+}
+
+func NewLimiter(store Store, account Policy, ip Policy) *Limiter {
+	return &Limiter{
+		store:   store,
+		account: account,
+		ip:      ip,
+		now:     time.Now,
+	}
+}
+
+// New builds the limiter from config, AUTH_LOCKOUT_STORE selects memory or postgres.
+func New(db *sql.DB) (*Limiter, error) {
+	var store Store
+	switch driver := config.GetString("AUTH_LOCKOUT_STORE"); driver {
+	case "memory":
+		store = NewMemoryStore()
+	case "postgres":
+		store = NewPostgresStore(db)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStore, driver)
+	}
+
+	baseDelay := time.Duration(config.GetInt("AUTH_LOCKOUT_BASE_DELAY_SECONDS")) * time.Second
+	duration := time.Duration(config.GetInt("AUTH_LOCKOUT_DURATION_MINUTES")) * time.Minute
+	window := time.Duration(config.GetInt("AUTH_LOCKOUT_WINDOW_MINUTES")) * time.Minute
+
+	return NewLimiter(store,
+		Policy{
+			FreeAttempts:    config.GetInt("AUTH_LOCKOUT_FREE_ATTEMPTS"),
+			BaseDelay:       baseDelay,
+			MaxFailures:     config.GetInt("AUTH_LOCKOUT_MAX_FAILURES"),
+			LockoutDuration: duration,
+			Window:          window,
+		},
+		Policy{
+			FreeAttempts:    config.GetInt("AUTH_LOCKOUT_IP_FREE_ATTEMPTS"),
+			BaseDelay:       baseDelay,
+			MaxFailures:     config.GetInt("AUTH_LOCKOUT_IP_MAX_FAILURES"),
+			LockoutDuration: duration,
+			Window:          window,
+		},
+	), nil
+}
+
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+func MagicLinkKey(email string) string {
+	return "magic_link:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func PasswordStrengthKey(ip string) string {
+	return "password_strength:" + ip
+}
+
+func PasswordResetKey(email string) string {
+	return "password_reset:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func PasswordResetIPKey(ip string) string {
+	return "password_reset_ip:" + ip
+}
+
+// Check returns a *auth_errors.LockoutError while either the account or the IP is locked.
+func (l *Limiter) Check(email string, ip string) error {
+	var retryAfter time.Duration
+
+	for _, key := range l.keys(email, ip) {
+		attempt, err := l.store.Get(key)
+		if err != nil {
+			return err
+		}
+		if attempt == nil {
+			continue
+		}
+
+		if remaining := attempt.LockedUntil.Sub(l.now()); remaining > retryAfter {
+			retryAfter = remaining
+		}
+	}
+
+	if retryAfter > 0 {
+		return &auth_errors.LockoutError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (l *Limiter) RegisterFailure(email string, ip string) error {
+	if err := l.registerFailure(AccountKey(email), l.account); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return l.registerFailure(IPKey(ip), l.ip)
+}
+
+// RegisterSuccess only clears the account; a successful login from an address
+// that is spraying other accounts must not wipe its counter.
+func (l *Limiter) RegisterSuccess(email string) error {
+	return l.store.Reset(AccountKey(email))
+}
+
+// Unlock clears the counters and any lock for the given account and/or IP.
+func (l *Limiter) Unlock(email string, ip string) error {
+	for _, key := range l.keys(email, ip) {
+		if err := l.store.Reset(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Allow counts a request against key and returns a *auth_errors.LockoutError once
+// rate is exhausted. Unlike logins every request counts, successful or not.
+func (l *Limiter) Allow(key string, rate RateLimit) error {
+	attempt, err := l.store.Get(key)
+	if err != nil {
+		return err
+	}
+	if attempt != nil {
+		if remaining := attempt.LockedUntil.Sub(l.now()); remaining > 0 {
+			return &auth_errors.LockoutError{RetryAfter: remaining}
+		}
+	}
+
+	attempt, err = l.store.RecordFailure(key, rate.Window)
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failures < rate.Limit {
+		return nil
+	}
+	return l.store.Lock(key, l.now().Add(rate.Window))
+}
+
+func (l *Limiter) registerFailure(key string, policy Policy) error {
+	attempt, err := l.store.RecordFailure(key, policy.Window)
+	if err != nil {
+		return err
+	}
+
+	lockFor := policy.lockFor(attempt.Failures)
+	if lockFor == 0 {
+		return nil
+	}
+	return l.store.Lock(key, l.now().Add(lockFor))
+}
+
+func (l *Limiter) keys(email string, ip string) []string {
+	var keys []string
+	if email != "" {
+		keys = append(keys, AccountKey(email))
+	}
+	if ip != "" {
+		keys = append(keys, IPKey(ip))
+	}
+	return keys
+}
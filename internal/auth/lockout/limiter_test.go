@@ -0,0 +1,159 @@
+package auth_lockout
+
+import (
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyLockFor(t *testing.T) {
+	policy := Policy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxFailures:     10,
+		LockoutDuration: 15 * time.Minute,
+		Window:          15 * time.Minute,
+	}
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 1, expected: 0},
+		{failures: 3, expected: 0},
+		{failures: 4, expected: time.Second},
+		{failures: 5, expected: 2 * time.Second},
+		{failures: 6, expected: 4 * time.Second},
+		{failures: 9, expected: 32 * time.Second},
+		{failures: 10, expected: 15 * time.Minute},
+		{failures: 50, expected: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, policy.lockFor(tt.failures), "failures=%d", tt.failures)
+	}
+}
+
+func TestPolicyLockFor_CappedAtLockoutDuration(t *testing.T) {
+	policy := Policy{
+		FreeAttempts:    0,
+		BaseDelay:       time.Minute,
+		MaxFailures:     100,
+		LockoutDuration: 5 * time.Minute,
+	}
+
+	assert.Equal(t, 5*time.Minute, policy.lockFor(60))
+}
+
+func newTestLimiter(now *time.Time) *Limiter {
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return *now }
+
+	limiter := NewLimiter(store,
+		Policy{FreeAttempts: 2, BaseDelay: time.Second, MaxFailures: 5, LockoutDuration: time.Hour, Window: time.Hour},
+		Policy{FreeAttempts: 4, BaseDelay: time.Second, MaxFailures: 8, LockoutDuration: time.Hour, Window: time.Hour},
+	)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiter_AccountBackoff(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, limiter.RegisterFailure("test@mail.com", "10.0.0.1"))
+		assert.NoError(t, limiter.Check("test@mail.com", "10.0.0.1"))
+	}
+
+	assert.NoError(t, limiter.RegisterFailure("test@mail.com", "10.0.0.1"))
+
+	err := limiter.Check("test@mail.com", "10.0.0.2")
+	var lockoutErr *auth_errors.LockoutError
+	assert.ErrorAs(t, err, &lockoutErr)
+	assert.Equal(t, time.Second, lockoutErr.RetryAfter)
+
+	// the email is normalised
+	assert.Error(t, limiter.Check(" TEST@mail.com", ""))
+
+	now = now.Add(time.Second)
+	assert.NoError(t, limiter.Check("test@mail.com", "10.0.0.2"))
+
+	assert.NoError(t, limiter.RegisterFailure("test@mail.com", "10.0.0.1"))
+	err = limiter.Check("test@mail.com", "")
+	assert.ErrorAs(t, err, &lockoutErr)
+	assert.Equal(t, 2*time.Second, lockoutErr.RetryAfter)
+}
+
+func TestLimiter_IPLockoutAcrossAccounts(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	accounts := []string{"a@mail.com", "b@mail.com", "c@mail.com", "d@mail.com", "e@mail.com", "f@mail.com", "g@mail.com", "h@mail.com"}
+	for _, email := range accounts {
+		assert.NoError(t, limiter.RegisterFailure(email, "10.0.0.1"))
+	}
+
+	assert.ErrorIs(t, limiter.Check("new@mail.com", "10.0.0.1"), auth_errors.ErrTooManyLoginAttempts)
+	assert.NoError(t, limiter.Check("new@mail.com", "10.0.0.2"))
+
+	// a success on one account does not clear the address
+	assert.NoError(t, limiter.RegisterSuccess("a@mail.com"))
+	assert.Error(t, limiter.Check("new@mail.com", "10.0.0.1"))
+
+	assert.NoError(t, limiter.Unlock("", "10.0.0.1"))
+	assert.NoError(t, limiter.Check("new@mail.com", "10.0.0.1"))
+}
+
+func TestLimiter_WindowExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	assert.NoError(t, limiter.RegisterFailure("test@mail.com", ""))
+	assert.NoError(t, limiter.RegisterFailure("test@mail.com", ""))
+
+	now = now.Add(2 * time.Hour)
+
+	// old failures are forgotten, so this one is free again
+	assert.NoError(t, limiter.RegisterFailure("test@mail.com", ""))
+	assert.NoError(t, limiter.Check("test@mail.com", ""))
+}
+
+func TestLimiter_MaxFailuresLocksOut(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, limiter.RegisterFailure("test@mail.com", ""))
+	}
+
+	var lockoutErr *auth_errors.LockoutError
+	assert.ErrorAs(t, limiter.Check("test@mail.com", ""), &lockoutErr)
+	assert.Equal(t, time.Hour, lockoutErr.RetryAfter)
+
+	assert.NoError(t, limiter.Unlock("test@mail.com", ""))
+	assert.NoError(t, limiter.Check("test@mail.com", ""))
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rate := RateLimit{Limit: 3, Window: 15 * time.Minute}
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, limiter.Allow(MagicLinkKey("test@mail.com"), rate))
+	}
+
+	var lockoutErr *auth_errors.LockoutError
+	assert.ErrorAs(t, limiter.Allow(MagicLinkKey(" TEST@mail.com"), rate), &lockoutErr)
+	assert.Equal(t, 15*time.Minute, lockoutErr.RetryAfter)
+
+	// other emails and the login counters are not affected
+	assert.NoError(t, limiter.Allow(MagicLinkKey("other@mail.com"), rate))
+	assert.NoError(t, limiter.Check("test@mail.com", ""))
+
+	now = now.Add(15 * time.Minute)
+	assert.NoError(t, limiter.Allow(MagicLinkKey("test@mail.com"), rate))
+}
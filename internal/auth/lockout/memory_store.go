@@ -0,0 +1,141 @@
+package auth_lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often writes also purge the whole map.
+const memorySweepInterval = time.Minute
+
+type memoryEntry struct {
+	attempt Attempt
+	window  time.Duration
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return now.Sub(e.attempt.LastFailureAt) > e.window && now.After(e.attempt.LockedUntil)
+}
+
+// memoryStore keeps attempts in process memory. Counters are not shared between
+// instances and are lost on restart, use the postgres store when running more than one.
+type memoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Get(key string) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.lookup(key)
+	if entry == nil {
+		return nil, nil
+	}
+
+	attempt := entry.attempt
+	return &attempt, nil
+}
+
+func (s *memoryStore) RecordFailure(key string, window time.Duration) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweepIfDue(now)
+
+	entry := s.lookup(key)
+	if entry == nil {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+
+	if now.Sub(entry.attempt.LastFailureAt) > window {
+		entry.attempt.Failures = 0
+	}
+
+	entry.attempt.Failures++
+	entry.attempt.LastFailureAt = now
+	entry.window = window
+
+	attempt := entry.attempt
+	return &attempt, nil
+}
+
+func (s *memoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweepIfDue(s.now())
+
+	entry := s.lookup(key)
+	if entry == nil {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+
+	entry.attempt.LockedUntil = until
+	return nil
+}
+
+func (s *memoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStore) Purge() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sweep(s.now()), nil
+}
+
+// lookup treats an expired entry as missing and drops it. That only covers keys that
+// are seen again, the sweep takes care of the others.
+func (s *memoryStore) lookup(key string) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if entry.expired(s.now()) {
+		delete(s.entries, key)
+		return nil
+	}
+
+	return entry
+}
+
+// sweepIfDue runs the sweep at most once per memorySweepInterval, from the writes that
+// add entries, so the map doesn't grow with every address that ever failed a login and
+// never came back.
+func (s *memoryStore) sweepIfDue(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.sweep(now)
+}
+
+func (s *memoryStore) sweep(now time.Time) int64 {
+	var removed int64
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, key)
+			removed++
+		}
+	}
+	s.lastSweep = now
+	return removed
+}
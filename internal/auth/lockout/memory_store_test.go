@@ -0,0 +1,100 @@
+package auth_lockout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	attempt, err := store.Get("ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	attempt, err = store.RecordFailure("ip:10.0.0.1", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+
+	attempt, err = store.RecordFailure("ip:10.0.0.1", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempt.Failures)
+
+	assert.NoError(t, store.Lock("ip:10.0.0.1", now.Add(time.Hour)))
+
+	attempt, err = store.Get("ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), attempt.LockedUntil)
+
+	// the window passed but the lock is still active, so the entry is kept
+	now = now.Add(30 * time.Minute)
+	attempt, err = store.Get("ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.NotNil(t, attempt)
+
+	// window and lock both expired
+	now = now.Add(time.Hour)
+	attempt, err = store.Get("ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+	assert.Empty(t, store.entries)
+}
+
+func TestMemoryStore_Reset(t *testing.T) {
+	store := NewMemoryStore()
+
+	_, err := store.RecordFailure("account:test@mail.com", time.Minute)
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Reset("account:test@mail.com"))
+
+	attempt, err := store.Get("account:test@mail.com")
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+}
+
+func TestMemoryStore_SweepsKeysNotSeenAgain(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	for _, key := range []string{"ip:10.0.0.1", "ip:10.0.0.2", "account:test@mail.com"} {
+		_, err := store.RecordFailure(key, time.Minute)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, store.Lock("account:test@mail.com", now.Add(time.Hour)))
+
+	// the windows are over but the lock is not, only the lock survives
+	now = now.Add(2 * memorySweepInterval)
+	_, err := store.RecordFailure("ip:10.0.0.3", time.Minute)
+	assert.NoError(t, err)
+
+	assert.Len(t, store.entries, 2)
+	assert.Contains(t, store.entries, "account:test@mail.com")
+	assert.Contains(t, store.entries, "ip:10.0.0.3")
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	_, err := store.RecordFailure("ip:10.0.0.1", time.Minute)
+	assert.NoError(t, err)
+	_, err = store.RecordFailure("ip:10.0.0.2", time.Hour)
+	assert.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	removed, err := store.Purge()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+	assert.Len(t, store.entries, 1)
+}
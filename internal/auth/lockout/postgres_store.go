@@ -0,0 +1,127 @@
+package auth_lockout
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	getAttemptQuery = `SELECT failures, last_failure_at, locked_until
+	FROM login_attempts
+	WHERE key = $1`
+
+	// expires_at is when the failures are forgotten, Purge also waits for the lock
+	recordFailureQuery = `INSERT INTO login_attempts (key, failures, last_failure_at, expires_at)
+	VALUES ($1, 1, now(), now() + make_interval(secs => $2))
+	ON CONFLICT (key) DO UPDATE
+	SET
+	failures = CASE
+		WHEN login_attempts.last_failure_at < now() - make_interval(secs => $2) THEN 1
+		ELSE login_attempts.failures + 1
+	END,
+	last_failure_at = now(),
+	expires_at = now() + make_interval(secs => $2)
+	RETURNING failures, last_failure_at, locked_until`
+
+	lockQuery = `INSERT INTO login_attempts (key, failures, last_failure_at, locked_until)
+	VALUES ($1, 0, now(), $2)
+	ON CONFLICT (key) DO UPDATE
+	SET locked_until = $2`
+
+	resetQuery = `DELETE FROM login_attempts
+	WHERE key = $1`
+
+	purgeQuery = `DELETE FROM login_attempts
+	WHERE expires_at < now()
+	AND (locked_until IS NULL OR locked_until < now())`
+)
+
+type postgresStore struct {
+	Db *sql.DB
+}
+
+func NewPostgresStore(Db *sql.DB) Store {
+	return &postgresStore{
+		Db: Db,
+	}
+}
+
+func (s *postgresStore) Get(key string) (*Attempt, error) {
+	ctx := context.Background()
+
+	row := s.Db.QueryRowContext(ctx, getAttemptQuery, key)
+
+	attempt, err := scanAttempt(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		logger.Log.Error("error getting login attempts", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (s *postgresStore) RecordFailure(key string, window time.Duration) (*Attempt, error) {
+	ctx := context.Background()
+
+	row := s.Db.QueryRowContext(ctx, recordFailureQuery, key, window.Seconds())
+
+	attempt, err := scanAttempt(row)
+	if err != nil {
+		logger.Log.Error("error recording login failure", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (s *postgresStore) Lock(key string, until time.Time) error {
+	ctx := context.Background()
+
+	_, err := s.Db.ExecContext(ctx, lockQuery, key, until)
+	if err != nil {
+		logger.Log.Error("error locking login", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *postgresStore) Reset(key string) error {
+	ctx := context.Background()
+
+	_, err := s.Db.ExecContext(ctx, resetQuery, key)
+	if err != nil {
+		logger.Log.Error("error resetting login attempts", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *postgresStore) Purge() (int64, error) {
+	ctx := context.Background()
+
+	result, err := s.Db.ExecContext(ctx, purgeQuery)
+	if err != nil {
+		logger.Log.Error("error purging login attempts", zap.String("error", err.Error()))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanAttempt(row *sql.Row) (*Attempt, error) {
+	var attempt Attempt
+	var lockedUntil sql.NullTime
+
+	if err := row.Scan(&attempt.Failures, &attempt.LastFailureAt, &lockedUntil); err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		attempt.LockedUntil = lockedUntil.Time
+	}
+	return &attempt, nil
+}
@@ -0,0 +1,144 @@
+package auth_lockout
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresStoreGet(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		mockError   error
+		expected    *Attempt
+		expectError bool
+	}{
+		{
+			name: "locked",
+			rows: sqlmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).
+				AddRow(4, now, now.Add(time.Minute)),
+			expected: &Attempt{Failures: 4, LastFailureAt: now, LockedUntil: now.Add(time.Minute)},
+		},
+		{
+			name: "not locked",
+			rows: sqlmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).
+				AddRow(1, now, nil),
+			expected: &Attempt{Failures: 1, LastFailureAt: now},
+		},
+		{
+			name:      "no attempts",
+			mockError: sql.ErrNoRows,
+			expected:  nil,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			store := NewPostgresStore(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getAttemptQuery)).WithArgs("ip:10.0.0.1")
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.rows)
+			}
+
+			attempt, err := store.Get("ip:10.0.0.1")
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, attempt)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresStoreRecordFailure(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(recordFailureQuery)).
+		WithArgs("account:test@mail.com", float64(900)).
+		WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).
+			AddRow(3, now, nil))
+
+	attempt, err := store.RecordFailure("account:test@mail.com", 15*time.Minute)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempt.Failures)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStoreLockAndReset(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	until := time.Now().Add(time.Hour)
+
+	mock.ExpectExec(regexp.QuoteMeta(lockQuery)).
+		WithArgs("account:test@mail.com", until).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(resetQuery)).
+		WithArgs("account:test@mail.com").
+		WillReturnError(fmt.Errorf("db error"))
+
+	assert.NoError(t, store.Lock("account:test@mail.com", until))
+	assert.Error(t, store.Reset("account:test@mail.com"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStorePurge(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+
+	mock.ExpectExec(regexp.QuoteMeta(purgeQuery)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta(purgeQuery)).
+		WillReturnError(fmt.Errorf("db error"))
+
+	removed, err := store.Purge()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), removed)
+
+	_, err = store.Purge()
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,25 @@
+package auth_lockout
+
+import "time"
+
+// Attempt is the failed-login state tracked for a single key.
+type Attempt struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Store keeps failed-login attempts per key ("account:<email>" or "ip:<addr>"),
+// and rate limited requests such as "magic_link:<email>".
+type Store interface {
+	// Get returns nil when the key has no recorded failures.
+	Get(key string) (*Attempt, error)
+	// RecordFailure counts a new failure. The counter restarts when the previous
+	// failure is older than window.
+	RecordFailure(key string, window time.Duration) (*Attempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+	// Purge removes the keys whose failures and lock have both expired, and returns
+	// how many it removed.
+	Purge() (int64, error)
+}
@@ -0,0 +1,47 @@
+package auth_lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Sweeper purges the expired keys of a store. The memory store sweeps itself, the
+// postgres one relies on the worker running this.
+type Sweeper struct {
+	store    Store
+	interval time.Duration
+}
+
+func NewSweeper(store Store) *Sweeper {
+	return &Sweeper{
+		store:    store,
+		interval: time.Duration(config.GetInt("AUTH_LOCKOUT_PURGE_INTERVAL_MINUTES")) * time.Minute,
+	}
+}
+
+// Start purges right away and then once per interval until ctx is done.
+func (s *Sweeper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.store.Purge(); err != nil {
+			logger.Log.Error("failed to purge login attempts", zap.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop has nothing to release, Start returns as soon as its context is done.
+func (s *Sweeper) Stop(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package auth_oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"golang.org/x/oauth2"
+)
+
+const (
+	githubBaseURL = "https://github.com"
+	githubAPIURL  = "https://api.github.com"
+)
+
+// githubProvider speaks plain OAuth2: GitHub has no ID token, the identity comes
+// from the REST API. Nonce is not used, the state alone protects the flow.
+type githubProvider struct {
+	name   string
+	oauth  *oauth2.Config
+	apiURL string
+}
+
+func NewGitHubProvider(cfg ProviderConfig) Provider {
+	baseURL, apiURL := githubBaseURL, githubAPIURL
+	if cfg.IssuerURL != "" {
+		baseURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+		apiURL = baseURL + "/api/v3"
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+
+	return &githubProvider{
+		name: cfg.Name,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  baseURL + "/login/oauth/authorize",
+				TokenURL: baseURL + "/login/oauth/access_token",
+			},
+		},
+		apiURL: apiURL,
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return p.name
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	return p.oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code string, nonce string, codeVerifier string) (*auth_types.ExternalIdentity, error) {
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	client := p.oauth.Client(ctx, token)
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := p.get(ctx, client, "/user", &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.get(ctx, client, "/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &auth_types.ExternalIdentity{
+		Provider: p.name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+	}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email = e.Email
+			identity.EmailVerified = e.Verified
+		}
+	}
+
+	return identity, nil
+}
+
+func (p *githubProvider) get(ctx context.Context, client *http.Client, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github api %s: %s", path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
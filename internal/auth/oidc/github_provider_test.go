@@ -0,0 +1,66 @@
+package auth_oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestGitHubProvider(t *testing.T) {
+	var gotVerifier string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		gotVerifier = r.PostForm.Get("code_verifier")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "gho_token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/api/v3/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gho_token", r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 42, "login": "octocat", "name": ""})
+	})
+	mux.HandleFunc("/api/v3/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "octo@example.com", "primary": true, "verified": true},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := NewGitHubProvider(ProviderConfig{
+		Name:         "github",
+		IssuerURL:    server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oauth/github/callback",
+	})
+
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "", verifier)
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/login/oauth/authorize", parsed.Path)
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+
+	identity, err := provider.Exchange(context.Background(), "code", "", verifier)
+
+	require.NoError(t, err)
+	assert.Equal(t, verifier, gotVerifier)
+	assert.Equal(t, "42", identity.Subject)
+	assert.Equal(t, "octocat", identity.Name)
+	assert.Equal(t, "octo@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+}
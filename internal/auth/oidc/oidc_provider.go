@@ -0,0 +1,109 @@
+package auth_oidc
+
+import (
+	"context"
+	"sync"
+
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+type oidcProvider struct {
+	cfg ProviderConfig
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider returns a provider for any OpenID Connect issuer. Discovery runs on
+// first use and is retried until it succeeds, so a provider that is down at startup
+// doesn't keep the API from booting.
+func NewOIDCProvider(cfg ProviderConfig) Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	return &oidcProvider{cfg: cfg}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.cfg.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+
+	return p.oauth, p.verifier, nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	oauth, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier), oidc.Nonce(nonce)), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code string, nonce string, codeVerifier string) (*auth_types.ExternalIdentity, error) {
+	oauth, verifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &auth_types.ExternalIdentity{
+		Provider:      p.cfg.Name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package auth_oidc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/celio001/prodify/internal/auth/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Server, Provider) {
+	server := oidctest.NewServer(t, "prodify", "secret")
+	server.SetUser(oidctest.User{
+		Subject:       "user-1",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	})
+
+	provider := NewOIDCProvider(ProviderConfig{
+		Name:         "fake",
+		IssuerURL:    server.URL,
+		ClientID:     "prodify",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oauth/fake/callback",
+	})
+
+	return server, provider
+}
+
+func TestOIDCProvider_Login(t *testing.T) {
+	server, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	code, state, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	identity, err := provider.Exchange(ctx, code, "nonce-1", verifier)
+
+	require.NoError(t, err)
+	assert.Equal(t, "fake", identity.Provider)
+	assert.Equal(t, "user-1", identity.Subject)
+	assert.Equal(t, "jane@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "Jane Doe", identity.Name)
+}
+
+func TestOIDCProvider_NonceMismatch(t *testing.T) {
+	server, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	code, _, err := server.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(ctx, code, "another-nonce", verifier)
+
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestOIDCProvider_WrongCodeVerifier(t *testing.T) {
+	server, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", oauth2.GenerateVerifier())
+	require.NoError(t, err)
+
+	code, _, err := server.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(ctx, code, "nonce-1", oauth2.GenerateVerifier())
+
+	assert.Error(t, err)
+}
+
+func TestOIDCProvider_DiscoveryFailure(t *testing.T) {
+	provider := NewOIDCProvider(ProviderConfig{
+		Name:      "down",
+		IssuerURL: "http://127.0.0.1:1",
+		ClientID:  "prodify",
+	})
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", oauth2.GenerateVerifier())
+
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "google, github, acme")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "google-client")
+	t.Setenv("OIDC_GITHUB_CLIENT_ID", "github-client")
+	t.Setenv("OIDC_ACME_CLIENT_ID", "acme-client")
+	t.Setenv("OIDC_ACME_ISSUER", "https://login.acme.test")
+
+	registry, err := New()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme", "github", "google"}, registry.Names())
+
+	_, err = registry.Get("facebook")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestNew_MissingIssuer(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "acme")
+	t.Setenv("OIDC_ACME_CLIENT_ID", "acme-client")
+
+	_, err := New()
+
+	assert.ErrorIs(t, err, ErrMissingIssuer)
+}
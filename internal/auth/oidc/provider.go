@@ -0,0 +1,112 @@
+package auth_oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/celio001/prodify/config"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrMissingIssuer   = errors.New("identity provider issuer is not configured")
+	ErrMissingClient   = errors.New("identity provider client id is not configured")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+)
+
+const googleIssuer = "https://accounts.google.com"
+
+// Provider is one external identity provider. AuthCodeURL starts the
+// authorization-code flow with PKCE, Exchange finishes it and returns the
+// identity the provider vouches for.
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code string, nonce string, codeVerifier string) (*auth_types.ExternalIdentity, error)
+}
+
+// ProviderConfig describes a provider. For OIDC providers IssuerURL is used for
+// discovery, for GitHub it is the GitHub Enterprise base URL and empty means github.com.
+type ProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Registry holds the configured providers by name.
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the registry from config. OIDC_PROVIDERS is a comma separated list of
+// names, each one reads OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and
+// OIDC_<NAME>_ISSUER. "github" is handled as plain OAuth2, "google" defaults its issuer.
+func New() (*Registry, error) {
+	var providers []Provider
+
+	for _, name := range strings.Split(config.GetString("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := ProviderConfig{
+			Name:         name,
+			IssuerURL:    config.GetString(prefix + "ISSUER"),
+			ClientID:     config.GetString(prefix + "CLIENT_ID"),
+			ClientSecret: config.GetString(prefix + "CLIENT_SECRET"),
+			RedirectURL:  strings.TrimSuffix(config.GetString("OIDC_REDIRECT_BASE_URL"), "/") + "/" + name + "/callback",
+		}
+		if cfg.ClientID == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingClient, name)
+		}
+
+		switch name {
+		case "github":
+			providers = append(providers, NewGitHubProvider(cfg))
+		default:
+			if cfg.IssuerURL == "" && name == "google" {
+				cfg.IssuerURL = googleIssuer
+			}
+			if cfg.IssuerURL == "" {
+				return nil, fmt.Errorf("%w: %s", ErrMissingIssuer, name)
+			}
+			providers = append(providers, NewOIDCProvider(cfg))
+		}
+	}
+
+	return NewRegistry(providers...), nil
+}
@@ -0,0 +1,63 @@
+package auth_repository
+
+import (
+	"context"
+	"database/sql"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	getUserIDByIdentityQuery = `SELECT user_id
+	FROM user_identities
+	WHERE provider = $1
+	AND subject = $2`
+
+	linkIdentityQuery = `INSERT INTO user_identities (user_id, provider, subject, email)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (provider, subject) DO NOTHING`
+)
+
+type identityRepository struct {
+	Db *sql.DB
+}
+
+// IdentityRepository links external identities, keyed by provider and subject, to users.
+type IdentityRepository interface {
+	GetUserIDByIdentity(provider string, subject string) (int64, error)
+	LinkIdentity(user_id int64, provider string, subject string, email string) error
+}
+
+func NewIdentityRepository(Db *sql.DB) IdentityRepository {
+	return &identityRepository{
+		Db: Db,
+	}
+}
+
+func (r *identityRepository) GetUserIDByIdentity(provider string, subject string) (int64, error) {
+	ctx := context.Background()
+
+	var userID int64
+	err := r.Db.QueryRowContext(ctx, getUserIDByIdentityQuery, provider, subject).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, auth_errors.ErrIdentityNotFound
+		}
+		logger.Log.Error("error getting external identity", zap.String("provider", provider), zap.String("error", err.Error()))
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (r *identityRepository) LinkIdentity(user_id int64, provider string, subject string, email string) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, linkIdentityQuery, user_id, provider, subject, email)
+	if err != nil {
+		logger.Log.Error("error linking external identity", zap.String("provider", provider), zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package auth_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserIDByIdentity(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		mockError   error
+		expectID    int64
+		expectError error
+	}{
+		{
+			name:     "linked",
+			expectID: 7,
+		},
+		{
+			name:        "not linked",
+			mockError:   sql.ErrNoRows,
+			expectError: auth_errors.ErrIdentityNotFound,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewIdentityRepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getUserIDByIdentityQuery)).WithArgs("google", "sub-1")
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(tt.expectID))
+			}
+
+			userID, err := repo.GetUserIDByIdentity("google", "sub-1")
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectID, userID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewIdentityRepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta(linkIdentityQuery)).
+		WithArgs(int64(7), "github", "42", "octo@example.com").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = repo.LinkIdentity(7, "github", "42", "octo@example.com")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
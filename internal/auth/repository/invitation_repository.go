@@ -0,0 +1,253 @@
+package auth_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	revokePendingUserInvitationsQuery = `UPDATE user_invitations
+	SET revoked_at = now()
+	WHERE lower(email) = lower($1)
+	AND accepted_at IS NULL
+	AND revoked_at IS NULL`
+
+	createUserInvitationQuery = `INSERT INTO user_invitations (email, role, token_hash, invited_by, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, public_id, created_at`
+
+	listUserInvitationsQuery = `SELECT i.id, i.public_id, i.email, i.role, COALESCE(u.public_id::text, ''),
+	i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+	FROM user_invitations i
+	LEFT JOIN users u ON u.id = i.invited_by
+	ORDER BY i.created_at DESC`
+
+	revokeUserInvitationQuery = `UPDATE user_invitations
+	SET revoked_at = now()
+	WHERE public_id = $1
+	AND accepted_at IS NULL
+	AND revoked_at IS NULL`
+
+	getUserInvitationByTokenHashQuery = `SELECT id, public_id, email, role, created_at, expires_at, accepted_at, revoked_at
+	FROM user_invitations
+	WHERE token_hash = $1`
+
+	acceptUserInvitationQuery = `UPDATE user_invitations
+	SET accepted_at = now()
+	WHERE id = $1
+	AND accepted_at IS NULL
+	AND revoked_at IS NULL
+	AND expires_at > now()
+	RETURNING email, role`
+
+	// the link went to the address, it counts as verified
+	createInvitedUserQuery = `INSERT INTO users (name, email, password_hash, is_active, role, email_verified_at)
+	VALUES ($1, $2, $3, true, $4, now())
+	RETURNING id`
+
+	setInvitationUserQuery = `UPDATE user_invitations
+	SET user_id = $2
+	WHERE id = $1`
+)
+
+type invitationRepository struct {
+	Db *sql.DB
+}
+
+// InvitationRepository keeps the accounts admins invited people to create. Only the hash
+// of the link token is stored.
+type InvitationRepository interface {
+	CreateInvitation(invitation auth_types.Invitation) (*auth_types.Invitation, error)
+	ListInvitations() ([]auth_types.Invitation, error)
+	RevokeInvitation(invitationPublicID uuid.UUID) error
+	GetInvitationByTokenHash(tokenHash string) (*auth_types.Invitation, error)
+	AcceptInvitation(invitation_id int64, name string, passwordHash string) (int64, error)
+}
+
+func NewInvitationRepository(Db *sql.DB) InvitationRepository {
+	return &invitationRepository{
+		Db: Db,
+	}
+}
+
+// CreateInvitation replaces any invitation still open for the same address, only the
+// newest link works.
+func (r *invitationRepository) CreateInvitation(invitation auth_types.Invitation) (*auth_types.Invitation, error) {
+	ctx := context.Background()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("error starting user invitation creation", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, revokePendingUserInvitationsQuery, invitation.Email); err != nil {
+		logger.Log.Error("error revoking pending user invitations", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, createUserInvitationQuery,
+		invitation.Email,
+		invitation.Role,
+		invitation.TokenHash,
+		invitation.InvitedBy,
+		invitation.ExpiresAt).Scan(&invitation.ID, &invitation.PublicID, &invitation.CreatedAt)
+	if err != nil {
+		logger.Log.Error("error creating user invitation", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) ListInvitations() ([]auth_types.Invitation, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, listUserInvitationsQuery)
+	if err != nil {
+		logger.Log.Error("error listing user invitations", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []auth_types.Invitation{}
+	for rows.Next() {
+		var invitation auth_types.Invitation
+		var acceptedAt, revokedAt sql.NullTime
+
+		err := rows.Scan(
+			&invitation.ID,
+			&invitation.PublicID,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.Inviter,
+			&invitation.CreatedAt,
+			&invitation.ExpiresAt,
+			&acceptedAt,
+			&revokedAt)
+		if err != nil {
+			logger.Log.Error("error scanning user invitation", zap.String("error", err.Error()))
+			return nil, err
+		}
+
+		invitation.AcceptedAt = nullTime(acceptedAt)
+		invitation.RevokedAt = nullTime(revokedAt)
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+// RevokeInvitation only revokes pending invitations, an accepted or revoked one is not
+// found.
+func (r *invitationRepository) RevokeInvitation(invitationPublicID uuid.UUID) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, revokeUserInvitationQuery, invitationPublicID)
+	if err != nil {
+		logger.Log.Error("error revoking user invitation", zap.String("error", err.Error()))
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return auth_errors.ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *invitationRepository) GetInvitationByTokenHash(tokenHash string) (*auth_types.Invitation, error) {
+	ctx := context.Background()
+
+	var invitation auth_types.Invitation
+	var acceptedAt, revokedAt sql.NullTime
+
+	err := r.Db.QueryRowContext(ctx, getUserInvitationByTokenHashQuery, tokenHash).Scan(
+		&invitation.ID,
+		&invitation.PublicID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.CreatedAt,
+		&invitation.ExpiresAt,
+		&acceptedAt,
+		&revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth_errors.ErrInvalidInvitation
+		}
+		logger.Log.Error("error getting user invitation", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	invitation.AcceptedAt = nullTime(acceptedAt)
+	invitation.RevokedAt = nullTime(revokedAt)
+	return &invitation, nil
+}
+
+// AcceptInvitation uses up the invitation and creates its account in one transaction,
+// with the invitation's email and role. The invitation is only used once, even when two
+// requests race with the same link. It returns the new user's id.
+func (r *invitationRepository) AcceptInvitation(invitation_id int64, name string, passwordHash string) (int64, error) {
+	ctx := context.Background()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("error starting user invitation acceptance", zap.String("error", err.Error()))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var email, role string
+	err = tx.QueryRowContext(ctx, acceptUserInvitationQuery, invitation_id).Scan(&email, &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, auth_errors.ErrInvalidInvitation
+		}
+		logger.Log.Error("error accepting user invitation", zap.String("error", err.Error()))
+		return 0, err
+	}
+
+	var userID int64
+	err = tx.QueryRowContext(ctx, createInvitedUserQuery, name, email, passwordHash, role).Scan(&userID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, auth_errors.ErrUserAlreadyExists
+		}
+		logger.Log.Error("error creating invited user", zap.String("error", err.Error()))
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, setInvitationUserQuery, invitation_id, userID); err != nil {
+		logger.Log.Error("error linking user invitation", zap.String("error", err.Error()))
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
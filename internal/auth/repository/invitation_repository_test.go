@@ -0,0 +1,203 @@
+package auth_repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateUserInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewInvitationRepository(db)
+
+	adminID := int64(1)
+	expiresAt := time.Now().Add(72 * time.Hour)
+	publicID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(revokePendingUserInvitationsQuery)).
+		WithArgs("bob@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(createUserInvitationQuery)).
+		WithArgs("bob@example.com", "admin", "hash", &adminID, expiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "created_at"}).AddRow(5, publicID.String(), time.Now()))
+	mock.ExpectCommit()
+
+	invitation, err := repo.CreateInvitation(auth_types.Invitation{
+		Email:     "bob@example.com",
+		Role:      "admin",
+		TokenHash: "hash",
+		InvitedBy: &adminID,
+		ExpiresAt: expiresAt,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), invitation.ID)
+	assert.Equal(t, publicID.String(), invitation.PublicID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListUserInvitations(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewInvitationRepository(db)
+
+	now := time.Now()
+	admin := uuid.NewString()
+
+	mock.ExpectQuery(regexp.QuoteMeta(listUserInvitationsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "email", "role", "inviter", "created_at", "expires_at", "accepted_at", "revoked_at"}).
+			AddRow(2, uuid.NewString(), "bob@example.com", "user", admin, now, now.Add(time.Hour), nil, nil).
+			AddRow(1, uuid.NewString(), "ann@example.com", "admin", "", now, now.Add(time.Hour), now, nil))
+
+	invitations, err := repo.ListInvitations()
+
+	assert.NoError(t, err)
+	assert.Len(t, invitations, 2)
+	assert.Equal(t, admin, invitations[0].Inviter)
+	assert.Nil(t, invitations[0].AcceptedAt)
+	assert.NotNil(t, invitations[1].AcceptedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeUserInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		affected    int64
+		expectError error
+	}{
+		{name: "pending", affected: 1},
+		{name: "already accepted or revoked", affected: 0, expectError: auth_errors.ErrInvitationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewInvitationRepository(db)
+			id := uuid.New()
+
+			mock.ExpectExec(regexp.QuoteMeta(revokeUserInvitationQuery)).
+				WithArgs(id).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			err = repo.RevokeInvitation(id)
+
+			assert.Equal(t, tt.expectError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUserInvitationByTokenHash(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewInvitationRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getUserInvitationByTokenHashQuery)).
+		WithArgs("unknown").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = repo.GetInvitationByTokenHash("unknown")
+
+	assert.Equal(t, auth_errors.ErrInvalidInvitation, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcceptUserInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("creates the account", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewInvitationRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(acceptUserInvitationQuery)).
+			WithArgs(int64(5)).
+			WillReturnRows(sqlmock.NewRows([]string{"email", "role"}).AddRow("bob@example.com", "admin"))
+		mock.ExpectQuery(regexp.QuoteMeta(createInvitedUserQuery)).
+			WithArgs("Bob", "bob@example.com", "hashed", "admin").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectExec(regexp.QuoteMeta(setInvitationUserQuery)).
+			WithArgs(int64(5), int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		userID, err := repo.AcceptInvitation(5, "Bob", "hashed")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), userID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("used, revoked or expired", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewInvitationRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(acceptUserInvitationQuery)).
+			WithArgs(int64(5)).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err = repo.AcceptInvitation(5, "Bob", "hashed")
+
+		assert.Equal(t, auth_errors.ErrInvalidInvitation, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("email taken in the meantime", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewInvitationRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(acceptUserInvitationQuery)).
+			WithArgs(int64(5)).
+			WillReturnRows(sqlmock.NewRows([]string{"email", "role"}).AddRow("bob@example.com", "user"))
+		mock.ExpectQuery(regexp.QuoteMeta(createInvitedUserQuery)).
+			WithArgs("Bob", "bob@example.com", "hashed", "user").
+			WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		_, err = repo.AcceptInvitation(5, "Bob", "hashed")
+
+		// the invitation stays pending, the rollback undid accepted_at
+		assert.Equal(t, auth_errors.ErrUserAlreadyExists, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
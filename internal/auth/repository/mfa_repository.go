@@ -0,0 +1,187 @@
+package auth_repository
+
+import (
+	"context"
+	"database/sql"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	savePendingMFAQuery = `INSERT INTO user_mfa (user_id, secret)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE
+	SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = now()
+	WHERE user_mfa.enabled_at IS NULL`
+
+	getMFAQuery = `SELECT secret, enabled_at IS NOT NULL, COALESCE(last_used_step, 0)
+	FROM user_mfa
+	WHERE user_id = $1`
+
+	enableMFAQuery = `UPDATE user_mfa
+	SET enabled_at = now()
+	WHERE user_id = $1
+	AND enabled_at IS NULL`
+
+	deleteRecoveryCodesQuery = `DELETE FROM mfa_recovery_codes
+	WHERE user_id = $1`
+
+	createRecoveryCodeQuery = `INSERT INTO mfa_recovery_codes (user_id, code_hash)
+	VALUES ($1, $2)`
+
+	// only moves forward, so a code can't be replayed inside its validity window
+	useTOTPStepQuery = `UPDATE user_mfa
+	SET last_used_step = $2
+	WHERE user_id = $1
+	AND enabled_at IS NOT NULL
+	AND (last_used_step IS NULL OR last_used_step < $2)`
+
+	consumeRecoveryCodeQuery = `UPDATE mfa_recovery_codes
+	SET used_at = now()
+	WHERE user_id = $1
+	AND code_hash = $2
+	AND used_at IS NULL`
+
+	deleteMFAQuery = `DELETE FROM user_mfa
+	WHERE user_id = $1`
+)
+
+type mfaRepository struct {
+	Db *sql.DB
+}
+
+type MFARepository interface {
+	SavePendingSecret(user_id int64, secret string) error
+	GetMFA(user_id int64) (*auth_types.MFA, error)
+	EnableMFA(user_id int64, recoveryCodeHashes []string) error
+	UseTOTPStep(user_id int64, step int64) (bool, error)
+	ConsumeRecoveryCode(user_id int64, codeHash string) (bool, error)
+	DeleteMFA(user_id int64) error
+}
+
+func NewMFARepository(Db *sql.DB) MFARepository {
+	return &mfaRepository{
+		Db: Db,
+	}
+}
+
+// SavePendingSecret starts (or restarts) an enrollment. An enabled MFA is never overwritten.
+func (r *mfaRepository) SavePendingSecret(user_id int64, secret string) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, savePendingMFAQuery, user_id, secret)
+	if err != nil {
+		logger.Log.Error("error saving mfa secret", zap.String("error", err.Error()))
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return auth_errors.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *mfaRepository) GetMFA(user_id int64) (*auth_types.MFA, error) {
+	ctx := context.Background()
+
+	var mfa auth_types.MFA
+	err := r.Db.QueryRowContext(ctx, getMFAQuery, user_id).Scan(&mfa.Secret, &mfa.Enabled, &mfa.LastUsedStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth_errors.ErrMFANotEnrolled
+		}
+		logger.Log.Error("error getting mfa", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// EnableMFA activates the pending secret and replaces the recovery codes in one transaction.
+func (r *mfaRepository) EnableMFA(user_id int64, recoveryCodeHashes []string) error {
+	ctx := context.Background()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("error starting mfa enable", zap.String("error", err.Error()))
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, enableMFAQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error enabling mfa", zap.String("error", err.Error()))
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return auth_errors.ErrMFAAlreadyEnabled
+	}
+
+	if _, err = tx.ExecContext(ctx, deleteRecoveryCodesQuery, user_id); err != nil {
+		logger.Log.Error("error deleting recovery codes", zap.String("error", err.Error()))
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err = tx.ExecContext(ctx, createRecoveryCodeQuery, user_id, hash); err != nil {
+			logger.Log.Error("error saving recovery code", zap.String("error", err.Error()))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *mfaRepository) UseTOTPStep(user_id int64, step int64) (bool, error) {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, useTOTPStepQuery, user_id, step)
+	if err != nil {
+		logger.Log.Error("error using totp step", zap.String("error", err.Error()))
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *mfaRepository) ConsumeRecoveryCode(user_id int64, codeHash string) (bool, error) {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, consumeRecoveryCodeQuery, user_id, codeHash)
+	if err != nil {
+		logger.Log.Error("error consuming recovery code", zap.String("error", err.Error()))
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *mfaRepository) DeleteMFA(user_id int64) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, deleteMFAQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error deleting mfa", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
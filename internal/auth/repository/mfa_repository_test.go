@@ -0,0 +1,254 @@
+package auth_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavePendingSecret(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		affected    int64
+		mockError   error
+		expectError error
+	}{
+		{
+			name:     "success",
+			affected: 1,
+		},
+		{
+			name:        "already enabled",
+			affected:    0,
+			expectError: auth_errors.ErrMFAAlreadyEnabled,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewMFARepository(db)
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(savePendingMFAQuery)).WithArgs(int64(1), "SECRET")
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			err = repo.SavePendingSecret(1, "SECRET")
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetMFA(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expected    *auth_types.MFA
+		expectError error
+	}{
+		{
+			name:     "success",
+			mockRows: sqlmock.NewRows([]string{"secret", "enabled", "last_used_step"}).AddRow("SECRET", true, 42),
+			expected: &auth_types.MFA{Secret: "SECRET", Enabled: true, LastUsedStep: 42},
+		},
+		{
+			name:        "not enrolled",
+			mockError:   sql.ErrNoRows,
+			expectError: auth_errors.ErrMFANotEnrolled,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewMFARepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getMFAQuery)).WithArgs(int64(1))
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			mfa, err := repo.GetMFA(1)
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+				assert.Nil(t, mfa)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, mfa)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestEnableMFA(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("success", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewMFARepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(enableMFAQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(deleteRecoveryCodesQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(createRecoveryCodeQuery)).WithArgs(int64(1), "hash-1").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(createRecoveryCodeQuery)).WithArgs(int64(1), "hash-2").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		err = repo.EnableMFA(1, []string{"hash-1", "hash-2"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		repo := NewMFARepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(enableMFAQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = repo.EnableMFA(1, []string{"hash-1"})
+
+		assert.ErrorIs(t, err, auth_errors.ErrMFAAlreadyEnabled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name     string
+		affected int64
+		expected bool
+	}{
+		{name: "new step", affected: 1, expected: true},
+		{name: "replayed step", affected: 0, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewMFARepository(db)
+
+			mock.ExpectExec(regexp.QuoteMeta(useTOTPStepQuery)).
+				WithArgs(int64(1), int64(100)).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			ok, err := repo.UseTOTPStep(1, 100)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestConsumeRecoveryCode(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		affected    int64
+		mockError   error
+		expected    bool
+		expectError bool
+	}{
+		{name: "valid code", affected: 1, expected: true},
+		{name: "used or unknown code", affected: 0, expected: false},
+		{name: "database error", mockError: fmt.Errorf("db error"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewMFARepository(db)
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(consumeRecoveryCodeQuery)).WithArgs(int64(1), "hash")
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			ok, err := repo.ConsumeRecoveryCode(1, "hash")
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, ok)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteMFA(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMFARepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta(deleteMFAQuery)).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.DeleteMFA(1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,19 @@
+package auth_repository_mock
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) GetUserIDByIdentity(provider string, subject string) (int64, error) {
+	args := m.Called(provider, subject)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockIdentityRepository) LinkIdentity(userID int64, provider string, subject string, email string) error {
+	args := m.Called(userID, provider, subject, email)
+	return args.Error(0)
+}
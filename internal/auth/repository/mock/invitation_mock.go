@@ -0,0 +1,45 @@
+package auth_repository_mock
+
+import (
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInvitationRepository struct {
+	mock.Mock
+}
+
+func (m *MockInvitationRepository) CreateInvitation(invitation auth_types.Invitation) (*auth_types.Invitation, error) {
+	args := m.Called(invitation)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) ListInvitations() ([]auth_types.Invitation, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth_types.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) RevokeInvitation(invitationPublicID uuid.UUID) error {
+	args := m.Called(invitationPublicID)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) GetInvitationByTokenHash(tokenHash string) (*auth_types.Invitation, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) AcceptInvitation(invitation_id int64, name string, passwordHash string) (int64, error) {
+	args := m.Called(invitation_id, name, passwordHash)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,43 @@
+package auth_repository_mock
+
+import (
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) SavePendingSecret(userID int64, secret string) error {
+	args := m.Called(userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) GetMFA(userID int64) (*auth_types.MFA, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.MFA), args.Error(1)
+}
+
+func (m *MockMFARepository) EnableMFA(userID int64, recoveryCodeHashes []string) error {
+	args := m.Called(userID, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseTOTPStep(userID int64, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) ConsumeRecoveryCode(userID int64, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) DeleteMFA(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
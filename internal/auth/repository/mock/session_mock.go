@@ -0,0 +1,46 @@
+package auth_repository_mock
+
+import (
+	"time"
+
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateSession(userID int64, refreshTokenID uuid.UUID, client auth_types.ClientInfo, expiresAt time.Time) (uuid.UUID, error) {
+	args := m.Called(userID, refreshTokenID, client, expiresAt)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockSessionRepository) RotateSession(sessionID uuid.UUID, currentTokenID uuid.UUID, newTokenID uuid.UUID, client auth_types.ClientInfo, expiresAt time.Time) error {
+	args := m.Called(sessionID, currentTokenID, newTokenID, client, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeSession(sessionID uuid.UUID) error {
+	args := m.Called(sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeUserSession(userID int64, sessionID uuid.UUID) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) ListSessions(userID int64) ([]auth_types.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth_types.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) IsSessionActive(sessionID uuid.UUID) (bool, error) {
+	args := m.Called(sessionID)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,31 @@
+package auth_repository_mock
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRepository) CreateToken(userID int64, purpose string, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(userID, purpose, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) FindToken(purpose string, tokenHash string) (int64, error) {
+	args := m.Called(purpose, tokenHash)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) ConsumeToken(purpose string, tokenHash string) (int64, error) {
+	args := m.Called(purpose, tokenHash)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) RevokeUserTokens(userID int64, purpose string) error {
+	args := m.Called(userID, purpose)
+	return args.Error(0)
+}
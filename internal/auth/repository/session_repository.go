@@ -0,0 +1,184 @@
+package auth_repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	createSessionQuery = `INSERT INTO user_sessions (user_id, refresh_token_id, user_agent, ip, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING public_id`
+
+	// only the newest refresh token of the family matches, an older one falls through to ErrNoRows
+	rotateSessionQuery = `UPDATE user_sessions
+	SET refresh_token_id = $3, user_agent = $4, ip = $5, expires_at = $6, last_seen_at = now()
+	WHERE public_id = $1
+	AND refresh_token_id = $2
+	AND revoked_at IS NULL
+	AND expires_at > now()`
+
+	revokeSessionQuery = `UPDATE user_sessions
+	SET revoked_at = now()
+	WHERE public_id = $1
+	AND revoked_at IS NULL`
+
+	revokeUserSessionQuery = `UPDATE user_sessions
+	SET revoked_at = now()
+	WHERE public_id = $2
+	AND user_id = $1
+	AND revoked_at IS NULL
+	AND expires_at > now()`
+
+	listSessionsQuery = `SELECT id, public_id, user_agent, ip, created_at, last_seen_at
+	FROM user_sessions
+	WHERE user_id = $1
+	AND revoked_at IS NULL
+	AND expires_at > now()
+	ORDER BY last_seen_at DESC`
+
+	isSessionActiveQuery = `SELECT EXISTS (
+		SELECT 1 FROM user_sessions
+		WHERE public_id = $1
+		AND revoked_at IS NULL
+		AND expires_at > now()
+	)`
+)
+
+type sessionRepository struct {
+	Db *sql.DB
+}
+
+// SessionRepository keeps one row per login, linked to its refresh-token family.
+type SessionRepository interface {
+	CreateSession(user_id int64, refreshTokenID uuid.UUID, client auth_types.ClientInfo, expiresAt time.Time) (uuid.UUID, error)
+	RotateSession(sessionID uuid.UUID, currentTokenID uuid.UUID, newTokenID uuid.UUID, client auth_types.ClientInfo, expiresAt time.Time) error
+	RevokeSession(sessionID uuid.UUID) error
+	RevokeUserSession(user_id int64, sessionID uuid.UUID) error
+	ListSessions(user_id int64) ([]auth_types.Session, error)
+	IsSessionActive(sessionID uuid.UUID) (bool, error)
+}
+
+func NewSessionRepository(Db *sql.DB) SessionRepository {
+	return &sessionRepository{
+		Db: Db,
+	}
+}
+
+func (r *sessionRepository) CreateSession(user_id int64, refreshTokenID uuid.UUID, client auth_types.ClientInfo, expiresAt time.Time) (uuid.UUID, error) {
+	ctx := context.Background()
+
+	var sessionID uuid.UUID
+	err := r.Db.QueryRowContext(ctx, createSessionQuery, user_id, refreshTokenID, client.UserAgent, client.IP, expiresAt).Scan(&sessionID)
+	if err != nil {
+		logger.Log.Error("error creating session", zap.String("error", err.Error()))
+		return uuid.Nil, err
+	}
+	return sessionID, nil
+}
+
+// RotateSession swaps the family's refresh token. ErrInvalidRefreshToken means the
+// presented token is not the newest one, or the session is gone.
+func (r *sessionRepository) RotateSession(sessionID uuid.UUID, currentTokenID uuid.UUID, newTokenID uuid.UUID, client auth_types.ClientInfo, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, rotateSessionQuery, sessionID, currentTokenID, newTokenID, client.UserAgent, client.IP, expiresAt)
+	if err != nil {
+		logger.Log.Error("error rotating session", zap.String("error", err.Error()))
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("error rotating session", zap.String("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return auth_errors.ErrInvalidRefreshToken
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeSession(sessionID uuid.UUID) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, revokeSessionQuery, sessionID)
+	if err != nil {
+		logger.Log.Error("error revoking session", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// RevokeUserSession only touches sessions owned by the user, anything else is ErrSessionNotFound.
+func (r *sessionRepository) RevokeUserSession(user_id int64, sessionID uuid.UUID) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, revokeUserSessionQuery, user_id, sessionID)
+	if err != nil {
+		logger.Log.Error("error revoking session", zap.String("error", err.Error()))
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("error revoking session", zap.String("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return auth_errors.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepository) ListSessions(user_id int64) ([]auth_types.Session, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, listSessionsQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error listing sessions", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []auth_types.Session{}
+	for rows.Next() {
+		var session auth_types.Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.PublicID,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastSeenAt); err != nil {
+			logger.Log.Error("error scanning session", zap.String("error", err.Error()))
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Log.Error("error listing sessions", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) IsSessionActive(sessionID uuid.UUID) (bool, error) {
+	ctx := context.Background()
+
+	var active bool
+	err := r.Db.QueryRowContext(ctx, isSessionActiveQuery, sessionID).Scan(&active)
+	if err != nil {
+		logger.Log.Error("error checking session", zap.String("error", err.Error()))
+		return false, err
+	}
+	return active, nil
+}
@@ -0,0 +1,200 @@
+package auth_repository
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSession(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSessionRepository(db)
+
+	tokenID := uuid.New()
+	sessionID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+	client := auth_types.ClientInfo{IP: "10.0.0.1", UserAgent: "curl/8.0"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(createSessionQuery)).
+		WithArgs(int64(1), tokenID, "curl/8.0", "10.0.0.1", expiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"public_id"}).AddRow(sessionID))
+
+	got, err := repo.CreateSession(1, tokenID, client, expiresAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, sessionID, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateSession(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		affected    int64
+		mockError   error
+		expectError error
+	}{
+		{
+			name:     "success",
+			affected: 1,
+		},
+		{
+			name:        "stale refresh token",
+			affected:    0,
+			expectError: auth_errors.ErrInvalidRefreshToken,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewSessionRepository(db)
+
+			sessionID, currentID, newID := uuid.New(), uuid.New(), uuid.New()
+			expiresAt := time.Now().Add(time.Hour)
+			client := auth_types.ClientInfo{IP: "10.0.0.1", UserAgent: "curl/8.0"}
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(rotateSessionQuery)).
+				WithArgs(sessionID, currentID, newID, "curl/8.0", "10.0.0.1", expiresAt)
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			err = repo.RotateSession(sessionID, currentID, newID, client, expiresAt)
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeUserSession(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		affected    int64
+		mockError   error
+		expectError error
+	}{
+		{
+			name:     "success",
+			affected: 1,
+		},
+		{
+			name:        "not owned or already revoked",
+			affected:    0,
+			expectError: auth_errors.ErrSessionNotFound,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewSessionRepository(db)
+
+			sessionID := uuid.New()
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(revokeUserSessionQuery)).WithArgs(int64(1), sessionID)
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			err = repo.RevokeUserSession(1, sessionID)
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSessionRepository(db)
+
+	now := time.Now()
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(listSessionsQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "user_agent", "ip", "created_at", "last_seen_at"}).
+			AddRow(int64(2), first, "Firefox", "10.0.0.2", now, now).
+			AddRow(int64(1), second, "curl/8.0", "10.0.0.1", now.Add(-time.Hour), now.Add(-time.Hour)))
+
+	sessions, err := repo.ListSessions(1)
+
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, first, sessions[0].PublicID)
+	assert.Equal(t, "curl/8.0", sessions[1].UserAgent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsSessionActive(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewSessionRepository(db)
+
+	sessionID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(isSessionActiveQuery)).
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	active, err := repo.IsSessionActive(sessionID)
+
+	assert.NoError(t, err)
+	assert.False(t, active)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,107 @@
+package auth_repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	createTokenQuery = `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+	VALUES ($1, $2, $3, $4)`
+
+	findTokenQuery = `SELECT user_id FROM user_tokens
+	WHERE token_hash = $1
+	AND purpose = $2
+	AND consumed_at IS NULL
+	AND expires_at > now()`
+
+	consumeTokenQuery = `UPDATE user_tokens
+	SET consumed_at = now()
+	WHERE token_hash = $1
+	AND purpose = $2
+	AND consumed_at IS NULL
+	AND expires_at > now()
+	RETURNING user_id`
+
+	revokeUserTokensQuery = `UPDATE user_tokens
+	SET consumed_at = now()
+	WHERE user_id = $1
+	AND purpose = $2
+	AND consumed_at IS NULL`
+)
+
+type tokenRepository struct {
+	Db *sql.DB
+}
+
+// TokenRepository stores hashed single-use tokens; the plain value is only ever sent to the user.
+type TokenRepository interface {
+	CreateToken(user_id int64, purpose string, tokenHash string, expiresAt time.Time) error
+	FindToken(purpose string, tokenHash string) (int64, error)
+	ConsumeToken(purpose string, tokenHash string) (int64, error)
+	RevokeUserTokens(user_id int64, purpose string) error
+}
+
+func NewTokenRepository(Db *sql.DB) TokenRepository {
+	return &tokenRepository{
+		Db: Db,
+	}
+}
+
+func (r *tokenRepository) CreateToken(user_id int64, purpose string, tokenHash string, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, createTokenQuery, user_id, purpose, tokenHash, expiresAt)
+	if err != nil {
+		logger.Log.Error("error creating user token", zap.String("purpose", purpose), zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// FindToken returns the owner of a valid token without using it up.
+func (r *tokenRepository) FindToken(purpose string, tokenHash string) (int64, error) {
+	ctx := context.Background()
+
+	var userID int64
+	err := r.Db.QueryRowContext(ctx, findTokenQuery, tokenHash, purpose).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, auth_errors.ErrInvalidToken
+		}
+		logger.Log.Error("error finding user token", zap.String("purpose", purpose), zap.String("error", err.Error()))
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (r *tokenRepository) ConsumeToken(purpose string, tokenHash string) (int64, error) {
+	ctx := context.Background()
+
+	var userID int64
+	err := r.Db.QueryRowContext(ctx, consumeTokenQuery, tokenHash, purpose).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, auth_errors.ErrInvalidToken
+		}
+		logger.Log.Error("error consuming user token", zap.String("purpose", purpose), zap.String("error", err.Error()))
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (r *tokenRepository) RevokeUserTokens(user_id int64, purpose string) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, revokeUserTokensQuery, user_id, purpose)
+	if err != nil {
+		logger.Log.Error("error revoking user tokens", zap.String("purpose", purpose), zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package auth_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateToken(t *testing.T) {
+	logger.Init("dev")
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		mockError   error
+		expectError bool
+	}{
+		{
+			name:        "success",
+			mockError:   nil,
+			expectError: false,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewTokenRepository(db)
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(createTokenQuery)).
+				WithArgs(int64(1), "email_verification", "hash", expiresAt)
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			err = repo.CreateToken(1, "email_verification", "hash", expiresAt)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFindToken(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectError error
+	}{
+		{
+			name:     "success",
+			mockRows: sqlmock.NewRows([]string{"user_id"}).AddRow(7),
+		},
+		{
+			name:        "expired, used or unknown token",
+			mockError:   sql.ErrNoRows,
+			expectError: auth_errors.ErrInvalidToken,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewTokenRepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(findTokenQuery)).
+				WithArgs("hash", "password_reset")
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			userID, err := repo.FindToken("password_reset", "hash")
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(7), userID)
+			} else {
+				assert.EqualError(t, err, tt.expectError.Error())
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestConsumeToken(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectError error
+	}{
+		{
+			name:        "success",
+			mockRows:    sqlmock.NewRows([]string{"user_id"}).AddRow(7),
+			expectError: nil,
+		},
+		{
+			name:        "expired, used or unknown token",
+			mockError:   sql.ErrNoRows,
+			expectError: auth_errors.ErrInvalidToken,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewTokenRepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(consumeTokenQuery)).
+				WithArgs("hash", "email_verification")
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			userID, err := repo.ConsumeToken("email_verification", "hash")
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(7), userID)
+			} else if tt.expectError == auth_errors.ErrInvalidToken {
+				assert.ErrorIs(t, err, auth_errors.ErrInvalidToken)
+			} else {
+				assert.Error(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTokenRepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta(revokeUserTokensQuery)).
+		WithArgs(int64(1), "email_verification").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.RevokeUserTokens(1, "email_verification")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
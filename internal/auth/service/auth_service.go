@@ -1,58 +1,199 @@
 package auth_service
 
 import (
+	"sync"
+	"time"
+
+	"github.com/celio001/prodify/config"
 	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_repository "github.com/celio001/prodify/internal/auth/repository"
 	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_repository "github.com/celio001/prodify/internal/user/repository"
 	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	"github.com/celio001/prodify/pkg/password-hasher/hasher"
+	"github.com/celio001/prodify/pkg/password-validator/validator"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"go.uber.org/zap"
 )
 
 type authService struct {
-	userRepo user_repository.UserRepository
+	userRepo                 user_repository.UserRepository
+	tokenRepo                auth_repository.TokenRepository
+	mfaRepo                  auth_repository.MFARepository
+	sessionRepo              auth_repository.SessionRepository
+	identityRepo             auth_repository.IdentityRepository
+	invitationRepo           auth_repository.InvitationRepository
+	mailer                   mailer.Mailer
+	limiter                  *auth_lockout.Limiter
+	magicLinkRate            auth_lockout.RateLimit
+	passwordStrengthRate     auth_lockout.RateLimit
+	passwordResetRate        auth_lockout.RateLimit
+	passwordResetIPRate      auth_lockout.RateLimit
+	hasher                   hasher.Hasher
+	dummyHashOnce            sync.Once
+	dummyHash                string
+	requireEmailVerification bool
+	passwordHistorySize      int
+	passwordPolicy           validator.Policy
+	allowSelfRegistration    bool
+	invitationTTL            time.Duration
 }
 
 type AuthService interface {
-	Login(loginRequest auth_types.LoginRequest) (user_types.GetUserResponse, error)
+	Login(loginRequest auth_types.LoginRequest, client auth_types.ClientInfo) (user_types.GetUserResponse, error)
 	RegisterUser(user auth_types.CreateUserRequest) (*auth_types.CreateUserResponse, error)
 	ResetPassword(userPublicID uuid.UUID, resetPasswordRequest auth_types.ResetPasswordRequest) error
+	VerifyEmail(token string) error
+	ResendVerificationEmail(email string) error
+	RequestEmailChange(userPublicID uuid.UUID, newEmail string) error
+	ConfirmEmailChange(token string) error
+	ForgotPassword(email string)
+	RequestPasswordReset(email string, client auth_types.ClientInfo) error
+	ConfirmPasswordReset(confirmRequest auth_types.ConfirmPasswordResetRequest) (string, error)
+	CheckPasswordStrength(request auth_types.PasswordStrengthRequest, client auth_types.ClientInfo) (*auth_types.PasswordStrengthResponse, error)
+	RequestMagicLink(email string) error
+	LoginWithMagicLink(token string) (user_types.GetUserResponse, error)
+	CheckSession(userPublicID string, sessionID string, issuedAt time.Time) error
+	IssueTokens(userPublicID string, client auth_types.ClientInfo) (*auth_types.TokenPair, error)
+	RefreshTokens(refreshToken string, client auth_types.ClientInfo) (*auth_types.TokenPair, error)
+	ListSessions(userPublicID uuid.UUID, currentSessionID string) ([]auth_types.Session, error)
+	RevokeSession(userPublicID uuid.UUID, sessionID uuid.UUID) error
+	LoginWithIdentity(identity auth_types.ExternalIdentity) (user_types.GetUserResponse, error)
+	HasRole(userPublicID string, role string) (bool, error)
+	LookupAccount(email string) (string, error)
+	UnlockLogin(unlockRequest auth_types.UnlockLoginRequest) error
+	EnrollMFA(userPublicID uuid.UUID) (*auth_types.MFAEnrollmentResponse, error)
+	ConfirmMFA(userPublicID uuid.UUID, code string) ([]string, error)
+	VerifyMFALogin(userPublicID string, code string, client auth_types.ClientInfo) (user_types.GetUserResponse, error)
+	ResetMFA(userPublicID uuid.UUID) error
+	CreateInvitation(adminPublicID uuid.UUID, createRequest auth_types.CreateInvitationRequest) (*auth_types.Invitation, error)
+	ListInvitations(listRequest auth_types.ListInvitationsRequest) ([]auth_types.Invitation, error)
+	RevokeInvitation(invitationPublicID uuid.UUID) error
+	AcceptInvitation(acceptRequest auth_types.AcceptInvitationRequest) (*user_types.GetUserResponse, error)
+	Impersonate(adminPublicID uuid.UUID, adminSessionID string, userPublicID uuid.UUID) (*auth_types.ImpersonationToken, error)
 }
 
-func NewAuthService(userRepo user_repository.UserRepository) AuthService {
+func NewAuthService(userRepo user_repository.UserRepository, tokenRepo auth_repository.TokenRepository, mfaRepo auth_repository.MFARepository, sessionRepo auth_repository.SessionRepository, identityRepo auth_repository.IdentityRepository, invitationRepo auth_repository.InvitationRepository, mailer mailer.Mailer, limiter *auth_lockout.Limiter, passwordHasher hasher.Hasher, breaches validator.BreachChecker) AuthService {
 	return &authService{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
+		mfaRepo:        mfaRepo,
+		sessionRepo:    sessionRepo,
+		identityRepo:   identityRepo,
+		invitationRepo: invitationRepo,
+		mailer:         mailer,
+		limiter:        limiter,
+		magicLinkRate: auth_lockout.RateLimit{
+			Limit:  config.GetInt("AUTH_MAGIC_LINK_MAX_REQUESTS"),
+			Window: time.Duration(config.GetInt("AUTH_MAGIC_LINK_WINDOW_MINUTES")) * time.Minute,
+		},
+		passwordStrengthRate: auth_lockout.RateLimit{
+			Limit:  config.GetInt("AUTH_PASSWORD_STRENGTH_MAX_REQUESTS"),
+			Window: time.Duration(config.GetInt("AUTH_PASSWORD_STRENGTH_WINDOW_MINUTES")) * time.Minute,
+		},
+		passwordResetRate: auth_lockout.RateLimit{
+			Limit:  config.GetInt("AUTH_PASSWORD_RESET_MAX_REQUESTS"),
+			Window: time.Duration(config.GetInt("AUTH_PASSWORD_RESET_WINDOW_MINUTES")) * time.Minute,
+		},
+		passwordResetIPRate: auth_lockout.RateLimit{
+			Limit:  config.GetInt("AUTH_PASSWORD_RESET_IP_MAX_REQUESTS"),
+			Window: time.Duration(config.GetInt("AUTH_PASSWORD_RESET_WINDOW_MINUTES")) * time.Minute,
+		},
+		hasher:                   passwordHasher,
+		requireEmailVerification: config.GetBool("AUTH_REQUIRE_EMAIL_VERIFICATION"),
+		passwordHistorySize:      config.GetInt("AUTH_PASSWORD_HISTORY_SIZE"),
+		passwordPolicy:           newPasswordPolicy(breaches),
+		allowSelfRegistration:    config.GetBool("AUTH_ALLOW_SELF_REGISTRATION"),
+		invitationTTL:            time.Duration(config.GetInt("AUTH_INVITATION_TTL_HOURS")) * time.Hour,
 	}
 }
 
-func (s *authService) Login(loginRequest auth_types.LoginRequest) (user_types.GetUserResponse, error) {
+func (s *authService) Login(loginRequest auth_types.LoginRequest, client auth_types.ClientInfo) (user_types.GetUserResponse, error) {
+	if err := s.limiter.Check(loginRequest.Email, client.IP); err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(loginRequest.Email)
-	if err != nil {
+	if err != nil && err != user_errors.ErrUserNotFound {
 		return user_types.GetUserResponse{}, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(loginRequest.Password)); err != nil {
+	// unknown emails still pay for a hash comparison and count as a failure,
+	// so neither timing nor lockout behaviour tells whether the account exists
+	passwordHash := s.dummyPasswordHash()
+	if user != nil {
+		passwordHash = user.PasswordHash
+	}
+
+	if err := s.hasher.Compare(passwordHash, loginRequest.Password); err != nil || user == nil {
+		if err := s.limiter.RegisterFailure(loginRequest.Email, client.IP); err != nil {
+			logger.Log.Error("failed to register login failure", zap.String("error", err.Error()))
+		}
 		return user_types.GetUserResponse{}, auth_errors.ErrMatchDataUser
 	}
 
+	if err := s.limiter.RegisterSuccess(loginRequest.Email); err != nil {
+		logger.Log.Error("failed to reset login failures", zap.String("error", err.Error()))
+	}
+
+	s.upgradePasswordHash(user, loginRequest.Password)
+
+	if !user.IsActive {
+		return user_types.GetUserResponse{}, auth_errors.ErrUserInactive
+	}
+
+	if user.PasswordResetRequired {
+		return user_types.GetUserResponse{}, auth_errors.ErrPasswordReset
+	}
+
+	if s.requireEmailVerification && !user.EmailVerified {
+		return user_types.GetUserResponse{}, auth_errors.ErrEmailNotVerified
+	}
+
 	return *user, nil
 }
 
+// LookupAccount returns the public id of the account registered with email, or "" when
+// there is none. It lets the audit log of admin actions point at an account instead of
+// storing its email.
+func (s *authService) LookupAccount(email string) (string, error) {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if err == user_errors.ErrUserNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return user.PublicID, nil
+}
+
 func (s *authService) ResetPassword(userPublicID uuid.UUID, resetPasswordRequest auth_types.ResetPasswordRequest) error {
 	user, err := s.userRepo.GetUserByPublicID(userPublicID)
 	if err != nil {
 		return err
 	}
 
-	err = s.userRepo.UpdateUserPassword(user.ID, resetPasswordRequest)
-	if err != nil {
-		return err
+	if err := s.hasher.Compare(user.PasswordHash, resetPasswordRequest.CurrentPassword); err != nil {
+		return auth_errors.ErrInvalidCurrentPassword
 	}
 
-	return nil
+	return s.changePassword(user, resetPasswordRequest.NewPassword)
 }
 
+// RegisterUser is open self-registration, when it is turned off accounts are only
+// created through invitations.
 func (s *authService) RegisterUser(user auth_types.CreateUserRequest) (*auth_types.CreateUserResponse, error) {
+	if !s.allowSelfRegistration {
+		return &auth_types.CreateUserResponse{}, auth_errors.ErrRegistrationDisabled
+	}
+
+	if err := s.passwordPolicy.Validate(user.Password, user.Name, user.Email); err != nil {
+		return &auth_types.CreateUserResponse{}, err
+	}
 
 	u := user_types.CreateUserRequest{
 		Name:     user.Name,
@@ -63,7 +204,7 @@ func (s *authService) RegisterUser(user auth_types.CreateUserRequest) (*auth_typ
 	userExists, err := s.userRepo.GetUserByEmail(user.Email)
 	if userExists != nil {
 		return &auth_types.CreateUserResponse{}, auth_errors.ErrUserAlreadyExists
-	}else if err != nil {
+	} else if err != nil && err != user_errors.ErrUserNotFound {
 		return &auth_types.CreateUserResponse{}, err
 	}
 
@@ -71,6 +212,11 @@ func (s *authService) RegisterUser(user auth_types.CreateUserRequest) (*auth_typ
 	if err != nil {
 		return &auth_types.CreateUserResponse{}, err
 	}
+
+	// the account already exists at this point, a failed email can be retried through the resend endpoint
+	if err := s.sendVerificationEmail(userRepo.Id, userRepo.Email, ""); err != nil {
+		logger.Log.Error("failed to send verification email", zap.String("error", err.Error()))
+	}
 	return &auth_types.CreateUserResponse{
 		Id:           userRepo.Id,
 		PublicID:     userRepo.PublicID.String(),
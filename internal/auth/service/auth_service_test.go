@@ -5,13 +5,19 @@ import (
 	"testing"
 
 	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
 	auth_types "github.com/celio001/prodify/internal/auth/types"
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
 	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	"github.com/celio001/prodify/pkg/password-hasher/hasher"
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
 	"github.com/google/uuid"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -33,6 +39,7 @@ func TestLogin(t *testing.T) {
 			mockReturn: &user_types.GetUserResponse{
 				Email:        "test@mail.com",
 				PasswordHash: string(hash),
+				IsActive:     true,
 			},
 			mockError: nil,
 			request: auth_types.LoginRequest{
@@ -49,7 +56,7 @@ func TestLogin(t *testing.T) {
 				Email:    "test@mail.com",
 				Password: validPassword,
 			},
-			expectError: user_errors.ErrUserNotFound,
+			expectError: auth_errors.ErrMatchDataUser,
 		},
 		{
 			name:       "repository error",
@@ -66,6 +73,7 @@ func TestLogin(t *testing.T) {
 			mockReturn: &user_types.GetUserResponse{
 				Email:        "test@mail.com",
 				PasswordHash: string(hash),
+				IsActive:     true,
 			},
 			mockError: nil,
 			request: auth_types.LoginRequest{
@@ -74,6 +82,34 @@ func TestLogin(t *testing.T) {
 			},
 			expectError: auth_errors.ErrMatchDataUser,
 		},
+		{
+			name: "deactivated account",
+			mockReturn: &user_types.GetUserResponse{
+				Email:        "test@mail.com",
+				PasswordHash: string(hash),
+			},
+			mockError: nil,
+			request: auth_types.LoginRequest{
+				Email:    "test@mail.com",
+				Password: validPassword,
+			},
+			expectError: auth_errors.ErrUserInactive,
+		},
+		{
+			name: "password reset required",
+			mockReturn: &user_types.GetUserResponse{
+				Email:                 "test@mail.com",
+				PasswordHash:          string(hash),
+				IsActive:              true,
+				PasswordResetRequired: true,
+			},
+			mockError: nil,
+			request: auth_types.LoginRequest{
+				Email:    "test@mail.com",
+				Password: validPassword,
+			},
+			expectError: auth_errors.ErrPasswordReset,
+		},
 	}
 
 	for _, tt := range tests {
@@ -86,9 +122,9 @@ func TestLogin(t *testing.T) {
 				On("GetUserByEmail", tt.request.Email).
 				Return(tt.mockReturn, tt.mockError)
 
-			service := NewAuthService(mockRepo)
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
 
-			result, err := service.Login(tt.request)
+			result, err := service.Login(tt.request, auth_types.ClientInfo{IP: "127.0.0.1"})
 
 			if tt.expectError == nil {
 				assert.NoError(t, err)
@@ -97,7 +133,9 @@ func TestLogin(t *testing.T) {
 				assert.Error(t, err)
 
 				if errors.Is(tt.expectError, auth_errors.ErrMatchDataUser) ||
-					errors.Is(tt.expectError, user_errors.ErrUserNotFound) {
+					errors.Is(tt.expectError, user_errors.ErrUserNotFound) ||
+					errors.Is(tt.expectError, auth_errors.ErrUserInactive) ||
+					errors.Is(tt.expectError, auth_errors.ErrPasswordReset) {
 					assert.ErrorIs(t, err, tt.expectError)
 				}
 			}
@@ -107,46 +145,92 @@ func TestLogin(t *testing.T) {
 	}
 }
 
+func TestLookupAccount(t *testing.T) {
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByEmail", "jane@example.com").Return(&user_types.GetUserResponse{ID: 1, PublicID: "user-1"}, nil)
+	mockRepo.On("GetUserByEmail", "ghost@example.com").Return(nil, user_errors.ErrUserNotFound)
+	mockRepo.On("GetUserByEmail", "broken@example.com").Return(nil, errors.New("db error"))
+
+	service := &authService{userRepo: mockRepo}
+
+	publicID, err := service.LookupAccount("jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", publicID)
+
+	publicID, err = service.LookupAccount("ghost@example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, publicID)
+
+	_, err = service.LookupAccount("broken@example.com")
+	assert.EqualError(t, err, "db error")
+}
+
 func TestResetPassword(t *testing.T) {
 
 	userPublicID := uuid.New()
 
-	resetReq := auth_types.ResetPasswordRequest{
-		OldPasswordHash: "old-hash",
-		NewPassword:     "new-password",
-	}
+	currentPassword := "Current-Passw0rd!2026"
+	newPassword := "Brand-New#Passw0rd-2026"
+
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte(currentPassword), bcrypt.MinCost)
+	reusedHash, _ := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.MinCost)
 
 	tests := []struct {
 		name                string
+		request             auth_types.ResetPasswordRequest
 		mockUserReturn      *user_types.GetUserResponse
 		mockGetUserError    error
+		mockHistory         []string
+		expectHistoryCall   bool
+		expectUpdateCall    bool
 		mockUpdatePassError error
 		expectError         error
 	}{
 		{
-			name: "success",
-			mockUserReturn: &user_types.GetUserResponse{
-				ID: 1,
-			},
-			mockGetUserError:    nil,
-			mockUpdatePassError: nil,
-			expectError:         nil,
+			name:              "success",
+			request:           auth_types.ResetPasswordRequest{CurrentPassword: currentPassword, NewPassword: newPassword},
+			mockUserReturn:    &user_types.GetUserResponse{ID: 1, PasswordHash: string(currentHash)},
+			mockHistory:       []string{},
+			expectHistoryCall: true,
+			expectUpdateCall:  true,
+			expectError:       nil,
 		},
 		{
-			name:                "get user error",
-			mockUserReturn:      nil,
-			mockGetUserError:    user_errors.ErrUserNotFound,
-			mockUpdatePassError: nil,
-			expectError:         user_errors.ErrUserNotFound,
+			name:             "get user error",
+			request:          auth_types.ResetPasswordRequest{CurrentPassword: currentPassword, NewPassword: newPassword},
+			mockUserReturn:   nil,
+			mockGetUserError: user_errors.ErrUserNotFound,
+			expectError:      user_errors.ErrUserNotFound,
 		},
 		{
-			name: "update password error",
-			mockUserReturn: &user_types.GetUserResponse{
-				ID: 1,
-			},
-			mockGetUserError:    nil,
-			mockUpdatePassError: user_errors.ErrSamePassword,
-			expectError:         user_errors.ErrSamePassword,
+			name:           "wrong current password",
+			request:        auth_types.ResetPasswordRequest{CurrentPassword: "not-the-password", NewPassword: newPassword},
+			mockUserReturn: &user_types.GetUserResponse{ID: 1, PasswordHash: string(currentHash)},
+			expectError:    auth_errors.ErrInvalidCurrentPassword,
+		},
+		{
+			name:           "same password",
+			request:        auth_types.ResetPasswordRequest{CurrentPassword: currentPassword, NewPassword: currentPassword},
+			mockUserReturn: &user_types.GetUserResponse{ID: 1, PasswordHash: string(currentHash)},
+			expectError:    user_errors.ErrSamePassword,
+		},
+		{
+			name:              "password used recently",
+			request:           auth_types.ResetPasswordRequest{CurrentPassword: currentPassword, NewPassword: newPassword},
+			mockUserReturn:    &user_types.GetUserResponse{ID: 1, PasswordHash: string(currentHash)},
+			mockHistory:       []string{string(reusedHash)},
+			expectHistoryCall: true,
+			expectError:       user_errors.ErrPasswordReused,
+		},
+		{
+			name:                "update password error",
+			request:             auth_types.ResetPasswordRequest{CurrentPassword: currentPassword, NewPassword: newPassword},
+			mockUserReturn:      &user_types.GetUserResponse{ID: 1, PasswordHash: string(currentHash)},
+			mockHistory:         []string{},
+			expectHistoryCall:   true,
+			expectUpdateCall:    true,
+			mockUpdatePassError: errors.New("db error"),
+			expectError:         errors.New("db error"),
 		},
 	}
 
@@ -160,20 +244,159 @@ func TestResetPassword(t *testing.T) {
 				On("GetUserByPublicID", userPublicID).
 				Return(tt.mockUserReturn, tt.mockGetUserError)
 
-			if tt.mockGetUserError == nil {
+			if tt.expectHistoryCall {
+				mockRepo.
+					On("GetPasswordHistory", int64(1), 5).
+					Return(tt.mockHistory, nil)
+			}
+
+			if tt.expectUpdateCall {
 				mockRepo.
-					On("UpdateUserPassword", tt.mockUserReturn.ID, resetReq).
+					On("UpdateUserPassword", int64(1), tt.request.NewPassword).
 					Return(tt.mockUpdatePassError)
 			}
 
-			service := NewAuthService(mockRepo)
+			if tt.expectUpdateCall && tt.mockUpdatePassError == nil {
+				mockRepo.
+					On("RevokeUserSessions", int64(1)).
+					Return(nil)
+			}
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
 
-			err := service.ResetPassword(userPublicID, resetReq)
+			err := service.ResetPassword(userPublicID, tt.request)
 
 			if tt.expectError == nil {
 				assert.NoError(t, err)
 			} else {
 				assert.Error(t, err)
+				assert.EqualError(t, err, tt.expectError.Error())
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResetPassword_WeakPassword(t *testing.T) {
+
+	userPublicID := uuid.New()
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("Current-Passw0rd!2026"), bcrypt.MinCost)
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.
+		On("GetUserByPublicID", userPublicID).
+		Return(&user_types.GetUserResponse{ID: 1, PasswordHash: string(currentHash)}, nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	err := service.ResetPassword(userPublicID, auth_types.ResetPasswordRequest{
+		CurrentPassword: "Current-Passw0rd!2026",
+		NewPassword:     "123456",
+	})
+
+	var passwordErr *password_errors.PasswordError
+	assert.ErrorAs(t, err, &passwordErr)
+	mockRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+}
+
+type fakeBreaches map[string]int
+
+func (f fakeBreaches) Count(password string) (int, error) {
+	return f[password], nil
+}
+
+func TestBreachedPasswordRejected(t *testing.T) {
+	logger.Init("dev")
+
+	breached := "Summer-Holiday-2019!"
+	breaches := fakeBreaches{breached: 42}
+
+	userPublicID := uuid.New()
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("Current-Passw0rd!2026"), bcrypt.MinCost)
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.
+		On("GetUserByPublicID", userPublicID).
+		Return(&user_types.GetUserResponse{ID: 1, PasswordHash: string(currentHash)}, nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), breaches)
+
+	_, err := service.RegisterUser(auth_types.CreateUserRequest{Name: "Test", Email: "test@mail.com", Password: breached})
+
+	var passwordErr *password_errors.PasswordError
+	assert.ErrorAs(t, err, &passwordErr)
+	assert.Equal(t, []error{password_errors.ErrBreached}, passwordErr.Reasons)
+
+	err = service.ResetPassword(userPublicID, auth_types.ResetPasswordRequest{
+		CurrentPassword: "Current-Passw0rd!2026",
+		NewPassword:     breached,
+	})
+
+	assert.ErrorAs(t, err, &passwordErr)
+	assert.Equal(t, []error{password_errors.ErrBreached}, passwordErr.Reasons)
+
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+}
+
+func TestGuessablePasswordRejected(t *testing.T) {
+	logger.Init("dev")
+
+	mockRepo := new(user_mock.MockUserRepository)
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	_, err := service.RegisterUser(auth_types.CreateUserRequest{Name: "Zebulon Kravitz", Email: "zebulon.kravitz@mail.com", Password: "Zebulon.Kravitz1!"})
+
+	var passwordErr *password_errors.PasswordError
+	assert.ErrorAs(t, err, &passwordErr)
+	assert.Equal(t, []error{password_errors.ErrTooGuessable}, passwordErr.Reasons)
+
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestLogin_EmailVerificationRequired(t *testing.T) {
+	t.Setenv("AUTH_REQUIRE_EMAIL_VERIFICATION", "true")
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
+
+	tests := []struct {
+		name          string
+		emailVerified bool
+		expectError   error
+	}{
+		{
+			name:          "verified",
+			emailVerified: true,
+			expectError:   nil,
+		},
+		{
+			name:          "not verified",
+			emailVerified: false,
+			expectError:   auth_errors.ErrEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+
+			mockRepo.
+				On("GetUserByEmail", "test@mail.com").
+				Return(&user_types.GetUserResponse{
+					Email:         "test@mail.com",
+					PasswordHash:  string(hash),
+					IsActive:      true,
+					EmailVerified: tt.emailVerified,
+				}, nil)
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			_, err := service.Login(auth_types.LoginRequest{Email: "test@mail.com", Password: "123456"}, auth_types.ClientInfo{IP: "127.0.0.1"})
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+			} else {
 				assert.ErrorIs(t, err, tt.expectError)
 			}
 
@@ -181,3 +404,141 @@ func TestResetPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestLogin_UpgradesPasswordHash(t *testing.T) {
+	logger.Init("dev")
+
+	weakBcrypt, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	currentBcrypt, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
+	argon := hasher.NewArgon2idHasher(hasher.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1})
+	currentArgon, _ := argon.Hash("123456")
+
+	tests := []struct {
+		name          string
+		hasher        hasher.Hasher
+		storedHash    string
+		expectUpgrade bool
+	}{
+		{
+			name:          "bcrypt cost raised",
+			hasher:        newTestHasher(),
+			storedHash:    string(weakBcrypt),
+			expectUpgrade: true,
+		},
+		{
+			name:          "bcrypt to argon2id",
+			hasher:        argon,
+			storedHash:    string(currentBcrypt),
+			expectUpgrade: true,
+		},
+		{
+			name:          "bcrypt up to date",
+			hasher:        newTestHasher(),
+			storedHash:    string(currentBcrypt),
+			expectUpgrade: false,
+		},
+		{
+			name:          "argon2id up to date",
+			hasher:        argon,
+			storedHash:    currentArgon,
+			expectUpgrade: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+
+			mockRepo.
+				On("GetUserByEmail", "test@mail.com").
+				Return(&user_types.GetUserResponse{ID: 1, Email: "test@mail.com", PasswordHash: tt.storedHash, IsActive: true}, nil)
+
+			if tt.expectUpgrade {
+				mockRepo.On("UpgradePasswordHash", int64(1), tt.storedHash, "123456").Return(errors.New("db error"))
+			}
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), tt.hasher, nil)
+
+			// a failed upgrade does not fail the login
+			_, err := service.Login(auth_types.LoginRequest{Email: "test@mail.com", Password: "123456"}, auth_types.ClientInfo{IP: "127.0.0.1"})
+
+			assert.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+			if !tt.expectUpgrade {
+				mockRepo.AssertNotCalled(t, "UpgradePasswordHash", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRegisterUser(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		password     string
+		existingUser *user_types.GetUserResponse
+		lookupError  error
+		expectCreate bool
+		expectPolicy bool
+		expectError  error
+	}{
+		{
+			name:         "success",
+			password:     "Str0ng-Enough#Passw0rd",
+			lookupError:  user_errors.ErrUserNotFound,
+			expectCreate: true,
+		},
+		{
+			name:         "weak password",
+			password:     "123456",
+			expectPolicy: true,
+		},
+		{
+			name:         "email already registered",
+			password:     "Str0ng-Enough#Passw0rd",
+			existingUser: &user_types.GetUserResponse{ID: 1},
+			expectError:  auth_errors.ErrUserAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+			mockMailer := new(mailer_mock.MockMailer)
+
+			request := auth_types.CreateUserRequest{Name: "Célio", Email: "celio@email.com", Password: tt.password}
+
+			if !tt.expectPolicy {
+				mockRepo.On("GetUserByEmail", "celio@email.com").Return(tt.existingUser, tt.lookupError)
+			}
+
+			if tt.expectCreate {
+				mockRepo.
+					On("CreateUser", user_types.CreateUserRequest{Name: "Célio", Email: "celio@email.com", Password: tt.password}).
+					Return(&user_types.CreateUserResponse{Id: 1, PublicID: uuid.New(), Email: "celio@email.com"}, nil)
+				mockTokenRepo.On("CreateToken", int64(1), auth_types.TokenPurposeEmailVerification, mock.Anything, mock.Anything).Return(nil)
+				mockMailer.On("Send", mock.Anything).Return(nil)
+			}
+
+			service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), mockMailer, newTestLimiter(), newTestHasher(), nil)
+
+			_, err := service.RegisterUser(request)
+
+			switch {
+			case tt.expectPolicy:
+				var passwordErr *password_errors.PasswordError
+				assert.ErrorAs(t, err, &passwordErr)
+			case tt.expectError != nil:
+				assert.ErrorIs(t, err, tt.expectError)
+			default:
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package auth_service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	"github.com/celio001/prodify/pkg/locale"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+// RequestEmailChange keeps the current address until the new one is confirmed. The
+// current address is told about the request, so a stolen session can't move the account
+// away quietly.
+func (s *authService) RequestEmailChange(userPublicID uuid.UUID, newEmail string) error {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return auth_errors.ErrSameEmail
+	}
+
+	taken, err := s.userRepo.GetUserByEmail(newEmail)
+	if taken != nil {
+		return user_errors.ErrEmailTaken
+	} else if err != nil && err != user_errors.ErrUserNotFound {
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeUserTokens(user.ID, auth_types.TokenPurposeEmailChange); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.SetPendingEmail(user.ID, newEmail); err != nil {
+		return err
+	}
+
+	plain, hash, err := pkg_token.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.CreateToken(user.ID, auth_types.TokenPurposeEmailChange, hash, time.Now().Add(emailChangeTTL)); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/confirm-email-change?token=%s", config.GetString("APP_BASE_URL"), url.QueryEscape(plain))
+
+	p := locale.Printer(user.Preferences.Locale)
+	err = s.mailer.Send(mailer.Message{
+		To:      newEmail,
+		Subject: p.Sprintf(emailChangeSubject),
+		Body:    p.Sprintf(emailChangeBody, link, int(emailChangeTTL.Hours())),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: p.Sprintf(emailChangeRequestedSubject),
+		Body:    p.Sprintf(emailChangeRequestedBody),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("email change requested", zap.Int64("user_id", user.ID))
+	return nil
+}
+
+// ConfirmEmailChange swaps in the pending address, opening the link proved it is the user's.
+func (s *authService) ConfirmEmailChange(token string) error {
+	userID, err := s.tokenRepo.ConsumeToken(auth_types.TokenPurposeEmailChange, pkg_token.Hash(token))
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.userRepo.ConfirmEmailChange(user.ID); err != nil {
+		return err
+	}
+
+	logger.Log.Info("email changed", zap.Int64("user_id", user.ID))
+
+	p := locale.Printer(user.Preferences.Locale)
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: p.Sprintf(emailChangedSubject),
+		Body:    p.Sprintf(emailChangedBody),
+	})
+	if err != nil {
+		// the change is done, a lost notice must not undo it
+		logger.Log.Error("failed to notify previous email address", zap.String("error", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package auth_service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRequestEmailChange(t *testing.T) {
+	logger.Init("dev")
+
+	publicID := uuid.New()
+	user := &user_types.GetUserResponse{ID: 1, PublicID: publicID.String(), Email: "old@mail.com", IsActive: true}
+
+	t.Run("sends confirmation to the new address and a notice to the old one", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+		mockMailer := new(mailer_mock.MockMailer)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(user, nil)
+		mockRepo.On("GetUserByEmail", "new@mail.com").Return(nil, user_errors.ErrUserNotFound)
+		mockRepo.On("SetPendingEmail", int64(1), "new@mail.com").Return(nil)
+		mockTokenRepo.On("RevokeUserTokens", int64(1), auth_types.TokenPurposeEmailChange).Return(nil)
+		mockTokenRepo.On("CreateToken", int64(1), auth_types.TokenPurposeEmailChange, mock.Anything, mock.Anything).Return(nil)
+		mockMailer.On("Send", mock.MatchedBy(func(m mailer.Message) bool {
+			return m.To == "new@mail.com" && strings.Contains(m.Body, "/confirm-email-change?token=")
+		})).Return(nil).Once()
+		mockMailer.On("Send", mock.MatchedBy(func(m mailer.Message) bool {
+			return m.To == "old@mail.com" && !strings.Contains(m.Body, "token=")
+		})).Return(nil).Once()
+
+		service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), mockMailer, newTestLimiter(), newTestHasher(), nil)
+
+		assert.NoError(t, service.RequestEmailChange(publicID, "new@mail.com"))
+
+		mockRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("email taken", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(user, nil)
+		mockRepo.On("GetUserByEmail", "taken@mail.com").Return(&user_types.GetUserResponse{ID: 2}, nil)
+
+		service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+		assert.Equal(t, user_errors.ErrEmailTaken, service.RequestEmailChange(publicID, "taken@mail.com"))
+		mockRepo.AssertNotCalled(t, "SetPendingEmail", mock.Anything, mock.Anything)
+		mockTokenRepo.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("same email", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(user, nil)
+
+		service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+		assert.Equal(t, auth_errors.ErrSameEmail, service.RequestEmailChange(publicID, "OLD@mail.com"))
+	})
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	logger.Init("dev")
+
+	user := &user_types.GetUserResponse{ID: 1, Email: "old@mail.com", IsActive: true}
+
+	tests := []struct {
+		name         string
+		consumeError error
+		confirmError error
+		expectError  error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:         "invalid token",
+			consumeError: auth_errors.ErrInvalidToken,
+			expectError:  auth_errors.ErrInvalidToken,
+		},
+		{
+			name:         "address taken in the meantime",
+			confirmError: user_errors.ErrEmailTaken,
+			expectError:  user_errors.ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+			mockMailer := new(mailer_mock.MockMailer)
+
+			mockTokenRepo.
+				On("ConsumeToken", auth_types.TokenPurposeEmailChange, pkg_token.Hash("plain-token")).
+				Return(int64(1), tt.consumeError)
+
+			if tt.consumeError == nil {
+				mockRepo.On("GetUserByID", int64(1)).Return(user, nil)
+				mockRepo.On("ConfirmEmailChange", int64(1)).Return("new@mail.com", tt.confirmError)
+			}
+			if tt.expectError == nil {
+				// a failed notice does not undo the change
+				mockMailer.On("Send", mock.MatchedBy(func(m mailer.Message) bool {
+					return m.To == "old@mail.com"
+				})).Return(errors.New("smtp down"))
+			}
+
+			service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), mockMailer, newTestLimiter(), newTestHasher(), nil)
+
+			err := service.ConfirmEmailChange("plain-token")
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.expectError, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
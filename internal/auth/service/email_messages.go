@@ -0,0 +1,75 @@
+package auth_service
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Email texts are written in English and double as keys into the message catalog, a
+// user whose locale has a translation gets that instead (see locale.Printer).
+const (
+	verificationSubject = "Confirm your email address"
+	verificationBody    = "Welcome to prodify!\n\nConfirm your email address by opening the link below:\n\n%s\n\n" +
+		"The link expires in %d hours. If you did not create an account you can ignore this message.\n"
+
+	passwordResetSubject = "Reset your password"
+	passwordResetBody    = "We received a request to reset your prodify password.\n\nChoose a new password by opening the link below:\n\n%s\n\n" +
+		"The link expires in %d minutes and can only be used once. If you did not ask for a reset you can ignore this message.\n"
+
+	magicLinkSubject = "Your sign-in link"
+	magicLinkBody    = "Sign in to prodify by opening the link below:\n\n%s\n\n" +
+		"The link expires in %d minutes and can only be used once. If you did not ask to sign in you can ignore this message.\n"
+
+	emailChangeSubject = "Confirm your new email address"
+	emailChangeBody    = "We received a request to use this address for your prodify account.\n\nConfirm it by opening the link below:\n\n%s\n\n" +
+		"The link expires in %d hours. If you did not ask for this you can ignore this message.\n"
+
+	emailChangeRequestedSubject = "Your email address is about to change"
+	emailChangeRequestedBody    = "We received a request to change the email address of your prodify account.\n\n" +
+		"Nothing changes until the new address is confirmed. If this wasn't you, change your password and sign out your other sessions right away.\n"
+
+	emailChangedSubject = "Your email address was changed"
+	emailChangedBody    = "The email address of your prodify account was changed and this address no longer signs in.\n\n" +
+		"If this wasn't you, contact support right away.\n"
+
+	accountInvitationSubject = "%s invited you to prodify"
+	accountInvitationBody    = "%s invited you to prodify as %s.\n\nChoose your name and password by opening the link below:\n\n%s\n\n" +
+		"The link expires in %d hours and can only be used once. If you weren't expecting this you can ignore this message.\n"
+)
+
+// emailTranslations are the pt-BR texts, keep their verbs in the same order as the key.
+var emailTranslations = map[string]string{
+	verificationSubject: "Confirme seu endereço de email",
+	verificationBody: "Boas-vindas ao prodify!\n\nConfirme seu endereço de email abrindo o link abaixo:\n\n%s\n\n" +
+		"O link expira em %d horas. Se você não criou uma conta, ignore esta mensagem.\n",
+
+	passwordResetSubject: "Redefina sua senha",
+	passwordResetBody: "Recebemos um pedido para redefinir sua senha do prodify.\n\nEscolha uma nova senha abrindo o link abaixo:\n\n%s\n\n" +
+		"O link expira em %d minutos e só pode ser usado uma vez. Se você não pediu a redefinição, ignore esta mensagem.\n",
+
+	magicLinkSubject: "Seu link de acesso",
+	magicLinkBody: "Entre no prodify abrindo o link abaixo:\n\n%s\n\n" +
+		"O link expira em %d minutos e só pode ser usado uma vez. Se você não pediu para entrar, ignore esta mensagem.\n",
+
+	emailChangeSubject: "Confirme seu novo endereço de email",
+	emailChangeBody: "Recebemos um pedido para usar este endereço na sua conta do prodify.\n\nConfirme abrindo o link abaixo:\n\n%s\n\n" +
+		"O link expira em %d horas. Se você não pediu isso, ignore esta mensagem.\n",
+
+	emailChangeRequestedSubject: "Seu endereço de email está prestes a mudar",
+	emailChangeRequestedBody: "Recebemos um pedido para mudar o endereço de email da sua conta do prodify.\n\n" +
+		"Nada muda até o novo endereço ser confirmado. Se não foi você, troque sua senha e encerre suas outras sessões imediatamente.\n",
+
+	emailChangedSubject: "Seu endereço de email foi alterado",
+	emailChangedBody: "O endereço de email da sua conta do prodify foi alterado e este endereço não permite mais entrar.\n\n" +
+		"Se não foi você, entre em contato com o suporte imediatamente.\n",
+
+	accountInvitationSubject: "%s convidou você para o prodify",
+	accountInvitationBody: "%s convidou você para o prodify como %s.\n\nEscolha seu nome e sua senha abrindo o link abaixo:\n\n%s\n\n" +
+		"O link expira em %d horas e só pode ser usado uma vez. Se você não esperava este convite, ignore esta mensagem.\n",
+}
+
+func init() {
+	for key, translation := range emailTranslations {
+		message.SetString(language.BrazilianPortuguese, key, translation)
+	}
+}
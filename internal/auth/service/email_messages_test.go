@@ -0,0 +1,16 @@
+package auth_service
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailTranslationsKeepVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+
+	for key, translation := range emailTranslations {
+		assert.Equal(t, verbs.FindAllString(key, -1), verbs.FindAllString(translation, -1), key)
+	}
+}
@@ -0,0 +1,74 @@
+package auth_service
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	"github.com/celio001/prodify/pkg/locale"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"go.uber.org/zap"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+func (s *authService) VerifyEmail(token string) error {
+	userID, err := s.tokenRepo.ConsumeToken(auth_types.TokenPurposeEmailVerification, pkg_token.Hash(token))
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.MarkEmailVerified(userID)
+}
+
+// ResendVerificationEmail never reports whether the email exists, callers always answer the same way.
+func (s *authService) ResendVerificationEmail(email string) error {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if err == user_errors.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := s.tokenRepo.RevokeUserTokens(user.ID, auth_types.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	return s.sendVerificationEmail(user.ID, user.Email, user.Preferences.Locale)
+}
+
+func (s *authService) sendVerificationEmail(userID int64, email string, userLocale string) error {
+	plain, hash, err := pkg_token.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.CreateToken(userID, auth_types.TokenPurposeEmailVerification, hash, time.Now().Add(emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", config.GetString("APP_BASE_URL"), url.QueryEscape(plain))
+
+	p := locale.Printer(userLocale)
+	err = s.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: p.Sprintf(verificationSubject),
+		Body:    p.Sprintf(verificationBody, link, int(emailVerificationTTL.Hours())),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("verification email sent", zap.Int64("user_id", userID))
+	return nil
+}
@@ -0,0 +1,142 @@
+package auth_service
+
+import (
+	"errors"
+	"testing"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVerifyEmail(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		consumeError error
+		markError    error
+		expectError  error
+	}{
+		{
+			name:         "success",
+			consumeError: nil,
+			markError:    nil,
+			expectError:  nil,
+		},
+		{
+			name:         "invalid token",
+			consumeError: auth_errors.ErrInvalidToken,
+			expectError:  auth_errors.ErrInvalidToken,
+		},
+		{
+			name:         "mark verified error",
+			consumeError: nil,
+			markError:    errors.New("db error"),
+			expectError:  errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+
+			mockTokenRepo.
+				On("ConsumeToken", auth_types.TokenPurposeEmailVerification, pkg_token.Hash("plain-token")).
+				Return(int64(1), tt.consumeError)
+
+			if tt.consumeError == nil {
+				mockRepo.On("MarkEmailVerified", int64(1)).Return(tt.markError)
+			}
+
+			service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			err := service.VerifyEmail("plain-token")
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectError.Error())
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		user        *user_types.GetUserResponse
+		userError   error
+		expectSend  bool
+		expectError bool
+	}{
+		{
+			name:       "unverified user receives a new link",
+			user:       &user_types.GetUserResponse{ID: 1, Email: "test@mail.com"},
+			expectSend: true,
+		},
+		{
+			name:       "already verified",
+			user:       &user_types.GetUserResponse{ID: 1, Email: "test@mail.com", EmailVerified: true},
+			expectSend: false,
+		},
+		{
+			name:       "unknown email is silently ignored",
+			userError:  user_errors.ErrUserNotFound,
+			expectSend: false,
+		},
+		{
+			name:        "repository error",
+			userError:   errors.New("db error"),
+			expectSend:  false,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+			mockMailer := new(mailer_mock.MockMailer)
+
+			mockRepo.On("GetUserByEmail", "test@mail.com").Return(tt.user, tt.userError)
+
+			if tt.expectSend {
+				mockTokenRepo.On("RevokeUserTokens", int64(1), auth_types.TokenPurposeEmailVerification).Return(nil)
+				mockTokenRepo.On("CreateToken", int64(1), auth_types.TokenPurposeEmailVerification, mock.Anything, mock.Anything).Return(nil)
+				mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+					return msg.To == "test@mail.com"
+				})).Return(nil)
+			}
+
+			service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), mockMailer, newTestLimiter(), newTestHasher(), nil)
+
+			err := service.ResendVerificationEmail("test@mail.com")
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package auth_service
+
+import (
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Impersonate issues a short-lived access token for another user whose act claim names
+// the admin. It stops working when the admin's session ends. Admins cannot be
+// impersonated, the token would open the admin API to whoever holds it.
+func (s *authService) Impersonate(adminPublicID uuid.UUID, adminSessionID string, userPublicID uuid.UUID) (*auth_types.ImpersonationToken, error) {
+	if adminPublicID == userPublicID {
+		return nil, auth_errors.ErrCannotImpersonate
+	}
+
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == user_types.RoleAdmin {
+		return nil, auth_errors.ErrCannotImpersonate
+	}
+	if !user.IsActive {
+		return nil, auth_errors.ErrUserInactive
+	}
+
+	accessToken, err := pkg_jwt.CreateImpersonationToken(user.PublicID, pkg_jwt.Actor{
+		UserID:    adminPublicID.String(),
+		SessionID: adminSessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("admin started impersonation",
+		zap.String("actor_id", adminPublicID.String()),
+		zap.String("user_id", user.PublicID))
+
+	return &auth_types.ImpersonationToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(pkg_jwt.ImpersonationTTL.Seconds()),
+		UserID:      user.PublicID,
+		ActorID:     adminPublicID.String(),
+	}, nil
+}
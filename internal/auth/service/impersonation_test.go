@@ -0,0 +1,71 @@
+package auth_service
+
+import (
+	"testing"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonate(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.New()
+	userID := uuid.New()
+	sessionID := uuid.NewString()
+
+	tests := []struct {
+		name        string
+		target      uuid.UUID
+		user        *user_types.GetUserResponse
+		lookupError error
+		expectError error
+	}{
+		{name: "success", target: userID, user: &user_types.GetUserResponse{ID: 2, PublicID: userID.String(), Role: user_types.RoleUser, IsActive: true}},
+		{name: "own account", target: adminID, expectError: auth_errors.ErrCannotImpersonate},
+		{name: "another admin", target: userID, user: &user_types.GetUserResponse{ID: 2, PublicID: userID.String(), Role: user_types.RoleAdmin, IsActive: true}, expectError: auth_errors.ErrCannotImpersonate},
+		{name: "deactivated user", target: userID, user: &user_types.GetUserResponse{ID: 2, PublicID: userID.String(), Role: user_types.RoleUser}, expectError: auth_errors.ErrUserInactive},
+		{name: "unknown user", target: userID, lookupError: user_errors.ErrUserNotFound, expectError: user_errors.ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockRepo.On("GetUserByPublicID", tt.target).Return(tt.user, tt.lookupError)
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			impersonation, err := service.Impersonate(adminID, sessionID, tt.target)
+
+			if tt.expectError != nil {
+				assert.Equal(t, tt.expectError, err)
+				assert.Nil(t, impersonation)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "Bearer", impersonation.TokenType)
+			assert.Equal(t, adminID.String(), impersonation.ActorID)
+
+			token, err := pkg_jwt.ParseToken(impersonation.AccessToken)
+			assert.NoError(t, err)
+
+			subject, _ := pkg_jwt.GetUserIDFromToken(token)
+			assert.Equal(t, userID.String(), subject)
+
+			actor, impersonated, err := pkg_jwt.GetActorFromToken(token)
+			assert.NoError(t, err)
+			assert.True(t, impersonated)
+			assert.Equal(t, pkg_jwt.Actor{UserID: adminID.String(), SessionID: sessionID}, actor)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package auth_service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/locale"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CreateInvitation emails a single-use link to set up an account with the given role.
+// Inviting an address again replaces its open invitation. The email is in the admin's
+// language, the invitee has no account to read a preference from.
+func (s *authService) CreateInvitation(adminPublicID uuid.UUID, createRequest auth_types.CreateInvitationRequest) (*auth_types.Invitation, error) {
+	admin, err := s.userRepo.GetUserByPublicID(adminPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(createRequest.Email)
+	role := createRequest.Role
+	if role == "" {
+		role = user_types.RoleUser
+	}
+
+	existing, err := s.userRepo.GetUserByEmail(email)
+	if existing != nil {
+		return nil, auth_errors.ErrUserAlreadyExists
+	} else if err != nil && err != user_errors.ErrUserNotFound {
+		return nil, err
+	}
+
+	plain, hash, err := pkg_token.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.invitationRepo.CreateInvitation(auth_types.Invitation{
+		Email:     email,
+		Role:      role,
+		TokenHash: hash,
+		InvitedBy: &admin.ID,
+		ExpiresAt: time.Now().Add(s.invitationTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	invitation.Inviter = admin.PublicID
+	invitation.Status = auth_types.InvitationPending
+
+	link := fmt.Sprintf("%s/signup/invitation?token=%s", config.GetString("APP_BASE_URL"), url.QueryEscape(plain))
+
+	p := locale.Printer(admin.Preferences.Locale)
+	err = s.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: p.Sprintf(accountInvitationSubject, admin.Name),
+		Body:    p.Sprintf(accountInvitationBody, admin.Name, role, link, int(s.invitationTTL.Hours())),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("user invitation sent", zap.Int64("invitation_id", invitation.ID), zap.Int64("admin_id", admin.ID))
+	return invitation, nil
+}
+
+// ListInvitations returns every invitation, newest first, optionally only those in one
+// state.
+func (s *authService) ListInvitations(listRequest auth_types.ListInvitationsRequest) ([]auth_types.Invitation, error) {
+	invitations, err := s.invitationRepo.ListInvitations()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	filtered := []auth_types.Invitation{}
+	for _, invitation := range invitations {
+		invitation.Status = invitation.State(now)
+		if listRequest.Status == "" || listRequest.Status == invitation.Status {
+			filtered = append(filtered, invitation)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *authService) RevokeInvitation(invitationPublicID uuid.UUID) error {
+	return s.invitationRepo.RevokeInvitation(invitationPublicID)
+}
+
+// AcceptInvitation creates the invited account with the name and password the invitee
+// chose. Opening the link proves the address, the account starts verified.
+func (s *authService) AcceptInvitation(acceptRequest auth_types.AcceptInvitationRequest) (*user_types.GetUserResponse, error) {
+	invitation, err := s.invitationRepo.GetInvitationByTokenHash(pkg_token.Hash(acceptRequest.Token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation.State(time.Now()) != auth_types.InvitationPending {
+		return nil, auth_errors.ErrInvalidInvitation
+	}
+
+	if err := s.passwordPolicy.Validate(acceptRequest.Password, acceptRequest.Name, invitation.Email); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.userRepo.GetUserByEmail(invitation.Email)
+	if existing != nil {
+		return nil, auth_errors.ErrUserAlreadyExists
+	} else if err != nil && err != user_errors.ErrUserNotFound {
+		return nil, err
+	}
+
+	passwordHash, err := s.hasher.Hash(acceptRequest.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := s.invitationRepo.AcceptInvitation(invitation.ID, acceptRequest.Name, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("user invitation accepted", zap.Int64("invitation_id", invitation.ID), zap.Int64("user_id", userID))
+	return s.userRepo.GetUserByID(userID)
+}
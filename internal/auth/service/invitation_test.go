@@ -0,0 +1,198 @@
+package auth_service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.New()
+	admin := &user_types.GetUserResponse{ID: 1, PublicID: adminID.String(), Name: "Ana", Preferences: user_types.Preferences{Locale: "pt-BR"}}
+
+	t.Run("emails a link in the admin's language", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		mockInvitationRepo := new(auth_repository_mock.MockInvitationRepository)
+		mockMailer := new(mailer_mock.MockMailer)
+
+		mockRepo.On("GetUserByPublicID", adminID).Return(admin, nil)
+		mockRepo.On("GetUserByEmail", "bob@example.com").Return(nil, user_errors.ErrUserNotFound)
+		mockInvitationRepo.On("CreateInvitation", mock.MatchedBy(func(i auth_types.Invitation) bool {
+			return i.Email == "bob@example.com" && i.Role == user_types.RoleUser && *i.InvitedBy == 1 &&
+				len(i.TokenHash) == 64 && i.ExpiresAt.After(time.Now().Add(71*time.Hour))
+		})).Return(&auth_types.Invitation{ID: 3, PublicID: uuid.NewString(), Email: "bob@example.com", Role: user_types.RoleUser}, nil)
+		mockMailer.On("Send", mock.MatchedBy(func(m mailer.Message) bool {
+			return m.To == "bob@example.com" && strings.Contains(m.Subject, "convidou") &&
+				strings.Contains(m.Body, "/signup/invitation?token=")
+		})).Return(nil)
+
+		service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), mockInvitationRepo, mockMailer, newTestLimiter(), newTestHasher(), nil)
+
+		invitation, err := service.CreateInvitation(adminID, auth_types.CreateInvitationRequest{Email: " bob@example.com "})
+
+		assert.NoError(t, err)
+		assert.Equal(t, auth_types.InvitationPending, invitation.Status)
+		assert.Equal(t, adminID.String(), invitation.Inviter)
+		mockInvitationRepo.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("address already has an account", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		mockInvitationRepo := new(auth_repository_mock.MockInvitationRepository)
+
+		mockRepo.On("GetUserByPublicID", adminID).Return(admin, nil)
+		mockRepo.On("GetUserByEmail", "bob@example.com").Return(&user_types.GetUserResponse{ID: 2}, nil)
+
+		service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), mockInvitationRepo, new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+		_, err := service.CreateInvitation(adminID, auth_types.CreateInvitationRequest{Email: "bob@example.com", Role: user_types.RoleAdmin})
+
+		assert.Equal(t, auth_errors.ErrUserAlreadyExists, err)
+		mockInvitationRepo.AssertNotCalled(t, "CreateInvitation", mock.Anything)
+	})
+}
+
+func TestListInvitations(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+	mockInvitationRepo := new(auth_repository_mock.MockInvitationRepository)
+	mockInvitationRepo.On("ListInvitations").Return([]auth_types.Invitation{
+		{Email: "pending@example.com", ExpiresAt: now.Add(time.Hour)},
+		{Email: "expired@example.com", ExpiresAt: now.Add(-time.Hour)},
+		{Email: "accepted@example.com", ExpiresAt: now.Add(-time.Hour), AcceptedAt: &now},
+		{Email: "revoked@example.com", ExpiresAt: now.Add(time.Hour), RevokedAt: &now},
+	}, nil)
+
+	service := NewAuthService(new(user_mock.MockUserRepository), new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), mockInvitationRepo, new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	all, err := service.ListInvitations(auth_types.ListInvitationsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{auth_types.InvitationPending, auth_types.InvitationExpired, auth_types.InvitationAccepted, auth_types.InvitationRevoked},
+		[]string{all[0].Status, all[1].Status, all[2].Status, all[3].Status})
+
+	expired, err := service.ListInvitations(auth_types.ListInvitationsRequest{Status: auth_types.InvitationExpired})
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "expired@example.com", expired[0].Email)
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	const token = "raw-token"
+	const password = "Str0ng-Enough#Passw0rd"
+	pending := &auth_types.Invitation{ID: 3, Email: "bob@example.com", Role: user_types.RoleAdmin, ExpiresAt: time.Now().Add(time.Hour)}
+	accepted := time.Now()
+
+	tests := []struct {
+		name         string
+		invitation   *auth_types.Invitation
+		lookupError  error
+		password     string
+		existingUser *user_types.GetUserResponse
+		acceptError  error
+		expectAccept bool
+		expectPolicy bool
+		expectError  error
+	}{
+		{name: "success", invitation: pending, password: password, expectAccept: true},
+		{name: "unknown token", lookupError: auth_errors.ErrInvalidInvitation, password: password, expectError: auth_errors.ErrInvalidInvitation},
+		{name: "already used", invitation: &auth_types.Invitation{ID: 3, Email: "bob@example.com", ExpiresAt: time.Now().Add(time.Hour), AcceptedAt: &accepted}, password: password, expectError: auth_errors.ErrInvalidInvitation},
+		{name: "expired", invitation: &auth_types.Invitation{ID: 3, Email: "bob@example.com", ExpiresAt: time.Now().Add(-time.Minute)}, password: password, expectError: auth_errors.ErrInvalidInvitation},
+		{name: "weak password", invitation: pending, password: "123456", expectPolicy: true},
+		{name: "account created meanwhile", invitation: pending, password: password, existingUser: &user_types.GetUserResponse{ID: 2}, expectError: auth_errors.ErrUserAlreadyExists},
+		{name: "raced with the same link", invitation: pending, password: password, acceptError: auth_errors.ErrInvalidInvitation, expectAccept: true, expectError: auth_errors.ErrInvalidInvitation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockInvitationRepo := new(auth_repository_mock.MockInvitationRepository)
+
+			mockInvitationRepo.On("GetInvitationByTokenHash", pkg_token.Hash(token)).Return(tt.invitation, tt.lookupError)
+			if tt.existingUser != nil {
+				mockRepo.On("GetUserByEmail", "bob@example.com").Return(tt.existingUser, nil)
+			} else {
+				mockRepo.On("GetUserByEmail", "bob@example.com").Return(nil, user_errors.ErrUserNotFound)
+			}
+			mockInvitationRepo.On("AcceptInvitation", int64(3), "Bob Silva", mock.Anything).Return(int64(9), tt.acceptError)
+			mockRepo.On("GetUserByID", int64(9)).Return(&user_types.GetUserResponse{ID: 9, Email: "bob@example.com", Role: user_types.RoleAdmin, IsActive: true, EmailVerified: true}, nil)
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), mockInvitationRepo, new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			user, err := service.AcceptInvitation(auth_types.AcceptInvitationRequest{Token: token, Name: "Bob Silva", Password: tt.password})
+
+			switch {
+			case tt.expectPolicy:
+				var passwordErr *password_errors.PasswordError
+				assert.True(t, errors.As(err, &passwordErr))
+			case tt.expectError != nil:
+				assert.Equal(t, tt.expectError, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, user_types.RoleAdmin, user.Role)
+			}
+
+			if tt.expectAccept {
+				// the password is stored hashed
+				mockInvitationRepo.AssertCalled(t, "AcceptInvitation", int64(3), "Bob Silva", mock.MatchedBy(func(hash string) bool {
+					return hash != "" && hash != tt.password
+				}))
+			} else {
+				mockInvitationRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSelfRegistrationDisabled(t *testing.T) {
+	logger.Init("dev")
+	t.Setenv("AUTH_ALLOW_SELF_REGISTRATION", "false")
+
+	t.Run("register", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+
+		service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+		_, err := service.RegisterUser(auth_types.CreateUserRequest{Name: "Célio", Email: "celio@email.com", Password: "Str0ng-Enough#Passw0rd"})
+
+		assert.Equal(t, auth_errors.ErrRegistrationDisabled, err)
+		mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+	})
+
+	t.Run("social login of a new address", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		mockIdentityRepo := new(auth_repository_mock.MockIdentityRepository)
+
+		mockIdentityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(0), auth_errors.ErrIdentityNotFound)
+		mockRepo.On("GetUserByEmail", "new@example.com").Return(nil, user_errors.ErrUserNotFound)
+
+		service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), mockIdentityRepo, new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+		_, err := service.LoginWithIdentity(auth_types.ExternalIdentity{Provider: "google", Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+
+		assert.Equal(t, auth_errors.ErrRegistrationDisabled, err)
+		mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+	})
+}
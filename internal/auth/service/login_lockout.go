@@ -0,0 +1,33 @@
+package auth_service
+
+import (
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+)
+
+// dummyPasswordHash is compared against when the email is unknown, it is built
+// with the current hasher so both paths take the same time.
+func (s *authService) dummyPasswordHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.hasher.Hash("prodify-dummy-password")
+	})
+	return s.dummyHash
+}
+
+func (s *authService) UnlockLogin(unlockRequest auth_types.UnlockLoginRequest) error {
+	return s.limiter.Unlock(unlockRequest.Email, unlockRequest.IP)
+}
+
+func (s *authService) HasRole(userPublicID string, role string) (bool, error) {
+	publicID, err := uuidvalidator.ValidateUuid(userPublicID)
+	if err != nil {
+		return false, err
+	}
+
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.Role == role, nil
+}
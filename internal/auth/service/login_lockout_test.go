@@ -0,0 +1,193 @@
+package auth_service
+
+import (
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	"github.com/celio001/prodify/pkg/password-hasher/hasher"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestLimiter() *auth_lockout.Limiter {
+	return auth_lockout.NewLimiter(auth_lockout.NewMemoryStore(),
+		auth_lockout.Policy{FreeAttempts: 2, BaseDelay: time.Minute, MaxFailures: 3, LockoutDuration: time.Hour, Window: time.Hour},
+		auth_lockout.Policy{FreeAttempts: 10, BaseDelay: time.Minute, MaxFailures: 20, LockoutDuration: time.Hour, Window: time.Hour},
+	)
+}
+
+func newTestHasher() hasher.Hasher {
+	return hasher.NewBcryptHasher(bcrypt.DefaultCost)
+}
+
+func TestLogin_Lockout(t *testing.T) {
+	logger.Init("dev")
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
+	user := &user_types.GetUserResponse{Email: "test@mail.com", PasswordHash: string(hash), IsActive: true}
+	client := auth_types.ClientInfo{IP: "127.0.0.1"}
+
+	tests := []struct {
+		name  string
+		user  *user_types.GetUserResponse
+		err   error
+		email string
+	}{
+		{
+			name:  "existing account",
+			user:  user,
+			err:   nil,
+			email: "test@mail.com",
+		},
+		{
+			name:  "unknown account",
+			user:  nil,
+			err:   user_errors.ErrUserNotFound,
+			email: "ghost@mail.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockRepo.On("GetUserByEmail", tt.email).Return(tt.user, tt.err)
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			wrong := auth_types.LoginRequest{Email: tt.email, Password: "wrong-password"}
+
+			// free attempts get the generic message
+			for i := 0; i < 2; i++ {
+				_, err := service.Login(wrong, client)
+				assert.ErrorIs(t, err, auth_errors.ErrMatchDataUser)
+			}
+
+			// the third failure is still reported as a bad password, the lock applies afterwards
+			_, err := service.Login(wrong, client)
+			assert.ErrorIs(t, err, auth_errors.ErrMatchDataUser)
+
+			_, err = service.Login(auth_types.LoginRequest{Email: tt.email, Password: "123456"}, client)
+
+			var lockoutErr *auth_errors.LockoutError
+			assert.ErrorAs(t, err, &lockoutErr)
+			assert.ErrorIs(t, err, auth_errors.ErrTooManyLoginAttempts)
+			assert.Greater(t, lockoutErr.RetryAfter, 59*time.Minute)
+			mockRepo.AssertNumberOfCalls(t, "GetUserByEmail", 3)
+		})
+	}
+}
+
+func TestLogin_SuccessResetsFailures(t *testing.T) {
+	logger.Init("dev")
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
+	user := &user_types.GetUserResponse{Email: "test@mail.com", PasswordHash: string(hash), IsActive: true}
+	client := auth_types.ClientInfo{IP: "127.0.0.1"}
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByEmail", "test@mail.com").Return(user, nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	wrong := auth_types.LoginRequest{Email: "test@mail.com", Password: "wrong-password"}
+	right := auth_types.LoginRequest{Email: "test@mail.com", Password: "123456"}
+
+	_, _ = service.Login(wrong, client)
+	_, _ = service.Login(wrong, client)
+
+	_, err := service.Login(right, client)
+	assert.NoError(t, err)
+
+	// counter was reset, two more free failures are allowed
+	_, _ = service.Login(wrong, client)
+	_, _ = service.Login(wrong, client)
+
+	_, err = service.Login(right, client)
+	assert.NoError(t, err)
+}
+
+func TestUnlockLogin(t *testing.T) {
+	logger.Init("dev")
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
+	user := &user_types.GetUserResponse{Email: "test@mail.com", PasswordHash: string(hash), IsActive: true}
+	client := auth_types.ClientInfo{IP: "127.0.0.1"}
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByEmail", "test@mail.com").Return(user, nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	for i := 0; i < 3; i++ {
+		_, _ = service.Login(auth_types.LoginRequest{Email: "test@mail.com", Password: "wrong-password"}, client)
+	}
+
+	right := auth_types.LoginRequest{Email: "test@mail.com", Password: "123456"}
+
+	_, err := service.Login(right, client)
+	assert.ErrorIs(t, err, auth_errors.ErrTooManyLoginAttempts)
+
+	assert.NoError(t, service.UnlockLogin(auth_types.UnlockLoginRequest{Email: "test@mail.com"}))
+
+	_, err = service.Login(right, client)
+	assert.NoError(t, err)
+}
+
+func TestHasRole(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+
+	tests := []struct {
+		name        string
+		mockReturn  *user_types.GetUserResponse
+		mockError   error
+		expected    bool
+		expectError bool
+	}{
+		{
+			name:       "admin",
+			mockReturn: &user_types.GetUserResponse{Role: user_types.RoleAdmin},
+			expected:   true,
+		},
+		{
+			name:       "regular user",
+			mockReturn: &user_types.GetUserResponse{Role: user_types.RoleUser},
+			expected:   false,
+		},
+		{
+			name:        "user not found",
+			mockError:   user_errors.ErrUserNotFound,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockRepo.On("GetUserByPublicID", mock.Anything).Return(tt.mockReturn, tt.mockError)
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			ok, err := service.HasRole(userPublicID.String(), user_types.RoleAdmin)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, ok)
+		})
+	}
+}
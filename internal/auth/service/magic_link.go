@@ -0,0 +1,101 @@
+package auth_service
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/locale"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"go.uber.org/zap"
+)
+
+const magicLinkTTL = 10 * time.Minute
+
+// RequestMagicLink is rate limited per email before the account is looked up, and the
+// email is sent in the background, so neither the status nor the timing tells whether
+// the email belongs to an account.
+func (s *authService) RequestMagicLink(email string) error {
+	if err := s.limiter.Allow(auth_lockout.MagicLinkKey(email), s.magicLinkRate); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.sendMagicLinkEmail(email); err != nil {
+			logger.Log.Error("failed to send magic link email", zap.String("error", err.Error()))
+		}
+	}()
+	return nil
+}
+
+// LoginWithMagicLink consumes the emailed token. Opening the link proves the user owns
+// the address, so an unverified email is marked as verified. It is refused while an
+// admin requires a password reset, the mailbox may be what got compromised.
+func (s *authService) LoginWithMagicLink(token string) (user_types.GetUserResponse, error) {
+	userID, err := s.tokenRepo.ConsumeToken(auth_types.TokenPurposeMagicLink, pkg_token.Hash(token))
+	if err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
+	if !user.IsActive {
+		return user_types.GetUserResponse{}, auth_errors.ErrUserInactive
+	}
+
+	if user.PasswordResetRequired {
+		return user_types.GetUserResponse{}, auth_errors.ErrPasswordReset
+	}
+
+	if !user.EmailVerified {
+		if err := s.userRepo.MarkEmailVerified(user.ID); err != nil {
+			return user_types.GetUserResponse{}, err
+		}
+		user.EmailVerified = true
+	}
+
+	return *user, nil
+}
+
+func (s *authService) sendMagicLinkEmail(email string) error {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if err == user_errors.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeUserTokens(user.ID, auth_types.TokenPurposeMagicLink); err != nil {
+		return err
+	}
+
+	plain, hash, err := pkg_token.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.CreateToken(user.ID, auth_types.TokenPurposeMagicLink, hash, time.Now().Add(magicLinkTTL)); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/magic-link?token=%s", config.GetString("APP_BASE_URL"), url.QueryEscape(plain))
+
+	p := locale.Printer(user.Preferences.Locale)
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: p.Sprintf(magicLinkSubject),
+		Body:    p.Sprintf(magicLinkBody, link, int(magicLinkTTL.Minutes())),
+	})
+}
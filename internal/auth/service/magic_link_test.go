@@ -0,0 +1,180 @@
+package auth_service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/mailer"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSendMagicLinkEmail(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		user        *user_types.GetUserResponse
+		userError   error
+		expectSend  bool
+		expectError bool
+	}{
+		{
+			name:       "existing user receives a link",
+			user:       &user_types.GetUserResponse{ID: 1, Email: "test@mail.com"},
+			expectSend: true,
+		},
+		{
+			name:       "unknown email is silently ignored",
+			userError:  user_errors.ErrUserNotFound,
+			expectSend: false,
+		},
+		{
+			name:        "repository error",
+			userError:   errors.New("db error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+			mockMailer := new(mailer_mock.MockMailer)
+
+			mockRepo.On("GetUserByEmail", "test@mail.com").Return(tt.user, tt.userError)
+
+			if tt.expectSend {
+				mockTokenRepo.On("RevokeUserTokens", int64(1), auth_types.TokenPurposeMagicLink).Return(nil)
+				mockTokenRepo.On("CreateToken", int64(1), auth_types.TokenPurposeMagicLink, mock.Anything, mock.MatchedBy(func(expiresAt time.Time) bool {
+					return time.Until(expiresAt) <= 10*time.Minute
+				})).Return(nil)
+				mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+					return msg.To == "test@mail.com"
+				})).Return(nil)
+			}
+
+			service := &authService{userRepo: mockRepo, tokenRepo: mockTokenRepo, mailer: mockMailer}
+
+			err := service.sendMagicLinkEmail("test@mail.com")
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRequestMagicLink_RateLimited(t *testing.T) {
+	mockRepo := new(user_mock.MockUserRepository)
+	mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+
+	// the background send finds no account, the limit applies all the same
+	mockRepo.On("GetUserByEmail", mock.Anything).Return(nil, user_errors.ErrUserNotFound)
+
+	service := &authService{
+		userRepo:      mockRepo,
+		tokenRepo:     mockTokenRepo,
+		mailer:        new(mailer_mock.MockMailer),
+		limiter:       newTestLimiter(),
+		magicLinkRate: auth_lockout.RateLimit{Limit: 2, Window: time.Hour},
+	}
+
+	assert.NoError(t, service.RequestMagicLink("ghost@mail.com"))
+	assert.NoError(t, service.RequestMagicLink("ghost@mail.com"))
+
+	err := service.RequestMagicLink("ghost@mail.com")
+
+	var lockoutErr *auth_errors.LockoutError
+	assert.ErrorAs(t, err, &lockoutErr)
+	assert.Greater(t, lockoutErr.RetryAfter, 59*time.Minute)
+
+	// the password login of the same email is untouched
+	assert.NoError(t, service.limiter.Check("ghost@mail.com", ""))
+}
+
+func TestLoginWithMagicLink(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		user         *user_types.GetUserResponse
+		consumeError error
+		userError    error
+		expectMark   bool
+		expectError  error
+	}{
+		{
+			name: "success",
+			user: &user_types.GetUserResponse{ID: 1, Email: "test@mail.com", IsActive: true, EmailVerified: true},
+		},
+		{
+			name:       "unverified email is verified by the link",
+			user:       &user_types.GetUserResponse{ID: 1, Email: "test@mail.com", IsActive: true},
+			expectMark: true,
+		},
+		{
+			name:        "password reset required",
+			user:        &user_types.GetUserResponse{ID: 1, Email: "test@mail.com", IsActive: true, EmailVerified: true, PasswordResetRequired: true},
+			expectError: auth_errors.ErrPasswordReset,
+		},
+		{
+			name:         "invalid token",
+			consumeError: auth_errors.ErrInvalidToken,
+			expectError:  auth_errors.ErrInvalidToken,
+		},
+		{
+			name:        "user deleted",
+			userError:   user_errors.ErrUserNotFound,
+			expectError: user_errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+
+			mockTokenRepo.
+				On("ConsumeToken", auth_types.TokenPurposeMagicLink, pkg_token.Hash("plain-token")).
+				Return(int64(1), tt.consumeError)
+
+			if tt.consumeError == nil {
+				mockRepo.On("GetUserByID", int64(1)).Return(tt.user, tt.userError)
+			}
+			if tt.expectMark {
+				mockRepo.On("MarkEmailVerified", int64(1)).Return(nil)
+			}
+
+			service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			user, err := service.LoginWithMagicLink("plain-token")
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, "test@mail.com", user.Email)
+				assert.True(t, user.EmailVerified)
+			} else {
+				assert.ErrorIs(t, err, tt.expectError)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package auth_service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"go.uber.org/zap"
+)
+
+const (
+	mfaPeriod         = 30
+	mfaSkew           = 1
+	mfaQRCodeSize     = 256
+	recoveryCodeCount = 10
+)
+
+var mfaValidateOpts = totp.ValidateOpts{
+	Period:    mfaPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// EnrollMFA creates a new pending secret. It only becomes active once ConfirmMFA
+// receives a valid code, so a half finished enrollment never locks the user out.
+func (s *authService) EnrollMFA(userPublicID uuid.UUID) (*auth_types.MFAEnrollmentResponse, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      config.GetString("MFA_ISSUER"),
+		AccountName: user.Email,
+		Period:      mfaPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.SavePendingSecret(user.ID, key.Secret()); err != nil {
+		return nil, err
+	}
+
+	img, err := key.Image(mfaQRCodeSize, mfaQRCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		return nil, err
+	}
+
+	return &auth_types.MFAEnrollmentResponse{
+		Secret:     key.Secret(),
+		OTPAuthURI: key.URL(),
+		QRCodePNG:  base64.StdEncoding.EncodeToString(qr.Bytes()),
+	}, nil
+}
+
+// ConfirmMFA enables MFA with the first valid code and returns the recovery codes.
+// The plain codes are only available in this response.
+func (s *authService) ConfirmMFA(userPublicID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.mfaRepo.GetMFA(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, auth_errors.ErrMFAAlreadyEnabled
+	}
+
+	step, ok := matchTOTP(mfa.Secret, code, time.Now())
+	if !ok {
+		return nil, auth_errors.ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.EnableMFA(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	// burn the confirmation code so it can't be used again to log in
+	if _, err := s.mfaRepo.UseTOTPStep(user.ID, step); err != nil {
+		logger.Log.Error("failed to mark totp step as used", zap.String("error", err.Error()))
+	}
+
+	return codes, nil
+}
+
+// VerifyMFALogin is the second login step. code is either a TOTP code or a recovery code,
+// failures count towards the same lockout as wrong passwords.
+func (s *authService) VerifyMFALogin(userPublicID string, code string, client auth_types.ClientInfo) (user_types.GetUserResponse, error) {
+	publicID, err := uuidvalidator.ValidateUuid(userPublicID)
+	if err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
+	if err := s.limiter.Check(user.Email, client.IP); err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
+	ok, err := s.verifySecondFactor(user.ID, code)
+	if err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
+	if !ok {
+		if err := s.limiter.RegisterFailure(user.Email, client.IP); err != nil {
+			logger.Log.Error("failed to register login failure", zap.String("error", err.Error()))
+		}
+		return user_types.GetUserResponse{}, auth_errors.ErrInvalidMFACode
+	}
+
+	if err := s.limiter.RegisterSuccess(user.Email); err != nil {
+		logger.Log.Error("failed to reset login failures", zap.String("error", err.Error()))
+	}
+
+	return *user, nil
+}
+
+// ResetMFA removes the secret and recovery codes so the user can log in with
+// the password alone and enroll again.
+func (s *authService) ResetMFA(userPublicID uuid.UUID) error {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return err
+	}
+
+	return s.mfaRepo.DeleteMFA(user.ID)
+}
+
+func (s *authService) verifySecondFactor(userID int64, code string) (bool, error) {
+	mfa, err := s.mfaRepo.GetMFA(userID)
+	if err == auth_errors.ErrMFANotEnrolled {
+		// MFA was reset after the pending token was issued
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !mfa.Enabled {
+		return false, nil
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == int(otp.DigitsSix) {
+		step, ok := matchTOTP(mfa.Secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return s.mfaRepo.UseTOTPStep(userID, step)
+	}
+
+	return s.mfaRepo.ConsumeRecoveryCode(userID, pkg_token.Hash(normalizeRecoveryCode(code)))
+}
+
+// matchTOTP accepts the current period and one on each side for clock drift.
+// It returns the matching time step so the caller can reject replays.
+func matchTOTP(secret string, code string, now time.Time) (int64, bool) {
+	for skew := -mfaSkew; skew <= mfaSkew; skew++ {
+		t := now.Add(time.Duration(skew*mfaPeriod) * time.Second)
+
+		expected, err := totp.GenerateCodeCustom(secret, t, mfaValidateOpts)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return t.Unix() / mfaPeriod, true
+		}
+	}
+	return 0, false
+}
+
+// generateRecoveryCodes returns codes formatted as xxxx-xxxx-xxxx-xxxx (80 random bits)
+// and their hashes.
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		raw := strings.ToLower(encoding.EncodeToString(b))
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, pkg_token.Hash(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
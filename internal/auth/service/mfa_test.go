@@ -0,0 +1,300 @@
+package auth_service
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testMFASecret = "JBSWY3DPEHPK3PXP"
+
+func TestEnrollMFA(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", userPublicID).
+		Return(&user_types.GetUserResponse{ID: 1, Email: "test@mail.com"}, nil)
+
+	mockMFARepo := new(auth_repository_mock.MockMFARepository)
+	mockMFARepo.On("SavePendingSecret", int64(1), mock.Anything).Return(nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), mockMFARepo, new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	enrollment, err := service.EnrollMFA(userPublicID)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/"))
+	assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+	png, err := base64.StdEncoding.DecodeString(enrollment.QRCodePNG)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x89PNG", string(png[:4]))
+
+	mockMFARepo.AssertCalled(t, "SavePendingSecret", int64(1), enrollment.Secret)
+}
+
+func TestEnrollMFA_AlreadyEnabled(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", userPublicID).
+		Return(&user_types.GetUserResponse{ID: 1, Email: "test@mail.com"}, nil)
+
+	mockMFARepo := new(auth_repository_mock.MockMFARepository)
+	mockMFARepo.On("SavePendingSecret", int64(1), mock.Anything).Return(auth_errors.ErrMFAAlreadyEnabled)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), mockMFARepo, new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	_, err := service.EnrollMFA(userPublicID)
+
+	assert.ErrorIs(t, err, auth_errors.ErrMFAAlreadyEnabled)
+}
+
+func TestConfirmMFA(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+	validCode, _ := totp.GenerateCode(testMFASecret, time.Now())
+
+	tests := []struct {
+		name         string
+		mfa          *auth_types.MFA
+		mfaError     error
+		code         string
+		expectEnable bool
+		expectError  error
+	}{
+		{
+			name:         "success",
+			mfa:          &auth_types.MFA{Secret: testMFASecret},
+			code:         validCode,
+			expectEnable: true,
+		},
+		{
+			name:        "wrong code",
+			mfa:         &auth_types.MFA{Secret: testMFASecret},
+			code:        "000000",
+			expectError: auth_errors.ErrInvalidMFACode,
+		},
+		{
+			name:        "already enabled",
+			mfa:         &auth_types.MFA{Secret: testMFASecret, Enabled: true},
+			code:        validCode,
+			expectError: auth_errors.ErrMFAAlreadyEnabled,
+		},
+		{
+			name:        "not enrolled",
+			mfaError:    auth_errors.ErrMFANotEnrolled,
+			code:        validCode,
+			expectError: auth_errors.ErrMFANotEnrolled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockRepo.On("GetUserByPublicID", userPublicID).
+				Return(&user_types.GetUserResponse{ID: 1, Email: "test@mail.com"}, nil)
+
+			mockMFARepo := new(auth_repository_mock.MockMFARepository)
+			mockMFARepo.On("GetMFA", int64(1)).Return(tt.mfa, tt.mfaError)
+			if tt.expectEnable {
+				mockMFARepo.On("EnableMFA", int64(1), mock.Anything).Return(nil)
+				mockMFARepo.On("UseTOTPStep", int64(1), mock.Anything).Return(true, nil)
+			}
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), mockMFARepo, new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			codes, err := service.ConfirmMFA(userPublicID, tt.code)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				mockMFARepo.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, codes, recoveryCodeCount)
+
+			// only hashes are stored, and they match the normalised codes returned to the user
+			hashes := mockMFARepo.Calls[1].Arguments.Get(1).([]string)
+			assert.Len(t, hashes, recoveryCodeCount)
+			for i, code := range codes {
+				assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+				assert.Equal(t, pkg_token.Hash(normalizeRecoveryCode(code)), hashes[i])
+			}
+		})
+	}
+}
+
+func TestVerifyMFALogin(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+	user := &user_types.GetUserResponse{ID: 1, PublicID: userPublicID.String(), Email: "test@mail.com"}
+	client := auth_types.ClientInfo{IP: "127.0.0.1"}
+
+	validCode, _ := totp.GenerateCode(testMFASecret, time.Now())
+
+	tests := []struct {
+		name        string
+		code        string
+		setup       func(m *auth_repository_mock.MockMFARepository)
+		expectError error
+	}{
+		{
+			name: "valid totp code",
+			code: validCode,
+			setup: func(m *auth_repository_mock.MockMFARepository) {
+				m.On("UseTOTPStep", int64(1), mock.Anything).Return(true, nil)
+			},
+		},
+		{
+			name: "replayed totp code",
+			code: validCode,
+			setup: func(m *auth_repository_mock.MockMFARepository) {
+				m.On("UseTOTPStep", int64(1), mock.Anything).Return(false, nil)
+			},
+			expectError: auth_errors.ErrInvalidMFACode,
+		},
+		{
+			name: "valid recovery code",
+			code: "ABCD-EFGH-IJKL-MNOP",
+			setup: func(m *auth_repository_mock.MockMFARepository) {
+				m.On("ConsumeRecoveryCode", int64(1), pkg_token.Hash("abcdefghijklmnop")).Return(true, nil)
+			},
+		},
+		{
+			name: "used recovery code",
+			code: "abcd-efgh-ijkl-mnop",
+			setup: func(m *auth_repository_mock.MockMFARepository) {
+				m.On("ConsumeRecoveryCode", int64(1), pkg_token.Hash("abcdefghijklmnop")).Return(false, nil)
+			},
+			expectError: auth_errors.ErrInvalidMFACode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockRepo.On("GetUserByPublicID", userPublicID).Return(user, nil)
+
+			mockMFARepo := new(auth_repository_mock.MockMFARepository)
+			mockMFARepo.On("GetMFA", int64(1)).Return(&auth_types.MFA{Secret: testMFASecret, Enabled: true}, nil)
+			tt.setup(mockMFARepo)
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), mockMFARepo, new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			result, err := service.VerifyMFALogin(userPublicID.String(), tt.code, client)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, user.PublicID, result.PublicID)
+			}
+			mockMFARepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyMFALogin_Lockout(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+	client := auth_types.ClientInfo{IP: "127.0.0.1"}
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", userPublicID).
+		Return(&user_types.GetUserResponse{ID: 1, Email: "test@mail.com"}, nil)
+
+	mockMFARepo := new(auth_repository_mock.MockMFARepository)
+	mockMFARepo.On("GetMFA", int64(1)).Return(&auth_types.MFA{Secret: testMFASecret, Enabled: true}, nil)
+	mockMFARepo.On("ConsumeRecoveryCode", int64(1), mock.Anything).Return(false, nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), mockMFARepo, new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := service.VerifyMFALogin(userPublicID.String(), "not-a-code", client)
+		assert.ErrorIs(t, err, auth_errors.ErrInvalidMFACode)
+	}
+
+	_, err := service.VerifyMFALogin(userPublicID.String(), "not-a-code", client)
+	assert.ErrorIs(t, err, auth_errors.ErrTooManyLoginAttempts)
+}
+
+func TestVerifyMFALogin_MFAReset(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", userPublicID).
+		Return(&user_types.GetUserResponse{ID: 1, Email: "test@mail.com"}, nil)
+
+	mockMFARepo := new(auth_repository_mock.MockMFARepository)
+	mockMFARepo.On("GetMFA", int64(1)).Return(nil, auth_errors.ErrMFANotEnrolled)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), mockMFARepo, new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	_, err := service.VerifyMFALogin(userPublicID.String(), "123456", auth_types.ClientInfo{})
+
+	assert.ErrorIs(t, err, auth_errors.ErrInvalidMFACode)
+}
+
+func TestResetMFA(t *testing.T) {
+	logger.Init("dev")
+
+	userPublicID := uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", userPublicID).
+		Return(&user_types.GetUserResponse{ID: 7}, nil)
+
+	mockMFARepo := new(auth_repository_mock.MockMFARepository)
+	mockMFARepo.On("DeleteMFA", int64(7)).Return(nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), mockMFARepo, new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	assert.NoError(t, service.ResetMFA(userPublicID))
+	mockMFARepo.AssertExpectations(t)
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	current, _ := totp.GenerateCode(testMFASecret, now)
+	previous, _ := totp.GenerateCode(testMFASecret, now.Add(-30*time.Second))
+	stale, _ := totp.GenerateCode(testMFASecret, now.Add(-90*time.Second))
+
+	step, ok := matchTOTP(testMFASecret, current, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30, step)
+
+	step, ok = matchTOTP(testMFASecret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30-1, step)
+
+	_, ok = matchTOTP(testMFASecret, stale, now)
+	assert.False(t, ok)
+}
@@ -1,6 +1,8 @@
 package auth_mock
 
 import (
+	"time"
+
 	auth_types "github.com/celio001/prodify/internal/auth/types"
 	user_types "github.com/celio001/prodify/internal/user/type"
 	"github.com/stretchr/testify/mock"
@@ -11,8 +13,8 @@ type MockAuthService struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) Login(req auth_types.LoginRequest) (user_types.GetUserResponse, error) {
-	args := m.Called(req)
+func (m *MockAuthService) Login(req auth_types.LoginRequest, client auth_types.ClientInfo) (user_types.GetUserResponse, error) {
+	args := m.Called(req, client)
 
 	return args.Get(0).(user_types.GetUserResponse), args.Error(1)
 }
@@ -28,4 +30,172 @@ func (m *MockAuthService) RegisterUser(user auth_types.CreateUserRequest) (*auth
 func (m *MockAuthService) ResetPassword(userPublicID uuid.UUID, resetPasswordRequest auth_types.ResetPasswordRequest) error {
 	args := m.Called(userPublicID, resetPasswordRequest)
 	return args.Error(0)
-}
\ No newline at end of file
+}
+func (m *MockAuthService) VerifyEmail(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResendVerificationEmail(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RequestEmailChange(userPublicID uuid.UUID, newEmail string) error {
+	args := m.Called(userPublicID, newEmail)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ConfirmEmailChange(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ForgotPassword(email string) {
+	m.Called(email)
+}
+
+func (m *MockAuthService) LookupAccount(email string) (string, error) {
+	args := m.Called(email)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) RequestPasswordReset(email string, client auth_types.ClientInfo) error {
+	args := m.Called(email, client)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ConfirmPasswordReset(confirmRequest auth_types.ConfirmPasswordResetRequest) (string, error) {
+	args := m.Called(confirmRequest)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) CheckPasswordStrength(request auth_types.PasswordStrengthRequest, client auth_types.ClientInfo) (*auth_types.PasswordStrengthResponse, error) {
+	args := m.Called(request, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.PasswordStrengthResponse), args.Error(1)
+}
+
+func (m *MockAuthService) RequestMagicLink(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LoginWithMagicLink(token string) (user_types.GetUserResponse, error) {
+	args := m.Called(token)
+	return args.Get(0).(user_types.GetUserResponse), args.Error(1)
+}
+
+func (m *MockAuthService) CheckSession(userPublicID string, sessionID string, issuedAt time.Time) error {
+	args := m.Called(userPublicID, sessionID, issuedAt)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) IssueTokens(userPublicID string, client auth_types.ClientInfo) (*auth_types.TokenPair, error) {
+	args := m.Called(userPublicID, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthService) RefreshTokens(refreshToken string, client auth_types.ClientInfo) (*auth_types.TokenPair, error) {
+	args := m.Called(refreshToken, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.TokenPair), args.Error(1)
+}
+
+func (m *MockAuthService) ListSessions(userPublicID uuid.UUID, currentSessionID string) ([]auth_types.Session, error) {
+	args := m.Called(userPublicID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth_types.Session), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(userPublicID uuid.UUID, sessionID uuid.UUID) error {
+	args := m.Called(userPublicID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) HasRole(userPublicID string, role string) (bool, error) {
+	args := m.Called(userPublicID, role)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthService) UnlockLogin(unlockRequest auth_types.UnlockLoginRequest) error {
+	args := m.Called(unlockRequest)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) EnrollMFA(userPublicID uuid.UUID) (*auth_types.MFAEnrollmentResponse, error) {
+	args := m.Called(userPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.MFAEnrollmentResponse), args.Error(1)
+}
+
+func (m *MockAuthService) ConfirmMFA(userPublicID uuid.UUID, code string) ([]string, error) {
+	args := m.Called(userPublicID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAuthService) VerifyMFALogin(userPublicID string, code string, client auth_types.ClientInfo) (user_types.GetUserResponse, error) {
+	args := m.Called(userPublicID, code, client)
+	return args.Get(0).(user_types.GetUserResponse), args.Error(1)
+}
+
+func (m *MockAuthService) ResetMFA(userPublicID uuid.UUID) error {
+	args := m.Called(userPublicID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LoginWithIdentity(identity auth_types.ExternalIdentity) (user_types.GetUserResponse, error) {
+	args := m.Called(identity)
+	return args.Get(0).(user_types.GetUserResponse), args.Error(1)
+}
+
+func (m *MockAuthService) CreateInvitation(adminPublicID uuid.UUID, createRequest auth_types.CreateInvitationRequest) (*auth_types.Invitation, error) {
+	args := m.Called(adminPublicID, createRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.Invitation), args.Error(1)
+}
+
+func (m *MockAuthService) ListInvitations(listRequest auth_types.ListInvitationsRequest) ([]auth_types.Invitation, error) {
+	args := m.Called(listRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth_types.Invitation), args.Error(1)
+}
+
+func (m *MockAuthService) RevokeInvitation(invitationPublicID uuid.UUID) error {
+	args := m.Called(invitationPublicID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) AcceptInvitation(acceptRequest auth_types.AcceptInvitationRequest) (*user_types.GetUserResponse, error) {
+	args := m.Called(acceptRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user_types.GetUserResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Impersonate(adminPublicID uuid.UUID, adminSessionID string, userPublicID uuid.UUID) (*auth_types.ImpersonationToken, error) {
+	args := m.Called(adminPublicID, adminSessionID, userPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth_types.ImpersonationToken), args.Error(1)
+}
@@ -0,0 +1,83 @@
+package auth_service
+
+import (
+	"github.com/celio001/prodify/config"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/password-validator/validator"
+	"go.uber.org/zap"
+)
+
+func newPasswordPolicy(breaches validator.BreachChecker) validator.Policy {
+	return validator.Policy{
+		MinEntropy:     float64(config.GetInt("PASSWORD_MIN_ENTROPY")),
+		MinScore:       config.GetInt("PASSWORD_MIN_SCORE"),
+		MinLength:      config.GetInt("PASSWORD_MIN_LENGTH"),
+		MaxLength:      config.GetInt("PASSWORD_MAX_LENGTH"),
+		RequireLower:   config.GetBool("PASSWORD_REQUIRE_LOWER"),
+		RequireUpper:   config.GetBool("PASSWORD_REQUIRE_UPPER"),
+		RequireDigits:  config.GetBool("PASSWORD_REQUIRE_DIGITS"),
+		RequireSpecial: config.GetBool("PASSWORD_REQUIRE_SPECIAL"),
+		Breaches:       breaches,
+	}
+}
+
+// changePassword is shared by every path that sets a new password for an existing user:
+// the policy is checked first, then the new password is compared with the current hash
+// and the last passwordHistorySize hashes. Once stored, every existing session is revoked.
+func (s *authService) changePassword(user *user_types.GetUserResponse, newPassword string) error {
+	if err := s.checkNewPassword(user, newPassword); err != nil {
+		return err
+	}
+
+	return s.storePassword(user, newPassword)
+}
+
+// checkNewPassword runs the checks of changePassword without storing anything, for
+// callers that have to spend something, like a single-use token, in between.
+func (s *authService) checkNewPassword(user *user_types.GetUserResponse, newPassword string) error {
+	if err := s.passwordPolicy.Validate(newPassword, user.Name, user.Email); err != nil {
+		return err
+	}
+
+	if s.hasher.Compare(user.PasswordHash, newPassword) == nil {
+		return user_errors.ErrSamePassword
+	}
+
+	if s.passwordHistorySize > 0 {
+		history, err := s.userRepo.GetPasswordHistory(user.ID, s.passwordHistorySize)
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range history {
+			if s.hasher.Compare(hash, newPassword) == nil {
+				return user_errors.ErrPasswordReused
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *authService) storePassword(user *user_types.GetUserResponse, newPassword string) error {
+	if err := s.userRepo.UpdateUserPassword(user.ID, newPassword); err != nil {
+		return err
+	}
+
+	return s.userRepo.RevokeUserSessions(user.ID)
+}
+
+// upgradePasswordHash is called after a successful login, while the plain password is
+// at hand, to rehash it when the stored hash uses an older algorithm or weaker parameters.
+// A failure only means the upgrade is retried on the next login.
+func (s *authService) upgradePasswordHash(user *user_types.GetUserResponse, password string) {
+	if !s.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	if err := s.userRepo.UpgradePasswordHash(user.ID, user.PasswordHash, password); err != nil {
+		logger.Log.Error("failed to upgrade password hash", zap.String("error", err.Error()))
+	}
+}
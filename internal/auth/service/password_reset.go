@@ -0,0 +1,112 @@
+package auth_service
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	"github.com/celio001/prodify/pkg/locale"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"go.uber.org/zap"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+// RequestPasswordReset is the public entry to ForgotPassword. It is rate limited per IP
+// and per email before the account is looked up, since every email sent also revokes the
+// links sent before it.
+func (s *authService) RequestPasswordReset(email string, client auth_types.ClientInfo) error {
+	if err := s.limiter.Allow(auth_lockout.PasswordResetIPKey(client.IP), s.passwordResetIPRate); err != nil {
+		return err
+	}
+	if err := s.limiter.Allow(auth_lockout.PasswordResetKey(email), s.passwordResetRate); err != nil {
+		return err
+	}
+
+	s.ForgotPassword(email)
+	return nil
+}
+
+// ForgotPassword runs in the background so the response time is the same whether or not
+// the email belongs to an account.
+func (s *authService) ForgotPassword(email string) {
+	go func() {
+		if err := s.sendPasswordResetEmail(email); err != nil {
+			logger.Log.Error("failed to send password reset email", zap.String("error", err.Error()))
+		}
+	}()
+}
+
+// ConfirmPasswordReset returns the public id of the user whose password changed. The new
+// password is checked before the token is used up, so a rejected password leaves the
+// link valid for another try.
+func (s *authService) ConfirmPasswordReset(confirmRequest auth_types.ConfirmPasswordResetRequest) (string, error) {
+	tokenHash := pkg_token.Hash(confirmRequest.Token)
+
+	userID, err := s.tokenRepo.FindToken(auth_types.TokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.checkNewPassword(user, confirmRequest.NewPassword); err != nil {
+		return "", err
+	}
+
+	// consuming is what makes the token single use, a concurrent request may have won
+	if _, err := s.tokenRepo.ConsumeToken(auth_types.TokenPurposePasswordReset, tokenHash); err != nil {
+		return "", err
+	}
+
+	if err := s.storePassword(user, confirmRequest.NewPassword); err != nil {
+		return "", err
+	}
+
+	if err := s.tokenRepo.RevokeUserTokens(user.ID, auth_types.TokenPurposePasswordReset); err != nil {
+		return "", err
+	}
+
+	return user.PublicID, nil
+}
+
+func (s *authService) sendPasswordResetEmail(email string) error {
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil {
+		if err == user_errors.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeUserTokens(user.ID, auth_types.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	plain, hash, err := pkg_token.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.CreateToken(user.ID, auth_types.TokenPurposePasswordReset, hash, time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.GetString("APP_BASE_URL"), url.QueryEscape(plain))
+
+	p := locale.Printer(user.Preferences.Locale)
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: p.Sprintf(passwordResetSubject),
+		Body:    p.Sprintf(passwordResetBody, link, int(passwordResetTTL.Minutes())),
+	})
+}
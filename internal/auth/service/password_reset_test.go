@@ -0,0 +1,221 @@
+package auth_service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/mailer"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSendPasswordResetEmail(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		user        *user_types.GetUserResponse
+		userError   error
+		expectSend  bool
+		subject     string
+		expectError bool
+	}{
+		{
+			name:       "existing user receives a link",
+			user:       &user_types.GetUserResponse{ID: 1, Email: "test@mail.com"},
+			expectSend: true,
+			subject:    "Reset your password",
+		},
+		{
+			name: "email is written in the user's locale",
+			user: &user_types.GetUserResponse{ID: 1, Email: "test@mail.com",
+				Preferences: user_types.Preferences{Locale: "pt-BR"}},
+			expectSend: true,
+			subject:    "Redefina sua senha",
+		},
+		{
+			name:       "unknown email is silently ignored",
+			userError:  user_errors.ErrUserNotFound,
+			expectSend: false,
+		},
+		{
+			name:        "repository error",
+			userError:   errors.New("db error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+			mockMailer := new(mailer_mock.MockMailer)
+
+			mockRepo.On("GetUserByEmail", "test@mail.com").Return(tt.user, tt.userError)
+
+			if tt.expectSend {
+				mockTokenRepo.On("RevokeUserTokens", int64(1), auth_types.TokenPurposePasswordReset).Return(nil)
+				mockTokenRepo.On("CreateToken", int64(1), auth_types.TokenPurposePasswordReset, mock.Anything, mock.Anything).Return(nil)
+				mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+					return msg.To == "test@mail.com" && msg.Subject == tt.subject
+				})).Return(nil)
+			}
+
+			service := &authService{userRepo: mockRepo, tokenRepo: mockTokenRepo, mailer: mockMailer}
+
+			err := service.sendPasswordResetEmail("test@mail.com")
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRequestPasswordReset_RateLimited(t *testing.T) {
+	mockRepo := new(user_mock.MockUserRepository)
+
+	// the background send finds no account, the limit applies all the same
+	mockRepo.On("GetUserByEmail", mock.Anything).Return(nil, user_errors.ErrUserNotFound)
+
+	service := &authService{
+		userRepo:            mockRepo,
+		tokenRepo:           new(auth_repository_mock.MockTokenRepository),
+		mailer:              new(mailer_mock.MockMailer),
+		limiter:             newTestLimiter(),
+		passwordResetRate:   auth_lockout.RateLimit{Limit: 2, Window: time.Hour},
+		passwordResetIPRate: auth_lockout.RateLimit{Limit: 2, Window: time.Hour},
+	}
+	client := auth_types.ClientInfo{IP: "10.0.0.1"}
+
+	assert.NoError(t, service.RequestPasswordReset("victim@mail.com", client))
+	assert.NoError(t, service.RequestPasswordReset("victim@mail.com", auth_types.ClientInfo{IP: "10.0.0.2"}))
+
+	var lockoutErr *auth_errors.LockoutError
+	assert.ErrorAs(t, service.RequestPasswordReset(" VICTIM@mail.com", auth_types.ClientInfo{IP: "10.0.0.3"}), &lockoutErr)
+	assert.Greater(t, lockoutErr.RetryAfter, 59*time.Minute)
+
+	// one IP can't spread the flood over many addresses either
+	assert.NoError(t, service.RequestPasswordReset("other@mail.com", client))
+	assert.ErrorAs(t, service.RequestPasswordReset("third@mail.com", client), &lockoutErr)
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+
+	storedHash, _ := bcrypt.GenerateFromPassword([]byte("Current-Passw0rd!2026"), bcrypt.MinCost)
+	user := &user_types.GetUserResponse{ID: 1, PublicID: "user-1", PasswordHash: string(storedHash)}
+
+	tests := []struct {
+		name         string
+		newPassword  string
+		findError    error
+		consumeError error
+		updateError  error
+		expectError  error
+		expectStored bool
+	}{
+		{
+			name:         "success",
+			newPassword:  "Brand-New#Passw0rd-2026",
+			expectError:  nil,
+			expectStored: true,
+		},
+		{
+			name:        "invalid token",
+			newPassword: "Brand-New#Passw0rd-2026",
+			findError:   auth_errors.ErrInvalidToken,
+			expectError: auth_errors.ErrInvalidToken,
+		},
+		{
+			name:        "rejected password keeps the token valid",
+			newPassword: "Current-Passw0rd!2026",
+			expectError: user_errors.ErrSamePassword,
+		},
+		{
+			name:         "token used by a concurrent request",
+			newPassword:  "Brand-New#Passw0rd-2026",
+			consumeError: auth_errors.ErrInvalidToken,
+			expectError:  auth_errors.ErrInvalidToken,
+		},
+		{
+			name:         "update error",
+			newPassword:  "Brand-New#Passw0rd-2026",
+			updateError:  errors.New("db error"),
+			expectError:  errors.New("db error"),
+			expectStored: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockTokenRepo := new(auth_repository_mock.MockTokenRepository)
+			tokenHash := pkg_token.Hash("plain-token")
+
+			mockTokenRepo.
+				On("FindToken", auth_types.TokenPurposePasswordReset, tokenHash).
+				Return(int64(1), tt.findError)
+
+			if tt.findError == nil {
+				mockRepo.On("GetUserByID", int64(1)).Return(user, nil)
+				mockRepo.On("GetPasswordHistory", int64(1), 5).Return([]string{}, nil).Maybe()
+			}
+
+			if tt.expectStored || tt.consumeError != nil {
+				mockTokenRepo.
+					On("ConsumeToken", auth_types.TokenPurposePasswordReset, tokenHash).
+					Return(int64(1), tt.consumeError)
+			}
+
+			if tt.expectStored {
+				mockRepo.On("UpdateUserPassword", int64(1), tt.newPassword).Return(tt.updateError)
+			}
+
+			if tt.expectError == nil {
+				mockTokenRepo.On("RevokeUserTokens", int64(1), auth_types.TokenPurposePasswordReset).Return(nil)
+				mockRepo.On("RevokeUserSessions", int64(1)).Return(nil)
+			}
+
+			service := NewAuthService(mockRepo, mockTokenRepo, new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			userPublicID, err := service.ConfirmPasswordReset(auth_types.ConfirmPasswordResetRequest{
+				Token:       "plain-token",
+				NewPassword: tt.newPassword,
+			})
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-1", userPublicID)
+			} else {
+				assert.EqualError(t, err, tt.expectError.Error())
+			}
+
+			if !tt.expectStored && tt.consumeError == nil {
+				mockTokenRepo.AssertNotCalled(t, "ConsumeToken", mock.Anything, mock.Anything)
+			}
+			if !tt.expectStored {
+				mockRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package auth_service
+
+import (
+	"errors"
+	"fmt"
+
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
+	"github.com/celio001/prodify/pkg/password-validator/strength"
+	"github.com/celio001/prodify/pkg/password-validator/validator"
+)
+
+// CheckPasswordStrength runs the registration policy without storing anything, so the
+// signup form shows the same verdict the server will give. It is rate limited per IP
+// because every call may hit the breach corpus. The password is never logged.
+func (s *authService) CheckPasswordStrength(request auth_types.PasswordStrengthRequest, client auth_types.ClientInfo) (*auth_types.PasswordStrengthResponse, error) {
+	if err := s.limiter.Allow(auth_lockout.PasswordStrengthKey(client.IP), s.passwordStrengthRate); err != nil {
+		return nil, err
+	}
+
+	estimate := strength.Estimate(request.Password, request.Name, request.Email)
+	response := &auth_types.PasswordStrengthResponse{
+		Valid:     true,
+		Reasons:   []string{},
+		Entropy:   validator.GetEntropy(request.Password),
+		Score:     estimate.Score,
+		CrackTime: estimate.CrackTimesDisplay.OfflineSlowHashing,
+		Warning:   estimate.Feedback.Warning,
+		Hints:     []string{},
+	}
+
+	if err := s.passwordPolicy.Validate(request.Password, request.Name, request.Email); err != nil {
+		var passwordErr *password_errors.PasswordError
+		if !errors.As(err, &passwordErr) {
+			return nil, err
+		}
+
+		response.Valid = false
+		response.Reasons = passwordErr.ReasonsList()
+		response.Hints = append(response.Hints, s.policyHints(passwordErr.Reasons)...)
+	}
+
+	response.Hints = append(response.Hints, estimate.Feedback.Suggestions...)
+	return response, nil
+}
+
+func (s *authService) policyHints(reasons []error) []string {
+	hints := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		switch reason {
+		case password_errors.ErrTooShort:
+			hints = append(hints, fmt.Sprintf("Use at least %d characters.", s.passwordPolicy.MinLength))
+		case password_errors.ErrTooLong:
+			hints = append(hints, fmt.Sprintf("Use at most %d characters.", s.passwordPolicy.MaxLength))
+		case password_errors.ErrNoLowercase:
+			hints = append(hints, "Add a lowercase letter.")
+		case password_errors.ErrNoUppercase:
+			hints = append(hints, "Add an uppercase letter.")
+		case password_errors.ErrNoDigits:
+			hints = append(hints, "Add a digit.")
+		case password_errors.ErrNoSpecialChars:
+			hints = append(hints, "Add a symbol such as ! or -.")
+		case password_errors.ErrLowEntropy:
+			hints = append(hints, "Make it longer, every extra character counts more than a symbol.")
+		case password_errors.ErrBreached:
+			hints = append(hints, "This password was exposed in a data breach, choose one you have never used.")
+		}
+	}
+	return hints
+}
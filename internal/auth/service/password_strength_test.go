@@ -0,0 +1,100 @@
+package auth_service
+
+import (
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_lockout "github.com/celio001/prodify/internal/auth/lockout"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
+	"github.com/celio001/prodify/pkg/password-validator/validator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPasswordStrength(t *testing.T) {
+	client := auth_types.ClientInfo{IP: "10.0.0.1"}
+
+	tests := []struct {
+		name          string
+		request       auth_types.PasswordStrengthRequest
+		breaches      validator.BreachChecker
+		expectValid   bool
+		expectReasons []string
+		expectHint    string
+	}{
+		{
+			name:        "strong password",
+			request:     auth_types.PasswordStrengthRequest{Password: "Violet-Harbor-Lantern-42"},
+			expectValid: true,
+		},
+		{
+			name:          "short and missing classes",
+			request:       auth_types.PasswordStrengthRequest{Password: "abc"},
+			expectReasons: []string{password_errors.ErrTooShort.Error(), password_errors.ErrNoUppercase.Error(), password_errors.ErrNoDigits.Error(), password_errors.ErrLowEntropy.Error(), password_errors.ErrTooGuessable.Error()},
+			expectHint:    "Use at least 8 characters.",
+		},
+		{
+			name:          "built from the user's name",
+			request:       auth_types.PasswordStrengthRequest{Password: "Zebulon.Kravitz1!", Name: "Zebulon Kravitz", Email: "zebulon.kravitz@mail.com"},
+			expectReasons: []string{password_errors.ErrTooGuessable.Error()},
+			expectHint:    "Add another word or two. Uncommon words are better.",
+		},
+		{
+			name:          "breached",
+			request:       auth_types.PasswordStrengthRequest{Password: "Violet-Harbor-Lantern-42"},
+			breaches:      fakeBreaches{"Violet-Harbor-Lantern-42": 3},
+			expectReasons: []string{password_errors.ErrBreached.Error()},
+			expectHint:    "This password was exposed in a data breach, choose one you have never used.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &authService{
+				limiter:              newTestLimiter(),
+				passwordStrengthRate: auth_lockout.RateLimit{Limit: 10, Window: time.Minute},
+				passwordPolicy:       newPasswordPolicy(tt.breaches),
+			}
+
+			response, err := service.CheckPasswordStrength(tt.request, client)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectValid, response.Valid)
+			assert.Equal(t, validator.GetEntropy(tt.request.Password), response.Entropy)
+			if tt.expectValid {
+				assert.Empty(t, response.Reasons)
+				assert.GreaterOrEqual(t, response.Score, 3)
+			} else {
+				assert.Equal(t, tt.expectReasons, response.Reasons)
+				assert.Contains(t, response.Hints, tt.expectHint)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordStrength_RateLimited(t *testing.T) {
+	service := &authService{
+		limiter:              newTestLimiter(),
+		passwordStrengthRate: auth_lockout.RateLimit{Limit: 2, Window: time.Minute},
+		passwordPolicy:       newPasswordPolicy(nil),
+	}
+	request := auth_types.PasswordStrengthRequest{Password: "Violet-Harbor-Lantern-42"}
+
+	for i := 0; i < 2; i++ {
+		_, err := service.CheckPasswordStrength(request, auth_types.ClientInfo{IP: "10.0.0.1"})
+		assert.NoError(t, err)
+	}
+
+	_, err := service.CheckPasswordStrength(request, auth_types.ClientInfo{IP: "10.0.0.1"})
+
+	var lockoutErr *auth_errors.LockoutError
+	assert.ErrorAs(t, err, &lockoutErr)
+
+	// other clients and the login counters are not affected
+	_, err = service.CheckPasswordStrength(request, auth_types.ClientInfo{IP: "10.0.0.2"})
+	assert.NoError(t, err)
+	assert.NoError(t, service.limiter.Check("", "10.0.0.1"))
+}
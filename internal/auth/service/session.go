@@ -0,0 +1,181 @@
+package auth_service
+
+import (
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CheckSession rejects tokens issued before the user's sessions were revoked, and tokens
+// whose session was signed out. JWT iat has second precision, so the revocation instant is
+// truncated the same way. Tokens without a session id predate session tracking and only
+// get the first check.
+func (s *authService) CheckSession(userPublicID string, sessionID string, issuedAt time.Time) error {
+	publicID, err := uuidvalidator.ValidateUuid(userPublicID)
+	if err != nil {
+		return err
+	}
+
+	revokedAt, err := s.userRepo.GetSessionsRevokedAt(publicID)
+	if err != nil {
+		return err
+	}
+
+	if revokedAt != nil && issuedAt.Before(revokedAt.Truncate(time.Second)) {
+		return auth_errors.ErrSessionRevoked
+	}
+
+	if sessionID == "" {
+		return nil
+	}
+
+	sessionPublicID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return auth_errors.ErrSessionRevoked
+	}
+
+	active, err := s.sessionRepo.IsSessionActive(sessionPublicID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return auth_errors.ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// IssueTokens opens a new session for the user and returns its first token pair.
+func (s *authService) IssueTokens(userPublicID string, client auth_types.ClientInfo) (*auth_types.TokenPair, error) {
+	publicID, err := uuidvalidator.ValidateUuid(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID := uuid.New()
+	sessionID, err := s.sessionRepo.CreateSession(user.ID, tokenID, client, time.Now().Add(pkg_jwt.RefreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenPair(userPublicID, sessionID, tokenID)
+}
+
+// RefreshTokens rotates the refresh token of a session. A refresh token that was already
+// rotated away means the family leaked, so the whole session is revoked.
+func (s *authService) RefreshTokens(refreshToken string, client auth_types.ClientInfo) (*auth_types.TokenPair, error) {
+	token, err := pkg_jwt.ParseToken(refreshToken)
+	if err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+
+	if tokenType, err := pkg_jwt.IsAccessToken(token); err != nil || tokenType != "refresh" {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+
+	userPublicID, err := pkg_jwt.GetUserIDFromToken(token)
+	if err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+
+	sessionClaim, err := pkg_jwt.GetSessionIDFromToken(token)
+	if err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+	sessionID, err := uuid.Parse(sessionClaim)
+	if err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+
+	tokenClaim, err := pkg_jwt.GetTokenIDFromToken(token)
+	if err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+	tokenID, err := uuid.Parse(tokenClaim)
+	if err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+
+	issuedAt, err := pkg_jwt.GetIssuedAtFromToken(token)
+	if err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+
+	if err := s.CheckSession(userPublicID, "", issuedAt); err != nil {
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+
+	newTokenID := uuid.New()
+	err = s.sessionRepo.RotateSession(sessionID, tokenID, newTokenID, client, time.Now().Add(pkg_jwt.RefreshTokenTTL))
+	if err == auth_errors.ErrInvalidRefreshToken {
+		logger.Log.Warn("refresh token reuse, revoking session",
+			zap.String("user_id", userPublicID),
+			zap.String("session_id", sessionID.String()))
+		if err := s.sessionRepo.RevokeSession(sessionID); err != nil {
+			logger.Log.Error("failed to revoke session", zap.String("error", err.Error()))
+		}
+		return nil, auth_errors.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenPair(userPublicID, sessionID, newTokenID)
+}
+
+// ListSessions returns the user's active sessions, flagging the one currentSessionID belongs to.
+func (s *authService) ListSessions(userPublicID uuid.UUID, currentSessionID string) ([]auth_types.Session, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListSessions(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].PublicID.String() == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+func (s *authService) RevokeSession(userPublicID uuid.UUID, sessionID uuid.UUID) error {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return err
+	}
+
+	return s.sessionRepo.RevokeUserSession(user.ID, sessionID)
+}
+
+func newTokenPair(userPublicID string, sessionID uuid.UUID, tokenID uuid.UUID) (*auth_types.TokenPair, error) {
+	accessToken, err := pkg_jwt.CreateAccessToken(userPublicID, sessionID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := pkg_jwt.CreateRefreshToken(userPublicID, sessionID.String(), tokenID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth_types.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+	}, nil
+}
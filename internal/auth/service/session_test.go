@@ -0,0 +1,290 @@
+package auth_service
+
+import (
+	"testing"
+	"time"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	"github.com/google/uuid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckSession(t *testing.T) {
+
+	publicID := uuid.New()
+	revokedAt := time.Date(2026, 1, 1, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name        string
+		revokedAt   *time.Time
+		issuedAt    time.Time
+		expectError error
+	}{
+		{
+			name:        "never revoked",
+			revokedAt:   nil,
+			issuedAt:    revokedAt.Add(-time.Hour),
+			expectError: nil,
+		},
+		{
+			name:        "issued before revocation",
+			revokedAt:   &revokedAt,
+			issuedAt:    revokedAt.Add(-time.Minute),
+			expectError: auth_errors.ErrSessionRevoked,
+		},
+		{
+			name:        "issued in the same second as the revocation",
+			revokedAt:   &revokedAt,
+			issuedAt:    revokedAt.Truncate(time.Second),
+			expectError: nil,
+		},
+		{
+			name:        "issued after revocation",
+			revokedAt:   &revokedAt,
+			issuedAt:    revokedAt.Add(time.Minute),
+			expectError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+
+			if tt.revokedAt == nil {
+				mockRepo.On("GetSessionsRevokedAt", publicID).Return(nil, nil)
+			} else {
+				mockRepo.On("GetSessionsRevokedAt", publicID).Return(tt.revokedAt, nil)
+			}
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			err := service.CheckSession(publicID.String(), "", tt.issuedAt)
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectError)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckSession_SignedOutSession(t *testing.T) {
+
+	publicID := uuid.New()
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name        string
+		sessionID   string
+		active      bool
+		expectError error
+	}{
+		{
+			name:      "active session",
+			sessionID: sessionID.String(),
+			active:    true,
+		},
+		{
+			name:        "revoked session",
+			sessionID:   sessionID.String(),
+			active:      false,
+			expectError: auth_errors.ErrSessionRevoked,
+		},
+		{
+			name:        "malformed session id",
+			sessionID:   "not-a-uuid",
+			expectError: auth_errors.ErrSessionRevoked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockRepo.On("GetSessionsRevokedAt", publicID).Return(nil, nil)
+
+			mockSessionRepo := new(auth_repository_mock.MockSessionRepository)
+			if tt.sessionID == sessionID.String() {
+				mockSessionRepo.On("IsSessionActive", sessionID).Return(tt.active, nil)
+			}
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), mockSessionRepo, new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			err := service.CheckSession(publicID.String(), tt.sessionID, time.Now())
+
+			if tt.expectError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectError)
+			}
+
+			mockSessionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIssueTokens(t *testing.T) {
+
+	publicID := uuid.New()
+	sessionID := uuid.New()
+	client := auth_types.ClientInfo{IP: "10.0.0.1", UserAgent: "curl/8.0"}
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1, PublicID: publicID.String()}, nil)
+
+	mockSessionRepo := new(auth_repository_mock.MockSessionRepository)
+	mockSessionRepo.On("CreateSession", int64(1), mock.AnythingOfType("uuid.UUID"), client, mock.AnythingOfType("time.Time")).Return(sessionID, nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), mockSessionRepo, new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	tokens, err := service.IssueTokens(publicID.String(), client)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+
+	refreshToken, err := pkg_jwt.ParseToken(tokens.RefreshToken)
+	assert.NoError(t, err)
+
+	sid, _ := pkg_jwt.GetSessionIDFromToken(refreshToken)
+	jti, _ := pkg_jwt.GetTokenIDFromToken(refreshToken)
+	assert.Equal(t, sessionID.String(), sid)
+	assert.Equal(t, mockSessionRepo.Calls[0].Arguments.Get(1).(uuid.UUID).String(), jti)
+
+	accessToken, err := pkg_jwt.ParseToken(tokens.AccessToken)
+	assert.NoError(t, err)
+
+	sid, _ = pkg_jwt.GetSessionIDFromToken(accessToken)
+	assert.Equal(t, sessionID.String(), sid)
+}
+
+func TestRefreshTokens(t *testing.T) {
+	logger.Init("dev")
+
+	publicID := uuid.New()
+	sessionID := uuid.New()
+	tokenID := uuid.New()
+	client := auth_types.ClientInfo{IP: "10.0.0.2", UserAgent: "Firefox"}
+
+	refreshToken, _ := pkg_jwt.CreateRefreshToken(publicID.String(), sessionID.String(), tokenID.String())
+	accessToken, _ := pkg_jwt.CreateAccessToken(publicID.String(), sessionID.String())
+
+	tests := []struct {
+		name         string
+		token        string
+		rotateError  error
+		callRotate   bool
+		expectRevoke bool
+		expectError  error
+	}{
+		{
+			name:       "success",
+			token:      refreshToken,
+			callRotate: true,
+		},
+		{
+			name:         "reused refresh token revokes the session",
+			token:        refreshToken,
+			callRotate:   true,
+			rotateError:  auth_errors.ErrInvalidRefreshToken,
+			expectRevoke: true,
+			expectError:  auth_errors.ErrInvalidRefreshToken,
+		},
+		{
+			name:        "access token",
+			token:       accessToken,
+			expectError: auth_errors.ErrInvalidRefreshToken,
+		},
+		{
+			name:        "garbage",
+			token:       "not-a-jwt",
+			expectError: auth_errors.ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockRepo.On("GetSessionsRevokedAt", publicID).Return(nil, nil)
+
+			mockSessionRepo := new(auth_repository_mock.MockSessionRepository)
+			if tt.callRotate {
+				mockSessionRepo.On("RotateSession", sessionID, tokenID, mock.AnythingOfType("uuid.UUID"), client, mock.AnythingOfType("time.Time")).Return(tt.rotateError)
+			}
+			if tt.expectRevoke {
+				mockSessionRepo.On("RevokeSession", sessionID).Return(nil)
+			}
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), mockSessionRepo, new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			tokens, err := service.RefreshTokens(tt.token, client)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				assert.Nil(t, tokens)
+			} else {
+				assert.NoError(t, err)
+
+				parsed, err := pkg_jwt.ParseToken(tokens.RefreshToken)
+				assert.NoError(t, err)
+
+				jti, _ := pkg_jwt.GetTokenIDFromToken(parsed)
+				assert.NotEqual(t, tokenID.String(), jti)
+			}
+
+			mockSessionRepo.AssertExpectations(t)
+			if !tt.callRotate {
+				mockSessionRepo.AssertNotCalled(t, "RotateSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestListSessions_FlagsCurrent(t *testing.T) {
+
+	publicID := uuid.New()
+	current, other := uuid.New(), uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+
+	mockSessionRepo := new(auth_repository_mock.MockSessionRepository)
+	mockSessionRepo.On("ListSessions", int64(1)).Return([]auth_types.Session{{PublicID: other}, {PublicID: current}}, nil)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), mockSessionRepo, new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	sessions, err := service.ListSessions(publicID, current.String())
+
+	assert.NoError(t, err)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestRevokeSession(t *testing.T) {
+
+	publicID := uuid.New()
+	sessionID := uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+
+	mockSessionRepo := new(auth_repository_mock.MockSessionRepository)
+	mockSessionRepo.On("RevokeUserSession", int64(1), sessionID).Return(auth_errors.ErrSessionNotFound)
+
+	service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), mockSessionRepo, new(auth_repository_mock.MockIdentityRepository), new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+	err := service.RevokeSession(publicID, sessionID)
+
+	assert.ErrorIs(t, err, auth_errors.ErrSessionNotFound)
+	mockSessionRepo.AssertExpectations(t)
+}
@@ -0,0 +1,100 @@
+package auth_service
+
+import (
+	"strings"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+)
+
+// LoginWithIdentity signs a user in with an identity an external provider vouched for.
+// A known identity logs its user in. Otherwise the identity is linked to the account
+// with the same verified email, or a new account is created for it when self-registration
+// is allowed. Accounts that must reset their password are refused either way.
+func (s *authService) LoginWithIdentity(identity auth_types.ExternalIdentity) (user_types.GetUserResponse, error) {
+	userID, err := s.identityRepo.GetUserIDByIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetUserByID(userID)
+		if err != nil {
+			return user_types.GetUserResponse{}, err
+		}
+		if !user.IsActive {
+			return user_types.GetUserResponse{}, auth_errors.ErrUserInactive
+		}
+		if user.PasswordResetRequired {
+			return user_types.GetUserResponse{}, auth_errors.ErrPasswordReset
+		}
+		return *user, nil
+	}
+	if err != auth_errors.ErrIdentityNotFound {
+		return user_types.GetUserResponse{}, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return user_types.GetUserResponse{}, auth_errors.ErrIdentityEmailUnverified
+	}
+
+	user, err := s.userRepo.GetUserByEmail(identity.Email)
+	switch {
+	case err == nil:
+		if !user.IsActive {
+			return user_types.GetUserResponse{}, auth_errors.ErrUserInactive
+		}
+		if user.PasswordResetRequired {
+			return user_types.GetUserResponse{}, auth_errors.ErrPasswordReset
+		}
+		// anyone can register an unverified account for an address they don't own,
+		// linking to it would hand that account to whoever signs in with the provider
+		if !user.EmailVerified {
+			return user_types.GetUserResponse{}, auth_errors.ErrIdentityLinkConflict
+		}
+	case err == user_errors.ErrUserNotFound:
+		if !s.allowSelfRegistration {
+			return user_types.GetUserResponse{}, auth_errors.ErrRegistrationDisabled
+		}
+		user, err = s.createUserFromIdentity(identity)
+		if err != nil {
+			return user_types.GetUserResponse{}, err
+		}
+	default:
+		return user_types.GetUserResponse{}, err
+	}
+
+	if err := s.identityRepo.LinkIdentity(user.ID, identity.Provider, identity.Subject, identity.Email); err != nil {
+		return user_types.GetUserResponse{}, err
+	}
+
+	return *user, nil
+}
+
+// createUserFromIdentity registers an account with a random password, the user can
+// set a real one later through the forgot-password flow.
+func (s *authService) createUserFromIdentity(identity auth_types.ExternalIdentity) (*user_types.GetUserResponse, error) {
+	password, _, err := pkg_token.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	created, err := s.userRepo.CreateUser(user_types.CreateUserRequest{
+		Name:     name,
+		Email:    identity.Email,
+		Password: password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.MarkEmailVerified(created.Id); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetUserByID(created.Id)
+}
@@ -0,0 +1,138 @@
+package auth_service
+
+import (
+	"errors"
+	"testing"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_repository_mock "github.com/celio001/prodify/internal/auth/repository/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoginWithIdentity(t *testing.T) {
+
+	identity := auth_types.ExternalIdentity{
+		Provider:      "google",
+		Subject:       "sub-1",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	}
+
+	verifiedUser := &user_types.GetUserResponse{ID: 7, PublicID: "user-7", Email: "jane@example.com", IsActive: true, EmailVerified: true}
+
+	tests := []struct {
+		name        string
+		identity    auth_types.ExternalIdentity
+		setup       func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository)
+		expectUser  int64
+		expectError error
+	}{
+		{
+			name:     "already linked",
+			identity: identity,
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(7), nil)
+				userRepo.On("GetUserByID", int64(7)).Return(verifiedUser, nil)
+			},
+			expectUser: 7,
+		},
+		{
+			name:     "links to the account with the same verified email",
+			identity: identity,
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(0), auth_errors.ErrIdentityNotFound)
+				userRepo.On("GetUserByEmail", "jane@example.com").Return(verifiedUser, nil)
+				identityRepo.On("LinkIdentity", int64(7), "google", "sub-1", "jane@example.com").Return(nil)
+			},
+			expectUser: 7,
+		},
+		{
+			name:     "linked account must reset its password",
+			identity: identity,
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(7), nil)
+				userRepo.On("GetUserByID", int64(7)).Return(&user_types.GetUserResponse{ID: 7, IsActive: true, EmailVerified: true, PasswordResetRequired: true}, nil)
+			},
+			expectError: auth_errors.ErrPasswordReset,
+		},
+		{
+			name:     "refuses to link to an account that must reset its password",
+			identity: identity,
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(0), auth_errors.ErrIdentityNotFound)
+				userRepo.On("GetUserByEmail", "jane@example.com").Return(&user_types.GetUserResponse{ID: 7, IsActive: true, EmailVerified: true, PasswordResetRequired: true}, nil)
+			},
+			expectError: auth_errors.ErrPasswordReset,
+		},
+		{
+			name:     "refuses to link to an unverified account",
+			identity: identity,
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(0), auth_errors.ErrIdentityNotFound)
+				userRepo.On("GetUserByEmail", "jane@example.com").Return(&user_types.GetUserResponse{ID: 7, IsActive: true}, nil)
+			},
+			expectError: auth_errors.ErrIdentityLinkConflict,
+		},
+		{
+			name:     "creates a verified account",
+			identity: identity,
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(0), auth_errors.ErrIdentityNotFound)
+				userRepo.On("GetUserByEmail", "jane@example.com").Return(nil, user_errors.ErrUserNotFound)
+				userRepo.On("CreateUser", mock.MatchedBy(func(req user_types.CreateUserRequest) bool {
+					return req.Name == "Jane Doe" && req.Email == "jane@example.com" && len(req.Password) >= 32
+				})).Return(&user_types.CreateUserResponse{Id: 9}, nil)
+				userRepo.On("MarkEmailVerified", int64(9)).Return(nil)
+				userRepo.On("GetUserByID", int64(9)).Return(&user_types.GetUserResponse{ID: 9, IsActive: true, EmailVerified: true}, nil)
+				identityRepo.On("LinkIdentity", int64(9), "google", "sub-1", "jane@example.com").Return(nil)
+			},
+			expectUser: 9,
+		},
+		{
+			name:     "unverified provider email",
+			identity: auth_types.ExternalIdentity{Provider: "google", Subject: "sub-1", Email: "jane@example.com"},
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(0), auth_errors.ErrIdentityNotFound)
+			},
+			expectError: auth_errors.ErrIdentityEmailUnverified,
+		},
+		{
+			name:     "repository error",
+			identity: identity,
+			setup: func(userRepo *user_mock.MockUserRepository, identityRepo *auth_repository_mock.MockIdentityRepository) {
+				identityRepo.On("GetUserIDByIdentity", "google", "sub-1").Return(int64(0), errors.New("db error"))
+			},
+			expectError: errors.New("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(user_mock.MockUserRepository)
+			mockIdentityRepo := new(auth_repository_mock.MockIdentityRepository)
+			tt.setup(mockRepo, mockIdentityRepo)
+
+			service := NewAuthService(mockRepo, new(auth_repository_mock.MockTokenRepository), new(auth_repository_mock.MockMFARepository), new(auth_repository_mock.MockSessionRepository), mockIdentityRepo, new(auth_repository_mock.MockInvitationRepository), new(mailer_mock.MockMailer), newTestLimiter(), newTestHasher(), nil)
+
+			user, err := service.LoginWithIdentity(tt.identity)
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectUser, user.ID)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockIdentityRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -7,11 +7,40 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// ClientInfo describes where a request came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type UnlockLoginRequest struct {
+	Email string `json:"email" validate:"required_without=IP,omitempty,email"`
+	IP    string `json:"ip" validate:"required_without=Email,omitempty,ip"`
+}
+
 type ResetPasswordRequest struct {
-	OldPasswordHash string `json:"old_password_hash" validate:"required"`
+	CurrentPassword string `json:"current_password" validate:"required"`
 	NewPassword     string `json:"new_password" validate:"required"`
 }
 
+// PasswordStrengthRequest is checked exactly like a registration, Name and Email are
+// optional and make passwords built from them score lower.
+type PasswordStrengthRequest struct {
+	Password string `json:"password" validate:"required,max=256"`
+	Name     string `json:"name" validate:"omitempty,max=50"`
+	Email    string `json:"email" validate:"omitempty,max=254"`
+}
+
+type PasswordStrengthResponse struct {
+	Valid     bool     `json:"valid"`
+	Reasons   []string `json:"reasons"`
+	Entropy   float64  `json:"entropy"`
+	Score     int      `json:"score"`
+	CrackTime string   `json:"crackTime"`
+	Warning   string   `json:"warning,omitempty"`
+	Hints     []string `json:"hints"`
+}
+
 type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=3,max=50"`
 	Email    string `json:"email" validate:"required,email"`
@@ -0,0 +1,10 @@
+package auth_types
+
+// ExternalIdentity is what an identity provider vouches for after a successful social login.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
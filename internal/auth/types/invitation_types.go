@@ -0,0 +1,58 @@
+package auth_types
+
+import "time"
+
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+// Invitation lets an admin create an account for someone else. The invitee picks their
+// name and password, the email and role are the admin's.
+type Invitation struct {
+	ID         int64      `json:"-"`
+	PublicID   string     `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	TokenHash  string     `json:"-"`
+	InvitedBy  *int64     `json:"-"`
+	Inviter    string     `json:"invitedBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Status     string     `json:"status"`
+}
+
+// State is the invitation status at now, expiry is never written to the row.
+func (i Invitation) State(now time.Time) string {
+	switch {
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"omitempty,oneof=user admin"`
+}
+
+type ListInvitationsRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending accepted expired revoked"`
+}
+
+// AcceptInvitationRequest completes an invited account, the password goes through the
+// same policy as a registration.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"required,min=3,max=50"`
+	Password string `json:"password" validate:"required"`
+}
@@ -0,0 +1,28 @@
+package auth_types
+
+type MFA struct {
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+}
+
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	// QRCodePNG is the otpauth URI rendered as a base64 encoded PNG
+	QRCodePNG string `json:"qr_png"`
+}
+
+type ConfirmMFARequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	// Code is either a TOTP code or one of the recovery codes
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type ResetMFARequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
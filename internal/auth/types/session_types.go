@@ -0,0 +1,38 @@
+package auth_types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Session struct {
+	ID         int64     `json:"-"`
+	PublicID   uuid.UUID `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+}
+
+// ImpersonationToken lets an admin see the API as another user. There is no refresh
+// token, a new one has to be requested when it expires.
+type ImpersonationToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	UserID      string `json:"user_id"`
+	ActorID     string `json:"actor_id"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/celio001/prodify/config"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDKey     = "request_id"
+	auditRecorderKey = "audit_recorder"
+
+	// a request id sent by the client or a proxy is kept when it fits the audit log
+	maxRequestIDLength = 64
+	maxUserAgentLength = 512
+)
+
+// AuditRecorder stores audit events without blocking the request, audit_service.Recorder is one.
+type AuditRecorder interface {
+	Record(event audit_types.Event)
+}
+
+// RequestID tags every request with the X-Request-ID it came with, or a new one, and
+// echoes it in the response.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength || strings.IndexFunc(requestID, isNotPrintable) >= 0 {
+			requestID = uuid.NewString()
+		}
+
+		c.Locals(RequestIDKey, strings.Clone(requestID))
+		c.Set(fiber.HeaderXRequestID, requestID)
+		return c.Next()
+	}
+}
+
+// Audit makes recorder available to RecordAudit for the rest of the request.
+func Audit(recorder AuditRecorder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(auditRecorderKey, recorder)
+		return c.Next()
+	}
+}
+
+// Auditing reports whether RecordAudit records anything for this request, so callers can
+// skip the work of building an event nobody keeps.
+func Auditing(c *fiber.Ctx) bool {
+	_, ok := c.Locals(auditRecorderKey).(AuditRecorder)
+	return ok
+}
+
+// EmailDigest stands in for an email in the audit log. Audit events can't be changed
+// once written, so the erasure of an account couldn't reach an address stored there;
+// the keyed hash still lets requests for the same address be grouped together.
+func EmailDigest(email string) string {
+	key := config.GetString("AUDIT_EMAIL_KEY")
+	if key == "" {
+		key = config.GetString("JWT_SECRET")
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RecordAudit fills in who made the request, from where and its request id, keeping
+// what event already has, and records it. Nothing is recorded on routes outside Audit.
+func RecordAudit(c *fiber.Ctx, event audit_types.Event) {
+	recorder, ok := c.Locals(auditRecorderKey).(AuditRecorder)
+	if !ok {
+		return
+	}
+
+	if event.ActorID == "" {
+		event.ActorID, _ = c.Locals(UserIDKey).(string)
+	}
+	if event.ImpersonatorID == "" {
+		event.ImpersonatorID, _ = c.Locals(ActorIDKey).(string)
+	}
+	if event.IP == "" {
+		event.IP = c.IP()
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = truncate(c.Get(fiber.HeaderUserAgent), maxUserAgentLength)
+	}
+	if event.RequestID == "" {
+		event.RequestID, _ = c.Locals(RequestIDKey).(string)
+	}
+
+	// fiber reuses the memory behind headers and params once the request is over, the
+	// recorder keeps the event longer than that
+	event.ActorID = strings.Clone(event.ActorID)
+	event.ImpersonatorID = strings.Clone(event.ImpersonatorID)
+	event.TargetID = strings.Clone(event.TargetID)
+	event.IP = strings.Clone(event.IP)
+	event.UserAgent = strings.Clone(event.UserAgent)
+	event.RequestID = strings.Clone(event.RequestID)
+	if event.Metadata != nil {
+		metadata := make(map[string]string, len(event.Metadata))
+		for key, value := range event.Metadata {
+			metadata[key] = strings.Clone(value)
+		}
+		event.Metadata = metadata
+	}
+
+	recorder.Record(event)
+}
+
+func isNotPrintable(r rune) bool {
+	return r > unicode.MaxASCII || !unicode.IsPrint(r)
+}
+
+// truncate cuts value to at most max bytes without splitting a character. Invalid
+// UTF-8, which Postgres would refuse, is dropped.
+func truncate(value string, max int) string {
+	value = strings.ToValidUTF8(value, "")
+	if len(value) <= max {
+		return value
+	}
+	value = value[:max]
+	for !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	audit_service_mock "github.com/celio001/prodify/internal/audit/service/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "generated", incoming: ""},
+		{name: "kept from the client", incoming: "req-123", keep: true},
+		{name: "too long", incoming: strings.Repeat("a", 65)},
+		{name: "not printable", incoming: "req\x01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", RequestID(), func(c *fiber.Ctx) error {
+				return c.SendString(c.Locals(RequestIDKey).(string))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(fiber.HeaderXRequestID, tt.incoming)
+			}
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			requestID := resp.Header.Get(fiber.HeaderXRequestID)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, requestID)
+			} else {
+				assert.NoError(t, uuid.Validate(requestID))
+			}
+		})
+	}
+}
+
+func TestRecordAudit(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New().String()
+	adminID := uuid.New().String()
+
+	t.Run("fills in the request", func(t *testing.T) {
+		recorder := new(audit_service_mock.MockRecorder)
+
+		app := fiber.New()
+		app.Use(RequestID(), Audit(recorder))
+		app.Get("/", func(c *fiber.Ctx) error {
+			c.Locals(UserIDKey, userID)
+			c.Locals(ActorIDKey, adminID)
+			RecordAudit(c, audit_types.Event{Action: audit_types.ActionUserUpdated, TargetType: audit_types.TargetUser, TargetID: userID})
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderXRequestID, "req-1")
+		req.Header.Set(fiber.HeaderUserAgent, "curl/8.0")
+		_, err := app.Test(req)
+
+		assert.NoError(t, err)
+		if assert.Len(t, recorder.Events, 1) {
+			event := recorder.Events[0]
+			assert.Equal(t, userID, event.ActorID)
+			assert.Equal(t, adminID, event.ImpersonatorID)
+			assert.Equal(t, "req-1", event.RequestID)
+			assert.Equal(t, "curl/8.0", event.UserAgent)
+			assert.Equal(t, "0.0.0.0", event.IP)
+		}
+	})
+
+	t.Run("keeps an actor set by the handler", func(t *testing.T) {
+		recorder := new(audit_service_mock.MockRecorder)
+
+		app := fiber.New()
+		app.Use(Audit(recorder))
+		app.Post("/login", func(c *fiber.Ctx) error {
+			RecordAudit(c, audit_types.Event{Action: audit_types.ActionLoginSucceeded, ActorID: userID})
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		_, err := app.Test(httptest.NewRequest(http.MethodPost, "/login", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, userID, recorder.Events[0].ActorID)
+	})
+
+	t.Run("routes outside Audit record nothing", func(t *testing.T) {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			RecordAudit(c, audit_types.Event{Action: audit_types.ActionUserUpdated})
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 5))
+	assert.Equal(t, "ab", truncate("abé", 3))
+	assert.Equal(t, "ab", truncate("a\xffb", 5))
+}
+
+func TestEmailDigest(t *testing.T) {
+	t.Setenv("AUDIT_EMAIL_KEY", "audit-key")
+
+	digest := EmailDigest("Jane@Example.com ")
+
+	assert.Len(t, digest, 64)
+	assert.Equal(t, digest, EmailDigest("jane@example.com"))
+	assert.NotContains(t, digest, "jane")
+	assert.NotEqual(t, digest, EmailDigest("john@example.com"))
+
+	t.Setenv("AUDIT_EMAIL_KEY", "other-key")
+	assert.NotEqual(t, digest, EmailDigest("jane@example.com"))
+}
@@ -2,19 +2,63 @@ package middleware
 
 import (
 	"strings"
+	"time"
 
 	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
 	"github.com/celio001/prodify/pkg/logger"
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 )
 
-const UserIDKey = "user_id"
+const (
+	UserIDKey     = "user_id"
+	SessionIDKey  = "session_id"
+	AuthMethodKey = "auth_method"
+	ScopesKey     = "scopes"
+	ActorIDKey    = "actor_id"
 
-func AuthMiddleware() fiber.Handler {
+	AuthMethodJWT           = "jwt"
+	AuthMethodAPIKey        = "api_key"
+	AuthMethodOAuth         = "oauth"
+	AuthMethodImpersonation = "impersonation"
+
+	APIKeyHeader = "X-API-Key"
+	// ImpersonatedByHeader is set on every response to a request made with an
+	// impersonation token, it holds the admin's public id
+	ImpersonatedByHeader = "X-Impersonated-By"
+)
+
+// SessionChecker reports whether tokens issued to a user at a given time, for a given
+// session, are still accepted.
+type SessionChecker interface {
+	CheckSession(userPublicID string, sessionID string, issuedAt time.Time) error
+}
+
+// APIKeyAuthenticator resolves a raw API key to its owner and scopes.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(rawKey string) (string, []string, error)
+}
+
+// OAuthGrantChecker reports whether the grant behind a token issued to an oauth client is still honoured.
+type OAuthGrantChecker interface {
+	CheckGrant(grantID string) error
+}
+
+// AuthMiddleware accepts a Bearer access token, first-party or issued to an oauth
+// client, or an API key sent as "Authorization: ApiKey <key>" or in the X-API-Key header.
+func AuthMiddleware(sessions SessionChecker, apiKeys APIKeyAuthenticator, grants OAuthGrantChecker) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		authHeader := c.Get("Authorization")
+
+		if apiKey := c.Get(APIKeyHeader); apiKey != "" {
+			return authenticateAPIKey(c, apiKeys, apiKey)
+		}
+		if rawKey, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+			return authenticateAPIKey(c, apiKeys, rawKey)
+		}
+
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "missing Authorization header",
@@ -36,6 +80,9 @@ func AuthMiddleware() fiber.Handler {
 		}
 
 		tokenType, err := pkg_jwt.IsAccessToken(token)
+		if err == nil && tokenType == "oauth_access" {
+			return authenticateOAuthToken(c, grants, token)
+		}
 		if err != nil || tokenType != "access" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "invalid token type",
@@ -49,8 +96,106 @@ func AuthMiddleware() fiber.Handler {
 			})
 		}
 
+		issuedAt, err := pkg_jwt.GetIssuedAtFromToken(token)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid token claims",
+			})
+		}
+
+		sessionID, err := pkg_jwt.GetSessionIDFromToken(token)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid token claims",
+			})
+		}
+
+		if err := sessions.CheckSession(userID, sessionID, issuedAt); err != nil {
+			logger.Log.Info("rejected token", zap.String("user_id", userID), zap.String("error", err.Error()))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "session expired, please login again",
+			})
+		}
+
+		actor, impersonated, err := pkg_jwt.GetActorFromToken(token)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid token claims",
+			})
+		}
+		if impersonated {
+			return authenticateImpersonation(c, sessions, userID, actor, issuedAt)
+		}
+
 		logger.Log.Info("authenticated user", zap.String("user_id", userID))
 		c.Locals(UserIDKey, userID)
+		c.Locals(SessionIDKey, sessionID)
+		c.Locals(AuthMethodKey, AuthMethodJWT)
 		return c.Next()
 	}
 }
+
+// authenticateImpersonation lets an admin act as userID. The token dies with the session
+// the admin started it from, and every request is logged under both identities.
+func authenticateImpersonation(c *fiber.Ctx, sessions SessionChecker, userID string, actor pkg_jwt.Actor, issuedAt time.Time) error {
+	if err := sessions.CheckSession(actor.UserID, actor.SessionID, issuedAt); err != nil {
+		logger.Log.Info("rejected impersonation token", zap.String("user_id", userID), zap.String("actor_id", actor.UserID), zap.String("error", err.Error()))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "impersonation ended, the admin session is no longer active",
+		})
+	}
+
+	c.Locals(UserIDKey, userID)
+	c.Locals(ActorIDKey, actor.UserID)
+	c.Locals(AuthMethodKey, AuthMethodImpersonation)
+	c.Set(ImpersonatedByHeader, actor.UserID)
+
+	err := c.Next()
+
+	logger.Log.Info("impersonated request",
+		zap.String("user_id", userID),
+		zap.String("actor_id", actor.UserID),
+		zap.String("method", c.Method()),
+		zap.String("path", c.Path()),
+		zap.Int("status", c.Response().StatusCode()),
+		zap.String("ip", c.IP()))
+	return err
+}
+
+func authenticateOAuthToken(c *fiber.Ctx, grants OAuthGrantChecker, token *jwt.Token) error {
+	claims, err := pkg_jwt.GetOAuthClaimsFromToken(token)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid token claims",
+		})
+	}
+
+	if err := grants.CheckGrant(claims.GrantID); err != nil {
+		logger.Log.Info("rejected oauth token", zap.String("client_id", claims.ClientID), zap.String("error", err.Error()))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "token revoked or expired",
+		})
+	}
+
+	logger.Log.Info("authenticated user with oauth token", zap.String("user_id", claims.UserID), zap.String("client_id", claims.ClientID))
+	c.Locals(UserIDKey, claims.UserID)
+	c.Locals(AuthMethodKey, AuthMethodOAuth)
+	c.Locals(ScopesKey, claims.Scopes)
+	return c.Next()
+}
+
+func authenticateAPIKey(c *fiber.Ctx, apiKeys APIKeyAuthenticator, rawKey string) error {
+	userID, scopes, err := apiKeys.AuthenticateAPIKey(strings.TrimSpace(rawKey))
+	if err != nil {
+		logger.Log.Info("rejected api key", zap.String("error", err.Error()))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid api key",
+		})
+	}
+
+	logger.Log.Info("authenticated user with api key", zap.String("user_id", userID))
+	c.Locals(UserIDKey, userID)
+	c.Locals(AuthMethodKey, AuthMethodAPIKey)
+	c.Locals(ScopesKey, scopes)
+	return c.Next()
+}
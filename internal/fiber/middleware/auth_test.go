@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type fakeSessions struct {
+	err error
+}
+
+func (f fakeSessions) CheckSession(userPublicID string, sessionID string, issuedAt time.Time) error {
+	return f.err
+}
+
+type fakeAPIKeys struct {
+	keys map[string][]string
+}
+
+func (f fakeAPIKeys) AuthenticateAPIKey(rawKey string) (string, []string, error) {
+	scopes, ok := f.keys[rawKey]
+	if !ok {
+		return "", nil, errors.New("invalid api key")
+	}
+	return "key-owner", scopes, nil
+}
+
+type fakeGrants struct {
+	revoked map[string]bool
+}
+
+func (f fakeGrants) CheckGrant(grantID string) error {
+	if f.revoked[grantID] {
+		return errors.New("grant revoked")
+	}
+	return nil
+}
+
+func newTestApp(routeMiddleware ...fiber.Handler) *fiber.App {
+	app := fiber.New()
+
+	apiKeys := fakeAPIKeys{keys: map[string][]string{
+		"pk_read": {"user:read"},
+	}}
+
+	grants := fakeGrants{revoked: map[string]bool{"revoked-grant": true}}
+
+	handlers := append([]fiber.Handler{AuthMiddleware(fakeSessions{}, apiKeys, grants)}, routeMiddleware...)
+	handlers = append(handlers, func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals(UserIDKey).(string) + " " + c.Locals(AuthMethodKey).(string))
+	})
+
+	app.Get("/", handlers...)
+	return app
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New().String()
+	accessToken, _ := pkg_jwt.CreateAccessToken(userID, uuid.New().String())
+	refreshToken, _ := pkg_jwt.CreateRefreshToken(userID, uuid.New().String(), uuid.New().String())
+	oauthToken, _ := pkg_jwt.CreateOAuthAccessToken(pkg_jwt.OAuthClaims{UserID: userID, ClientID: "client", GrantID: "grant", Scopes: []string{"user:read"}})
+	revokedOAuthToken, _ := pkg_jwt.CreateOAuthAccessToken(pkg_jwt.OAuthClaims{UserID: userID, ClientID: "client", GrantID: "revoked-grant"})
+	oauthRefreshToken, _ := pkg_jwt.CreateOAuthRefreshToken(pkg_jwt.OAuthClaims{UserID: userID, ClientID: "client", GrantID: "grant", TokenID: "jti"})
+	impersonationToken, _ := pkg_jwt.CreateImpersonationToken(userID, pkg_jwt.Actor{UserID: uuid.New().String(), SessionID: uuid.New().String()})
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		expectStatus int
+	}{
+		{
+			name:         "bearer access token",
+			headers:      map[string]string{"Authorization": "Bearer " + accessToken},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "refresh token is rejected",
+			headers:      map[string]string{"Authorization": "Bearer " + refreshToken},
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "oauth access token",
+			headers:      map[string]string{"Authorization": "Bearer " + oauthToken},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "oauth token of a revoked grant",
+			headers:      map[string]string{"Authorization": "Bearer " + revokedOAuthToken},
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "oauth refresh token is rejected",
+			headers:      map[string]string{"Authorization": "Bearer " + oauthRefreshToken},
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "impersonation token",
+			headers:      map[string]string{"Authorization": "Bearer " + impersonationToken},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "api key in authorization header",
+			headers:      map[string]string{"Authorization": "ApiKey pk_read"},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "api key in x-api-key header",
+			headers:      map[string]string{"X-API-Key": "pk_read"},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "unknown api key",
+			headers:      map[string]string{"X-API-Key": "pk_unknown"},
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "no credentials",
+			headers:      map[string]string{},
+			expectStatus: fiber.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestRequireScopeAndSession(t *testing.T) {
+	logger.Init("dev")
+
+	accessToken, _ := pkg_jwt.CreateAccessToken(uuid.New().String(), uuid.New().String())
+	oauthToken, _ := pkg_jwt.CreateOAuthAccessToken(pkg_jwt.OAuthClaims{UserID: uuid.New().String(), ClientID: "client", GrantID: "grant", Scopes: []string{"user:read"}})
+	impersonationToken, _ := pkg_jwt.CreateImpersonationToken(uuid.New().String(), pkg_jwt.Actor{UserID: uuid.New().String()})
+
+	tests := []struct {
+		name         string
+		middleware   fiber.Handler
+		headers      map[string]string
+		expectStatus int
+	}{
+		{
+			name:         "api key with scope",
+			middleware:   RequireScope("user:read"),
+			headers:      map[string]string{"X-API-Key": "pk_read"},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "api key without scope",
+			middleware:   RequireScope("user:write"),
+			headers:      map[string]string{"X-API-Key": "pk_read"},
+			expectStatus: fiber.StatusForbidden,
+		},
+		{
+			name:         "oauth token with scope",
+			middleware:   RequireScope("user:read"),
+			headers:      map[string]string{"Authorization": "Bearer " + oauthToken},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "oauth token without scope",
+			middleware:   RequireScope("user:write"),
+			headers:      map[string]string{"Authorization": "Bearer " + oauthToken},
+			expectStatus: fiber.StatusForbidden,
+		},
+		{
+			name:         "access tokens are not scoped",
+			middleware:   RequireScope("user:write"),
+			headers:      map[string]string{"Authorization": "Bearer " + accessToken},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "session only route rejects api keys",
+			middleware:   RequireSession(),
+			headers:      map[string]string{"X-API-Key": "pk_read"},
+			expectStatus: fiber.StatusForbidden,
+		},
+		{
+			name:         "session only route rejects oauth tokens",
+			middleware:   RequireSession(),
+			headers:      map[string]string{"Authorization": "Bearer " + oauthToken},
+			expectStatus: fiber.StatusForbidden,
+		},
+		{
+			name:         "session only route rejects impersonation tokens",
+			middleware:   RequireSession(),
+			headers:      map[string]string{"Authorization": "Bearer " + impersonationToken},
+			expectStatus: fiber.StatusForbidden,
+		},
+		{
+			name:         "impersonation tokens are not scoped",
+			middleware:   RequireScope("user:write"),
+			headers:      map[string]string{"Authorization": "Bearer " + impersonationToken},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "session only route accepts access tokens",
+			middleware:   RequireSession(),
+			headers:      map[string]string{"Authorization": "Bearer " + accessToken},
+			expectStatus: fiber.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(tt.middleware)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+		})
+	}
+}
+
+// adminSessionEnded accepts the user's tokens but not those checked against the admin's session
+type adminSessionEnded struct {
+	adminID string
+}
+
+func (f adminSessionEnded) CheckSession(userPublicID string, sessionID string, issuedAt time.Time) error {
+	if userPublicID == f.adminID {
+		return errors.New("session revoked")
+	}
+	return nil
+}
+
+func TestImpersonation(t *testing.T) {
+	userID := uuid.New().String()
+	adminID := uuid.New().String()
+	token, _ := pkg_jwt.CreateImpersonationToken(userID, pkg_jwt.Actor{UserID: adminID, SessionID: uuid.New().String()})
+
+	t.Run("marks the response and logs both identities", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		logger.Log = zap.New(core)
+
+		app := fiber.New()
+		app.Get("/", AuthMiddleware(fakeSessions{}, fakeAPIKeys{}, fakeGrants{}), func(c *fiber.Ctx) error {
+			return c.SendString(c.Locals(UserIDKey).(string) + " " + c.Locals(ActorIDKey).(string))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, adminID, resp.Header.Get(ImpersonatedByHeader))
+
+		entries := logs.FilterMessage("impersonated request").All()
+		if assert.Len(t, entries, 1) {
+			fields := entries[0].ContextMap()
+			assert.Equal(t, userID, fields["user_id"])
+			assert.Equal(t, adminID, fields["actor_id"])
+			assert.Equal(t, int64(fiber.StatusOK), fields["status"])
+		}
+	})
+
+	t.Run("ends with the admin session", func(t *testing.T) {
+		logger.Init("dev")
+
+		app := fiber.New()
+		app.Get("/", AuthMiddleware(adminSessionEnded{adminID: adminID}, fakeAPIKeys{}, fakeGrants{}), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	OrgIDKey   = "org_id"
+	OrgRoleKey = "org_role"
+
+	OrgIDHeader = "X-Org-ID"
+)
+
+// OrgMembershipChecker returns a user's role in an organization, or
+// organization_errors.ErrNotMember.
+type OrgMembershipChecker interface {
+	GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error)
+}
+
+// RequireOrg selects the organization a request works in from the X-Org-ID header.
+// It must run after AuthMiddleware and stores the organization id and the user's role in it.
+func RequireOrg(orgs OrgMembershipChecker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		userID, ok := c.Locals(UserIDKey).(string)
+		if !ok || userID == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "user not authenticated",
+			})
+		}
+
+		header := c.Get(OrgIDHeader)
+		if header == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "missing " + OrgIDHeader + " header",
+			})
+		}
+
+		orgID, err := uuid.Parse(header)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid " + OrgIDHeader + " header",
+			})
+		}
+
+		userPublicID, err := uuid.Parse(userID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "user not authenticated",
+			})
+		}
+
+		role, err := orgs.GetMemberRole(orgID, userPublicID)
+		if err != nil {
+			if err != organization_errors.ErrNotMember {
+				logger.Log.Error("failed to check organization membership", zap.String("user_id", userID), zap.String("error", err.Error()))
+			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": organization_errors.ErrNotMember.Error(),
+			})
+		}
+
+		c.Locals(OrgIDKey, orgID)
+		c.Locals(OrgRoleKey, role)
+		return c.Next()
+	}
+}
+
+// RequireOrgRole must run after RequireOrg, it lets through members whose role is at
+// least role.
+func RequireOrgRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		current, _ := c.Locals(OrgRoleKey).(string)
+		if !organization_types.RoleAtLeast(current, role) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": organization_errors.ErrInsufficientRole.Error(),
+			})
+		}
+
+		return c.Next()
+	}
+}
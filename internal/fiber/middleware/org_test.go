@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMemberships struct {
+	roles map[uuid.UUID]string
+	err   error
+}
+
+func (f fakeMemberships) GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	role, ok := f.roles[orgPublicID]
+	if !ok {
+		return "", organization_errors.ErrNotMember
+	}
+	return role, nil
+}
+
+func TestRequireOrg(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.NewString()
+	viewerOrg := uuid.New()
+	editorOrg := uuid.New()
+	memberships := fakeMemberships{roles: map[uuid.UUID]string{viewerOrg: "viewer", editorOrg: "editor"}}
+
+	tests := []struct {
+		name         string
+		memberships  fakeMemberships
+		orgHeader    string
+		minRole      string
+		expectStatus int
+	}{
+		{name: "member", memberships: memberships, orgHeader: viewerOrg.String(), minRole: "viewer", expectStatus: fiber.StatusOK},
+		{name: "role high enough", memberships: memberships, orgHeader: editorOrg.String(), minRole: "editor", expectStatus: fiber.StatusOK},
+		{name: "role too low", memberships: memberships, orgHeader: viewerOrg.String(), minRole: "editor", expectStatus: fiber.StatusForbidden},
+		{name: "not a member", memberships: memberships, orgHeader: uuid.NewString(), minRole: "viewer", expectStatus: fiber.StatusForbidden},
+		{name: "missing header", memberships: memberships, minRole: "viewer", expectStatus: fiber.StatusBadRequest},
+		{name: "invalid header", memberships: memberships, orgHeader: "acme", minRole: "viewer", expectStatus: fiber.StatusBadRequest},
+		{name: "lookup fails closed", memberships: fakeMemberships{err: errors.New("db error")}, orgHeader: viewerOrg.String(), minRole: "viewer", expectStatus: fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/",
+				func(c *fiber.Ctx) error {
+					c.Locals(UserIDKey, userID)
+					return c.Next()
+				},
+				RequireOrg(tt.memberships),
+				RequireOrgRole(tt.minRole),
+				func(c *fiber.Ctx) error {
+					return c.SendString(c.Locals(OrgIDKey).(uuid.UUID).String())
+				})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.orgHeader != "" {
+				req.Header.Set(OrgIDHeader, tt.orgHeader)
+			}
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// RoleChecker reports whether a user holds the given role.
+type RoleChecker interface {
+	HasRole(userPublicID string, role string) (bool, error)
+}
+
+// RequireRole must run after AuthMiddleware, it reads the user id stored there.
+func RequireRole(roles RoleChecker, role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		userID, ok := c.Locals(UserIDKey).(string)
+		if !ok || userID == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "user not authenticated",
+			})
+		}
+
+		allowed, err := roles.HasRole(userID, role)
+		if err != nil {
+			logger.Log.Error("failed to check user role", zap.String("user_id", userID), zap.String("error", err.Error()))
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "forbidden",
+			})
+		}
+
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "forbidden",
+			})
+		}
+
+		return c.Next()
+	}
+}
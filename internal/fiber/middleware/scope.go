@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireScope lets API key and oauth client requests through only when they were
+// granted scope. Access tokens belong to an interactive login and are not scoped.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		method := c.Locals(AuthMethodKey)
+		if method != AuthMethodAPIKey && method != AuthMethodOAuth {
+			return c.Next()
+		}
+
+		scopes, _ := c.Locals(ScopesKey).([]string)
+		if !slices.Contains(scopes, scope) {
+			credential := "api key"
+			if method == AuthMethodOAuth {
+				credential = "access token"
+			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": credential + " is missing the " + scope + " scope",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireSession rejects API keys, oauth clients and impersonation tokens, for account
+// management routes that must only be reachable from the user's own interactive login
+// (passwords, MFA, API keys, deletion).
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Locals(AuthMethodKey) {
+		case AuthMethodAPIKey:
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "this endpoint does not accept api keys",
+			})
+		case AuthMethodOAuth:
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "this endpoint does not accept tokens issued to applications",
+			})
+		case AuthMethodImpersonation:
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "this endpoint is not available while impersonating a user",
+			})
+		}
+
+		return c.Next()
+	}
+}
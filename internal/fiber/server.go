@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/celio001/prodify/config"
+	"github.com/celio001/prodify/internal/fiber/middleware"
 	v1 "github.com/celio001/prodify/internal/fiber/v1"
 	"github.com/gofiber/adaptor/v2"
 	"github.com/gofiber/fiber/v2"
@@ -26,6 +28,8 @@ import (
 // @name Authorization
 func (h HttpServer) Start(ctx context.Context) error {
 	
+	h.app.Use(middleware.RequestID(), middleware.Audit(h.auditRecorder))
+
 	router := h.app.Group("/api/")
 
 	h.app.Get("/health", healthCheck)
@@ -48,16 +52,25 @@ func (h HttpServer) Start(ctx context.Context) error {
 
 	h.app.Get("/api/health", healthCheck)
 
+	// uploaded files kept on this host, STORAGE_PUBLIC_URL points here
+	if config.GetString("STORAGE_DRIVER") == "local" {
+		h.app.Static("/uploads", config.GetString("STORAGE_LOCAL_DIR"))
+	}
+
 	v1Router := router.Group(v1.HandlerPath)
-	v1.RegisterRouter(v1Router, h.productRepository, h.auth_service, h.userService)
+	v1.RegisterRouter(v1Router, h.productRepository, h.auth_service, h.userService, h.apiKeyService, h.oauthService, h.orgService, h.providers, h.auditService)
 
 	addr := fmt.Sprint(":8080")
 	logger.Log.Info("Starting server on " + addr)
 	return h.app.Listen(addr)
 }
 
+// Stop waits for requests in flight, then for the audit events they recorded to be stored.
 func (h HttpServer) Stop(ctx context.Context) error {
-	return h.app.Shutdown()
+	if err := h.app.Shutdown(); err != nil {
+		return err
+	}
+	return h.auditRecorder.Stop(ctx)
 }
 
 // HealthCheck godoc
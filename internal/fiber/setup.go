@@ -1,7 +1,13 @@
 package fiber
 
 import (
+	"github.com/celio001/prodify/config"
+	apikey_service "github.com/celio001/prodify/internal/apikey/service"
+	audit_service "github.com/celio001/prodify/internal/audit/service"
+	auth_oidc "github.com/celio001/prodify/internal/auth/oidc"
 	auth_service "github.com/celio001/prodify/internal/auth/service"
+	oauth_service "github.com/celio001/prodify/internal/oauth/service"
+	organization_service "github.com/celio001/prodify/internal/organization/service"
 	user_service "github.com/celio001/prodify/internal/user/service"
 	product_repo "github.com/celio001/prodify/product"
 	"github.com/gofiber/fiber/v2"
@@ -12,11 +18,19 @@ type HttpServer struct {
 	productRepository product_repo.Repository
 	auth_service      auth_service.AuthService
 	userService       user_service.UserService
+	apiKeyService     apikey_service.APIKeyService
+	oauthService      oauth_service.OAuthService
+	orgService        organization_service.OrganizationService
+	providers         *auth_oidc.Registry
+	auditService      audit_service.AuditService
+	auditRecorder     *audit_service.Recorder
 }
 
-func CreateServer(productRepository product_repo.Repository, authRepository auth_service.AuthService, userService user_service.UserService) HttpServer {
+func CreateServer(productRepository product_repo.Repository, authRepository auth_service.AuthService, userService user_service.UserService, apiKeyService apikey_service.APIKeyService, oauthService oauth_service.OAuthService, orgService organization_service.OrganizationService, providers *auth_oidc.Registry, auditService audit_service.AuditService, auditRecorder *audit_service.Recorder) HttpServer {
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
+		// set when running behind a reverse proxy so ctx.IP() is the real client
+		ProxyHeader: config.GetString("HTTP_PROXY_HEADER"),
 	})
 
 	httpServer := HttpServer{
@@ -24,6 +38,12 @@ func CreateServer(productRepository product_repo.Repository, authRepository auth
 		productRepository: productRepository,
 		auth_service:      authRepository,
 		userService:       userService,
+		apiKeyService:     apiKeyService,
+		oauthService:      oauthService,
+		orgService:        orgService,
+		providers:         providers,
+		auditService:      auditService,
+		auditRecorder:     auditRecorder,
 	}
 
 	return httpServer
@@ -0,0 +1,436 @@
+package admin_handler
+
+import (
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_service "github.com/celio001/prodify/internal/user/service"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_request "github.com/celio001/prodify/pkg/request"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PasswordResetSender emails a password reset link, auth_service.AuthService is one.
+type PasswordResetSender interface {
+	ForgotPassword(email string)
+}
+
+// UserInviter sends and manages account invitations, auth_service.AuthService is one.
+type UserInviter interface {
+	CreateInvitation(adminPublicID uuid.UUID, createRequest auth_types.CreateInvitationRequest) (*auth_types.Invitation, error)
+	ListInvitations(listRequest auth_types.ListInvitationsRequest) ([]auth_types.Invitation, error)
+	RevokeInvitation(invitationPublicID uuid.UUID) error
+}
+
+// UserImpersonator issues access tokens to act as another user, auth_service.AuthService is one.
+type UserImpersonator interface {
+	Impersonate(adminPublicID uuid.UUID, adminSessionID string, userPublicID uuid.UUID) (*auth_types.ImpersonationToken, error)
+}
+
+type adminHandler struct {
+	userService    user_service.UserService
+	passwordResets PasswordResetSender
+	invitations    UserInviter
+	impersonations UserImpersonator
+}
+
+type AdminHandler interface {
+	ListUsersHandler(ctx *fiber.Ctx) error
+	GetUserHandler(ctx *fiber.Ctx) error
+	ActivateUserHandler(ctx *fiber.Ctx) error
+	DeactivateUserHandler(ctx *fiber.Ctx) error
+	RestoreUserHandler(ctx *fiber.Ctx) error
+	ForcePasswordResetHandler(ctx *fiber.Ctx) error
+	ChangeRoleHandler(ctx *fiber.Ctx) error
+	CreateInvitationHandler(ctx *fiber.Ctx) error
+	ListInvitationsHandler(ctx *fiber.Ctx) error
+	RevokeInvitationHandler(ctx *fiber.Ctx) error
+	ImpersonateUserHandler(ctx *fiber.Ctx) error
+}
+
+func NewAdminHandler(userService user_service.UserService, passwordResets PasswordResetSender, invitations UserInviter, impersonations UserImpersonator) AdminHandler {
+	return &adminHandler{userService: userService, passwordResets: passwordResets, invitations: invitations, impersonations: impersonations}
+}
+
+const maxBodySize = 1 << 20 // 1MB
+var validate = validator.New()
+
+// @Summary List users
+// @Description Paginated user list for admins, newest first. Deleted users are included unless filtered out
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param email query string false "Part of the email, case insensitive"
+// @Param name query string false "Part of the name, case insensitive"
+// @Param role query string false "user or admin"
+// @Param active query bool false "Only active or only deactivated users"
+// @Param deleted query bool false "Only deleted or only non-deleted users"
+// @Param created_from query string false "Created at or after, RFC 3339"
+// @Param created_to query string false "Created before, RFC 3339"
+// @Param page query int false "Page, starting at 1"
+// @Param page_size query int false "Page size, at most 100"
+// @Success 200 {object} user_types.ListUsersResponse "Users loaded successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid filters"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users [get]
+func (h *adminHandler) ListUsersHandler(ctx *fiber.Ctx) error {
+	var listRequest user_types.ListUsersRequest
+
+	if err := ctx.QueryParser(&listRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "invalid query parameters"})
+	}
+
+	if err := validate.Struct(listRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": user_errors.ListUsersValidateError(err)})
+	}
+
+	users, err := h.userService.ListUsers(listRequest)
+	if err != nil {
+		return adminError(ctx, err, "failed to list users")
+	}
+
+	logger.Log.Info("admin listed users",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("query", string(ctx.Request().URI().QueryString())))
+
+	return ctx.Status(fiber.StatusOK).JSON(users)
+}
+
+// @Summary Get a user
+// @Description Admin view of a user, deleted users included
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User public ID"
+// @Success 200 {object} user_types.AdminUserResponse "User loaded successfully"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/{id} [get]
+func (h *adminHandler) GetUserHandler(ctx *fiber.Ctx) error {
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	user, err := h.userService.GetAdminUser(id)
+	if err != nil {
+		return adminError(ctx, err, "failed to get user")
+	}
+
+	logger.Log.Info("admin viewed user",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("user_id", id.String()))
+
+	return ctx.Status(fiber.StatusOK).JSON(user)
+}
+
+// @Summary Activate a user
+// @Description Lets a deactivated user sign in again
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User public ID"
+// @Success 200 {object} map[string]string "User activated"
+// @Failure 400 {object} map[string]string "Invalid user ID or user is deleted"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/{id}/activate [post]
+func (h *adminHandler) ActivateUserHandler(ctx *fiber.Ctx) error {
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	before, err := h.userService.GetAdminUser(id)
+	if err != nil {
+		return adminError(ctx, err, "failed to activate user")
+	}
+
+	if err := h.userService.SetUserActive(id, true); err != nil {
+		return adminError(ctx, err, "failed to activate user")
+	}
+
+	after := *before
+	after.IsActive = true
+	auditUserUpdate(ctx, id, before, &after)
+
+	logger.Log.Info("admin activated user",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("user_id", id.String()))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "user activated"})
+}
+
+// @Summary Deactivate a user
+// @Description Blocks every sign-in method and revokes the user's sessions. Admins cannot deactivate themselves
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User public ID"
+// @Success 200 {object} map[string]string "User deactivated"
+// @Failure 400 {object} map[string]string "Invalid user ID, own account or user is deleted"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/{id}/deactivate [post]
+func (h *adminHandler) DeactivateUserHandler(ctx *fiber.Ctx) error {
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if isSelf(ctx, id) {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": user_errors.ErrCannotModifySelf.Error()})
+	}
+
+	before, err := h.userService.GetAdminUser(id)
+	if err != nil {
+		return adminError(ctx, err, "failed to deactivate user")
+	}
+
+	if err := h.userService.SetUserActive(id, false); err != nil {
+		return adminError(ctx, err, "failed to deactivate user")
+	}
+
+	after := *before
+	after.IsActive = false
+	auditUserUpdate(ctx, id, before, &after)
+
+	logger.Log.Info("admin deactivated user",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("user_id", id.String()))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "user deactivated"})
+}
+
+// @Summary Restore a user
+// @Description Undoes a soft delete and cancels the pending erasure, the user comes back active
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User public ID"
+// @Success 200 {object} map[string]string "User restored"
+// @Failure 400 {object} map[string]string "Invalid user ID, user is not deleted or already erased"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 409 {object} map[string]string "Email is in use by another account"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/{id}/restore [post]
+func (h *adminHandler) RestoreUserHandler(ctx *fiber.Ctx) error {
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if err := h.userService.RestoreUser(id); err != nil {
+		return adminError(ctx, err, "failed to restore user")
+	}
+
+	middleware.RecordAudit(ctx, audit_types.Event{
+		Action:     audit_types.ActionUserRestored,
+		TargetType: audit_types.TargetUser,
+		TargetID:   id.String(),
+	})
+
+	logger.Log.Info("admin restored user",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("user_id", id.String()))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "user restored"})
+}
+
+// @Summary Force a password reset
+// @Description Signs the user out everywhere, revokes their API keys and OAuth grants, blocks every login method until the password is changed and emails a reset link
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User public ID"
+// @Success 200 {object} map[string]string "Password reset required"
+// @Failure 400 {object} map[string]string "Invalid user ID or user is deleted"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/{id}/password-reset [post]
+func (h *adminHandler) ForcePasswordResetHandler(ctx *fiber.Ctx) error {
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	user, err := h.userService.ForcePasswordReset(id)
+	if err != nil {
+		return adminError(ctx, err, "failed to force password reset")
+	}
+
+	h.passwordResets.ForgotPassword(user.Email)
+
+	middleware.RecordAudit(ctx, audit_types.Event{
+		Action:     audit_types.ActionPasswordResetForced,
+		TargetType: audit_types.TargetUser,
+		TargetID:   id.String(),
+	})
+
+	logger.Log.Info("admin forced password reset",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("user_id", id.String()))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "password reset required, a reset link was sent"})
+}
+
+// @Summary Change a user's role
+// @Description Takes effect on the user's next request. Admins cannot change their own role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User public ID"
+// @Param request body user_types.ChangeRoleRequest true "New role"
+// @Success 200 {object} map[string]string "Role changed"
+// @Failure 400 {object} map[string]interface{} "Invalid request, own account or user is deleted"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/{id}/role [patch]
+func (h *adminHandler) ChangeRoleHandler(ctx *fiber.Ctx) error {
+	var roleRequest user_types.ChangeRoleRequest
+
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &roleRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(roleRequest); err != nil {
+		logger.Log.Error("invalid change role payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": user_errors.ChangeRoleValidateError(err)})
+	}
+
+	if isSelf(ctx, id) {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": user_errors.ErrCannotModifySelf.Error()})
+	}
+
+	before, err := h.userService.GetAdminUser(id)
+	if err != nil {
+		return adminError(ctx, err, "failed to change role")
+	}
+
+	if err := h.userService.ChangeUserRole(id, roleRequest.Role); err != nil {
+		return adminError(ctx, err, "failed to change role")
+	}
+
+	after := *before
+	after.Role = roleRequest.Role
+	auditUserUpdate(ctx, id, before, &after)
+
+	logger.Log.Info("admin changed user role",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("user_id", id.String()),
+		zap.String("role", roleRequest.Role))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "role changed"})
+}
+
+// isSelf reports whether the target of an admin action is the admin making it.
+func isSelf(ctx *fiber.Ctx, id uuid.UUID) bool {
+	adminID, _ := ctx.Locals(middleware.UserIDKey).(string)
+	return adminID == id.String()
+}
+
+// @Summary Impersonate a user
+// @Description Returns a 15 minute access token to use the API as the user, without a refresh token. Responses to its requests carry
+// @Description the X-Impersonated-By header, account management (passwords, email, MFA, API keys, sessions, deletion, export) is refused
+// @Description and every request is logged under both identities. The token stops working when the admin's session ends
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User public ID"
+// @Success 200 {object} auth_types.ImpersonationToken "Impersonation token issued"
+// @Failure 400 {object} map[string]string "Invalid user ID, own account, an admin or a deactivated user"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/{id}/impersonate [post]
+func (h *adminHandler) ImpersonateUserHandler(ctx *fiber.Ctx) error {
+	userID, _ := ctx.Locals(middleware.UserIDKey).(string)
+	adminID, err := uuid.Parse(userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "user not authenticated"})
+	}
+	sessionID, _ := ctx.Locals(middleware.SessionIDKey).(string)
+
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	impersonation, err := h.impersonations.Impersonate(adminID, sessionID, id)
+	if err != nil {
+		switch err {
+		case auth_errors.ErrCannotImpersonate, auth_errors.ErrUserInactive:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return adminError(ctx, err, "failed to impersonate user")
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(impersonation)
+}
+
+// auditUserUpdate records an admin change to a user with what it changed.
+func auditUserUpdate(ctx *fiber.Ctx, id uuid.UUID, before, after *user_types.AdminUserResponse) {
+	middleware.RecordAudit(ctx, audit_types.Event{
+		Action:     audit_types.ActionUserUpdated,
+		TargetType: audit_types.TargetUser,
+		TargetID:   id.String(),
+		Changes:    audit_types.Diff(before, after),
+	})
+}
+
+func adminError(ctx *fiber.Ctx, err error, message string) error {
+	switch err {
+	case user_errors.ErrUserNotFound:
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case user_errors.ErrUserDeleted, user_errors.ErrUserNotDeleted, user_errors.ErrUserErased, user_errors.ErrInvalidDateRange:
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case user_errors.ErrEmailTaken:
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		logger.Log.Error(message, zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,438 @@
+package admin_handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	audit_service_mock "github.com/celio001/prodify/internal/audit/service/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_mock "github.com/celio001/prodify/internal/auth/service/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_service_mock "github.com/celio001/prodify/internal/user/service/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestApp(userService *user_service_mock.MockUserService, authService *auth_mock.MockAuthService, adminID string) *fiber.App {
+	app := fiber.New()
+	handler := NewAdminHandler(userService, authService, authService, authService)
+
+	withAdmin := func(next fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", adminID)
+			return next(c)
+		}
+	}
+
+	app.Get("/users", withAdmin(handler.ListUsersHandler))
+	app.Post("/users/invitations", withAdmin(handler.CreateInvitationHandler))
+	app.Get("/users/invitations", withAdmin(handler.ListInvitationsHandler))
+	app.Delete("/users/invitations/:id", withAdmin(handler.RevokeInvitationHandler))
+	app.Get("/users/:id", withAdmin(handler.GetUserHandler))
+	app.Post("/users/:id/activate", withAdmin(handler.ActivateUserHandler))
+	app.Post("/users/:id/deactivate", withAdmin(handler.DeactivateUserHandler))
+	app.Post("/users/:id/restore", withAdmin(handler.RestoreUserHandler))
+	app.Post("/users/:id/password-reset", withAdmin(handler.ForcePasswordResetHandler))
+	app.Patch("/users/:id/role", withAdmin(handler.ChangeRoleHandler))
+	app.Post("/users/:id/impersonate", withAdmin(handler.ImpersonateUserHandler))
+
+	return app
+}
+
+func TestListUsersHandler(t *testing.T) {
+	logger.Init("dev")
+
+	active := true
+
+	tests := []struct {
+		name         string
+		query        string
+		request      user_types.ListUsersRequest
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "no filters",
+			query:        "",
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:  "filters",
+			query: "?email=acme&role=admin&active=true&created_from=2026-01-01T00:00:00Z&page=2&page_size=50",
+			request: user_types.ListUsersRequest{
+				Email:       "acme",
+				Role:        "admin",
+				Active:      &active,
+				CreatedFrom: "2026-01-01T00:00:00Z",
+				Page:        2,
+				PageSize:    50,
+			},
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "unknown role",
+			query:        "?role=owner",
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "page size too large",
+			query:        "?page_size=500",
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "invalid date",
+			query:        "?created_to=yesterday",
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "inverted date range",
+			query:        "?created_from=2026-02-01T00:00:00Z&created_to=2026-01-01T00:00:00Z",
+			request:      user_types.ListUsersRequest{CreatedFrom: "2026-02-01T00:00:00Z", CreatedTo: "2026-01-01T00:00:00Z"},
+			serviceError: user_errors.ErrInvalidDateRange,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "service error",
+			query:        "",
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(user_service_mock.MockUserService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					userService.On("ListUsers", tt.request).Return(nil, tt.serviceError)
+				} else {
+					userService.On("ListUsers", tt.request).Return(&user_types.ListUsersResponse{Page: 1, PageSize: 20}, nil)
+				}
+			}
+
+			app := setupTestApp(userService, new(auth_mock.MockAuthService), uuid.NewString())
+
+			req := httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				userService.AssertExpectations(t)
+			} else {
+				userService.AssertNotCalled(t, "ListUsers", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestGetUserHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		id           string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "success", id: userID.String(), callService: true, expectStatus: fiber.StatusOK},
+		{name: "invalid id", id: "abc", callService: false, expectStatus: fiber.StatusBadRequest},
+		{name: "not found", id: userID.String(), serviceError: user_errors.ErrUserNotFound, callService: true, expectStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(user_service_mock.MockUserService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					userService.On("GetAdminUser", userID).Return(nil, tt.serviceError)
+				} else {
+					userService.On("GetAdminUser", userID).Return(&user_types.AdminUserResponse{PublicID: userID.String()}, nil)
+				}
+			}
+
+			app := setupTestApp(userService, new(auth_mock.MockAuthService), uuid.NewString())
+
+			req := httptest.NewRequest(http.MethodGet, "/users/"+tt.id, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			userService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSetUserActiveHandlers(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		path         string
+		active       bool
+		lookupError  error
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "activate", path: "/users/" + userID.String() + "/activate", active: true, callService: true, expectStatus: fiber.StatusOK},
+		{name: "deactivate", path: "/users/" + userID.String() + "/deactivate", active: false, callService: true, expectStatus: fiber.StatusOK},
+		{name: "deactivate self", path: "/users/" + adminID.String() + "/deactivate", callService: false, expectStatus: fiber.StatusBadRequest},
+		{name: "deleted user", path: "/users/" + userID.String() + "/activate", active: true, serviceError: user_errors.ErrUserDeleted, callService: true, expectStatus: fiber.StatusBadRequest},
+		{name: "not found", path: "/users/" + userID.String() + "/deactivate", active: false, lookupError: user_errors.ErrUserNotFound, callService: false, expectStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(user_service_mock.MockUserService)
+			if tt.lookupError != nil {
+				userService.On("GetAdminUser", userID).Return(nil, tt.lookupError)
+			}
+			if tt.callService {
+				userService.On("GetAdminUser", userID).Return(&user_types.AdminUserResponse{IsActive: !tt.active}, nil)
+				userService.On("SetUserActive", userID, tt.active).Return(tt.serviceError)
+			}
+
+			app := setupTestApp(userService, new(auth_mock.MockAuthService), adminID.String())
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				userService.AssertExpectations(t)
+			} else {
+				userService.AssertNotCalled(t, "SetUserActive", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRestoreUserHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		serviceError error
+		expectStatus int
+	}{
+		{name: "success", expectStatus: fiber.StatusOK},
+		{name: "not deleted", serviceError: user_errors.ErrUserNotDeleted, expectStatus: fiber.StatusBadRequest},
+		{name: "email taken", serviceError: user_errors.ErrEmailTaken, expectStatus: fiber.StatusConflict},
+		{name: "service error", serviceError: errors.New("db error"), expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(user_service_mock.MockUserService)
+			userService.On("RestoreUser", userID).Return(tt.serviceError)
+
+			app := setupTestApp(userService, new(auth_mock.MockAuthService), uuid.NewString())
+
+			req := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/restore", nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			userService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestForcePasswordResetHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	t.Run("sends the reset email", func(t *testing.T) {
+		userService := new(user_service_mock.MockUserService)
+		authService := new(auth_mock.MockAuthService)
+		userService.On("ForcePasswordReset", userID).Return(&user_types.AdminUserResponse{Email: "jane@example.com", PasswordResetRequired: true}, nil)
+		authService.On("ForgotPassword", "jane@example.com").Return()
+
+		app := setupTestApp(userService, authService, uuid.NewString())
+
+		req := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/password-reset", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		userService.AssertExpectations(t)
+		authService.AssertExpectations(t)
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		userService := new(user_service_mock.MockUserService)
+		authService := new(auth_mock.MockAuthService)
+		userService.On("ForcePasswordReset", userID).Return(nil, user_errors.ErrUserDeleted)
+
+		app := setupTestApp(userService, authService, uuid.NewString())
+
+		req := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/password-reset", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		authService.AssertNotCalled(t, "ForgotPassword", mock.Anything)
+	})
+}
+
+func TestChangeRoleHandler(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		id           uuid.UUID
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "success", id: userID, body: `{"role":"admin"}`, callService: true, expectStatus: fiber.StatusOK},
+		{name: "unknown role", id: userID, body: `{"role":"owner"}`, callService: false, expectStatus: fiber.StatusBadRequest},
+		{name: "missing role", id: userID, body: `{}`, callService: false, expectStatus: fiber.StatusBadRequest},
+		{name: "own role", id: adminID, body: `{"role":"user"}`, callService: false, expectStatus: fiber.StatusBadRequest},
+		{name: "not found", id: userID, body: `{"role":"admin"}`, serviceError: user_errors.ErrUserNotFound, callService: true, expectStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := new(user_service_mock.MockUserService)
+			if tt.callService {
+				userService.On("GetAdminUser", tt.id).Return(&user_types.AdminUserResponse{Role: "user"}, nil)
+				userService.On("ChangeUserRole", tt.id, "admin").Return(tt.serviceError)
+			}
+
+			app := setupTestApp(userService, new(auth_mock.MockAuthService), adminID.String())
+
+			req := httptest.NewRequest(http.MethodPatch, "/users/"+tt.id.String()+"/role", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				userService.AssertExpectations(t)
+			} else {
+				userService.AssertNotCalled(t, "ChangeUserRole", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestImpersonateUserHandler(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		id           string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "success", id: userID.String(), callService: true, expectStatus: fiber.StatusOK},
+		{name: "invalid id", id: "not-a-uuid", expectStatus: fiber.StatusBadRequest},
+		{name: "admin or own account", id: userID.String(), serviceError: auth_errors.ErrCannotImpersonate, callService: true, expectStatus: fiber.StatusBadRequest},
+		{name: "deactivated user", id: userID.String(), serviceError: auth_errors.ErrUserInactive, callService: true, expectStatus: fiber.StatusBadRequest},
+		{name: "not found", id: userID.String(), serviceError: user_errors.ErrUserNotFound, callService: true, expectStatus: fiber.StatusNotFound},
+		{name: "service error", id: userID.String(), serviceError: errors.New("db error"), callService: true, expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					authService.On("Impersonate", adminID, "", userID).Return(nil, tt.serviceError)
+				} else {
+					authService.On("Impersonate", adminID, "", userID).
+						Return(&auth_types.ImpersonationToken{AccessToken: "access", TokenType: "Bearer", UserID: userID.String(), ActorID: adminID.String()}, nil)
+				}
+			}
+
+			app := setupTestApp(new(user_service_mock.MockUserService), authService, adminID.String())
+
+			req := httptest.NewRequest(http.MethodPost, "/users/"+tt.id+"/impersonate", nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				authService.AssertExpectations(t)
+			} else {
+				authService.AssertNotCalled(t, "Impersonate", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestChangeRoleHandler_Audited(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.NewString()
+	userID := uuid.New()
+
+	userService := new(user_service_mock.MockUserService)
+	userService.On("GetAdminUser", userID).Return(&user_types.AdminUserResponse{PublicID: userID.String(), Role: "user"}, nil)
+	userService.On("ChangeUserRole", userID, "admin").Return(nil)
+
+	recorder := new(audit_service_mock.MockRecorder)
+	handler := NewAdminHandler(userService, nil, nil, nil)
+	app := fiber.New()
+	app.Patch("/users/:id/role", middleware.Audit(recorder), func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserIDKey, adminID)
+		return handler.ChangeRoleHandler(c)
+	})
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/"+userID.String()+"/role", strings.NewReader(`{"role":"admin"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	if assert.Len(t, recorder.Events, 1) {
+		event := recorder.Events[0]
+		assert.Equal(t, audit_types.ActionUserUpdated, event.Action)
+		assert.Equal(t, adminID, event.ActorID)
+		assert.Equal(t, userID.String(), event.TargetID)
+		assert.Equal(t, map[string]audit_types.Change{"role": {Before: "user", After: "admin"}}, event.Changes)
+	}
+}
@@ -0,0 +1,137 @@
+package admin_handler
+
+import (
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_request "github.com/celio001/prodify/pkg/request"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// @Summary Invite a user
+// @Description Emails a single-use link to create an account with the given role, the invitee chooses their name and password.
+// @Description Inviting an address again replaces its pending invitation
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body auth_types.CreateInvitationRequest true "Email and role, user when omitted"
+// @Success 201 {object} auth_types.Invitation "Invitation sent"
+// @Failure 400 {object} map[string]interface{} "Invalid request"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 409 {object} map[string]string "The email already has an account"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/invitations [post]
+func (h *adminHandler) CreateInvitationHandler(ctx *fiber.Ctx) error {
+	var createRequest auth_types.CreateInvitationRequest
+
+	userID, _ := ctx.Locals(middleware.UserIDKey).(string)
+	adminID, err := uuid.Parse(userID)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &createRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(createRequest); err != nil {
+		logger.Log.Error("invalid invitation payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.CreateInvitationValidateError(err)})
+	}
+
+	invitation, err := h.invitations.CreateInvitation(adminID, createRequest)
+	if err != nil {
+		return invitationError(ctx, err, "failed to invite user")
+	}
+
+	logger.Log.Info("admin invited user",
+		zap.String("admin_id", adminID.String()),
+		zap.String("invitation_id", invitation.PublicID),
+		zap.String("role", invitation.Role))
+
+	return ctx.Status(fiber.StatusCreated).JSON(invitation)
+}
+
+// @Summary List invitations
+// @Description Every account invitation, newest first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, accepted, expired or revoked"
+// @Success 200 {array} auth_types.Invitation "Invitations loaded successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid filters"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/invitations [get]
+func (h *adminHandler) ListInvitationsHandler(ctx *fiber.Ctx) error {
+	var listRequest auth_types.ListInvitationsRequest
+
+	if err := ctx.QueryParser(&listRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "invalid query parameters"})
+	}
+
+	if err := validate.Struct(listRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.ListInvitationsValidateError(err)})
+	}
+
+	invitations, err := h.invitations.ListInvitations(listRequest)
+	if err != nil {
+		return invitationError(ctx, err, "failed to list invitations")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(invitations)
+}
+
+// @Summary Revoke an invitation
+// @Description The link stops working. Only pending invitations can be revoked
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} map[string]string "Invitation revoked"
+// @Failure 400 {object} map[string]string "Invalid invitation ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "No pending invitation with this ID"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/users/invitations/{id} [delete]
+func (h *adminHandler) RevokeInvitationHandler(ctx *fiber.Ctx) error {
+	id, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_INVITATION_ID"})
+	}
+
+	if err := h.invitations.RevokeInvitation(id); err != nil {
+		return invitationError(ctx, err, "failed to revoke invitation")
+	}
+
+	logger.Log.Info("admin revoked invitation",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("invitation_id", id.String()))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "invitation revoked"})
+}
+
+func invitationError(ctx *fiber.Ctx, err error, message string) error {
+	switch err {
+	case auth_errors.ErrInvitationNotFound:
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case auth_errors.ErrUserAlreadyExists:
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		logger.Log.Error(message, zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,144 @@
+package admin_handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_mock "github.com/celio001/prodify/internal/auth/service/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_service_mock "github.com/celio001/prodify/internal/user/service/mock"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateInvitationHandler(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.New()
+
+	tests := []struct {
+		name         string
+		body         string
+		request      auth_types.CreateInvitationRequest
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "success", body: `{"email":"bob@example.com","role":"admin"}`, request: auth_types.CreateInvitationRequest{Email: "bob@example.com", Role: "admin"}, callService: true, expectStatus: fiber.StatusCreated},
+		{name: "default role", body: `{"email":"bob@example.com"}`, request: auth_types.CreateInvitationRequest{Email: "bob@example.com"}, callService: true, expectStatus: fiber.StatusCreated},
+		{name: "invalid email", body: `{"email":"bob"}`, expectStatus: fiber.StatusBadRequest},
+		{name: "unknown role", body: `{"email":"bob@example.com","role":"owner"}`, expectStatus: fiber.StatusBadRequest},
+		{name: "already registered", body: `{"email":"bob@example.com"}`, request: auth_types.CreateInvitationRequest{Email: "bob@example.com"}, serviceError: auth_errors.ErrUserAlreadyExists, callService: true, expectStatus: fiber.StatusConflict},
+		{name: "service error", body: `{"email":"bob@example.com"}`, request: auth_types.CreateInvitationRequest{Email: "bob@example.com"}, serviceError: errors.New("smtp down"), callService: true, expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					authService.On("CreateInvitation", adminID, tt.request).Return(nil, tt.serviceError)
+				} else {
+					authService.On("CreateInvitation", adminID, tt.request).
+						Return(&auth_types.Invitation{PublicID: uuid.NewString(), Email: "bob@example.com", Role: "user", Status: auth_types.InvitationPending}, nil)
+				}
+			}
+
+			app := setupTestApp(new(user_service_mock.MockUserService), authService, adminID.String())
+
+			req := httptest.NewRequest(http.MethodPost, "/users/invitations", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				authService.AssertExpectations(t)
+			} else {
+				authService.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestListInvitationsHandler(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("filters by status", func(t *testing.T) {
+		authService := new(auth_mock.MockAuthService)
+		authService.On("ListInvitations", auth_types.ListInvitationsRequest{Status: auth_types.InvitationExpired}).
+			Return([]auth_types.Invitation{}, nil)
+
+		app := setupTestApp(new(user_service_mock.MockUserService), authService, uuid.NewString())
+
+		req := httptest.NewRequest(http.MethodGet, "/users/invitations?status=expired", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		authService.AssertExpectations(t)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		authService := new(auth_mock.MockAuthService)
+
+		app := setupTestApp(new(user_service_mock.MockUserService), authService, uuid.NewString())
+
+		req := httptest.NewRequest(http.MethodGet, "/users/invitations?status=lost", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		authService.AssertNotCalled(t, "ListInvitations", mock.Anything)
+	})
+}
+
+func TestRevokeInvitationHandler(t *testing.T) {
+	logger.Init("dev")
+
+	invitationID := uuid.New()
+
+	tests := []struct {
+		name         string
+		id           string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "success", id: invitationID.String(), callService: true, expectStatus: fiber.StatusOK},
+		{name: "invalid id", id: "not-a-uuid", expectStatus: fiber.StatusBadRequest},
+		{name: "not pending", id: invitationID.String(), serviceError: auth_errors.ErrInvitationNotFound, callService: true, expectStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				authService.On("RevokeInvitation", invitationID).Return(tt.serviceError)
+			}
+
+			app := setupTestApp(new(user_service_mock.MockUserService), authService, uuid.NewString())
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/invitations/"+tt.id, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				authService.AssertExpectations(t)
+			} else {
+				authService.AssertNotCalled(t, "RevokeInvitation", mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package admin_handler
+
+import (
+	auth_service "github.com/celio001/prodify/internal/auth/service"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_service "github.com/celio001/prodify/internal/user/service"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HandlerPath = "/admin/users"
+)
+
+func RegisterRouter(router fiber.Router, userService user_service.UserService, authService auth_service.AuthService, authMiddleware fiber.Handler) {
+
+	handler := NewAdminHandler(userService, authService, authService, authService)
+	session := middleware.RequireSession()
+	admin := middleware.RequireRole(authService, user_types.RoleAdmin)
+
+	router.Get("/", authMiddleware, session, admin, handler.ListUsersHandler)
+	// before "/:id", invitations is not a user id
+	router.Post("/invitations", authMiddleware, session, admin, handler.CreateInvitationHandler)
+	router.Get("/invitations", authMiddleware, session, admin, handler.ListInvitationsHandler)
+	router.Delete("/invitations/:id", authMiddleware, session, admin, handler.RevokeInvitationHandler)
+	router.Get("/:id", authMiddleware, session, admin, handler.GetUserHandler)
+	router.Post("/:id/activate", authMiddleware, session, admin, handler.ActivateUserHandler)
+	router.Post("/:id/deactivate", authMiddleware, session, admin, handler.DeactivateUserHandler)
+	router.Post("/:id/restore", authMiddleware, session, admin, handler.RestoreUserHandler)
+	router.Post("/:id/password-reset", authMiddleware, session, admin, handler.ForcePasswordResetHandler)
+	router.Patch("/:id/role", authMiddleware, session, admin, handler.ChangeRoleHandler)
+	router.Post("/:id/impersonate", authMiddleware, session, admin, handler.ImpersonateUserHandler)
+}
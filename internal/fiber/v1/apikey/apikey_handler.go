@@ -0,0 +1,178 @@
+package apikey_handler
+
+import (
+	apikey_errors "github.com/celio001/prodify/internal/apikey/errors"
+	apikey_service "github.com/celio001/prodify/internal/apikey/service"
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_request "github.com/celio001/prodify/pkg/request"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type apiKeyHandler struct {
+	apiKeyService apikey_service.APIKeyService
+}
+
+type APIKeyHandler interface {
+	CreateAPIKeyHandler(ctx *fiber.Ctx) error
+	ListAPIKeysHandler(ctx *fiber.Ctx) error
+	RevokeAPIKeyHandler(ctx *fiber.Ctx) error
+}
+
+func NewAPIKeyHandler(apiKeyService apikey_service.APIKeyService) APIKeyHandler {
+	return &apiKeyHandler{apiKeyService: apiKeyService}
+}
+
+const maxBodySize = 1 << 20 // 1MB
+var validate = validator.New()
+
+// @Summary Create an API key
+// @Description Creates a long-lived API key for machine clients. The full key is only returned in this response
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body apikey_types.CreateAPIKeyRequest true "Create API key payload"
+// @Success 201 {object} apikey_types.CreateAPIKeyResponse "API key created"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error or expiry in the past"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/api-keys [post]
+func (h *apiKeyHandler) CreateAPIKeyHandler(ctx *fiber.Ctx) error {
+	var createRequest apikey_types.CreateAPIKeyRequest
+
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &createRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(createRequest); err != nil {
+		logger.Log.Error("invalid create api key payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": apikey_errors.CreateAPIKeyValidateError(err)})
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(id, createRequest)
+	if err != nil {
+		switch err {
+		case apikey_errors.ErrInvalidExpiry:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to create api key", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create api key"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(key)
+}
+
+// @Summary List API keys
+// @Description Lists the active API keys of the authenticated user. Secrets are never returned
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "API keys loaded successfully"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/api-keys [get]
+func (h *apiKeyHandler) ListAPIKeysHandler(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(id)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to list api keys", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list api keys"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "api keys loaded successfully",
+		"data":    keys,
+	})
+}
+
+// @Summary Revoke an API key
+// @Description Revokes one of the authenticated user's API keys, it stops working immediately
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]string "API key revoked"
+// @Failure 400 {object} map[string]string "Invalid user or key ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key"
+// @Failure 404 {object} map[string]string "API key not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/api-keys/{id} [delete]
+func (h *apiKeyHandler) RevokeAPIKeyHandler(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	keyID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_API_KEY_ID"})
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(id, keyID); err != nil {
+		switch err {
+		case apikey_errors.ErrAPIKeyNotFound:
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to revoke api key", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke api key"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "api key revoked"})
+}
@@ -0,0 +1,203 @@
+package apikey_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apikey_errors "github.com/celio001/prodify/internal/apikey/errors"
+	apikey_service_mock "github.com/celio001/prodify/internal/apikey/service/mock"
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestApp(service *apikey_service_mock.MockAPIKeyService, userID string) *fiber.App {
+	app := fiber.New()
+	handler := NewAPIKeyHandler(service)
+
+	withUser := func(next fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID)
+			return next(c)
+		}
+	}
+
+	app.Post("/api-keys", withUser(handler.CreateAPIKeyHandler))
+	app.Get("/api-keys", withUser(handler.ListAPIKeysHandler))
+	app.Delete("/api-keys/:id", withUser(handler.RevokeAPIKeyHandler))
+
+	return app
+}
+
+func TestCreateAPIKeyHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"name":"ci-runner","scopes":["user:read"]}`,
+			callService:  true,
+			expectStatus: fiber.StatusCreated,
+		},
+		{
+			name:         "unknown scope",
+			body:         `{"name":"ci-runner","scopes":["admin"]}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "missing scopes",
+			body:         `{"name":"ci-runner"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "expiry in the past",
+			body:         `{"name":"ci-runner","scopes":["user:read"],"expiresAt":"2020-01-01T00:00:00Z"}`,
+			serviceError: apikey_errors.ErrInvalidExpiry,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "service error",
+			body:         `{"name":"ci-runner","scopes":["user:read"]}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(apikey_service_mock.MockAPIKeyService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					mockService.On("CreateAPIKey", userID, mock.Anything).Return(nil, tt.serviceError)
+				} else {
+					mockService.On("CreateAPIKey", userID, mock.Anything).Return(&apikey_types.CreateAPIKeyResponse{
+						APIKey: apikey_types.APIKey{Name: "ci", Prefix: "pk_0123456789ab", KeyHash: "hash"},
+						Key:    "pk_0123456789ab_secret",
+					}, nil)
+				}
+			}
+
+			app := setupTestApp(mockService, userID.String())
+
+			req := httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.expectStatus == fiber.StatusCreated {
+				var respBody map[string]interface{}
+				_ = json.NewDecoder(resp.Body).Decode(&respBody)
+				assert.Equal(t, "pk_0123456789ab_secret", respBody["key"])
+				assert.Equal(t, "pk_0123456789ab", respBody["prefix"])
+				assert.NotContains(t, respBody, "KeyHash")
+			}
+
+			if !tt.callService {
+				mockService.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestListAPIKeysHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	mockService := new(apikey_service_mock.MockAPIKeyService)
+	mockService.On("ListAPIKeys", userID).Return([]apikey_types.APIKey{{Name: "ci", Prefix: "pk_1"}}, nil)
+
+	app := setupTestApp(mockService, userID.String())
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api-keys", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var respBody struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&respBody)
+	assert.Len(t, respBody.Data, 1)
+	assert.Equal(t, "pk_1", respBody.Data[0]["prefix"])
+}
+
+func TestRevokeAPIKeyHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	keyID := uuid.New()
+
+	tests := []struct {
+		name         string
+		path         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			path:         "/api-keys/" + keyID.String(),
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "not found",
+			path:         "/api-keys/" + keyID.String(),
+			serviceError: apikey_errors.ErrAPIKeyNotFound,
+			callService:  true,
+			expectStatus: fiber.StatusNotFound,
+		},
+		{
+			name:         "invalid id",
+			path:         "/api-keys/abc",
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(apikey_service_mock.MockAPIKeyService)
+			if tt.callService {
+				mockService.On("RevokeAPIKey", userID, keyID).Return(tt.serviceError)
+			}
+
+			app := setupTestApp(mockService, userID.String())
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodDelete, tt.path, nil))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "RevokeAPIKey", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package apikey_handler
+
+import (
+	apikey_service "github.com/celio001/prodify/internal/apikey/service"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HandlerPath = "/user/api-keys"
+)
+
+func RegisterRouter(router fiber.Router, apiKeyService apikey_service.APIKeyService, authMiddleware fiber.Handler) {
+
+	handler := NewAPIKeyHandler(apiKeyService)
+	router.Post("/", authMiddleware, middleware.RequireSession(), handler.CreateAPIKeyHandler)
+	router.Get("/", authMiddleware, middleware.RequireSession(), handler.ListAPIKeysHandler)
+	router.Delete("/:id", authMiddleware, middleware.RequireSession(), handler.RevokeAPIKeyHandler)
+}
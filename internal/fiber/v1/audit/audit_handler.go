@@ -0,0 +1,216 @@
+package audit_handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_service "github.com/celio001/prodify/internal/audit/service"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type auditHandler struct {
+	auditService audit_service.AuditService
+}
+
+type AuditHandler interface {
+	ListEventsHandler(ctx *fiber.Ctx) error
+	ExportEventsHandler(ctx *fiber.Ctx) error
+}
+
+func NewAuditHandler(auditService audit_service.AuditService) AuditHandler {
+	return &auditHandler{auditService: auditService}
+}
+
+var validate = validator.New()
+
+var csvHeader = []string{
+	"id", "created_at", "action", "actor_id", "impersonator_id", "target_type", "target_id",
+	"ip", "user_agent", "request_id", "changes", "metadata", "prev_hash", "hash",
+}
+
+// @Summary List audit events
+// @Description Paginated audit log for admins, newest first. actor_id also matches the admin behind impersonated requests
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param action query string false "Action, e.g. auth.login.failed"
+// @Param actor_id query string false "User who made the request"
+// @Param target_type query string false "user or product"
+// @Param target_id query string false "ID of the user or product acted on"
+// @Param ip query string false "Client IP"
+// @Param request_id query string false "Request ID, as sent in X-Request-ID"
+// @Param from query string false "At or after, RFC 3339"
+// @Param to query string false "Before, RFC 3339"
+// @Param page query int false "Page, starting at 1"
+// @Param page_size query int false "Page size, at most 100"
+// @Success 200 {object} audit_types.ListEventsResponse "Events loaded successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid filters"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/audit [get]
+func (h *auditHandler) ListEventsHandler(ctx *fiber.Ctx) error {
+	listRequest, invalid := parseListRequest(ctx)
+	if invalid != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": invalid})
+	}
+
+	events, err := h.auditService.ListEvents(listRequest)
+	if err != nil {
+		return auditError(ctx, err, "failed to list audit events")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(events)
+}
+
+// @Summary Export audit events
+// @Description Downloads every event matching the filters, newest first, as CSV (default) or a JSON array.
+// @Description Takes the same filters as the list, without paging. Exports over AUDIT_EXPORT_MAX_ROWS events are refused
+// @Tags admin
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "csv (default) or json"
+// @Param action query string false "Action, e.g. auth.login.failed"
+// @Param actor_id query string false "User who made the request"
+// @Param target_type query string false "user or product"
+// @Param target_id query string false "ID of the user or product acted on"
+// @Param ip query string false "Client IP"
+// @Param request_id query string false "Request ID, as sent in X-Request-ID"
+// @Param from query string false "At or after, RFC 3339"
+// @Param to query string false "Before, RFC 3339"
+// @Success 200 {array} audit_types.Event "Audit events"
+// @Failure 400 {object} map[string]interface{} "Invalid format or filters, or too many events"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/admin/audit/export [get]
+func (h *auditHandler) ExportEventsHandler(ctx *fiber.Ctx) error {
+	format := ctx.Query("format", "csv")
+	if format != "csv" && format != "json" {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_FORMAT"})
+	}
+
+	listRequest, invalid := parseListRequest(ctx)
+	if invalid != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": invalid})
+	}
+	listRequest.Page, listRequest.PageSize = 0, 0
+
+	events, err := h.auditService.ExportEvents(listRequest)
+	if err != nil {
+		return auditError(ctx, err, "failed to export audit events")
+	}
+
+	logger.Log.Info("admin exported audit log",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("query", string(ctx.Request().URI().QueryString())),
+		zap.Int("events", len(events)))
+
+	filename := "prodify-audit-" + time.Now().UTC().Format("20060102T150405Z") + "." + format
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "json" {
+		return ctx.Status(fiber.StatusOK).JSON(events)
+	}
+
+	data, err := eventsCSV(events)
+	if err != nil {
+		logger.Log.Error("failed to write audit export", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"error": "failed to export audit events"})
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return ctx.Status(fiber.StatusOK).Send(data)
+}
+
+// parseListRequest reads the filters, the second value is the error to answer with when
+// they are invalid.
+func parseListRequest(ctx *fiber.Ctx) (audit_types.ListEventsRequest, any) {
+	var listRequest audit_types.ListEventsRequest
+
+	if err := ctx.QueryParser(&listRequest); err != nil {
+		return listRequest, "invalid query parameters"
+	}
+
+	if err := validate.Struct(listRequest); err != nil {
+		return listRequest, audit_errors.ListEventsValidateError(err)
+	}
+
+	return listRequest, nil
+}
+
+func eventsCSV(events []audit_types.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		changes, err := jsonCell(event.Changes)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := jsonCell(event.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		record := []string{
+			event.PublicID, event.CreatedAt.UTC().Format(time.RFC3339Nano), event.Action, event.ActorID,
+			event.ImpersonatorID, event.TargetType, event.TargetID, event.IP, event.UserAgent,
+			event.RequestID, changes, metadata, event.PrevHash, event.Hash,
+		}
+		for i, cell := range record {
+			record[i] = escapeFormula(cell)
+		}
+
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func jsonCell[T any](value map[string]T) (string, error) {
+	if len(value) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// escapeFormula keeps spreadsheets from running cells that come from clients, like the
+// user agent, as formulas.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func auditError(ctx *fiber.Ctx, err error, message string) error {
+	switch err {
+	case audit_errors.ErrInvalidDateRange, audit_errors.ErrExportTooLarge:
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		logger.Log.Error(message, zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,175 @@
+package audit_handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	audit_errors "github.com/celio001/prodify/internal/audit/errors"
+	audit_service_mock "github.com/celio001/prodify/internal/audit/service/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestApp(auditService *audit_service_mock.MockAuditService) *fiber.App {
+	app := fiber.New()
+	handler := NewAuditHandler(auditService)
+
+	app.Get("/audit", handler.ListEventsHandler)
+	app.Get("/audit/export", handler.ExportEventsHandler)
+
+	return app
+}
+
+func TestListEventsHandler(t *testing.T) {
+	logger.Init("dev")
+
+	actorID := uuid.NewString()
+
+	tests := []struct {
+		name         string
+		query        string
+		request      audit_types.ListEventsRequest
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "filters",
+			query:        "?action=auth.login.failed&actor_id=" + actorID + "&from=2026-01-01T00:00:00Z&page=2",
+			request:      audit_types.ListEventsRequest{Action: audit_types.ActionLoginFailed, ActorID: actorID, From: "2026-01-01T00:00:00Z", Page: 2},
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{name: "invalid actor", query: "?actor_id=someone", expectStatus: fiber.StatusBadRequest},
+		{name: "invalid date", query: "?from=yesterday", expectStatus: fiber.StatusBadRequest},
+		{name: "page size too large", query: "?page_size=500", expectStatus: fiber.StatusBadRequest},
+		{
+			name:         "inverted range",
+			query:        "?from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+			request:      audit_types.ListEventsRequest{From: "2026-02-01T00:00:00Z", To: "2026-01-01T00:00:00Z"},
+			serviceError: audit_errors.ErrInvalidDateRange,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{name: "service error", serviceError: errors.New("db error"), callService: true, expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditService := new(audit_service_mock.MockAuditService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					auditService.On("ListEvents", tt.request).Return(nil, tt.serviceError)
+				} else {
+					auditService.On("ListEvents", tt.request).Return(&audit_types.ListEventsResponse{Page: 2, PageSize: 20}, nil)
+				}
+			}
+
+			app := setupTestApp(auditService)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				auditService.AssertExpectations(t)
+			} else {
+				auditService.AssertNotCalled(t, "ListEvents", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestExportEventsHandler(t *testing.T) {
+	logger.Init("dev")
+
+	events := []audit_types.Event{
+		{
+			PublicID:   uuid.NewString(),
+			Action:     audit_types.ActionUserUpdated,
+			ActorID:    uuid.NewString(),
+			TargetType: audit_types.TargetUser,
+			TargetID:   uuid.NewString(),
+			IP:         "10.0.0.1",
+			UserAgent:  "=HYPERLINK(\"http://evil\")",
+			RequestID:  "req-1",
+			Changes:    map[string]audit_types.Change{"name": {Before: "Jane", After: "Janet"}},
+			CreatedAt:  time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	t.Run("csv by default", func(t *testing.T) {
+		auditService := new(audit_service_mock.MockAuditService)
+		auditService.On("ExportEvents", audit_types.ListEventsRequest{Action: audit_types.ActionUserUpdated}).Return(events, nil)
+
+		app := setupTestApp(auditService)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/audit/export?action=user.updated&page=3", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get(fiber.HeaderContentType))
+		assert.True(t, strings.HasPrefix(resp.Header.Get(fiber.HeaderContentDisposition), `attachment; filename="prodify-audit-`))
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, records, 2) {
+			assert.Equal(t, csvHeader, records[0])
+			assert.Equal(t, "2026-10-01T12:00:00Z", records[1][1])
+			assert.Equal(t, "'=HYPERLINK(\"http://evil\")", records[1][8])
+			assert.JSONEq(t, `{"name":{"before":"Jane","after":"Janet"}}`, records[1][10])
+			assert.Equal(t, "", records[1][11])
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		auditService := new(audit_service_mock.MockAuditService)
+		auditService.On("ExportEvents", audit_types.ListEventsRequest{}).Return(events, nil)
+
+		app := setupTestApp(auditService)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/audit/export?format=json", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body []audit_types.Event
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Len(t, body, 1)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		auditService := new(audit_service_mock.MockAuditService)
+		app := setupTestApp(auditService)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/audit/export?format=xlsx", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		auditService.AssertNotCalled(t, "ExportEvents", mock.Anything)
+	})
+
+	t.Run("too many events", func(t *testing.T) {
+		auditService := new(audit_service_mock.MockAuditService)
+		auditService.On("ExportEvents", audit_types.ListEventsRequest{}).Return(nil, audit_errors.ErrExportTooLarge)
+
+		app := setupTestApp(auditService)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/audit/export", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,23 @@
+package audit_handler
+
+import (
+	audit_service "github.com/celio001/prodify/internal/audit/service"
+	auth_service "github.com/celio001/prodify/internal/auth/service"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HandlerPath = "/admin/audit"
+)
+
+func RegisterRouter(router fiber.Router, auditService audit_service.AuditService, authService auth_service.AuthService, authMiddleware fiber.Handler) {
+
+	handler := NewAuditHandler(auditService)
+	session := middleware.RequireSession()
+	admin := middleware.RequireRole(authService, user_types.RoleAdmin)
+
+	router.Get("/", authMiddleware, session, admin, handler.ListEventsHandler)
+	router.Get("/export", authMiddleware, session, admin, handler.ExportEventsHandler)
+}
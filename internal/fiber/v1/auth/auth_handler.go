@@ -1,13 +1,22 @@
 package auth_handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/celio001/prodify/config"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
 	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_oidc "github.com/celio001/prodify/internal/auth/oidc"
 	auth_service "github.com/celio001/prodify/internal/auth/service"
 	auth_types "github.com/celio001/prodify/internal/auth/types"
 	"github.com/celio001/prodify/internal/fiber/middleware"
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
 	"github.com/celio001/prodify/pkg/logger"
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
 	pkg_request "github.com/celio001/prodify/pkg/request"
 	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
 	"github.com/go-playground/validator/v10"
@@ -16,18 +25,41 @@ import (
 )
 
 type authHandler struct {
-	authService auth_service.AuthService
+	authService              auth_service.AuthService
+	providers                *auth_oidc.Registry
+	requireEmailVerification bool
+	secureCookies            bool
 }
 
 type AuthHandler interface {
 	AuthLoginHandler(ctx *fiber.Ctx) error
 	RegisterUserHandler(ctx *fiber.Ctx) error
+	AcceptInvitationHandler(ctx *fiber.Ctx) error
+	PasswordStrengthHandler(ctx *fiber.Ctx) error
 	AuthResetPasswordHandler(ctx *fiber.Ctx) error
+	VerifyEmailHandler(ctx *fiber.Ctx) error
+	ResendVerificationEmailHandler(ctx *fiber.Ctx) error
+	ConfirmEmailChangeHandler(ctx *fiber.Ctx) error
+	ForgotPasswordHandler(ctx *fiber.Ctx) error
+	ConfirmPasswordResetHandler(ctx *fiber.Ctx) error
+	MagicLinkHandler(ctx *fiber.Ctx) error
+	MagicLinkLoginHandler(ctx *fiber.Ctx) error
+	UnlockLoginHandler(ctx *fiber.Ctx) error
+	MFALoginHandler(ctx *fiber.Ctx) error
+	EnrollMFAHandler(ctx *fiber.Ctx) error
+	ConfirmMFAHandler(ctx *fiber.Ctx) error
+	ResetMFAHandler(ctx *fiber.Ctx) error
+	RefreshTokenHandler(ctx *fiber.Ctx) error
+	OAuthStartHandler(ctx *fiber.Ctx) error
+	OAuthCallbackHandler(ctx *fiber.Ctx) error
 }
 
-func NewAuthHandler(authService auth_service.AuthService) *authHandler {
+func NewAuthHandler(authService auth_service.AuthService, providers *auth_oidc.Registry) *authHandler {
 	return &authHandler{
-		authService: authService,
+		authService:              authService,
+		providers:                providers,
+		requireEmailVerification: config.GetBool("AUTH_REQUIRE_EMAIL_VERIFICATION"),
+		secureCookies:            strings.HasPrefix(config.GetString("OIDC_REDIRECT_BASE_URL"), "https://"),
 	}
 }
 
@@ -35,14 +67,17 @@ const maxBodySize = 1 << 20 // 1MB
 var validate = validator.New()
 
 // @Summary User login
-// @Description Authenticates a user using email and password and returns a JWT access token
+// @Description Authenticates a user using email and password and returns a JWT access token.
+// @Description When the user has two-factor authentication enabled it returns mfa_required and a short-lived mfa_token instead, see /v1/auth/login/mfa
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body auth_types.LoginRequest true "Login payload"
-// @Success 200 {object} map[string]string "Access token generated successfully"
+// @Success 200 {object} map[string]interface{} "Access token generated successfully, or MFA code required"
 // @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
 // @Failure 401 {object} map[string]string "Invalid credentials or user not found"
+// @Failure 403 {object} map[string]string "Email address not verified, account deactivated or password reset required"
+// @Failure 429 {object} map[string]string "Too many failed attempts, see the Retry-After header"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /v1/auth/login [post]
 func (h *authHandler) AuthLoginHandler(ctx *fiber.Ctx) error {
@@ -59,13 +94,23 @@ func (h *authHandler) AuthLoginHandler(ctx *fiber.Ctx) error {
 			JSON(fiber.Map{"error": auth_errors.LoginValidateError(err)})
 	}
 
-	user, err := h.authService.Login(loginRequest)
+	user, err := h.authService.Login(loginRequest, clientInfo(ctx))
 	if err != nil {
+		h.auditLogin(ctx, "password", "", loginRequest.Email, err)
+
+		var lockoutErr *auth_errors.LockoutError
+		if errors.As(err, &lockoutErr) {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+			return ctx.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
 		switch err {
 		case auth_errors.ErrMatchDataUser:
 			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		case user_errors.ErrUserNotFound:
-			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": auth_errors.ErrMatchDataUser.Error()})
+		case auth_errors.ErrEmailNotVerified, auth_errors.ErrUserInactive, auth_errors.ErrPasswordReset:
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
 		default:
 			logger.Log.Error("failed to login user", zap.String("error", err.Error()))
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to login user"})
@@ -73,23 +118,7 @@ func (h *authHandler) AuthLoginHandler(ctx *fiber.Ctx) error {
 
 	}
 
-	token, err := pkg_jwt.CreateAccessToken(user.PublicID)
-	if err != nil {
-		logger.Log.Error("failed to create access token", zap.String("error", err.Error()))
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create access token"})
-	}
-
-	refresh_token, err := pkg_jwt.CreateRefreshToken(user.PublicID)
-	if err != nil {
-		logger.Log.Error("failed to create refresh token", zap.String("error", err.Error()))
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create refresh token"})
-	}
-
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-		"access_token":  token,
-		"refresh_token": refresh_token,
-		"token_type":    "Bearer",
-	})
+	return h.completeLogin(ctx, "password", user.PublicID, user.MFAEnabled)
 }
 
 // @Summary Register a new user
@@ -99,7 +128,9 @@ func (h *authHandler) AuthLoginHandler(ctx *fiber.Ctx) error {
 // @Produce json
 // @Param request body auth_types.CreateUserRequest true "Create user payload"
 // @Success 200 {object} map[string]string "User registered successfully with access token"
-// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or user creation failure"
+// @Success 201 {object} map[string]string "User registered, email verification required before login"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, password policy violation or user creation failure"
+// @Failure 403 {object} map[string]string "Registration is by invitation only"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /v1/auth/register [post]
 func (h *authHandler) RegisterUserHandler(ctx *fiber.Ctx) error {
@@ -118,46 +149,136 @@ func (h *authHandler) RegisterUserHandler(ctx *fiber.Ctx) error {
 
 	user, err := h.authService.RegisterUser(createUserRequest)
 	if err != nil {
+		var passwordErr *password_errors.PasswordError
+		if errors.As(err, &passwordErr) {
+			return ctx.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": auth_errors.PasswordPolicyError("Password", passwordErr)})
+		}
+
 		switch err {
 		case user_errors.ErrUserCreationFailed:
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		case auth_errors.ErrUserAlreadyExists:
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case auth_errors.ErrRegistrationDisabled:
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
 		default:
 			logger.Log.Error("failed to register user", zap.String("error", err.Error()))
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to register user"})
 		}
 	}
 
-	token, err := pkg_jwt.CreateAccessToken(user.PublicID)
+	auditRegistration(ctx, "self", user.PublicID)
+
+	if h.requireEmailVerification {
+		return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"message": "user registered, check your email to verify your account",
+		})
+	}
+
+	return h.respondWithTokens(ctx, user.PublicID)
+}
+
+// @Summary Accept an invitation
+// @Description Creates the invited account with the chosen name and password and signs it in. The email and role come from the invitation,
+// @Description the password is checked like a registration. Works while self-registration is disabled, each link works once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.AcceptInvitationRequest true "Accept invitation payload"
+// @Success 200 {object} auth_types.TokenPair "Account created, access token generated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, password policy violation, or invalid, expired or used invitation"
+// @Failure 409 {object} map[string]string "The email already has an account"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/invitations/accept [post]
+func (h *authHandler) AcceptInvitationHandler(ctx *fiber.Ctx) error {
+	var acceptRequest auth_types.AcceptInvitationRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &acceptRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(acceptRequest); err != nil {
+		logger.Log.Error("invalid accept invitation payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.AcceptInvitationValidateError(err)})
+	}
+
+	user, err := h.authService.AcceptInvitation(acceptRequest)
 	if err != nil {
-		logger.Log.Error("failed to create access token", zap.String("error", err.Error()))
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create access token"})
+		var passwordErr *password_errors.PasswordError
+		if errors.As(err, &passwordErr) {
+			return ctx.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": auth_errors.PasswordPolicyError("Password", passwordErr)})
+		}
+
+		switch err {
+		case auth_errors.ErrInvalidInvitation:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case auth_errors.ErrUserAlreadyExists:
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to accept invitation", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create account"})
+		}
 	}
 
-	refresh_token, err := pkg_jwt.CreateRefreshToken(user.PublicID)
+	auditRegistration(ctx, "invitation", user.PublicID)
+
+	return h.respondWithTokens(ctx, user.PublicID)
+}
+
+// @Summary Check password strength
+// @Description Checks a password against the registration policy without creating anything, so the signup form can show the server's verdict.
+// @Description Name and email are optional and lower the score of passwords built from them. The password is never logged
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.PasswordStrengthRequest true "Password strength payload"
+// @Success 200 {object} auth_types.PasswordStrengthResponse "Policy result, entropy, score and hints"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 429 {object} map[string]string "Too many checks from this IP, see the Retry-After header"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/password-strength [post]
+func (h *authHandler) PasswordStrengthHandler(ctx *fiber.Ctx) error {
+	var passwordStrengthRequest auth_types.PasswordStrengthRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &passwordStrengthRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(passwordStrengthRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.PasswordStrengthValidateError(err)})
+	}
+
+	response, err := h.authService.CheckPasswordStrength(passwordStrengthRequest, clientInfo(ctx))
 	if err != nil {
-		logger.Log.Error("failed to create refresh token", zap.String("error", err.Error()))
-		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create refresh token"})
+		var lockoutErr *auth_errors.LockoutError
+		if errors.As(err, &lockoutErr) {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+			return ctx.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		logger.Log.Error("failed to check password strength", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check password strength"})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
-		"access_token":  token,
-		"refresh_token": refresh_token,
-		"token_type":    "Bearer",
-	})
+	return ctx.Status(fiber.StatusOK).JSON(response)
 }
 
 // @Summary Reset user password
-// @Description Allows an authenticated user to reset their password
+// @Description Allows an authenticated user to change their password. The current password is required and every session, including the current one, is signed out
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body auth_types.ResetPasswordRequest true "Reset password payload"
 // @Success 200 {object} map[string]string "Password reset successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error or invalid user ID"
-// @Failure 401 {object} map[string]string "User not authenticated or business rule violation"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, invalid user ID, weak or recently used password"
+// @Failure 401 {object} map[string]string "User not authenticated, wrong current password or business rule violation"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /v1/auth/reset-password [patch]
 func (h *authHandler) AuthResetPasswordHandler(ctx *fiber.Ctx) error {
@@ -188,6 +309,12 @@ func (h *authHandler) AuthResetPasswordHandler(ctx *fiber.Ctx) error {
 	}
 
 	if err := h.authService.ResetPassword(userIDParsed, resetPasswordRequest); err != nil {
+		var passwordErr *password_errors.PasswordError
+		if errors.As(err, &passwordErr) {
+			return ctx.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": auth_errors.PasswordPolicyError("NewPassword", passwordErr)})
+		}
+
 		switch err {
 		case user_errors.ErrSamePassword:
 			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
@@ -197,11 +324,515 @@ func (h *authHandler) AuthResetPasswordHandler(ctx *fiber.Ctx) error {
 			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		case auth_errors.ErrMatchDataUser:
 			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		case auth_errors.ErrInvalidCurrentPassword:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrPasswordReused:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		default:
 			logger.Log.Error("failed to reset password", zap.String("error", err.Error()))
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to reset password"})
 		}
 	}
 
+	middleware.RecordAudit(ctx, audit_types.Event{
+		Action:     audit_types.ActionPasswordChanged,
+		TargetType: audit_types.TargetUser,
+		TargetID:   userIDParsed.String(),
+	})
+
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "password reset successfully"})
 }
+
+// @Summary Verify email address
+// @Description Confirms the user email address using the token sent by email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.VerifyEmailRequest true "Verify email payload"
+// @Success 200 {object} map[string]string "Email verified successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error or invalid token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/verify-email [post]
+func (h *authHandler) VerifyEmailHandler(ctx *fiber.Ctx) error {
+	var verifyEmailRequest auth_types.VerifyEmailRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &verifyEmailRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(verifyEmailRequest); err != nil {
+		logger.Log.Error("invalid verify email payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.VerifyEmailValidateError(err)})
+	}
+
+	if err := h.authService.VerifyEmail(verifyEmailRequest.Token); err != nil {
+		switch err {
+		case auth_errors.ErrInvalidToken:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to verify email", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify email"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "email verified successfully"})
+}
+
+// @Summary Confirm email change
+// @Description Replaces the account email with the pending address, using the token sent to that address
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.ConfirmEmailChangeRequest true "Confirm email change payload"
+// @Success 200 {object} map[string]string "Email changed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error or invalid token"
+// @Failure 409 {object} map[string]string "Email is already in use by another account"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/email-change/confirm [post]
+func (h *authHandler) ConfirmEmailChangeHandler(ctx *fiber.Ctx) error {
+	var confirmRequest auth_types.ConfirmEmailChangeRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &confirmRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(confirmRequest); err != nil {
+		logger.Log.Error("invalid confirm email change payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.VerifyEmailValidateError(err)})
+	}
+
+	if err := h.authService.ConfirmEmailChange(confirmRequest.Token); err != nil {
+		switch err {
+		case auth_errors.ErrInvalidToken, user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": auth_errors.ErrInvalidToken.Error()})
+		case user_errors.ErrEmailTaken:
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to confirm email change", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to confirm email change"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "email changed successfully"})
+}
+
+// @Summary Resend verification email
+// @Description Sends a new verification link. The response is the same whether or not the email is registered
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.ResendVerificationRequest true "Resend verification payload"
+// @Success 202 {object} map[string]string "Verification email sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/verify-email/resend [post]
+func (h *authHandler) ResendVerificationEmailHandler(ctx *fiber.Ctx) error {
+	var resendRequest auth_types.ResendVerificationRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &resendRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(resendRequest); err != nil {
+		logger.Log.Error("invalid resend verification payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.ResendVerificationValidateError(err)})
+	}
+
+	if err := h.authService.ResendVerificationEmail(resendRequest.Email); err != nil {
+		logger.Log.Error("failed to resend verification email", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to resend verification email"})
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "if the account exists and is not verified, a new verification email was sent",
+	})
+}
+
+// @Summary Request a password reset
+// @Description Emails a single-use password reset link. The response is the same whether or not the email is registered
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.ForgotPasswordRequest true "Forgot password payload"
+// @Success 202 {object} map[string]string "Reset email sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 429 {object} map[string]string "Too many reset requests for this email or IP, see Retry-After"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/forgot-password [post]
+func (h *authHandler) ForgotPasswordHandler(ctx *fiber.Ctx) error {
+	var forgotPasswordRequest auth_types.ForgotPasswordRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &forgotPasswordRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(forgotPasswordRequest); err != nil {
+		logger.Log.Error("invalid forgot password payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.ForgotPasswordValidateError(err)})
+	}
+
+	if err := h.authService.RequestPasswordReset(forgotPasswordRequest.Email, clientInfo(ctx)); err != nil {
+		var lockoutErr *auth_errors.LockoutError
+		if errors.As(err, &lockoutErr) {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+			return ctx.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		logger.Log.Error("failed to request password reset", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to request password reset"})
+	}
+
+	event := audit_types.Event{Action: audit_types.ActionPasswordResetRequested}
+	auditEmailDigest(&event, forgotPasswordRequest.Email)
+	middleware.RecordAudit(ctx, event)
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "if the account exists, a password reset email was sent",
+	})
+}
+
+// @Summary Confirm a password reset
+// @Description Sets a new password using the token received by email and signs out every existing session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.ConfirmPasswordResetRequest true "Confirm password reset payload"
+// @Success 200 {object} map[string]string "Password reset successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, invalid token or same password"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/reset-password/confirm [post]
+func (h *authHandler) ConfirmPasswordResetHandler(ctx *fiber.Ctx) error {
+	var confirmRequest auth_types.ConfirmPasswordResetRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &confirmRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(confirmRequest); err != nil {
+		logger.Log.Error("invalid confirm password reset payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.ConfirmPasswordResetValidateError(err)})
+	}
+
+	userPublicID, err := h.authService.ConfirmPasswordReset(confirmRequest)
+	if err != nil {
+		var passwordErr *password_errors.PasswordError
+		if errors.As(err, &passwordErr) {
+			return ctx.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": auth_errors.PasswordPolicyError("NewPassword", passwordErr)})
+		}
+
+		switch err {
+		case auth_errors.ErrInvalidToken:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": auth_errors.ErrInvalidToken.Error()})
+		case user_errors.ErrSamePassword:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrPasswordReused:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to confirm password reset", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to reset password"})
+		}
+	}
+
+	middleware.RecordAudit(ctx, audit_types.Event{
+		Action:     audit_types.ActionPasswordResetCompleted,
+		ActorID:    userPublicID,
+		TargetType: audit_types.TargetUser,
+		TargetID:   userPublicID,
+	})
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "password reset successfully"})
+}
+
+// @Summary Request a sign-in link
+// @Description Emails a single-use sign-in link valid for 10 minutes. The response is the same whether or not the email is registered
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.MagicLinkRequest true "Magic link payload"
+// @Success 202 {object} map[string]string "Sign-in link sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 429 {object} map[string]string "Too many links requested for this email, see the Retry-After header"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/magic-link [post]
+func (h *authHandler) MagicLinkHandler(ctx *fiber.Ctx) error {
+	var magicLinkRequest auth_types.MagicLinkRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &magicLinkRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(magicLinkRequest); err != nil {
+		logger.Log.Error("invalid magic link payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.MagicLinkValidateError(err)})
+	}
+
+	if err := h.authService.RequestMagicLink(magicLinkRequest.Email); err != nil {
+		var lockoutErr *auth_errors.LockoutError
+		if errors.As(err, &lockoutErr) {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+			return ctx.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		logger.Log.Error("failed to request magic link", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to send sign-in link"})
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "if the account exists, a sign-in link was sent",
+	})
+}
+
+// @Summary Sign in with a magic link
+// @Description Exchanges the token from a sign-in link for an access and refresh token pair. Each link works once.
+// @Description When the user has two-factor authentication enabled it returns mfa_required and a short-lived mfa_token instead, see /v1/auth/login/mfa
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.MagicLinkLoginRequest true "Magic link login payload"
+// @Success 200 {object} map[string]interface{} "Access token generated successfully, or MFA code required"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "Invalid, expired or already used link"
+// @Failure 403 {object} map[string]string "Account deactivated"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/magic-link/login [post]
+func (h *authHandler) MagicLinkLoginHandler(ctx *fiber.Ctx) error {
+	var loginRequest auth_types.MagicLinkLoginRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &loginRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(loginRequest); err != nil {
+		logger.Log.Error("invalid magic link login payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.MagicLinkLoginValidateError(err)})
+	}
+
+	user, err := h.authService.LoginWithMagicLink(loginRequest.Token)
+	if err != nil {
+		h.auditLogin(ctx, "magic_link", "", "", err)
+
+		switch err {
+		case auth_errors.ErrInvalidToken:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": auth_errors.ErrInvalidToken.Error()})
+		case auth_errors.ErrUserInactive, auth_errors.ErrPasswordReset:
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to login with magic link", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to login user"})
+		}
+	}
+
+	return h.completeLogin(ctx, "magic_link", user.PublicID, user.MFAEnabled)
+}
+
+// @Summary Unlock a login
+// @Description Clears failed login attempts and any lockout for an account and/or client IP. Requires the admin role
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body auth_types.UnlockLoginRequest true "Unlock login payload"
+// @Success 200 {object} map[string]string "Login unlocked"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/unlock [post]
+func (h *authHandler) UnlockLoginHandler(ctx *fiber.Ctx) error {
+	var unlockRequest auth_types.UnlockLoginRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &unlockRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(unlockRequest); err != nil {
+		logger.Log.Error("invalid unlock login payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.UnlockLoginValidateError(err)})
+	}
+
+	if err := h.authService.UnlockLogin(unlockRequest); err != nil {
+		logger.Log.Error("failed to unlock login", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to unlock login"})
+	}
+
+	event := audit_types.Event{Action: audit_types.ActionLoginUnlocked}
+	if unlockRequest.IP != "" {
+		event.Metadata = map[string]string{"ip": unlockRequest.IP}
+	}
+	if unlockRequest.Email != "" {
+		h.auditAccount(ctx, &event, unlockRequest.Email)
+	}
+	middleware.RecordAudit(ctx, event)
+
+	logger.Log.Info("login unlocked",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("ip", unlockRequest.IP))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "login unlocked"})
+}
+
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token for a new access and refresh token pair. Each refresh token works once;
+// @Description presenting one that was already used signs the whole session out
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.RefreshTokenRequest true "Refresh token payload"
+// @Success 200 {object} auth_types.TokenPair "Tokens refreshed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "Invalid, expired, reused or revoked refresh token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/refresh [post]
+func (h *authHandler) RefreshTokenHandler(ctx *fiber.Ctx) error {
+	var refreshRequest auth_types.RefreshTokenRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &refreshRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(refreshRequest); err != nil {
+		logger.Log.Error("invalid refresh token payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.RefreshTokenValidateError(err)})
+	}
+
+	tokens, err := h.authService.RefreshTokens(refreshRequest.RefreshToken, clientInfo(ctx))
+	if err != nil {
+		switch err {
+		case auth_errors.ErrInvalidRefreshToken:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to refresh tokens", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to refresh tokens"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(tokens)
+}
+
+// completeLogin answers a successful first factor: users with MFA get a short-lived
+// mfa_token to exchange at /v1/auth/login/mfa, everyone else gets their tokens and the
+// login is audited.
+func (h *authHandler) completeLogin(ctx *fiber.Ctx, method, userPublicID string, mfaEnabled bool) error {
+	if mfaEnabled {
+		mfaToken, err := pkg_jwt.CreateMFAPendingToken(userPublicID)
+		if err != nil {
+			logger.Log.Error("failed to create mfa token", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to login user"})
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+	}
+
+	h.auditLogin(ctx, method, userPublicID, "", nil)
+	return h.respondWithTokens(ctx, userPublicID)
+}
+
+// respondWithTokens opens a session for the user and answers with its first token pair.
+func (h *authHandler) respondWithTokens(ctx *fiber.Ctx, userPublicID string) error {
+	tokens, err := h.authService.IssueTokens(userPublicID, clientInfo(ctx))
+	if err != nil {
+		logger.Log.Error("failed to create session", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create access token"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(tokens)
+}
+
+// auditLogin records a sign-in attempt made with method, err is nil when it succeeded.
+// A failure that only knows the email keeps the keyed hash of it, see auditEmailDigest.
+func (h *authHandler) auditLogin(ctx *fiber.Ctx, method, userPublicID, email string, err error) {
+	event := audit_types.Event{
+		Action:     audit_types.ActionLoginSucceeded,
+		ActorID:    userPublicID,
+		TargetType: audit_types.TargetUser,
+		TargetID:   userPublicID,
+		Metadata:   map[string]string{"method": method},
+	}
+	if err != nil {
+		event.Action = audit_types.ActionLoginFailed
+		event.Metadata["reason"] = err.Error()
+	}
+	if userPublicID == "" {
+		event.TargetType = ""
+	}
+	if email != "" {
+		auditEmailDigest(&event, email)
+	}
+
+	middleware.RecordAudit(ctx, event)
+}
+
+// auditEmailDigest keeps the keyed hash of email on event, so the address itself never
+// reaches the audit log. Unauthenticated endpoints use it without looking the account
+// up, anyone could otherwise make them query the users table on every request.
+func auditEmailDigest(event *audit_types.Event, email string) {
+	if event.Metadata == nil {
+		event.Metadata = map[string]string{}
+	}
+	event.Metadata["email_hmac"] = middleware.EmailDigest(email)
+}
+
+// auditAccount points event at the account registered with email, or falls back to
+// auditEmailDigest when there is none. Only for admin endpoints, see auditEmailDigest.
+func (h *authHandler) auditAccount(ctx *fiber.Ctx, event *audit_types.Event, email string) {
+	if !middleware.Auditing(ctx) {
+		return
+	}
+
+	publicID, err := h.authService.LookupAccount(email)
+	if err != nil {
+		logger.Log.Error("failed to look up audited account", zap.String("error", err.Error()))
+	}
+
+	if publicID != "" {
+		event.TargetType = audit_types.TargetUser
+		event.TargetID = publicID
+		return
+	}
+
+	auditEmailDigest(event, email)
+}
+
+// auditRegistration records a new account, method is how it was created.
+func auditRegistration(ctx *fiber.Ctx, method, userPublicID string) {
+	middleware.RecordAudit(ctx, audit_types.Event{
+		Action:     audit_types.ActionRegistered,
+		ActorID:    userPublicID,
+		TargetType: audit_types.TargetUser,
+		TargetID:   userPublicID,
+		Metadata:   map[string]string{"method": method},
+	})
+}
+
+func clientInfo(ctx *fiber.Ctx) auth_types.ClientInfo {
+	return auth_types.ClientInfo{
+		IP:        ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+}
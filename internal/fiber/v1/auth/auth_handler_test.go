@@ -1,24 +1,33 @@
 package auth_handler
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	audit_service_mock "github.com/celio001/prodify/internal/audit/service/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
 	auth_errors "github.com/celio001/prodify/internal/auth/errors"
 	auth_service "github.com/celio001/prodify/internal/auth/service"
 	auth_mock "github.com/celio001/prodify/internal/auth/service/mock"
 	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_types "github.com/celio001/prodify/internal/user/type"
 	"github.com/celio001/prodify/pkg/logger"
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func setupTestApp(service *auth_mock.MockAuthService) *fiber.App {
@@ -57,11 +66,15 @@ func TestAuthLoginHandler_Success(t *testing.T) {
 	userID := uuid.New().String()
 
 	mockService.
-		On("Login", mock.Anything).
+		On("Login", mock.Anything, mock.Anything).
 		Return(user_types.GetUserResponse{
 			PublicID: userID,
 		}, nil)
 
+	mockService.
+		On("IssueTokens", userID, auth_types.ClientInfo{IP: "0.0.0.0"}).
+		Return(&auth_types.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil)
+
 	app := setupTestApp(mockService)
 
 	body := `{
@@ -109,7 +122,7 @@ func TestAuthLoginHandler_UserNotFound(t *testing.T) {
 	mockService := new(auth_mock.MockAuthService)
 
 	mockService.
-		On("Login", mock.Anything).
+		On("Login", mock.Anything, mock.Anything).
 		Return(user_types.GetUserResponse{}, user_errors.ErrUserNotFound)
 
 	app := setupTestApp(mockService)
@@ -137,7 +150,7 @@ func TestAuthLoginHandler_InvalidPassword(t *testing.T) {
 	mockService := new(auth_mock.MockAuthService)
 
 	mockService.
-		On("Login", mock.Anything).
+		On("Login", mock.Anything, mock.Anything).
 		Return(user_types.GetUserResponse{}, auth_errors.ErrMatchDataUser)
 
 	app := setupTestApp(mockService)
@@ -165,7 +178,7 @@ func TestAuthLoginHandler_InternalError(t *testing.T) {
 	mockService := new(auth_mock.MockAuthService)
 
 	mockService.
-		On("Login", mock.Anything).
+		On("Login", mock.Anything, mock.Anything).
 		Return(user_types.GetUserResponse{}, errors.New("db error"))
 
 	app := setupTestApp(mockService)
@@ -199,7 +212,7 @@ func TestAuthResetPasswordHandler_Success(t *testing.T) {
 	app := setupTestAppWithUser(mockService, userID)
 
 	body := `{
-		"old_password_hash":"123456",
+		"current_password":"123456",
 		"new_password":"654321"
 	}`
 
@@ -230,7 +243,7 @@ func TestAuthResetPasswordHandler_UserNotAuthenticated(t *testing.T) {
 	})
 
 	body := `{
-		"old_password_hash":"123456",
+		"current_password":"123456",
 		"new_password":"654321"
 	}`
 
@@ -297,7 +310,7 @@ func TestAuthResetPasswordHandler_ValidationError(t *testing.T) {
 	app := setupTestAppWithUser(mockService, userID)
 
 	body := `{
-		"old_password_hash":"123456"
+		"current_password":"123456"
 	}`
 
 	req := httptest.NewRequest(http.MethodPatch, "/reset-password", strings.NewReader(body))
@@ -324,7 +337,7 @@ func TestAuthResetPasswordHandler_SamePassword(t *testing.T) {
 	app := setupTestAppWithUser(mockService, userID)
 
 	body := `{
-		"old_password_hash":"123456",
+		"current_password":"123456",
 		"new_password":"123456"
 	}`
 
@@ -352,7 +365,7 @@ func TestAuthResetPasswordHandler_UserNotFound(t *testing.T) {
 	app := setupTestAppWithUser(mockService, userID)
 
 	body := `{
-		"old_password_hash":"123456",
+		"current_password":"123456",
 		"new_password":"654321"
 	}`
 
@@ -375,12 +388,12 @@ func TestAuthResetPasswordHandler_InvalidOldPassword(t *testing.T) {
 
 	mockService.
 		On("ResetPassword", mock.Anything, mock.Anything).
-		Return(auth_errors.ErrMatchDataUser)
+		Return(auth_errors.ErrInvalidCurrentPassword)
 
 	app := setupTestAppWithUser(mockService, userID)
 
 	body := `{
-		"old_password_hash":"wrong-password",
+		"current_password":"wrong-password",
 		"new_password":"654321"
 	}`
 
@@ -408,7 +421,7 @@ func TestAuthResetPasswordHandler_InternalError(t *testing.T) {
 	app := setupTestAppWithUser(mockService, userID)
 
 	body := `{
-		"old_password_hash":"123456",
+		"current_password":"123456",
 		"new_password":"654321"
 	}`
 
@@ -442,6 +455,10 @@ func TestRegisterUserHandler_Success(t *testing.T) {
 			PublicID: userID.String(),
 		}, nil)
 
+	mockService.
+		On("IssueTokens", userID.String(), mock.Anything).
+		Return(&auth_types.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil)
+
 	app := fiber.New()
 	handler := &authHandler{authService: mockService}
 
@@ -541,4 +558,1151 @@ func TestRegisterUserHandler_UserCreationFailed(t *testing.T) {
 	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 
 	mockService.AssertExpectations(t)
-}
\ No newline at end of file
+}
+func TestRegisterUserHandler_EmailVerificationRequired(t *testing.T) {
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+
+	mockService.
+		On("RegisterUser", mock.Anything).
+		Return(&auth_types.CreateUserResponse{
+			PublicID: uuid.New().String(),
+		}, nil)
+
+	app := fiber.New()
+	handler := &authHandler{authService: mockService, requireEmailVerification: true}
+
+	app.Post("/register", handler.RegisterUserHandler)
+
+	body := `{
+		"name":"Célio",
+		"email":"celio@email.com",
+		"password":"123456"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthLoginHandler_EmailNotVerified(t *testing.T) {
+
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+
+	mockService.
+		On("Login", mock.Anything, mock.Anything).
+		Return(user_types.GetUserResponse{}, auth_errors.ErrEmailNotVerified)
+
+	app := setupTestApp(mockService)
+
+	body := `{
+		"email":"test@mail.com",
+		"password":"123456"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthLoginHandler_TooManyAttempts(t *testing.T) {
+
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+
+	mockService.
+		On("Login", mock.Anything, mock.Anything).
+		Return(user_types.GetUserResponse{}, &auth_errors.LockoutError{RetryAfter: 1500 * time.Millisecond})
+
+	app := setupTestApp(mockService)
+
+	body := `{
+		"email":"test@mail.com",
+		"password":"123456"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthLoginHandler_UserNotFoundIsGeneric(t *testing.T) {
+
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+
+	mockService.
+		On("Login", mock.Anything, mock.Anything).
+		Return(user_types.GetUserResponse{}, user_errors.ErrUserNotFound)
+
+	app := setupTestApp(mockService)
+
+	body := `{
+		"email":"test@mail.com",
+		"password":"123456"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	var respBody map[string]string
+	_ = json.NewDecoder(resp.Body).Decode(&respBody)
+
+	assert.Equal(t, auth_errors.ErrMatchDataUser.Error(), respBody["error"])
+}
+
+func TestUnlockLoginHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "unlock account",
+			body:         `{"email":"test@mail.com"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "unlock ip",
+			body:         `{"ip":"10.0.0.1"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "missing email and ip",
+			body:         `{}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "invalid ip",
+			body:         `{"ip":"not-an-ip"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "store error",
+			body:         `{"email":"test@mail.com"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("UnlockLogin", mock.Anything).Return(tt.serviceError)
+			}
+
+			handler := &authHandler{authService: mockService}
+			app := fiber.New()
+			app.Post("/unlock", handler.UnlockLoginHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/unlock", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "UnlockLogin", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUnlockLoginHandler_Audited(t *testing.T) {
+	logger.Init("dev")
+
+	adminID := uuid.New().String()
+	userID := uuid.New().String()
+
+	mockService := new(auth_mock.MockAuthService)
+	mockService.On("UnlockLogin", mock.Anything).Return(nil)
+	mockService.On("LookupAccount", "test@mail.com").Return(userID, nil)
+
+	recorder := new(audit_service_mock.MockRecorder)
+	app := fiber.New()
+	handler := &authHandler{authService: mockService}
+	app.Post("/unlock", middleware.Audit(recorder), func(ctx *fiber.Ctx) error {
+		ctx.Locals(middleware.UserIDKey, adminID)
+		return handler.UnlockLoginHandler(ctx)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/unlock", strings.NewReader(`{"email":"test@mail.com","ip":"10.0.0.1"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	if assert.Len(t, recorder.Events, 1) {
+		event := recorder.Events[0]
+		assert.Equal(t, audit_types.ActionLoginUnlocked, event.Action)
+		assert.Equal(t, adminID, event.ActorID)
+		assert.Equal(t, audit_types.TargetUser, event.TargetType)
+		assert.Equal(t, userID, event.TargetID)
+		assert.Equal(t, "10.0.0.1", event.Metadata["ip"])
+		assert.NotContains(t, fmt.Sprint(event), "test@mail.com")
+	}
+}
+
+func TestVerifyEmailHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"token":"abc"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "missing token",
+			body:         `{}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "invalid token",
+			body:         `{"token":"abc"}`,
+			serviceError: auth_errors.ErrInvalidToken,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "internal error",
+			body:         `{"token":"abc"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("VerifyEmail", "abc").Return(tt.serviceError)
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/verify-email", handler.VerifyEmailHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/verify-email", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfirmEmailChangeHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"token":"abc"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "missing token",
+			body:         `{}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "invalid token",
+			body:         `{"token":"abc"}`,
+			serviceError: auth_errors.ErrInvalidToken,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "email taken",
+			body:         `{"token":"abc"}`,
+			serviceError: user_errors.ErrEmailTaken,
+			callService:  true,
+			expectStatus: fiber.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("ConfirmEmailChange", "abc").Return(tt.serviceError)
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/email-change/confirm", handler.ConfirmEmailChangeHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/email-change/confirm", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResendVerificationEmailHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "accepted",
+			body:         `{"email":"test@mail.com"}`,
+			callService:  true,
+			expectStatus: fiber.StatusAccepted,
+		},
+		{
+			name:         "invalid email",
+			body:         `{"email":"invalid"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "internal error",
+			body:         `{"email":"test@mail.com"}`,
+			serviceError: errors.New("smtp down"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("ResendVerificationEmail", "test@mail.com").Return(tt.serviceError)
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/verify-email/resend", handler.ResendVerificationEmailHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/verify-email/resend", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestForgotPasswordHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name             string
+		body             string
+		serviceError     error
+		callService      bool
+		expectStatus     int
+		expectRetryAfter string
+	}{
+		{
+			name:         "always accepted",
+			body:         `{"email":"test@mail.com"}`,
+			callService:  true,
+			expectStatus: fiber.StatusAccepted,
+		},
+		{
+			name:             "rate limited",
+			body:             `{"email":"test@mail.com"}`,
+			serviceError:     &auth_errors.LockoutError{RetryAfter: 90 * time.Second},
+			callService:      true,
+			expectStatus:     fiber.StatusTooManyRequests,
+			expectRetryAfter: "90",
+		},
+		{
+			name:         "invalid email",
+			body:         `{"email":"invalid"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("RequestPasswordReset", "test@mail.com", mock.AnythingOfType("auth_types.ClientInfo")).Return(tt.serviceError)
+			}
+
+			recorder := new(audit_service_mock.MockRecorder)
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/forgot-password", middleware.Audit(recorder), handler.ForgotPasswordHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/forgot-password", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectRetryAfter, resp.Header.Get(fiber.HeaderRetryAfter))
+
+			if tt.expectStatus == fiber.StatusAccepted && assert.Len(t, recorder.Events, 1) {
+				event := recorder.Events[0]
+				assert.Equal(t, audit_types.ActionPasswordResetRequested, event.Action)
+				assert.Empty(t, event.TargetID)
+				assert.Equal(t, middleware.EmailDigest("test@mail.com"), event.Metadata["email_hmac"])
+			}
+
+			mockService.AssertExpectations(t)
+			mockService.AssertNotCalled(t, "LookupAccount", mock.Anything)
+		})
+	}
+}
+
+func TestConfirmPasswordResetHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"token":"abc","new_password":"654321"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "missing new password",
+			body:         `{"token":"abc"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "invalid token",
+			body:         `{"token":"abc","new_password":"654321"}`,
+			serviceError: auth_errors.ErrInvalidToken,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "same password",
+			body:         `{"token":"abc","new_password":"654321"}`,
+			serviceError: user_errors.ErrSamePassword,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "internal error",
+			body:         `{"token":"abc","new_password":"654321"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.
+					On("ConfirmPasswordReset", auth_types.ConfirmPasswordResetRequest{Token: "abc", NewPassword: "654321"}).
+					Return("", tt.serviceError)
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/reset-password/confirm", handler.ConfirmPasswordResetHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/reset-password/confirm", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthResetPasswordHandler_PolicyViolations(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		serviceError error
+	}{
+		{
+			name: "weak password",
+			serviceError: &password_errors.PasswordError{
+				BaseErr: password_errors.ErrPasswordValidator,
+				Reasons: []error{password_errors.ErrLowEntropy},
+			},
+		},
+		{
+			name:         "recently used password",
+			serviceError: user_errors.ErrPasswordReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+
+			mockService.
+				On("ResetPassword", mock.Anything, mock.Anything).
+				Return(tt.serviceError)
+
+			app := setupTestAppWithUser(mockService, uuid.New().String())
+
+			body := `{
+		"current_password":"123456",
+		"new_password":"654321"
+	}`
+
+			req := httptest.NewRequest(http.MethodPatch, "/reset-password", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRegisterUserHandler_WeakPassword(t *testing.T) {
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+
+	mockService.
+		On("RegisterUser", mock.Anything).
+		Return(&auth_types.CreateUserResponse{}, &password_errors.PasswordError{
+			BaseErr: password_errors.ErrPasswordValidator,
+			Reasons: []error{password_errors.ErrNoUppercase, password_errors.ErrLowEntropy},
+		})
+
+	app := fiber.New()
+	handler := &authHandler{authService: mockService}
+
+	app.Post("/register", handler.RegisterUserHandler)
+
+	body := `{
+		"name":"Célio",
+		"email":"celio@email.com",
+		"password":"123456"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var payload struct {
+		Error map[string][]string `json:"error"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+	assert.Equal(t, []string{"missing uppercase letters", "password entropy too low"}, payload.Error["Password"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRefreshTokenHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"refresh_token":"refresh"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "missing token",
+			body:         `{}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "reused or revoked token",
+			body:         `{"refresh_token":"refresh"}`,
+			serviceError: auth_errors.ErrInvalidRefreshToken,
+			callService:  true,
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "service error",
+			body:         `{"refresh_token":"refresh"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					mockService.On("RefreshTokens", "refresh", mock.Anything).Return(nil, tt.serviceError)
+				} else {
+					mockService.On("RefreshTokens", "refresh", mock.Anything).
+						Return(&auth_types.TokenPair{AccessToken: "new-access", RefreshToken: "new-refresh", TokenType: "Bearer"}, nil)
+				}
+			}
+
+			handler := &authHandler{authService: mockService}
+			app := fiber.New()
+			app.Post("/refresh", handler.RefreshTokenHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/refresh", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.expectStatus == fiber.StatusOK {
+				var respBody map[string]string
+				_ = json.NewDecoder(resp.Body).Decode(&respBody)
+				assert.Equal(t, "new-refresh", respBody["refresh_token"])
+			}
+
+			if !tt.callService {
+				mockService.AssertNotCalled(t, "RefreshTokens", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestMagicLinkHandler(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "always accepted",
+			body:         `{"email":"test@mail.com"}`,
+			callService:  true,
+			expectStatus: fiber.StatusAccepted,
+		},
+		{
+			name:         "invalid email",
+			body:         `{"email":"invalid"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "rate limited",
+			body:         `{"email":"test@mail.com"}`,
+			serviceError: &auth_errors.LockoutError{RetryAfter: 90 * time.Second},
+			callService:  true,
+			expectStatus: fiber.StatusTooManyRequests,
+		},
+		{
+			name:         "service error",
+			body:         `{"email":"test@mail.com"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("RequestMagicLink", "test@mail.com").Return(tt.serviceError)
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/magic-link", handler.MagicLinkHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/magic-link", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			if tt.expectStatus == fiber.StatusTooManyRequests {
+				assert.Equal(t, "90", resp.Header.Get(fiber.HeaderRetryAfter))
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordStrengthHandler(t *testing.T) {
+	const password = "Secret-Pass-2026"
+
+	tests := []struct {
+		name         string
+		body         string
+		response     *auth_types.PasswordStrengthResponse
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name: "policy result",
+			body: `{"password":"` + password + `","email":"test@mail.com"}`,
+			response: &auth_types.PasswordStrengthResponse{
+				Valid:   false,
+				Reasons: []string{password_errors.ErrTooGuessable.Error()},
+				Entropy: 98.5,
+				Score:   2,
+				Hints:   []string{"Add another word or two. Uncommon words are better."},
+			},
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "missing password",
+			body:         `{"email":"test@mail.com"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "rate limited",
+			body:         `{"password":"` + password + `","email":"test@mail.com"}`,
+			serviceError: &auth_errors.LockoutError{RetryAfter: 30 * time.Second},
+			callService:  true,
+			expectStatus: fiber.StatusTooManyRequests,
+		},
+		{
+			name:         "service error",
+			body:         `{"password":"` + password + `","email":"test@mail.com"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.DebugLevel)
+			logger.Log = zap.New(core)
+
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.
+					On("CheckPasswordStrength", auth_types.PasswordStrengthRequest{Password: password, Email: "test@mail.com"}, auth_types.ClientInfo{IP: "0.0.0.0"}).
+					Return(tt.response, tt.serviceError)
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/password-strength", handler.PasswordStrengthHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/password-strength", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			if tt.expectStatus == fiber.StatusOK {
+				var body auth_types.PasswordStrengthResponse
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+				assert.Equal(t, *tt.response, body)
+			}
+			if tt.expectStatus == fiber.StatusTooManyRequests {
+				assert.Equal(t, "30", resp.Header.Get(fiber.HeaderRetryAfter))
+			}
+
+			for _, entry := range logs.All() {
+				assert.NotContains(t, entry.Message, password)
+				for _, field := range entry.Context {
+					assert.NotContains(t, field.String, password)
+				}
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMagicLinkLoginHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New().String()
+
+	tests := []struct {
+		name         string
+		body         string
+		user         user_types.GetUserResponse
+		serviceError error
+		callService  bool
+		expectStatus int
+		expectMFA    bool
+	}{
+		{
+			name:         "success",
+			body:         `{"token":"abc"}`,
+			user:         user_types.GetUserResponse{PublicID: userID},
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "mfa required",
+			body:         `{"token":"abc"}`,
+			user:         user_types.GetUserResponse{PublicID: userID, MFAEnabled: true},
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+			expectMFA:    true,
+		},
+		{
+			name:         "missing token",
+			body:         `{}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "invalid token",
+			body:         `{"token":"abc"}`,
+			serviceError: auth_errors.ErrInvalidToken,
+			callService:  true,
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "user deleted",
+			body:         `{"token":"abc"}`,
+			serviceError: user_errors.ErrUserNotFound,
+			callService:  true,
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "password reset required",
+			body:         `{"token":"abc"}`,
+			serviceError: auth_errors.ErrPasswordReset,
+			callService:  true,
+			expectStatus: fiber.StatusForbidden,
+		},
+		{
+			name:         "service error",
+			body:         `{"token":"abc"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("LoginWithMagicLink", "abc").Return(tt.user, tt.serviceError)
+			}
+			if tt.expectStatus == fiber.StatusOK && !tt.expectMFA {
+				mockService.On("IssueTokens", userID, mock.Anything).
+					Return(&auth_types.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/magic-link/login", handler.MagicLinkLoginHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/magic-link/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.expectStatus == fiber.StatusOK {
+				var respBody map[string]interface{}
+				_ = json.NewDecoder(resp.Body).Decode(&respBody)
+
+				if tt.expectMFA {
+					assert.Equal(t, true, respBody["mfa_required"])
+					assert.NotContains(t, respBody, "access_token")
+				} else {
+					assert.Equal(t, "access", respBody["access_token"])
+				}
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAcceptInvitationHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New().String()
+	request := auth_types.AcceptInvitationRequest{Token: "abc", Name: "Bob Silva", Password: "Str0ng-Enough#Passw0rd"}
+	validBody := `{"token":"abc","name":"Bob Silva","password":"Str0ng-Enough#Passw0rd"}`
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "success", body: validBody, callService: true, expectStatus: fiber.StatusOK},
+		{name: "missing name", body: `{"token":"abc","password":"Str0ng-Enough#Passw0rd"}`, expectStatus: fiber.StatusBadRequest},
+		{name: "weak password", body: validBody, serviceError: &password_errors.PasswordError{BaseErr: password_errors.ErrPasswordValidator, Reasons: []error{password_errors.ErrTooShort}}, callService: true, expectStatus: fiber.StatusBadRequest},
+		{name: "used or expired", body: validBody, serviceError: auth_errors.ErrInvalidInvitation, callService: true, expectStatus: fiber.StatusBadRequest},
+		{name: "email taken", body: validBody, serviceError: auth_errors.ErrUserAlreadyExists, callService: true, expectStatus: fiber.StatusConflict},
+		{name: "service error", body: validBody, serviceError: errors.New("db error"), callService: true, expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					mockService.On("AcceptInvitation", request).Return(nil, tt.serviceError)
+				} else {
+					mockService.On("AcceptInvitation", request).Return(&user_types.GetUserResponse{PublicID: userID}, nil)
+					mockService.On("IssueTokens", userID, mock.Anything).
+						Return(&auth_types.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
+				}
+			}
+
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/invitations/accept", handler.AcceptInvitationHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/invitations/accept", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRegisterUserHandler_RegistrationDisabled(t *testing.T) {
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+	mockService.On("RegisterUser", mock.Anything).Return(nil, auth_errors.ErrRegistrationDisabled)
+
+	app := fiber.New()
+	handler := &authHandler{authService: mockService}
+	app.Post("/register", handler.RegisterUserHandler)
+
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(`{"name":"Célio","email":"celio@email.com","password":"Str0ng-Enough#Passw0rd"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestAuthLoginHandler_Audited(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New().String()
+	body := `{"email":"test@mail.com","password":"123456"}`
+
+	tests := []struct {
+		name         string
+		loginError   error
+		expectAction string
+		expectTarget string
+		expectReason string
+		expectHMAC   bool
+	}{
+		{
+			name:         "success",
+			expectAction: audit_types.ActionLoginSucceeded,
+			expectTarget: userID,
+		},
+		{
+			name:         "failure only keeps a keyed hash of the email",
+			loginError:   auth_errors.ErrMatchDataUser,
+			expectAction: audit_types.ActionLoginFailed,
+			expectReason: auth_errors.ErrMatchDataUser.Error(),
+			expectHMAC:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			mockService.
+				On("Login", mock.Anything, mock.Anything).
+				Return(user_types.GetUserResponse{PublicID: userID}, tt.loginError)
+			mockService.
+				On("IssueTokens", userID, mock.Anything).
+				Return(&auth_types.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil).
+				Maybe()
+
+			recorder := new(audit_service_mock.MockRecorder)
+			app := fiber.New()
+			handler := &authHandler{authService: mockService}
+			app.Post("/login", middleware.Audit(recorder), handler.AuthLoginHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			_, err := app.Test(req)
+
+			assert.NoError(t, err)
+			if assert.Len(t, recorder.Events, 1) {
+				event := recorder.Events[0]
+				assert.Equal(t, tt.expectAction, event.Action)
+				assert.Equal(t, tt.expectTarget, event.TargetID)
+				assert.Equal(t, "password", event.Metadata["method"])
+				assert.Equal(t, tt.expectReason, event.Metadata["reason"])
+				if tt.expectHMAC {
+					assert.Equal(t, middleware.EmailDigest("test@mail.com"), event.Metadata["email_hmac"])
+				} else {
+					assert.NotContains(t, event.Metadata, "email_hmac")
+				}
+				assert.NotContains(t, fmt.Sprint(event), "test@mail.com")
+			}
+			mockService.AssertExpectations(t)
+			mockService.AssertNotCalled(t, "LookupAccount", mock.Anything)
+		})
+	}
+}
+
+// Audit events can't be changed once written, so the erasure of an account never reaches
+// them. The email-keyed endpoints record the same keyed hash whether the account exists
+// or not, there is nothing in them for the erasure to change.
+func TestAuditEvents_KeepNoEmailOfErasedUser(t *testing.T) {
+	logger.Init("dev")
+
+	const email = "Jane.Doe@example.com"
+
+	mockService := new(auth_mock.MockAuthService)
+	mockService.On("Login", mock.Anything, mock.Anything).Return(user_types.GetUserResponse{}, auth_errors.ErrMatchDataUser)
+	mockService.On("RequestPasswordReset", email, mock.Anything).Return(nil)
+
+	recorder := new(audit_service_mock.MockRecorder)
+	app := fiber.New()
+	app.Use(middleware.Audit(recorder))
+	handler := &authHandler{authService: mockService}
+	app.Post("/login", handler.AuthLoginHandler)
+	app.Post("/forgot-password", handler.ForgotPasswordHandler)
+
+	send := func(path, body string) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		_, err := app.Test(req)
+		assert.NoError(t, err)
+	}
+
+	send("/login", `{"email":"`+email+`","password":"wrong-password"}`)
+	send("/forgot-password", `{"email":"`+email+`"}`)
+	send("/login", `{"email":"`+email+`","password":"wrong-password"}`)
+
+	assert.Len(t, recorder.Events, 3)
+	mockService.AssertNotCalled(t, "LookupAccount", mock.Anything)
+
+	for _, event := range recorder.Events {
+		assert.Empty(t, event.TargetID)
+		assert.Equal(t, middleware.EmailDigest(email), event.Metadata["email_hmac"])
+
+		row, err := json.Marshal(event)
+		assert.NoError(t, err)
+		assert.NotContains(t, strings.ToLower(string(row)), strings.ToLower(email))
+	}
+}
@@ -0,0 +1,239 @@
+package auth_handler
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_request "github.com/celio001/prodify/pkg/request"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// @Summary Complete a login with MFA
+// @Description Exchanges the mfa_token returned by /v1/auth/login and a TOTP or recovery code for the access and refresh tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth_types.MFALoginRequest true "MFA login payload"
+// @Success 200 {object} map[string]string "Access token generated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "Invalid or expired mfa token, or invalid code"
+// @Failure 429 {object} map[string]string "Too many failed attempts, see the Retry-After header"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/login/mfa [post]
+func (h *authHandler) MFALoginHandler(ctx *fiber.Ctx) error {
+	var mfaLoginRequest auth_types.MFALoginRequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &mfaLoginRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(mfaLoginRequest); err != nil {
+		logger.Log.Error("invalid mfa login payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.MFALoginValidateError(err)})
+	}
+
+	token, err := pkg_jwt.ParseToken(mfaLoginRequest.MFAToken)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid mfa token"})
+	}
+
+	tokenType, err := pkg_jwt.IsAccessToken(token)
+	if err != nil || tokenType != "mfa_pending" {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid mfa token"})
+	}
+
+	userID, err := pkg_jwt.GetUserIDFromToken(token)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid mfa token"})
+	}
+
+	user, err := h.authService.VerifyMFALogin(userID, mfaLoginRequest.Code, clientInfo(ctx))
+	if err != nil {
+		h.auditLogin(ctx, "mfa", userID, "", err)
+
+		var lockoutErr *auth_errors.LockoutError
+		if errors.As(err, &lockoutErr) {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+			return ctx.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		switch err {
+		case auth_errors.ErrInvalidMFACode:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid mfa token"})
+		default:
+			logger.Log.Error("failed to verify mfa login", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to login user"})
+		}
+	}
+
+	h.auditLogin(ctx, "mfa", user.PublicID, "", nil)
+	return h.respondWithTokens(ctx, user.PublicID)
+}
+
+// @Summary Start MFA enrollment
+// @Description Generates a new TOTP secret and returns it as an otpauth URI and a base64 PNG QR code. MFA stays disabled until confirmed
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth_types.MFAEnrollmentResponse "Enrollment started"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 409 {object} map[string]string "MFA already enabled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/mfa/enroll [post]
+func (h *authHandler) EnrollMFAHandler(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	userIDParsed, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	enrollment, err := h.authService.EnrollMFA(userIDParsed)
+	if err != nil {
+		switch err {
+		case auth_errors.ErrMFAAlreadyEnabled:
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to enroll mfa", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to enroll mfa"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(enrollment)
+}
+
+// @Summary Confirm MFA enrollment
+// @Description Enables MFA with the first TOTP code from the authenticator app and returns one-time recovery codes. They are only shown once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body auth_types.ConfirmMFARequest true "Confirm MFA payload"
+// @Success 200 {object} map[string]interface{} "MFA enabled, recovery codes returned"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, invalid code or no pending enrollment"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 409 {object} map[string]string "MFA already enabled"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/mfa/confirm [post]
+func (h *authHandler) ConfirmMFAHandler(ctx *fiber.Ctx) error {
+	var confirmRequest auth_types.ConfirmMFARequest
+
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	userIDParsed, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &confirmRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(confirmRequest); err != nil {
+		logger.Log.Error("invalid confirm mfa payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.ConfirmMFAValidateError(err)})
+	}
+
+	recoveryCodes, err := h.authService.ConfirmMFA(userIDParsed, confirmRequest.Code)
+	if err != nil {
+		switch err {
+		case auth_errors.ErrInvalidMFACode:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case auth_errors.ErrMFANotEnrolled:
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case auth_errors.ErrMFAAlreadyEnabled:
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to confirm mfa", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to confirm mfa"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":        "two-factor authentication enabled",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// @Summary Reset a user's MFA
+// @Description Removes the TOTP secret and recovery codes of a user so they can log in with the password and enroll again. Requires the admin role
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body auth_types.ResetMFARequest true "Reset MFA payload"
+// @Success 200 {object} map[string]string "MFA reset"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "User is not an admin"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/mfa/reset [post]
+func (h *authHandler) ResetMFAHandler(ctx *fiber.Ctx) error {
+	var resetRequest auth_types.ResetMFARequest
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &resetRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(resetRequest); err != nil {
+		logger.Log.Error("invalid reset mfa payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": auth_errors.ResetMFAValidateError(err)})
+	}
+
+	userIDParsed, err := uuidvalidator.ValidateUuid(resetRequest.UserID)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if err := h.authService.ResetMFA(userIDParsed); err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to reset mfa", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to reset mfa"})
+		}
+	}
+
+	logger.Log.Info("mfa reset",
+		zap.Any("admin_id", ctx.Locals(middleware.UserIDKey)),
+		zap.String("user_id", resetRequest.UserID))
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "two-factor authentication reset"})
+}
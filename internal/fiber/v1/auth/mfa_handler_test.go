@@ -0,0 +1,352 @@
+package auth_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_mock "github.com/celio001/prodify/internal/auth/service/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthLoginHandler_MFARequired(t *testing.T) {
+
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+
+	userID := uuid.New().String()
+
+	mockService.
+		On("Login", mock.Anything, mock.Anything).
+		Return(user_types.GetUserResponse{PublicID: userID, MFAEnabled: true}, nil)
+
+	app := setupTestApp(mockService)
+
+	body := `{
+		"email":"test@mail.com",
+		"password":"123456"
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var respBody map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&respBody)
+
+	assert.Equal(t, true, respBody["mfa_required"])
+	assert.NotContains(t, respBody, "access_token")
+
+	token, err := pkg_jwt.ParseToken(respBody["mfa_token"].(string))
+	assert.NoError(t, err)
+
+	tokenType, _ := pkg_jwt.IsAccessToken(token)
+	assert.Equal(t, "mfa_pending", tokenType)
+}
+
+func TestMFALoginHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New().String()
+	mfaToken, _ := pkg_jwt.CreateMFAPendingToken(userID)
+	accessToken, _ := pkg_jwt.CreateAccessToken(userID, uuid.New().String())
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"mfa_token":"` + mfaToken + `","code":"123456"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "invalid code",
+			body:         `{"mfa_token":"` + mfaToken + `","code":"123456"}`,
+			serviceError: auth_errors.ErrInvalidMFACode,
+			callService:  true,
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "locked out",
+			body:         `{"mfa_token":"` + mfaToken + `","code":"123456"}`,
+			serviceError: &auth_errors.LockoutError{RetryAfter: 1},
+			callService:  true,
+			expectStatus: fiber.StatusTooManyRequests,
+		},
+		{
+			name:         "access token is not accepted",
+			body:         `{"mfa_token":"` + accessToken + `","code":"123456"}`,
+			callService:  false,
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "garbage token",
+			body:         `{"mfa_token":"abc","code":"123456"}`,
+			callService:  false,
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:         "missing code",
+			body:         `{"mfa_token":"` + mfaToken + `"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "service error",
+			body:         `{"mfa_token":"` + mfaToken + `","code":"123456"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("VerifyMFALogin", userID, "123456", mock.Anything).
+					Return(user_types.GetUserResponse{PublicID: userID}, tt.serviceError)
+			}
+			if tt.callService && tt.serviceError == nil {
+				mockService.On("IssueTokens", userID, mock.Anything).
+					Return(&auth_types.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil)
+			}
+
+			handler := &authHandler{authService: mockService}
+			app := fiber.New()
+			app.Post("/login/mfa", handler.MFALoginHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/login/mfa", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "VerifyMFALogin", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestEnrollMFAHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		serviceError error
+		expectStatus int
+	}{
+		{name: "success", expectStatus: fiber.StatusOK},
+		{name: "already enabled", serviceError: auth_errors.ErrMFAAlreadyEnabled, expectStatus: fiber.StatusConflict},
+		{name: "service error", serviceError: errors.New("db error"), expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.serviceError != nil {
+				mockService.On("EnrollMFA", userID).Return(nil, tt.serviceError)
+			} else {
+				mockService.On("EnrollMFA", userID).Return(&auth_types.MFAEnrollmentResponse{
+					Secret:     "SECRET",
+					OTPAuthURI: "otpauth://totp/prodify:test@mail.com?secret=SECRET",
+					QRCodePNG:  "iVBORw0KGgo=",
+				}, nil)
+			}
+
+			handler := &authHandler{authService: mockService}
+			app := fiber.New()
+			app.Post("/mfa/enroll", func(c *fiber.Ctx) error {
+				c.Locals("user_id", userID.String())
+				return handler.EnrollMFAHandler(c)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/mfa/enroll", nil)
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.serviceError == nil {
+				var respBody map[string]string
+				_ = json.NewDecoder(resp.Body).Decode(&respBody)
+				assert.Equal(t, "SECRET", respBody["secret"])
+				assert.NotEmpty(t, respBody["otpauth_uri"])
+				assert.NotEmpty(t, respBody["qr_png"])
+			}
+		})
+	}
+}
+
+func TestConfirmMFAHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"code":"123456"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "invalid code",
+			body:         `{"code":"123456"}`,
+			serviceError: auth_errors.ErrInvalidMFACode,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "not enrolled",
+			body:         `{"code":"123456"}`,
+			serviceError: auth_errors.ErrMFANotEnrolled,
+			callService:  true,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "already enabled",
+			body:         `{"code":"123456"}`,
+			serviceError: auth_errors.ErrMFAAlreadyEnabled,
+			callService:  true,
+			expectStatus: fiber.StatusConflict,
+		},
+		{
+			name:         "malformed code",
+			body:         `{"code":"12ab"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				var codes []string
+				if tt.serviceError == nil {
+					codes = []string{"aaaa-bbbb-cccc-dddd"}
+				}
+				mockService.On("ConfirmMFA", userID, "123456").Return(codes, tt.serviceError)
+			}
+
+			handler := &authHandler{authService: mockService}
+			app := fiber.New()
+			app.Post("/mfa/confirm", func(c *fiber.Ctx) error {
+				c.Locals("user_id", userID.String())
+				return handler.ConfirmMFAHandler(c)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/mfa/confirm", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "ConfirmMFA", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestResetMFAHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"user_id":"` + userID.String() + `"}`,
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "invalid user id",
+			body:         `{"user_id":"abc"}`,
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "service error",
+			body:         `{"user_id":"` + userID.String() + `"}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("ResetMFA", userID).Return(tt.serviceError)
+			}
+
+			handler := &authHandler{authService: mockService}
+			app := fiber.New()
+			app.Post("/mfa/reset", handler.ResetMFAHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/mfa/reset", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "ResetMFA", mock.Anything)
+			}
+		})
+	}
+}
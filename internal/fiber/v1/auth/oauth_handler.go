@@ -0,0 +1,170 @@
+package auth_handler
+
+import (
+	"crypto/subtle"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	oauthStateCookie = "oauth_state"
+	oauthStateMaxAge = 10 * 60
+)
+
+// @Summary Start a social login
+// @Description Redirects to the login page of an identity provider configured in OIDC_PROVIDERS, using the authorization-code flow with PKCE
+// @Tags auth
+// @Param provider path string true "Provider name, e.g. google or github"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Failure 502 {object} map[string]string "Identity provider unavailable"
+// @Router /v1/auth/oauth/{provider} [get]
+func (h *authHandler) OAuthStartHandler(ctx *fiber.Ctx) error {
+	provider, err := h.providers.Get(ctx.Params("provider"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// random url-safe tokens, 43 chars is also a valid PKCE verifier
+	state, _, err := pkg_token.Generate()
+	if err != nil {
+		logger.Log.Error("failed to generate oauth state", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+	nonce, _, err := pkg_token.Generate()
+	if err != nil {
+		logger.Log.Error("failed to generate oauth nonce", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+	codeVerifier, _, err := pkg_token.Generate()
+	if err != nil {
+		logger.Log.Error("failed to generate pkce verifier", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx.UserContext(), state, nonce, codeVerifier)
+	if err != nil {
+		logger.Log.Error("identity provider unavailable", zap.String("provider", provider.Name()), zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "identity provider unavailable"})
+	}
+
+	stateToken, err := pkg_jwt.CreateOAuthStateToken(pkg_jwt.OAuthState{
+		Provider:     provider.Name(),
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+	})
+	if err != nil {
+		logger.Log.Error("failed to create oauth state token", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+
+	// Lax so the cookie survives the top-level redirect back from the provider
+	ctx.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Value:    stateToken,
+		Path:     "/",
+		MaxAge:   oauthStateMaxAge,
+		HTTPOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return ctx.Redirect(authURL, fiber.StatusFound)
+}
+
+// @Summary Finish a social login
+// @Description Callback the identity provider redirects to. Signs in the user linked to the external identity,
+// @Description links it to the account with the same verified email, or creates a new account.
+// @Description Answers like /v1/auth/login, including mfa_required when the user has two-factor authentication enabled
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name, e.g. google or github"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State sent with the authorization request"
+// @Success 200 {object} map[string]interface{} "Access token generated successfully, or MFA code required"
+// @Failure 400 {object} map[string]string "Missing code, or state that doesn't match the login started in this browser"
+// @Failure 401 {object} map[string]string "Login denied or rejected by the identity provider"
+// @Failure 403 {object} map[string]string "The provider did not return a verified email, the account is deactivated, or it would be a new account while registration is by invitation only"
+// @Failure 404 {object} map[string]string "Unknown provider"
+// @Failure 409 {object} map[string]string "An unverified account already uses the email"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/auth/oauth/{provider}/callback [get]
+func (h *authHandler) OAuthCallbackHandler(ctx *fiber.Ctx) error {
+	provider, err := h.providers.Get(ctx.Params("provider"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rawState := ctx.Cookies(oauthStateCookie)
+	ctx.Cookie(&fiber.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HTTPOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	if reason := ctx.Query("error"); reason != "" {
+		logger.Log.Info("social login denied", zap.String("provider", provider.Name()), zap.String("reason", reason))
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "login was not completed at the identity provider"})
+	}
+
+	state, err := parseOAuthState(rawState)
+	if err != nil || state.Provider != provider.Name() ||
+		subtle.ConstantTimeCompare([]byte(state.State), []byte(ctx.Query("state"))) != 1 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid oauth state"})
+	}
+
+	code := ctx.Query("code")
+	if code == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing authorization code"})
+	}
+
+	identity, err := provider.Exchange(ctx.UserContext(), code, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		logger.Log.Error("failed to exchange authorization code", zap.String("provider", provider.Name()), zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "identity provider rejected the login"})
+	}
+
+	user, err := h.authService.LoginWithIdentity(*identity)
+	if err != nil {
+		h.auditLogin(ctx, provider.Name(), "", identity.Email, err)
+
+		switch err {
+		case auth_errors.ErrIdentityEmailUnverified, auth_errors.ErrUserInactive, auth_errors.ErrRegistrationDisabled, auth_errors.ErrPasswordReset:
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case auth_errors.ErrIdentityLinkConflict:
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to login with external identity", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to login user"})
+		}
+	}
+
+	return h.completeLogin(ctx, provider.Name(), user.PublicID, user.MFAEnabled)
+}
+
+func parseOAuthState(raw string) (pkg_jwt.OAuthState, error) {
+	token, err := pkg_jwt.ParseToken(raw)
+	if err != nil {
+		return pkg_jwt.OAuthState{}, err
+	}
+
+	tokenType, err := pkg_jwt.IsAccessToken(token)
+	if err != nil || tokenType != "oauth_state" {
+		return pkg_jwt.OAuthState{}, pkg_jwt.ErrInvalidToken
+	}
+
+	return pkg_jwt.GetOAuthStateFromToken(token)
+}
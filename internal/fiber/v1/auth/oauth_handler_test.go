@@ -0,0 +1,280 @@
+package auth_handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	audit_service_mock "github.com/celio001/prodify/internal/audit/service/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_oidc "github.com/celio001/prodify/internal/auth/oidc"
+	"github.com/celio001/prodify/internal/auth/oidc/oidctest"
+	auth_mock "github.com/celio001/prodify/internal/auth/service/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOAuthTestApp(t *testing.T, service *auth_mock.MockAuthService, middlewares ...fiber.Handler) (*fiber.App, *oidctest.Server) {
+	server := oidctest.NewServer(t, "prodify", "secret")
+	server.SetUser(oidctest.User{
+		Subject:       "user-1",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	})
+
+	providers := auth_oidc.NewRegistry(auth_oidc.NewOIDCProvider(auth_oidc.ProviderConfig{
+		Name:         "fake",
+		IssuerURL:    server.URL,
+		ClientID:     "prodify",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oauth/fake/callback",
+	}))
+
+	handler := &authHandler{authService: service, providers: providers}
+
+	app := fiber.New()
+	for _, m := range middlewares {
+		app.Use(m)
+	}
+	app.Get("/oauth/:provider", handler.OAuthStartHandler)
+	app.Get("/oauth/:provider/callback", handler.OAuthCallbackHandler)
+
+	return app, server
+}
+
+// startOAuthLogin runs the redirect to the provider and its sign-in, returning
+// the state cookie set by the start handler and the callback query.
+func startOAuthLogin(t *testing.T, app *fiber.App, server *oidctest.Server) (*http.Cookie, url.Values) {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/oauth/fake", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusFound, resp.StatusCode)
+
+	var stateCookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == oauthStateCookie {
+			stateCookie = c
+		}
+	}
+	require.NotNil(t, stateCookie)
+	assert.True(t, stateCookie.HttpOnly)
+
+	code, state, err := server.Authorize(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	return stateCookie, url.Values{"code": {code}, "state": {state}}
+}
+
+func oauthCallback(t *testing.T, app *fiber.App, cookie *http.Cookie, query url.Values) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, "/oauth/fake/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	return resp
+}
+
+func TestOAuthLogin_Success(t *testing.T) {
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+	app, server := setupOAuthTestApp(t, mockService)
+
+	publicID := uuid.New().String()
+	identity := auth_types.ExternalIdentity{
+		Provider:      "fake",
+		Subject:       "user-1",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	}
+	mockService.On("LoginWithIdentity", identity).
+		Return(user_types.GetUserResponse{PublicID: publicID, Email: "jane@example.com"}, nil)
+	mockService.On("IssueTokens", publicID, mock.Anything).
+		Return(&auth_types.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil)
+
+	cookie, query := startOAuthLogin(t, app, server)
+	resp := oauthCallback(t, app, cookie, query)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body map[string]string
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, "access", body["access_token"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestOAuthLogin_MFARequired(t *testing.T) {
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+	app, server := setupOAuthTestApp(t, mockService)
+
+	mockService.On("LoginWithIdentity", mock.Anything).
+		Return(user_types.GetUserResponse{PublicID: uuid.New().String(), MFAEnabled: true}, nil)
+
+	cookie, query := startOAuthLogin(t, app, server)
+	resp := oauthCallback(t, app, cookie, query)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, true, body["mfa_required"])
+	assert.NotEmpty(t, body["mfa_token"])
+
+	mockService.AssertNotCalled(t, "IssueTokens", mock.Anything, mock.Anything)
+}
+
+func TestOAuthCallbackHandler_Rejected(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		tamper       func(cookie *http.Cookie, query url.Values) (*http.Cookie, url.Values)
+		expectStatus int
+	}{
+		{
+			name: "state mismatch",
+			tamper: func(cookie *http.Cookie, query url.Values) (*http.Cookie, url.Values) {
+				query.Set("state", "forged")
+				return cookie, query
+			},
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name: "missing state cookie",
+			tamper: func(cookie *http.Cookie, query url.Values) (*http.Cookie, url.Values) {
+				return nil, query
+			},
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name: "missing code",
+			tamper: func(cookie *http.Cookie, query url.Values) (*http.Cookie, url.Values) {
+				query.Del("code")
+				return cookie, query
+			},
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name: "unknown code",
+			tamper: func(cookie *http.Cookie, query url.Values) (*http.Cookie, url.Values) {
+				query.Set("code", "unknown")
+				return cookie, query
+			},
+			expectStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name: "denied at the provider",
+			tamper: func(cookie *http.Cookie, query url.Values) (*http.Cookie, url.Values) {
+				return cookie, url.Values{"error": {"access_denied"}, "state": query["state"]}
+			},
+			expectStatus: fiber.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			app, server := setupOAuthTestApp(t, mockService)
+
+			cookie, query := tt.tamper(startOAuthLogin(t, app, server))
+			resp := oauthCallback(t, app, cookie, query)
+
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			mockService.AssertNotCalled(t, "LoginWithIdentity", mock.Anything)
+		})
+	}
+}
+
+func TestOAuthCallbackHandler_ServiceErrors(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		serviceError error
+		expectStatus int
+	}{
+		{
+			name:         "unverified provider email",
+			serviceError: auth_errors.ErrIdentityEmailUnverified,
+			expectStatus: fiber.StatusForbidden,
+		},
+		{
+			name:         "unverified local account",
+			serviceError: auth_errors.ErrIdentityLinkConflict,
+			expectStatus: fiber.StatusConflict,
+		},
+		{
+			name:         "password reset required",
+			serviceError: auth_errors.ErrPasswordReset,
+			expectStatus: fiber.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			app, server := setupOAuthTestApp(t, mockService)
+
+			mockService.On("LoginWithIdentity", mock.Anything).
+				Return(user_types.GetUserResponse{}, tt.serviceError)
+
+			cookie, query := startOAuthLogin(t, app, server)
+			resp := oauthCallback(t, app, cookie, query)
+
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			mockService.AssertNotCalled(t, "IssueTokens", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestOAuthCallbackHandler_AuditedFailure(t *testing.T) {
+	logger.Init("dev")
+
+	mockService := new(auth_mock.MockAuthService)
+	recorder := new(audit_service_mock.MockRecorder)
+	app, server := setupOAuthTestApp(t, mockService, middleware.Audit(recorder))
+
+	mockService.On("LoginWithIdentity", mock.Anything).
+		Return(user_types.GetUserResponse{}, auth_errors.ErrUserInactive)
+
+	cookie, query := startOAuthLogin(t, app, server)
+	resp := oauthCallback(t, app, cookie, query)
+
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	if assert.Len(t, recorder.Events, 1) {
+		event := recorder.Events[0]
+		assert.Equal(t, audit_types.ActionLoginFailed, event.Action)
+		assert.Empty(t, event.TargetID)
+		assert.Equal(t, middleware.EmailDigest("jane@example.com"), event.Metadata["email_hmac"])
+	}
+	mockService.AssertNotCalled(t, "LookupAccount", mock.Anything)
+}
+
+func TestOAuthStartHandler_UnknownProvider(t *testing.T) {
+	logger.Init("dev")
+
+	app, _ := setupOAuthTestApp(t, new(auth_mock.MockAuthService))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/oauth/unknown", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
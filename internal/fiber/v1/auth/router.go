@@ -1,8 +1,10 @@
 package auth_handler
 
 import (
+	auth_oidc "github.com/celio001/prodify/internal/auth/oidc"
 	auth_service "github.com/celio001/prodify/internal/auth/service"
 	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_types "github.com/celio001/prodify/internal/user/type"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -10,10 +12,30 @@ const (
 	HandlerPath = "/auth"
 )
 
-func RegisterRouter(router fiber.Router, authService auth_service.AuthService) {
+func RegisterRouter(router fiber.Router, authService auth_service.AuthService, authMiddleware fiber.Handler, providers *auth_oidc.Registry) {
+
+	handler := NewAuthHandler(authService, providers)
+	session := middleware.RequireSession()
+	admin := middleware.RequireRole(authService, user_types.RoleAdmin)
 
-	handler := NewAuthHandler(authService)
 	router.Post("/login", handler.AuthLoginHandler)
 	router.Post("/register", handler.RegisterUserHandler)
-	router.Patch("/reset-password", middleware.AuthMiddleware(), handler.AuthResetPasswordHandler, )
+	router.Post("/invitations/accept", handler.AcceptInvitationHandler)
+	router.Post("/password-strength", handler.PasswordStrengthHandler)
+	router.Post("/verify-email", handler.VerifyEmailHandler)
+	router.Post("/verify-email/resend", handler.ResendVerificationEmailHandler)
+	router.Post("/email-change/confirm", handler.ConfirmEmailChangeHandler)
+	router.Post("/forgot-password", handler.ForgotPasswordHandler)
+	router.Post("/reset-password/confirm", handler.ConfirmPasswordResetHandler)
+	router.Post("/magic-link", handler.MagicLinkHandler)
+	router.Post("/magic-link/login", handler.MagicLinkLoginHandler)
+	router.Patch("/reset-password", authMiddleware, session, handler.AuthResetPasswordHandler)
+	router.Post("/unlock", authMiddleware, session, admin, handler.UnlockLoginHandler)
+	router.Post("/login/mfa", handler.MFALoginHandler)
+	router.Post("/refresh", handler.RefreshTokenHandler)
+	router.Get("/oauth/:provider", handler.OAuthStartHandler)
+	router.Get("/oauth/:provider/callback", handler.OAuthCallbackHandler)
+	router.Post("/mfa/enroll", authMiddleware, session, handler.EnrollMFAHandler)
+	router.Post("/mfa/confirm", authMiddleware, session, handler.ConfirmMFAHandler)
+	router.Post("/mfa/reset", authMiddleware, session, admin, handler.ResetMFAHandler)
 }
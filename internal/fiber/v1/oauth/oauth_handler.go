@@ -0,0 +1,274 @@
+package oauth_handler
+
+import (
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_service "github.com/celio001/prodify/internal/oauth/service"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_request "github.com/celio001/prodify/pkg/request"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type oauthHandler struct {
+	oauthService oauth_service.OAuthService
+}
+
+type OAuthHandler interface {
+	RegisterClientHandler(ctx *fiber.Ctx) error
+	ListClientsHandler(ctx *fiber.Ctx) error
+	RevokeClientHandler(ctx *fiber.Ctx) error
+	ConsentHandler(ctx *fiber.Ctx) error
+	AuthorizeHandler(ctx *fiber.Ctx) error
+	TokenHandler(ctx *fiber.Ctx) error
+	IntrospectHandler(ctx *fiber.Ctx) error
+	RevokeHandler(ctx *fiber.Ctx) error
+}
+
+func NewOAuthHandler(oauthService oauth_service.OAuthService) OAuthHandler {
+	return &oauthHandler{oauthService: oauthService}
+}
+
+const maxBodySize = 1 << 20 // 1MB
+var validate = validator.New()
+
+// @Summary Register an OAuth client
+// @Description Registers a third-party application that can ask users for access. Confidential clients get a secret, only returned in this response
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body oauth_types.RegisterClientRequest true "Register client payload"
+// @Success 201 {object} oauth_types.RegisterClientResponse "Client registered"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key or an application token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/oauth/clients [post]
+func (h *oauthHandler) RegisterClientHandler(ctx *fiber.Ctx) error {
+	var registerRequest oauth_types.RegisterClientRequest
+
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &registerRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(registerRequest); err != nil {
+		logger.Log.Error("invalid register client payload", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": oauth_errors.RegisterClientValidateError(err)})
+	}
+
+	client, err := h.oauthService.RegisterClient(id, registerRequest)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to register oauth client", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to register client"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(client)
+}
+
+// @Summary List OAuth clients
+// @Description Lists the applications registered by the authenticated user. Secrets are never returned
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Clients loaded successfully"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key or an application token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/oauth/clients [get]
+func (h *oauthHandler) ListClientsHandler(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	clients, err := h.oauthService.ListClients(id)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to list oauth clients", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list clients"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "clients loaded successfully",
+		"data":    clients,
+	})
+}
+
+// @Summary Delete an OAuth client
+// @Description Deletes one of the authenticated user's applications, every token issued to it stops working immediately
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Client ID"
+// @Success 200 {object} map[string]string "Client deleted"
+// @Failure 400 {object} map[string]string "Invalid user or client ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key or an application token"
+// @Failure 404 {object} map[string]string "Client not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/oauth/clients/{id} [delete]
+func (h *oauthHandler) RevokeClientHandler(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	clientID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_CLIENT_ID"})
+	}
+
+	if err := h.oauthService.RevokeClient(id, clientID); err != nil {
+		switch err {
+		case oauth_errors.ErrClientNotFound:
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to revoke oauth client", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete client"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "client deleted"})
+}
+
+// @Summary Describe an authorization request
+// @Description Called by the consent screen with the query the application sent the user with. Returns the application and the permissions it asks for.
+// @Description Invalid requests are answered here and never redirected back to the application
+// @Tags oauth
+// @Produce json
+// @Security BearerAuth
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "One of the client's registered redirect URIs"
+// @Param scope query string false "Space-delimited scopes, defaults to every scope of the client"
+// @Param state query string false "Opaque value sent back to the application"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 200 {object} oauth_types.Consent "What the user is asked to approve"
+// @Failure 400 {object} map[string]string "Unknown client, unregistered redirect URI, missing PKCE or invalid scope"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key or an application token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/oauth/authorize [get]
+func (h *oauthHandler) ConsentHandler(ctx *fiber.Ctx) error {
+	var authRequest oauth_types.AuthorizationRequest
+
+	if err := ctx.QueryParser(&authRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid authorization request"})
+	}
+
+	consent, err := h.oauthService.GetConsent(authRequest)
+	if err != nil {
+		return authorizationError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(consent)
+}
+
+// @Summary Approve or deny an application
+// @Description Records the user's answer on the consent screen and returns where to send the browser:
+// @Description the application's redirect URI with an authorization code, or with error=access_denied
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body oauth_types.ConsentRequest true "The authorization request and the user's answer"
+// @Success 200 {object} oauth_types.ConsentResponse "Redirect back to the application"
+// @Failure 400 {object} map[string]string "Unknown client, unregistered redirect URI, missing PKCE or invalid scope"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key or an application token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/oauth/authorize [post]
+func (h *oauthHandler) AuthorizeHandler(ctx *fiber.Ctx) error {
+	var consentRequest oauth_types.ConsentRequest
+
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &consentRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	response, err := h.oauthService.Authorize(id, consentRequest)
+	if err != nil {
+		return authorizationError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+func authorizationError(ctx *fiber.Ctx, err error) error {
+	switch err {
+	case oauth_errors.ErrClientNotFound,
+		oauth_errors.ErrInvalidRedirectURI,
+		oauth_errors.ErrUnsupportedResponseType,
+		oauth_errors.ErrPKCERequired,
+		oauth_errors.ErrInvalidScope:
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case user_errors.ErrUserNotFound:
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	default:
+		logger.Log.Error("failed to authorize oauth client", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to authorize application"})
+	}
+}
@@ -0,0 +1,228 @@
+package oauth_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_service_mock "github.com/celio001/prodify/internal/oauth/service/mock"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestApp(service *oauth_service_mock.MockOAuthService, userID string) *fiber.App {
+	app := fiber.New()
+	handler := NewOAuthHandler(service)
+
+	withUser := func(next fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID)
+			return next(c)
+		}
+	}
+
+	app.Post("/clients", withUser(handler.RegisterClientHandler))
+	app.Get("/clients", withUser(handler.ListClientsHandler))
+	app.Delete("/clients/:id", withUser(handler.RevokeClientHandler))
+	app.Get("/authorize", withUser(handler.ConsentHandler))
+	app.Post("/authorize", withUser(handler.AuthorizeHandler))
+	app.Post("/token", handler.TokenHandler)
+	app.Post("/introspect", handler.IntrospectHandler)
+	app.Post("/revoke", handler.RevokeHandler)
+
+	return app
+}
+
+func TestRegisterClientHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		body         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			body:         `{"name":"Partner","redirect_uris":["https://partner.example/callback"],"scopes":["user:read"],"confidential":true}`,
+			callService:  true,
+			expectStatus: fiber.StatusCreated,
+		},
+		{
+			name:         "relative redirect uri",
+			body:         `{"name":"Partner","redirect_uris":["/callback"],"scopes":["user:read"]}`,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "unknown scope",
+			body:         `{"name":"Partner","redirect_uris":["https://partner.example/callback"],"scopes":["admin"]}`,
+			expectStatus: fiber.StatusBadRequest,
+		},
+		{
+			name:         "service error",
+			body:         `{"name":"Partner","redirect_uris":["https://partner.example/callback"],"scopes":["user:read"]}`,
+			serviceError: errors.New("db error"),
+			callService:  true,
+			expectStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(oauth_service_mock.MockOAuthService)
+			if tt.callService {
+				if tt.serviceError != nil {
+					mockService.On("RegisterClient", userID, mock.Anything).Return(nil, tt.serviceError)
+				} else {
+					mockService.On("RegisterClient", userID, mock.Anything).Return(&oauth_types.RegisterClientResponse{
+						Client:       oauth_types.Client{PublicID: "client", Confidential: true},
+						ClientSecret: "cs_secret",
+					}, nil)
+				}
+			}
+
+			app := setupTestApp(mockService, userID.String())
+
+			req := httptest.NewRequest(http.MethodPost, "/clients", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.expectStatus == fiber.StatusCreated {
+				var body map[string]interface{}
+				_ = json.NewDecoder(resp.Body).Decode(&body)
+				assert.Equal(t, "cs_secret", body["client_secret"])
+			}
+			if !tt.callService {
+				mockService.AssertNotCalled(t, "RegisterClient", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRevokeClientHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	clientID := uuid.New()
+
+	tests := []struct {
+		name         string
+		path         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "success", path: "/clients/" + clientID.String(), callService: true, expectStatus: fiber.StatusOK},
+		{name: "invalid id", path: "/clients/abc", expectStatus: fiber.StatusBadRequest},
+		{
+			name:         "not found",
+			path:         "/clients/" + clientID.String(),
+			serviceError: oauth_errors.ErrClientNotFound,
+			callService:  true,
+			expectStatus: fiber.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(oauth_service_mock.MockOAuthService)
+			if tt.callService {
+				mockService.On("RevokeClient", userID, clientID).Return(tt.serviceError)
+			}
+
+			app := setupTestApp(mockService, userID.String())
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodDelete, tt.path, nil))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestConsentHandler(t *testing.T) {
+	logger.Init("dev")
+
+	query := "/authorize?response_type=code&client_id=client&redirect_uri=https%3A%2F%2Fpartner.example%2Fcallback" +
+		"&scope=user%3Aread&state=xyz&code_challenge=challenge&code_challenge_method=S256"
+	expected := oauth_types.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "client",
+		RedirectURI:         "https://partner.example/callback",
+		Scope:               "user:read",
+		State:               "xyz",
+		CodeChallenge:       "challenge",
+		CodeChallengeMethod: "S256",
+	}
+
+	tests := []struct {
+		name         string
+		serviceError error
+		expectStatus int
+	}{
+		{name: "valid request", expectStatus: fiber.StatusOK},
+		{name: "unregistered redirect uri", serviceError: oauth_errors.ErrInvalidRedirectURI, expectStatus: fiber.StatusBadRequest},
+		{name: "missing pkce", serviceError: oauth_errors.ErrPKCERequired, expectStatus: fiber.StatusBadRequest},
+		{name: "service error", serviceError: errors.New("db error"), expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(oauth_service_mock.MockOAuthService)
+			if tt.serviceError != nil {
+				mockService.On("GetConsent", expected).Return(nil, tt.serviceError)
+			} else {
+				mockService.On("GetConsent", expected).Return(&oauth_types.Consent{ClientName: "Partner"}, nil)
+			}
+
+			app := setupTestApp(mockService, uuid.New().String())
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, query, nil))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthorizeHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	mockService := new(oauth_service_mock.MockOAuthService)
+	mockService.On("Authorize", userID, mock.MatchedBy(func(r oauth_types.ConsentRequest) bool {
+		return r.Approve && r.ClientID == "client" && r.State == "xyz"
+	})).Return(&oauth_types.ConsentResponse{RedirectTo: "https://partner.example/callback?code=abc&state=xyz"}, nil)
+
+	app := setupTestApp(mockService, userID.String())
+
+	req := httptest.NewRequest(http.MethodPost, "/authorize",
+		strings.NewReader(`{"client_id":"client","state":"xyz","approve":true}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body map[string]string
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, "https://partner.example/callback?code=abc&state=xyz", body["redirect_to"])
+}
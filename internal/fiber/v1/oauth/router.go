@@ -0,0 +1,29 @@
+package oauth_handler
+
+import (
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	oauth_service "github.com/celio001/prodify/internal/oauth/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HandlerPath = "/oauth"
+)
+
+func RegisterRouter(router fiber.Router, oauthService oauth_service.OAuthService, authMiddleware fiber.Handler) {
+
+	handler := NewOAuthHandler(oauthService)
+	session := middleware.RequireSession()
+
+	router.Post("/clients", authMiddleware, session, handler.RegisterClientHandler)
+	router.Get("/clients", authMiddleware, session, handler.ListClientsHandler)
+	router.Delete("/clients/:id", authMiddleware, session, handler.RevokeClientHandler)
+
+	router.Get("/authorize", authMiddleware, session, handler.ConsentHandler)
+	router.Post("/authorize", authMiddleware, session, handler.AuthorizeHandler)
+
+	// called by the clients themselves, they authenticate with their own credentials
+	router.Post("/token", handler.TokenHandler)
+	router.Post("/introspect", handler.IntrospectHandler)
+	router.Post("/revoke", handler.RevokeHandler)
+}
@@ -0,0 +1,183 @@
+package oauth_handler
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// @Summary Issue OAuth tokens
+// @Description Token endpoint (RFC 6749). Supports authorization_code with PKCE, refresh_token and, for confidential clients, client_credentials.
+// @Description Clients authenticate with HTTP Basic or client_id/client_secret form fields, public clients send client_id only
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param code formData string false "Authorization code, for authorization_code"
+// @Param redirect_uri formData string false "Redirect URI the code was issued for, for authorization_code"
+// @Param code_verifier formData string false "PKCE code verifier, for authorization_code"
+// @Param refresh_token formData string false "Refresh token, for refresh_token"
+// @Param scope formData string false "Space-delimited scopes, for client_credentials and refresh_token"
+// @Param client_id formData string false "Client ID, when not using HTTP Basic"
+// @Param client_secret formData string false "Client secret, when not using HTTP Basic"
+// @Success 200 {object} oauth_types.TokenResponse "Tokens issued"
+// @Failure 400 {object} map[string]string "invalid_request, invalid_grant, invalid_scope, unauthorized_client or unsupported_grant_type"
+// @Failure 401 {object} map[string]string "invalid_client"
+// @Failure 500 {object} map[string]string "server_error"
+// @Router /v1/oauth/token [post]
+func (h *oauthHandler) TokenHandler(ctx *fiber.Ctx) error {
+	var tokenRequest oauth_types.TokenRequest
+
+	if err := ctx.BodyParser(&tokenRequest); err != nil {
+		return tokenError(ctx, oauth_errors.ErrInvalidRequest)
+	}
+
+	client, err := clientCredentials(ctx)
+	if err != nil {
+		return tokenError(ctx, err)
+	}
+
+	response, err := h.oauthService.Token(client, tokenRequest)
+	if err != nil {
+		return tokenError(ctx, err)
+	}
+
+	noStore(ctx)
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// @Summary Introspect an OAuth token
+// @Description Token introspection (RFC 7662). Clients can only introspect their own tokens, anything else is reported inactive
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access or refresh token"
+// @Param token_type_hint formData string false "access_token or refresh_token, ignored"
+// @Success 200 {object} oauth_types.IntrospectionResponse "Token state"
+// @Failure 400 {object} map[string]string "invalid_request"
+// @Failure 401 {object} map[string]string "invalid_client"
+// @Failure 500 {object} map[string]string "server_error"
+// @Router /v1/oauth/introspect [post]
+func (h *oauthHandler) IntrospectHandler(ctx *fiber.Ctx) error {
+	token := ctx.FormValue("token")
+	if token == "" {
+		return tokenError(ctx, oauth_errors.ErrInvalidRequest)
+	}
+
+	client, err := clientCredentials(ctx)
+	if err != nil {
+		return tokenError(ctx, err)
+	}
+
+	response, err := h.oauthService.Introspect(client, token)
+	if err != nil {
+		return tokenError(ctx, err)
+	}
+
+	noStore(ctx)
+	return ctx.Status(fiber.StatusOK).JSON(response)
+}
+
+// @Summary Revoke an OAuth token
+// @Description Token revocation (RFC 7009). Revoking an access or a refresh token ends the whole grant. Unknown tokens are not an error
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "Access or refresh token"
+// @Param token_type_hint formData string false "access_token or refresh_token, ignored"
+// @Success 200 "Token revoked or already invalid"
+// @Failure 400 {object} map[string]string "invalid_request"
+// @Failure 401 {object} map[string]string "invalid_client"
+// @Failure 500 {object} map[string]string "server_error"
+// @Router /v1/oauth/revoke [post]
+func (h *oauthHandler) RevokeHandler(ctx *fiber.Ctx) error {
+	token := ctx.FormValue("token")
+	if token == "" {
+		return tokenError(ctx, oauth_errors.ErrInvalidRequest)
+	}
+
+	client, err := clientCredentials(ctx)
+	if err != nil {
+		return tokenError(ctx, err)
+	}
+
+	if err := h.oauthService.Revoke(client, token); err != nil {
+		return tokenError(ctx, err)
+	}
+
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+// clientCredentials reads HTTP Basic credentials (RFC 6749 2.3.1), falling back to
+// the client_id and client_secret form fields.
+func clientCredentials(ctx *fiber.Ctx) (oauth_types.ClientCredentials, error) {
+	encoded, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Basic ")
+	if !ok {
+		return oauth_types.ClientCredentials{
+			ClientID:     ctx.FormValue("client_id"),
+			ClientSecret: ctx.FormValue("client_secret"),
+		}, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return oauth_types.ClientCredentials{}, oauth_errors.ErrInvalidClient
+	}
+
+	rawID, rawSecret, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return oauth_types.ClientCredentials{}, oauth_errors.ErrInvalidClient
+	}
+
+	// both parts are form-urlencoded before being joined
+	clientID, err := url.QueryUnescape(rawID)
+	if err != nil {
+		return oauth_types.ClientCredentials{}, oauth_errors.ErrInvalidClient
+	}
+	clientSecret, err := url.QueryUnescape(rawSecret)
+	if err != nil {
+		return oauth_types.ClientCredentials{}, oauth_errors.ErrInvalidClient
+	}
+
+	return oauth_types.ClientCredentials{ClientID: clientID, ClientSecret: clientSecret}, nil
+}
+
+// tokenError answers with the error codes of RFC 6749 5.2.
+func tokenError(ctx *fiber.Ctx, err error) error {
+	noStore(ctx)
+
+	status, code := fiber.StatusBadRequest, ""
+	switch err {
+	case oauth_errors.ErrInvalidClient:
+		status, code = fiber.StatusUnauthorized, "invalid_client"
+		ctx.Set(fiber.HeaderWWWAuthenticate, `Basic realm="prodify"`)
+	case oauth_errors.ErrInvalidRequest:
+		code = "invalid_request"
+	case oauth_errors.ErrInvalidGrant:
+		code = "invalid_grant"
+	case oauth_errors.ErrInvalidScope:
+		code = "invalid_scope"
+	case oauth_errors.ErrUnauthorizedClient:
+		code = "unauthorized_client"
+	case oauth_errors.ErrUnsupportedGrantType:
+		code = "unsupported_grant_type"
+	default:
+		logger.Log.Error("failed to handle oauth token request", zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "server_error"})
+	}
+
+	return ctx.Status(status).JSON(fiber.Map{
+		"error":             code,
+		"error_description": err.Error(),
+	})
+}
+
+func noStore(ctx *fiber.Ctx) {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set(fiber.HeaderPragma, "no-cache")
+}
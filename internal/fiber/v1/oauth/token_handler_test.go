@@ -0,0 +1,189 @@
+package oauth_handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_service_mock "github.com/celio001/prodify/internal/oauth/service/mock"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func formRequest(path string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestTokenHandler(t *testing.T) {
+	logger.Init("dev")
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {"code"},
+		"redirect_uri":  {"https://partner.example/callback"},
+		"code_verifier": {"verifier"},
+	}
+	tokenRequest := oauth_types.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         "code",
+		RedirectURI:  "https://partner.example/callback",
+		CodeVerifier: "verifier",
+	}
+
+	tests := []struct {
+		name         string
+		basicAuth    string
+		form         url.Values
+		credentials  oauth_types.ClientCredentials
+		serviceError error
+		expectStatus int
+		expectError  string
+	}{
+		{
+			name:         "http basic client authentication",
+			basicAuth:    base64.StdEncoding.EncodeToString([]byte("client:cs_a%2Bb")),
+			form:         form,
+			credentials:  oauth_types.ClientCredentials{ClientID: "client", ClientSecret: "cs_a+b"},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name: "client credentials in the form",
+			form: func() url.Values {
+				f := url.Values{"client_id": {"client"}, "client_secret": {"cs_secret"}}
+				for k, v := range form {
+					f[k] = v
+				}
+				return f
+			}(),
+			credentials:  oauth_types.ClientCredentials{ClientID: "client", ClientSecret: "cs_secret"},
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "invalid client",
+			form:         form,
+			serviceError: oauth_errors.ErrInvalidClient,
+			expectStatus: fiber.StatusUnauthorized,
+			expectError:  "invalid_client",
+		},
+		{
+			name:         "invalid grant",
+			form:         form,
+			serviceError: oauth_errors.ErrInvalidGrant,
+			expectStatus: fiber.StatusBadRequest,
+			expectError:  "invalid_grant",
+		},
+		{
+			name:         "service error",
+			form:         form,
+			serviceError: errors.New("db error"),
+			expectStatus: fiber.StatusInternalServerError,
+			expectError:  "server_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(oauth_service_mock.MockOAuthService)
+			if tt.serviceError != nil {
+				mockService.On("Token", mock.Anything, tokenRequest).Return(nil, tt.serviceError)
+			} else {
+				mockService.On("Token", tt.credentials, tokenRequest).
+					Return(&oauth_types.TokenResponse{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 3600}, nil)
+			}
+
+			app := setupTestApp(mockService, uuid.New().String())
+
+			req := formRequest("/token", tt.form)
+			if tt.basicAuth != "" {
+				req.Header.Set("Authorization", "Basic "+tt.basicAuth)
+			}
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+
+			var body map[string]interface{}
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+			if tt.expectError != "" {
+				assert.Equal(t, tt.expectError, body["error"])
+			} else {
+				assert.Equal(t, "access", body["access_token"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIntrospectHandler(t *testing.T) {
+	logger.Init("dev")
+
+	credentials := oauth_types.ClientCredentials{ClientID: "client", ClientSecret: "cs_secret"}
+
+	mockService := new(oauth_service_mock.MockOAuthService)
+	mockService.On("Introspect", credentials, "token").
+		Return(&oauth_types.IntrospectionResponse{Active: true, Scope: "user:read"}, nil)
+
+	app := setupTestApp(mockService, uuid.New().String())
+
+	resp, err := app.Test(formRequest("/introspect", url.Values{
+		"token": {"token"}, "client_id": {"client"}, "client_secret": {"cs_secret"},
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, true, body["active"])
+
+	resp, err = app.Test(formRequest("/introspect", url.Values{"client_id": {"client"}}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRevokeHandler(t *testing.T) {
+	logger.Init("dev")
+
+	credentials := oauth_types.ClientCredentials{ClientID: "client", ClientSecret: "cs_secret"}
+
+	tests := []struct {
+		name         string
+		serviceError error
+		expectStatus int
+	}{
+		{name: "revoked", expectStatus: fiber.StatusOK},
+		{name: "invalid client", serviceError: oauth_errors.ErrInvalidClient, expectStatus: fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(oauth_service_mock.MockOAuthService)
+			mockService.On("Revoke", credentials, "token").Return(tt.serviceError)
+
+			app := setupTestApp(mockService, uuid.New().String())
+
+			resp, err := app.Test(formRequest("/revoke", url.Values{
+				"token": {"token"}, "client_id": {"client"}, "client_secret": {"cs_secret"},
+			}))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,495 @@
+package organization_handler
+
+import (
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	organization_service "github.com/celio001/prodify/internal/organization/service"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_request "github.com/celio001/prodify/pkg/request"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type organizationHandler struct {
+	orgService organization_service.OrganizationService
+}
+
+type OrganizationHandler interface {
+	CreateOrganizationHandler(ctx *fiber.Ctx) error
+	ListOrganizationsHandler(ctx *fiber.Ctx) error
+	GetOrganizationHandler(ctx *fiber.Ctx) error
+	UpdateOrganizationHandler(ctx *fiber.Ctx) error
+	ListMembersHandler(ctx *fiber.Ctx) error
+	UpdateMemberRoleHandler(ctx *fiber.Ctx) error
+	RemoveMemberHandler(ctx *fiber.Ctx) error
+	CreateInvitationHandler(ctx *fiber.Ctx) error
+	ListInvitationsHandler(ctx *fiber.Ctx) error
+	RevokeInvitationHandler(ctx *fiber.Ctx) error
+	AcceptInvitationHandler(ctx *fiber.Ctx) error
+}
+
+func NewOrganizationHandler(orgService organization_service.OrganizationService) OrganizationHandler {
+	return &organizationHandler{orgService: orgService}
+}
+
+const maxBodySize = 1 << 20 // 1MB
+var validate = validator.New()
+
+// @Summary Create an organization
+// @Description Creates an organization, the authenticated user becomes its owner
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body organization_types.CreateOrganizationRequest true "Create organization payload"
+// @Success 201 {object} organization_types.Organization "Organization created"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs [post]
+func (h *organizationHandler) CreateOrganizationHandler(ctx *fiber.Ctx) error {
+	var createRequest organization_types.CreateOrganizationRequest
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &createRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(createRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": organization_errors.OrganizationValidateError(err)})
+	}
+
+	org, err := h.orgService.CreateOrganization(userID, createRequest)
+	if err != nil {
+		return organizationError(ctx, err, "failed to create organization")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(org)
+}
+
+// @Summary List organizations
+// @Description Lists the organizations the authenticated user belongs to, with their role in each
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Organizations loaded successfully"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs [get]
+func (h *organizationHandler) ListOrganizationsHandler(ctx *fiber.Ctx) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgs, err := h.orgService.ListOrganizations(userID)
+	if err != nil {
+		return organizationError(ctx, err, "failed to list organizations")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "organizations loaded successfully",
+		"data":    orgs,
+	})
+}
+
+// @Summary Get an organization
+// @Description Gets an organization the authenticated user belongs to
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} organization_types.Organization "Organization"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id} [get]
+func (h *organizationHandler) GetOrganizationHandler(ctx *fiber.Ctx) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	org, err := h.orgService.GetOrganization(userID, orgID)
+	if err != nil {
+		return organizationError(ctx, err, "failed to get organization")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(org)
+}
+
+// @Summary Rename an organization
+// @Description Renames an organization, admins and owners only
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body organization_types.UpdateOrganizationRequest true "Update organization payload"
+// @Success 200 {object} organization_types.Organization "Organization updated"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Role too low"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id} [patch]
+func (h *organizationHandler) UpdateOrganizationHandler(ctx *fiber.Ctx) error {
+	var updateRequest organization_types.UpdateOrganizationRequest
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &updateRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(updateRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": organization_errors.OrganizationValidateError(err)})
+	}
+
+	org, err := h.orgService.UpdateOrganization(userID, orgID, updateRequest)
+	if err != nil {
+		return organizationError(ctx, err, "failed to update organization")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(org)
+}
+
+// @Summary List members
+// @Description Lists the members of an organization and their roles
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} map[string]interface{} "Members loaded successfully"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id}/members [get]
+func (h *organizationHandler) ListMembersHandler(ctx *fiber.Ctx) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	members, err := h.orgService.ListMembers(userID, orgID)
+	if err != nil {
+		return organizationError(ctx, err, "failed to list members")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "members loaded successfully",
+		"data":    members,
+	})
+}
+
+// @Summary Change a member's role
+// @Description Changes the role of a member. Admins manage editors and viewers, only owners grant or remove ownership
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param userId path string true "Member user ID"
+// @Param request body organization_types.UpdateMemberRoleRequest true "New role"
+// @Success 200 {object} map[string]string "Role changed"
+// @Failure 400 {object} map[string]interface{} "Invalid role"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Role too low"
+// @Failure 404 {object} map[string]string "Organization or member not found"
+// @Failure 409 {object} map[string]string "Would leave the organization without an owner"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id}/members/{userId} [patch]
+func (h *organizationHandler) UpdateMemberRoleHandler(ctx *fiber.Ctx) error {
+	var roleRequest organization_types.UpdateMemberRoleRequest
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	memberID, err := uuidvalidator.ValidateUuid(ctx.Params("userId"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrMemberNotFound, "")
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &roleRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(roleRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": organization_errors.MemberRoleValidateError(err)})
+	}
+
+	if err := h.orgService.UpdateMemberRole(userID, orgID, memberID, roleRequest.Role); err != nil {
+		return organizationError(ctx, err, "failed to change member role")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "role changed"})
+}
+
+// @Summary Remove a member
+// @Description Removes a member from an organization. Any member may remove themselves to leave it
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param userId path string true "Member user ID"
+// @Success 200 {object} map[string]string "Member removed"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Role too low"
+// @Failure 404 {object} map[string]string "Organization or member not found"
+// @Failure 409 {object} map[string]string "Would leave the organization without an owner"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id}/members/{userId} [delete]
+func (h *organizationHandler) RemoveMemberHandler(ctx *fiber.Ctx) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	memberID, err := uuidvalidator.ValidateUuid(ctx.Params("userId"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrMemberNotFound, "")
+	}
+
+	if err := h.orgService.RemoveMember(userID, orgID, memberID); err != nil {
+		return organizationError(ctx, err, "failed to remove member")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "member removed"})
+}
+
+// @Summary Invite someone
+// @Description Emails a single-use invitation link with a pre-assigned role, admins and owners only. Inviting the same address again replaces the previous invitation
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param request body organization_types.CreateInvitationRequest true "Invitation payload"
+// @Success 201 {object} organization_types.Invitation "Invitation sent"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Role too low"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 409 {object} map[string]string "Already a member"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id}/invitations [post]
+func (h *organizationHandler) CreateInvitationHandler(ctx *fiber.Ctx) error {
+	var createRequest organization_types.CreateInvitationRequest
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &createRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(createRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": organization_errors.InvitationValidateError(err)})
+	}
+
+	invitation, err := h.orgService.CreateInvitation(userID, orgID, createRequest)
+	if err != nil {
+		return organizationError(ctx, err, "failed to send invitation")
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(invitation)
+}
+
+// @Summary List invitations
+// @Description Lists the invitations of an organization with their status: pending, accepted, expired or revoked
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} map[string]interface{} "Invitations loaded successfully"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Role too low"
+// @Failure 404 {object} map[string]string "Organization not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id}/invitations [get]
+func (h *organizationHandler) ListInvitationsHandler(ctx *fiber.Ctx) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	invitations, err := h.orgService.ListInvitations(userID, orgID)
+	if err != nil {
+		return organizationError(ctx, err, "failed to list invitations")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "invitations loaded successfully",
+		"data":    invitations,
+	})
+}
+
+// @Summary Revoke an invitation
+// @Description Revokes a pending invitation, its link stops working immediately
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Organization ID"
+// @Param invitationId path string true "Invitation ID"
+// @Success 200 {object} map[string]string "Invitation revoked"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Role too low"
+// @Failure 404 {object} map[string]string "Organization or pending invitation not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/{id}/invitations/{invitationId} [delete]
+func (h *organizationHandler) RevokeInvitationHandler(ctx *fiber.Ctx) error {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	orgID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrOrganizationNotFound, "")
+	}
+
+	invitationID, err := uuidvalidator.ValidateUuid(ctx.Params("invitationId"))
+	if err != nil {
+		return organizationError(ctx, organization_errors.ErrInvitationNotFound, "")
+	}
+
+	if err := h.orgService.RevokeInvitation(userID, orgID, invitationID); err != nil {
+		return organizationError(ctx, err, "failed to revoke invitation")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "invitation revoked"})
+}
+
+// @Summary Accept an invitation
+// @Description Joins the organization with the role from the invitation. The user must be signed in with the invited email address
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body organization_types.AcceptInvitationRequest true "Token from the invitation link"
+// @Success 200 {object} organization_types.Organization "Invitation accepted"
+// @Failure 400 {object} map[string]string "Invalid, used or expired invitation"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Invitation sent to another address"
+// @Failure 409 {object} map[string]string "Already a member"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/orgs/invitations/accept [post]
+func (h *organizationHandler) AcceptInvitationHandler(ctx *fiber.Ctx) error {
+	var acceptRequest organization_types.AcceptInvitationRequest
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	if err := pkg_request.LimitBodyJSON(ctx, maxBodySize, &acceptRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(acceptRequest); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": organization_errors.ErrInvalidInvitation.Error()})
+	}
+
+	org, err := h.orgService.AcceptInvitation(userID, acceptRequest.Token)
+	if err != nil {
+		return organizationError(ctx, err, "failed to accept invitation")
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(org)
+}
+
+// currentUser reads the user AuthMiddleware stored.
+func currentUser(ctx *fiber.Ctx) (uuid.UUID, error) {
+	userID, _ := ctx.Locals(middleware.UserIDKey).(string)
+	return uuidvalidator.ValidateUuid(userID)
+}
+
+func organizationError(ctx *fiber.Ctx, err error, message string) error {
+	switch err {
+	case organization_errors.ErrOrganizationNotFound, organization_errors.ErrMemberNotFound, organization_errors.ErrInvitationNotFound:
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case organization_errors.ErrInsufficientRole, organization_errors.ErrInvitationEmailMismatch:
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case organization_errors.ErrLastOwner, organization_errors.ErrAlreadyMember:
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case organization_errors.ErrInvalidInvitation:
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case user_errors.ErrUserNotFound:
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	default:
+		logger.Log.Error(message, zap.String("error", err.Error()))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
@@ -0,0 +1,185 @@
+package organization_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	organization_service_mock "github.com/celio001/prodify/internal/organization/service/mock"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestApp(service *organization_service_mock.MockOrganizationService, userID string) *fiber.App {
+	app := fiber.New()
+
+	authMiddleware := func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID)
+		return c.Next()
+	}
+
+	RegisterRouter(app.Group("/orgs"), service, authMiddleware)
+	return app
+}
+
+func TestCreateOrganizationHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		body         string
+		callService  bool
+		serviceError error
+		expectStatus int
+	}{
+		{name: "success", body: `{"name":"Acme"}`, callService: true, expectStatus: fiber.StatusCreated},
+		{name: "missing name", body: `{}`, expectStatus: fiber.StatusBadRequest},
+		{name: "service error", body: `{"name":"Acme"}`, callService: true, serviceError: errors.New("db error"), expectStatus: fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(organization_service_mock.MockOrganizationService)
+			if tt.serviceError != nil {
+				service.On("CreateOrganization", userID, mock.Anything).Return(nil, tt.serviceError)
+			} else {
+				service.On("CreateOrganization", userID, mock.Anything).Return(&organization_types.Organization{PublicID: uuid.NewString(), Name: "Acme", Role: "owner"}, nil)
+			}
+
+			app := setupTestApp(service, userID.String())
+
+			req := httptest.NewRequest(http.MethodPost, "/orgs", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			if tt.callService {
+				service.AssertCalled(t, "CreateOrganization", userID, organization_types.CreateOrganizationRequest{Name: "Acme"})
+			} else {
+				service.AssertNotCalled(t, "CreateOrganization", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUpdateMemberRoleHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	orgID := uuid.New()
+	memberID := uuid.New()
+
+	tests := []struct {
+		name         string
+		path         string
+		body         string
+		serviceError error
+		expectStatus int
+	}{
+		{name: "success", path: "/orgs/" + orgID.String() + "/members/" + memberID.String(), body: `{"role":"editor"}`, expectStatus: fiber.StatusOK},
+		{name: "unknown role", path: "/orgs/" + orgID.String() + "/members/" + memberID.String(), body: `{"role":"root"}`, expectStatus: fiber.StatusBadRequest},
+		{name: "role too low", path: "/orgs/" + orgID.String() + "/members/" + memberID.String(), body: `{"role":"editor"}`, serviceError: organization_errors.ErrInsufficientRole, expectStatus: fiber.StatusForbidden},
+		{name: "last owner", path: "/orgs/" + orgID.String() + "/members/" + memberID.String(), body: `{"role":"admin"}`, serviceError: organization_errors.ErrLastOwner, expectStatus: fiber.StatusConflict},
+		{name: "not a member", path: "/orgs/" + orgID.String() + "/members/" + memberID.String(), body: `{"role":"editor"}`, serviceError: organization_errors.ErrOrganizationNotFound, expectStatus: fiber.StatusNotFound},
+		{name: "invalid organization id", path: "/orgs/acme/members/" + memberID.String(), body: `{"role":"editor"}`, expectStatus: fiber.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(organization_service_mock.MockOrganizationService)
+			service.On("UpdateMemberRole", userID, orgID, memberID, mock.Anything).Return(tt.serviceError)
+
+			app := setupTestApp(service, userID.String())
+
+			req := httptest.NewRequest(http.MethodPatch, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestInvitationHandlers(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	orgID := uuid.New()
+
+	t.Run("invite", func(t *testing.T) {
+		service := new(organization_service_mock.MockOrganizationService)
+		service.On("CreateInvitation", userID, orgID, organization_types.CreateInvitationRequest{Email: "bob@example.com", Role: "viewer"}).
+			Return(&organization_types.Invitation{PublicID: uuid.NewString(), Email: "bob@example.com", Role: "viewer", Status: "pending", TokenHash: "secret"}, nil)
+
+		app := setupTestApp(service, userID.String())
+
+		req := httptest.NewRequest(http.MethodPost, "/orgs/"+orgID.String()+"/invitations", strings.NewReader(`{"email":"bob@example.com","role":"viewer"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "pending", body["status"])
+		assert.NotContains(t, body, "tokenHash")
+	})
+
+	t.Run("owner can't be invited", func(t *testing.T) {
+		service := new(organization_service_mock.MockOrganizationService)
+		app := setupTestApp(service, userID.String())
+
+		req := httptest.NewRequest(http.MethodPost, "/orgs/"+orgID.String()+"/invitations", strings.NewReader(`{"email":"bob@example.com","role":"owner"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		service.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	acceptTests := []struct {
+		name         string
+		serviceError error
+		expectStatus int
+	}{
+		{name: "accept", expectStatus: fiber.StatusOK},
+		{name: "expired", serviceError: organization_errors.ErrInvalidInvitation, expectStatus: fiber.StatusBadRequest},
+		{name: "other address", serviceError: organization_errors.ErrInvitationEmailMismatch, expectStatus: fiber.StatusForbidden},
+		{name: "already a member", serviceError: organization_errors.ErrAlreadyMember, expectStatus: fiber.StatusConflict},
+	}
+
+	for _, tt := range acceptTests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(organization_service_mock.MockOrganizationService)
+			if tt.serviceError != nil {
+				service.On("AcceptInvitation", userID, "raw-token").Return(nil, tt.serviceError)
+			} else {
+				service.On("AcceptInvitation", userID, "raw-token").Return(&organization_types.Organization{PublicID: orgID.String(), Role: "viewer"}, nil)
+			}
+
+			app := setupTestApp(service, userID.String())
+
+			req := httptest.NewRequest(http.MethodPost, "/orgs/invitations/accept", strings.NewReader(`{"token":"raw-token"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+		})
+	}
+}
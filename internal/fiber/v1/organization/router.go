@@ -0,0 +1,29 @@
+package organization_handler
+
+import (
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	organization_service "github.com/celio001/prodify/internal/organization/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HandlerPath = "/orgs"
+)
+
+func RegisterRouter(router fiber.Router, orgService organization_service.OrganizationService, authMiddleware fiber.Handler) {
+
+	handler := NewOrganizationHandler(orgService)
+	session := middleware.RequireSession()
+
+	router.Post("/", authMiddleware, session, handler.CreateOrganizationHandler)
+	router.Get("/", authMiddleware, session, handler.ListOrganizationsHandler)
+	router.Post("/invitations/accept", authMiddleware, session, handler.AcceptInvitationHandler)
+	router.Get("/:id", authMiddleware, session, handler.GetOrganizationHandler)
+	router.Patch("/:id", authMiddleware, session, handler.UpdateOrganizationHandler)
+	router.Get("/:id/members", authMiddleware, session, handler.ListMembersHandler)
+	router.Patch("/:id/members/:userId", authMiddleware, session, handler.UpdateMemberRoleHandler)
+	router.Delete("/:id/members/:userId", authMiddleware, session, handler.RemoveMemberHandler)
+	router.Post("/:id/invitations", authMiddleware, session, handler.CreateInvitationHandler)
+	router.Get("/:id/invitations", authMiddleware, session, handler.ListInvitationsHandler)
+	router.Delete("/:id/invitations/:invitationId", authMiddleware, session, handler.RevokeInvitationHandler)
+}
@@ -1,32 +1,296 @@
 package product
 
 import (
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/locale"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_request "github.com/celio001/prodify/pkg/request"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
 	"github.com/celio001/prodify/product"
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
+const maxBodySize = 1 << 20 // 1MB
+var validate = validator.New()
+
+// PreferencesReader gives the currency prices of a user's products are in,
+// user_service.UserService is one.
+type PreferencesReader interface {
+	GetPreferences(publicID uuid.UUID) (*user_types.Preferences, error)
+}
+
 type ProductHandler struct {
 	productRepository product.Repository
+	preferences       PreferencesReader
+}
+
+type ProductResponse struct {
+	product.Product
+	// Price in the owner's currency, written for the locale asked for in Accept-Language
+	FormattedPrice string
 }
 
-func NewProductHandler(productRepository product.Repository) *ProductHandler {
+func NewProductHandler(productRepository product.Repository, preferences PreferencesReader) *ProductHandler {
 	return &ProductHandler{
 		productRepository: productRepository,
+		preferences:       preferences,
 	}
 }
 
+// @Summary List products
+// @Description Lists the products of the organization selected with X-Org-ID, any member can read them
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param page query int false "Page, starting at 1"
+// @Param limit query int false "Products per page"
+// @Param sort query string false "Creation order, asc or desc"
+// @Success 200 {object} map[string]interface{} "Products loaded successfully"
+// @Failure 400 {object} map[string]string "Missing or invalid organization"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Not a member of the organization"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/product [get]
+func (h *ProductHandler) ListProducts(c *fiber.Ctx) error {
+	orgID := c.Locals(middleware.OrgIDKey).(uuid.UUID)
+
+	products, err := h.productRepository.FindAll(c.Context(), orgID, c.QueryInt("page"), c.QueryInt("limit"), c.Query("sort"))
+	if err != nil {
+		logger.Log.Error("failed to list products", zap.String("error", err.Error()))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list products"})
+	}
+
+	p := newPriceFormatter(h.preferences, c.Get(fiber.HeaderAcceptLanguage))
+	data := make([]ProductResponse, 0, len(products))
+	for _, prod := range products {
+		data = append(data, p.response(prod))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "products loaded successfully",
+		"data":    data,
+	})
+}
+
+// @Summary Get a product
+// @Description Gets a product of the organization selected with X-Org-ID
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Product ID"
+// @Success 200 {object} ProductResponse "Product"
+// @Failure 400 {object} map[string]string "Missing or invalid organization"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Not a member of the organization"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/product/{id} [get]
 func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
+	orgID := c.Locals(middleware.OrgIDKey).(uuid.UUID)
+
+	prod, err := h.productRepository.FindByID(c.Context(), orgID, c.Params("id"))
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(newPriceFormatter(h.preferences, c.Get(fiber.HeaderAcceptLanguage)).response(*prod))
+}
+
+// @Summary Create a product
+// @Description Creates a product in the organization selected with X-Org-ID, editors and above only
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param request body product.CreateProductRequest true "Create product payload"
+// @Success 201 {object} ProductResponse "Product created"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Not a member of the organization or role too low"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/product [post]
+func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
+	var createRequest product.CreateProductRequest
+
+	orgID := c.Locals(middleware.OrgIDKey).(uuid.UUID)
+
+	userID, err := uuidvalidator.ValidateUuid(c.Locals(middleware.UserIDKey).(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := pkg_request.LimitBodyJSON(c, maxBodySize, &createRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(createRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": product.ProductValidateError(err)})
+	}
+
+	id := uuid.New()
+	err = h.productRepository.CreateProduct(c.Context(), orgID, id, createRequest.Name, createRequest.Description, createRequest.Price, createRequest.Stock, userID)
+	if err != nil {
+		logger.Log.Error("failed to create product", zap.String("error", err.Error()))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create product"})
+	}
+
+	prod, err := h.productRepository.FindByID(c.Context(), orgID, id.String())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	auditProduct(c, audit_types.ActionProductCreated, orgID, nil, prod)
+
+	return c.Status(fiber.StatusCreated).JSON(newPriceFormatter(h.preferences, c.Get(fiber.HeaderAcceptLanguage)).response(*prod))
+}
+
+// @Summary Update a product
+// @Description Replaces a product of the organization selected with X-Org-ID, editors and above only
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Product ID"
+// @Param request body product.UpdateProductRequest true "Update product payload"
+// @Success 200 {object} ProductResponse "Product updated"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Not a member of the organization or role too low"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/product/{id} [put]
+func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
+	var updateRequest product.UpdateProductRequest
 
-	id := c.Query("id")
+	orgID := c.Locals(middleware.OrgIDKey).(uuid.UUID)
 
-	prod, err := h.productRepository.FindByID(c.Context(), id)
+	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		if err == product.ErrProductNotFound {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": product.ErrProductNotFound.Error()})
-		} else {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": product.ErrProductNotFound.Error()})
+	}
+
+	if err := pkg_request.LimitBodyJSON(c, maxBodySize, &updateRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(updateRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": product.ProductValidateError(err)})
+	}
+
+	before, err := h.productRepository.FindByID(c.Context(), orgID, id.String())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	prod, err := h.productRepository.UpdateProduct(c.Context(), orgID, &product.Product{
+		ID:          id,
+		Name:        updateRequest.Name,
+		Description: updateRequest.Description,
+		Price:       updateRequest.Price,
+		Stock:       updateRequest.Stock,
+		IsActive:    updateRequest.IsActive,
+	})
+	if err != nil {
+		return productError(c, err)
+	}
+
+	auditProduct(c, audit_types.ActionProductUpdated, orgID, before, prod)
+
+	return c.Status(fiber.StatusOK).JSON(newPriceFormatter(h.preferences, c.Get(fiber.HeaderAcceptLanguage)).response(*prod))
+}
+
+// @Summary Delete a product
+// @Description Deletes a product of the organization selected with X-Org-ID, editors and above only
+// @Tags products
+// @Produce json
+// @Security BearerAuth
+// @Param X-Org-ID header string true "Organization ID"
+// @Param id path string true "Product ID"
+// @Success 200 {object} map[string]string "Product deleted"
+// @Failure 400 {object} map[string]string "Missing or invalid organization"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Not a member of the organization or role too low"
+// @Failure 404 {object} map[string]string "Product not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/product/{id} [delete]
+func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
+	orgID := c.Locals(middleware.OrgIDKey).(uuid.UUID)
+
+	before, err := h.productRepository.FindByID(c.Context(), orgID, c.Params("id"))
+	if err != nil {
+		return productError(c, err)
+	}
+
+	if err := h.productRepository.DeleteProduct(c.Context(), orgID, c.Params("id")); err != nil {
+		return productError(c, err)
+	}
+
+	auditProduct(c, audit_types.ActionProductDeleted, orgID, before, nil)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "product deleted"})
+}
+
+// auditProduct records a change to a product, before is nil for a new product and after
+// for a deleted one.
+func auditProduct(c *fiber.Ctx, action string, orgID uuid.UUID, before, after *product.Product) {
+	target := after
+	if target == nil {
+		target = before
+	}
+
+	middleware.RecordAudit(c, audit_types.Event{
+		Action:     action,
+		TargetType: audit_types.TargetProduct,
+		TargetID:   target.ID.String(),
+		Changes:    audit_types.Diff(before, after),
+		Metadata:   map[string]string{"org_id": orgID.String()},
+	})
+}
+
+func productError(c *fiber.Ctx, err error) error {
+	if err == product.ErrProductNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	logger.Log.Error("product query failed", zap.String("error", err.Error()))
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+}
+
+// priceFormatter formats prices for one request, looking up each owner's currency once.
+type priceFormatter struct {
+	preferences PreferencesReader
+	locale      string
+	currencies  map[uuid.UUID]string
+}
+
+func newPriceFormatter(preferences PreferencesReader, acceptLanguage string) *priceFormatter {
+	return &priceFormatter{
+		preferences: preferences,
+		locale:      locale.Match(acceptLanguage),
+		currencies:  map[uuid.UUID]string{},
+	}
+}
+
+func (p *priceFormatter) response(prod product.Product) ProductResponse {
+	currency, ok := p.currencies[prod.UserID]
+	if !ok {
+		// the owner may be gone, their products are still shown in the default currency
+		if owner, err := p.preferences.GetPreferences(prod.UserID); err == nil {
+			currency = owner.Currency
 		}
+		p.currencies[prod.UserID] = currency
 	}
 
-	return c.Status(fiber.StatusOK).JSON(prod)
+	return ProductResponse{
+		Product:        prod,
+		FormattedPrice: locale.FormatMoney(p.locale, currency, prod.Price),
+	}
 }
@@ -0,0 +1,272 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	audit_service_mock "github.com/celio001/prodify/internal/audit/service/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_service_mock "github.com/celio001/prodify/internal/user/service/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/product"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// productRepository keeps products in memory, per organization like the real one.
+type productRepository struct {
+	product.Repository
+	products []product.Product
+}
+
+func (r *productRepository) FindByID(ctx context.Context, orgID uuid.UUID, id string) (*product.Product, error) {
+	for _, p := range r.products {
+		if p.OrgID == orgID && p.ID.String() == id {
+			return &p, nil
+		}
+	}
+	return nil, product.ErrProductNotFound
+}
+
+func (r *productRepository) FindAll(ctx context.Context, orgID uuid.UUID, page int, limit int, sort string) ([]product.Product, error) {
+	var products []product.Product
+	for _, p := range r.products {
+		if p.OrgID == orgID {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+func (r *productRepository) CreateProduct(ctx context.Context, orgID uuid.UUID, id uuid.UUID, name string, description string, price float64, stock int, userID uuid.UUID) error {
+	r.products = append(r.products, product.Product{ID: id, Name: name, Description: description, Price: price, Stock: stock, UserID: userID, OrgID: orgID})
+	return nil
+}
+
+func (r *productRepository) DeleteProduct(ctx context.Context, orgID uuid.UUID, id string) error {
+	for i, p := range r.products {
+		if p.OrgID == orgID && p.ID.String() == id {
+			r.products = append(r.products[:i], r.products[i+1:]...)
+			return nil
+		}
+	}
+	return product.ErrProductNotFound
+}
+
+// memberships gives the signed in user a role in some organizations.
+type memberships map[uuid.UUID]string
+
+func (m memberships) GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error) {
+	role, ok := m[orgPublicID]
+	if !ok {
+		return "", organization_errors.ErrNotMember
+	}
+	return role, nil
+}
+
+func setupTestApp(repo product.Repository, preferences PreferencesReader, orgs memberships, userID string) *fiber.App {
+	app := fiber.New()
+
+	authMiddleware := func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserIDKey, userID)
+		c.Locals(middleware.AuthMethodKey, middleware.AuthMethodJWT)
+		return c.Next()
+	}
+
+	RegisterRouter(app.Group("/v1/product"), repo, preferences, orgs, authMiddleware)
+	return app
+}
+
+func TestGetProductFormattedPrice(t *testing.T) {
+	logger.Init("dev")
+
+	owner := uuid.New()
+	org := uuid.New()
+	prod := product.Product{ID: uuid.New(), Name: "Mouse", Price: 1234.5, UserID: owner, OrgID: org}
+
+	get := func(t *testing.T, preferences PreferencesReader, acceptLanguage string) (int, map[string]any) {
+		app := setupTestApp(&productRepository{products: []product.Product{prod}}, preferences, memberships{org: "viewer"}, owner.String())
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/product/"+prod.ID.String(), nil)
+		req.Header.Set(middleware.OrgIDHeader, org.String())
+		req.Header.Set(fiber.HeaderAcceptLanguage, acceptLanguage)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	t.Run("owner currency in the reader's locale", func(t *testing.T) {
+		preferences := new(user_service_mock.MockUserService)
+		preferences.On("GetPreferences", owner).Return(&user_types.Preferences{Currency: "BRL"}, nil)
+
+		status, body := get(t, preferences, "pt-BR,pt;q=0.9")
+
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "Mouse", body["Name"])
+		assert.Equal(t, "R$ 1.234,50", body["FormattedPrice"])
+
+		_, body = get(t, preferences, "en-US")
+		assert.Equal(t, "R$ 1,234.50", body["FormattedPrice"])
+	})
+
+	t.Run("owner gone", func(t *testing.T) {
+		preferences := new(user_service_mock.MockUserService)
+		preferences.On("GetPreferences", owner).Return(nil, user_errors.ErrUserNotFound)
+
+		status, body := get(t, preferences, "")
+
+		assert.Equal(t, fiber.StatusOK, status)
+		assert.Equal(t, "$ 1,234.50", body["FormattedPrice"])
+	})
+}
+
+func TestProductTenantIsolation(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	ownOrg := uuid.New()
+	otherOrg := uuid.New()
+	viewerOrg := uuid.New()
+
+	ownProduct := product.Product{ID: uuid.New(), Name: "Mouse", OrgID: ownOrg}
+	otherProduct := product.Product{ID: uuid.New(), Name: "Keyboard", OrgID: otherOrg}
+	viewerProduct := product.Product{ID: uuid.New(), Name: "Monitor", OrgID: viewerOrg}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		org          string
+		body         string
+		expectStatus int
+	}{
+		{name: "read own product", method: http.MethodGet, path: "/" + ownProduct.ID.String(), org: ownOrg.String(), expectStatus: fiber.StatusOK},
+		{name: "product of another organization is not found", method: http.MethodGet, path: "/" + otherProduct.ID.String(), org: ownOrg.String(), expectStatus: fiber.StatusNotFound},
+		{name: "organization the user is not in", method: http.MethodGet, path: "/" + otherProduct.ID.String(), org: otherOrg.String(), expectStatus: fiber.StatusForbidden},
+		{name: "delete through another organization", method: http.MethodDelete, path: "/" + otherProduct.ID.String(), org: ownOrg.String(), expectStatus: fiber.StatusNotFound},
+		{name: "no organization selected", method: http.MethodGet, path: "/", expectStatus: fiber.StatusBadRequest},
+		{name: "viewer reads", method: http.MethodGet, path: "/" + viewerProduct.ID.String(), org: viewerOrg.String(), expectStatus: fiber.StatusOK},
+		{name: "viewer can't create", method: http.MethodPost, path: "/", org: viewerOrg.String(), body: `{"name":"Webcam","price":10,"stock":1}`, expectStatus: fiber.StatusForbidden},
+		{name: "viewer can't delete", method: http.MethodDelete, path: "/" + viewerProduct.ID.String(), org: viewerOrg.String(), expectStatus: fiber.StatusForbidden},
+		{name: "editor creates", method: http.MethodPost, path: "/", org: ownOrg.String(), body: `{"name":"Webcam","price":10,"stock":1}`, expectStatus: fiber.StatusCreated},
+		{name: "invalid product", method: http.MethodPost, path: "/", org: ownOrg.String(), body: `{"name":"","price":-1}`, expectStatus: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &productRepository{products: []product.Product{ownProduct, otherProduct, viewerProduct}}
+
+			preferences := new(user_service_mock.MockUserService)
+			preferences.On("GetPreferences", mock.Anything).Return(nil, user_errors.ErrUserNotFound)
+
+			app := setupTestApp(repo, preferences, memberships{ownOrg: "editor", viewerOrg: "viewer"}, userID.String())
+
+			req := httptest.NewRequest(tt.method, "/v1/product"+tt.path, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.org != "" {
+				req.Header.Set(middleware.OrgIDHeader, tt.org)
+			}
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			assert.Contains(t, repo.products, otherProduct)
+		})
+	}
+}
+
+func TestListProductsOnlyShowsSelectedOrganization(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	org := uuid.New()
+
+	repo := &productRepository{products: []product.Product{
+		{ID: uuid.New(), Name: "Mouse", OrgID: org, UserID: userID},
+		{ID: uuid.New(), Name: "Keyboard", OrgID: uuid.New(), UserID: userID},
+	}}
+
+	preferences := new(user_service_mock.MockUserService)
+	preferences.On("GetPreferences", userID).Return(&user_types.Preferences{Currency: "USD"}, nil)
+
+	app := setupTestApp(repo, preferences, memberships{org: "viewer"}, userID.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/product", nil)
+	req.Header.Set(middleware.OrgIDHeader, org.String())
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data []ProductResponse `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, "Mouse", body.Data[0].Name)
+
+	// one lookup for the owner, however many products they have
+	preferences.AssertNumberOfCalls(t, "GetPreferences", 1)
+}
+
+func TestProductChangesAreAudited(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	org := uuid.New()
+	existing := product.Product{ID: uuid.New(), Name: "Mouse", Price: 10, OrgID: org}
+
+	repo := &productRepository{products: []product.Product{existing}}
+	preferences := new(user_service_mock.MockUserService)
+	preferences.On("GetPreferences", mock.Anything).Return(nil, user_errors.ErrUserNotFound)
+
+	recorder := new(audit_service_mock.MockRecorder)
+	app := fiber.New()
+	app.Use(middleware.Audit(recorder))
+	authMiddleware := func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserIDKey, userID.String())
+		c.Locals(middleware.AuthMethodKey, middleware.AuthMethodJWT)
+		return c.Next()
+	}
+	RegisterRouter(app.Group("/v1/product"), repo, preferences, memberships{org: "editor"}, authMiddleware)
+
+	send := func(method, path, body string) int {
+		req := httptest.NewRequest(method, "/v1/product"+path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(middleware.OrgIDHeader, org.String())
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusCreated, send(http.MethodPost, "/", `{"name":"Webcam","price":25,"stock":3}`))
+	assert.Equal(t, fiber.StatusOK, send(http.MethodDelete, "/"+existing.ID.String(), ""))
+	assert.Equal(t, fiber.StatusNotFound, send(http.MethodDelete, "/"+existing.ID.String(), ""))
+
+	if assert.Len(t, recorder.Events, 2) {
+		created := recorder.Events[0]
+		assert.Equal(t, audit_types.ActionProductCreated, created.Action)
+		assert.Equal(t, userID.String(), created.ActorID)
+		assert.Equal(t, org.String(), created.Metadata["org_id"])
+		assert.Equal(t, audit_types.Change{After: "Webcam"}, created.Changes["Name"])
+
+		deleted := recorder.Events[1]
+		assert.Equal(t, audit_types.ActionProductDeleted, deleted.Action)
+		assert.Equal(t, existing.ID.String(), deleted.TargetID)
+		assert.Equal(t, audit_types.Change{Before: "Mouse"}, deleted.Changes["Name"])
+	}
+}
@@ -1,6 +1,9 @@
 package product
 
 import (
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
 	"github.com/celio001/prodify/product"
 	"github.com/gofiber/fiber/v2"
 )
@@ -9,8 +12,16 @@ const (
 	HandlerPath = "/product"
 )
 
-func RegisterRouter(router fiber.Router, productRepository product.Repository) {
+func RegisterRouter(router fiber.Router, productRepository product.Repository, preferences PreferencesReader, orgs middleware.OrgMembershipChecker, authMiddleware fiber.Handler) {
 
-	handler := NewProductHandler(productRepository)
-	router.Get("", handler.GetProduct)
+	handler := NewProductHandler(productRepository, preferences)
+
+	read := []fiber.Handler{authMiddleware, middleware.RequireScope(apikey_types.ScopeProductRead), middleware.RequireOrg(orgs), middleware.RequireOrgRole(organization_types.RoleViewer)}
+	write := []fiber.Handler{authMiddleware, middleware.RequireScope(apikey_types.ScopeProductWrite), middleware.RequireOrg(orgs), middleware.RequireOrgRole(organization_types.RoleEditor)}
+
+	router.Get("/", append(read, handler.ListProducts)...)
+	router.Get("/:id", append(read, handler.GetProduct)...)
+	router.Post("/", append(write, handler.CreateProduct)...)
+	router.Put("/:id", append(write, handler.UpdateProduct)...)
+	router.Delete("/:id", append(write, handler.DeleteProduct)...)
 }
@@ -1,10 +1,22 @@
 package v1
 
 import (
+	apikey_service "github.com/celio001/prodify/internal/apikey/service"
+	audit_service "github.com/celio001/prodify/internal/audit/service"
+	auth_oidc "github.com/celio001/prodify/internal/auth/oidc"
 	auth_service "github.com/celio001/prodify/internal/auth/service"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	admin_handler "github.com/celio001/prodify/internal/fiber/v1/admin"
+	apikey_handler "github.com/celio001/prodify/internal/fiber/v1/apikey"
+	audit_handler "github.com/celio001/prodify/internal/fiber/v1/audit"
 	auth_handler "github.com/celio001/prodify/internal/fiber/v1/auth"
+	oauth_handler "github.com/celio001/prodify/internal/fiber/v1/oauth"
+	organization_handler "github.com/celio001/prodify/internal/fiber/v1/organization"
 	product_handler "github.com/celio001/prodify/internal/fiber/v1/product"
+	session_handler "github.com/celio001/prodify/internal/fiber/v1/session"
 	user_handler "github.com/celio001/prodify/internal/fiber/v1/user"
+	oauth_service "github.com/celio001/prodify/internal/oauth/service"
+	organization_service "github.com/celio001/prodify/internal/organization/service"
 	user_service "github.com/celio001/prodify/internal/user/service"
 	product_repo "github.com/celio001/prodify/product"
 	"github.com/gofiber/fiber/v2"
@@ -14,14 +26,28 @@ const (
 	HandlerPath = "/v1"
 )
 
-func RegisterRouter(router fiber.Router, productRepository product_repo.Repository, authSvc auth_service.AuthService, userSvc user_service.UserService) {
+func RegisterRouter(router fiber.Router, productRepository product_repo.Repository, authSvc auth_service.AuthService, userSvc user_service.UserService, apiKeySvc apikey_service.APIKeyService, oauthSvc oauth_service.OAuthService, orgSvc organization_service.OrganizationService, providers *auth_oidc.Registry, auditSvc audit_service.AuditService) {
 	productRouter := router.Group(product_handler.HandlerPath)
 	authRouter := router.Group(auth_handler.HandlerPath)
 	userRouter := router.Group(user_handler.HandlerPath)
+	apiKeyRouter := router.Group(apikey_handler.HandlerPath)
+	sessionRouter := router.Group(session_handler.HandlerPath)
+	oauthRouter := router.Group(oauth_handler.HandlerPath)
+	adminRouter := router.Group(admin_handler.HandlerPath)
+	orgRouter := router.Group(organization_handler.HandlerPath)
+	auditRouter := router.Group(audit_handler.HandlerPath)
 
-	auth_handler.RegisterRouter(authRouter, authSvc)
-	user_handler.RegisterRouter(userRouter, userSvc)
+	authMiddleware := middleware.AuthMiddleware(authSvc, apiKeySvc, oauthSvc)
+
+	auth_handler.RegisterRouter(authRouter, authSvc, authMiddleware, providers)
+	user_handler.RegisterRouter(userRouter, userSvc, authSvc, authMiddleware)
+	apikey_handler.RegisterRouter(apiKeyRouter, apiKeySvc, authMiddleware)
+	session_handler.RegisterRouter(sessionRouter, authSvc, authMiddleware)
+	oauth_handler.RegisterRouter(oauthRouter, oauthSvc, authMiddleware)
+	admin_handler.RegisterRouter(adminRouter, userSvc, authSvc, authMiddleware)
+	organization_handler.RegisterRouter(orgRouter, orgSvc, authMiddleware)
+	audit_handler.RegisterRouter(auditRouter, auditSvc, authSvc, authMiddleware)
 	
-	product_handler.RegisterRouter(productRouter, productRepository)
+	product_handler.RegisterRouter(productRouter, productRepository, userSvc, orgSvc, authMiddleware)
 	
 }
@@ -0,0 +1,18 @@
+package session_handler
+
+import (
+	auth_service "github.com/celio001/prodify/internal/auth/service"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HandlerPath = "/user/sessions"
+)
+
+func RegisterRouter(router fiber.Router, authService auth_service.AuthService, authMiddleware fiber.Handler) {
+
+	handler := NewSessionHandler(authService)
+	router.Get("/", authMiddleware, middleware.RequireSession(), handler.ListSessionsHandler)
+	router.Delete("/:id", authMiddleware, middleware.RequireSession(), handler.RevokeSessionHandler)
+}
@@ -0,0 +1,117 @@
+package session_handler
+
+import (
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_service "github.com/celio001/prodify/internal/auth/service"
+	"github.com/celio001/prodify/internal/fiber/middleware"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	"github.com/celio001/prodify/pkg/logger"
+	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type sessionHandler struct {
+	authService auth_service.AuthService
+}
+
+type SessionHandler interface {
+	ListSessionsHandler(ctx *fiber.Ctx) error
+	RevokeSessionHandler(ctx *fiber.Ctx) error
+}
+
+func NewSessionHandler(authService auth_service.AuthService) SessionHandler {
+	return &sessionHandler{authService: authService}
+}
+
+// @Summary List active sessions
+// @Description Lists the devices the authenticated user is signed in on. The session making the request is flagged as current
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Sessions loaded successfully"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/sessions [get]
+func (h *sessionHandler) ListSessionsHandler(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	currentSessionID, _ := ctx.Locals(middleware.SessionIDKey).(string)
+
+	sessions, err := h.authService.ListSessions(id, currentSessionID)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to list sessions", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list sessions"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "sessions loaded successfully",
+		"data":    sessions,
+	})
+}
+
+// @Summary Revoke a session
+// @Description Signs one of the authenticated user's devices out. Its refresh token stops working immediately and its access token on the next request
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]string "Session revoked"
+// @Failure 400 {object} map[string]string "Invalid user or session ID"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key"
+// @Failure 404 {object} map[string]string "Session not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/sessions/{id} [delete]
+func (h *sessionHandler) RevokeSessionHandler(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(middleware.UserIDKey)
+	if userID == nil {
+		return ctx.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	sessionID, err := uuidvalidator.ValidateUuid(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_SESSION_ID"})
+	}
+
+	if err := h.authService.RevokeSession(id, sessionID); err != nil {
+		switch err {
+		case auth_errors.ErrSessionNotFound:
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case user_errors.ErrUserNotFound:
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			logger.Log.Error("failed to revoke session", zap.String("error", err.Error()))
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke session"})
+		}
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "session revoked"})
+}
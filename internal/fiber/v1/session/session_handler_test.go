@@ -0,0 +1,122 @@
+package session_handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_mock "github.com/celio001/prodify/internal/auth/service/mock"
+	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestApp(service *auth_mock.MockAuthService, userID string, sessionID string) *fiber.App {
+	app := fiber.New()
+	handler := NewSessionHandler(service)
+
+	withUser := func(next fiber.Handler) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID)
+			c.Locals("session_id", sessionID)
+			return next(c)
+		}
+	}
+
+	app.Get("/sessions", withUser(handler.ListSessionsHandler))
+	app.Delete("/sessions/:id", withUser(handler.RevokeSessionHandler))
+
+	return app
+}
+
+func TestListSessionsHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	current := uuid.New()
+
+	mockService := new(auth_mock.MockAuthService)
+	mockService.On("ListSessions", userID, current.String()).
+		Return([]auth_types.Session{{PublicID: current, UserAgent: "curl/8.0", IP: "10.0.0.1", Current: true}}, nil)
+
+	app := setupTestApp(mockService, userID.String(), current.String())
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/sessions", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var respBody struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&respBody)
+	assert.Len(t, respBody.Data, 1)
+	assert.Equal(t, current.String(), respBody.Data[0]["id"])
+	assert.Equal(t, true, respBody.Data[0]["current"])
+	assert.NotContains(t, respBody.Data[0], "ID")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRevokeSessionHandler(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	tests := []struct {
+		name         string
+		path         string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{
+			name:         "success",
+			path:         "/sessions/" + sessionID.String(),
+			callService:  true,
+			expectStatus: fiber.StatusOK,
+		},
+		{
+			name:         "not found",
+			path:         "/sessions/" + sessionID.String(),
+			serviceError: auth_errors.ErrSessionNotFound,
+			callService:  true,
+			expectStatus: fiber.StatusNotFound,
+		},
+		{
+			name:         "invalid id",
+			path:         "/sessions/abc",
+			callService:  false,
+			expectStatus: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(auth_mock.MockAuthService)
+			if tt.callService {
+				mockService.On("RevokeSession", userID, sessionID).Return(tt.serviceError)
+			}
+
+			app := setupTestApp(mockService, userID.String(), uuid.New().String())
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodDelete, tt.path, nil))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+
+			if tt.callService {
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package user_handler
 
 import (
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+	auth_service "github.com/celio001/prodify/internal/auth/service"
 	"github.com/celio001/prodify/internal/fiber/middleware"
 	user_service "github.com/celio001/prodify/internal/user/service"
 	"github.com/gofiber/fiber/v2"
@@ -10,10 +12,15 @@ const (
 	HandlerPath = "/user"
 )
 
-func RegisterRouter(router fiber.Router, userService user_service.UserService) {
+func RegisterRouter(router fiber.Router, userService user_service.UserService, authService auth_service.AuthService, authMiddleware fiber.Handler) {
 
-	userHandler := NewUserHandler(userService)
-	router.Get("/", middleware.AuthMiddleware(), userHandler.GetUserByPublicIDHandler)
-	router.Patch("/", middleware.AuthMiddleware(), userHandler.UpdateUserHandler)
-	router.Delete("/", middleware.AuthMiddleware(), userHandler.DeleteUserHandler)
+	userHandler := NewUserHandler(userService, authService)
+	router.Get("/", authMiddleware, middleware.RequireScope(apikey_types.ScopeUserRead), userHandler.GetUserByPublicIDHandler)
+	router.Patch("/", authMiddleware, middleware.RequireScope(apikey_types.ScopeUserWrite), userHandler.UpdateUserHandler)
+	router.Delete("/", authMiddleware, middleware.RequireSession(), userHandler.DeleteUserHandler)
+	router.Get("/export", authMiddleware, middleware.RequireSession(), userHandler.ExportUserDataHandler)
+	router.Put("/avatar", authMiddleware, middleware.RequireScope(apikey_types.ScopeUserWrite), userHandler.SetAvatarHandler)
+	router.Delete("/avatar", authMiddleware, middleware.RequireScope(apikey_types.ScopeUserWrite), userHandler.DeleteAvatarHandler)
+	router.Get("/preferences", authMiddleware, middleware.RequireScope(apikey_types.ScopeUserRead), userHandler.GetPreferencesHandler)
+	router.Patch("/preferences", authMiddleware, middleware.RequireScope(apikey_types.ScopeUserWrite), userHandler.UpdatePreferencesHandler)
 }
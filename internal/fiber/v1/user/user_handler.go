@@ -1,16 +1,27 @@
 package user_handler
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/celio001/prodify/pkg/imaging"
 	"github.com/celio001/prodify/pkg/logger"
 	pkg_request "github.com/celio001/prodify/pkg/request"
 	uuidvalidator "github.com/celio001/prodify/pkg/uuid-validator"
 
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	"github.com/celio001/prodify/internal/fiber/middleware"
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_service "github.com/celio001/prodify/internal/user/service"
 	user_types "github.com/celio001/prodify/internal/user/type"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -18,17 +29,32 @@ type UserHandler interface {
 	GetUserByPublicIDHandler(c *fiber.Ctx) error
 	UpdateUserHandler(c *fiber.Ctx) error
 	DeleteUserHandler(c *fiber.Ctx) error
+	ExportUserDataHandler(c *fiber.Ctx) error
+	SetAvatarHandler(c *fiber.Ctx) error
+	DeleteAvatarHandler(c *fiber.Ctx) error
+	GetPreferencesHandler(c *fiber.Ctx) error
+	UpdatePreferencesHandler(c *fiber.Ctx) error
+}
+
+// EmailChanger starts the confirmation of a new email address, auth_service.AuthService is one.
+type EmailChanger interface {
+	RequestEmailChange(userPublicID uuid.UUID, newEmail string) error
 }
 
 type userHandler struct {
-	userService user_service.UserService
+	userService  user_service.UserService
+	emailChanges EmailChanger
 }
 
-func NewUserHandler(userService user_service.UserService) UserHandler {
-	return &userHandler{userService: userService}
+func NewUserHandler(userService user_service.UserService, emailChanges EmailChanger) UserHandler {
+	return &userHandler{userService: userService, emailChanges: emailChanges}
 }
 
-const maxBodySize = 1 << 20
+const (
+	maxBodySize   = 1 << 20
+	maxAvatarSize = 2 << 20
+)
+
 var validate = validator.New()
 
 // @Summary Get authenticated user profile
@@ -77,15 +103,18 @@ func (h *userHandler) GetUserByPublicIDHandler(c *fiber.Ctx) error {
 }
 
 // @Summary Update authenticated user
-// @Description Updates authenticated user profile using the user ID extracted from the access token
+// @Description Updates authenticated user profile using the user ID extracted from the access token.
+// @Description A new email is not applied right away: a confirmation link goes to the new address and a notice to the current one, see /v1/auth/email-change/confirm
 // @Tags user
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body user_types.UpdateUserRequest true "User update payload"
-// @Success 200 {object} map[string]string "User updated successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request body, invalid user ID or user not found"
+// @Success 200 {object} map[string]string "User updated successfully, or email confirmation sent"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, invalid user ID, user not found or email unchanged"
 // @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Email change attempted with an API key, application token or impersonation token"
+// @Failure 409 {object} map[string]string "Email is already in use by another account"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /v1/user [patch]
 func (h *userHandler) UpdateUserHandler(c *fiber.Ctx) error {
@@ -116,24 +145,78 @@ func (h *userHandler) UpdateUserHandler(c *fiber.Ctx) error {
 			JSON(fiber.Map{"error": user_errors.UpdateUserValidateError(err)})
 	}
 
-	err = h.userService.UpdateUser(id, req)
+	// only the user's own interactive login may move the account to another address
+	method := c.Locals(middleware.AuthMethodKey)
+	if req.Email != "" && (method == middleware.AuthMethodAPIKey || method == middleware.AuthMethodOAuth || method == middleware.AuthMethodImpersonation) {
+		return c.Status(fiber.StatusForbidden).
+			JSON(fiber.Map{"error": "EMAIL_CHANGE_REQUIRES_SESSION"})
+	}
+
+	if req.Name != "" {
+		before, err := h.userService.GetUserByPublicID(id)
+		if err == nil {
+			err = h.userService.UpdateUser(id, user_types.UpdateUserRequest{Name: req.Name})
+		}
+		if err != nil {
+			switch err {
+			case user_errors.ErrUserNotFound:
+				return c.Status(fiber.StatusBadRequest).
+					JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+			default:
+				return c.Status(fiber.StatusInternalServerError).
+					JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+			}
+		}
+
+		if before.Name != req.Name {
+			middleware.RecordAudit(c, audit_types.Event{
+				Action:     audit_types.ActionUserUpdated,
+				TargetType: audit_types.TargetUser,
+				TargetID:   id.String(),
+				// names are personal data the erasure job couldn't reach here, only the fact is kept
+				Changes: map[string]audit_types.Change{"name": {}},
+			})
+		}
+	}
+
+	if req.Email == "" {
+		return c.Status(fiber.StatusOK).
+			JSON(fiber.Map{"message": "user updated successfully"})
+	}
+
+	err = h.emailChanges.RequestEmailChange(id, req.Email)
 	if err != nil {
 		switch err {
 		case user_errors.ErrUserNotFound:
 			return c.Status(fiber.StatusBadRequest).
 				JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+		case auth_errors.ErrSameEmail:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "EMAIL_UNCHANGED"})
+		case user_errors.ErrEmailTaken:
+			return c.Status(fiber.StatusConflict).
+				JSON(fiber.Map{"error": "EMAIL_TAKEN"})
 		default:
+			logger.Log.Error("failed to request email change", zap.String("error", err.Error()))
 			return c.Status(fiber.StatusInternalServerError).
 				JSON(fiber.Map{"error": "INTERNAL_ERROR"})
 		}
 	}
 
+	// the address only changes once the link is confirmed, the request is what happened here
+	middleware.RecordAudit(c, audit_types.Event{
+		Action:     audit_types.ActionUserUpdated,
+		TargetType: audit_types.TargetUser,
+		TargetID:   id.String(),
+		Metadata:   map[string]string{"pending_email_hmac": middleware.EmailDigest(req.Email)},
+	})
+
 	return c.Status(fiber.StatusOK).
-		JSON(fiber.Map{"message": "user updated successfully"})
+		JSON(fiber.Map{"message": "user updated successfully, confirm the new email address from the link we sent to it"})
 }
 
 // @Summary Delete authenticated user
-// @Description Soft deletes the authenticated user using the user ID extracted from the access token
+// @Description Soft deletes the authenticated user using the user ID extracted from the access token. Personal data is erased once the grace period is over, until then an admin can restore the account
 // @Tags user
 // @Accept json
 // @Produce json
@@ -170,6 +253,355 @@ func (h *userHandler) DeleteUserHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	middleware.RecordAudit(c, audit_types.Event{
+		Action:     audit_types.ActionUserDeleted,
+		TargetType: audit_types.TargetUser,
+		TargetID:   id.String(),
+	})
+
 	return c.Status(fiber.StatusOK).
 		JSON(fiber.Map{"message": "user successfully deleted"})
 }
+
+// @Summary Export my personal data
+// @Description Downloads everything stored about the authenticated user: profile, products, sessions, linked identities, API keys, OAuth grants and the audit log entries made by or about them. A ZIP archive with one JSON file per section by default, format=json returns a single JSON document
+// @Tags user
+// @Produce application/zip
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "zip (default) or json"
+// @Success 200 {object} user_types.UserDataExport "Personal data export"
+// @Failure 400 {object} map[string]string "Invalid user ID, invalid format or user not found"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 403 {object} map[string]string "Called with an API key"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/export [get]
+func (h *userHandler) ExportUserDataHandler(c *fiber.Ctx) error {
+
+	format := c.Query("format", "zip")
+	if format != "zip" && format != "json" {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_FORMAT"})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	data, err := h.userService.ExportUserData(id)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+		default:
+			logger.Log.Error("failed to export user data", zap.String("error", err.Error()))
+			return c.Status(fiber.StatusInternalServerError).
+				JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+		}
+	}
+
+	logger.Log.Info("personal data exported",
+		zap.String("user_id", id.String()),
+		zap.String("format", format))
+
+	filename := "prodify-export-" + data.ExportedAt.Format("20060102") + "." + format
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "json" {
+		return c.Status(fiber.StatusOK).JSON(data)
+	}
+
+	archive, err := exportArchive(data)
+	if err != nil {
+		logger.Log.Error("failed to build export archive", zap.String("error", err.Error()))
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	return c.Status(fiber.StatusOK).Send(archive)
+}
+
+// exportArchive writes one indented JSON file per section of the export.
+func exportArchive(data *user_types.UserDataExport) ([]byte, error) {
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"profile.json", data.Profile},
+		{"products.json", data.Products},
+		{"sessions.json", data.Sessions},
+		{"identities.json", data.Identities},
+		{"api_keys.json", data.APIKeys},
+		{"oauth_grants.json", data.OAuthGrants},
+		{"audit_events.json", data.AuditEvents},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: data.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// @Summary Upload my avatar
+// @Description Replaces the profile picture. JPEG, PNG or GIF up to 2MB and 4096x4096 pixels; the image is cropped to a centered square and scaled to 256x256
+// @Tags user
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param avatar formData file true "Image file"
+// @Success 200 {object} map[string]interface{} "Avatar updated, data.avatarUrl is the new image"
+// @Failure 400 {object} map[string]string "Missing file, unsupported image, image too large or user not found"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 413 {object} map[string]string "File larger than 2MB"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/avatar [put]
+func (h *userHandler) SetAvatarHandler(c *fiber.Ctx) error {
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "AVATAR_REQUIRED"})
+	}
+	if header.Size > maxAvatarSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).
+			JSON(fiber.Map{"error": "AVATAR_TOO_LARGE"})
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.Log.Error("failed to open avatar upload", zap.String("error", err.Error()))
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(io.LimitReader(file, maxAvatarSize))
+	if err != nil {
+		logger.Log.Error("failed to read avatar upload", zap.String("error", err.Error()))
+		return c.Status(fiber.StatusInternalServerError).
+			JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+	}
+
+	url, err := h.userService.SetAvatar(id, image)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+		case imaging.ErrUnsupportedFormat:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "UNSUPPORTED_IMAGE"})
+		case imaging.ErrTooLarge:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "IMAGE_DIMENSIONS_TOO_LARGE"})
+		default:
+			logger.Log.Error("failed to set avatar", zap.String("error", err.Error()))
+			return c.Status(fiber.StatusInternalServerError).
+				JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(fiber.Map{
+			"message": "avatar updated successfully",
+			"data":    fiber.Map{"avatarUrl": url},
+		})
+}
+
+// @Summary Remove my avatar
+// @Description Deletes the profile picture, succeeds when there is none
+// @Tags user
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Avatar removed"
+// @Failure 400 {object} map[string]string "Invalid user ID or user not found"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/avatar [delete]
+func (h *userHandler) DeleteAvatarHandler(c *fiber.Ctx) error {
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	err = h.userService.DeleteAvatar(id)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).
+				JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(fiber.Map{"message": "avatar removed successfully"})
+}
+
+// @Summary Get my preferences
+// @Description Returns locale, timezone, currency and notification opt-ins. Preferences never saved come back with the defaults
+// @Tags user
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Preferences loaded successfully"
+// @Failure 400 {object} map[string]string "Invalid user ID or user not found"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/preferences [get]
+func (h *userHandler) GetPreferencesHandler(c *fiber.Ctx) error {
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	preferences, err := h.userService.GetPreferences(id)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).
+				JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(fiber.Map{
+			"message": "preferences loaded successfully",
+			"data":    preferences,
+		})
+}
+
+// @Summary Update my preferences
+// @Description Updates the fields present in the body, the others keep their current value. Emails are sent in the chosen locale
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user_types.Preferences true "Preferences to change"
+// @Success 200 {object} map[string]interface{} "Preferences updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, invalid user ID or user not found"
+// @Failure 401 {object} map[string]string "User not authenticated"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v1/user/preferences [patch]
+func (h *userHandler) UpdatePreferencesHandler(c *fiber.Ctx) error {
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).
+			JSON(fiber.Map{"error": "user not authenticated"})
+	}
+
+	id, err := uuidvalidator.ValidateUuid(userID.(string))
+	if err != nil {
+		logger.Log.Error("invalid uuid", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": "INVALID_USER_ID"})
+	}
+
+	preferences, err := h.userService.GetPreferences(id)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).
+				JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+		}
+	}
+
+	// decoding over the current preferences leaves out-of-body fields untouched
+	if err := pkg_request.LimitBodyJSON(c, maxBodySize, preferences); err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validate.Struct(preferences); err != nil {
+		return c.Status(fiber.StatusBadRequest).
+			JSON(fiber.Map{"error": user_errors.PreferencesValidateError(err)})
+	}
+
+	err = h.userService.UpdatePreferences(id, *preferences)
+	if err != nil {
+		switch err {
+		case user_errors.ErrUserNotFound:
+			return c.Status(fiber.StatusBadRequest).
+				JSON(fiber.Map{"error": "USER_NOT_FOUND"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).
+				JSON(fiber.Map{"error": "INTERNAL_ERROR"})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(fiber.Map{
+			"message": "preferences updated successfully",
+			"data":    preferences,
+		})
+}
@@ -1,16 +1,26 @@
 package user_handler
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	audit_service_mock "github.com/celio001/prodify/internal/audit/service/mock"
+	audit_types "github.com/celio001/prodify/internal/audit/types"
+	auth_errors "github.com/celio001/prodify/internal/auth/errors"
+	auth_mock "github.com/celio001/prodify/internal/auth/service/mock"
+	"github.com/celio001/prodify/internal/fiber/middleware"
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_service_mock "github.com/celio001/prodify/internal/user/service/mock"
 	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/imaging"
 	"github.com/celio001/prodify/pkg/logger"
 
 	"github.com/gofiber/fiber/v2"
@@ -24,7 +34,7 @@ func TestGetUserByPublicIDHandler_Success(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -59,7 +69,7 @@ func TestGetUserByPublicIDHandler_Unauthorized(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	app := fiber.New()
 
@@ -76,7 +86,7 @@ func TestGetUserByPublicIDHandler_InvalidUUID(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	app := fiber.New()
 
@@ -96,7 +106,7 @@ func TestGetUserByPublicIDHandler_UserNotFound(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -124,7 +134,7 @@ func TestGetUserByPublicIDHandler_InternalError(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -152,7 +162,8 @@ func TestUpdateUserHandler_Success(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	emailChanges := new(auth_mock.MockAuthService)
+	handler := NewUserHandler(mockService, emailChanges)
 
 	userID := uuid.New()
 
@@ -162,14 +173,23 @@ func TestUpdateUserHandler_Success(t *testing.T) {
 	}
 
 	mockService.
-		On("UpdateUser", userID, payload).
+		On("GetUserByPublicID", userID).
+		Return(&user_types.GetUserResponse{Name: "Nome Antigo"}, nil)
+
+	mockService.
+		On("UpdateUser", userID, user_types.UpdateUserRequest{Name: "Novo Nome"}).
+		Return(nil)
+
+	emailChanges.
+		On("RequestEmailChange", userID, "novo@email.com").
 		Return(nil)
 
 	body, _ := json.Marshal(payload)
 
+	recorder := new(audit_service_mock.MockRecorder)
 	app := fiber.New()
 
-	app.Patch("/v1/user", func(c *fiber.Ctx) error {
+	app.Patch("/v1/user", middleware.Audit(recorder), func(c *fiber.Ctx) error {
 		c.Locals("user_id", userID.String())
 		return handler.UpdateUserHandler(c)
 	})
@@ -181,7 +201,73 @@ func TestUpdateUserHandler_Success(t *testing.T) {
 
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 
+	if assert.Len(t, recorder.Events, 2) {
+		assert.Equal(t, audit_types.ActionUserUpdated, recorder.Events[0].Action)
+		assert.Equal(t, userID.String(), recorder.Events[0].ActorID)
+		assert.Contains(t, recorder.Events[0].Changes, "name")
+		assert.Equal(t, audit_types.Change{}, recorder.Events[0].Changes["name"])
+		assert.Equal(t, middleware.EmailDigest("novo@email.com"), recorder.Events[1].Metadata["pending_email_hmac"])
+		assert.NotContains(t, fmt.Sprint(recorder.Events), "novo@email.com")
+		assert.NotContains(t, fmt.Sprint(recorder.Events), "Nome Antigo")
+	}
+
 	mockService.AssertExpectations(t)
+	emailChanges.AssertExpectations(t)
+}
+
+func TestUpdateUserHandler_EmailChange(t *testing.T) {
+
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		authMethod   string
+		serviceError error
+		callService  bool
+		expectStatus int
+	}{
+		{name: "confirmation sent", callService: true, expectStatus: fiber.StatusOK},
+		{name: "email taken", serviceError: user_errors.ErrEmailTaken, callService: true, expectStatus: fiber.StatusConflict},
+		{name: "same email", serviceError: auth_errors.ErrSameEmail, callService: true, expectStatus: fiber.StatusBadRequest},
+		{name: "api key", authMethod: middleware.AuthMethodAPIKey, callService: false, expectStatus: fiber.StatusForbidden},
+		{name: "impersonating admin", authMethod: middleware.AuthMethodImpersonation, callService: false, expectStatus: fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(user_service_mock.MockUserService)
+			emailChanges := new(auth_mock.MockAuthService)
+			handler := NewUserHandler(mockService, emailChanges)
+
+			if tt.callService {
+				emailChanges.On("RequestEmailChange", userID, "novo@email.com").Return(tt.serviceError)
+			}
+
+			app := fiber.New()
+			app.Patch("/v1/user", func(c *fiber.Ctx) error {
+				c.Locals("user_id", userID.String())
+				if tt.authMethod != "" {
+					c.Locals(middleware.AuthMethodKey, tt.authMethod)
+				}
+				return handler.UpdateUserHandler(c)
+			})
+
+			req := httptest.NewRequest(http.MethodPatch, "/v1/user", bytes.NewBufferString(`{"email":"novo@email.com"}`))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectStatus, resp.StatusCode)
+			mockService.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+			if tt.callService {
+				emailChanges.AssertExpectations(t)
+			} else {
+				emailChanges.AssertNotCalled(t, "RequestEmailChange", mock.Anything, mock.Anything)
+			}
+		})
+	}
 }
 
 func TestUpdateUserHandler_Unauthorized(t *testing.T) {
@@ -189,7 +275,7 @@ func TestUpdateUserHandler_Unauthorized(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	app := fiber.New()
 	app.Patch("/v1/user", handler.UpdateUserHandler)
@@ -206,7 +292,7 @@ func TestUpdateUserHandler_InvalidUUID(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	app := fiber.New()
 
@@ -227,7 +313,7 @@ func TestUpdateUserHandler_InvalidJSON(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -256,7 +342,7 @@ func TestUpdateUserHandler_ValidationError(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -288,7 +374,7 @@ func TestUpdateUserHandler_UserNotFound(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -296,6 +382,10 @@ func TestUpdateUserHandler_UserNotFound(t *testing.T) {
 		Name: "Novo Nome",
 	}
 
+	mockService.
+		On("GetUserByPublicID", userID).
+		Return(&user_types.GetUserResponse{Name: "Nome Antigo"}, nil)
+
 	mockService.
 		On("UpdateUser", userID, payload).
 		Return(user_errors.ErrUserNotFound)
@@ -324,7 +414,7 @@ func TestUpdateUserHandler_InternalError(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -332,6 +422,10 @@ func TestUpdateUserHandler_InternalError(t *testing.T) {
 		Name: "Novo Nome",
 	}
 
+	mockService.
+		On("GetUserByPublicID", userID).
+		Return(&user_types.GetUserResponse{Name: "Nome Antigo"}, nil)
+
 	mockService.
 		On("UpdateUser", userID, payload).
 		Return(errors.New("db error"))
@@ -360,7 +454,7 @@ func TestDeleteUserHandler_Success(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -389,7 +483,7 @@ func TestDeleteUserHandler_Unauthorized(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	app := fiber.New()
 	app.Delete("/v1/user", handler.DeleteUserHandler)
@@ -408,7 +502,7 @@ func TestDeleteUserHandler_InvalidUUID(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	app := fiber.New()
 
@@ -431,7 +525,7 @@ func TestDeleteUserHandler_UserNotFound(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -460,7 +554,7 @@ func TestDeleteUserHandler_InternalError(t *testing.T) {
 	logger.Init("dev")
 
 	mockService := new(user_service_mock.MockUserService)
-	handler := NewUserHandler(mockService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
 
 	userID := uuid.New()
 
@@ -483,3 +577,270 @@ func TestDeleteUserHandler_InternalError(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestExportUserDataHandler(t *testing.T) {
+
+	logger.Init("dev")
+
+	userID := uuid.New()
+	export := &user_types.UserDataExport{
+		Profile:  user_types.ExportProfile{PublicID: userID.String(), Email: "celio@test.com"},
+		Products: []user_types.ExportProduct{{Name: "Mouse"}},
+		AuditEvents: []user_types.ExportAuditEvent{{Action: "auth.login.succeeded", IP: "10.0.0.1",
+			Metadata: json.RawMessage(`{"method":"password"}`)}},
+	}
+
+	setup := func(mockService *user_service_mock.MockUserService) *fiber.App {
+		handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
+		app := fiber.New()
+		app.Get("/v1/user/export", func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID.String())
+			return handler.ExportUserDataHandler(c)
+		})
+		return app
+	}
+
+	t.Run("zip archive by default", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("ExportUserData", userID).Return(export, nil)
+
+		resp, err := setup(mockService).Test(httptest.NewRequest(http.MethodGet, "/v1/user/export", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/zip", resp.Header.Get(fiber.HeaderContentType))
+		assert.Contains(t, resp.Header.Get(fiber.HeaderContentDisposition), "attachment")
+
+		body, _ := io.ReadAll(resp.Body)
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		assert.NoError(t, err)
+
+		var names []string
+		for _, file := range archive.File {
+			names = append(names, file.Name)
+		}
+		assert.Contains(t, names, "profile.json")
+		assert.Contains(t, names, "products.json")
+		assert.Contains(t, names, "sessions.json")
+		assert.Contains(t, names, "audit_events.json")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("ExportUserData", userID).Return(export, nil)
+
+		resp, err := setup(mockService).Test(httptest.NewRequest(http.MethodGet, "/v1/user/export?format=json", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body user_types.UserDataExport
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "celio@test.com", body.Profile.Email)
+		if assert.Len(t, body.AuditEvents, 1) {
+			assert.JSONEq(t, `{"method":"password"}`, string(body.AuditEvents[0].Metadata))
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+
+		resp, err := setup(mockService).Test(httptest.NewRequest(http.MethodGet, "/v1/user/export?format=xml", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "ExportUserData", mock.Anything)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("ExportUserData", userID).Return(nil, errors.New("db error"))
+
+		resp, err := setup(mockService).Test(httptest.NewRequest(http.MethodGet, "/v1/user/export", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	})
+}
+
+func avatarRequest(t *testing.T, field string, content []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, "me.png")
+	assert.NoError(t, err)
+	_, err = part.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPut, "/v1/user/avatar", &body)
+	req.Header.Set(fiber.HeaderContentType, writer.FormDataContentType())
+	return req
+}
+
+func TestSetAvatarHandler(t *testing.T) {
+
+	logger.Init("dev")
+
+	userID := uuid.New()
+
+	setup := func(mockService *user_service_mock.MockUserService) *fiber.App {
+		handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
+		app := fiber.New()
+		app.Put("/v1/user/avatar", func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID.String())
+			return handler.SetAvatarHandler(c)
+		})
+		return app
+	}
+
+	t.Run("uploaded", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("SetAvatar", userID, []byte("image")).Return("http://localhost:8080/uploads/avatars/1.png", nil)
+
+		resp, err := setup(mockService).Test(avatarRequest(t, "avatar", []byte("image")))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Data struct {
+				AvatarURL string `json:"avatarUrl"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "http://localhost:8080/uploads/avatars/1.png", body.Data.AvatarURL)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+
+		resp, err := setup(mockService).Test(avatarRequest(t, "picture", []byte("image")))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockService.AssertNotCalled(t, "SetAvatar", mock.Anything, mock.Anything)
+	})
+
+	t.Run("file too large", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+
+		resp, err := setup(mockService).Test(avatarRequest(t, "avatar", make([]byte, maxAvatarSize+1)))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusRequestEntityTooLarge, resp.StatusCode)
+		mockService.AssertNotCalled(t, "SetAvatar", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unsupported image", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("SetAvatar", userID, []byte("<svg/>")).Return("", imaging.ErrUnsupportedFormat)
+
+		resp, err := setup(mockService).Test(avatarRequest(t, "avatar", []byte("<svg/>")))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "UNSUPPORTED_IMAGE")
+	})
+}
+
+func TestDeleteAvatarHandler(t *testing.T) {
+
+	logger.Init("dev")
+
+	userID := uuid.New()
+	mockService := new(user_service_mock.MockUserService)
+	handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
+
+	mockService.On("DeleteAvatar", userID).Return(nil)
+
+	app := fiber.New()
+	app.Delete("/v1/user/avatar", func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.String())
+		return handler.DeleteAvatarHandler(c)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/v1/user/avatar", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	mockService.AssertExpectations(t)
+}
+
+func TestPreferencesHandlers(t *testing.T) {
+
+	logger.Init("dev")
+
+	userID := uuid.New()
+	current := func() *user_types.Preferences {
+		return &user_types.Preferences{Locale: "en", Timezone: "UTC", Currency: "USD"}
+	}
+
+	setup := func(mockService *user_service_mock.MockUserService) *fiber.App {
+		handler := NewUserHandler(mockService, new(auth_mock.MockAuthService))
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID.String())
+			return c.Next()
+		})
+		app.Get("/v1/user/preferences", handler.GetPreferencesHandler)
+		app.Patch("/v1/user/preferences", handler.UpdatePreferencesHandler)
+		return app
+	}
+
+	patch := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/user/preferences", bytes.NewBufferString(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return req
+	}
+
+	t.Run("get", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("GetPreferences", userID).Return(current(), nil)
+
+		resp, err := setup(mockService).Test(httptest.NewRequest(http.MethodGet, "/v1/user/preferences", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Data user_types.Preferences `json:"data"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, *current(), body.Data)
+	})
+
+	t.Run("partial update keeps the other fields", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("GetPreferences", userID).Return(current(), nil)
+		mockService.On("UpdatePreferences", userID, user_types.Preferences{
+			Locale:        "pt-BR",
+			Timezone:      "America/Sao_Paulo",
+			Currency:      "USD",
+			Notifications: user_types.NotificationPreferences{Newsletter: true},
+		}).Return(nil)
+
+		resp, err := setup(mockService).Test(patch(`{"locale": "pt-BR", "timezone": "America/Sao_Paulo", "notifications": {"newsletter": true}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("GetPreferences", userID).Return(current(), nil)
+
+		resp, err := setup(mockService).Test(patch(`{"locale": "fr", "timezone": "Mars/Olympus", "currency": "XXXX"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+		var body struct {
+			Error map[string]string `json:"error"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Contains(t, body.Error, "Locale")
+		assert.Contains(t, body.Error, "Timezone")
+		assert.Contains(t, body.Error, "Currency")
+		mockService.AssertNotCalled(t, "UpdatePreferences", mock.Anything, mock.Anything)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockService := new(user_service_mock.MockUserService)
+		mockService.On("GetPreferences", userID).Return(nil, user_errors.ErrUserNotFound)
+
+		resp, err := setup(mockService).Test(patch(`{"locale": "pt-BR"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,66 @@
+package oauth_errors
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrClientNotFound          = errors.New("client not found")
+	ErrInvalidClient           = errors.New("client authentication failed")
+	ErrInvalidRedirectURI      = errors.New("redirect_uri is not registered for this client")
+	ErrUnsupportedResponseType = errors.New("only the code response type is supported")
+	ErrPKCERequired            = errors.New("a code_challenge with the S256 method is required")
+	ErrInvalidScope            = errors.New("requested scope is unknown or not allowed for this client")
+	ErrInvalidRequest          = errors.New("request is missing a required parameter")
+	ErrInvalidGrant            = errors.New("authorization code or refresh token is invalid, expired or revoked")
+	ErrUnsupportedGrantType    = errors.New("unsupported grant type")
+	ErrUnauthorizedClient      = errors.New("client is not allowed to use this grant type")
+)
+
+func RegisterClientValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+
+			switch {
+			case field == "Name":
+				switch tag {
+				case "required":
+					errors[field] = "name is required"
+				case "min":
+					errors[field] = "name must have at least 3 characters"
+				case "max":
+					errors[field] = "name must have at most 100 characters"
+				}
+
+			case field == "RedirectURIs":
+				switch tag {
+				case "required", "min":
+					errors[field] = "at least one redirect uri is required"
+				case "max":
+					errors[field] = "at most 10 redirect uris are allowed"
+				}
+
+			case strings.HasPrefix(field, "RedirectURIs["):
+				errors["RedirectURIs"] = "redirect uris must be absolute urls"
+
+			case field == "Scopes":
+				switch tag {
+				case "required", "min":
+					errors[field] = "at least one scope is required"
+				}
+
+			case strings.HasPrefix(field, "Scopes["):
+				errors["Scopes"] = "invalid scope, allowed: user:read, user:write, product:read, product:write"
+			}
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,160 @@
+package oauth_repository
+
+import (
+	"context"
+	"database/sql"
+
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	createClientQuery = `INSERT INTO oauth_clients (user_id, name, secret_hash, redirect_uris, scopes)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, public_id, created_at`
+
+	listClientsQuery = `SELECT id, public_id, name, secret_hash IS NOT NULL, redirect_uris, scopes, created_at
+	FROM oauth_clients
+	WHERE user_id = $1
+	AND revoked_at IS NULL
+	ORDER BY created_at DESC`
+
+	getClientQuery = `SELECT c.id, c.public_id, c.user_id, u.public_id, c.name, c.secret_hash, c.redirect_uris, c.scopes, c.created_at
+	FROM oauth_clients c
+	JOIN users u ON u.id = c.user_id
+	WHERE c.public_id = $1
+	AND c.revoked_at IS NULL
+	AND u.deleted_at IS NULL
+	AND u.is_active`
+
+	revokeClientQuery = `UPDATE oauth_clients
+	SET revoked_at = now()
+	WHERE public_id = $2
+	AND user_id = $1
+	AND revoked_at IS NULL`
+)
+
+type clientRepository struct {
+	Db *sql.DB
+}
+
+// ClientRepository stores the applications registered by users. Revoking a client
+// also ends its grants, they are only honoured while the client is active.
+type ClientRepository interface {
+	CreateClient(client oauth_types.Client) (*oauth_types.Client, error)
+	ListClients(user_id int64) ([]oauth_types.Client, error)
+	GetClient(clientID uuid.UUID) (*oauth_types.Client, error)
+	RevokeClient(user_id int64, clientID uuid.UUID) error
+}
+
+func NewClientRepository(Db *sql.DB) ClientRepository {
+	return &clientRepository{
+		Db: Db,
+	}
+}
+
+func (r *clientRepository) CreateClient(client oauth_types.Client) (*oauth_types.Client, error) {
+	ctx := context.Background()
+
+	secretHash := sql.NullString{String: client.SecretHash, Valid: client.SecretHash != ""}
+
+	row := r.Db.QueryRowContext(ctx, createClientQuery,
+		client.UserID, client.Name, secretHash, pq.Array(client.RedirectURIs), pq.Array(client.Scopes))
+
+	if err := row.Scan(&client.ID, &client.PublicID, &client.CreatedAt); err != nil {
+		logger.Log.Error("error creating oauth client", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	client.Confidential = secretHash.Valid
+	return &client, nil
+}
+
+func (r *clientRepository) ListClients(user_id int64) ([]oauth_types.Client, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, listClientsQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error listing oauth clients", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []oauth_types.Client{}
+	for rows.Next() {
+		var client oauth_types.Client
+
+		err := rows.Scan(
+			&client.ID,
+			&client.PublicID,
+			&client.Name,
+			&client.Confidential,
+			pq.Array(&client.RedirectURIs),
+			pq.Array(&client.Scopes),
+			&client.CreatedAt)
+		if err != nil {
+			logger.Log.Error("error scanning oauth client", zap.String("error", err.Error()))
+			return nil, err
+		}
+
+		client.UserID = user_id
+		clients = append(clients, client)
+	}
+
+	return clients, rows.Err()
+}
+
+// GetClient only finds active clients whose owner is still active, anything else is ErrClientNotFound.
+func (r *clientRepository) GetClient(clientID uuid.UUID) (*oauth_types.Client, error) {
+	ctx := context.Background()
+
+	row := r.Db.QueryRowContext(ctx, getClientQuery, clientID)
+
+	var client oauth_types.Client
+	var secretHash sql.NullString
+
+	err := row.Scan(
+		&client.ID,
+		&client.PublicID,
+		&client.UserID,
+		&client.UserPublicID,
+		&client.Name,
+		&secretHash,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.Scopes),
+		&client.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, oauth_errors.ErrClientNotFound
+		}
+		logger.Log.Error("error getting oauth client", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	client.SecretHash = secretHash.String
+	client.Confidential = secretHash.Valid
+	return &client, nil
+}
+
+func (r *clientRepository) RevokeClient(user_id int64, clientID uuid.UUID) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, revokeClientQuery, user_id, clientID)
+	if err != nil {
+		logger.Log.Error("error revoking oauth client", zap.String("error", err.Error()))
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return oauth_errors.ErrClientNotFound
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package oauth_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateClient(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+	publicID := uuid.New().String()
+
+	tests := []struct {
+		name         string
+		secretHash   string
+		mockError    error
+		expectError  bool
+		confidential bool
+	}{
+		{name: "confidential client", secretHash: "hash", confidential: true},
+		{name: "public client"},
+		{name: "database error", mockError: fmt.Errorf("db error"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewClientRepository(db)
+
+			client := oauth_types.Client{
+				UserID:       1,
+				Name:         "partner",
+				SecretHash:   tt.secretHash,
+				RedirectURIs: []string{"https://partner.example/callback"},
+				Scopes:       []string{"user:read"},
+			}
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(createClientQuery)).
+				WithArgs(int64(1), "partner", sql.NullString{String: tt.secretHash, Valid: tt.secretHash != ""},
+					pq.Array(client.RedirectURIs), pq.Array(client.Scopes))
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "created_at"}).AddRow(10, publicID, now))
+			}
+
+			created, err := repo.CreateClient(client)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, created)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(10), created.ID)
+				assert.Equal(t, publicID, created.PublicID)
+				assert.Equal(t, tt.confidential, created.Confidential)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListClients(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewClientRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(listClientsQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "name", "confidential", "redirect_uris", "scopes", "created_at"}).
+			AddRow(1, "a", "partner", true, "{https://partner.example/callback}", "{user:read,user:write}", now).
+			AddRow(2, "b", "mobile", false, "{app://callback}", "{user:read}", now))
+
+	clients, err := repo.ListClients(1)
+
+	require.NoError(t, err)
+	require.Len(t, clients, 2)
+	assert.True(t, clients[0].Confidential)
+	assert.Equal(t, []string{"user:read", "user:write"}, clients[0].Scopes)
+	assert.False(t, clients[1].Confidential)
+	assert.Equal(t, []string{"app://callback"}, clients[1].RedirectURIs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetClient(t *testing.T) {
+	logger.Init("dev")
+
+	clientID := uuid.New()
+	now := time.Now()
+	columns := []string{"id", "public_id", "user_id", "user_public_id", "name", "secret_hash", "redirect_uris", "scopes", "created_at"}
+
+	tests := []struct {
+		name         string
+		mockRows     *sqlmock.Rows
+		mockError    error
+		expectError  error
+		confidential bool
+	}{
+		{
+			name:         "confidential client",
+			mockRows:     sqlmock.NewRows(columns).AddRow(1, clientID.String(), 2, "u", "partner", "hash", "{https://partner.example/callback}", "{user:read}", now),
+			confidential: true,
+		},
+		{
+			name:     "public client",
+			mockRows: sqlmock.NewRows(columns).AddRow(1, clientID.String(), 2, "u", "mobile", nil, "{app://callback}", "{user:read}", now),
+		},
+		{
+			name:        "unknown or revoked client",
+			mockError:   sql.ErrNoRows,
+			expectError: oauth_errors.ErrClientNotFound,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewClientRepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getClientQuery)).WithArgs(clientID)
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			client, err := repo.GetClient(clientID)
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "u", client.UserPublicID)
+				assert.Equal(t, tt.confidential, client.Confidential)
+				assert.Equal(t, []string{"user:read"}, client.Scopes)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeClient(t *testing.T) {
+	logger.Init("dev")
+
+	clientID := uuid.New()
+
+	tests := []struct {
+		name        string
+		affected    int64
+		expectError error
+	}{
+		{name: "success", affected: 1},
+		{name: "not found or not owned", affected: 0, expectError: oauth_errors.ErrClientNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewClientRepository(db)
+
+			mock.ExpectExec(regexp.QuoteMeta(revokeClientQuery)).
+				WithArgs(int64(1), clientID).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			err = repo.RevokeClient(1, clientID)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package oauth_repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	createAuthorizationCodeQuery = `INSERT INTO oauth_authorization_codes
+	(code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	// marking the code used and reading it is one statement, so two exchanges
+	// racing for the same code can't both win
+	consumeAuthorizationCodeQuery = `WITH code AS (
+		UPDATE oauth_authorization_codes
+		SET used_at = now()
+		WHERE code_hash = $1
+		AND used_at IS NULL
+		AND expires_at > now()
+		RETURNING id, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at
+	)
+	SELECT code.id, code.client_id, code.user_id, u.public_id, code.redirect_uri, code.scopes,
+	code.code_challenge, code.expires_at
+	FROM code
+	JOIN users u ON u.id = code.user_id`
+
+	createGrantQuery = `INSERT INTO oauth_grants (client_id, user_id, scopes, refresh_token_id, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING public_id`
+
+	getGrantQuery = `SELECT g.id, g.public_id, g.client_id, c.public_id, g.user_id, u.public_id, g.scopes,
+	g.refresh_token_id, g.created_at, g.expires_at
+	FROM oauth_grants g
+	JOIN oauth_clients c ON c.id = g.client_id
+	JOIN users u ON u.id = g.user_id
+	WHERE g.public_id = $1
+	AND g.revoked_at IS NULL
+	AND g.expires_at > now()
+	AND c.revoked_at IS NULL
+	AND u.deleted_at IS NULL
+	AND u.is_active`
+
+	// only the newest refresh token of the grant matches, an older one falls through
+	rotateGrantQuery = `UPDATE oauth_grants
+	SET refresh_token_id = $3, expires_at = $4
+	WHERE public_id = $1
+	AND refresh_token_id = $2
+	AND revoked_at IS NULL
+	AND expires_at > now()`
+
+	revokeGrantQuery = `UPDATE oauth_grants
+	SET revoked_at = now()
+	WHERE public_id = $1
+	AND revoked_at IS NULL`
+)
+
+type grantRepository struct {
+	Db *sql.DB
+}
+
+// GrantRepository keeps authorization codes and the grants they are exchanged for.
+type GrantRepository interface {
+	CreateAuthorizationCode(code oauth_types.AuthorizationCode) error
+	ConsumeAuthorizationCode(codeHash string) (*oauth_types.AuthorizationCode, error)
+	CreateGrant(grant oauth_types.Grant) (uuid.UUID, error)
+	GetGrant(grantID uuid.UUID) (*oauth_types.Grant, error)
+	RotateGrant(grantID uuid.UUID, currentTokenID uuid.UUID, newTokenID uuid.UUID, expiresAt time.Time) error
+	RevokeGrant(grantID uuid.UUID) error
+}
+
+func NewGrantRepository(Db *sql.DB) GrantRepository {
+	return &grantRepository{
+		Db: Db,
+	}
+}
+
+func (r *grantRepository) CreateAuthorizationCode(code oauth_types.AuthorizationCode) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, createAuthorizationCodeQuery,
+		code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, pq.Array(code.Scopes), code.CodeChallenge, code.ExpiresAt)
+	if err != nil {
+		logger.Log.Error("error creating authorization code", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// ConsumeAuthorizationCode marks the code used and returns it. Unknown, expired and
+// already used codes are all ErrInvalidGrant.
+func (r *grantRepository) ConsumeAuthorizationCode(codeHash string) (*oauth_types.AuthorizationCode, error) {
+	ctx := context.Background()
+
+	row := r.Db.QueryRowContext(ctx, consumeAuthorizationCodeQuery, codeHash)
+
+	code := oauth_types.AuthorizationCode{CodeHash: codeHash}
+	err := row.Scan(
+		&code.ID,
+		&code.ClientID,
+		&code.UserID,
+		&code.UserPublicID,
+		&code.RedirectURI,
+		pq.Array(&code.Scopes),
+		&code.CodeChallenge,
+		&code.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, oauth_errors.ErrInvalidGrant
+		}
+		logger.Log.Error("error consuming authorization code", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return &code, nil
+}
+
+// CreateGrant stores a grant, grant.RefreshTokenID is left empty for grants without a refresh token.
+func (r *grantRepository) CreateGrant(grant oauth_types.Grant) (uuid.UUID, error) {
+	ctx := context.Background()
+
+	refreshTokenID := sql.NullString{String: grant.RefreshTokenID, Valid: grant.RefreshTokenID != ""}
+
+	var grantID uuid.UUID
+	err := r.Db.QueryRowContext(ctx, createGrantQuery,
+		grant.ClientID, grant.UserID, pq.Array(grant.Scopes), refreshTokenID, grant.ExpiresAt).Scan(&grantID)
+	if err != nil {
+		logger.Log.Error("error creating oauth grant", zap.String("error", err.Error()))
+		return uuid.Nil, err
+	}
+	return grantID, nil
+}
+
+// GetGrant only finds grants that are still honoured: not revoked or expired, and
+// whose client and user are active. Anything else is ErrInvalidGrant.
+func (r *grantRepository) GetGrant(grantID uuid.UUID) (*oauth_types.Grant, error) {
+	ctx := context.Background()
+
+	row := r.Db.QueryRowContext(ctx, getGrantQuery, grantID)
+
+	var grant oauth_types.Grant
+	var refreshTokenID sql.NullString
+
+	err := row.Scan(
+		&grant.ID,
+		&grant.PublicID,
+		&grant.ClientID,
+		&grant.ClientPublicID,
+		&grant.UserID,
+		&grant.UserPublicID,
+		pq.Array(&grant.Scopes),
+		&refreshTokenID,
+		&grant.CreatedAt,
+		&grant.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, oauth_errors.ErrInvalidGrant
+		}
+		logger.Log.Error("error getting oauth grant", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	grant.RefreshTokenID = refreshTokenID.String
+	return &grant, nil
+}
+
+// RotateGrant swaps the grant's refresh token. ErrInvalidGrant means the presented
+// token is not the newest one, or the grant is gone.
+func (r *grantRepository) RotateGrant(grantID uuid.UUID, currentTokenID uuid.UUID, newTokenID uuid.UUID, expiresAt time.Time) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, rotateGrantQuery, grantID, currentTokenID, newTokenID, expiresAt)
+	if err != nil {
+		logger.Log.Error("error rotating oauth grant", zap.String("error", err.Error()))
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("error rotating oauth grant", zap.String("error", err.Error()))
+		return err
+	}
+	if rows == 0 {
+		return oauth_errors.ErrInvalidGrant
+	}
+	return nil
+}
+
+func (r *grantRepository) RevokeGrant(grantID uuid.UUID) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, revokeGrantQuery, grantID)
+	if err != nil {
+		logger.Log.Error("error revoking oauth grant", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package oauth_repository
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAuthorizationCode(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewGrantRepository(db)
+
+	code := oauth_types.AuthorizationCode{
+		CodeHash:      "hash",
+		ClientID:      1,
+		UserID:        2,
+		RedirectURI:   "https://partner.example/callback",
+		Scopes:        []string{"user:read"},
+		CodeChallenge: "challenge",
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(createAuthorizationCodeQuery)).
+		WithArgs("hash", int64(1), int64(2), code.RedirectURI, pq.Array(code.Scopes), "challenge", code.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, repo.CreateAuthorizationCode(code))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumeAuthorizationCode(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectError error
+	}{
+		{
+			name: "success",
+			mockRows: sqlmock.NewRows([]string{"id", "client_id", "user_id", "user_public_id", "redirect_uri", "scopes", "code_challenge", "expires_at"}).
+				AddRow(1, 2, 3, "u", "https://partner.example/callback", "{user:read}", "challenge", now),
+		},
+		{
+			name:        "unknown, expired or used code",
+			mockError:   sql.ErrNoRows,
+			expectError: oauth_errors.ErrInvalidGrant,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewGrantRepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(consumeAuthorizationCodeQuery)).WithArgs("hash")
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			code, err := repo.ConsumeAuthorizationCode("hash")
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(2), code.ClientID)
+				assert.Equal(t, "u", code.UserPublicID)
+				assert.Equal(t, "challenge", code.CodeChallenge)
+				assert.Equal(t, []string{"user:read"}, code.Scopes)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateGrant(t *testing.T) {
+	logger.Init("dev")
+
+	grantID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		refreshTokenID string
+	}{
+		{name: "with refresh token", refreshTokenID: uuid.New().String()},
+		{name: "without refresh token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewGrantRepository(db)
+
+			scopes := []string{"user:read"}
+			mock.ExpectQuery(regexp.QuoteMeta(createGrantQuery)).
+				WithArgs(int64(1), int64(2), pq.Array(scopes),
+					sql.NullString{String: tt.refreshTokenID, Valid: tt.refreshTokenID != ""}, expiresAt).
+				WillReturnRows(sqlmock.NewRows([]string{"public_id"}).AddRow(grantID))
+
+			got, err := repo.CreateGrant(oauth_types.Grant{
+				ClientID:       1,
+				UserID:         2,
+				Scopes:         scopes,
+				RefreshTokenID: tt.refreshTokenID,
+				ExpiresAt:      expiresAt,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, grantID, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetGrant(t *testing.T) {
+	logger.Init("dev")
+
+	grantID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectError error
+	}{
+		{
+			name: "active grant",
+			mockRows: sqlmock.NewRows([]string{"id", "public_id", "client_id", "client_public_id", "user_id", "user_public_id", "scopes", "refresh_token_id", "created_at", "expires_at"}).
+				AddRow(1, grantID.String(), 2, "c", 3, "u", "{user:read}", "jti", now, now.Add(time.Hour)),
+		},
+		{
+			name:        "revoked, expired or client deleted",
+			mockError:   sql.ErrNoRows,
+			expectError: oauth_errors.ErrInvalidGrant,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: fmt.Errorf("db error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewGrantRepository(db)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getGrantQuery)).WithArgs(grantID)
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			grant, err := repo.GetGrant(grantID)
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "c", grant.ClientPublicID)
+				assert.Equal(t, "u", grant.UserPublicID)
+				assert.Equal(t, "jti", grant.RefreshTokenID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRotateGrant(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		affected    int64
+		mockError   error
+		expectError error
+	}{
+		{name: "success", affected: 1},
+		{name: "stale refresh token", affected: 0, expectError: oauth_errors.ErrInvalidGrant},
+		{name: "database error", mockError: fmt.Errorf("db error"), expectError: fmt.Errorf("db error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewGrantRepository(db)
+
+			grantID, currentID, newID := uuid.New(), uuid.New(), uuid.New()
+			expiresAt := time.Now().Add(time.Hour)
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(rotateGrantQuery)).
+				WithArgs(grantID, currentID, newID, expiresAt)
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			err = repo.RotateGrant(grantID, currentID, newID, expiresAt)
+
+			if tt.expectError != nil {
+				assert.EqualError(t, err, tt.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeGrant(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewGrantRepository(db)
+	grantID := uuid.New()
+
+	mock.ExpectExec(regexp.QuoteMeta(revokeGrantQuery)).
+		WithArgs(grantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.RevokeGrant(grantID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
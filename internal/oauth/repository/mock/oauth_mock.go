@@ -0,0 +1,82 @@
+package oauth_repository_mock
+
+import (
+	"time"
+
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockClientRepository struct {
+	mock.Mock
+}
+
+func (m *MockClientRepository) CreateClient(client oauth_types.Client) (*oauth_types.Client, error) {
+	args := m.Called(client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.Client), args.Error(1)
+}
+
+func (m *MockClientRepository) ListClients(userID int64) ([]oauth_types.Client, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]oauth_types.Client), args.Error(1)
+}
+
+func (m *MockClientRepository) GetClient(clientID uuid.UUID) (*oauth_types.Client, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.Client), args.Error(1)
+}
+
+func (m *MockClientRepository) RevokeClient(userID int64, clientID uuid.UUID) error {
+	args := m.Called(userID, clientID)
+	return args.Error(0)
+}
+
+type MockGrantRepository struct {
+	mock.Mock
+}
+
+func (m *MockGrantRepository) CreateAuthorizationCode(code oauth_types.AuthorizationCode) error {
+	args := m.Called(code)
+	return args.Error(0)
+}
+
+func (m *MockGrantRepository) ConsumeAuthorizationCode(codeHash string) (*oauth_types.AuthorizationCode, error) {
+	args := m.Called(codeHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.AuthorizationCode), args.Error(1)
+}
+
+func (m *MockGrantRepository) CreateGrant(grant oauth_types.Grant) (uuid.UUID, error) {
+	args := m.Called(grant)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockGrantRepository) GetGrant(grantID uuid.UUID) (*oauth_types.Grant, error) {
+	args := m.Called(grantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.Grant), args.Error(1)
+}
+
+func (m *MockGrantRepository) RotateGrant(grantID uuid.UUID, currentTokenID uuid.UUID, newTokenID uuid.UUID, expiresAt time.Time) error {
+	args := m.Called(grantID, currentTokenID, newTokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockGrantRepository) RevokeGrant(grantID uuid.UUID) error {
+	args := m.Called(grantID)
+	return args.Error(0)
+}
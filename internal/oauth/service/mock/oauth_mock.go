@@ -0,0 +1,74 @@
+package oauth_service_mock
+
+import (
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOAuthService struct {
+	mock.Mock
+}
+
+func (m *MockOAuthService) RegisterClient(userPublicID uuid.UUID, registerRequest oauth_types.RegisterClientRequest) (*oauth_types.RegisterClientResponse, error) {
+	args := m.Called(userPublicID, registerRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.RegisterClientResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) ListClients(userPublicID uuid.UUID) ([]oauth_types.Client, error) {
+	args := m.Called(userPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]oauth_types.Client), args.Error(1)
+}
+
+func (m *MockOAuthService) RevokeClient(userPublicID uuid.UUID, clientID uuid.UUID) error {
+	args := m.Called(userPublicID, clientID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthService) GetConsent(authRequest oauth_types.AuthorizationRequest) (*oauth_types.Consent, error) {
+	args := m.Called(authRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.Consent), args.Error(1)
+}
+
+func (m *MockOAuthService) Authorize(userPublicID uuid.UUID, consentRequest oauth_types.ConsentRequest) (*oauth_types.ConsentResponse, error) {
+	args := m.Called(userPublicID, consentRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.ConsentResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) Token(client oauth_types.ClientCredentials, tokenRequest oauth_types.TokenRequest) (*oauth_types.TokenResponse, error) {
+	args := m.Called(client, tokenRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.TokenResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) Introspect(client oauth_types.ClientCredentials, token string) (*oauth_types.IntrospectionResponse, error) {
+	args := m.Called(client, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth_types.IntrospectionResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) Revoke(client oauth_types.ClientCredentials, token string) error {
+	args := m.Called(client, token)
+	return args.Error(0)
+}
+
+func (m *MockOAuthService) CheckGrant(grantID string) error {
+	args := m.Called(grantID)
+	return args.Error(0)
+}
@@ -0,0 +1,565 @@
+package oauth_service
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_repository "github.com/celio001/prodify/internal/oauth/repository"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	user_repository "github.com/celio001/prodify/internal/user/repository"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// client secrets look like cs_<secret>, the prefix only helps secret scanners
+	clientSecretPrefix = "cs_"
+
+	authorizationCodeTTL = 5 * time.Minute
+
+	// RFC 7636 4.1, a verifier is 43 to 128 characters and so is its S256 challenge
+	minCodeChallengeLength = 43
+	maxCodeChallengeLength = 128
+)
+
+type oauthService struct {
+	clientRepo oauth_repository.ClientRepository
+	grantRepo  oauth_repository.GrantRepository
+	userRepo   user_repository.UserRepository
+}
+
+type OAuthService interface {
+	RegisterClient(userPublicID uuid.UUID, registerRequest oauth_types.RegisterClientRequest) (*oauth_types.RegisterClientResponse, error)
+	ListClients(userPublicID uuid.UUID) ([]oauth_types.Client, error)
+	RevokeClient(userPublicID uuid.UUID, clientID uuid.UUID) error
+	GetConsent(authRequest oauth_types.AuthorizationRequest) (*oauth_types.Consent, error)
+	Authorize(userPublicID uuid.UUID, consentRequest oauth_types.ConsentRequest) (*oauth_types.ConsentResponse, error)
+	Token(client oauth_types.ClientCredentials, tokenRequest oauth_types.TokenRequest) (*oauth_types.TokenResponse, error)
+	Introspect(client oauth_types.ClientCredentials, token string) (*oauth_types.IntrospectionResponse, error)
+	Revoke(client oauth_types.ClientCredentials, token string) error
+	CheckGrant(grantID string) error
+}
+
+func NewOAuthService(clientRepo oauth_repository.ClientRepository, grantRepo oauth_repository.GrantRepository, userRepo user_repository.UserRepository) OAuthService {
+	return &oauthService{
+		clientRepo: clientRepo,
+		grantRepo:  grantRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *oauthService) RegisterClient(userPublicID uuid.UUID, registerRequest oauth_types.RegisterClientRequest) (*oauth_types.RegisterClientResponse, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := oauth_types.Client{
+		UserID:       user.ID,
+		Name:         registerRequest.Name,
+		RedirectURIs: registerRequest.RedirectURIs,
+		Scopes:       registerRequest.Scopes,
+	}
+
+	var secret string
+	if registerRequest.Confidential {
+		plain, _, err := pkg_token.Generate()
+		if err != nil {
+			return nil, err
+		}
+		secret = clientSecretPrefix + plain
+		client.SecretHash = pkg_token.Hash(secret)
+	}
+
+	created, err := s.clientRepo.CreateClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth_types.RegisterClientResponse{
+		Client:       *created,
+		ClientSecret: secret,
+	}, nil
+}
+
+func (s *oauthService) ListClients(userPublicID uuid.UUID) ([]oauth_types.Client, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.ListClients(user.ID)
+}
+
+// RevokeClient deletes one of the user's clients, every token it holds stops working with it.
+func (s *oauthService) RevokeClient(userPublicID uuid.UUID, clientID uuid.UUID) error {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return err
+	}
+
+	return s.clientRepo.RevokeClient(user.ID, clientID)
+}
+
+// GetConsent validates an authorization request and describes it for the consent screen.
+func (s *oauthService) GetConsent(authRequest oauth_types.AuthorizationRequest) (*oauth_types.Consent, error) {
+	client, scopes, err := s.validateAuthorization(authRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	consent := &oauth_types.Consent{
+		ClientID:    client.PublicID,
+		ClientName:  client.Name,
+		RedirectURI: authRequest.RedirectURI,
+		Scopes:      make([]oauth_types.ScopeDescription, 0, len(scopes)),
+	}
+	for _, scope := range scopes {
+		consent.Scopes = append(consent.Scopes, oauth_types.ScopeDescription{
+			Scope:       scope,
+			Description: oauth_types.Scopes[scope],
+		})
+	}
+
+	return consent, nil
+}
+
+// Authorize records the user's answer on the consent screen. Approving issues a
+// single-use code, denying sends access_denied back to the client.
+func (s *oauthService) Authorize(userPublicID uuid.UUID, consentRequest oauth_types.ConsentRequest) (*oauth_types.ConsentResponse, error) {
+	authRequest := consentRequest.AuthorizationRequest
+
+	client, scopes, err := s.validateAuthorization(authRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if !consentRequest.Approve {
+		return &oauth_types.ConsentResponse{
+			RedirectTo: redirectWith(authRequest.RedirectURI, authRequest.State, "error", "access_denied"),
+		}, nil
+	}
+
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	code, codeHash, err := pkg_token.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.grantRepo.CreateAuthorizationCode(oauth_types.AuthorizationCode{
+		CodeHash:      codeHash,
+		ClientID:      client.ID,
+		UserID:        user.ID,
+		RedirectURI:   authRequest.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: authRequest.CodeChallenge,
+		ExpiresAt:     time.Now().Add(authorizationCodeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth_types.ConsentResponse{
+		RedirectTo: redirectWith(authRequest.RedirectURI, authRequest.State, "code", code),
+	}, nil
+}
+
+// Token authenticates the client and runs the requested grant (RFC 6749 section 4 and 6).
+func (s *oauthService) Token(clientCredentials oauth_types.ClientCredentials, tokenRequest oauth_types.TokenRequest) (*oauth_types.TokenResponse, error) {
+	client, err := s.authenticateClient(clientCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tokenRequest.GrantType {
+	case oauth_types.GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(client, tokenRequest)
+	case oauth_types.GrantTypeRefreshToken:
+		return s.refresh(client, tokenRequest)
+	case oauth_types.GrantTypeClientCredentials:
+		return s.clientCredentials(client, tokenRequest)
+	case "":
+		return nil, oauth_errors.ErrInvalidRequest
+	default:
+		return nil, oauth_errors.ErrUnsupportedGrantType
+	}
+}
+
+// Introspect reports whether a token is active (RFC 7662). Clients can only
+// introspect their own tokens, any other token is reported inactive.
+func (s *oauthService) Introspect(clientCredentials oauth_types.ClientCredentials, rawToken string) (*oauth_types.IntrospectionResponse, error) {
+	client, err := s.authenticateClient(clientCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	inactive := &oauth_types.IntrospectionResponse{Active: false}
+
+	token, tokenType, claims, err := parseOAuthToken(rawToken)
+	if err != nil || claims.ClientID != client.PublicID {
+		return inactive, nil
+	}
+
+	grant, err := s.getGrant(claims.GrantID)
+	if err != nil {
+		if err == oauth_errors.ErrInvalidGrant {
+			return inactive, nil
+		}
+		return nil, err
+	}
+
+	if grant.ClientPublicID != client.PublicID {
+		return inactive, nil
+	}
+	if tokenType == "oauth_refresh" && claims.TokenID != grant.RefreshTokenID {
+		return inactive, nil
+	}
+
+	response := &oauth_types.IntrospectionResponse{
+		Active:   true,
+		Scope:    strings.Join(claims.Scopes, " "),
+		ClientID: client.PublicID,
+		Sub:      grant.UserPublicID,
+	}
+	if tokenType == "oauth_access" {
+		response.TokenType = "Bearer"
+	}
+	if exp, err := token.Claims.GetExpirationTime(); err == nil && exp != nil {
+		response.Exp = exp.Unix()
+	}
+	if iat, err := token.Claims.GetIssuedAt(); err == nil && iat != nil {
+		response.Iat = iat.Unix()
+	}
+
+	return response, nil
+}
+
+// Revoke ends the grant behind an access or refresh token (RFC 7009), so both
+// stop working. Invalid tokens and tokens of other clients are ignored.
+func (s *oauthService) Revoke(clientCredentials oauth_types.ClientCredentials, rawToken string) error {
+	client, err := s.authenticateClient(clientCredentials)
+	if err != nil {
+		return err
+	}
+
+	_, _, claims, err := parseOAuthToken(rawToken)
+	if err != nil || claims.ClientID != client.PublicID {
+		return nil
+	}
+
+	grantID, err := uuid.Parse(claims.GrantID)
+	if err != nil {
+		return nil
+	}
+
+	return s.grantRepo.RevokeGrant(grantID)
+}
+
+// CheckGrant tells the auth middleware whether the grant behind an access token is still honoured.
+func (s *oauthService) CheckGrant(grantID string) error {
+	_, err := s.getGrant(grantID)
+	return err
+}
+
+func (s *oauthService) exchangeAuthorizationCode(client *oauth_types.Client, tokenRequest oauth_types.TokenRequest) (*oauth_types.TokenResponse, error) {
+	if tokenRequest.Code == "" || tokenRequest.CodeVerifier == "" || tokenRequest.RedirectURI == "" {
+		return nil, oauth_errors.ErrInvalidRequest
+	}
+
+	code, err := s.grantRepo.ConsumeAuthorizationCode(pkg_token.Hash(tokenRequest.Code))
+	if err != nil {
+		return nil, err
+	}
+
+	if code.ClientID != client.ID ||
+		code.RedirectURI != tokenRequest.RedirectURI ||
+		!verifyCodeChallenge(tokenRequest.CodeVerifier, code.CodeChallenge) {
+		return nil, oauth_errors.ErrInvalidGrant
+	}
+
+	tokenID := uuid.New()
+	grantID, err := s.grantRepo.CreateGrant(oauth_types.Grant{
+		ClientID:       client.ID,
+		UserID:         code.UserID,
+		Scopes:         code.Scopes,
+		RefreshTokenID: tokenID.String(),
+		ExpiresAt:      time.Now().Add(pkg_jwt.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenResponse(pkg_jwt.OAuthClaims{
+		UserID:   code.UserPublicID,
+		ClientID: client.PublicID,
+		GrantID:  grantID.String(),
+		Scopes:   code.Scopes,
+		TokenID:  tokenID.String(),
+	})
+}
+
+// clientCredentials issues a token acting as the client's owner, like an API key
+// would. Only confidential clients can use it and no refresh token is issued.
+func (s *oauthService) clientCredentials(client *oauth_types.Client, tokenRequest oauth_types.TokenRequest) (*oauth_types.TokenResponse, error) {
+	if !client.Confidential {
+		return nil, oauth_errors.ErrUnauthorizedClient
+	}
+
+	scopes, err := parseScopes(tokenRequest.Scope, client.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	grantID, err := s.grantRepo.CreateGrant(oauth_types.Grant{
+		ClientID:  client.ID,
+		UserID:    client.UserID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(pkg_jwt.OAuthAccessTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenResponse(pkg_jwt.OAuthClaims{
+		UserID:   client.UserPublicID,
+		ClientID: client.PublicID,
+		GrantID:  grantID.String(),
+		Scopes:   scopes,
+	})
+}
+
+// refresh rotates the grant's refresh token. Presenting one that was already
+// rotated means it leaked, the whole grant is revoked.
+func (s *oauthService) refresh(client *oauth_types.Client, tokenRequest oauth_types.TokenRequest) (*oauth_types.TokenResponse, error) {
+	if tokenRequest.RefreshToken == "" {
+		return nil, oauth_errors.ErrInvalidRequest
+	}
+
+	_, tokenType, claims, err := parseOAuthToken(tokenRequest.RefreshToken)
+	if err != nil || tokenType != "oauth_refresh" || claims.ClientID != client.PublicID {
+		return nil, oauth_errors.ErrInvalidGrant
+	}
+
+	grantID, err := uuid.Parse(claims.GrantID)
+	if err != nil {
+		return nil, oauth_errors.ErrInvalidGrant
+	}
+	tokenID, err := uuid.Parse(claims.TokenID)
+	if err != nil {
+		return nil, oauth_errors.ErrInvalidGrant
+	}
+
+	grant, err := s.grantRepo.GetGrant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if grant.ClientPublicID != client.PublicID {
+		return nil, oauth_errors.ErrInvalidGrant
+	}
+
+	// the refreshed access token may ask for less, never for more
+	scopes, err := parseScopes(tokenRequest.Scope, grant.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	newTokenID := uuid.New()
+	err = s.grantRepo.RotateGrant(grantID, tokenID, newTokenID, time.Now().Add(pkg_jwt.RefreshTokenTTL))
+	if err == oauth_errors.ErrInvalidGrant {
+		logger.Log.Warn("oauth refresh token reused, revoking grant", zap.String("grant_id", grantID.String()))
+		if err := s.grantRepo.RevokeGrant(grantID); err != nil {
+			return nil, err
+		}
+		return nil, oauth_errors.ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newTokenResponse(pkg_jwt.OAuthClaims{
+		UserID:   grant.UserPublicID,
+		ClientID: client.PublicID,
+		GrantID:  grant.PublicID,
+		Scopes:   scopes,
+		TokenID:  newTokenID.String(),
+	})
+}
+
+// authenticateClient checks the client secret of confidential clients. Public
+// clients must not send one. Every rejection is ErrInvalidClient.
+func (s *oauthService) authenticateClient(clientCredentials oauth_types.ClientCredentials) (*oauth_types.Client, error) {
+	clientID, err := uuid.Parse(clientCredentials.ClientID)
+	if err != nil {
+		return nil, oauth_errors.ErrInvalidClient
+	}
+
+	client, err := s.clientRepo.GetClient(clientID)
+	if err != nil {
+		if err == oauth_errors.ErrClientNotFound {
+			return nil, oauth_errors.ErrInvalidClient
+		}
+		return nil, err
+	}
+
+	if !client.Confidential {
+		if clientCredentials.ClientSecret != "" {
+			return nil, oauth_errors.ErrInvalidClient
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(pkg_token.Hash(clientCredentials.ClientSecret))) != 1 {
+		return nil, oauth_errors.ErrInvalidClient
+	}
+	return client, nil
+}
+
+// validateAuthorization checks the client and redirect uri first, so a request for
+// an unknown client is never redirected anywhere.
+func (s *oauthService) validateAuthorization(authRequest oauth_types.AuthorizationRequest) (*oauth_types.Client, []string, error) {
+	clientID, err := uuid.Parse(authRequest.ClientID)
+	if err != nil {
+		return nil, nil, oauth_errors.ErrClientNotFound
+	}
+
+	client, err := s.clientRepo.GetClient(clientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !slices.Contains(client.RedirectURIs, authRequest.RedirectURI) {
+		return nil, nil, oauth_errors.ErrInvalidRedirectURI
+	}
+
+	if authRequest.ResponseType != oauth_types.ResponseTypeCode {
+		return nil, nil, oauth_errors.ErrUnsupportedResponseType
+	}
+
+	if authRequest.CodeChallengeMethod != oauth_types.CodeChallengeMethodS256 ||
+		len(authRequest.CodeChallenge) < minCodeChallengeLength ||
+		len(authRequest.CodeChallenge) > maxCodeChallengeLength {
+		return nil, nil, oauth_errors.ErrPKCERequired
+	}
+
+	scopes, err := parseScopes(authRequest.Scope, client.Scopes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, scopes, nil
+}
+
+func (s *oauthService) getGrant(grantID string) (*oauth_types.Grant, error) {
+	id, err := uuid.Parse(grantID)
+	if err != nil {
+		return nil, oauth_errors.ErrInvalidGrant
+	}
+
+	return s.grantRepo.GetGrant(id)
+}
+
+func newTokenResponse(claims pkg_jwt.OAuthClaims) (*oauth_types.TokenResponse, error) {
+	accessToken, err := pkg_jwt.CreateOAuthAccessToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &oauth_types.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(pkg_jwt.OAuthAccessTokenTTL.Seconds()),
+		Scope:       strings.Join(claims.Scopes, " "),
+	}
+
+	if claims.TokenID != "" {
+		response.RefreshToken, err = pkg_jwt.CreateOAuthRefreshToken(claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// parseOAuthToken accepts tokens issued to oauth clients only, never first-party ones.
+func parseOAuthToken(rawToken string) (*jwt.Token, string, pkg_jwt.OAuthClaims, error) {
+	token, err := pkg_jwt.ParseToken(rawToken)
+	if err != nil {
+		return nil, "", pkg_jwt.OAuthClaims{}, err
+	}
+
+	tokenType, err := pkg_jwt.IsAccessToken(token)
+	if err != nil {
+		return nil, "", pkg_jwt.OAuthClaims{}, err
+	}
+	if tokenType != "oauth_access" && tokenType != "oauth_refresh" {
+		return nil, "", pkg_jwt.OAuthClaims{}, pkg_jwt.ErrInvalidToken
+	}
+
+	claims, err := pkg_jwt.GetOAuthClaimsFromToken(token)
+	if err != nil {
+		return nil, "", pkg_jwt.OAuthClaims{}, err
+	}
+
+	return token, tokenType, claims, nil
+}
+
+// parseScopes splits a space-delimited scope parameter. An empty one asks for
+// everything allowed, anything outside allowed is ErrInvalidScope.
+func parseScopes(scope string, allowed []string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return slices.Clone(allowed), nil
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, s := range requested {
+		if _, known := oauth_types.Scopes[s]; !known || !slices.Contains(allowed, s) {
+			return nil, oauth_errors.ErrInvalidScope
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes, nil
+}
+
+// verifyCodeChallenge checks a PKCE verifier against its S256 challenge (RFC 7636 4.6).
+func verifyCodeChallenge(verifier string, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// redirectWith adds a parameter and the client's state to a registered redirect uri.
+func redirectWith(redirectURI string, state string, key string, value string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	query.Set(key, value)
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
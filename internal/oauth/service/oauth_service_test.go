@@ -0,0 +1,528 @@
+package oauth_service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+
+	oauth_errors "github.com/celio001/prodify/internal/oauth/errors"
+	oauth_repository_mock "github.com/celio001/prodify/internal/oauth/repository/mock"
+	oauth_types "github.com/celio001/prodify/internal/oauth/types"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	pkg_jwt "github.com/celio001/prodify/pkg/jwt"
+	"github.com/celio001/prodify/pkg/logger"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	redirectURI  = "https://partner.example/callback"
+	clientSecret = "cs_secret"
+	codeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+type fixture struct {
+	service    OAuthService
+	clientRepo *oauth_repository_mock.MockClientRepository
+	grantRepo  *oauth_repository_mock.MockGrantRepository
+	userRepo   *user_mock.MockUserRepository
+	client     *oauth_types.Client
+}
+
+func newFixture(confidential bool) fixture {
+	clientRepo := new(oauth_repository_mock.MockClientRepository)
+	grantRepo := new(oauth_repository_mock.MockGrantRepository)
+	userRepo := new(user_mock.MockUserRepository)
+
+	client := &oauth_types.Client{
+		ID:           1,
+		PublicID:     uuid.New().String(),
+		UserID:       7,
+		UserPublicID: uuid.New().String(),
+		Name:         "Partner",
+		RedirectURIs: []string{redirectURI},
+		Scopes:       []string{"user:read", "user:write"},
+		Confidential: confidential,
+	}
+	if confidential {
+		client.SecretHash = pkg_token.Hash(clientSecret)
+	}
+	clientRepo.On("GetClient", uuid.MustParse(client.PublicID)).Return(client, nil)
+
+	return fixture{
+		service:    NewOAuthService(clientRepo, grantRepo, userRepo),
+		clientRepo: clientRepo,
+		grantRepo:  grantRepo,
+		userRepo:   userRepo,
+		client:     client,
+	}
+}
+
+func (f fixture) credentials() oauth_types.ClientCredentials {
+	secret := ""
+	if f.client.Confidential {
+		secret = clientSecret
+	}
+	return oauth_types.ClientCredentials{ClientID: f.client.PublicID, ClientSecret: secret}
+}
+
+func (f fixture) authRequest() oauth_types.AuthorizationRequest {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return oauth_types.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            f.client.PublicID,
+		RedirectURI:         redirectURI,
+		Scope:               "user:read",
+		State:               "xyz",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestRegisterClient(t *testing.T) {
+	logger.Init("dev")
+
+	for _, confidential := range []bool{true, false} {
+		f := newFixture(confidential)
+		userPublicID := uuid.New()
+
+		f.userRepo.On("GetUserByPublicID", userPublicID).Return(&user_types.GetUserResponse{ID: 7}, nil)
+
+		var stored oauth_types.Client
+		f.clientRepo.On("CreateClient", mock.Anything).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(oauth_types.Client) }).
+			Return(&oauth_types.Client{PublicID: "id", Confidential: confidential}, nil)
+
+		response, err := f.service.RegisterClient(userPublicID, oauth_types.RegisterClientRequest{
+			Name:         "Partner",
+			RedirectURIs: []string{redirectURI},
+			Scopes:       []string{"user:read"},
+			Confidential: confidential,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), stored.UserID)
+		if confidential {
+			assert.True(t, strings.HasPrefix(response.ClientSecret, "cs_"))
+			assert.Equal(t, pkg_token.Hash(response.ClientSecret), stored.SecretHash)
+		} else {
+			assert.Empty(t, response.ClientSecret)
+			assert.Empty(t, stored.SecretHash)
+		}
+	}
+}
+
+func TestGetConsent(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		tamper      func(r *oauth_types.AuthorizationRequest)
+		expectError error
+	}{
+		{name: "valid request", tamper: func(r *oauth_types.AuthorizationRequest) {}},
+		{
+			name:        "unknown client",
+			tamper:      func(r *oauth_types.AuthorizationRequest) { r.ClientID = "not-a-uuid" },
+			expectError: oauth_errors.ErrClientNotFound,
+		},
+		{
+			name:        "unregistered redirect uri",
+			tamper:      func(r *oauth_types.AuthorizationRequest) { r.RedirectURI = "https://evil.example/callback" },
+			expectError: oauth_errors.ErrInvalidRedirectURI,
+		},
+		{
+			name:        "implicit flow",
+			tamper:      func(r *oauth_types.AuthorizationRequest) { r.ResponseType = "token" },
+			expectError: oauth_errors.ErrUnsupportedResponseType,
+		},
+		{
+			name:        "missing pkce",
+			tamper:      func(r *oauth_types.AuthorizationRequest) { r.CodeChallenge = "" },
+			expectError: oauth_errors.ErrPKCERequired,
+		},
+		{
+			name:        "plain pkce",
+			tamper:      func(r *oauth_types.AuthorizationRequest) { r.CodeChallengeMethod = "plain" },
+			expectError: oauth_errors.ErrPKCERequired,
+		},
+		{
+			name:        "unknown scope",
+			tamper:      func(r *oauth_types.AuthorizationRequest) { r.Scope = "user:read admin" },
+			expectError: oauth_errors.ErrInvalidScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(true)
+			request := f.authRequest()
+			tt.tamper(&request)
+
+			consent, err := f.service.GetConsent(request)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Partner", consent.ClientName)
+			assert.Equal(t, []oauth_types.ScopeDescription{{Scope: "user:read", Description: "Read your profile"}}, consent.Scopes)
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("approved", func(t *testing.T) {
+		f := newFixture(true)
+		userPublicID := uuid.New()
+		request := f.authRequest()
+
+		f.userRepo.On("GetUserByPublicID", userPublicID).Return(&user_types.GetUserResponse{ID: 3}, nil)
+
+		var stored oauth_types.AuthorizationCode
+		f.grantRepo.On("CreateAuthorizationCode", mock.Anything).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(oauth_types.AuthorizationCode) }).
+			Return(nil)
+
+		response, err := f.service.Authorize(userPublicID, oauth_types.ConsentRequest{AuthorizationRequest: request, Approve: true})
+
+		require.NoError(t, err)
+		redirect, err := url.Parse(response.RedirectTo)
+		require.NoError(t, err)
+		assert.Equal(t, "xyz", redirect.Query().Get("state"))
+		assert.Equal(t, pkg_token.Hash(redirect.Query().Get("code")), stored.CodeHash)
+		assert.Equal(t, int64(3), stored.UserID)
+		assert.Equal(t, request.CodeChallenge, stored.CodeChallenge)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		f := newFixture(true)
+
+		response, err := f.service.Authorize(uuid.New(), oauth_types.ConsentRequest{AuthorizationRequest: f.authRequest()})
+
+		require.NoError(t, err)
+		assert.Equal(t, redirectURI+"?error=access_denied&state=xyz", response.RedirectTo)
+		f.grantRepo.AssertNotCalled(t, "CreateAuthorizationCode", mock.Anything)
+	})
+}
+
+func TestToken_AuthorizationCode(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		confidential bool
+		credentials  func(f fixture) oauth_types.ClientCredentials
+		codeClientID int64
+		verifier     string
+		expectError  error
+	}{
+		{name: "confidential client", confidential: true, codeClientID: 1, verifier: codeVerifier},
+		{name: "public client", codeClientID: 1, verifier: codeVerifier},
+		{
+			name:         "wrong client secret",
+			confidential: true,
+			credentials: func(f fixture) oauth_types.ClientCredentials {
+				return oauth_types.ClientCredentials{ClientID: f.client.PublicID, ClientSecret: "cs_wrong"}
+			},
+			expectError: oauth_errors.ErrInvalidClient,
+		},
+		{
+			name:         "code issued to another client",
+			codeClientID: 2,
+			verifier:     codeVerifier,
+			expectError:  oauth_errors.ErrInvalidGrant,
+		},
+		{
+			name:         "wrong code verifier",
+			codeClientID: 1,
+			verifier:     strings.Repeat("a", 43),
+			expectError:  oauth_errors.ErrInvalidGrant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(tt.confidential)
+			credentials := f.credentials()
+			if tt.credentials != nil {
+				credentials = tt.credentials(f)
+			}
+
+			f.grantRepo.On("ConsumeAuthorizationCode", pkg_token.Hash("code")).Return(&oauth_types.AuthorizationCode{
+				ClientID:      tt.codeClientID,
+				UserID:        3,
+				UserPublicID:  "user",
+				RedirectURI:   redirectURI,
+				Scopes:        []string{"user:read"},
+				CodeChallenge: f.authRequest().CodeChallenge,
+			}, nil)
+			grantID := uuid.New()
+			f.grantRepo.On("CreateGrant", mock.Anything).Return(grantID, nil)
+
+			response, err := f.service.Token(credentials, oauth_types.TokenRequest{
+				GrantType:    "authorization_code",
+				Code:         "code",
+				RedirectURI:  redirectURI,
+				CodeVerifier: tt.verifier,
+			})
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				f.grantRepo.AssertNotCalled(t, "CreateGrant", mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer", response.TokenType)
+			assert.Equal(t, "user:read", response.Scope)
+			assert.NotEmpty(t, response.RefreshToken)
+
+			token, err := pkg_jwt.ParseToken(response.AccessToken)
+			require.NoError(t, err)
+			claims, err := pkg_jwt.GetOAuthClaimsFromToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, grantID.String(), claims.GrantID)
+			assert.Equal(t, "user", claims.UserID)
+		})
+	}
+}
+
+func TestToken_ClientCredentials(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("confidential client acts as its owner", func(t *testing.T) {
+		f := newFixture(true)
+		f.grantRepo.On("CreateGrant", mock.MatchedBy(func(g oauth_types.Grant) bool {
+			return g.UserID == 7 && g.RefreshTokenID == ""
+		})).Return(uuid.New(), nil)
+
+		response, err := f.service.Token(f.credentials(), oauth_types.TokenRequest{GrantType: "client_credentials", Scope: "user:write"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "user:write", response.Scope)
+		assert.Empty(t, response.RefreshToken)
+	})
+
+	t.Run("public client", func(t *testing.T) {
+		f := newFixture(false)
+
+		_, err := f.service.Token(f.credentials(), oauth_types.TokenRequest{GrantType: "client_credentials"})
+
+		assert.ErrorIs(t, err, oauth_errors.ErrUnauthorizedClient)
+	})
+
+	t.Run("scope the client was not registered with", func(t *testing.T) {
+		f := newFixture(true)
+		f.client.Scopes = []string{"user:read"}
+
+		_, err := f.service.Token(f.credentials(), oauth_types.TokenRequest{GrantType: "client_credentials", Scope: "user:write"})
+
+		assert.ErrorIs(t, err, oauth_errors.ErrInvalidScope)
+	})
+
+	t.Run("unsupported grant type", func(t *testing.T) {
+		f := newFixture(true)
+
+		_, err := f.service.Token(f.credentials(), oauth_types.TokenRequest{GrantType: "password"})
+
+		assert.ErrorIs(t, err, oauth_errors.ErrUnsupportedGrantType)
+	})
+}
+
+func TestToken_RefreshToken(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		rotateError error
+		expectError error
+	}{
+		{name: "rotates the refresh token"},
+		{name: "reused refresh token revokes the grant", rotateError: oauth_errors.ErrInvalidGrant, expectError: oauth_errors.ErrInvalidGrant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(true)
+			grantID, tokenID := uuid.New(), uuid.New()
+
+			refreshToken, err := pkg_jwt.CreateOAuthRefreshToken(pkg_jwt.OAuthClaims{
+				UserID:   "user",
+				ClientID: f.client.PublicID,
+				GrantID:  grantID.String(),
+				Scopes:   []string{"user:read"},
+				TokenID:  tokenID.String(),
+			})
+			require.NoError(t, err)
+
+			f.grantRepo.On("GetGrant", grantID).Return(&oauth_types.Grant{
+				PublicID:       grantID.String(),
+				ClientPublicID: f.client.PublicID,
+				UserPublicID:   "user",
+				Scopes:         []string{"user:read"},
+				RefreshTokenID: tokenID.String(),
+			}, nil)
+			f.grantRepo.On("RotateGrant", grantID, tokenID, mock.Anything, mock.Anything).Return(tt.rotateError)
+			f.grantRepo.On("RevokeGrant", grantID).Return(nil)
+
+			response, err := f.service.Token(f.credentials(), oauth_types.TokenRequest{GrantType: "refresh_token", RefreshToken: refreshToken})
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				f.grantRepo.AssertCalled(t, "RevokeGrant", grantID)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEqual(t, refreshToken, response.RefreshToken)
+			f.grantRepo.AssertNotCalled(t, "RevokeGrant", grantID)
+		})
+	}
+}
+
+func TestToken_RefreshTokenOfAnotherClient(t *testing.T) {
+	logger.Init("dev")
+
+	f := newFixture(true)
+	refreshToken, _ := pkg_jwt.CreateOAuthRefreshToken(pkg_jwt.OAuthClaims{
+		UserID:   "user",
+		ClientID: uuid.New().String(),
+		GrantID:  uuid.New().String(),
+		TokenID:  uuid.New().String(),
+	})
+
+	_, err := f.service.Token(f.credentials(), oauth_types.TokenRequest{GrantType: "refresh_token", RefreshToken: refreshToken})
+
+	assert.ErrorIs(t, err, oauth_errors.ErrInvalidGrant)
+	f.grantRepo.AssertNotCalled(t, "GetGrant", mock.Anything)
+}
+
+func TestIntrospect(t *testing.T) {
+	logger.Init("dev")
+
+	f := newFixture(true)
+	grantID := uuid.New()
+	revokedGrantID := uuid.New()
+
+	f.grantRepo.On("GetGrant", grantID).Return(&oauth_types.Grant{
+		PublicID:       grantID.String(),
+		ClientPublicID: f.client.PublicID,
+		UserPublicID:   "user",
+		RefreshTokenID: "current",
+	}, nil)
+	f.grantRepo.On("GetGrant", revokedGrantID).Return(nil, oauth_errors.ErrInvalidGrant)
+
+	token := func(clientID string, grantID uuid.UUID) string {
+		t, _ := pkg_jwt.CreateOAuthAccessToken(pkg_jwt.OAuthClaims{UserID: "user", ClientID: clientID, GrantID: grantID.String(), Scopes: []string{"user:read"}})
+		return t
+	}
+	staleRefresh, _ := pkg_jwt.CreateOAuthRefreshToken(pkg_jwt.OAuthClaims{UserID: "user", ClientID: f.client.PublicID, GrantID: grantID.String(), TokenID: "old"})
+	firstParty, _ := pkg_jwt.CreateAccessToken("user", uuid.New().String())
+
+	tests := []struct {
+		name   string
+		token  string
+		active bool
+	}{
+		{name: "active access token", token: token(f.client.PublicID, grantID), active: true},
+		{name: "token of another client", token: token(uuid.New().String(), grantID)},
+		{name: "revoked grant", token: token(f.client.PublicID, revokedGrantID)},
+		{name: "rotated refresh token", token: staleRefresh},
+		{name: "first-party access token", token: firstParty},
+		{name: "garbage", token: "garbage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := f.service.Introspect(f.credentials(), tt.token)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.active, response.Active)
+			if tt.active {
+				assert.Equal(t, "user:read", response.Scope)
+				assert.Equal(t, "user", response.Sub)
+				assert.Equal(t, "Bearer", response.TokenType)
+				assert.NotZero(t, response.Exp)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	logger.Init("dev")
+
+	f := newFixture(true)
+	grantID := uuid.New()
+	f.grantRepo.On("RevokeGrant", grantID).Return(nil)
+
+	own, _ := pkg_jwt.CreateOAuthAccessToken(pkg_jwt.OAuthClaims{UserID: "user", ClientID: f.client.PublicID, GrantID: grantID.String()})
+	other, _ := pkg_jwt.CreateOAuthAccessToken(pkg_jwt.OAuthClaims{UserID: "user", ClientID: uuid.New().String(), GrantID: uuid.New().String()})
+
+	assert.NoError(t, f.service.Revoke(f.credentials(), own))
+	assert.NoError(t, f.service.Revoke(f.credentials(), other))
+	assert.NoError(t, f.service.Revoke(f.credentials(), "garbage"))
+
+	f.grantRepo.AssertNumberOfCalls(t, "RevokeGrant", 1)
+}
+
+func TestClientAuthentication(t *testing.T) {
+	logger.Init("dev")
+
+	unknownClient := uuid.New()
+
+	tests := []struct {
+		name         string
+		confidential bool
+		credentials  func(f fixture) oauth_types.ClientCredentials
+	}{
+		{
+			name:         "confidential client without secret",
+			confidential: true,
+			credentials: func(f fixture) oauth_types.ClientCredentials {
+				return oauth_types.ClientCredentials{ClientID: f.client.PublicID}
+			},
+		},
+		{
+			name: "public client with a secret",
+			credentials: func(f fixture) oauth_types.ClientCredentials {
+				return oauth_types.ClientCredentials{ClientID: f.client.PublicID, ClientSecret: clientSecret}
+			},
+		},
+		{
+			name: "unknown client",
+			credentials: func(f fixture) oauth_types.ClientCredentials {
+				return oauth_types.ClientCredentials{ClientID: unknownClient.String()}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(tt.confidential)
+			f.clientRepo.On("GetClient", unknownClient).Return(nil, oauth_errors.ErrClientNotFound)
+
+			_, err := f.service.Introspect(tt.credentials(f), "token")
+
+			assert.ErrorIs(t, err, oauth_errors.ErrInvalidClient)
+		})
+	}
+}
+
+func TestCheckGrant(t *testing.T) {
+	logger.Init("dev")
+
+	f := newFixture(true)
+	grantID := uuid.New()
+	f.grantRepo.On("GetGrant", grantID).Return(&oauth_types.Grant{}, nil)
+
+	assert.NoError(t, f.service.CheckGrant(grantID.String()))
+	assert.ErrorIs(t, f.service.CheckGrant("not-a-uuid"), oauth_errors.ErrInvalidGrant)
+}
@@ -0,0 +1,149 @@
+package oauth_types
+
+import (
+	"time"
+
+	apikey_types "github.com/celio001/prodify/internal/apikey/types"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+
+	ResponseTypeCode        = "code"
+	CodeChallengeMethodS256 = "S256"
+)
+
+// Scopes a client can be granted, with the text shown on the consent screen. They
+// are the permissions API keys use, enforced per route by middleware.RequireScope.
+var Scopes = map[string]string{
+	apikey_types.ScopeUserRead:     "Read your profile",
+	apikey_types.ScopeUserWrite:    "Update your profile",
+	apikey_types.ScopeProductRead:  "Read the products of your organizations",
+	apikey_types.ScopeProductWrite: "Create, update and delete products in your organizations",
+}
+
+type Client struct {
+	ID           int64     `json:"-"`
+	PublicID     string    `json:"client_id"`
+	UserID       int64     `json:"-"`
+	Name         string    `json:"name"`
+	SecretHash   string    `json:"-"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// UserPublicID is the owner, only filled when the client is looked up to authenticate it
+	UserPublicID string `json:"-"`
+}
+
+type RegisterClientRequest struct {
+	Name         string   `json:"name" validate:"required,min=3,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,max=10,dive,url"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=user:read user:write product:read product:write"`
+	// Confidential clients get a secret; public ones (mobile, SPA) rely on PKCE alone
+	Confidential bool `json:"confidential"`
+}
+
+type RegisterClientResponse struct {
+	Client
+	// ClientSecret is only returned once at registration
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizationRequest is the query of an authorization request (RFC 6749 4.1.1, RFC 7636 4.3).
+type AuthorizationRequest struct {
+	ResponseType        string `json:"response_type" query:"response_type"`
+	ClientID            string `json:"client_id" query:"client_id"`
+	RedirectURI         string `json:"redirect_uri" query:"redirect_uri"`
+	Scope               string `json:"scope" query:"scope"`
+	State               string `json:"state" query:"state"`
+	CodeChallenge       string `json:"code_challenge" query:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method"`
+}
+
+// ConsentRequest is sent by the consent screen once the user made their choice.
+type ConsentRequest struct {
+	AuthorizationRequest
+	Approve bool `json:"approve"`
+}
+
+type ScopeDescription struct {
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+}
+
+// Consent is what the consent screen shows the user before they approve a client.
+type Consent struct {
+	ClientID    string             `json:"client_id"`
+	ClientName  string             `json:"client_name"`
+	RedirectURI string             `json:"redirect_uri"`
+	Scopes      []ScopeDescription `json:"scopes"`
+}
+
+type ConsentResponse struct {
+	// RedirectTo is where the browser goes next, the client's redirect_uri with
+	// either a code or an error
+	RedirectTo string `json:"redirect_to"`
+}
+
+type AuthorizationCode struct {
+	ID            int64
+	CodeHash      string
+	ClientID      int64
+	UserID        int64
+	UserPublicID  string
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+type Grant struct {
+	ID             int64
+	PublicID       string
+	ClientID       int64
+	ClientPublicID string
+	UserID         int64
+	UserPublicID   string
+	Scopes         []string
+	RefreshTokenID string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// ClientCredentials authenticate a client at the token, introspection and revocation endpoints.
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// IntrospectionResponse follows RFC 7662 2.2, inactive tokens only carry Active.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+}
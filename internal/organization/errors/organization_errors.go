@@ -0,0 +1,93 @@
+package organization_errors
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrOrganizationNotFound    = errors.New("organization not found")
+	ErrNotMember               = errors.New("not a member of this organization")
+	ErrMemberNotFound          = errors.New("member not found")
+	ErrAlreadyMember           = errors.New("user is already a member of this organization")
+	ErrInsufficientRole        = errors.New("your role in this organization does not allow this")
+	ErrLastOwner               = errors.New("an organization must keep at least one owner")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvalidInvitation       = errors.New("invalid or expired invitation")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+)
+
+func OrganizationValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+
+			if field == "Name" {
+				switch fieldErr.Tag() {
+				case "required":
+					errors[field] = "name is required"
+				case "min":
+					errors[field] = "name must have at least 2 characters"
+				case "max":
+					errors[field] = "name must have at most 100 characters"
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+func InvitationValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+
+			switch field {
+			case "Email":
+				switch tag {
+				case "required":
+					errors[field] = "email is required"
+				case "email":
+					errors[field] = "invalid email format"
+				case "max":
+					errors[field] = "email must have at most 255 characters"
+				}
+			case "Role":
+				switch tag {
+				case "required":
+					errors[field] = "role is required"
+				case "oneof":
+					errors[field] = "invalid role, allowed: admin, editor, viewer"
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+func MemberRoleValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			if fieldErr.Field() == "Role" {
+				switch fieldErr.Tag() {
+				case "required":
+					errors["Role"] = "role is required"
+				case "oneof":
+					errors["Role"] = "invalid role, allowed: owner, admin, editor, viewer"
+				}
+			}
+		}
+	}
+
+	return errors
+}
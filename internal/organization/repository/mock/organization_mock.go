@@ -0,0 +1,110 @@
+package organization_repository_mock
+
+import (
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationRepository) CreateOrganization(name string, ownerID int64) (*organization_types.Organization, error) {
+	args := m.Called(name, ownerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) ListOrganizations(userPublicID uuid.UUID) ([]organization_types.Organization, error) {
+	args := m.Called(userPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetOrganization(orgPublicID uuid.UUID, userID int64) (*organization_types.Organization, error) {
+	args := m.Called(orgPublicID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error) {
+	args := m.Called(orgPublicID, userPublicID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) UpdateOrganization(orgID int64, name string) error {
+	args := m.Called(orgID, name)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) ListMembers(orgPublicID uuid.UUID) ([]organization_types.Member, error) {
+	args := m.Called(orgPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]organization_types.Member), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetMember(orgPublicID uuid.UUID, userPublicID uuid.UUID) (*organization_types.Member, error) {
+	args := m.Called(orgPublicID, userPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Member), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) UpdateMemberRole(orgPublicID uuid.UUID, userID int64, role string) error {
+	args := m.Called(orgPublicID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) RemoveMember(orgPublicID uuid.UUID, userID int64) error {
+	args := m.Called(orgPublicID, userID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) CountOwners(orgPublicID uuid.UUID) (int, error) {
+	args := m.Called(orgPublicID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) CreateInvitation(orgPublicID uuid.UUID, invitation organization_types.Invitation) (*organization_types.Invitation, error) {
+	args := m.Called(orgPublicID, invitation)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Invitation), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) ListInvitations(orgPublicID uuid.UUID) ([]organization_types.Invitation, error) {
+	args := m.Called(orgPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]organization_types.Invitation), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) RevokeInvitation(orgPublicID uuid.UUID, invitationPublicID uuid.UUID) error {
+	args := m.Called(orgPublicID, invitationPublicID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) GetInvitationByTokenHash(userPublicID uuid.UUID, tokenHash string) (*organization_types.Invitation, error) {
+	args := m.Called(userPublicID, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Invitation), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) AcceptInvitation(orgPublicID uuid.UUID, invitationID int64, userID int64, role string) error {
+	args := m.Called(orgPublicID, invitationID, userID, role)
+	return args.Error(0)
+}
@@ -0,0 +1,529 @@
+package organization_repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/postgress"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Memberships and invitations are picked by the organization public id, which is also
+// the tenant their queries run under with postgress.WithTenant, so the row-level
+// security policies hold even if a filter is missing. Organizations themselves are not
+// covered by the policies.
+const (
+	createOrganizationQuery = `INSERT INTO organizations (public_id, name)
+	VALUES ($1, $2)
+	RETURNING id, created_at, updated_at`
+
+	addMemberQuery = `INSERT INTO organization_members (organization_id, user_id, role)
+	SELECT id, $2, $3
+	FROM organizations
+	WHERE public_id = $1
+	ON CONFLICT (organization_id, user_id) DO NOTHING`
+
+	// runs under postgress.WithCurrentUser, the user's memberships across tenants
+	listOrganizationsQuery = `SELECT o.id, o.public_id, o.name, o.created_at, o.updated_at, m.role
+	FROM organizations o
+	JOIN organization_members m ON m.organization_id = o.id
+	JOIN users u ON u.id = m.user_id
+	WHERE u.public_id = $1
+	ORDER BY o.name`
+
+	getOrganizationQuery = `SELECT o.id, o.public_id, o.name, o.created_at, o.updated_at, m.role
+	FROM organizations o
+	JOIN organization_members m ON m.organization_id = o.id
+	WHERE o.public_id = $1
+	AND m.user_id = $2`
+
+	getMemberRoleQuery = `SELECT m.role
+	FROM organization_members m
+	JOIN organizations o ON o.id = m.organization_id
+	JOIN users u ON u.id = m.user_id
+	WHERE o.public_id = $1
+	AND u.public_id = $2
+	AND u.deleted_at IS NULL
+	AND u.is_active`
+
+	updateOrganizationQuery = `UPDATE organizations
+	SET name = $2, updated_at = now()
+	WHERE id = $1`
+
+	listMembersQuery = `SELECT u.id, u.public_id, u.name, u.email, m.role, m.created_at
+	FROM organization_members m
+	JOIN organizations o ON o.id = m.organization_id
+	JOIN users u ON u.id = m.user_id
+	WHERE o.public_id = $1
+	ORDER BY m.created_at`
+
+	getMemberQuery = `SELECT u.id, u.public_id, u.name, u.email, m.role, m.created_at
+	FROM organization_members m
+	JOIN organizations o ON o.id = m.organization_id
+	JOIN users u ON u.id = m.user_id
+	WHERE o.public_id = $1
+	AND u.public_id = $2`
+
+	updateMemberRoleQuery = `UPDATE organization_members m
+	SET role = $3
+	FROM organizations o
+	WHERE o.id = m.organization_id
+	AND o.public_id = $1
+	AND m.user_id = $2`
+
+	removeMemberQuery = `DELETE FROM organization_members m
+	USING organizations o
+	WHERE o.id = m.organization_id
+	AND o.public_id = $1
+	AND m.user_id = $2`
+
+	countOwnersQuery = `SELECT count(*)
+	FROM organization_members m
+	JOIN organizations o ON o.id = m.organization_id
+	WHERE o.public_id = $1
+	AND m.role = 'owner'`
+
+	revokePendingInvitationsQuery = `UPDATE organization_invitations i
+	SET revoked_at = now()
+	FROM organizations o
+	WHERE o.id = i.organization_id
+	AND o.public_id = $1
+	AND lower(i.email) = lower($2)
+	AND i.accepted_at IS NULL
+	AND i.revoked_at IS NULL`
+
+	createInvitationQuery = `INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at)
+	SELECT id, $2, $3, $4, $5, $6
+	FROM organizations
+	WHERE public_id = $1
+	RETURNING id, public_id, organization_id, created_at`
+
+	listInvitationsQuery = `SELECT i.id, i.public_id, i.organization_id, i.email, i.role, i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+	FROM organization_invitations i
+	JOIN organizations o ON o.id = i.organization_id
+	WHERE o.public_id = $1
+	ORDER BY i.created_at DESC`
+
+	revokeInvitationQuery = `UPDATE organization_invitations i
+	SET revoked_at = now()
+	FROM organizations o
+	WHERE o.id = i.organization_id
+	AND o.public_id = $1
+	AND i.public_id = $2
+	AND i.accepted_at IS NULL
+	AND i.revoked_at IS NULL`
+
+	// runs under postgress.WithCurrentUser, only invitations sent to the user's address
+	// are found
+	getInvitationByTokenHashQuery = `SELECT i.id, i.public_id, i.organization_id, o.public_id, o.name, i.email, i.role,
+	i.created_at, i.expires_at, i.accepted_at, i.revoked_at
+	FROM organization_invitations i
+	JOIN organizations o ON o.id = i.organization_id
+	WHERE i.token_hash = $1`
+
+	acceptInvitationQuery = `UPDATE organization_invitations
+	SET accepted_at = now()
+	WHERE id = $1
+	AND accepted_at IS NULL
+	AND revoked_at IS NULL
+	AND expires_at > now()`
+)
+
+type organizationRepository struct {
+	Db *sql.DB
+}
+
+type OrganizationRepository interface {
+	CreateOrganization(name string, owner_id int64) (*organization_types.Organization, error)
+	ListOrganizations(userPublicID uuid.UUID) ([]organization_types.Organization, error)
+	GetOrganization(orgPublicID uuid.UUID, user_id int64) (*organization_types.Organization, error)
+	GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error)
+	UpdateOrganization(org_id int64, name string) error
+	ListMembers(orgPublicID uuid.UUID) ([]organization_types.Member, error)
+	GetMember(orgPublicID uuid.UUID, userPublicID uuid.UUID) (*organization_types.Member, error)
+	UpdateMemberRole(orgPublicID uuid.UUID, user_id int64, role string) error
+	RemoveMember(orgPublicID uuid.UUID, user_id int64) error
+	CountOwners(orgPublicID uuid.UUID) (int, error)
+	CreateInvitation(orgPublicID uuid.UUID, invitation organization_types.Invitation) (*organization_types.Invitation, error)
+	ListInvitations(orgPublicID uuid.UUID) ([]organization_types.Invitation, error)
+	RevokeInvitation(orgPublicID uuid.UUID, invitationPublicID uuid.UUID) error
+	GetInvitationByTokenHash(userPublicID uuid.UUID, tokenHash string) (*organization_types.Invitation, error)
+	AcceptInvitation(orgPublicID uuid.UUID, invitation_id int64, user_id int64, role string) error
+}
+
+func NewOrganizationRepository(Db *sql.DB) OrganizationRepository {
+	return &organizationRepository{
+		Db: Db,
+	}
+}
+
+// CreateOrganization creates the organization and makes owner_id its owner in one
+// transaction. The public id is picked here, it is the tenant the owner is added under.
+func (r *organizationRepository) CreateOrganization(name string, owner_id int64) (*organization_types.Organization, error) {
+	ctx := context.Background()
+
+	publicID := uuid.New()
+	org := organization_types.Organization{PublicID: publicID.String(), Name: name, Role: organization_types.RoleOwner}
+
+	err := postgress.WithTenant(ctx, r.Db, publicID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, createOrganizationQuery, publicID, name).Scan(&org.ID, &org.CreatedAt, &org.UpdatedAt)
+		if err != nil {
+			logger.Log.Error("error creating organization", zap.String("error", err.Error()))
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, addMemberQuery, publicID, owner_id, organization_types.RoleOwner); err != nil {
+			logger.Log.Error("error adding organization owner", zap.String("error", err.Error()))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) ListOrganizations(userPublicID uuid.UUID) ([]organization_types.Organization, error) {
+	ctx := context.Background()
+
+	orgs := []organization_types.Organization{}
+	err := postgress.WithCurrentUser(ctx, r.Db, userPublicID, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, listOrganizationsQuery, userPublicID)
+		if err != nil {
+			logger.Log.Error("error listing organizations", zap.String("error", err.Error()))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var org organization_types.Organization
+			if err := rows.Scan(&org.ID, &org.PublicID, &org.Name, &org.CreatedAt, &org.UpdatedAt, &org.Role); err != nil {
+				logger.Log.Error("error scanning organization", zap.String("error", err.Error()))
+				return err
+			}
+			orgs = append(orgs, org)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// GetOrganization only finds organizations user_id belongs to, with their role in it.
+func (r *organizationRepository) GetOrganization(orgPublicID uuid.UUID, user_id int64) (*organization_types.Organization, error) {
+	ctx := context.Background()
+
+	var org organization_types.Organization
+	err := postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, getOrganizationQuery, orgPublicID, user_id).
+			Scan(&org.ID, &org.PublicID, &org.Name, &org.CreatedAt, &org.UpdatedAt, &org.Role)
+		if err == sql.ErrNoRows {
+			return organization_errors.ErrOrganizationNotFound
+		} else if err != nil {
+			logger.Log.Error("error getting organization", zap.String("error", err.Error()))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error) {
+	ctx := context.Background()
+
+	var role string
+	err := postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, getMemberRoleQuery, orgPublicID, userPublicID).Scan(&role)
+		if err == sql.ErrNoRows {
+			return organization_errors.ErrNotMember
+		} else if err != nil {
+			logger.Log.Error("error getting member role", zap.String("error", err.Error()))
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+func (r *organizationRepository) UpdateOrganization(org_id int64, name string) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, updateOrganizationQuery, org_id, name)
+	if err != nil {
+		logger.Log.Error("error updating organization", zap.String("error", err.Error()))
+		return err
+	}
+	return requireAffected(result, organization_errors.ErrOrganizationNotFound)
+}
+
+func (r *organizationRepository) ListMembers(orgPublicID uuid.UUID) ([]organization_types.Member, error) {
+	ctx := context.Background()
+
+	members := []organization_types.Member{}
+	err := postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, listMembersQuery, orgPublicID)
+		if err != nil {
+			logger.Log.Error("error listing organization members", zap.String("error", err.Error()))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var member organization_types.Member
+			err := rows.Scan(&member.UserID, &member.UserPublicID, &member.Name, &member.Email, &member.Role, &member.JoinedAt)
+			if err != nil {
+				logger.Log.Error("error scanning organization member", zap.String("error", err.Error()))
+				return err
+			}
+			members = append(members, member)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *organizationRepository) GetMember(orgPublicID uuid.UUID, userPublicID uuid.UUID) (*organization_types.Member, error) {
+	ctx := context.Background()
+
+	var member organization_types.Member
+	err := postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, getMemberQuery, orgPublicID, userPublicID).
+			Scan(&member.UserID, &member.UserPublicID, &member.Name, &member.Email, &member.Role, &member.JoinedAt)
+		if err == sql.ErrNoRows {
+			return organization_errors.ErrMemberNotFound
+		} else if err != nil {
+			logger.Log.Error("error getting organization member", zap.String("error", err.Error()))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *organizationRepository) UpdateMemberRole(orgPublicID uuid.UUID, user_id int64, role string) error {
+	ctx := context.Background()
+
+	return postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, updateMemberRoleQuery, orgPublicID, user_id, role)
+		if err != nil {
+			logger.Log.Error("error updating member role", zap.String("error", err.Error()))
+			return err
+		}
+		return requireAffected(result, organization_errors.ErrMemberNotFound)
+	})
+}
+
+func (r *organizationRepository) RemoveMember(orgPublicID uuid.UUID, user_id int64) error {
+	ctx := context.Background()
+
+	return postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, removeMemberQuery, orgPublicID, user_id)
+		if err != nil {
+			logger.Log.Error("error removing organization member", zap.String("error", err.Error()))
+			return err
+		}
+		return requireAffected(result, organization_errors.ErrMemberNotFound)
+	})
+}
+
+func (r *organizationRepository) CountOwners(orgPublicID uuid.UUID) (int, error) {
+	ctx := context.Background()
+
+	var owners int
+	err := postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, countOwnersQuery, orgPublicID).Scan(&owners)
+		if err != nil {
+			logger.Log.Error("error counting organization owners", zap.String("error", err.Error()))
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return owners, nil
+}
+
+// CreateInvitation replaces any open invitation to the same address, so only the
+// latest link works.
+func (r *organizationRepository) CreateInvitation(orgPublicID uuid.UUID, invitation organization_types.Invitation) (*organization_types.Invitation, error) {
+	ctx := context.Background()
+
+	err := postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, revokePendingInvitationsQuery, orgPublicID, invitation.Email); err != nil {
+			logger.Log.Error("error revoking pending invitations", zap.String("error", err.Error()))
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx, createInvitationQuery,
+			orgPublicID,
+			invitation.Email,
+			invitation.Role,
+			invitation.TokenHash,
+			invitation.InvitedBy,
+			invitation.ExpiresAt).Scan(&invitation.ID, &invitation.PublicID, &invitation.OrganizationID, &invitation.CreatedAt)
+		if err == sql.ErrNoRows {
+			return organization_errors.ErrOrganizationNotFound
+		} else if err != nil {
+			logger.Log.Error("error creating invitation", zap.String("error", err.Error()))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *organizationRepository) ListInvitations(orgPublicID uuid.UUID) ([]organization_types.Invitation, error) {
+	ctx := context.Background()
+
+	invitations := []organization_types.Invitation{}
+	err := postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, listInvitationsQuery, orgPublicID)
+		if err != nil {
+			logger.Log.Error("error listing invitations", zap.String("error", err.Error()))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var invitation organization_types.Invitation
+			var acceptedAt, revokedAt sql.NullTime
+
+			err := rows.Scan(
+				&invitation.ID,
+				&invitation.PublicID,
+				&invitation.OrganizationID,
+				&invitation.Email,
+				&invitation.Role,
+				&invitation.CreatedAt,
+				&invitation.ExpiresAt,
+				&acceptedAt,
+				&revokedAt)
+			if err != nil {
+				logger.Log.Error("error scanning invitation", zap.String("error", err.Error()))
+				return err
+			}
+
+			invitation.AcceptedAt = nullTime(acceptedAt)
+			invitation.RevokedAt = nullTime(revokedAt)
+			invitations = append(invitations, invitation)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *organizationRepository) RevokeInvitation(orgPublicID uuid.UUID, invitationPublicID uuid.UUID) error {
+	ctx := context.Background()
+
+	return postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, revokeInvitationQuery, orgPublicID, invitationPublicID)
+		if err != nil {
+			logger.Log.Error("error revoking invitation", zap.String("error", err.Error()))
+			return err
+		}
+		return requireAffected(result, organization_errors.ErrInvitationNotFound)
+	})
+}
+
+// GetInvitationByTokenHash looks the invitation up for the user opening the link. It is
+// not in any tenant yet, the policies only let through the invitations sent to the
+// user's address, someone else's link is not found.
+func (r *organizationRepository) GetInvitationByTokenHash(userPublicID uuid.UUID, tokenHash string) (*organization_types.Invitation, error) {
+	ctx := context.Background()
+
+	var invitation organization_types.Invitation
+	var acceptedAt, revokedAt sql.NullTime
+
+	err := postgress.WithCurrentUser(ctx, r.Db, userPublicID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, getInvitationByTokenHashQuery, tokenHash).Scan(
+			&invitation.ID,
+			&invitation.PublicID,
+			&invitation.OrganizationID,
+			&invitation.OrganizationPublicID,
+			&invitation.OrganizationName,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.CreatedAt,
+			&invitation.ExpiresAt,
+			&acceptedAt,
+			&revokedAt)
+		if err == sql.ErrNoRows {
+			return organization_errors.ErrInvalidInvitation
+		} else if err != nil {
+			logger.Log.Error("error getting invitation", zap.String("error", err.Error()))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.AcceptedAt = nullTime(acceptedAt)
+	invitation.RevokedAt = nullTime(revokedAt)
+	return &invitation, nil
+}
+
+// AcceptInvitation uses up the invitation and adds the member in one transaction. The
+// invitation is only used once, even when two requests race with the same link.
+func (r *organizationRepository) AcceptInvitation(orgPublicID uuid.UUID, invitation_id int64, user_id int64, role string) error {
+	ctx := context.Background()
+
+	return postgress.WithTenant(ctx, r.Db, orgPublicID, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, acceptInvitationQuery, invitation_id)
+		if err != nil {
+			logger.Log.Error("error accepting invitation", zap.String("error", err.Error()))
+			return err
+		}
+		if err := requireAffected(result, organization_errors.ErrInvalidInvitation); err != nil {
+			return err
+		}
+
+		result, err = tx.ExecContext(ctx, addMemberQuery, orgPublicID, user_id, role)
+		if err != nil {
+			logger.Log.Error("error adding organization member", zap.String("error", err.Error()))
+			return err
+		}
+		return requireAffected(result, organization_errors.ErrAlreadyMember)
+	})
+}
+
+// requireAffected returns notFound when the statement changed no row.
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,296 @@
+package organization_repository
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/postgress"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectSetting expects the transaction a repository call opens under the given
+// row-level security setting.
+func expectSetting(mock sqlmock.Sqlmock, setting string, value any) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config($1, $2, true)`)).
+		WithArgs(setting, value).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestCreateOrganization(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		memberError error
+		expectError bool
+	}{
+		{name: "success"},
+		{name: "owner not added", memberError: fmt.Errorf("db error"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewOrganizationRepository(db)
+
+			// the owner is added under the tenant of the new organization
+			expectSetting(mock, postgress.TenantSetting, sqlmock.AnyArg())
+			mock.ExpectQuery(regexp.QuoteMeta(createOrganizationQuery)).
+				WithArgs(sqlmock.AnyArg(), "Acme").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(7, now, now))
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(addMemberQuery)).WithArgs(sqlmock.AnyArg(), int64(1), organization_types.RoleOwner)
+			if tt.memberError != nil {
+				expect.WillReturnError(tt.memberError)
+				mock.ExpectRollback()
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			org, err := repo.CreateOrganization("Acme", 1)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, org)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(7), org.ID)
+				_, err = uuid.Parse(org.PublicID)
+				assert.NoError(t, err)
+				assert.Equal(t, organization_types.RoleOwner, org.Role)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetMemberRole(t *testing.T) {
+	logger.Init("dev")
+
+	orgID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		expectRole  string
+		expectError error
+	}{
+		{name: "member", rows: sqlmock.NewRows([]string{"role"}).AddRow("editor"), expectRole: "editor"},
+		{name: "not a member", rows: sqlmock.NewRows([]string{"role"}), expectError: organization_errors.ErrNotMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewOrganizationRepository(db)
+
+			expectSetting(mock, postgress.TenantSetting, orgID.String())
+			mock.ExpectQuery(regexp.QuoteMeta(getMemberRoleQuery)).
+				WithArgs(orgID, userID).
+				WillReturnRows(tt.rows)
+			if tt.expectError != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			role, err := repo.GetMemberRole(orgID, userID)
+
+			assert.Equal(t, tt.expectError, err)
+			assert.Equal(t, tt.expectRole, role)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetOrganizationNotMember(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOrganizationRepository(db)
+	orgID := uuid.New()
+
+	expectSetting(mock, postgress.TenantSetting, orgID.String())
+	mock.ExpectQuery(regexp.QuoteMeta(getOrganizationQuery)).
+		WithArgs(orgID, int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "name", "created_at", "updated_at", "role"}))
+	mock.ExpectRollback()
+
+	org, err := repo.GetOrganization(orgID, 3)
+
+	assert.Equal(t, organization_errors.ErrOrganizationNotFound, err)
+	assert.Nil(t, org)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateInvitationReplacesPending(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOrganizationRepository(db)
+
+	orgID := uuid.New()
+	inviter := int64(1)
+	expires := time.Now().Add(time.Hour)
+	invitation := organization_types.Invitation{
+		Email:     "ana@example.com",
+		Role:      organization_types.RoleEditor,
+		TokenHash: "hash",
+		InvitedBy: &inviter,
+		ExpiresAt: expires,
+	}
+
+	expectSetting(mock, postgress.TenantSetting, orgID.String())
+	mock.ExpectExec(regexp.QuoteMeta(revokePendingInvitationsQuery)).
+		WithArgs(orgID, "ana@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(createInvitationQuery)).
+		WithArgs(orgID, "ana@example.com", "editor", "hash", &inviter, expires).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "organization_id", "created_at"}).AddRow(3, uuid.NewString(), 7, time.Now()))
+	mock.ExpectCommit()
+
+	created, err := repo.CreateInvitation(orgID, invitation)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), created.ID)
+	assert.Equal(t, int64(7), created.OrganizationID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	orgID := uuid.New()
+	invitationID := uuid.New()
+
+	tests := []struct {
+		name        string
+		affected    int64
+		expectError error
+	}{
+		{name: "success", affected: 1},
+		{name: "not pending", affected: 0, expectError: organization_errors.ErrInvitationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewOrganizationRepository(db)
+
+			expectSetting(mock, postgress.TenantSetting, orgID.String())
+			mock.ExpectExec(regexp.QuoteMeta(revokeInvitationQuery)).
+				WithArgs(orgID, invitationID).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			if tt.expectError != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			err = repo.RevokeInvitation(orgID, invitationID)
+
+			assert.Equal(t, tt.expectError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetInvitationByTokenHash(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOrganizationRepository(db)
+	userID := uuid.New()
+
+	// an invitation sent to another address is hidden by the policies
+	expectSetting(mock, postgress.CurrentUserSetting, userID.String())
+	mock.ExpectQuery(regexp.QuoteMeta(getInvitationByTokenHashQuery)).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "public_id", "organization_id", "org_public_id", "name", "email", "role",
+			"created_at", "expires_at", "accepted_at", "revoked_at"}))
+	mock.ExpectRollback()
+
+	invitation, err := repo.GetInvitationByTokenHash(userID, "hash")
+
+	assert.Equal(t, organization_errors.ErrInvalidInvitation, err)
+	assert.Nil(t, invitation)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	orgID := uuid.New()
+
+	tests := []struct {
+		name           string
+		acceptAffected int64
+		memberAffected int64
+		expectError    error
+	}{
+		{name: "success", acceptAffected: 1, memberAffected: 1},
+		{name: "already used or expired", acceptAffected: 0, expectError: organization_errors.ErrInvalidInvitation},
+		{name: "already a member", acceptAffected: 1, memberAffected: 0, expectError: organization_errors.ErrAlreadyMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewOrganizationRepository(db)
+
+			expectSetting(mock, postgress.TenantSetting, orgID.String())
+			mock.ExpectExec(regexp.QuoteMeta(acceptInvitationQuery)).
+				WithArgs(int64(3)).
+				WillReturnResult(sqlmock.NewResult(0, tt.acceptAffected))
+			if tt.acceptAffected > 0 {
+				mock.ExpectExec(regexp.QuoteMeta(addMemberQuery)).
+					WithArgs(orgID, int64(2), "viewer").
+					WillReturnResult(sqlmock.NewResult(0, tt.memberAffected))
+			}
+			if tt.expectError != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			err = repo.AcceptInvitation(orgID, 3, 2, "viewer")
+
+			assert.Equal(t, tt.expectError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package organization_service
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Email texts are written in English and double as keys into the message catalog, like
+// the ones in auth_service.
+const (
+	invitationSubject = "%s invited you to %s on prodify"
+	invitationBody    = "%s invited you to join %s on prodify as %s.\n\nAccept the invitation by opening the link below:\n\n%s\n\n" +
+		"The link expires in %d days and can only be used once. If you don't know this organization you can ignore this message.\n"
+)
+
+// emailTranslations are the pt-BR texts, keep their verbs in the same order as the key.
+var emailTranslations = map[string]string{
+	invitationSubject: "%s convidou você para %s no prodify",
+	invitationBody: "%s convidou você para participar de %s no prodify como %s.\n\nAceite o convite abrindo o link abaixo:\n\n%s\n\n" +
+		"O link expira em %d dias e só pode ser usado uma vez. Se você não conhece esta organização, ignore esta mensagem.\n",
+}
+
+func init() {
+	for key, translation := range emailTranslations {
+		message.SetString(language.BrazilianPortuguese, key, translation)
+	}
+}
@@ -0,0 +1,95 @@
+package organization_service_mock
+
+import (
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrganizationService struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationService) CreateOrganization(userPublicID uuid.UUID, createRequest organization_types.CreateOrganizationRequest) (*organization_types.Organization, error) {
+	args := m.Called(userPublicID, createRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListOrganizations(userPublicID uuid.UUID) ([]organization_types.Organization, error) {
+	args := m.Called(userPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) GetOrganization(userPublicID uuid.UUID, orgPublicID uuid.UUID) (*organization_types.Organization, error) {
+	args := m.Called(userPublicID, orgPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) UpdateOrganization(userPublicID uuid.UUID, orgPublicID uuid.UUID, updateRequest organization_types.UpdateOrganizationRequest) (*organization_types.Organization, error) {
+	args := m.Called(userPublicID, orgPublicID, updateRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListMembers(userPublicID uuid.UUID, orgPublicID uuid.UUID) ([]organization_types.Member, error) {
+	args := m.Called(userPublicID, orgPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]organization_types.Member), args.Error(1)
+}
+
+func (m *MockOrganizationService) UpdateMemberRole(userPublicID uuid.UUID, orgPublicID uuid.UUID, memberPublicID uuid.UUID, role string) error {
+	args := m.Called(userPublicID, orgPublicID, memberPublicID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationService) RemoveMember(userPublicID uuid.UUID, orgPublicID uuid.UUID, memberPublicID uuid.UUID) error {
+	args := m.Called(userPublicID, orgPublicID, memberPublicID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationService) CreateInvitation(userPublicID uuid.UUID, orgPublicID uuid.UUID, createRequest organization_types.CreateInvitationRequest) (*organization_types.Invitation, error) {
+	args := m.Called(userPublicID, orgPublicID, createRequest)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Invitation), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListInvitations(userPublicID uuid.UUID, orgPublicID uuid.UUID) ([]organization_types.Invitation, error) {
+	args := m.Called(userPublicID, orgPublicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]organization_types.Invitation), args.Error(1)
+}
+
+func (m *MockOrganizationService) RevokeInvitation(userPublicID uuid.UUID, orgPublicID uuid.UUID, invitationPublicID uuid.UUID) error {
+	args := m.Called(userPublicID, orgPublicID, invitationPublicID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationService) AcceptInvitation(userPublicID uuid.UUID, token string) (*organization_types.Organization, error) {
+	args := m.Called(userPublicID, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*organization_types.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error) {
+	args := m.Called(orgPublicID, userPublicID)
+	return args.String(0), args.Error(1)
+}
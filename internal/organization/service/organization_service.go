@@ -0,0 +1,316 @@
+package organization_service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	organization_repository "github.com/celio001/prodify/internal/organization/repository"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_repository "github.com/celio001/prodify/internal/user/repository"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/locale"
+	"github.com/celio001/prodify/pkg/mailer"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type organizationService struct {
+	orgRepo  organization_repository.OrganizationRepository
+	userRepo user_repository.UserRepository
+	mailer   mailer.Mailer
+}
+
+type OrganizationService interface {
+	CreateOrganization(userPublicID uuid.UUID, createRequest organization_types.CreateOrganizationRequest) (*organization_types.Organization, error)
+	ListOrganizations(userPublicID uuid.UUID) ([]organization_types.Organization, error)
+	GetOrganization(userPublicID uuid.UUID, orgPublicID uuid.UUID) (*organization_types.Organization, error)
+	UpdateOrganization(userPublicID uuid.UUID, orgPublicID uuid.UUID, updateRequest organization_types.UpdateOrganizationRequest) (*organization_types.Organization, error)
+	ListMembers(userPublicID uuid.UUID, orgPublicID uuid.UUID) ([]organization_types.Member, error)
+	UpdateMemberRole(userPublicID uuid.UUID, orgPublicID uuid.UUID, memberPublicID uuid.UUID, role string) error
+	RemoveMember(userPublicID uuid.UUID, orgPublicID uuid.UUID, memberPublicID uuid.UUID) error
+	CreateInvitation(userPublicID uuid.UUID, orgPublicID uuid.UUID, createRequest organization_types.CreateInvitationRequest) (*organization_types.Invitation, error)
+	ListInvitations(userPublicID uuid.UUID, orgPublicID uuid.UUID) ([]organization_types.Invitation, error)
+	RevokeInvitation(userPublicID uuid.UUID, orgPublicID uuid.UUID, invitationPublicID uuid.UUID) error
+	AcceptInvitation(userPublicID uuid.UUID, token string) (*organization_types.Organization, error)
+	GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error)
+}
+
+func NewOrganizationService(orgRepo organization_repository.OrganizationRepository, userRepo user_repository.UserRepository, mailer mailer.Mailer) OrganizationService {
+	return &organizationService{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		mailer:   mailer,
+	}
+}
+
+// authorize loads the user and the organization, as seen by that user, and checks
+// their role is at least min. Organizations the user doesn't belong to are not found.
+func (s *organizationService) authorize(userPublicID uuid.UUID, orgPublicID uuid.UUID, min string) (*user_types.GetUserResponse, *organization_types.Organization, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	org, err := s.orgRepo.GetOrganization(orgPublicID, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !organization_types.RoleAtLeast(org.Role, min) {
+		return nil, nil, organization_errors.ErrInsufficientRole
+	}
+	return user, org, nil
+}
+
+func (s *organizationService) CreateOrganization(userPublicID uuid.UUID, createRequest organization_types.CreateOrganizationRequest) (*organization_types.Organization, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.CreateOrganization(strings.TrimSpace(createRequest.Name), user.ID)
+}
+
+func (s *organizationService) ListOrganizations(userPublicID uuid.UUID) ([]organization_types.Organization, error) {
+	if _, err := s.userRepo.GetUserByPublicID(userPublicID); err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.ListOrganizations(userPublicID)
+}
+
+func (s *organizationService) GetOrganization(userPublicID uuid.UUID, orgPublicID uuid.UUID) (*organization_types.Organization, error) {
+	_, org, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleViewer)
+	return org, err
+}
+
+func (s *organizationService) UpdateOrganization(userPublicID uuid.UUID, orgPublicID uuid.UUID, updateRequest organization_types.UpdateOrganizationRequest) (*organization_types.Organization, error) {
+	_, org, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	org.Name = strings.TrimSpace(updateRequest.Name)
+	if err := s.orgRepo.UpdateOrganization(org.ID, org.Name); err != nil {
+		return nil, err
+	}
+	org.UpdatedAt = time.Now()
+	return org, nil
+}
+
+func (s *organizationService) ListMembers(userPublicID uuid.UUID, orgPublicID uuid.UUID) ([]organization_types.Member, error) {
+	if _, _, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.ListMembers(orgPublicID)
+}
+
+// UpdateMemberRole lets admins manage everyone below owner. Only owners make or unmake
+// owners, and the last owner can't step down.
+func (s *organizationService) UpdateMemberRole(userPublicID uuid.UUID, orgPublicID uuid.UUID, memberPublicID uuid.UUID, role string) error {
+	_, org, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.orgRepo.GetMember(orgPublicID, memberPublicID)
+	if err != nil {
+		return err
+	}
+
+	if org.Role != organization_types.RoleOwner && (member.Role == organization_types.RoleOwner || role == organization_types.RoleOwner) {
+		return organization_errors.ErrInsufficientRole
+	}
+
+	if member.Role == role {
+		return nil
+	}
+
+	if member.Role == organization_types.RoleOwner {
+		if err := s.checkNotLastOwner(orgPublicID); err != nil {
+			return err
+		}
+	}
+
+	return s.orgRepo.UpdateMemberRole(orgPublicID, member.UserID, role)
+}
+
+// RemoveMember is also how a member leaves, anyone may remove themselves.
+func (s *organizationService) RemoveMember(userPublicID uuid.UUID, orgPublicID uuid.UUID, memberPublicID uuid.UUID) error {
+	user, org, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.orgRepo.GetMember(orgPublicID, memberPublicID)
+	if err != nil {
+		return err
+	}
+
+	if member.UserID != user.ID {
+		if !organization_types.RoleAtLeast(org.Role, organization_types.RoleAdmin) {
+			return organization_errors.ErrInsufficientRole
+		}
+		if member.Role == organization_types.RoleOwner && org.Role != organization_types.RoleOwner {
+			return organization_errors.ErrInsufficientRole
+		}
+	}
+
+	if member.Role == organization_types.RoleOwner {
+		if err := s.checkNotLastOwner(orgPublicID); err != nil {
+			return err
+		}
+	}
+
+	return s.orgRepo.RemoveMember(orgPublicID, member.UserID)
+}
+
+func (s *organizationService) checkNotLastOwner(orgPublicID uuid.UUID) error {
+	owners, err := s.orgRepo.CountOwners(orgPublicID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return organization_errors.ErrLastOwner
+	}
+	return nil
+}
+
+// CreateInvitation emails a single-use link. The invitee doesn't need an account yet,
+// they accept after signing up with the invited address.
+func (s *organizationService) CreateInvitation(userPublicID uuid.UUID, orgPublicID uuid.UUID, createRequest organization_types.CreateInvitationRequest) (*organization_types.Invitation, error) {
+	user, org, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(createRequest.Email)
+
+	// the invitee reads the email in their own language, or the inviter's when they
+	// have no account yet
+	emailLocale := user.Preferences.Locale
+	invitee, err := s.userRepo.GetUserByEmail(email)
+	if err != nil && err != user_errors.ErrUserNotFound {
+		return nil, err
+	}
+	if invitee != nil {
+		if inviteeID, err := uuid.Parse(invitee.PublicID); err == nil {
+			if _, err := s.orgRepo.GetMemberRole(orgPublicID, inviteeID); err == nil {
+				return nil, organization_errors.ErrAlreadyMember
+			} else if err != organization_errors.ErrNotMember {
+				return nil, err
+			}
+		}
+		emailLocale = invitee.Preferences.Locale
+	}
+
+	plain, hash, err := pkg_token.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.orgRepo.CreateInvitation(orgPublicID, organization_types.Invitation{
+		Email:     email,
+		Role:      createRequest.Role,
+		TokenHash: hash,
+		InvitedBy: &user.ID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	invitation.Status = organization_types.InvitationPending
+
+	link := fmt.Sprintf("%s/accept-invitation?token=%s", config.GetString("APP_BASE_URL"), url.QueryEscape(plain))
+
+	p := locale.Printer(emailLocale)
+	err = s.mailer.Send(mailer.Message{
+		To:      email,
+		Subject: p.Sprintf(invitationSubject, user.Name, org.Name),
+		Body:    p.Sprintf(invitationBody, user.Name, org.Name, createRequest.Role, link, int(invitationTTL.Hours()/24)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *organizationService) ListInvitations(userPublicID uuid.UUID, orgPublicID uuid.UUID) ([]organization_types.Invitation, error) {
+	if _, _, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.orgRepo.ListInvitations(orgPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range invitations {
+		invitations[i].Status = invitations[i].State(now)
+	}
+	return invitations, nil
+}
+
+func (s *organizationService) RevokeInvitation(userPublicID uuid.UUID, orgPublicID uuid.UUID, invitationPublicID uuid.UUID) error {
+	if _, _, err := s.authorize(userPublicID, orgPublicID, organization_types.RoleAdmin); err != nil {
+		return err
+	}
+
+	return s.orgRepo.RevokeInvitation(orgPublicID, invitationPublicID)
+}
+
+// AcceptInvitation adds the user with the invited role. The link alone is not enough,
+// the user must be signed in with the address it was sent to. The row-level security
+// policies already hide invitations sent to another address, the check below doesn't
+// rely on them.
+func (s *organizationService) AcceptInvitation(userPublicID uuid.UUID, token string) (*organization_types.Organization, error) {
+	user, err := s.userRepo.GetUserByPublicID(userPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.orgRepo.GetInvitationByTokenHash(userPublicID, pkg_token.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.State(time.Now()) != organization_types.InvitationPending {
+		return nil, organization_errors.ErrInvalidInvitation
+	}
+
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, organization_errors.ErrInvitationEmailMismatch
+	}
+
+	orgPublicID, err := uuid.Parse(invitation.OrganizationPublicID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.orgRepo.AcceptInvitation(orgPublicID, invitation.ID, user.ID, invitation.Role); err != nil {
+		return nil, err
+	}
+
+	return &organization_types.Organization{
+		ID:       invitation.OrganizationID,
+		PublicID: invitation.OrganizationPublicID,
+		Name:     invitation.OrganizationName,
+		Role:     invitation.Role,
+	}, nil
+}
+
+// GetMemberRole backs middleware.RequireOrg, it returns ErrNotMember for users outside
+// the organization.
+func (s *organizationService) GetMemberRole(orgPublicID uuid.UUID, userPublicID uuid.UUID) (string, error) {
+	return s.orgRepo.GetMemberRole(orgPublicID, userPublicID)
+}
@@ -0,0 +1,306 @@
+package organization_service
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	organization_errors "github.com/celio001/prodify/internal/organization/errors"
+	organization_repository_mock "github.com/celio001/prodify/internal/organization/repository/mock"
+	organization_types "github.com/celio001/prodify/internal/organization/types"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/mailer"
+	mailer_mock "github.com/celio001/prodify/pkg/mailer/mock"
+	pkg_token "github.com/celio001/prodify/pkg/token"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleAtLeast(t *testing.T) {
+	assert.True(t, organization_types.RoleAtLeast(organization_types.RoleOwner, organization_types.RoleAdmin))
+	assert.True(t, organization_types.RoleAtLeast(organization_types.RoleEditor, organization_types.RoleEditor))
+	assert.False(t, organization_types.RoleAtLeast(organization_types.RoleViewer, organization_types.RoleEditor))
+	assert.False(t, organization_types.RoleAtLeast("", organization_types.RoleViewer))
+	assert.False(t, organization_types.RoleAtLeast("superuser", organization_types.RoleViewer))
+}
+
+func TestEmailTranslationsKeepVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+
+	for key, translation := range emailTranslations {
+		assert.Equal(t, verbs.FindAllString(key, -1), verbs.FindAllString(translation, -1), key)
+	}
+}
+
+func TestUpdateMemberRole(t *testing.T) {
+	logger.Init("dev")
+
+	actorID := uuid.New()
+	orgID := uuid.New()
+	memberID := uuid.New()
+
+	tests := []struct {
+		name        string
+		actorRole   string
+		memberRole  string
+		newRole     string
+		owners      int
+		expectError error
+		expectWrite bool
+	}{
+		{name: "admin promotes viewer", actorRole: "admin", memberRole: "viewer", newRole: "editor", expectWrite: true},
+		{name: "editor can't manage members", actorRole: "editor", memberRole: "viewer", newRole: "editor", expectError: organization_errors.ErrInsufficientRole},
+		{name: "admin can't grant owner", actorRole: "admin", memberRole: "editor", newRole: "owner", expectError: organization_errors.ErrInsufficientRole},
+		{name: "admin can't demote owner", actorRole: "admin", memberRole: "owner", newRole: "viewer", expectError: organization_errors.ErrInsufficientRole},
+		{name: "owner demotes another owner", actorRole: "owner", memberRole: "owner", newRole: "admin", owners: 2, expectWrite: true},
+		{name: "last owner can't step down", actorRole: "owner", memberRole: "owner", newRole: "admin", owners: 1, expectError: organization_errors.ErrLastOwner},
+		{name: "same role is a no-op", actorRole: "admin", memberRole: "editor", newRole: "editor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(user_mock.MockUserRepository)
+			userRepo.On("GetUserByPublicID", actorID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+
+			orgRepo := new(organization_repository_mock.MockOrganizationRepository)
+			orgRepo.On("GetOrganization", orgID, int64(1)).Return(&organization_types.Organization{ID: 7, Role: tt.actorRole}, nil)
+			orgRepo.On("GetMember", orgID, memberID).Return(&organization_types.Member{UserID: 2, Role: tt.memberRole}, nil)
+			orgRepo.On("CountOwners", orgID).Return(tt.owners, nil)
+			orgRepo.On("UpdateMemberRole", orgID, int64(2), tt.newRole).Return(nil)
+
+			service := NewOrganizationService(orgRepo, userRepo, nil)
+
+			err := service.UpdateMemberRole(actorID, orgID, memberID, tt.newRole)
+
+			assert.Equal(t, tt.expectError, err)
+			if tt.expectWrite {
+				orgRepo.AssertCalled(t, "UpdateMemberRole", orgID, int64(2), tt.newRole)
+			} else {
+				orgRepo.AssertNotCalled(t, "UpdateMemberRole", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	logger.Init("dev")
+
+	actorID := uuid.New()
+	orgID := uuid.New()
+	memberID := uuid.New()
+
+	tests := []struct {
+		name         string
+		actorRole    string
+		memberUserID int64
+		memberRole   string
+		owners       int
+		expectError  error
+	}{
+		{name: "admin removes editor", actorRole: "admin", memberUserID: 2, memberRole: "editor"},
+		{name: "viewer leaves", actorRole: "viewer", memberUserID: 1, memberRole: "viewer"},
+		{name: "viewer can't remove others", actorRole: "viewer", memberUserID: 2, memberRole: "viewer", expectError: organization_errors.ErrInsufficientRole},
+		{name: "admin can't remove owner", actorRole: "admin", memberUserID: 2, memberRole: "owner", expectError: organization_errors.ErrInsufficientRole},
+		{name: "last owner can't leave", actorRole: "owner", memberUserID: 1, memberRole: "owner", owners: 1, expectError: organization_errors.ErrLastOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(user_mock.MockUserRepository)
+			userRepo.On("GetUserByPublicID", actorID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+
+			orgRepo := new(organization_repository_mock.MockOrganizationRepository)
+			orgRepo.On("GetOrganization", orgID, int64(1)).Return(&organization_types.Organization{ID: 7, Role: tt.actorRole}, nil)
+			orgRepo.On("GetMember", orgID, memberID).Return(&organization_types.Member{UserID: tt.memberUserID, Role: tt.memberRole}, nil)
+			orgRepo.On("CountOwners", orgID).Return(tt.owners, nil)
+			orgRepo.On("RemoveMember", orgID, tt.memberUserID).Return(nil)
+
+			service := NewOrganizationService(orgRepo, userRepo, nil)
+
+			err := service.RemoveMember(actorID, orgID, memberID)
+
+			assert.Equal(t, tt.expectError, err)
+			if tt.expectError == nil {
+				orgRepo.AssertCalled(t, "RemoveMember", orgID, tt.memberUserID)
+			} else {
+				orgRepo.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestNotAMemberSeesNoOrganization(t *testing.T) {
+	logger.Init("dev")
+
+	actorID := uuid.New()
+	orgID := uuid.New()
+
+	userRepo := new(user_mock.MockUserRepository)
+	userRepo.On("GetUserByPublicID", actorID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+
+	orgRepo := new(organization_repository_mock.MockOrganizationRepository)
+	orgRepo.On("GetOrganization", orgID, int64(1)).Return(nil, organization_errors.ErrOrganizationNotFound)
+
+	service := NewOrganizationService(orgRepo, userRepo, nil)
+
+	_, err := service.ListMembers(actorID, orgID)
+	assert.Equal(t, organization_errors.ErrOrganizationNotFound, err)
+	orgRepo.AssertNotCalled(t, "ListMembers", mock.Anything)
+}
+
+func TestCreateInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	actorID := uuid.New()
+	orgID := uuid.New()
+	inviteeID := uuid.New()
+
+	tests := []struct {
+		name        string
+		invitee     *user_types.GetUserResponse
+		inviteeErr  error
+		memberErr   error
+		subject     string
+		expectError error
+	}{
+		{
+			name:       "address without an account",
+			inviteeErr: user_errors.ErrUserNotFound,
+			subject:    "Ana invited you to Acme on prodify",
+		},
+		{
+			name: "existing user reads it in their locale",
+			invitee: &user_types.GetUserResponse{ID: 2, PublicID: inviteeID.String(),
+				Preferences: user_types.Preferences{Locale: "pt-BR"}},
+			memberErr: organization_errors.ErrNotMember,
+			subject:   "Ana convidou você para Acme no prodify",
+		},
+		{
+			name:        "already a member",
+			invitee:     &user_types.GetUserResponse{ID: 2, PublicID: inviteeID.String()},
+			expectError: organization_errors.ErrAlreadyMember,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(user_mock.MockUserRepository)
+			userRepo.On("GetUserByPublicID", actorID).Return(&user_types.GetUserResponse{ID: 1, Name: "Ana"}, nil)
+			userRepo.On("GetUserByEmail", "bob@example.com").Return(tt.invitee, tt.inviteeErr)
+
+			orgRepo := new(organization_repository_mock.MockOrganizationRepository)
+			orgRepo.On("GetOrganization", orgID, int64(1)).Return(&organization_types.Organization{ID: 7, Name: "Acme", Role: "admin"}, nil)
+			orgRepo.On("GetMemberRole", orgID, inviteeID).Return("", tt.memberErr)
+			orgRepo.On("CreateInvitation", orgID, mock.Anything).Return(&organization_types.Invitation{ID: 3, Email: "bob@example.com"}, nil)
+
+			var sent mailer.Message
+			mail := new(mailer_mock.MockMailer)
+			mail.On("Send", mock.Anything).Run(func(args mock.Arguments) { sent = args.Get(0).(mailer.Message) }).Return(nil)
+
+			service := NewOrganizationService(orgRepo, userRepo, mail)
+
+			invitation, err := service.CreateInvitation(actorID, orgID, organization_types.CreateInvitationRequest{Email: "bob@example.com", Role: "editor"})
+
+			if tt.expectError != nil {
+				assert.Equal(t, tt.expectError, err)
+				orgRepo.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything)
+				mail.AssertNotCalled(t, "Send", mock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, organization_types.InvitationPending, invitation.Status)
+
+			stored := orgRepo.Calls[len(orgRepo.Calls)-1].Arguments.Get(1).(organization_types.Invitation)
+			assert.Equal(t, "editor", stored.Role)
+			assert.Equal(t, int64(1), *stored.InvitedBy)
+
+			assert.Equal(t, "bob@example.com", sent.To)
+			assert.Equal(t, tt.subject, sent.Subject)
+
+			token := sent.Body[strings.Index(sent.Body, "token=")+len("token="):]
+			token = token[:strings.Index(token, "\n")]
+			assert.Equal(t, pkg_token.Hash(token), stored.TokenHash)
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	logger.Init("dev")
+
+	userID := uuid.New()
+	orgID := uuid.New()
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		invitation  organization_types.Invitation
+		acceptErr   error
+		expectError error
+	}{
+		{
+			name:       "success",
+			invitation: organization_types.Invitation{Email: "Bob@Example.com", ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:        "expired",
+			invitation:  organization_types.Invitation{Email: "bob@example.com", ExpiresAt: past},
+			expectError: organization_errors.ErrInvalidInvitation,
+		},
+		{
+			name:        "revoked",
+			invitation:  organization_types.Invitation{Email: "bob@example.com", ExpiresAt: now.Add(time.Hour), RevokedAt: &past},
+			expectError: organization_errors.ErrInvalidInvitation,
+		},
+		{
+			name:        "sent to someone else",
+			invitation:  organization_types.Invitation{Email: "carol@example.com", ExpiresAt: now.Add(time.Hour)},
+			expectError: organization_errors.ErrInvitationEmailMismatch,
+		},
+		{
+			name:        "used concurrently",
+			invitation:  organization_types.Invitation{Email: "bob@example.com", ExpiresAt: now.Add(time.Hour)},
+			acceptErr:   organization_errors.ErrInvalidInvitation,
+			expectError: organization_errors.ErrInvalidInvitation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invitation := tt.invitation
+			invitation.ID = 3
+			invitation.OrganizationID = 7
+			invitation.OrganizationPublicID = orgID.String()
+			invitation.OrganizationName = "Acme"
+			invitation.Role = "editor"
+
+			userRepo := new(user_mock.MockUserRepository)
+			userRepo.On("GetUserByPublicID", userID).Return(&user_types.GetUserResponse{ID: 2, Email: "bob@example.com"}, nil)
+
+			orgRepo := new(organization_repository_mock.MockOrganizationRepository)
+			orgRepo.On("GetInvitationByTokenHash", userID, pkg_token.Hash("raw-token")).Return(&invitation, nil)
+			orgRepo.On("AcceptInvitation", orgID, int64(3), int64(2), "editor").Return(tt.acceptErr)
+
+			service := NewOrganizationService(orgRepo, userRepo, nil)
+
+			org, err := service.AcceptInvitation(userID, "raw-token")
+
+			if tt.expectError != nil {
+				assert.Equal(t, tt.expectError, err)
+				assert.Nil(t, org)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, invitation.OrganizationPublicID, org.PublicID)
+			assert.Equal(t, "editor", org.Role)
+		})
+	}
+}
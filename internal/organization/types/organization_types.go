@@ -0,0 +1,104 @@
+package organization_types
+
+import "time"
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+// roleRank orders the roles, each one can do everything the ones below it can.
+var roleRank = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// RoleAtLeast reports whether role grants at least what min does. Unknown roles grant nothing.
+func RoleAtLeast(role string, min string) bool {
+	rank, ok := roleRank[role]
+	return ok && rank >= roleRank[min]
+}
+
+type Organization struct {
+	ID        int64     `json:"-"`
+	PublicID  string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Role of the user the organization was loaded for
+	Role string `json:"role,omitempty"`
+}
+
+type Member struct {
+	UserID       int64     `json:"-"`
+	UserPublicID string    `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	JoinedAt     time.Time `json:"joinedAt"`
+}
+
+type Invitation struct {
+	ID             int64      `json:"-"`
+	PublicID       string     `json:"id"`
+	OrganizationID int64      `json:"-"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	TokenHash      string     `json:"-"`
+	InvitedBy      *int64     `json:"-"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt"`
+	RevokedAt      *time.Time `json:"-"`
+	Status         string     `json:"status"`
+
+	// OrganizationPublicID and OrganizationName are only filled when the invitation
+	// is looked up by its token
+	OrganizationPublicID string `json:"-"`
+	OrganizationName     string `json:"-"`
+}
+
+// State is the invitation status at now, expiry is never written to the row.
+func (i Invitation) State(now time.Time) string {
+	switch {
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"required,oneof=admin editor viewer"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,62 @@
+package user_erasure
+
+import (
+	"context"
+	"time"
+
+	"github.com/celio001/prodify/config"
+	user_service "github.com/celio001/prodify/internal/user/service"
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Worker anonymizes deleted users once their erasure grace period is over.
+type Worker struct {
+	userService user_service.UserService
+	interval    time.Duration
+	batchSize   int
+}
+
+func NewWorker(userService user_service.UserService) *Worker {
+	return &Worker{
+		userService: userService,
+		interval:    time.Duration(config.GetInt("USER_ERASURE_INTERVAL_MINUTES")) * time.Minute,
+		batchSize:   config.GetInt("USER_ERASURE_BATCH_SIZE"),
+	}
+}
+
+// Start runs a pass right away and then once per interval until ctx is done.
+func (w *Worker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop has nothing to release, Start returns as soon as its context is done.
+func (w *Worker) Stop(ctx context.Context) error {
+	return nil
+}
+
+// run keeps taking batches while they come back full, so a backlog doesn't wait for
+// the next tick.
+func (w *Worker) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		erased, err := w.userService.ProcessErasures(w.batchSize)
+		if err != nil {
+			logger.Log.Error("failed to process erasures", zap.String("error", err.Error()))
+			return
+		}
+		if erased < w.batchSize {
+			return
+		}
+	}
+}
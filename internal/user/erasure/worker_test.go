@@ -0,0 +1,59 @@
+package user_erasure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	user_service_mock "github.com/celio001/prodify/internal/user/service/mock"
+	"github.com/celio001/prodify/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerRun_DrainsFullBatches(t *testing.T) {
+	logger.Init("dev")
+
+	service := new(user_service_mock.MockUserService)
+	service.On("ProcessErasures", 2).Return(2, nil).Twice()
+	service.On("ProcessErasures", 2).Return(1, nil).Once()
+
+	worker := &Worker{userService: service, interval: time.Hour, batchSize: 2}
+	worker.run(context.Background())
+
+	service.AssertNumberOfCalls(t, "ProcessErasures", 3)
+}
+
+func TestWorkerRun_StopsOnError(t *testing.T) {
+	logger.Init("dev")
+
+	service := new(user_service_mock.MockUserService)
+	service.On("ProcessErasures", 2).Return(0, errors.New("db error")).Once()
+
+	worker := &Worker{userService: service, interval: time.Hour, batchSize: 2}
+	worker.run(context.Background())
+
+	service.AssertNumberOfCalls(t, "ProcessErasures", 1)
+}
+
+func TestWorkerStart_ReturnsWhenContextIsDone(t *testing.T) {
+	logger.Init("dev")
+
+	service := new(user_service_mock.MockUserService)
+	service.On("ProcessErasures", 2).Return(0, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	worker := &Worker{userService: service, interval: time.Hour, batchSize: 2}
+
+	done := make(chan error)
+	go func() { done <- worker.Start(ctx) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop")
+	}
+}
@@ -10,6 +10,13 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserCreationFailed = errors.New("failed to create user")
 	ErrSamePassword       = errors.New("new password cannot be the same as the old password")
+	ErrPasswordReused     = errors.New("new password was used recently, choose a different one")
+	ErrUserDeleted        = errors.New("user is deleted, restore it first")
+	ErrUserNotDeleted     = errors.New("user is not deleted")
+	ErrInvalidDateRange   = errors.New("created_from must be before created_to")
+	ErrCannotModifySelf   = errors.New("admins cannot deactivate or change the role of their own account")
+	ErrUserErased         = errors.New("user personal data was erased, it cannot be restored")
+	ErrEmailTaken         = errors.New("email is already in use by another account")
 )
 
 func CreateUserValidateError(err error) map[string]string {
@@ -87,3 +94,87 @@ func UpdateUserValidateError(err error) map[string]string {
 
 	return errors
 }
+
+func ListUsersValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+
+			field := fieldErr.Field()
+
+			switch field {
+
+			case "Email":
+				errors[field] = "email must have at most 254 characters"
+
+			case "Name":
+				errors[field] = "name must have at most 100 characters"
+
+			case "Role":
+				errors[field] = "role must be user or admin"
+
+			case "CreatedFrom", "CreatedTo":
+				errors[field] = "must be an RFC 3339 date, e.g. 2026-01-31T00:00:00Z"
+
+			case "Page":
+				errors[field] = "page must be at least 1"
+
+			case "PageSize":
+				errors[field] = "page_size must be between 1 and 100"
+			}
+		}
+	}
+
+	return errors
+}
+
+func ChangeRoleValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+
+			switch field {
+
+			case "Role":
+				switch tag {
+				case "required":
+					errors[field] = "role is required"
+				case "oneof":
+					errors[field] = "role must be user or admin"
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+func PreferencesValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+
+			field := fieldErr.Field()
+
+			switch field {
+
+			case "Locale":
+				errors[field] = "locale must be en or pt-BR"
+
+			case "Timezone":
+				errors[field] = "timezone must be an IANA time zone, e.g. America/Sao_Paulo"
+
+			case "Currency":
+				errors[field] = "currency must be an ISO 4217 code, e.g. BRL"
+			}
+		}
+	}
+
+	return errors
+}
@@ -1,7 +1,8 @@
 package user_repository_mock
 
 import (
-	auth_types "github.com/celio001/prodify/internal/auth/types"
+	"time"
+
 	user_types "github.com/celio001/prodify/internal/user/type"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -21,6 +22,16 @@ func (m *MockUserRepository) GetUserByPublicID(publicID uuid.UUID) (*user_types.
 	return args.Get(0).(*user_types.GetUserResponse), args.Error(1)
 }
 
+func (m *MockUserRepository) GetUserByID(id int64) (*user_types.GetUserResponse, error) {
+	args := m.Called(id)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*user_types.GetUserResponse), args.Error(1)
+}
+
 func (m *MockUserRepository) GetUserByEmail(email string) (*user_types.GetUserResponse, error) {
 	args := m.Called(email)
 
@@ -36,8 +47,8 @@ func (m *MockUserRepository) CreateUser(user user_types.CreateUserRequest) (*use
 	return args.Get(0).(*user_types.CreateUserResponse), args.Error(1)
 }
 
-func (m *MockUserRepository) SoftDeleteUser(userID int64) error {
-	args := m.Called(userID)
+func (m *MockUserRepository) SoftDeleteUser(userID int64, eraseAfter time.Time) error {
+	args := m.Called(userID, eraseAfter)
 	return args.Error(0)
 }
 
@@ -46,7 +57,132 @@ func (m *MockUserRepository) UpdateUser(userID int64, params user_types.UpdateUs
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) UpdateUserPassword(userID int64, resetPasswordRequest auth_types.ResetPasswordRequest) error {
-	args := m.Called(userID, resetPasswordRequest)
+func (m *MockUserRepository) UpdateUserPassword(userID int64, newPassword string) error {
+	args := m.Called(userID, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpgradePasswordHash(userID int64, currentHash string, password string) error {
+	args := m.Called(userID, currentHash, password)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetPasswordHistory(userID int64, limit int) ([]string, error) {
+	args := m.Called(userID, limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RevokeUserSessions(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetSessionsRevokedAt(publicID uuid.UUID) (*time.Time, error) {
+	args := m.Called(publicID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockUserRepository) ListUsers(filter user_types.ListUsersFilter) ([]user_types.AdminUserResponse, int64, error) {
+	args := m.Called(filter)
+
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+
+	return args.Get(0).([]user_types.AdminUserResponse), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) GetAdminUserByPublicID(publicID uuid.UUID) (*user_types.AdminUserResponse, error) {
+	args := m.Called(publicID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*user_types.AdminUserResponse), args.Error(1)
+}
+
+func (m *MockUserRepository) SetUserActive(userID int64, active bool) error {
+	args := m.Called(userID, active)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RestoreUser(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RequirePasswordReset(userID int64) error {
+	args := m.Called(userID)
 	return args.Error(0)
 }
+
+func (m *MockUserRepository) RevokeUserCredentials(userID int64) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateUserRole(userID int64, role string) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetUserData(userID int64) (*user_types.UserDataExport, error) {
+	args := m.Called(userID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*user_types.UserDataExport), args.Error(1)
+}
+
+func (m *MockUserRepository) GetPendingErasures(limit int) ([]int64, error) {
+	args := m.Called(limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockUserRepository) EraseUser(userID int64) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateAvatar(userID int64, key string) error {
+	args := m.Called(userID, key)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePreferences(userID int64, preferences user_types.Preferences) error {
+	args := m.Called(userID, preferences)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetPendingEmail(userID int64, email string) error {
+	args := m.Called(userID, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConfirmEmailChange(userID int64) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
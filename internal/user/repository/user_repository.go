@@ -3,65 +3,398 @@ package user_repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	auth_types "github.com/celio001/prodify/internal/auth/types"
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_types "github.com/celio001/prodify/internal/user/type"
 	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/password-hasher/hasher"
+	"github.com/celio001/prodify/pkg/postgress"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	getUserByPublicIDQuery = `SELECT id, public_id, name, email, password_hash, is_active, created_at, updated_at 
+	getUserByPublicIDQuery = `SELECT id, public_id, name, email, password_hash, role, is_active, email_verified_at IS NOT NULL,
+	EXISTS (SELECT 1 FROM user_mfa m WHERE m.user_id = users.id AND m.enabled_at IS NOT NULL), password_reset_required,
+	COALESCE(avatar_key, ''), preferences, created_at, updated_at 
 	FROM users 
 	WHERE public_id = $1
 	AND deleted_at IS NULL`
 
-	getUserByEmailQuery = `SELECT public_id, name, email, password_hash, is_active, created_at, updated_at 
+	getUserByIDQuery = `SELECT id, public_id, name, email, password_hash, role, is_active, email_verified_at IS NOT NULL,
+	EXISTS (SELECT 1 FROM user_mfa m WHERE m.user_id = users.id AND m.enabled_at IS NOT NULL), password_reset_required,
+	COALESCE(avatar_key, ''), preferences, created_at, updated_at 
 	FROM users 
-	WHERE email = $1
+	WHERE id = $1
+	AND deleted_at IS NULL`
+
+	getUserByEmailQuery = `SELECT id, public_id, name, email, password_hash, role, is_active, email_verified_at IS NOT NULL,
+	EXISTS (SELECT 1 FROM user_mfa m WHERE m.user_id = users.id AND m.enabled_at IS NOT NULL), password_reset_required,
+	COALESCE(avatar_key, ''), preferences, created_at, updated_at 
+	FROM users 
+	WHERE lower(email) = lower($1)
 	AND deleted_at IS NULL`
 
 	createUserQuery = `INSERT INTO users (name, email, password_hash, is_active) 
-	VALUES ($1, $2, $3, $4)`
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, public_id, name, email, password_hash, is_active, created_at, updated_at`
 
 	softDeleteUserQuery = `UPDATE users
-	SET deleted_at = now(), updated_at = now(), is_active = false
+	SET deleted_at = now(), updated_at = now(), is_active = false, erase_after = $2
 	WHERE id = $1`
 
 	updateUserQuery = `UPDATE users
 	SET
-		name = COALESCE($2, name),
-		email = COALESCE($4, email),
+		name = COALESCE(NULLIF($2, ''), name),
 		updated_at = now()
 	WHERE id = $1;`
 
+	setPendingEmailQuery = `UPDATE users
+	SET
+	pending_email = $2,
+	updated_at = now()
+	WHERE id = $1
+	AND deleted_at IS NULL;`
+
+	confirmEmailChangeQuery = `UPDATE users
+	SET
+	email = pending_email,
+	pending_email = NULL,
+	email_verified_at = now(),
+	updated_at = now()
+	WHERE id = $1
+	AND pending_email IS NOT NULL
+	AND deleted_at IS NULL
+	RETURNING email`
+
+	updateAvatarQuery = `UPDATE users
+	SET
+	avatar_key = NULLIF($2, ''),
+	updated_at = now()
+	WHERE id = $1
+	AND deleted_at IS NULL;`
+
+	updatePreferencesQuery = `UPDATE users
+	SET
+	preferences = $2,
+	updated_at = now()
+	WHERE id = $1
+	AND deleted_at IS NULL;`
+
 	updateUserPasswordQuery = `UPDATE users
 	SET
 	password_hash = $2,
+	password_reset_required = false,
+	updated_at = now()
+	WHERE id = $1;`
+
+	upgradePasswordHashQuery = `UPDATE users
+	SET password_hash = $3
+	WHERE id = $1
+	AND password_hash = $2;`
+
+	savePasswordHistoryQuery = `INSERT INTO password_history (user_id, password_hash)
+	SELECT id, password_hash
+	FROM users
+	WHERE id = $1;`
+
+	getPasswordHistoryQuery = `SELECT password_hash
+	FROM password_history
+	WHERE user_id = $1
+	ORDER BY created_at DESC
+	LIMIT $2`
+
+	revokeUserSessionsQuery = `WITH revoked AS (
+		UPDATE user_sessions
+		SET revoked_at = now()
+		WHERE user_id = $1
+		AND revoked_at IS NULL
+	)
+	UPDATE users
+	SET
+	sessions_revoked_at = now(),
+	updated_at = now()
+	WHERE id = $1;`
+
+	getSessionsRevokedAtQuery = `SELECT sessions_revoked_at
+	FROM users
+	WHERE public_id = $1
+	AND deleted_at IS NULL`
+
+	markEmailVerifiedQuery = `UPDATE users
+	SET
+	email_verified_at = COALESCE(email_verified_at, now()),
+	updated_at = now()
+	WHERE id = $1
+	AND deleted_at IS NULL;`
+
+	adminUserColumns = `id, public_id, name, email, role, is_active, email_verified_at IS NOT NULL,
+	EXISTS (SELECT 1 FROM user_mfa m WHERE m.user_id = users.id AND m.enabled_at IS NOT NULL), password_reset_required,
+	created_at, updated_at, deleted_at, erase_after, erased_at`
+
+	getAdminUserByPublicIDQuery = `SELECT ` + adminUserColumns + `
+	FROM users
+	WHERE public_id = $1`
+
+	listUsersQuery = `SELECT ` + adminUserColumns + `
+	FROM users`
+
+	countUsersQuery = `SELECT COUNT(*)
+	FROM users`
+
+	setUserActiveQuery = `UPDATE users
+	SET
+	is_active = $2,
+	updated_at = now()
+	WHERE id = $1
+	AND deleted_at IS NULL;`
+
+	restoreUserQuery = `UPDATE users
+	SET
+	deleted_at = NULL,
+	erase_after = NULL,
+	is_active = true,
+	updated_at = now()
+	WHERE id = $1
+	AND deleted_at IS NOT NULL
+	AND erased_at IS NULL;`
+
+	requirePasswordResetQuery = `UPDATE users
+	SET
+	password_reset_required = true,
 	updated_at = now()
 	WHERE id = $1;`
+
+	updateUserRoleQuery = `UPDATE users
+	SET
+	role = $2,
+	updated_at = now()
+	WHERE id = $1;`
+
+	getUserExportPublicIDQuery = `SELECT public_id FROM users WHERE id = $1`
+
+	// runs under postgress.WithCurrentUser, the product rows of every organization the
+	// user created something in
+	getUserProductsQuery = `SELECT id, name, description, price, stock, isActive, createdAt, updatedAt
+	FROM product
+	WHERE userID = $1
+	ORDER BY createdAt`
+
+	getUserSessionsQuery = `SELECT user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+	FROM user_sessions
+	WHERE user_id = $1
+	ORDER BY created_at`
+
+	getUserIdentitiesQuery = `SELECT provider, subject, email, created_at
+	FROM user_identities
+	WHERE user_id = $1
+	ORDER BY created_at`
+
+	getUserAPIKeysQuery = `SELECT name, prefix, scopes, created_at, last_used_at, expires_at, revoked_at
+	FROM api_keys
+	WHERE user_id = $1
+	ORDER BY created_at`
+
+	getUserOAuthGrantsQuery = `SELECT c.name, g.scopes, g.created_at, g.expires_at, g.revoked_at
+	FROM oauth_grants g
+	JOIN oauth_clients c ON c.id = g.client_id
+	WHERE g.user_id = $1
+	ORDER BY g.created_at`
+
+	// audit events name users by public id, as the actor or as the target
+	getUserAuditEventsQuery = `SELECT public_id, action, impersonator_id IS NOT NULL, target_type, target_id,
+	ip, user_agent, changes, metadata, created_at
+	FROM audit_events
+	WHERE actor_id = $1
+	OR (target_type = 'user' AND target_id = $1::text)
+	ORDER BY id`
+
+	getPendingErasuresQuery = `SELECT id
+	FROM users
+	WHERE erased_at IS NULL
+	AND erase_after <= now()
+	ORDER BY erase_after
+	LIMIT $1`
+
+	// the lockout keys are built from the email, so this runs before eraseUserQuery
+	deleteUserLoginAttemptsQuery = `DELETE FROM login_attempts
+	WHERE key IN (
+		SELECT prefix || lower(email)
+		FROM users, unnest(ARRAY['account:', 'magic_link:', 'password_reset:']) AS prefix
+		WHERE id = $1
+	)`
+
+	// runs under postgress.WithCurrentUser, the organizations the user belongs to or was
+	// invited to
+	getUserOrganizationsQuery = `SELECT o.public_id
+	FROM organizations o
+	WHERE o.id IN (
+		SELECT organization_id
+		FROM organization_members
+		WHERE user_id = $1
+	)
+	OR o.id IN (
+		SELECT i.organization_id
+		FROM organization_invitations i
+		JOIN users u ON lower(u.email) = lower(i.email)
+		WHERE u.id = $1
+	)`
+
+	// the invitation history stays with the organization, without the address. Pending
+	// invitations are revoked, the link must not create a member for the erased address.
+	eraseOrganizationInvitationsQuery = `UPDATE organization_invitations i
+	SET
+	email = 'erased-' || i.public_id || '@erased.invalid',
+	revoked_at = CASE WHEN i.accepted_at IS NULL THEN COALESCE(i.revoked_at, now()) ELSE i.revoked_at END
+	FROM users u
+	WHERE u.id = $1
+	AND lower(i.email) = lower(u.email)`
+
+	// same for the invitations to sign up, including the one the account was created
+	// from, which keeps the address it was sent to
+	eraseUserInvitationsQuery = `UPDATE user_invitations i
+	SET
+	email = 'erased-' || i.public_id || '@erased.invalid',
+	revoked_at = CASE WHEN i.accepted_at IS NULL THEN COALESCE(i.revoked_at, now()) ELSE i.revoked_at END
+	FROM users u
+	WHERE u.id = $1
+	AND (lower(i.email) = lower(u.email) OR i.user_id = u.id)`
+
+	// an organization the user is the last owner of goes to its highest ranked remaining
+	// member, the oldest first. One with no other member is left without any, its
+	// products are kept like the user's own.
+	promoteNextOwnerQuery = `UPDATE organization_members m
+	SET role = 'owner'
+	FROM (
+		SELECT DISTINCT ON (organization_id) organization_id, user_id
+		FROM organization_members
+		WHERE user_id <> $1
+		AND organization_id IN (
+			SELECT organization_id
+			FROM organization_members
+			WHERE user_id = $1
+			AND role = 'owner'
+		)
+		AND organization_id NOT IN (
+			SELECT organization_id
+			FROM organization_members
+			WHERE user_id <> $1
+			AND role = 'owner'
+		)
+		ORDER BY organization_id, CASE role WHEN 'admin' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, created_at
+	) next_owner
+	WHERE m.organization_id = next_owner.organization_id
+	AND m.user_id = next_owner.user_id`
+
+	// returns the avatar key it cleared so the file can be deleted from storage
+	eraseUserQuery = `WITH previous AS (
+		SELECT id, avatar_key
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	)
+	UPDATE users
+	SET
+	name = 'Deleted user',
+	email = 'erased-' || public_id || '@erased.invalid',
+	pending_email = NULL,
+	password_hash = '',
+	is_active = false,
+	email_verified_at = NULL,
+	password_reset_required = false,
+	avatar_key = NULL,
+	preferences = '{}',
+	erase_after = NULL,
+	erased_at = now(),
+	updated_at = now()
+	FROM previous
+	WHERE users.id = previous.id
+	AND users.erased_at IS NULL
+	RETURNING COALESCE(previous.avatar_key, '')`
 )
 
+// eraseUserOrganizationQueries run once per organization of the user, under its tenant,
+// before eraseUserQuery replaces the email the invitations are found by. Each takes the
+// user id.
+var eraseUserOrganizationQueries = []string{
+	eraseOrganizationInvitationsQuery,
+	promoteNextOwnerQuery,
+	`DELETE FROM organization_members WHERE user_id = $1`,
+}
+
+// eraseUserEmailQueries find what was addressed to the user by email, so they run
+// before eraseUserQuery replaces it. Each takes the user id.
+var eraseUserEmailQueries = []string{
+	deleteUserLoginAttemptsQuery,
+	eraseUserInvitationsQuery,
+}
+
+// revokeUserCredentialsQueries cut off the access a user handed out to scripts and
+// third-party apps, each takes the user id. Their rows are kept for the user to review.
+var revokeUserCredentialsQueries = []string{
+	`UPDATE api_keys SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`,
+	`UPDATE oauth_authorization_codes SET used_at = now() WHERE user_id = $1 AND used_at IS NULL`,
+	`UPDATE oauth_grants SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`,
+}
+
+// eraseUserDataQueries drop what an erased user must not leave behind, each takes the
+// user id. Products are kept, they still point at the anonymized row.
+var eraseUserDataQueries = []string{
+	`DELETE FROM password_history WHERE user_id = $1`,
+	`DELETE FROM user_tokens WHERE user_id = $1`,
+	`DELETE FROM user_mfa WHERE user_id = $1`,
+	`DELETE FROM user_sessions WHERE user_id = $1`,
+	`DELETE FROM user_identities WHERE user_id = $1`,
+	`DELETE FROM api_keys WHERE user_id = $1`,
+	`DELETE FROM oauth_authorization_codes WHERE user_id = $1`,
+	`DELETE FROM oauth_grants WHERE user_id = $1`,
+	`UPDATE oauth_clients SET revoked_at = COALESCE(revoked_at, now()) WHERE user_id = $1`,
+}
+
 type userRepository struct {
-	Db *sql.DB
+	Db     *sql.DB
+	hasher hasher.Hasher
 }
 
 type UserRepository interface {
 	GetUserByPublicID(publicId uuid.UUID) (*user_types.GetUserResponse, error)
+	GetUserByID(id int64) (*user_types.GetUserResponse, error)
 	GetUserByEmail(email string) (*user_types.GetUserResponse, error)
 	CreateUser(user user_types.CreateUserRequest) (*user_types.CreateUserResponse, error)
-	SoftDeleteUser(user_id int64) error
+	SoftDeleteUser(user_id int64, eraseAfter time.Time) error
 	UpdateUser(user_id int64, user_params user_types.UpdateUserRequest) error
-	UpdateUserPassword(user_id int64, resetPasswordRequest auth_types.ResetPasswordRequest) error
+	SetPendingEmail(user_id int64, email string) error
+	ConfirmEmailChange(user_id int64) (string, error)
+	UpdateAvatar(user_id int64, key string) error
+	UpdatePreferences(user_id int64, preferences user_types.Preferences) error
+	UpdateUserPassword(user_id int64, newPassword string) error
+	UpgradePasswordHash(user_id int64, currentHash string, password string) error
+	GetPasswordHistory(user_id int64, limit int) ([]string, error)
+	MarkEmailVerified(user_id int64) error
+	RevokeUserSessions(user_id int64) error
+	GetSessionsRevokedAt(publicId uuid.UUID) (*time.Time, error)
+	ListUsers(filter user_types.ListUsersFilter) ([]user_types.AdminUserResponse, int64, error)
+	GetAdminUserByPublicID(publicId uuid.UUID) (*user_types.AdminUserResponse, error)
+	SetUserActive(user_id int64, active bool) error
+	RestoreUser(user_id int64) error
+	RequirePasswordReset(user_id int64) error
+	RevokeUserCredentials(user_id int64) error
+	UpdateUserRole(user_id int64, role string) error
+	GetUserData(user_id int64) (*user_types.UserDataExport, error)
+	GetPendingErasures(limit int) ([]int64, error)
+	EraseUser(user_id int64) (string, error)
 }
 
-func NewUserRepository(Db *sql.DB) UserRepository {
+func NewUserRepository(Db *sql.DB, passwordHasher hasher.Hasher) UserRepository {
 	return &userRepository{
-		Db: Db,
+		Db:     Db,
+		hasher: passwordHasher,
 	}
 }
 
@@ -77,7 +410,13 @@ func (r *userRepository) GetUserByPublicID(publicId uuid.UUID) (*user_types.GetU
 		&user.Name,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Role,
 		&user.IsActive,
+		&user.EmailVerified,
+		&user.MFAEnabled,
+		&user.PasswordResetRequired,
+		&user.AvatarKey,
+		&user.Preferences,
 		&user.CreatedAt,
 		&user.UpdatedAt)
 	if err != nil {
@@ -91,17 +430,56 @@ func (r *userRepository) GetUserByPublicID(publicId uuid.UUID) (*user_types.GetU
 	return &user, nil
 }
 
+func (r *userRepository) GetUserByID(id int64) (*user_types.GetUserResponse, error) {
+	ctx := context.Background()
+
+	row := r.Db.QueryRowContext(ctx, getUserByIDQuery, id)
+
+	var user user_types.GetUserResponse
+	err := row.Scan(
+		&user.ID,
+		&user.PublicID,
+		&user.Name,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.IsActive,
+		&user.EmailVerified,
+		&user.MFAEnabled,
+		&user.PasswordResetRequired,
+		&user.AvatarKey,
+		&user.Preferences,
+		&user.CreatedAt,
+		&user.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Log.Error("user not found", zap.String("error", err.Error()))
+			return nil, user_errors.ErrUserNotFound
+		}
+		logger.Log.Error("user get user by id", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) GetUserByEmail(email string) (*user_types.GetUserResponse, error) {
 	ctx := context.Background()
 
 	row := r.Db.QueryRowContext(ctx, getUserByEmailQuery, email)
 
 	var user user_types.GetUserResponse
-	err := row.Scan(&user.PublicID,
+	err := row.Scan(&user.ID,
+		&user.PublicID,
 		&user.Name,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Role,
 		&user.IsActive,
+		&user.EmailVerified,
+		&user.MFAEnabled,
+		&user.PasswordResetRequired,
+		&user.AvatarKey,
+		&user.Preferences,
 		&user.CreatedAt,
 		&user.UpdatedAt)
 	if err != nil {
@@ -118,17 +496,13 @@ func (r *userRepository) GetUserByEmail(email string) (*user_types.GetUserRespon
 func (r *userRepository) CreateUser(user user_types.CreateUserRequest) (*user_types.CreateUserResponse, error) {
 	ctx := context.Background()
 
-	passwordEncrypted, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	passwordEncrypted, err := r.hasher.Hash(user.Password)
 	if err != nil {
 		logger.Log.Error("error encrypted password", zap.String("error", err.Error()))
 		return nil, fmt.Errorf("%w: %v", user_errors.ErrUserCreationFailed, err)
 	}
 
-	row, err := r.Db.QueryContext(ctx, createUserQuery, user.Name, user.Email, passwordEncrypted, true)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
+	row := r.Db.QueryRowContext(ctx, createUserQuery, user.Name, user.Email, passwordEncrypted, true)
 
 	var u user_types.CreateUserResponse
 
@@ -142,13 +516,19 @@ func (r *userRepository) CreateUser(user user_types.CreateUserRequest) (*user_ty
 		&u.CreatedAt,
 		&u.UpdatedAt,
 	)
+	if err != nil {
+		logger.Log.Error("error creating user", zap.String("error", err.Error()))
+		return nil, err
+	}
 
 	return &u, nil
 }
 
-func (r *userRepository) SoftDeleteUser(user_id int64) error {
+// SoftDeleteUser keeps the user restorable until eraseAfter, then the erasure job
+// anonymizes it.
+func (r *userRepository) SoftDeleteUser(user_id int64, eraseAfter time.Time) error {
 	ctx := context.Background()
-	_, err := r.Db.ExecContext(ctx, softDeleteUserQuery, user_id)
+	_, err := r.Db.ExecContext(ctx, softDeleteUserQuery, user_id, eraseAfter)
 
 	if err != nil {
 		logger.Log.Error("error for soft delete user", zap.String("error", err.Error()))
@@ -158,9 +538,11 @@ func (r *userRepository) SoftDeleteUser(user_id int64) error {
 	return nil
 }
 
+// UpdateUser only changes the name, email changes go through SetPendingEmail and
+// ConfirmEmailChange.
 func (r *userRepository) UpdateUser(user_id int64, user_params user_types.UpdateUserRequest) error {
 	ctx := context.Background()
-	_, err := r.Db.ExecContext(ctx, updateUserQuery, user_id, user_params.Name, user_params.Email)
+	_, err := r.Db.ExecContext(ctx, updateUserQuery, user_id, user_params.Name)
 
 	if err != nil {
 		logger.Log.Error("error for update user", zap.String("error", err.Error()))
@@ -170,25 +552,648 @@ func (r *userRepository) UpdateUser(user_id int64, user_params user_types.Update
 	return nil
 }
 
-func (r *userRepository) UpdateUserPassword(user_id int64, resetPasswordRequest auth_types.ResetPasswordRequest) error {
+// SetPendingEmail replaces any earlier pending address.
+func (r *userRepository) SetPendingEmail(user_id int64, email string) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, setPendingEmailQuery, user_id, email)
+	if err != nil {
+		logger.Log.Error("error setting pending email", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// ConfirmEmailChange swaps the pending address in and returns it. The address counts
+// as verified, the confirmation link was sent to it.
+func (r *userRepository) ConfirmEmailChange(user_id int64) (string, error) {
+	ctx := context.Background()
+
+	var email string
+	err := r.Db.QueryRowContext(ctx, confirmEmailChangeQuery, user_id).Scan(&email)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return "", user_errors.ErrEmailTaken
+		}
+		if err == sql.ErrNoRows {
+			return "", user_errors.ErrUserNotFound
+		}
+		logger.Log.Error("error confirming email change", zap.String("error", err.Error()))
+		return "", err
+	}
+	return email, nil
+}
+
+// UpdateAvatar points the user at a new avatar file, an empty key removes the avatar.
+// Deleting the previous file is up to the caller.
+func (r *userRepository) UpdateAvatar(user_id int64, key string) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, updateAvatarQuery, user_id, key)
+	if err != nil {
+		logger.Log.Error("error updating avatar", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) UpdatePreferences(user_id int64, preferences user_types.Preferences) error {
 	ctx := context.Background()
 
-	err := bcrypt.CompareHashAndPassword([]byte(resetPasswordRequest.OldPasswordHash), []byte(resetPasswordRequest.NewPassword))
-	if err == nil {
-		logger.Log.Error("new password cannot be the same as the old password")
-		return user_errors.ErrSamePassword
+	_, err := r.Db.ExecContext(ctx, updatePreferencesQuery, user_id, preferences)
+	if err != nil {
+		logger.Log.Error("error updating preferences", zap.String("error", err.Error()))
+		return err
 	}
+	return nil
+}
 
-	passwordEncrypted, err := bcrypt.GenerateFromPassword([]byte(resetPasswordRequest.NewPassword), bcrypt.DefaultCost)
+// UpdateUserPassword keeps the replaced hash in password_history so it can't be reused.
+// Policy and reuse checks are the caller's responsibility.
+func (r *userRepository) UpdateUserPassword(user_id int64, newPassword string) error {
+	ctx := context.Background()
+
+	passwordEncrypted, err := r.hasher.Hash(newPassword)
 	if err != nil {
 		logger.Log.Error("error encrypting password", zap.String("error", err.Error()))
 		return fmt.Errorf("%w: %v", user_errors.ErrUserCreationFailed, err)
 	}
 
-	_, err = r.Db.ExecContext(ctx, updateUserPasswordQuery, user_id, passwordEncrypted)
+	tx, err := r.Db.BeginTx(ctx, nil)
 	if err != nil {
+		logger.Log.Error("error starting password update", zap.String("error", err.Error()))
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, savePasswordHistoryQuery, user_id); err != nil {
+		logger.Log.Error("error saving password history", zap.String("error", err.Error()))
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, updateUserPasswordQuery, user_id, passwordEncrypted); err != nil {
 		logger.Log.Error("error updating user password", zap.String("error", err.Error()))
 		return err
 	}
+
+	return tx.Commit()
+}
+
+// UpgradePasswordHash rehashes the same password with the current hasher. It is not a
+// password change: nothing goes to password_history, and nothing is written when the
+// stored hash is no longer currentHash because the password changed in the meantime.
+func (r *userRepository) UpgradePasswordHash(user_id int64, currentHash string, password string) error {
+	ctx := context.Background()
+
+	passwordEncrypted, err := r.hasher.Hash(password)
+	if err != nil {
+		logger.Log.Error("error encrypting password", zap.String("error", err.Error()))
+		return err
+	}
+
+	_, err = r.Db.ExecContext(ctx, upgradePasswordHashQuery, user_id, currentHash, passwordEncrypted)
+	if err != nil {
+		logger.Log.Error("error upgrading password hash", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) GetPasswordHistory(user_id int64, limit int) ([]string, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, getPasswordHistoryQuery, user_id, limit)
+	if err != nil {
+		logger.Log.Error("error getting password history", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+func (r *userRepository) MarkEmailVerified(user_id int64) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, markEmailVerifiedQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error marking email as verified", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) RevokeUserSessions(user_id int64) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, revokeUserSessionsQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error revoking user sessions", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) GetSessionsRevokedAt(publicId uuid.UUID) (*time.Time, error) {
+	ctx := context.Background()
+
+	var revokedAt sql.NullTime
+	err := r.Db.QueryRowContext(ctx, getSessionsRevokedAtQuery, publicId).Scan(&revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, user_errors.ErrUserNotFound
+		}
+		logger.Log.Error("error getting sessions revoked at", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	if !revokedAt.Valid {
+		return nil, nil
+	}
+	return &revokedAt.Time, nil
+}
+
+// ListUsers returns one page of users matching filter, newest first, and how many
+// users match in total.
+func (r *userRepository) ListUsers(filter user_types.ListUsersFilter) ([]user_types.AdminUserResponse, int64, error) {
+	ctx := context.Background()
+
+	where, args := listUsersWhere(filter)
+
+	var total int64
+	if err := r.Db.QueryRowContext(ctx, countUsersQuery+where, args...).Scan(&total); err != nil {
+		logger.Log.Error("error counting users", zap.String("error", err.Error()))
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("%s%s\n\tORDER BY created_at DESC, id DESC\n\tLIMIT $%d OFFSET $%d", listUsersQuery, where, len(args)+1, len(args)+2)
+	rows, err := r.Db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		logger.Log.Error("error listing users", zap.String("error", err.Error()))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []user_types.AdminUserResponse{}
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			logger.Log.Error("error scanning user", zap.String("error", err.Error()))
+			return nil, 0, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, total, rows.Err()
+}
+
+// listUsersWhere turns filter into a WHERE clause, every value is a placeholder.
+func listUsersWhere(filter user_types.ListUsersFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Email != "" {
+		add("email ILIKE $%d", containsPattern(filter.Email))
+	}
+	if filter.Name != "" {
+		add("name ILIKE $%d", containsPattern(filter.Name))
+	}
+	if filter.Role != "" {
+		add("role = $%d", filter.Role)
+	}
+	if filter.Active != nil {
+		add("is_active = $%d", *filter.Active)
+	}
+	if filter.Deleted != nil {
+		if *filter.Deleted {
+			conditions = append(conditions, "deleted_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "deleted_at IS NULL")
+		}
+	}
+	if filter.CreatedFrom != nil {
+		add("created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		add("created_at < $%d", *filter.CreatedTo)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "\n\tWHERE " + strings.Join(conditions, "\n\tAND "), args
+}
+
+// containsPattern matches value anywhere, LIKE wildcards in it are taken literally.
+func containsPattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(value) + "%"
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAdminUser(row rowScanner) (*user_types.AdminUserResponse, error) {
+	var user user_types.AdminUserResponse
+	var deletedAt, eraseAfter, erasedAt sql.NullTime
+	err := row.Scan(
+		&user.ID,
+		&user.PublicID,
+		&user.Name,
+		&user.Email,
+		&user.Role,
+		&user.IsActive,
+		&user.EmailVerified,
+		&user.MFAEnabled,
+		&user.PasswordResetRequired,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&deletedAt,
+		&eraseAfter,
+		&erasedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	user.DeletedAt = nullTime(deletedAt)
+	user.EraseAfter = nullTime(eraseAfter)
+	user.ErasedAt = nullTime(erasedAt)
+	return &user, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// GetAdminUserByPublicID finds deleted and inactive users too.
+func (r *userRepository) GetAdminUserByPublicID(publicId uuid.UUID) (*user_types.AdminUserResponse, error) {
+	ctx := context.Background()
+
+	user, err := scanAdminUser(r.Db.QueryRowContext(ctx, getAdminUserByPublicIDQuery, publicId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, user_errors.ErrUserNotFound
+		}
+		logger.Log.Error("error getting user for admin", zap.String("error", err.Error()))
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) SetUserActive(user_id int64, active bool) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, setUserActiveQuery, user_id, active)
+	if err != nil {
+		logger.Log.Error("error setting user active", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepository) RestoreUser(user_id int64) error {
+	ctx := context.Background()
+
+	result, err := r.Db.ExecContext(ctx, restoreUserQuery, user_id)
+	if err != nil {
+		// a live account took the address while this one was deleted
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return user_errors.ErrEmailTaken
+		}
+		logger.Log.Error("error restoring user", zap.String("error", err.Error()))
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return user_errors.ErrUserNotDeleted
+	}
+	return nil
+}
+
+// RequirePasswordReset blocks every kind of login until the password is changed,
+// UpdateUserPassword clears it.
+func (r *userRepository) RequirePasswordReset(user_id int64) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, requirePasswordResetQuery, user_id)
+	if err != nil {
+		logger.Log.Error("error requiring password reset", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// RevokeUserCredentials revokes the user's API keys and OAuth grants, and burns the
+// authorization codes not exchanged yet so they can't mint a new grant.
+func (r *userRepository) RevokeUserCredentials(user_id int64) error {
+	ctx := context.Background()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("error starting credentials revocation", zap.String("error", err.Error()))
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range revokeUserCredentialsQueries {
+		if _, err = tx.ExecContext(ctx, query, user_id); err != nil {
+			logger.Log.Error("error revoking user credentials", zap.String("error", err.Error()))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *userRepository) UpdateUserRole(user_id int64, role string) error {
+	ctx := context.Background()
+
+	_, err := r.Db.ExecContext(ctx, updateUserRoleQuery, user_id, role)
+	if err != nil {
+		logger.Log.Error("error updating user role", zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// GetUserData collects what the personal data export needs beyond the profile.
+func (r *userRepository) GetUserData(user_id int64) (*user_types.UserDataExport, error) {
+	ctx := context.Background()
+
+	data := &user_types.UserDataExport{
+		Products:    []user_types.ExportProduct{},
+		Sessions:    []user_types.ExportSession{},
+		Identities:  []user_types.ExportIdentity{},
+		APIKeys:     []user_types.ExportAPIKey{},
+		OAuthGrants: []user_types.ExportOAuthGrant{},
+		AuditEvents: []user_types.ExportAuditEvent{},
+	}
+
+	var publicID uuid.UUID
+	err := r.Db.QueryRowContext(ctx, getUserExportPublicIDQuery, user_id).Scan(&publicID)
+	if err == sql.ErrNoRows {
+		return nil, user_errors.ErrUserNotFound
+	} else if err != nil {
+		logger.Log.Error("error exporting user", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	// products sit behind row-level security, the policy for their creator lets them through
+	err = postgress.WithCurrentUser(ctx, r.Db, publicID, func(tx *sql.Tx) error {
+		return queryEach(ctx, tx, getUserProductsQuery, publicID, func(rows *sql.Rows) error {
+			var product user_types.ExportProduct
+			if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price,
+				&product.Stock, &product.IsActive, &product.CreatedAt, &product.UpdatedAt); err != nil {
+				return err
+			}
+			data.Products = append(data.Products, product)
+			return nil
+		})
+	})
+	if err != nil {
+		logger.Log.Error("error exporting user products", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = queryEach(ctx, r.Db, getUserSessionsQuery, user_id, func(rows *sql.Rows) error {
+		var session user_types.ExportSession
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&session.UserAgent, &session.IP, &session.CreatedAt,
+			&session.LastSeenAt, &session.ExpiresAt, &revokedAt); err != nil {
+			return err
+		}
+		session.RevokedAt = nullTime(revokedAt)
+		data.Sessions = append(data.Sessions, session)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("error exporting user sessions", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = queryEach(ctx, r.Db, getUserIdentitiesQuery, user_id, func(rows *sql.Rows) error {
+		var identity user_types.ExportIdentity
+		if err := rows.Scan(&identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return err
+		}
+		data.Identities = append(data.Identities, identity)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("error exporting user identities", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = queryEach(ctx, r.Db, getUserAPIKeysQuery, user_id, func(rows *sql.Rows) error {
+		var key user_types.ExportAPIKey
+		var lastUsedAt, expiresAt, revokedAt sql.NullTime
+		if err := rows.Scan(&key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedAt,
+			&lastUsedAt, &expiresAt, &revokedAt); err != nil {
+			return err
+		}
+		key.LastUsedAt = nullTime(lastUsedAt)
+		key.ExpiresAt = nullTime(expiresAt)
+		key.RevokedAt = nullTime(revokedAt)
+		data.APIKeys = append(data.APIKeys, key)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("error exporting user api keys", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = queryEach(ctx, r.Db, getUserOAuthGrantsQuery, user_id, func(rows *sql.Rows) error {
+		var grant user_types.ExportOAuthGrant
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&grant.Client, pq.Array(&grant.Scopes), &grant.CreatedAt,
+			&grant.ExpiresAt, &revokedAt); err != nil {
+			return err
+		}
+		grant.RevokedAt = nullTime(revokedAt)
+		data.OAuthGrants = append(data.OAuthGrants, grant)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("error exporting user oauth grants", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	err = queryEach(ctx, r.Db, getUserAuditEventsQuery, publicID, func(rows *sql.Rows) error {
+		var event user_types.ExportAuditEvent
+		var changes, metadata sql.NullString
+		if err := rows.Scan(&event.ID, &event.Action, &event.Impersonated, &event.TargetType, &event.TargetID,
+			&event.IP, &event.UserAgent, &changes, &metadata, &event.CreatedAt); err != nil {
+			return err
+		}
+		if changes.Valid {
+			event.Changes = json.RawMessage(changes.String)
+		}
+		if metadata.Valid {
+			event.Metadata = json.RawMessage(metadata.String)
+		}
+		data.AuditEvents = append(data.AuditEvents, event)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("error exporting user audit events", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// queryEach runs a query that takes the user id and calls scan once per row. q is the
+// database or a transaction.
+func queryEach(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, query string, user_id any, scan func(rows *sql.Rows) error) error {
+	rows, err := q.QueryContext(ctx, query, user_id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetPendingErasures returns up to limit users whose erasure is due, oldest first.
+func (r *userRepository) GetPendingErasures(limit int) ([]int64, error) {
+	ctx := context.Background()
+
+	rows, err := r.Db.QueryContext(ctx, getPendingErasuresQuery, limit)
+	if err != nil {
+		logger.Log.Error("error getting pending erasures", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// EraseUser anonymizes the user row and deletes everything linked to it in one
+// transaction, then returns the key of the avatar file left to delete, if any.
+// Erasing an already erased user does nothing.
+func (r *userRepository) EraseUser(user_id int64) (string, error) {
+	ctx := context.Background()
+
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("error starting user erasure", zap.String("error", err.Error()))
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var publicID uuid.UUID
+	err = tx.QueryRowContext(ctx, getUserExportPublicIDQuery, user_id).Scan(&publicID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		logger.Log.Error("error erasing user", zap.String("error", err.Error()))
+		return "", err
+	}
+
+	if err = r.eraseUserOrganizations(ctx, tx, user_id, publicID); err != nil {
+		return "", err
+	}
+
+	for _, query := range eraseUserEmailQueries {
+		if _, err = tx.ExecContext(ctx, query, user_id); err != nil {
+			logger.Log.Error("error erasing data addressed to the user", zap.String("error", err.Error()))
+			return "", err
+		}
+	}
+
+	var avatarKey string
+	err = tx.QueryRowContext(ctx, eraseUserQuery, user_id).Scan(&avatarKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		logger.Log.Error("error erasing user", zap.String("error", err.Error()))
+		return "", err
+	}
+
+	for _, query := range eraseUserDataQueries {
+		if _, err = tx.ExecContext(ctx, query, user_id); err != nil {
+			logger.Log.Error("error erasing user data", zap.String("error", err.Error()))
+			return "", err
+		}
+	}
+
+	return avatarKey, tx.Commit()
+}
+
+// eraseUserOrganizations leaves every organization the user is in and clears the
+// invitations sent to them. Memberships and invitations sit behind row-level security:
+// the user's own rows list the organizations, each one is then changed under its tenant.
+func (r *userRepository) eraseUserOrganizations(ctx context.Context, tx *sql.Tx, user_id int64, publicID uuid.UUID) error {
+	if err := postgress.SetCurrentUser(ctx, tx, publicID); err != nil {
+		return err
+	}
+
+	var orgs []uuid.UUID
+	err := queryEach(ctx, tx, getUserOrganizationsQuery, user_id, func(rows *sql.Rows) error {
+		var org uuid.UUID
+		if err := rows.Scan(&org); err != nil {
+			return err
+		}
+		orgs = append(orgs, org)
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("error listing organizations to erase the user from", zap.String("error", err.Error()))
+		return err
+	}
+
+	for _, org := range orgs {
+		if err := postgress.SetTenant(ctx, tx, org); err != nil {
+			return err
+		}
+
+		for _, query := range eraseUserOrganizationQueries {
+			if _, err := tx.ExecContext(ctx, query, user_id); err != nil {
+				logger.Log.Error("error erasing user from organization", zap.String("error", err.Error()))
+				return err
+			}
+		}
+	}
 	return nil
 }
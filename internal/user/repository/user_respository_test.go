@@ -3,20 +3,28 @@ package user_repository
 import (
 	"database/sql"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"regexp"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
-	auth_types "github.com/celio001/prodify/internal/auth/types"
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_types "github.com/celio001/prodify/internal/user/type"
 	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/password-hasher/hasher"
+	"github.com/celio001/prodify/pkg/postgress"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var testHasher = hasher.NewBcryptHasher(bcrypt.MinCost)
+
 func TestGetUserByPublicID(t *testing.T) {
 	logger.Init("dev")
 
@@ -37,7 +45,13 @@ func TestGetUserByPublicID(t *testing.T) {
 				"name",
 				"email",
 				"password_hash",
+				"role",
 				"isActive",
+				"email_verified",
+				"mfa_enabled",
+				"password_reset_required",
+				"avatar_key",
+				"preferences",
 				"created_at",
 				"updated_at",
 			}).AddRow(
@@ -46,7 +60,13 @@ func TestGetUserByPublicID(t *testing.T) {
 				"Célio",
 				"celio@email.com",
 				"hash",
+				"user",
+				true,
 				true,
+				false,
+				false,
+				"avatars/abc/1.png",
+				[]byte(`{"locale": "pt-BR", "notifications": {"newsletter": true}}`),
 				now,
 				now,
 			),
@@ -74,7 +94,7 @@ func TestGetUserByPublicID(t *testing.T) {
 			assert.NoError(t, err)
 			defer db.Close()
 
-			repo := NewUserRepository(db)
+			repo := NewUserRepository(db, testHasher)
 
 			expect := mock.ExpectQuery(regexp.QuoteMeta(getUserByPublicIDQuery)).
 				WithArgs(publicID)
@@ -91,6 +111,13 @@ func TestGetUserByPublicID(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, user)
 				assert.Equal(t, "Célio", user.Name)
+				assert.Equal(t, "avatars/abc/1.png", user.AvatarKey)
+				assert.Equal(t, user_types.Preferences{
+					Locale:        "pt-BR",
+					Timezone:      "UTC",
+					Currency:      "USD",
+					Notifications: user_types.NotificationPreferences{Newsletter: true},
+				}, user.Preferences)
 			} else if tt.expectError == user_errors.ErrUserNotFound {
 				assert.Nil(t, user)
 				assert.ErrorIs(t, err, user_errors.ErrUserNotFound)
@@ -119,19 +146,33 @@ func TestGetUserByEmail(t *testing.T) {
 		{
 			name: "success",
 			mockRows: sqlmock.NewRows([]string{
+				"id",
 				"publicId",
 				"name",
 				"email",
 				"password_hash",
+				"role",
 				"isActive",
+				"email_verified",
+				"mfa_enabled",
+				"password_reset_required",
+				"avatar_key",
+				"preferences",
 				"created_at",
 				"updated_at",
 			}).AddRow(
+				1,
 				uuid.New(),
 				"Célio",
 				email,
 				"hash",
+				"user",
 				true,
+				false,
+				false,
+				false,
+				"",
+				[]byte(`{}`),
 				now,
 				now,
 			),
@@ -159,7 +200,7 @@ func TestGetUserByEmail(t *testing.T) {
 			assert.NoError(t, err)
 			defer db.Close()
 
-			repo := NewUserRepository(db)
+			repo := NewUserRepository(db, testHasher)
 
 			expect := mock.ExpectQuery(regexp.QuoteMeta(getUserByEmailQuery)).
 				WithArgs(email)
@@ -230,7 +271,7 @@ func TestCreateUser(t *testing.T) {
 			assert.NoError(t, err)
 			defer db.Close()
 
-			repo := NewUserRepository(db)
+			repo := NewUserRepository(db, testHasher)
 
 			if tt.mockError != nil {
 				mock.ExpectQuery(regexp.QuoteMeta(createUserQuery)).
@@ -249,6 +290,7 @@ func TestCreateUser(t *testing.T) {
 					"name",
 					"email",
 					"password_hash",
+					"is_active",
 					"created_at",
 					"updated_at",
 				}).AddRow(
@@ -257,6 +299,7 @@ func TestCreateUser(t *testing.T) {
 					tt.user.Name,
 					tt.user.Email,
 					string(hashedPassword),
+					true,
 					now,
 					now,
 				)
@@ -311,12 +354,13 @@ func TestSoftDeleteUser(t *testing.T) {
 			assert.NoError(t, err)
 			defer db.Close()
 
-			repo := NewUserRepository(db)
+			repo := NewUserRepository(db, testHasher)
 
 			userID := int64(1)
+			eraseAfter := time.Now().Add(30 * 24 * time.Hour)
 
 			expect := mock.ExpectExec(regexp.QuoteMeta(softDeleteUserQuery)).
-				WithArgs(userID)
+				WithArgs(userID, eraseAfter)
 
 			if tt.mockError != nil {
 				expect.WillReturnError(tt.mockError)
@@ -324,7 +368,7 @@ func TestSoftDeleteUser(t *testing.T) {
 				expect.WillReturnResult(sqlmock.NewResult(0, 1))
 			}
 
-			err = repo.SoftDeleteUser(userID)
+			err = repo.SoftDeleteUser(userID, eraseAfter)
 
 			if tt.expectError == nil {
 				assert.NoError(t, err)
@@ -366,7 +410,7 @@ func TestUpdateUser(t *testing.T) {
 			assert.NoError(t, err)
 			defer db.Close()
 
-			repo := NewUserRepository(db)
+			repo := NewUserRepository(db, testHasher)
 
 			userID := int64(1)
 			params := user_types.UpdateUserRequest{
@@ -375,7 +419,7 @@ func TestUpdateUser(t *testing.T) {
 			}
 
 			expect := mock.ExpectExec(regexp.QuoteMeta(updateUserQuery)).
-				WithArgs(userID, params.Name, params.Email)
+				WithArgs(userID, params.Name)
 
 			if tt.mockError != nil {
 				expect.WillReturnError(tt.mockError)
@@ -400,36 +444,27 @@ func TestUpdateUserPassword(t *testing.T) {
 	logger.Init("dev")
 
 	tests := []struct {
-		name           string
-		oldPassword    string
-		newPassword    string
-		mockExecError  error
-		expectError    error
-		expectExecCall bool
+		name             string
+		historyError     error
+		updateError      error
+		expectUpdateCall bool
+		expectError      bool
 	}{
 		{
-			name:           "success",
-			oldPassword:    "123456",
-			newPassword:    "654321",
-			mockExecError:  nil,
-			expectError:    nil,
-			expectExecCall: true,
+			name:             "success",
+			expectUpdateCall: true,
+			expectError:      false,
 		},
 		{
-			name:           "same password",
-			oldPassword:    "123456",
-			newPassword:    "123456",
-			mockExecError:  nil,
-			expectError:    user_errors.ErrSamePassword,
-			expectExecCall: false,
+			name:         "history error",
+			historyError: fmt.Errorf("db error"),
+			expectError:  true,
 		},
 		{
-			name:           "database error",
-			oldPassword:    "123456",
-			newPassword:    "654321",
-			mockExecError:  fmt.Errorf("db error"),
-			expectError:    fmt.Errorf("db error"),
-			expectExecCall: true,
+			name:             "database error",
+			updateError:      fmt.Errorf("db error"),
+			expectUpdateCall: true,
+			expectError:      true,
 		},
 	}
 
@@ -440,40 +475,868 @@ func TestUpdateUserPassword(t *testing.T) {
 			assert.NoError(t, err)
 			defer db.Close()
 
-			repo := NewUserRepository(db)
+			repo := NewUserRepository(db, testHasher)
 
 			userID := int64(1)
 
-			hashedOldPassword, err := bcrypt.GenerateFromPassword([]byte(tt.oldPassword), bcrypt.DefaultCost)
-			assert.NoError(t, err)
+			mock.ExpectBegin()
 
-			resetReq := auth_types.ResetPasswordRequest{
-				OldPasswordHash: string(hashedOldPassword),
-				NewPassword:     tt.newPassword,
+			history := mock.ExpectExec(regexp.QuoteMeta(savePasswordHistoryQuery)).
+				WithArgs(userID)
+			if tt.historyError != nil {
+				history.WillReturnError(tt.historyError)
+			} else {
+				history.WillReturnResult(sqlmock.NewResult(1, 1))
 			}
 
-			if tt.expectExecCall {
+			if tt.expectUpdateCall {
 				expect := mock.ExpectExec(regexp.QuoteMeta(updateUserPasswordQuery)).
 					WithArgs(userID, sqlmock.AnyArg())
 
-				if tt.mockExecError != nil {
-					expect.WillReturnError(tt.mockExecError)
+				if tt.updateError != nil {
+					expect.WillReturnError(tt.updateError)
 				} else {
 					expect.WillReturnResult(sqlmock.NewResult(0, 1))
 				}
 			}
 
-			err = repo.UpdateUserPassword(userID, resetReq)
+			if tt.expectError {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			err = repo.UpdateUserPassword(userID, "654321")
 
-			if tt.expectError == nil {
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
 				assert.NoError(t, err)
-			} else if tt.expectError == user_errors.ErrSamePassword {
-				assert.ErrorIs(t, err, user_errors.ErrSamePassword)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetPasswordHistory(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getPasswordHistoryQuery)).
+		WithArgs(int64(1), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"password_hash"}).AddRow("hash-1").AddRow("hash-2"))
+
+	hashes, err := repo.GetPasswordHistory(1, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hash-1", "hash-2"}, hashes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpgradePasswordHash(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		mockError   error
+		expectError bool
+	}{
+		{
+			name:        "success",
+			mockError:   nil,
+			expectError: false,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewUserRepository(db, testHasher)
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(upgradePasswordHashQuery)).
+				WithArgs(int64(1), "old-hash", sqlmock.AnyArg())
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = repo.UpgradePasswordHash(1, "old-hash", "654321")
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMarkEmailVerified(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		mockError   error
+		expectError bool
+	}{
+		{
+			name:        "success",
+			mockError:   nil,
+			expectError: false,
+		},
+		{
+			name:        "database error",
+			mockError:   fmt.Errorf("db error"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewUserRepository(db, testHasher)
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(markEmailVerifiedQuery)).
+				WithArgs(int64(1))
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
 			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = repo.MarkEmailVerified(1)
+
+			if tt.expectError {
 				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
 			}
 
 			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
+
+func TestRevokeUserSessions(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectExec(regexp.QuoteMeta(revokeUserSessionsQuery)).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.RevokeUserSessions(1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeUserCredentials(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectBegin()
+	for _, query := range revokeUserCredentialsQueries {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	err = repo.RevokeUserCredentials(1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSessionsRevokedAt(t *testing.T) {
+	logger.Init("dev")
+
+	publicID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectNil   bool
+		expectError error
+	}{
+		{
+			name:     "revoked",
+			mockRows: sqlmock.NewRows([]string{"sessions_revoked_at"}).AddRow(now),
+		},
+		{
+			name:      "never revoked",
+			mockRows:  sqlmock.NewRows([]string{"sessions_revoked_at"}).AddRow(nil),
+			expectNil: true,
+		},
+		{
+			name:        "user not found",
+			mockError:   sql.ErrNoRows,
+			expectNil:   true,
+			expectError: user_errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewUserRepository(db, testHasher)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getSessionsRevokedAtQuery)).
+				WithArgs(publicID)
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			revokedAt, err := repo.GetSessionsRevokedAt(publicID)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tt.expectNil {
+				assert.Nil(t, revokedAt)
+			} else {
+				assert.NotNil(t, revokedAt)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+var adminUserRows = []string{
+	"id",
+	"public_id",
+	"name",
+	"email",
+	"role",
+	"is_active",
+	"email_verified",
+	"mfa_enabled",
+	"password_reset_required",
+	"created_at",
+	"updated_at",
+	"deleted_at",
+	"erase_after",
+	"erased_at",
+}
+
+func TestListUsers(t *testing.T) {
+	logger.Init("dev")
+
+	active := true
+	deleted := false
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	where := "\n\tWHERE email ILIKE $1\n\tAND role = $2\n\tAND is_active = $3\n\tAND deleted_at IS NULL\n\tAND created_at >= $4"
+
+	mock.ExpectQuery(regexp.QuoteMeta(countUsersQuery+where)).
+		WithArgs(`%ce\_lio%`, "admin", true, from).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+
+	mock.ExpectQuery(regexp.QuoteMeta(listUsersQuery+where+"\n\tORDER BY created_at DESC, id DESC\n\tLIMIT $5 OFFSET $6")).
+		WithArgs(`%ce\_lio%`, "admin", true, from, 10, 20).
+		WillReturnRows(sqlmock.NewRows(adminUserRows).
+			AddRow(1, uuid.New().String(), "Célio", "ce_lio@email.com", "admin", true, true, false, false, now, now, nil, nil, nil))
+
+	users, total, err := repo.ListUsers(user_types.ListUsersFilter{
+		Email:       "ce_lio",
+		Role:        "admin",
+		Active:      &active,
+		Deleted:     &deleted,
+		CreatedFrom: &from,
+		Limit:       10,
+		Offset:      20,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21), total)
+	assert.Len(t, users, 1)
+	assert.Nil(t, users[0].DeletedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListUsers_NoFilter(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectQuery(regexp.QuoteMeta(countUsersQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectQuery(regexp.QuoteMeta(listUsersQuery+"\n\tORDER BY created_at DESC, id DESC\n\tLIMIT $1 OFFSET $2")).
+		WithArgs(20, 0).
+		WillReturnRows(sqlmock.NewRows(adminUserRows))
+
+	users, total, err := repo.ListUsers(user_types.ListUsersFilter{Limit: 20})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NotNil(t, users)
+	assert.Empty(t, users)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAdminUserByPublicID(t *testing.T) {
+	logger.Init("dev")
+
+	publicID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectError error
+	}{
+		{
+			name: "deleted user",
+			mockRows: sqlmock.NewRows(adminUserRows).
+				AddRow(1, publicID.String(), "Célio", "celio@email.com", "user", false, true, false, false, now, now, now, now, nil),
+		},
+		{
+			name:        "user not found",
+			mockError:   sql.ErrNoRows,
+			expectError: user_errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewUserRepository(db, testHasher)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(getAdminUserByPublicIDQuery)).
+				WithArgs(publicID)
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			user, err := repo.GetAdminUserByPublicID(publicID)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), user.ID)
+				assert.NotNil(t, user.DeletedAt)
+				assert.NotNil(t, user.EraseAfter)
+				assert.Nil(t, user.ErasedAt)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		dbError      error
+		expectError  error
+	}{
+		{
+			name:         "restored",
+			rowsAffected: 1,
+		},
+		{
+			name:         "not deleted",
+			rowsAffected: 0,
+			expectError:  user_errors.ErrUserNotDeleted,
+		},
+		{
+			name:        "email taken by a live user",
+			dbError:     &pq.Error{Code: "23505"},
+			expectError: user_errors.ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewUserRepository(db, testHasher)
+
+			expect := mock.ExpectExec(regexp.QuoteMeta(restoreUserQuery)).
+				WithArgs(int64(1))
+			if tt.dbError != nil {
+				expect.WillReturnError(tt.dbError)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			}
+
+			err = repo.RestoreUser(1)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAdminUserUpdates(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectExec(regexp.QuoteMeta(setUserActiveQuery)).
+		WithArgs(int64(1), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(requirePasswordResetQuery)).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(updateUserRoleQuery)).
+		WithArgs(int64(1), "admin").
+		WillReturnError(fmt.Errorf("db error"))
+
+	assert.NoError(t, repo.SetUserActive(1, false))
+	assert.NoError(t, repo.RequirePasswordReset(1))
+	assert.Error(t, repo.UpdateUserRole(1, "admin"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserData(t *testing.T) {
+	logger.Init("dev")
+
+	now := time.Now()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+	publicID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(getUserExportPublicIDQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"public_id"}).AddRow(publicID.String()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config($1, $2, true)`)).
+		WithArgs(postgress.CurrentUserSetting, publicID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(getUserProductsQuery)).
+		WithArgs(publicID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "price", "stock", "isactive", "createdat", "updatedat"}).
+			AddRow(uuid.New().String(), "Mouse", "Wireless", 99.9, 3, true, now, now))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(getUserSessionsQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_agent", "ip", "created_at", "last_seen_at", "expires_at", "revoked_at"}).
+			AddRow("curl/8.0", "10.0.0.1", now, now, now, nil).
+			AddRow("Firefox", "10.0.0.2", now, now, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta(getUserIdentitiesQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "subject", "email", "created_at"}))
+	mock.ExpectQuery(regexp.QuoteMeta(getUserAPIKeysQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "prefix", "scopes", "created_at", "last_used_at", "expires_at", "revoked_at"}).
+			AddRow("ci-runner", "pfy_abc", "{user:read}", now, nil, nil, nil))
+	mock.ExpectQuery(regexp.QuoteMeta(getUserOAuthGrantsQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "scopes", "created_at", "expires_at", "revoked_at"}))
+	mock.ExpectQuery(regexp.QuoteMeta(getUserAuditEventsQuery)).
+		WithArgs(publicID).
+		WillReturnRows(sqlmock.NewRows([]string{"public_id", "action", "impersonated", "target_type", "target_id",
+			"ip", "user_agent", "changes", "metadata", "created_at"}).
+			AddRow(uuid.NewString(), "auth.login.succeeded", false, "user", publicID.String(),
+				"10.0.0.1", "curl/8.0", nil, `{"method": "password"}`, now).
+			AddRow(uuid.NewString(), "user.updated", true, "user", publicID.String(),
+				"10.0.0.2", "Firefox", `{"name": {"after": null, "before": null}}`, nil, now))
+
+	data, err := repo.GetUserData(1)
+
+	assert.NoError(t, err)
+	assert.Len(t, data.Products, 1)
+	assert.Len(t, data.Sessions, 2)
+	assert.Nil(t, data.Sessions[0].RevokedAt)
+	assert.NotNil(t, data.Sessions[1].RevokedAt)
+	assert.NotNil(t, data.Identities)
+	assert.Empty(t, data.Identities)
+	assert.Equal(t, []string{"user:read"}, data.APIKeys[0].Scopes)
+	assert.Empty(t, data.OAuthGrants)
+	if assert.Len(t, data.AuditEvents, 2) {
+		assert.Equal(t, "auth.login.succeeded", data.AuditEvents[0].Action)
+		assert.JSONEq(t, `{"method":"password"}`, string(data.AuditEvents[0].Metadata))
+		assert.Nil(t, data.AuditEvents[0].Changes)
+		assert.True(t, data.AuditEvents[1].Impersonated)
+		assert.Nil(t, data.AuditEvents[1].Metadata)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPendingErasures(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectQuery(regexp.QuoteMeta(getPendingErasuresQuery)).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(7))
+
+	ids, err := repo.GetPendingErasures(100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 7}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectEraseOrganizations expects the user to be taken out of each of orgs, each under
+// its own tenant.
+func expectEraseOrganizations(mock sqlmock.Sqlmock, publicID uuid.UUID, orgs ...uuid.UUID) {
+	mock.ExpectQuery(regexp.QuoteMeta(getUserExportPublicIDQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"public_id"}).AddRow(publicID.String()))
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config($1, $2, true)`)).
+		WithArgs(postgress.CurrentUserSetting, publicID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rows := sqlmock.NewRows([]string{"public_id"})
+	for _, org := range orgs {
+		rows.AddRow(org.String())
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(getUserOrganizationsQuery)).
+		WithArgs(int64(1)).
+		WillReturnRows(rows)
+
+	for _, org := range orgs {
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config($1, $2, true)`)).
+			WithArgs(postgress.TenantSetting, org.String()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		for _, query := range eraseUserOrganizationQueries {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+	}
+}
+
+func TestEraseUser(t *testing.T) {
+	logger.Init("dev")
+
+	publicID := uuid.New()
+
+	t.Run("erased", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		orgs := []uuid.UUID{uuid.New(), uuid.New()}
+
+		repo := NewUserRepository(db, testHasher)
+
+		mock.ExpectBegin()
+		expectEraseOrganizations(mock, publicID, orgs...)
+		for _, query := range eraseUserEmailQueries {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(eraseUserQuery)).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"avatar_key"}).AddRow("avatars/abc/1.png"))
+		for _, query := range eraseUserDataQueries {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		avatarKey, err := repo.EraseUser(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "avatars/abc/1.png", avatarKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already erased", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		var orgs []uuid.UUID
+
+		repo := NewUserRepository(db, testHasher)
+
+		mock.ExpectBegin()
+		expectEraseOrganizations(mock, publicID, orgs...)
+		for _, query := range eraseUserEmailQueries {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(eraseUserQuery)).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"avatar_key"}))
+		mock.ExpectRollback()
+
+		avatarKey, err := repo.EraseUser(1)
+		assert.NoError(t, err)
+		assert.Empty(t, avatarKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failure rolls back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		var orgs []uuid.UUID
+
+		repo := NewUserRepository(db, testHasher)
+
+		mock.ExpectBegin()
+		expectEraseOrganizations(mock, publicID, orgs...)
+		for _, query := range eraseUserEmailQueries {
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(eraseUserQuery)).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"avatar_key"}).AddRow(""))
+		mock.ExpectExec(regexp.QuoteMeta(eraseUserDataQueries[0])).
+			WithArgs(int64(1)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		_, err = repo.EraseUser(1)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestDeleteUserLoginAttemptsQuery_CoversEmailKeys reads the lockout key builders so a
+// new key built from the email can't be left behind when the account is erased.
+func TestDeleteUserLoginAttemptsQuery_CoversEmailKeys(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "../../auth/lockout/limiter.go", nil, 0)
+	assert.NoError(t, err)
+
+	prefixes := 0
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Type.Params.NumFields() != 1 || fn.Type.Params.List[0].Names[0].Name != "email" {
+			continue
+		}
+
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			ret, ok := node.(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				return true
+			}
+			concat, ok := ret.Results[0].(*ast.BinaryExpr)
+			if !ok {
+				return true
+			}
+			literal, ok := concat.X.(*ast.BasicLit)
+			if !ok || literal.Kind != token.STRING {
+				return true
+			}
+
+			prefix, err := strconv.Unquote(literal.Value)
+			assert.NoError(t, err)
+			assert.Contains(t, deleteUserLoginAttemptsQuery, "'"+prefix+"'", "%s keys are not erased with the user", fn.Name.Name)
+			prefixes++
+			return false
+		})
+	}
+
+	assert.GreaterOrEqual(t, prefixes, 3)
+}
+
+func TestSetPendingEmail(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectExec(regexp.QuoteMeta(setPendingEmailQuery)).
+		WithArgs(int64(1), "new@email.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.SetPendingEmail(1, "new@email.com"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	logger.Init("dev")
+
+	tests := []struct {
+		name        string
+		mockRows    *sqlmock.Rows
+		mockError   error
+		expectError error
+	}{
+		{
+			name:     "swapped",
+			mockRows: sqlmock.NewRows([]string{"email"}).AddRow("new@email.com"),
+		},
+		{
+			name:        "address taken by another account",
+			mockError:   &pq.Error{Code: "23505"},
+			expectError: user_errors.ErrEmailTaken,
+		},
+		{
+			name:        "nothing pending",
+			mockError:   sql.ErrNoRows,
+			expectError: user_errors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			repo := NewUserRepository(db, testHasher)
+
+			expect := mock.ExpectQuery(regexp.QuoteMeta(confirmEmailChangeQuery)).
+				WithArgs(int64(1))
+
+			if tt.mockError != nil {
+				expect.WillReturnError(tt.mockError)
+			} else {
+				expect.WillReturnRows(tt.mockRows)
+			}
+
+			email, err := repo.ConfirmEmailChange(1)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				assert.Empty(t, email)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "new@email.com", email)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateAvatar(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	mock.ExpectExec(regexp.QuoteMeta(updateAvatarQuery)).
+		WithArgs(int64(1), "avatars/abc/1.png").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.UpdateAvatar(1, "avatars/abc/1.png"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepository(db, testHasher)
+
+	preferences := user_types.Preferences{
+		Locale:        "pt-BR",
+		Timezone:      "America/Sao_Paulo",
+		Currency:      "BRL",
+		Notifications: user_types.NotificationPreferences{ProductUpdates: true},
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(updatePreferencesQuery)).
+		WithArgs(int64(1), `{"locale":"pt-BR","timezone":"America/Sao_Paulo","currency":"BRL","notifications":{"productUpdates":true,"newsletter":false}}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.UpdatePreferences(1, preferences))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -35,4 +35,78 @@ func (m *MockUserService) CreateUser(user user_types.CreateUserRequest) (*user_t
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user_types.CreateUserResponse), args.Error(1)
-}
\ No newline at end of file
+}
+
+func (m *MockUserService) ListUsers(request user_types.ListUsersRequest) (*user_types.ListUsersResponse, error) {
+	args := m.Called(request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user_types.ListUsersResponse), args.Error(1)
+}
+
+func (m *MockUserService) GetAdminUser(publicID uuid.UUID) (*user_types.AdminUserResponse, error) {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user_types.AdminUserResponse), args.Error(1)
+}
+
+func (m *MockUserService) SetUserActive(publicID uuid.UUID, active bool) error {
+	args := m.Called(publicID, active)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RestoreUser(publicID uuid.UUID) error {
+	args := m.Called(publicID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ForcePasswordReset(publicID uuid.UUID) (*user_types.AdminUserResponse, error) {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user_types.AdminUserResponse), args.Error(1)
+}
+
+func (m *MockUserService) ChangeUserRole(publicID uuid.UUID, role string) error {
+	args := m.Called(publicID, role)
+	return args.Error(0)
+}
+func (m *MockUserService) ExportUserData(publicID uuid.UUID) (*user_types.UserDataExport, error) {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user_types.UserDataExport), args.Error(1)
+}
+
+func (m *MockUserService) ProcessErasures(limit int) (int, error) {
+	args := m.Called(limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserService) SetAvatar(publicID uuid.UUID, image []byte) (string, error) {
+	args := m.Called(publicID, image)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) DeleteAvatar(publicID uuid.UUID) error {
+	args := m.Called(publicID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetPreferences(publicID uuid.UUID) (*user_types.Preferences, error) {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user_types.Preferences), args.Error(1)
+}
+
+func (m *MockUserService) UpdatePreferences(publicID uuid.UUID, preferences user_types.Preferences) error {
+	args := m.Called(publicID, preferences)
+	return args.Error(0)
+}
@@ -0,0 +1,65 @@
+package user_service
+
+import (
+	"time"
+
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ExportUserData gathers everything stored about the user for the personal data export.
+func (s *userService) ExportUserData(publicID uuid.UUID) (*user_types.UserDataExport, error) {
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.userRepo.GetUserData(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	data.ExportedAt = time.Now().UTC()
+	data.Profile = user_types.ExportProfile{
+		PublicID:      user.PublicID,
+		Name:          user.Name,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		MFAEnabled:    user.MFAEnabled,
+		Preferences:   user.Preferences,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+	if user.AvatarKey != "" {
+		data.Profile.AvatarURL = s.storage.URL(user.AvatarKey)
+	}
+	return data, nil
+}
+
+// ProcessErasures erases up to limit users whose grace period is over and returns how
+// many were erased. A failed erasure is logged and retried on the next run.
+func (s *userService) ProcessErasures(limit int) (int, error) {
+	ids, err := s.userRepo.GetPendingErasures(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	erased := 0
+	for _, id := range ids {
+		avatarKey, err := s.userRepo.EraseUser(id)
+		if err != nil {
+			logger.Log.Error("failed to erase user", zap.Int64("user_id", id), zap.String("error", err.Error()))
+			continue
+		}
+		if avatarKey != "" {
+			s.deleteAvatarFile(avatarKey)
+		}
+
+		logger.Log.Info("user erased", zap.Int64("user_id", id))
+		erased++
+	}
+	return erased, nil
+}
@@ -0,0 +1,93 @@
+package user_service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportUserData(t *testing.T) {
+
+	publicID := uuid.New()
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, nil)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{
+			ID:           1,
+			PublicID:     publicID.String(),
+			Name:         "Célio",
+			Email:        "celio@email.com",
+			PasswordHash: "$2a$10$hash",
+		}, nil)
+		mockRepo.On("GetUserData", int64(1)).Return(&user_types.UserDataExport{
+			Products: []user_types.ExportProduct{{Name: "Mouse"}},
+		}, nil)
+
+		data, err := service.ExportUserData(publicID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "celio@email.com", data.Profile.Email)
+		assert.Len(t, data.Products, 1)
+		assert.False(t, data.ExportedAt.IsZero())
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, nil)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(nil, user_errors.ErrUserNotFound)
+
+		data, err := service.ExportUserData(publicID)
+
+		assert.Equal(t, user_errors.ErrUserNotFound, err)
+		assert.Nil(t, data)
+		mockRepo.AssertNotCalled(t, "GetUserData", int64(1))
+	})
+}
+
+func TestProcessErasures(t *testing.T) {
+	logger.Init("dev")
+
+	t.Run("failed erasures are skipped", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		files := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads")
+		service := NewUserService(mockRepo, files)
+
+		assert.NoError(t, files.Put("avatars/abc/1.png", strings.NewReader("png")))
+
+		mockRepo.On("GetPendingErasures", 10).Return([]int64{1, 2, 3}, nil)
+		mockRepo.On("EraseUser", int64(1)).Return("avatars/abc/1.png", nil)
+		mockRepo.On("EraseUser", int64(2)).Return("", errors.New("db error"))
+		mockRepo.On("EraseUser", int64(3)).Return("", nil)
+
+		erased, err := service.ProcessErasures(10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, erased)
+		assert.ErrorIs(t, files.Delete("avatars/abc/1.png"), storage.ErrNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("pending query fails", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, nil)
+
+		mockRepo.On("GetPendingErasures", 10).Return(nil, errors.New("db error"))
+
+		erased, err := service.ProcessErasures(10)
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, erased)
+	})
+}
@@ -0,0 +1,98 @@
+package user_service
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/imaging"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	avatarSize = 256
+	// larger images are refused before decoding, a 4096x4096 image already takes 64MB
+	maxAvatarDimension = 4096
+)
+
+// SetAvatar crops the image to a centered square, scales it to avatarSize and stores
+// it as PNG under a new key, so cached copies of the previous avatar are never served
+// for the new one. Returns the URL of the new avatar.
+func (s *userService) SetAvatar(publicID uuid.UUID, image []byte) (string, error) {
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return "", err
+	}
+
+	decoded, err := imaging.Decode(image, maxAvatarDimension)
+	if err != nil {
+		return "", err
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, imaging.SquareThumbnail(decoded, avatarSize)); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("avatars/%s/%s.png", user.PublicID, uuid.NewString())
+	if err := s.storage.Put(key, &encoded); err != nil {
+		logger.Log.Error("failed to store avatar", zap.String("error", err.Error()))
+		return "", err
+	}
+
+	if err := s.userRepo.UpdateAvatar(user.ID, key); err != nil {
+		s.deleteAvatarFile(key)
+		return "", err
+	}
+
+	if user.AvatarKey != "" {
+		s.deleteAvatarFile(user.AvatarKey)
+	}
+	return s.storage.URL(key), nil
+}
+
+// DeleteAvatar removes the avatar, users without one are left as they are.
+func (s *userService) DeleteAvatar(publicID uuid.UUID) error {
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return err
+	}
+	if user.AvatarKey == "" {
+		return nil
+	}
+
+	if err := s.userRepo.UpdateAvatar(user.ID, ""); err != nil {
+		return err
+	}
+
+	s.deleteAvatarFile(user.AvatarKey)
+	return nil
+}
+
+// deleteAvatarFile is best effort, the user no longer points at the file so a
+// leftover only costs disk space.
+func (s *userService) deleteAvatarFile(key string) {
+	if err := s.storage.Delete(key); err != nil {
+		logger.Log.Error("failed to delete avatar file", zap.String("key", key), zap.String("error", err.Error()))
+	}
+}
+
+func (s *userService) GetPreferences(publicID uuid.UUID) (*user_types.Preferences, error) {
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return nil, err
+	}
+	return &user.Preferences, nil
+}
+
+// UpdatePreferences replaces the stored preferences, the caller validates them.
+func (s *userService) UpdatePreferences(publicID uuid.UUID, preferences user_types.Preferences) error {
+	user, err := s.userRepo.GetUserByPublicID(publicID)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.UpdatePreferences(user.ID, preferences)
+}
@@ -0,0 +1,179 @@
+package user_service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	user_errors "github.com/celio001/prodify/internal/user/errors"
+	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
+	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/imaging"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testImage(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestSetAvatar(t *testing.T) {
+	logger.Init("dev")
+
+	publicID := uuid.New()
+
+	t.Run("stores a square thumbnail and drops the previous one", func(t *testing.T) {
+		dir := t.TempDir()
+		files := storage.NewLocalStorage(dir, "http://localhost:8080/uploads")
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, files)
+
+		previous := "avatars/" + publicID.String() + "/old.png"
+		assert.NoError(t, files.Put(previous, strings.NewReader("old")))
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{
+			ID:        1,
+			PublicID:  publicID.String(),
+			AvatarKey: previous,
+		}, nil)
+
+		var key string
+		mockRepo.On("UpdateAvatar", int64(1), mock.MatchedBy(func(k string) bool {
+			key = k
+			return strings.HasPrefix(k, "avatars/"+publicID.String()+"/") && strings.HasSuffix(k, ".png")
+		})).Return(nil)
+
+		url, err := service.SetAvatar(publicID, testImage(t, 600, 400))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "http://localhost:8080/uploads/"+key, url)
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+		assert.NoError(t, err)
+		stored, err := png.Decode(bytes.NewReader(content))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, avatarSize, avatarSize), stored.Bounds())
+
+		assert.ErrorIs(t, files.Delete(previous), storage.ErrNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads"))
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1, PublicID: publicID.String()}, nil)
+
+		_, err := service.SetAvatar(publicID, []byte("<svg/>"))
+
+		assert.ErrorIs(t, err, imaging.ErrUnsupportedFormat)
+		mockRepo.AssertNotCalled(t, "UpdateAvatar", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stored file removed when the update fails", func(t *testing.T) {
+		dir := t.TempDir()
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, storage.NewLocalStorage(dir, "http://localhost:8080/uploads"))
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1, PublicID: publicID.String()}, nil)
+		mockRepo.On("UpdateAvatar", int64(1), mock.Anything).Return(errors.New("db error"))
+
+		_, err := service.SetAvatar(publicID, testImage(t, 10, 10))
+
+		assert.Error(t, err)
+		entries, _ := os.ReadDir(filepath.Join(dir, "avatars", publicID.String()))
+		assert.Empty(t, entries)
+	})
+}
+
+func TestDeleteAvatar(t *testing.T) {
+	logger.Init("dev")
+
+	publicID := uuid.New()
+
+	t.Run("removed", func(t *testing.T) {
+		files := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads")
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, files)
+
+		assert.NoError(t, files.Put("avatars/abc/1.png", strings.NewReader("png")))
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1, AvatarKey: "avatars/abc/1.png"}, nil)
+		mockRepo.On("UpdateAvatar", int64(1), "").Return(nil)
+
+		assert.NoError(t, service.DeleteAvatar(publicID))
+		assert.ErrorIs(t, files.Delete("avatars/abc/1.png"), storage.ErrNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("no avatar", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, nil)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+
+		assert.NoError(t, service.DeleteAvatar(publicID))
+		mockRepo.AssertNotCalled(t, "UpdateAvatar", int64(1), "")
+	})
+}
+
+func TestGetUserByPublicIDAvatarURL(t *testing.T) {
+	publicID := uuid.New()
+	mockRepo := new(user_mock.MockUserRepository)
+	service := NewUserService(mockRepo, storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads"))
+
+	mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1, AvatarKey: "avatars/abc/1.png"}, nil)
+
+	user, err := service.GetUserByPublicID(publicID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/uploads/avatars/abc/1.png", user.AvatarURL)
+}
+
+func TestPreferences(t *testing.T) {
+	publicID := uuid.New()
+	preferences := user_types.Preferences{Locale: "pt-BR", Timezone: "America/Sao_Paulo", Currency: "BRL"}
+
+	t.Run("get", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, nil)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1, Preferences: preferences}, nil)
+
+		got, err := service.GetPreferences(publicID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, preferences, *got)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, nil)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(&user_types.GetUserResponse{ID: 1}, nil)
+		mockRepo.On("UpdatePreferences", int64(1), preferences).Return(nil)
+
+		assert.NoError(t, service.UpdatePreferences(publicID, preferences))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockRepo := new(user_mock.MockUserRepository)
+		service := NewUserService(mockRepo, nil)
+
+		mockRepo.On("GetUserByPublicID", publicID).Return(nil, user_errors.ErrUserNotFound)
+
+		assert.Equal(t, user_errors.ErrUserNotFound, service.UpdatePreferences(publicID, preferences))
+		mockRepo.AssertNotCalled(t, "UpdatePreferences", int64(1), preferences)
+	})
+}
@@ -1,30 +1,47 @@
 package user_service
 
-// const minEntropyBits = 60
-
-// if err := password_validator.Validate(user.Password, minEntropyBits); err != nil{
-// 	return err
-// }
-
 import (
+	"time"
+
+	"github.com/celio001/prodify/config"
+	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_repository "github.com/celio001/prodify/internal/user/repository"
 	user_types "github.com/celio001/prodify/internal/user/type"
+	"github.com/celio001/prodify/pkg/storage"
 	"github.com/google/uuid"
 )
 
 type userService struct {
-	userRepo user_repository.UserRepository
+	userRepo     user_repository.UserRepository
+	storage      storage.Storage
+	erasureGrace time.Duration
 }
 
 type UserService interface {
 	GetUserByPublicID(publicID uuid.UUID) (*user_types.GetUserResponse, error)
 	SoftDeleteUser(publicID uuid.UUID) error
 	UpdateUser(publicID uuid.UUID, params user_types.UpdateUserRequest) error
+	ListUsers(request user_types.ListUsersRequest) (*user_types.ListUsersResponse, error)
+	GetAdminUser(publicID uuid.UUID) (*user_types.AdminUserResponse, error)
+	SetUserActive(publicID uuid.UUID, active bool) error
+	RestoreUser(publicID uuid.UUID) error
+	ForcePasswordReset(publicID uuid.UUID) (*user_types.AdminUserResponse, error)
+	ChangeUserRole(publicID uuid.UUID, role string) error
+	ExportUserData(publicID uuid.UUID) (*user_types.UserDataExport, error)
+	ProcessErasures(limit int) (int, error)
+	SetAvatar(publicID uuid.UUID, image []byte) (string, error)
+	DeleteAvatar(publicID uuid.UUID) error
+	GetPreferences(publicID uuid.UUID) (*user_types.Preferences, error)
+	UpdatePreferences(publicID uuid.UUID, preferences user_types.Preferences) error
 }
 
-func NewUserService(userRepo user_repository.UserRepository) UserService {
+const defaultPageSize = 20
+
+func NewUserService(userRepo user_repository.UserRepository, files storage.Storage) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		storage:      files,
+		erasureGrace: time.Duration(config.GetInt("USER_ERASURE_GRACE_DAYS")) * 24 * time.Hour,
 	}
 }
 
@@ -33,19 +50,27 @@ func (s *userService) GetUserByPublicID(publicID uuid.UUID) (*user_types.GetUser
 	if err != nil {
 		return nil, err
 	}
+
+	if user.AvatarKey != "" {
+		user.AvatarURL = s.storage.URL(user.AvatarKey)
+	}
 	return user, nil
 }
 
+// SoftDeleteUser leaves the account restorable by an admin during the erasure grace
+// period, after that ProcessErasures anonymizes it.
 func (s *userService) SoftDeleteUser(publicID uuid.UUID) error {
 	user, err := s.userRepo.GetUserByPublicID(publicID)
 	if err != nil {
 		return err
 	}
-	err = s.userRepo.SoftDeleteUser(user.ID)
+	err = s.userRepo.SoftDeleteUser(user.ID, time.Now().Add(s.erasureGrace))
 	if err != nil {
 		return err
 	}
-	return nil
+
+	// a deleted account must not keep any device signed in
+	return s.userRepo.RevokeUserSessions(user.ID)
 }
 
 func (s *userService) UpdateUser(publicID uuid.UUID, params user_types.UpdateUserRequest) error {
@@ -61,3 +86,140 @@ func (s *userService) UpdateUser(publicID uuid.UUID, params user_types.UpdateUse
 
 	return nil
 }
+
+func (s *userService) ListUsers(request user_types.ListUsersRequest) (*user_types.ListUsersResponse, error) {
+	page, pageSize := request.Page, request.PageSize
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	filter := user_types.ListUsersFilter{
+		Email:   request.Email,
+		Name:    request.Name,
+		Role:    request.Role,
+		Active:  request.Active,
+		Deleted: request.Deleted,
+		Limit:   pageSize,
+		Offset:  (page - 1) * pageSize,
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseDate(request.CreatedFrom); err != nil {
+		return nil, err
+	}
+	if filter.CreatedTo, err = parseDate(request.CreatedTo); err != nil {
+		return nil, err
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, user_errors.ErrInvalidDateRange
+	}
+
+	users, total, err := s.userRepo.ListUsers(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user_types.ListUsersResponse{
+		Users:    users,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
+
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func (s *userService) GetAdminUser(publicID uuid.UUID) (*user_types.AdminUserResponse, error) {
+	return s.userRepo.GetAdminUserByPublicID(publicID)
+}
+
+// SetUserActive signs a deactivated user out everywhere. Deleted users have to be
+// restored instead.
+func (s *userService) SetUserActive(publicID uuid.UUID, active bool) error {
+	user, err := s.userRepo.GetAdminUserByPublicID(publicID)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt != nil {
+		return user_errors.ErrUserDeleted
+	}
+
+	if err := s.userRepo.SetUserActive(user.ID, active); err != nil {
+		return err
+	}
+
+	if active {
+		return nil
+	}
+	return s.userRepo.RevokeUserSessions(user.ID)
+}
+
+// RestoreUser undoes SoftDeleteUser and cancels the erasure, the user comes back
+// active. Once erased there is nothing left to restore.
+func (s *userService) RestoreUser(publicID uuid.UUID) error {
+	user, err := s.userRepo.GetAdminUserByPublicID(publicID)
+	if err != nil {
+		return err
+	}
+	if user.ErasedAt != nil {
+		return user_errors.ErrUserErased
+	}
+	if user.DeletedAt == nil {
+		return user_errors.ErrUserNotDeleted
+	}
+
+	return s.userRepo.RestoreUser(user.ID)
+}
+
+// ForcePasswordReset is for an account that may be compromised. Until the password is
+// changed the user can't sign in by any method, and the sessions, API keys and OAuth
+// grants issued so far stop working. Sending the reset email is up to the caller.
+func (s *userService) ForcePasswordReset(publicID uuid.UUID) (*user_types.AdminUserResponse, error) {
+	user, err := s.userRepo.GetAdminUserByPublicID(publicID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt != nil {
+		return nil, user_errors.ErrUserDeleted
+	}
+
+	if err := s.userRepo.RequirePasswordReset(user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.RevokeUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.RevokeUserCredentials(user.ID); err != nil {
+		return nil, err
+	}
+
+	user.PasswordResetRequired = true
+	return user, nil
+}
+
+// ChangeUserRole takes effect on the next request, roles are checked against the
+// database rather than carried in tokens.
+func (s *userService) ChangeUserRole(publicID uuid.UUID, role string) error {
+	user, err := s.userRepo.GetAdminUserByPublicID(publicID)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt != nil {
+		return user_errors.ErrUserDeleted
+	}
+
+	return s.userRepo.UpdateUserRole(user.ID, role)
+}
@@ -3,6 +3,7 @@ package user_service
 import (
 	"errors"
 	"testing"
+	"time"
 
 	user_errors "github.com/celio001/prodify/internal/user/errors"
 	user_mock "github.com/celio001/prodify/internal/user/repository/mock"
@@ -10,12 +11,13 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestSoftDeleteUser_Success(t *testing.T) {
 
 	mockRepo := new(user_mock.MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	publicID := uuid.New()
 
@@ -28,7 +30,14 @@ func TestSoftDeleteUser_Success(t *testing.T) {
 		Return(user, nil)
 
 	mockRepo.
-		On("SoftDeleteUser", int64(1)).
+		On("SoftDeleteUser", int64(1), mock.MatchedBy(func(eraseAfter time.Time) bool {
+			// erasure is scheduled after the default 30 day grace period
+			return eraseAfter.Sub(time.Now()) > 29*24*time.Hour
+		})).
+		Return(nil)
+
+	mockRepo.
+		On("RevokeUserSessions", int64(1)).
 		Return(nil)
 
 	err := service.SoftDeleteUser(publicID)
@@ -40,7 +49,7 @@ func TestSoftDeleteUser_Success(t *testing.T) {
 func TestSoftDeleteUser_GetUserError(t *testing.T) {
 
 	mockRepo := new(user_mock.MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	publicID := uuid.New()
 	mockRepo.
@@ -58,7 +67,7 @@ func TestSoftDeleteUser_GetUserError(t *testing.T) {
 func TestSoftDeleteUser_DeleteError(t *testing.T) {
 
 	mockRepo := new(user_mock.MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	publicID := uuid.New()
 	deleteError := errors.New("delete error")
@@ -72,7 +81,7 @@ func TestSoftDeleteUser_DeleteError(t *testing.T) {
 		Return(user, nil)
 
 	mockRepo.
-		On("SoftDeleteUser", int64(1)).
+		On("SoftDeleteUser", int64(1), mock.AnythingOfType("time.Time")).
 		Return(deleteError)
 
 	err := service.SoftDeleteUser(publicID)
@@ -86,7 +95,7 @@ func TestSoftDeleteUser_DeleteError(t *testing.T) {
 func TestUpdateUser_Success(t *testing.T) {
 
 	mockRepo := new(user_mock.MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	publicID := uuid.New()
 
@@ -116,7 +125,7 @@ func TestUpdateUser_Success(t *testing.T) {
 func TestUpdateUser_GetUserError(t *testing.T) {
 
 	mockRepo := new(user_mock.MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	publicID := uuid.New()
 	params := user_types.UpdateUserRequest{}
@@ -138,7 +147,7 @@ func TestUpdateUser_GetUserError(t *testing.T) {
 func TestUpdateUser_UpdateError(t *testing.T) {
 
 	mockRepo := new(user_mock.MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	publicID := uuid.New()
 
@@ -167,3 +176,182 @@ func TestUpdateUser_UpdateError(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestListUsers(t *testing.T) {
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		request      user_types.ListUsersRequest
+		expectFilter *user_types.ListUsersFilter
+		expectError  error
+	}{
+		{
+			name:         "defaults",
+			request:      user_types.ListUsersRequest{},
+			expectFilter: &user_types.ListUsersFilter{Limit: 20, Offset: 0},
+		},
+		{
+			name: "filters and page",
+			request: user_types.ListUsersRequest{
+				Role:        "admin",
+				CreatedFrom: "2026-01-01T00:00:00Z",
+				CreatedTo:   "2026-02-01T00:00:00Z",
+				Page:        3,
+				PageSize:    10,
+			},
+			expectFilter: &user_types.ListUsersFilter{Role: "admin", CreatedFrom: &from, CreatedTo: &to, Limit: 10, Offset: 20},
+		},
+		{
+			name: "empty date range",
+			request: user_types.ListUsersRequest{
+				CreatedFrom: "2026-02-01T00:00:00Z",
+				CreatedTo:   "2026-01-01T00:00:00Z",
+			},
+			expectError: user_errors.ErrInvalidDateRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := new(user_mock.MockUserRepository)
+			service := NewUserService(mockRepo, nil)
+
+			if tt.expectFilter != nil {
+				mockRepo.
+					On("ListUsers", *tt.expectFilter).
+					Return([]user_types.AdminUserResponse{{PublicID: "user-1"}}, int64(31), nil)
+			}
+
+			response, err := service.ListUsers(tt.request)
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				mockRepo.AssertNotCalled(t, "ListUsers")
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, int64(31), response.Total)
+			assert.Equal(t, tt.expectFilter.Limit, response.PageSize)
+			assert.Len(t, response.Users, 1)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSetUserActive(t *testing.T) {
+
+	publicID := uuid.New()
+	deletedAt := time.Now()
+
+	tests := []struct {
+		name         string
+		user         *user_types.AdminUserResponse
+		active       bool
+		expectRevoke bool
+		expectError  error
+	}{
+		{
+			name:         "deactivate signs the user out",
+			user:         &user_types.AdminUserResponse{ID: 1, IsActive: true},
+			active:       false,
+			expectRevoke: true,
+		},
+		{
+			name:   "activate",
+			user:   &user_types.AdminUserResponse{ID: 1},
+			active: true,
+		},
+		{
+			name:        "deleted user",
+			user:        &user_types.AdminUserResponse{ID: 1, DeletedAt: &deletedAt},
+			active:      true,
+			expectError: user_errors.ErrUserDeleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			mockRepo := new(user_mock.MockUserRepository)
+			service := NewUserService(mockRepo, nil)
+
+			mockRepo.On("GetAdminUserByPublicID", publicID).Return(tt.user, nil)
+			if tt.expectError == nil {
+				mockRepo.On("SetUserActive", int64(1), tt.active).Return(nil)
+			}
+			if tt.expectRevoke {
+				mockRepo.On("RevokeUserSessions", int64(1)).Return(nil)
+			}
+
+			err := service.SetUserActive(publicID, tt.active)
+
+			assert.Equal(t, tt.expectError, err)
+			mockRepo.AssertExpectations(t)
+			if !tt.expectRevoke {
+				mockRepo.AssertNotCalled(t, "RevokeUserSessions", int64(1))
+			}
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+
+	publicID := uuid.New()
+	deletedAt := time.Now()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	service := NewUserService(mockRepo, nil)
+
+	mockRepo.On("GetAdminUserByPublicID", publicID).Return(&user_types.AdminUserResponse{ID: 1, DeletedAt: &deletedAt}, nil).Once()
+	mockRepo.On("RestoreUser", int64(1)).Return(nil)
+
+	assert.NoError(t, service.RestoreUser(publicID))
+
+	mockRepo.On("GetAdminUserByPublicID", publicID).Return(&user_types.AdminUserResponse{ID: 1}, nil).Once()
+
+	assert.Equal(t, user_errors.ErrUserNotDeleted, service.RestoreUser(publicID))
+
+	mockRepo.On("GetAdminUserByPublicID", publicID).Return(&user_types.AdminUserResponse{ID: 1, DeletedAt: &deletedAt, ErasedAt: &deletedAt}, nil).Once()
+
+	assert.Equal(t, user_errors.ErrUserErased, service.RestoreUser(publicID))
+	mockRepo.AssertNumberOfCalls(t, "RestoreUser", 1)
+}
+
+func TestForcePasswordReset(t *testing.T) {
+
+	publicID := uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	service := NewUserService(mockRepo, nil)
+
+	mockRepo.On("GetAdminUserByPublicID", publicID).Return(&user_types.AdminUserResponse{ID: 1, Email: "celio@test.com"}, nil)
+	mockRepo.On("RequirePasswordReset", int64(1)).Return(nil)
+	mockRepo.On("RevokeUserSessions", int64(1)).Return(nil)
+	mockRepo.On("RevokeUserCredentials", int64(1)).Return(nil)
+
+	user, err := service.ForcePasswordReset(publicID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "celio@test.com", user.Email)
+	assert.True(t, user.PasswordResetRequired)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangeUserRole(t *testing.T) {
+
+	publicID := uuid.New()
+
+	mockRepo := new(user_mock.MockUserRepository)
+	service := NewUserService(mockRepo, nil)
+
+	mockRepo.On("GetAdminUserByPublicID", publicID).Return(&user_types.AdminUserResponse{ID: 1, Role: user_types.RoleUser}, nil)
+	mockRepo.On("UpdateUserRole", int64(1), user_types.RoleAdmin).Return(nil)
+
+	assert.NoError(t, service.ChangeUserRole(publicID, user_types.RoleAdmin))
+	mockRepo.AssertExpectations(t)
+}
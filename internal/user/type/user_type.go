@@ -1,11 +1,20 @@
 package user_types
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/celio001/prodify/config"
 	"github.com/google/uuid"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=3,max=50"`
 	Email    string `json:"email" validate:"required,email"`
@@ -28,12 +37,209 @@ type UpdateUserRequest struct {
 	Email string `json:"email,omitempty" validate:"omitempty,email"`
 }
 type GetUserResponse struct {
-	ID           int64     `json:"id"`
-	PublicID     string    `json:"publicId"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"passwordHash"`
-	IsActive     bool      `json:"isActive"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID                    int64       `json:"id"`
+	PublicID              string      `json:"publicId"`
+	Name                  string      `json:"name"`
+	Email                 string      `json:"email"`
+	PasswordHash          string      `json:"passwordHash"`
+	Role                  string      `json:"role"`
+	IsActive              bool        `json:"isActive"`
+	EmailVerified         bool        `json:"emailVerified"`
+	MFAEnabled            bool        `json:"mfaEnabled"`
+	PasswordResetRequired bool        `json:"passwordResetRequired"`
+	AvatarKey             string      `json:"-"`
+	AvatarURL             string      `json:"avatarUrl,omitempty"`
+	Preferences           Preferences `json:"preferences"`
+	CreatedAt             time.Time   `json:"createdAt"`
+	UpdatedAt             time.Time   `json:"updatedAt"`
+}
+
+// AdminUserResponse is what admins see of a user, deleted and inactive users included.
+type AdminUserResponse struct {
+	ID                    int64      `json:"-"`
+	PublicID              string     `json:"publicId"`
+	Name                  string     `json:"name"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	IsActive              bool       `json:"isActive"`
+	EmailVerified         bool       `json:"emailVerified"`
+	MFAEnabled            bool       `json:"mfaEnabled"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+	DeletedAt             *time.Time `json:"deletedAt,omitempty"`
+	EraseAfter            *time.Time `json:"eraseAfter,omitempty"`
+	ErasedAt              *time.Time `json:"erasedAt,omitempty"`
+}
+
+// ListUsersRequest is the query string of the admin user list. Omitted filters match
+// every user, dates are RFC 3339.
+type ListUsersRequest struct {
+	Email       string `query:"email" validate:"omitempty,max=254"`
+	Name        string `query:"name" validate:"omitempty,max=100"`
+	Role        string `query:"role" validate:"omitempty,oneof=user admin"`
+	Active      *bool  `query:"active"`
+	Deleted     *bool  `query:"deleted"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Page        int    `query:"page" validate:"omitempty,min=1"`
+	PageSize    int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// ListUsersFilter is ListUsersRequest ready for the repository. Email and Name match
+// any part of the value, ignoring case.
+type ListUsersFilter struct {
+	Email       string
+	Name        string
+	Role        string
+	Active      *bool
+	Deleted     *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       int
+	Offset      int
+}
+
+type ListUsersResponse struct {
+	Users    []AdminUserResponse `json:"users"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"pageSize"`
+	Total    int64               `json:"total"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+// UserDataExport is everything stored about a user, served by the personal data export.
+type UserDataExport struct {
+	ExportedAt  time.Time          `json:"exportedAt"`
+	Profile     ExportProfile      `json:"profile"`
+	Products    []ExportProduct    `json:"products"`
+	Sessions    []ExportSession    `json:"sessions"`
+	Identities  []ExportIdentity   `json:"identities"`
+	APIKeys     []ExportAPIKey     `json:"apiKeys"`
+	OAuthGrants []ExportOAuthGrant `json:"oauthGrants"`
+	AuditEvents []ExportAuditEvent `json:"auditEvents"`
+}
+
+type ExportProfile struct {
+	PublicID      string      `json:"publicId"`
+	Name          string      `json:"name"`
+	Email         string      `json:"email"`
+	Role          string      `json:"role"`
+	EmailVerified bool        `json:"emailVerified"`
+	MFAEnabled    bool        `json:"mfaEnabled"`
+	AvatarURL     string      `json:"avatarUrl,omitempty"`
+	Preferences   Preferences `json:"preferences"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
+
+type ExportProduct struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       float64   `json:"price"`
+	Stock       int       `json:"stock"`
+	IsActive    bool      `json:"isActive"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type ExportSession struct {
+	UserAgent  string     `json:"userAgent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type ExportIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ExportAPIKey never carries the key itself, only the hash is stored.
+type ExportAPIKey struct {
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type ExportOAuthGrant struct {
+	Client    string     `json:"client"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// ExportAuditEvent is an audit log entry the user made or that is about them.
+// Impersonated marks requests an admin made while acting as the user.
+type ExportAuditEvent struct {
+	ID           string          `json:"id"`
+	Action       string          `json:"action"`
+	Impersonated bool            `json:"impersonated,omitempty"`
+	TargetType   string          `json:"targetType,omitempty"`
+	TargetID     string          `json:"targetId,omitempty"`
+	IP           string          `json:"ip"`
+	UserAgent    string          `json:"userAgent"`
+	Changes      json.RawMessage `json:"changes,omitempty"`
+	Metadata     json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// Preferences is stored as JSONB in users.preferences. Keys missing from the stored
+// document take the DefaultPreferences value.
+type Preferences struct {
+	Locale        string                  `json:"locale" validate:"required,oneof=en pt-BR"`
+	Timezone      string                  `json:"timezone" validate:"required,timezone"`
+	Currency      string                  `json:"currency" validate:"required,iso4217"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// NotificationPreferences are opt-ins, account and security emails are always sent.
+type NotificationPreferences struct {
+	ProductUpdates bool `json:"productUpdates"`
+	Newsletter     bool `json:"newsletter"`
+}
+
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Locale:   config.GetString("DEFAULT_LOCALE"),
+		Timezone: config.GetString("DEFAULT_TIMEZONE"),
+		Currency: config.GetString("DEFAULT_CURRENCY"),
+	}
+}
+
+// Scan reads the JSONB column over the defaults.
+func (p *Preferences) Scan(src any) error {
+	*p = DefaultPreferences()
+
+	switch value := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(value, p)
+	case string:
+		return json.Unmarshal([]byte(value), p)
+	default:
+		return fmt.Errorf("cannot scan %T into preferences", src)
+	}
+}
+
+func (p Preferences) Value() (driver.Value, error) {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
 }
@@ -1,6 +1,11 @@
 package main
 
-import "github.com/celio001/prodify/cmd"
+import (
+	// timezone preferences are checked against the embedded database, hosts may not ship one
+	_ "time/tzdata"
+
+	"github.com/celio001/prodify/cmd"
+)
 
 func main() {
 	cmd.Execute()
@@ -0,0 +1,14 @@
+-- Email verification: track when the address was proven and keep hashed one-time tokens.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ NULL;
+
+CREATE TABLE user_tokens (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose     VARCHAR(32) NOT NULL,
+    token_hash  CHAR(64)    NOT NULL UNIQUE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_tokens_user_purpose_idx ON user_tokens (user_id, purpose) WHERE consumed_at IS NULL;
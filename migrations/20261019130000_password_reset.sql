@@ -0,0 +1,2 @@
+-- Tokens issued before this instant are rejected by the auth middleware.
+ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ NULL;
@@ -0,0 +1,9 @@
+-- Previous password hashes, used to stop users from cycling back to a recent password.
+CREATE TABLE password_history (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX password_history_user_created_idx ON password_history (user_id, created_at DESC);
@@ -0,0 +1,2 @@
+-- Role used for authorization of administrative endpoints.
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
@@ -0,0 +1,7 @@
+-- Failed login counters keyed by "account:<email>" or "ip:<address>", used by the postgres lockout store.
+CREATE TABLE login_attempts (
+    key             VARCHAR(320) PRIMARY KEY,
+    failures        INT          NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMPTZ
+);
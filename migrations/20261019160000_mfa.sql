@@ -0,0 +1,19 @@
+-- TOTP second factor. A row with enabled_at NULL is an enrollment waiting for its first code.
+CREATE TABLE user_mfa (
+    user_id        BIGINT       PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret         VARCHAR(64)  NOT NULL,
+    enabled_at     TIMESTAMPTZ,
+    last_used_step BIGINT,
+    created_at     TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+-- Hashed one-time recovery codes, shown to the user once when MFA is enabled.
+CREATE TABLE mfa_recovery_codes (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES user_mfa (user_id) ON DELETE CASCADE,
+    code_hash  CHAR(64)    NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX mfa_recovery_codes_user_code_idx ON mfa_recovery_codes (user_id, code_hash);
@@ -0,0 +1,16 @@
+-- Long-lived personal API keys. Only the prefix is stored in clear, used to find the row.
+CREATE TABLE api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    public_id    UUID         NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    user_id      BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(32)  NOT NULL UNIQUE,
+    key_hash     CHAR(64)     NOT NULL,
+    scopes       TEXT[]       NOT NULL DEFAULT '{}',
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX api_keys_user_idx ON api_keys (user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,16 @@
+-- One row per login. The session is the refresh-token family: every refresh rotates
+-- refresh_token_id, and presenting an older one revokes the whole session.
+CREATE TABLE user_sessions (
+    id               BIGSERIAL PRIMARY KEY,
+    public_id        UUID        NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    user_id          BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    refresh_token_id UUID        NOT NULL,
+    user_agent       TEXT        NOT NULL DEFAULT '',
+    ip               VARCHAR(45) NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at       TIMESTAMPTZ NOT NULL,
+    revoked_at       TIMESTAMPTZ
+);
+
+CREATE INDEX user_sessions_user_idx ON user_sessions (user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,12 @@
+-- External identities (Google, GitHub, any OIDC provider) linked to local users.
+CREATE TABLE user_identities (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider   VARCHAR(50)  NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    email      VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX user_identities_user_idx ON user_identities (user_id);
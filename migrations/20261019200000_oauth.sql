@@ -0,0 +1,45 @@
+-- Third-party applications acting on behalf of users. Public clients have no secret
+-- and must use PKCE; confidential clients may also use the client-credentials grant.
+CREATE TABLE oauth_clients (
+    id            BIGSERIAL PRIMARY KEY,
+    public_id     UUID         NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    user_id       BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name          VARCHAR(100) NOT NULL,
+    secret_hash   CHAR(64),
+    redirect_uris TEXT[]       NOT NULL DEFAULT '{}',
+    scopes        TEXT[]       NOT NULL DEFAULT '{}',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX oauth_clients_user_idx ON oauth_clients (user_id) WHERE revoked_at IS NULL;
+
+-- Single-use codes from the consent step, only the hash is stored.
+CREATE TABLE oauth_authorization_codes (
+    id             BIGSERIAL PRIMARY KEY,
+    code_hash      CHAR(64)     NOT NULL UNIQUE,
+    client_id      BIGINT       NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id        BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri   TEXT         NOT NULL,
+    scopes         TEXT[]       NOT NULL DEFAULT '{}',
+    code_challenge VARCHAR(128) NOT NULL,
+    expires_at     TIMESTAMPTZ  NOT NULL,
+    used_at        TIMESTAMPTZ
+);
+
+-- One row per issued authorization, like user_sessions for first-party logins. Access
+-- tokens carry the grant id, refresh tokens rotate refresh_token_id and presenting an
+-- older one revokes the grant.
+CREATE TABLE oauth_grants (
+    id               BIGSERIAL PRIMARY KEY,
+    public_id        UUID        NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    client_id        BIGINT      NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id          BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    scopes           TEXT[]      NOT NULL DEFAULT '{}',
+    refresh_token_id UUID,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at       TIMESTAMPTZ NOT NULL,
+    revoked_at       TIMESTAMPTZ
+);
+
+CREATE INDEX oauth_grants_client_idx ON oauth_grants (client_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,5 @@
+-- Set by an admin to force a password reset, cleared when the password changes.
+ALTER TABLE users ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT false;
+
+-- Admin user list, newest first.
+CREATE INDEX users_created_idx ON users (created_at DESC, id DESC);
@@ -0,0 +1,7 @@
+-- Deleting an account schedules its erasure. Once erase_after has passed the erasure job
+-- anonymizes the row and drops everything linked to it; the row itself stays so
+-- product.userID keeps pointing at a user.
+ALTER TABLE users ADD COLUMN erase_after TIMESTAMPTZ NULL;
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMPTZ NULL;
+
+CREATE INDEX users_erase_after_idx ON users (erase_after) WHERE erased_at IS NULL;
@@ -0,0 +1,29 @@
+-- Address waiting for confirmation, it replaces email once the link sent to it is opened.
+ALTER TABLE users ADD COLUMN pending_email VARCHAR(255) NULL;
+
+-- Uniqueness was only checked by the application, and case sensitively, so live accounts
+-- may already share an address. Which one keeps it is an operator's call, so stop here
+-- and name them instead of picking one.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(ids, '; ') INTO duplicates
+    FROM (
+        SELECT string_agg(id::text, ', ' ORDER BY id) AS ids
+        FROM users
+        WHERE deleted_at IS NULL
+        GROUP BY lower(email)
+        HAVING count(*) > 1
+    ) shared;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'live users share an email address (user ids: %)', duplicates
+            USING HINT = 'change the email of or soft delete all but one user of each group, then apply this migration again';
+    END IF;
+END;
+$$;
+
+-- Addresses are compared lowercased. Deleted accounts keep their address until erased,
+-- so only live accounts are covered.
+CREATE UNIQUE INDEX users_email_live_idx ON users (lower(email)) WHERE deleted_at IS NULL;
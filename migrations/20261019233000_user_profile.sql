@@ -0,0 +1,6 @@
+-- Storage key of the profile picture, the file itself lives in the configured storage.
+ALTER TABLE users ADD COLUMN avatar_key VARCHAR(255) NULL;
+
+-- Locale, timezone, currency and notification opt-ins. Keys left out fall back to the
+-- application defaults, so new preferences need no backfill.
+ALTER TABLE users ADD COLUMN preferences JSONB NOT NULL DEFAULT '{}';
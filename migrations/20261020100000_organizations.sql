@@ -0,0 +1,71 @@
+-- Companies sharing one catalog. Every member has one role per organization:
+-- owner > admin > editor > viewer.
+CREATE TABLE organizations (
+    id         BIGSERIAL PRIMARY KEY,
+    public_id  UUID         NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    name       VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE organization_members (
+    organization_id BIGINT      NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id         BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role            VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'admin', 'editor', 'viewer')),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX organization_members_user_idx ON organization_members (user_id);
+
+-- Invitations by email, only the hash of the link token is stored. The invitee may not
+-- have an account yet.
+CREATE TABLE organization_invitations (
+    id              BIGSERIAL PRIMARY KEY,
+    public_id       UUID         NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    organization_id BIGINT       NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    email           VARCHAR(255) NOT NULL,
+    role            VARCHAR(20)  NOT NULL CHECK (role IN ('admin', 'editor', 'viewer')),
+    token_hash      CHAR(64)     NOT NULL UNIQUE,
+    invited_by      BIGINT       REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at      TIMESTAMPTZ  NOT NULL,
+    accepted_at     TIMESTAMPTZ,
+    revoked_at      TIMESTAMPTZ
+);
+
+CREATE INDEX organization_invitations_org_idx ON organization_invitations (organization_id, created_at);
+
+-- At most one open invitation per address, inviting again replaces it.
+CREATE UNIQUE INDEX organization_invitations_pending_idx ON organization_invitations (organization_id, lower(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+-- Products move from their creator to an organization. Every existing product owner
+-- gets an organization of their own holding their products.
+ALTER TABLE product ADD COLUMN orgID UUID REFERENCES organizations (public_id);
+
+CREATE TEMPORARY TABLE product_owner_orgs AS
+SELECT owners.userID AS user_public_id, gen_random_uuid() AS org_public_id
+FROM (SELECT DISTINCT userID FROM product) owners;
+
+INSERT INTO organizations (public_id, name)
+SELECT o.org_public_id, COALESCE(u.name, 'My organization')
+FROM product_owner_orgs o
+LEFT JOIN users u ON u.public_id = o.user_public_id;
+
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT org.id, u.id, 'owner'
+FROM product_owner_orgs o
+JOIN organizations org ON org.public_id = o.org_public_id
+JOIN users u ON u.public_id = o.user_public_id;
+
+UPDATE product p
+SET orgID = o.org_public_id
+FROM product_owner_orgs o
+WHERE p.userID = o.user_public_id;
+
+DROP TABLE product_owner_orgs;
+
+ALTER TABLE product ALTER COLUMN orgID SET NOT NULL;
+
+CREATE INDEX product_org_idx ON product (orgID, createdAt);
@@ -0,0 +1,54 @@
+-- Tenant isolation enforced by Postgres itself. Repositories run in a transaction that
+-- sets app.current_tenant (see postgress.WithTenant), a query that forgets its orgID
+-- filter still only sees and writes that organization's products. Without the setting
+-- nothing is visible. FORCE applies the policies to the table owner the API connects as;
+-- superusers and BYPASSRLS roles still skip them, the API must not use one.
+ALTER TABLE product ENABLE ROW LEVEL SECURITY;
+ALTER TABLE product FORCE ROW LEVEL SECURITY;
+
+-- current_setting is '' rather than NULL once a transaction that set it has ended on
+-- the same connection, NULLIF makes both mean "no tenant".
+CREATE POLICY product_tenant_isolation ON product
+    USING (orgID = NULLIF(current_setting('app.current_tenant', true), '')::uuid)
+    WITH CHECK (orgID = NULLIF(current_setting('app.current_tenant', true), '')::uuid);
+
+-- The personal data export reads the products a user created, whichever organization
+-- they are in. Read only, set through postgress.WithCurrentUser.
+CREATE POLICY product_creator_read ON product
+    FOR SELECT
+    USING (userID = NULLIF(current_setting('app.current_user', true), '')::uuid);
+
+-- Memberships and invitations belong to an organization the same way. The policies
+-- compare ids, these turn the settings into the rows they name, NULL when unset.
+CREATE FUNCTION app_current_organization_id() RETURNS BIGINT AS $$
+    SELECT id FROM organizations WHERE public_id = NULLIF(current_setting('app.current_tenant', true), '')::uuid
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION app_current_user_id() RETURNS BIGINT AS $$
+    SELECT id FROM users WHERE public_id = NULLIF(current_setting('app.current_user', true), '')::uuid
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE organization_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_members FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY organization_members_tenant_isolation ON organization_members
+    USING (organization_id = app_current_organization_id())
+    WITH CHECK (organization_id = app_current_organization_id());
+
+-- A user lists the organizations they belong to, whichever tenant is set. Read only.
+CREATE POLICY organization_members_own_read ON organization_members
+    FOR SELECT
+    USING (user_id = app_current_user_id());
+
+ALTER TABLE organization_invitations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_invitations FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY organization_invitations_tenant_isolation ON organization_invitations
+    USING (organization_id = app_current_organization_id())
+    WITH CHECK (organization_id = app_current_organization_id());
+
+-- The invitee opens their link before they belong to the organization. They only see
+-- the invitations sent to their own address. Read only.
+CREATE POLICY organization_invitations_invitee_read ON organization_invitations
+    FOR SELECT
+    USING (lower(email) = (SELECT lower(email) FROM users WHERE id = app_current_user_id()));
@@ -0,0 +1,22 @@
+-- Accounts created by invitation. An admin picks the email and role, the invitee sets
+-- their name and password through a single-use link. Only the hash of the link token is
+-- stored.
+CREATE TABLE user_invitations (
+    id          BIGSERIAL PRIMARY KEY,
+    public_id   UUID         NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    email       VARCHAR(255) NOT NULL,
+    role        VARCHAR(20)  NOT NULL CHECK (role IN ('user', 'admin')),
+    token_hash  CHAR(64)     NOT NULL UNIQUE,
+    invited_by  BIGINT       REFERENCES users (id) ON DELETE SET NULL,
+    user_id     BIGINT       REFERENCES users (id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ  NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ
+);
+
+CREATE INDEX user_invitations_created_idx ON user_invitations (created_at DESC);
+
+-- At most one open invitation per address, inviting again replaces it.
+CREATE UNIQUE INDEX user_invitations_pending_idx ON user_invitations (lower(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
@@ -0,0 +1,37 @@
+-- Security and audit events: who did what, to what, from where. Actors and targets are
+-- public ids without foreign keys, events outlive the users and products they name.
+CREATE TABLE audit_events (
+    id              BIGSERIAL PRIMARY KEY,
+    public_id       UUID         NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    action          VARCHAR(64)  NOT NULL,
+    actor_id        UUID,
+    impersonator_id UUID,
+    target_type     VARCHAR(32)  NOT NULL DEFAULT '',
+    target_id       VARCHAR(64)  NOT NULL DEFAULT '',
+    ip              VARCHAR(45)  NOT NULL DEFAULT '',
+    user_agent      VARCHAR(512) NOT NULL DEFAULT '',
+    request_id      VARCHAR(64)  NOT NULL DEFAULT '',
+    changes         JSONB,
+    metadata        JSONB,
+    created_at      TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX audit_events_created_idx ON audit_events (created_at DESC, id DESC);
+CREATE INDEX audit_events_action_idx ON audit_events (action, created_at DESC);
+CREATE INDEX audit_events_actor_idx ON audit_events (actor_id, created_at DESC);
+CREATE INDEX audit_events_target_idx ON audit_events (target_type, target_id, created_at DESC);
+
+-- Append-only: rows can be inserted and read, never changed or removed.
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
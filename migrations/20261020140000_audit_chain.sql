@@ -0,0 +1,32 @@
+-- Hash chain over the audit log: every event stores the hash of the one before it and
+-- its own, computed over its contents. Events written before the chain existed keep
+-- NULL hashes, the chain starts at the first hashed event.
+ALTER TABLE audit_events
+    ADD COLUMN prev_hash CHAR(64),
+    ADD COLUMN hash      CHAR(64);
+
+-- Signed checkpoints: the hash of the chain at event_id, signed with the audit signing
+-- key, so rewriting the whole chain from some event on can be told apart from the original.
+CREATE TABLE audit_checkpoints (
+    id         BIGSERIAL PRIMARY KEY,
+    event_id   BIGINT      NOT NULL,
+    hash       CHAR(64)    NOT NULL,
+    signature  TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX audit_checkpoints_event_idx ON audit_checkpoints (event_id);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_checkpoints_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_checkpoints
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_checkpoints_no_truncate
+    BEFORE TRUNCATE ON audit_checkpoints
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
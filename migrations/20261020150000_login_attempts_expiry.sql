@@ -0,0 +1,7 @@
+-- When the failures of a key are forgotten, so expired keys can be purged instead of
+-- piling up. The window of rows written before is unknown, they are kept for a day.
+ALTER TABLE login_attempts ADD COLUMN expires_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE login_attempts SET expires_at = last_failure_at + interval '1 day';
+
+CREATE INDEX login_attempts_expires_at_idx ON login_attempts (expires_at);
@@ -0,0 +1,87 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("image must be a JPEG, PNG or GIF")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// Decode reads a JPEG, PNG or GIF. The header is checked first so an image wider or
+// taller than maxDimension is rejected before its pixels are allocated.
+func Decode(data []byte, maxDimension int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	return img, nil
+}
+
+// SquareThumbnail crops the largest centered square out of src and scales it to
+// size x size. Downscaling averages every source pixel under a target pixel, which
+// keeps photos from aliasing; upscaling repeats pixels.
+func SquareThumbnail(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	origin := image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	)
+
+	// premultiplied alpha, so transparent pixels don't bleed their color into the average
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), src, origin, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := span(y, side, size)
+		for x := 0; x < size; x++ {
+			x0, x1 := span(x, side, size)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := square.Pix[sy*square.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					sum[0] += int(pixel[0])
+					sum[1] += int(pixel[1])
+					sum[2] += int(pixel[2])
+					sum[3] += int(pixel[3])
+				}
+			}
+
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for i := range sum {
+				dst.Pix[offset+i] = uint8((sum[i] + count/2) / count)
+			}
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) covered by target pixel i, never empty.
+func span(i, side, size int) (int, int) {
+	from := i * side / size
+	to := (i + 1) * side / size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
@@ -0,0 +1,79 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+
+	img, err := Decode(data, 40)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+
+	_, err = Decode(data, 39)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Decode([]byte("not an image"), 40)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestSquareThumbnailCropsCenter(t *testing.T) {
+	// red, green and blue thirds side by side, only the green one survives the crop
+	src := image.NewRGBA(image.Rect(0, 0, 30, 10))
+	for x := 0; x < 30; x++ {
+		c := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}[x/10]
+		for y := 0; y < 10; y++ {
+			src.Set(x, y, c)
+		}
+	}
+
+	thumb := SquareThumbnail(src, 4)
+
+	assert.Equal(t, image.Rect(0, 0, 4, 4), thumb.Bounds())
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			assert.Equal(t, color.RGBA{0, 255, 0, 255}, thumb.RGBAAt(x, y))
+		}
+	}
+}
+
+func TestSquareThumbnailAveragesWhenShrinking(t *testing.T) {
+	// black and white columns blend into gray
+	src := image.NewGray(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			if x%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	thumb := SquareThumbnail(src, 2)
+
+	assert.Equal(t, color.RGBA{128, 128, 128, 255}, thumb.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{128, 128, 128, 255}, thumb.RGBAAt(1, 1))
+}
+
+func TestSquareThumbnailEnlarges(t *testing.T) {
+	src := image.NewRGBA(image.Rect(5, 5, 7, 7))
+	src.Set(5, 5, color.RGBA{255, 0, 0, 255})
+
+	thumb := SquareThumbnail(src, 6)
+
+	assert.Equal(t, image.Rect(0, 0, 6, 6), thumb.Bounds())
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, thumb.RGBAAt(2, 2))
+	assert.Equal(t, color.RGBA{0, 0, 0, 0}, thumb.RGBAAt(3, 3))
+}
@@ -2,6 +2,7 @@ package pkg_jwt
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/celio001/prodify/config"
@@ -15,11 +16,12 @@ var (
 	ErrTokenTypeNotFound = errors.New("token type not found")
 )
 
-// token 15min
-func CreateAccessToken(userID string) (string, error) {
+// token 15min, sessionID ties it to the login it came from
+func CreateAccessToken(userID string, sessionID string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
 		jwt.MapClaims{
 			"user_id": userID,
+			"sid":     sessionID,
 			"exp":     time.Now().Add(15 * time.Minute).Unix(),
 			"iat":     time.Now().Unix(),
 			"type":    "access",
@@ -28,12 +30,71 @@ func CreateAccessToken(userID string) (string, error) {
 	return token.SignedString([]byte(config.GetString("JWT_SECRET")))
 }
 
-// CreateRefreshToken - Big token (7 days)
-func CreateRefreshToken(userID string) (string, error) {
+// ImpersonationTTL - lifetime of the access token an admin gets to act as another user, it is never refreshed
+const ImpersonationTTL = 15 * time.Minute
+
+// Actor is the admin behind an impersonation token.
+type Actor struct {
+	UserID    string
+	SessionID string
+}
+
+// CreateImpersonationToken - access token for userID whose act claim (RFC 8693) names the
+// admin using it and the session they started it from
+func CreateImpersonationToken(userID string, actor Actor) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+		jwt.MapClaims{
+			"user_id": userID,
+			"act": map[string]string{
+				"sub": actor.UserID,
+				"sid": actor.SessionID,
+			},
+			"exp":  time.Now().Add(ImpersonationTTL).Unix(),
+			"iat":  time.Now().Unix(),
+			"type": "access",
+		})
+
+	return token.SignedString([]byte(config.GetString("JWT_SECRET")))
+}
+
+// GetActorFromToken reports false for tokens the user got by logging in themselves
+func GetActorFromToken(token *jwt.Token) (Actor, bool, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Actor{}, false, ErrInvalidClaims
+	}
+
+	act, ok := claims["act"]
+	if !ok {
+		return Actor{}, false, nil
+	}
+
+	actClaims, ok := act.(map[string]interface{})
+	if !ok {
+		return Actor{}, false, ErrInvalidClaims
+	}
+
+	var actor Actor
+	actor.UserID, ok = actClaims["sub"].(string)
+	if !ok || actor.UserID == "" {
+		return Actor{}, false, ErrInvalidClaims
+	}
+	actor.SessionID, _ = actClaims["sid"].(string)
+
+	return actor, true, nil
+}
+
+// RefreshTokenTTL - how long a refresh token (and the session behind it) lives without being used
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// CreateRefreshToken - Big token (7 days). tokenID is rotated on every refresh
+func CreateRefreshToken(userID string, sessionID string, tokenID string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
 		jwt.MapClaims{
 			"user_id": userID,
-			"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
+			"sid":     sessionID,
+			"jti":     tokenID,
+			"exp":     time.Now().Add(RefreshTokenTTL).Unix(),
 			"iat":     time.Now().Unix(),
 			"type":    "refresh",
 		})
@@ -41,6 +102,132 @@ func CreateRefreshToken(userID string) (string, error) {
 	return token.SignedString([]byte(config.GetString("JWT_SECRET")))
 }
 
+// CreateMFAPendingToken - issued after the password check when the user has MFA
+// enabled, only good for exchanging a TOTP code for real tokens (5 min)
+func CreateMFAPendingToken(userID string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+		jwt.MapClaims{
+			"user_id": userID,
+			"exp":     time.Now().Add(5 * time.Minute).Unix(),
+			"iat":     time.Now().Unix(),
+			"type":    "mfa_pending",
+		})
+
+	return token.SignedString([]byte(config.GetString("JWT_SECRET")))
+}
+
+// OAuthState is what a social login has to remember between the redirect to the
+// provider and its callback.
+type OAuthState struct {
+	Provider     string
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// CreateOAuthStateToken - kept in a cookie while the user is at the provider (10 min)
+func CreateOAuthStateToken(state OAuthState) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+		jwt.MapClaims{
+			"provider":      state.Provider,
+			"state":         state.State,
+			"nonce":         state.Nonce,
+			"code_verifier": state.CodeVerifier,
+			"exp":           time.Now().Add(10 * time.Minute).Unix(),
+			"iat":           time.Now().Unix(),
+			"type":          "oauth_state",
+		})
+
+	return token.SignedString([]byte(config.GetString("JWT_SECRET")))
+}
+
+func GetOAuthStateFromToken(token *jwt.Token) (OAuthState, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return OAuthState{}, ErrInvalidClaims
+	}
+
+	var state OAuthState
+	var okProvider, okState, okNonce, okVerifier bool
+	state.Provider, okProvider = claims["provider"].(string)
+	state.State, okState = claims["state"].(string)
+	state.Nonce, okNonce = claims["nonce"].(string)
+	state.CodeVerifier, okVerifier = claims["code_verifier"].(string)
+	if !okProvider || !okState || !okNonce || !okVerifier {
+		return OAuthState{}, ErrInvalidClaims
+	}
+
+	return state, nil
+}
+
+// OAuthClaims identify a token issued to a third-party client through an oauth grant.
+type OAuthClaims struct {
+	UserID   string
+	ClientID string
+	GrantID  string
+	Scopes   []string
+	// TokenID is only set on refresh tokens, it is rotated on every refresh
+	TokenID string
+}
+
+// OAuthAccessTokenTTL - lifetime of access tokens issued to oauth clients
+const OAuthAccessTokenTTL = time.Hour
+
+// CreateOAuthAccessToken - scoped token (1h) a client calls the API with on behalf of the user
+func CreateOAuthAccessToken(claims OAuthClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+		jwt.MapClaims{
+			"user_id":   claims.UserID,
+			"client_id": claims.ClientID,
+			"gid":       claims.GrantID,
+			"scope":     strings.Join(claims.Scopes, " "),
+			"exp":       time.Now().Add(OAuthAccessTokenTTL).Unix(),
+			"iat":       time.Now().Unix(),
+			"type":      "oauth_access",
+		})
+
+	return token.SignedString([]byte(config.GetString("JWT_SECRET")))
+}
+
+// CreateOAuthRefreshToken - same lifetime as first-party refresh tokens, rotated on every use
+func CreateOAuthRefreshToken(claims OAuthClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
+		jwt.MapClaims{
+			"user_id":   claims.UserID,
+			"client_id": claims.ClientID,
+			"gid":       claims.GrantID,
+			"scope":     strings.Join(claims.Scopes, " "),
+			"jti":       claims.TokenID,
+			"exp":       time.Now().Add(RefreshTokenTTL).Unix(),
+			"iat":       time.Now().Unix(),
+			"type":      "oauth_refresh",
+		})
+
+	return token.SignedString([]byte(config.GetString("JWT_SECRET")))
+}
+
+func GetOAuthClaimsFromToken(token *jwt.Token) (OAuthClaims, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return OAuthClaims{}, ErrInvalidClaims
+	}
+
+	var oauthClaims OAuthClaims
+	var okUser, okClient, okGrant, okScope bool
+	var scope string
+	oauthClaims.UserID, okUser = claims["user_id"].(string)
+	oauthClaims.ClientID, okClient = claims["client_id"].(string)
+	oauthClaims.GrantID, okGrant = claims["gid"].(string)
+	scope, okScope = claims["scope"].(string)
+	if !okUser || !okClient || !okGrant || !okScope {
+		return OAuthClaims{}, ErrInvalidClaims
+	}
+
+	oauthClaims.Scopes = strings.Fields(scope)
+	oauthClaims.TokenID, _ = claims["jti"].(string)
+	return oauthClaims, nil
+}
+
 func ParseToken(tokenString string) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return []byte(config.GetString("JWT_SECRET")), nil
@@ -71,6 +258,31 @@ func GetUserIDFromToken(token *jwt.Token) (string, error) {
 	return userID, nil
 }
 
+// GetSessionIDFromToken returns an empty string for tokens issued before sessions were tracked
+func GetSessionIDFromToken(token *jwt.Token) (string, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidClaims
+	}
+
+	sessionID, _ := claims["sid"].(string)
+	return sessionID, nil
+}
+
+func GetTokenIDFromToken(token *jwt.Token) (string, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidClaims
+	}
+
+	tokenID, ok := claims["jti"].(string)
+	if !ok {
+		return "", ErrInvalidClaims
+	}
+
+	return tokenID, nil
+}
+
 func IsAccessToken(token *jwt.Token) (string, error) {
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
@@ -82,4 +294,12 @@ func IsAccessToken(token *jwt.Token) (string, error) {
 		return "", ErrTokenTypeNotFound
 	}
 	return tokenType, nil
-}
\ No newline at end of file
+}
+func GetIssuedAtFromToken(token *jwt.Token) (time.Time, error) {
+	issuedAt, err := token.Claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return time.Time{}, ErrInvalidClaims
+	}
+
+	return issuedAt.Time, nil
+}
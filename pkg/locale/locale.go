@@ -0,0 +1,55 @@
+package locale
+
+import (
+	"slices"
+
+	"github.com/celio001/prodify/config"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Supported lists the locales translations exist for, as BCP 47 tags.
+var Supported = []string{"en", "pt-BR"}
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.BrazilianPortuguese})
+
+// Resolve returns locale when it is supported and DEFAULT_LOCALE otherwise.
+func Resolve(locale string) string {
+	if slices.Contains(Supported, locale) {
+		return locale
+	}
+	return config.GetString("DEFAULT_LOCALE")
+}
+
+// Match picks the supported locale closest to an Accept-Language header, DEFAULT_LOCALE
+// when nothing in it is close enough.
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Resolve("")
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Resolve("")
+	}
+	return Supported[index]
+}
+
+// Printer formats and translates for locale. Messages registered with
+// message.SetString are translated, anything else is printed as given.
+func Printer(locale string) *message.Printer {
+	return message.NewPrinter(language.MustParse(Resolve(locale)))
+}
+
+// FormatMoney writes amount with the symbol of the ISO 4217 currency code and the
+// separators of locale, e.g. "$ 1,234.50" in en and "R$ 1.234,50" in pt-BR. An unknown
+// code falls back to DEFAULT_CURRENCY.
+func FormatMoney(locale string, code string, amount float64) string {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		unit = currency.MustParseISO(config.GetString("DEFAULT_CURRENCY"))
+	}
+	return Printer(locale).Sprint(currency.Symbol(unit.Amount(amount)))
+}
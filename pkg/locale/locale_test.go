@@ -0,0 +1,39 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func TestResolve(t *testing.T) {
+	assert.Equal(t, "pt-BR", Resolve("pt-BR"))
+	assert.Equal(t, "en", Resolve("fr"))
+	assert.Equal(t, "en", Resolve(""))
+}
+
+func TestMatch(t *testing.T) {
+	assert.Equal(t, "pt-BR", Match("pt-BR,pt;q=0.9,en;q=0.8"))
+	assert.Equal(t, "pt-BR", Match("pt"))
+	assert.Equal(t, "en", Match("en-GB"))
+	assert.Equal(t, "en", Match("ja"))
+	assert.Equal(t, "en", Match(""))
+	assert.Equal(t, "en", Match("not a header;;"))
+}
+
+func TestFormatMoney(t *testing.T) {
+	assert.Equal(t, "R$ 1.234,50", FormatMoney("pt-BR", "BRL", 1234.5))
+	assert.Equal(t, "$ 1,234.50", FormatMoney("en", "USD", 1234.5))
+	assert.Equal(t, "US$ 10,00", FormatMoney("pt-BR", "USD", 10))
+	assert.Equal(t, "$ 3.00", FormatMoney("en", "XYZ", 3))
+}
+
+func TestPrinterTranslates(t *testing.T) {
+	message.SetString(language.BrazilianPortuguese, "locale test %d", "teste de localidade %d")
+
+	assert.Equal(t, "teste de localidade 1.000", Printer("pt-BR").Sprintf("locale test %d", 1000))
+	assert.Equal(t, "locale test 1,000", Printer("en").Sprintf("locale test %d", 1000))
+	assert.Equal(t, "locale test 1,000", Printer("fr").Sprintf("locale test %d", 1000))
+}
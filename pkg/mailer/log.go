@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/celio001/prodify/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// logMailer is meant for development: messages are logged and, when dir is set,
+// written as .eml files so links can be opened without a real SMTP server.
+type logMailer struct {
+	dir  string
+	from string
+}
+
+func NewLogMailer(dir string, from string) Mailer {
+	return &logMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *logMailer) Send(msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+
+	logger.Log.Info("mail sent",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body))
+
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To))
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, msg), 0o644)
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/celio001/prodify/config"
+)
+
+var (
+	ErrUnknownDriver = errors.New("unknown mailer driver")
+	ErrNoRecipient   = errors.New("message has no recipient")
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New builds the mailer selected by MAILER_DRIVER (smtp or log).
+func New() (Mailer, error) {
+	switch driver := config.GetString("MAILER_DRIVER"); driver {
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Host:     config.GetString("SMTP_HOST"),
+			Port:     config.GetString("SMTP_PORT"),
+			Username: config.GetString("SMTP_USERNAME"),
+			Password: config.GetString("SMTP_PASSWORD"),
+			From:     config.GetString("MAILER_FROM"),
+		}), nil
+	case "log":
+		return NewLogMailer(config.GetString("MAILER_LOG_DIR"), config.GetString("MAILER_FROM")), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, driver)
+	}
+}
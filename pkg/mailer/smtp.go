@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPMailer(cfg SMTPConfig) Mailer {
+	return &smtpMailer{
+		cfg: cfg,
+	}
+}
+
+func (m *smtpMailer) Send(msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+	return smtp.SendMail(addr, auth, m.cfg.From, []string{msg.To}, buildMessage(m.cfg.From, msg))
+}
+
+func buildMessage(from string, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return b.Bytes()
+}
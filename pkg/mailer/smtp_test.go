@@ -0,0 +1,117 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSMTPServer struct {
+	listener net.Listener
+	from     string
+	rcpt     []string
+	data     string
+	done     chan struct{}
+}
+
+// newFakeSMTPServer accepts a single connection and records the envelope and body.
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{listener: l, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	write("220 fake smtp ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			write("250 localhost")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			write("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			s.rcpt = append(s.rcpt, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			write("250 OK")
+		case upper == "DATA":
+			write("354 end data with <CR><LF>.<CR><LF>")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.data = b.String()
+			write("250 OK")
+		case upper == "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	require.NoError(t, err)
+
+	m := NewSMTPMailer(SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "no-reply@prodify.local",
+	})
+
+	err = m.Send(Message{
+		To:      "celio@email.com",
+		Subject: "Verify your email",
+		Body:    "click here",
+	})
+	assert.NoError(t, err)
+
+	<-server.done
+	assert.Equal(t, "no-reply@prodify.local", server.from)
+	assert.Equal(t, []string{"celio@email.com"}, server.rcpt)
+	assert.Contains(t, server.data, "Subject: Verify your email")
+	assert.Contains(t, server.data, "click here")
+}
+
+func TestSMTPMailer_NoRecipient(t *testing.T) {
+	m := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: "1"})
+
+	err := m.Send(Message{Subject: "hello"})
+
+	assert.ErrorIs(t, err, ErrNoRecipient)
+}
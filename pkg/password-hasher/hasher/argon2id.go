@@ -0,0 +1,125 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2Params are the argon2id cost parameters, Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+type argon2idHasher struct {
+	params Argon2Params
+}
+
+// NewArgon2idHasher hashes with the given parameters, zero values fall back to the
+// OWASP recommended minimum of 19 MiB, 2 iterations and 1 thread.
+func NewArgon2idHasher(params Argon2Params) Hasher {
+	if params.Memory == 0 {
+		params.Memory = 19 * 1024
+	}
+	if params.Iterations == 0 {
+		params.Iterations = 2
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = 1
+	}
+	return &argon2idHasher{params: params}
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *argon2idHasher) Compare(encoded string, password string) error {
+	return compare(encoded, password)
+}
+
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	if algorithmOf(encoded) != AlgorithmArgon2id {
+		return true
+	}
+
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory < h.params.Memory ||
+		params.Iterations < h.params.Iterations ||
+		params.Parallelism < h.params.Parallelism ||
+		len(salt) < argon2SaltLength ||
+		len(key) < argon2KeyLength
+}
+
+func compareArgon2id(encoded string, password string) error {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+// decodeArgon2id parses "$argon2id$v=19$m=<kib>,t=<iterations>,p=<threads>$<salt>$<key>".
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	return params, salt, key, nil
+}
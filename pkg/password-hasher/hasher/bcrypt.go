@@ -0,0 +1,51 @@
+package hasher
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher hashes with the given cost, values outside bcrypt's range fall back to bcrypt.DefaultCost.
+func NewBcryptHasher(cost int) Hasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Compare(encoded string, password string) error {
+	return compare(encoded, password)
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	if algorithmOf(encoded) != AlgorithmBcrypt {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+	return cost < h.cost
+}
+
+func compareBcrypt(encoded string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatchedPassword
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/celio001/prodify/config"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrUnknownAlgorithm   = errors.New("unknown password hash algorithm")
+	ErrInvalidHash        = errors.New("invalid password hash")
+	ErrMismatchedPassword = errors.New("password does not match hash")
+)
+
+// Hasher hashes passwords into PHC strings ("$argon2id$v=19$m=...,t=...,p=...$salt$hash",
+// bcrypt keeps its own "$2a$<cost>$..." form). Compare accepts hashes of every supported
+// algorithm, so stored hashes keep working after the configured algorithm changes.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Compare returns nil when password matches encoded and ErrMismatchedPassword when it doesn't.
+	Compare(encoded string, password string) error
+	// NeedsRehash reports whether encoded was made with another algorithm or with
+	// parameters weaker than the ones this hasher uses.
+	NeedsRehash(encoded string) bool
+}
+
+// New builds the hasher selected by PASSWORD_HASH_ALGORITHM (bcrypt or argon2id).
+func New() (Hasher, error) {
+	switch algorithm := config.GetString("PASSWORD_HASH_ALGORITHM"); algorithm {
+	case AlgorithmBcrypt:
+		return NewBcryptHasher(config.GetInt("PASSWORD_BCRYPT_COST")), nil
+	case AlgorithmArgon2id:
+		return NewArgon2idHasher(Argon2Params{
+			Memory:      uint32(config.GetInt("PASSWORD_ARGON2_MEMORY_KIB")),
+			Iterations:  uint32(config.GetInt("PASSWORD_ARGON2_ITERATIONS")),
+			Parallelism: uint8(config.GetInt("PASSWORD_ARGON2_PARALLELISM")),
+		}), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, algorithm)
+	}
+}
+
+// compare checks password against a hash of any supported algorithm.
+func compare(encoded string, password string) error {
+	switch algorithmOf(encoded) {
+	case AlgorithmBcrypt:
+		return compareBcrypt(encoded, password)
+	case AlgorithmArgon2id:
+		return compareArgon2id(encoded, password)
+	default:
+		return ErrInvalidHash
+	}
+}
+
+func algorithmOf(encoded string) string {
+	switch {
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return AlgorithmBcrypt
+	case strings.HasPrefix(encoded, "$"+AlgorithmArgon2id+"$"):
+		return AlgorithmArgon2id
+	default:
+		return ""
+	}
+}
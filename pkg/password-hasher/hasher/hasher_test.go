@@ -0,0 +1,106 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestHashAndCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher Hasher
+		prefix string
+	}{
+		{
+			name:   "bcrypt",
+			hasher: NewBcryptHasher(bcrypt.MinCost),
+			prefix: "$2a$04$",
+		},
+		{
+			name:   "argon2id",
+			hasher: NewArgon2idHasher(testArgon2Params),
+			prefix: "$argon2id$v=19$m=1024,t=1,p=1$",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.hasher.Hash("Correct-Horse-1")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encoded, tt.prefix), encoded)
+
+			assert.NoError(t, tt.hasher.Compare(encoded, "Correct-Horse-1"))
+			assert.ErrorIs(t, tt.hasher.Compare(encoded, "correct-horse-1"), ErrMismatchedPassword)
+			assert.False(t, tt.hasher.NeedsRehash(encoded))
+
+			// salted, two hashes of the same password differ
+			again, err := tt.hasher.Hash("Correct-Horse-1")
+			require.NoError(t, err)
+			assert.NotEqual(t, encoded, again)
+		})
+	}
+}
+
+func TestCompare_AcrossAlgorithms(t *testing.T) {
+	bcryptHash, err := NewBcryptHasher(bcrypt.MinCost).Hash("Correct-Horse-1")
+	require.NoError(t, err)
+
+	argonHash, err := NewArgon2idHasher(testArgon2Params).Hash("Correct-Horse-1")
+	require.NoError(t, err)
+
+	assert.NoError(t, NewArgon2idHasher(testArgon2Params).Compare(bcryptHash, "Correct-Horse-1"))
+	assert.NoError(t, NewBcryptHasher(bcrypt.MinCost).Compare(argonHash, "Correct-Horse-1"))
+}
+
+func TestCompare_InvalidHash(t *testing.T) {
+	h := NewArgon2idHasher(testArgon2Params)
+
+	tests := []string{
+		"",
+		"plain-text",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5",
+		"$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+	}
+
+	for _, encoded := range tests {
+		assert.ErrorIs(t, h.Compare(encoded, "Correct-Horse-1"), ErrInvalidHash, encoded)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	weakBcrypt, _ := NewBcryptHasher(bcrypt.MinCost).Hash("Correct-Horse-1")
+	strongBcrypt, _ := NewBcryptHasher(bcrypt.MinCost + 1).Hash("Correct-Horse-1")
+	weakArgon, _ := NewArgon2idHasher(testArgon2Params).Hash("Correct-Horse-1")
+	strongArgon, _ := NewArgon2idHasher(Argon2Params{Memory: 2048, Iterations: 2, Parallelism: 1}).Hash("Correct-Horse-1")
+
+	bcryptHasher := NewBcryptHasher(bcrypt.MinCost + 1)
+	argonHasher := NewArgon2idHasher(Argon2Params{Memory: 2048, Iterations: 2, Parallelism: 1})
+
+	tests := []struct {
+		name     string
+		hasher   Hasher
+		encoded  string
+		expected bool
+	}{
+		{name: "bcrypt lower cost", hasher: bcryptHasher, encoded: weakBcrypt, expected: true},
+		{name: "bcrypt same cost", hasher: bcryptHasher, encoded: strongBcrypt, expected: false},
+		{name: "bcrypt to argon2id", hasher: argonHasher, encoded: strongBcrypt, expected: true},
+		{name: "argon2id lower memory and iterations", hasher: argonHasher, encoded: weakArgon, expected: true},
+		{name: "argon2id same parameters", hasher: argonHasher, encoded: strongArgon, expected: false},
+		{name: "argon2id to bcrypt", hasher: bcryptHasher, encoded: strongArgon, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.hasher.NeedsRehash(tt.encoded))
+		})
+	}
+}
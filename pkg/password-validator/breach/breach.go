@@ -0,0 +1,77 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/celio001/prodify/config"
+	"github.com/celio001/prodify/pkg/password-validator/validator"
+)
+
+const (
+	prefixLength = 5
+	hashLength   = sha1.Size * 2
+)
+
+var (
+	ErrInvalidPrefix = errors.New("range prefix must be 5 hex characters")
+	ErrInvalidCorpus = errors.New("invalid breached password corpus")
+)
+
+// RangeSource answers k-anonymity range queries in the Have I Been Pwned format: given
+// the first 5 hex characters of a SHA-1, it returns the remaining 35 characters of every
+// breached hash sharing that prefix with the number of times each was seen. The password,
+// and even its full hash, never has to leave the caller.
+type RangeSource interface {
+	Range(prefix string) (map[string]int, error)
+}
+
+// Checker looks passwords up in a RangeSource.
+type Checker struct {
+	source RangeSource
+}
+
+func NewChecker(source RangeSource) *Checker {
+	return &Checker{source: source}
+}
+
+// New builds the checker from the corpus at PASSWORD_BREACH_CORPUS. It returns nil,
+// which disables the check, when no corpus is configured.
+func New() (validator.BreachChecker, error) {
+	path := config.GetString("PASSWORD_BREACH_CORPUS")
+	if path == "" {
+		return nil, nil
+	}
+
+	source, err := OpenFileSource(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewChecker(source), nil
+}
+
+// Count returns how many times password appears in the corpus, 0 when it was never breached.
+func (c *Checker) Count(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := c.source.Range(hash[:prefixLength])
+	if err != nil {
+		return 0, err
+	}
+	return suffixes[hash[prefixLength:]], nil
+}
+
+func validPrefix(prefix string) bool {
+	if len(prefix) != prefixLength {
+		return false
+	}
+	for _, c := range prefix {
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
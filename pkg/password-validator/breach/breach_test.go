@@ -0,0 +1,150 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sha1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+// sha1("123456")   = 7C4A8D09CA3762AF61E59520943DC26494F8941B
+var testCorpus = []string{
+	"000000005AD76BD555C1D6D771DE417A4B87E4B4:10",
+	"00000000A8DAE4228F821FB418F59826079BF368:4",
+	"5BAA61E4C2D4ED12A0D0F4E0A8E0EB6E2A6E76B5:2",
+	"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365",
+	"5BAA61E4CA0F6F0A4F9E0E1A5D8E8E27E2F3E0C1:1",
+	"7C4A8D09CA3762AF61E59520943DC26494F8941B:37359195",
+	"FFFFFFFFF8A2BE34D0D0A6D1A0D3C0E0E0F0A0B1:3",
+}
+
+func writeCorpus(t *testing.T, lines []string, lineEnding string) string {
+	path := filepath.Join(t.TempDir(), "pwnedpasswords.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, lineEnding)+lineEnding), 0o600))
+	return path
+}
+
+func TestFileSource_Range(t *testing.T) {
+	for _, lineEnding := range []string{"\n", "\r\n"} {
+		source, err := OpenFileSource(writeCorpus(t, testCorpus, lineEnding))
+		require.NoError(t, err)
+		defer source.Close()
+
+		tests := []struct {
+			prefix   string
+			expected map[string]int
+		}{
+			{
+				prefix: "5BAA6",
+				expected: map[string]int{
+					"1E4C2D4ED12A0D0F4E0A8E0EB6E2A6E76B5": 2,
+					"1E4C9B93F3F0682250B6CF8331B7EE68FD8": 9659365,
+					"1E4CA0F6F0A4F9E0E1A5D8E8E27E2F3E0C1": 1,
+				},
+			},
+			{
+				prefix:   "00000",
+				expected: map[string]int{"0005AD76BD555C1D6D771DE417A4B87E4B4": 10, "000A8DAE4228F821FB418F59826079BF368": 4},
+			},
+			{
+				prefix:   "fffff",
+				expected: map[string]int{"FFFF8A2BE34D0D0A6D1A0D3C0E0E0F0A0B1": 3},
+			},
+			{
+				prefix:   "7C4A8",
+				expected: map[string]int{"D09CA3762AF61E59520943DC26494F8941B": 37359195},
+			},
+			{
+				prefix:   "12345",
+				expected: map[string]int{},
+			},
+		}
+
+		for _, tt := range tests {
+			suffixes, err := source.Range(tt.prefix)
+			assert.NoError(t, err, tt.prefix)
+			assert.Equal(t, tt.expected, suffixes, tt.prefix)
+		}
+	}
+}
+
+func TestFileSource_RangeMatchesLinearScan(t *testing.T) {
+	var lines []string
+	expected := make(map[string]map[string]int)
+	for i := 0; i < 5000; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("password-%d", i)))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		lines = append(lines, fmt.Sprintf("%s:%d", hash, i+1))
+
+		if expected[hash[:5]] == nil {
+			expected[hash[:5]] = make(map[string]int)
+		}
+		expected[hash[:5]][hash[5:]] = i + 1
+	}
+	sort.Strings(lines)
+
+	source, err := OpenFileSource(writeCorpus(t, lines, "\r\n"))
+	require.NoError(t, err)
+	defer source.Close()
+
+	for prefix, suffixes := range expected {
+		got, err := source.Range(prefix)
+		require.NoError(t, err)
+		assert.Equal(t, suffixes, got, prefix)
+	}
+}
+
+func TestFileSource_InvalidPrefix(t *testing.T) {
+	source, err := OpenFileSource(writeCorpus(t, testCorpus, "\n"))
+	require.NoError(t, err)
+	defer source.Close()
+
+	for _, prefix := range []string{"", "5BAA", "5BAA61", "ZZZZZ"} {
+		_, err := source.Range(prefix)
+		assert.ErrorIs(t, err, ErrInvalidPrefix, prefix)
+	}
+}
+
+func TestOpenFileSource_RejectsRangeFile(t *testing.T) {
+	// a single downloaded range file has no prefix on its lines
+	_, err := OpenFileSource(writeCorpus(t, []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365"}, "\r\n"))
+	assert.ErrorIs(t, err, ErrInvalidCorpus)
+
+	_, err = OpenFileSource(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+type fakeRangeSource map[string]map[string]int
+
+func (f fakeRangeSource) Range(prefix string) (map[string]int, error) {
+	return f[prefix], nil
+}
+
+func TestChecker_Count(t *testing.T) {
+	source, err := OpenFileSource(writeCorpus(t, testCorpus, "\n"))
+	require.NoError(t, err)
+	defer source.Close()
+
+	checkers := map[string]*Checker{
+		"file":   NewChecker(source),
+		"remote": NewChecker(fakeRangeSource{"5BAA6": {"1E4C9B93F3F0682250B6CF8331B7EE68FD8": 9659365}}),
+	}
+
+	for name, checker := range checkers {
+		count, err := checker.Count("password")
+		assert.NoError(t, err, name)
+		assert.Equal(t, 9659365, count, name)
+
+		count, err = checker.Count("Correct-Horse-Battery-Staple-1")
+		assert.NoError(t, err, name)
+		assert.Zero(t, count, name)
+	}
+}
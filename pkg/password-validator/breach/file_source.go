@@ -0,0 +1,185 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxLineLength is well above the 40 char hash, the colon, the count and a CRLF.
+const maxLineLength = 128
+
+// FileSource serves ranges from a local corpus in the single-file layout written by the
+// Pwned Passwords downloader: one "<40 hex SHA-1>:<count>" line per hash, ordered by hash.
+// That is every range file concatenated in prefix order with the prefix put back on each
+// line. Nothing is loaded in memory, each lookup binary searches the file for the first
+// line of the range and reads it from there.
+type FileSource struct {
+	file *os.File
+	size int64
+}
+
+func OpenFileSource(path string) (*FileSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s := &FileSource{file: file, size: info.Size()}
+
+	// fail on startup rather than on the first registration when the path points at
+	// something else, such as a single range file without the prefixes
+	if s.size > 0 {
+		line, err := s.lineAt(0)
+		if err == nil {
+			_, _, err = parseLine(line)
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *FileSource) Close() error {
+	return s.file.Close()
+}
+
+func (s *FileSource) Range(prefix string) (map[string]int, error) {
+	prefix = strings.ToUpper(prefix)
+	if !validPrefix(prefix) {
+		return nil, ErrInvalidPrefix
+	}
+
+	start, err := s.searchRange(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes := make(map[string]int)
+
+	scanner := bufio.NewScanner(io.NewSectionReader(s.file, start, s.size-start))
+	for scanner.Scan() {
+		hash, count, err := parseLine(scanner.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes[hash[prefixLength:]] = count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return suffixes, nil
+}
+
+// searchRange returns the offset of the first line whose hash is not below prefix. It
+// searches byte offsets: for any offset the next line start is the candidate, and
+// "candidate hash >= prefix" only flips once along the file because it is ordered.
+func (s *FileSource) searchRange(prefix string) (int64, error) {
+	lo, hi := int64(0), s.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		atOrAfter, err := s.lineAtOrAfterIsNotBelow(mid, prefix)
+		if err != nil {
+			return 0, err
+		}
+		if atOrAfter {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return s.nextLineStart(lo)
+}
+
+func (s *FileSource) lineAtOrAfterIsNotBelow(offset int64, prefix string) (bool, error) {
+	start, err := s.nextLineStart(offset)
+	if err != nil {
+		return false, err
+	}
+	if start >= s.size {
+		return true, nil
+	}
+
+	line, err := s.lineAt(start)
+	if err != nil {
+		return false, err
+	}
+	if len(line) < prefixLength {
+		return false, fmt.Errorf("%w: short line at offset %d", ErrInvalidCorpus, start)
+	}
+	return strings.ToUpper(string(line[:prefixLength])) >= prefix, nil
+}
+
+// nextLineStart returns offset when a line starts there, otherwise the start of the next line.
+func (s *FileSource) nextLineStart(offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+
+	buf := make([]byte, maxLineLength)
+	n, err := s.file.ReadAt(buf, offset-1)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+		return offset + int64(i), nil
+	}
+	if offset-1+int64(n) >= s.size {
+		return s.size, nil
+	}
+	return 0, fmt.Errorf("%w: line too long near offset %d", ErrInvalidCorpus, offset)
+}
+
+// lineAt reads the line starting at offset, without its line ending.
+func (s *FileSource) lineAt(offset int64) ([]byte, error) {
+	buf := make([]byte, maxLineLength)
+	n, err := s.file.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	buf = buf[:n]
+	end := bytes.IndexByte(buf, '\n')
+	if end < 0 {
+		if offset+int64(n) < s.size {
+			return nil, fmt.Errorf("%w: line too long at offset %d", ErrInvalidCorpus, offset)
+		}
+		end = n
+	}
+	return bytes.TrimRight(buf[:end], "\r"), nil
+}
+
+func parseLine(line []byte) (string, int, error) {
+	line = bytes.TrimRight(line, "\r")
+
+	hash, countText, ok := bytes.Cut(line, []byte(":"))
+	if !ok || len(hash) != hashLength {
+		return "", 0, fmt.Errorf("%w: expected <sha1>:<count>, got %q", ErrInvalidCorpus, line)
+	}
+
+	count, err := strconv.Atoi(string(countText))
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: invalid count in %q", ErrInvalidCorpus, line)
+	}
+
+	return strings.ToUpper(string(hash)), count, nil
+}
@@ -9,11 +9,14 @@ import (
 var (
 	ErrPasswordValidator = errors.New("password validation failed")
 	ErrTooShort          = errors.New("password too short")
+	ErrTooLong           = errors.New("password too long")
 	ErrNoUppercase       = errors.New("missing uppercase letters")
 	ErrNoLowercase       = errors.New("missing lowercase letters")
 	ErrNoDigits          = errors.New("missing digits")
 	ErrNoSpecialChars    = errors.New("missing special characters")
 	ErrLowEntropy        = errors.New("password entropy too low")
+	ErrTooGuessable      = errors.New("password is too easy to guess")
+	ErrBreached          = errors.New("password appears in a known data breach")
 )
 
 type PasswordError struct {
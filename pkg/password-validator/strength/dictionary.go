@@ -0,0 +1,288 @@
+package strength
+
+import (
+	"bufio"
+	"embed"
+	"path"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed wordlists/*.txt
+var wordlists embed.FS
+
+const userInputsDictionary = "user_inputs"
+
+// rankedDictionary maps a lowercase word to its rank, 1 being the most common.
+type rankedDictionary map[string]int
+
+type namedDictionary struct {
+	name  string
+	words rankedDictionary
+}
+
+var (
+	dictionaries     []namedDictionary
+	dictionariesOnce sync.Once
+)
+
+// frequencyLists loads every embedded wordlist, the file name without its extension
+// becomes the dictionary name.
+func frequencyLists() []namedDictionary {
+	dictionariesOnce.Do(func() {
+		entries, _ := wordlists.ReadDir("wordlists")
+		for _, entry := range entries {
+			file, err := wordlists.Open(path.Join("wordlists", entry.Name()))
+			if err != nil {
+				continue
+			}
+
+			words := make(rankedDictionary)
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+				if word == "" || strings.HasPrefix(word, "#") {
+					continue
+				}
+				if _, ok := words[word]; !ok {
+					words[word] = len(words) + 1
+				}
+			}
+			file.Close()
+
+			dictionaries = append(dictionaries, namedDictionary{
+				name:  strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())),
+				words: words,
+			})
+		}
+	})
+	return dictionaries
+}
+
+func withUserInputs(userInputs rankedDictionary) []namedDictionary {
+	lists := frequencyLists()
+	if len(userInputs) == 0 {
+		return lists
+	}
+	return append([]namedDictionary{{name: userInputsDictionary, words: userInputs}}, lists...)
+}
+
+// newUserInputsDictionary ranks the inputs in the given order. Every input is also
+// split into its parts, so "Maria da Silva" and "maria.silva@mail.com" both give "maria"
+// and "silva".
+func newUserInputsDictionary(userInputs []string) rankedDictionary {
+	words := make(rankedDictionary)
+	add := func(word string) {
+		word = foldAccents(strings.ToLower(strings.TrimSpace(word)))
+		if len([]rune(word)) < 3 {
+			return
+		}
+		if _, ok := words[word]; !ok {
+			words[word] = len(words) + 1
+		}
+	}
+
+	for _, input := range userInputs {
+		add(input)
+		local, _, isEmail := strings.Cut(input, "@")
+		if isEmail {
+			add(local)
+		}
+		for _, part := range strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			add(part)
+		}
+	}
+	return words
+}
+
+func dictionaryMatch(password []rune, dictionaries []namedDictionary) []*match {
+	folded := []rune(foldAccents(strings.ToLower(string(password))))
+	// lower casing can change the rune count for a few characters, matching then
+	// falls back to the exact lower case form only
+	if len(folded) != len(password) {
+		return nil
+	}
+
+	var matches []*match
+	for i := range folded {
+		for j := i; j < len(folded); j++ {
+			word := string(folded[i : j+1])
+			for _, dictionary := range dictionaries {
+				rank, ok := dictionary.words[word]
+				if !ok {
+					continue
+				}
+				matches = append(matches, &match{
+					pattern:        patternDictionary,
+					i:              i,
+					j:              j,
+					token:          string(password[i : j+1]),
+					matchedWord:    word,
+					rank:           rank,
+					dictionaryName: dictionary.name,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+func reverseDictionaryMatch(password []rune, dictionaries []namedDictionary) []*match {
+	reversed := reverseRunes(password)
+
+	matches := dictionaryMatch(reversed, dictionaries)
+	for _, m := range matches {
+		m.token = reverseString(m.token)
+		m.reversed = true
+		m.i, m.j = len(password)-1-m.j, len(password)-1-m.i
+	}
+	return matches
+}
+
+// l33tTable lists the letters each substitution can stand for.
+var l33tTable = map[rune][]rune{
+	'4': {'a'},
+	'@': {'a'},
+	'8': {'b'},
+	'(': {'c'},
+	'{': {'c'},
+	'[': {'c'},
+	'<': {'c'},
+	'3': {'e'},
+	'6': {'g'},
+	'9': {'g'},
+	'1': {'i', 'l'},
+	'!': {'i'},
+	'|': {'i', 'l'},
+	'7': {'l', 't'},
+	'0': {'o'},
+	'$': {'s'},
+	'5': {'s'},
+	'+': {'t'},
+	'%': {'x'},
+	'2': {'z'},
+}
+
+// maxL33tSubs caps the substitution combinations tried for a single password.
+const maxL33tSubs = 64
+
+func l33tMatch(password []rune, dictionaries []namedDictionary) []*match {
+	var matches []*match
+	seen := make(map[[2]int]map[string]bool)
+
+	for _, sub := range l33tSubs(password) {
+		translated := make([]rune, len(password))
+		for k, r := range password {
+			if letter, ok := sub[r]; ok {
+				translated[k] = letter
+			} else {
+				translated[k] = r
+			}
+		}
+
+		for _, m := range dictionaryMatch(translated, dictionaries) {
+			token := password[m.i : m.j+1]
+
+			// only the substitutions used inside the token count
+			used := make(map[rune]rune)
+			for _, r := range token {
+				if letter, ok := sub[r]; ok {
+					used[r] = letter
+				}
+			}
+			// a single character like "1" or "@" is not a word
+			if len(used) == 0 || len(token) <= 1 {
+				continue
+			}
+
+			key := [2]int{m.i, m.j}
+			if seen[key] == nil {
+				seen[key] = make(map[string]bool)
+			}
+			if seen[key][m.matchedWord+"/"+m.dictionaryName] {
+				continue
+			}
+			seen[key][m.matchedWord+"/"+m.dictionaryName] = true
+
+			m.token = string(token)
+			m.l33t = true
+			m.sub = used
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// l33tSubs enumerates the ways the substitution characters found in password can be
+// read back as letters.
+func l33tSubs(password []rune) []map[rune]rune {
+	var chars []rune
+	present := make(map[rune]bool)
+	for _, r := range password {
+		if _, ok := l33tTable[r]; ok && !present[r] {
+			present[r] = true
+			chars = append(chars, r)
+		}
+	}
+	if len(chars) == 0 {
+		return nil
+	}
+
+	subs := []map[rune]rune{{}}
+	for _, c := range chars {
+		var next []map[rune]rune
+		for _, sub := range subs {
+			for _, letter := range l33tTable[c] {
+				extended := make(map[rune]rune, len(sub)+1)
+				for k, v := range sub {
+					extended[k] = v
+				}
+				extended[c] = letter
+				next = append(next, extended)
+				if len(next) >= maxL33tSubs {
+					break
+				}
+			}
+			if len(next) >= maxL33tSubs {
+				break
+			}
+		}
+		subs = next
+	}
+	return subs
+}
+
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+// foldAccents maps accented letters to their base letter one rune for one rune, so
+// "coração" is found as "coracao" and indexes are unchanged.
+func foldAccents(s string) string {
+	return strings.Map(func(r rune) rune {
+		if folded, ok := accentFolds[r]; ok {
+			return folded
+		}
+		return r
+	}, s)
+}
+
+func reverseRunes(runes []rune) []rune {
+	reversed := make([]rune, len(runes))
+	for k, r := range runes {
+		reversed[len(runes)-1-k] = r
+	}
+	return reversed
+}
+
+func reverseString(s string) string {
+	return string(reverseRunes([]rune(s)))
+}
@@ -0,0 +1,44 @@
+package strength
+
+import (
+	"fmt"
+	"math"
+)
+
+func displayTime(seconds float64) string {
+	const (
+		minute  = 60
+		hour    = minute * 60
+		day     = hour * 24
+		month   = day * 31
+		year    = month * 12
+		century = year * 100
+	)
+
+	switch {
+	case seconds < 1:
+		return "less than a second"
+	case seconds < minute:
+		return plural(seconds, 1, "second")
+	case seconds < hour:
+		return plural(seconds, minute, "minute")
+	case seconds < day:
+		return plural(seconds, hour, "hour")
+	case seconds < month:
+		return plural(seconds, day, "day")
+	case seconds < year:
+		return plural(seconds, month, "month")
+	case seconds < century:
+		return plural(seconds, year, "year")
+	default:
+		return "centuries"
+	}
+}
+
+func plural(seconds float64, unit float64, name string) string {
+	n := int(math.Round(seconds / unit))
+	if n == 1 {
+		return fmt.Sprintf("1 %s", name)
+	}
+	return fmt.Sprintf("%d %ss", n, name)
+}
@@ -0,0 +1,125 @@
+package strength
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Feedback tells the user why a password is weak and how to improve it. It is empty
+// for passwords that score well.
+type Feedback struct {
+	Warning     string   `json:"warning"`
+	Suggestions []string `json:"suggestions"`
+}
+
+const suggestionExtraWord = "Add another word or two. Uncommon words are better."
+
+func feedbackFor(score int, sequence []*match) Feedback {
+	if len(sequence) == 0 {
+		return Feedback{
+			Suggestions: []string{
+				"Use a few words, avoid common phrases.",
+				"No need for symbols, digits, or uppercase letters.",
+			},
+		}
+	}
+	if score > 2 {
+		return Feedback{Suggestions: []string{}}
+	}
+
+	// the longest match is the weakest link worth pointing at
+	longest := sequence[0]
+	for _, m := range sequence[1:] {
+		if len([]rune(m.token)) > len([]rune(longest.token)) {
+			longest = m
+		}
+	}
+
+	feedback := matchFeedback(longest, len(sequence) == 1)
+	feedback.Suggestions = append([]string{suggestionExtraWord}, feedback.Suggestions...)
+	return feedback
+}
+
+func matchFeedback(m *match, soleMatch bool) Feedback {
+	switch m.pattern {
+	case patternDictionary:
+		return dictionaryFeedback(m, soleMatch)
+	case patternSpatial:
+		warning := "Straight rows of keys are easy to guess."
+		if m.turns > 1 {
+			warning = "Short keyboard patterns are easy to guess."
+		}
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Use a longer keyboard pattern with more turns."},
+		}
+	case patternRepeat:
+		warning := "Repeats like \"aaa\" are easy to guess."
+		if len([]rune(m.baseToken)) > 1 {
+			warning = "Repeats like \"abcabcabc\" are only slightly harder to guess than \"abc\"."
+		}
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Avoid repeated words and characters."},
+		}
+	case patternSequence:
+		return Feedback{
+			Warning:     "Sequences like abc or 6543 are easy to guess.",
+			Suggestions: []string{"Avoid sequences."},
+		}
+	case patternRegex:
+		return Feedback{
+			Warning:     "Recent years are easy to guess.",
+			Suggestions: []string{"Avoid recent years.", "Avoid years that are associated with you."},
+		}
+	case patternDate:
+		return Feedback{
+			Warning:     "Dates are often easy to guess.",
+			Suggestions: []string{"Avoid dates and years that are associated with you."},
+		}
+	}
+	return Feedback{Suggestions: []string{}}
+}
+
+func dictionaryFeedback(m *match, soleMatch bool) Feedback {
+	var feedback Feedback
+
+	switch m.dictionaryName {
+	case userInputsDictionary:
+		feedback.Warning = "Avoid your name, email or other personal details."
+	case "passwords":
+		if !soleMatch || m.l33t || m.reversed {
+			feedback.Warning = "This is similar to a commonly used password."
+		} else if m.rank <= 10 {
+			feedback.Warning = "This is a top-10 common password."
+		} else if m.rank <= 100 {
+			feedback.Warning = "This is a top-100 common password."
+		} else {
+			feedback.Warning = "This is a very common password."
+		}
+	case "names":
+		if soleMatch {
+			feedback.Warning = "Names and surnames by themselves are easy to guess."
+		} else {
+			feedback.Warning = "Common names and surnames are easy to guess."
+		}
+	default:
+		if soleMatch {
+			feedback.Warning = "A word by itself is easy to guess."
+		}
+	}
+
+	runes := []rune(m.token)
+	if unicode.IsUpper(runes[0]) {
+		feedback.Suggestions = append(feedback.Suggestions, "Capitalization doesn't help very much.")
+	} else if strings.ToUpper(m.token) == m.token && strings.ToLower(m.token) != m.token {
+		feedback.Suggestions = append(feedback.Suggestions, "All-uppercase is almost as easy to guess as all-lowercase.")
+	}
+	if m.reversed && len(runes) >= 4 {
+		feedback.Suggestions = append(feedback.Suggestions, "Reversed words aren't much harder to guess.")
+	}
+	if m.l33t {
+		feedback.Suggestions = append(feedback.Suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much.")
+	}
+	return feedback
+}
@@ -0,0 +1,78 @@
+package strength
+
+import "sort"
+
+const (
+	patternDictionary = "dictionary"
+	patternSpatial    = "spatial"
+	patternRepeat     = "repeat"
+	patternSequence   = "sequence"
+	patternRegex      = "regex"
+	patternDate       = "date"
+	patternBruteforce = "bruteforce"
+)
+
+// match is a pattern found in password[i..j], both rune indexes inclusive. Only the
+// fields of its pattern are set.
+type match struct {
+	pattern string
+	i, j    int
+	token   string
+
+	// dictionary
+	matchedWord    string
+	rank           int
+	dictionaryName string
+	reversed       bool
+	l33t           bool
+	sub            map[rune]rune
+
+	// spatial
+	graph        string
+	turns        int
+	shiftedCount int
+
+	// repeat
+	baseToken   string
+	baseGuesses float64
+	repeatCount int
+
+	// sequence
+	sequenceSpace int
+	ascending     bool
+
+	// regex
+	regexName string
+
+	// date
+	year      int
+	separator string
+
+	guesses float64
+}
+
+func omnimatch(password []rune, userInputs rankedDictionary) []*match {
+	dictionaries := withUserInputs(userInputs)
+
+	var matches []*match
+	matches = append(matches, dictionaryMatch(password, dictionaries)...)
+	matches = append(matches, reverseDictionaryMatch(password, dictionaries)...)
+	matches = append(matches, l33tMatch(password, dictionaries)...)
+	matches = append(matches, spatialMatch(password)...)
+	matches = append(matches, repeatMatch(password, userInputs)...)
+	matches = append(matches, sequenceMatch(password)...)
+	matches = append(matches, regexMatch(password)...)
+	matches = append(matches, dateMatch(password)...)
+
+	sortMatches(matches)
+	return matches
+}
+
+func sortMatches(matches []*match) {
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].i != matches[b].i {
+			return matches[a].i < matches[b].i
+		}
+		return matches[a].j < matches[b].j
+	})
+}
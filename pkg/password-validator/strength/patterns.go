@@ -0,0 +1,313 @@
+package strength
+
+import (
+	"strconv"
+	"unicode"
+)
+
+// repeatMatch finds a token repeated back to back, like "aaaa" or "abcabcabc". At each
+// position the longest run wins, ties go to the shortest base token.
+func repeatMatch(password []rune, userInputs rankedDictionary) []*match {
+	var matches []*match
+
+	i := 0
+	for i < len(password) {
+		bestEnd, bestBase := -1, 0
+		for base := 1; i+2*base <= len(password); base++ {
+			count := 1
+			for i+(count+1)*base <= len(password) && equalRunes(password[i:i+base], password[i+count*base:i+(count+1)*base]) {
+				count++
+			}
+			if count < 2 {
+				continue
+			}
+			if end := i + count*base - 1; end > bestEnd {
+				bestEnd, bestBase = end, base
+			}
+		}
+
+		if bestEnd < 0 {
+			i++
+			continue
+		}
+
+		base := password[i : i+bestBase]
+		baseGuesses, _ := mostGuessableSequence(base, omnimatch(base, userInputs), true)
+		matches = append(matches, &match{
+			pattern:     patternRepeat,
+			i:           i,
+			j:           bestEnd,
+			token:       string(password[i : bestEnd+1]),
+			baseToken:   string(base),
+			baseGuesses: baseGuesses,
+			repeatCount: (bestEnd - i + 1) / bestBase,
+		})
+		i = bestEnd + 1
+	}
+	return matches
+}
+
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// maxSequenceDelta is the largest step between characters still seen as a sequence,
+// "aceg" and "9753" count, "adgj" as well.
+const maxSequenceDelta = 5
+
+// sequenceMatch finds runs with a constant step between characters of the same class,
+// like "abcd", "97531" or "XYZ".
+func sequenceMatch(password []rune) []*match {
+	var matches []*match
+	if len(password) < 3 {
+		return nil
+	}
+
+	add := func(i, j, delta int) {
+		if j-i < 2 || delta == 0 || delta > maxSequenceDelta || delta < -maxSequenceDelta {
+			return
+		}
+
+		token := password[i : j+1]
+		space := 26
+		switch {
+		case allRunes(token, unicode.IsLower), allRunes(token, unicode.IsUpper):
+		case allRunes(token, unicode.IsDigit):
+			space = 10
+		default:
+			// mixed classes, like "789:;<", are not something people type on purpose
+			return
+		}
+
+		matches = append(matches, &match{
+			pattern:       patternSequence,
+			i:             i,
+			j:             j,
+			token:         string(token),
+			sequenceSpace: space,
+			ascending:     delta > 0,
+		})
+	}
+
+	i := 0
+	lastDelta := 0
+	for k := 1; k < len(password); k++ {
+		delta := int(password[k]) - int(password[k-1])
+		if k == 1 {
+			lastDelta = delta
+			continue
+		}
+		if delta == lastDelta {
+			continue
+		}
+		add(i, k-1, lastDelta)
+		i = k - 1
+		lastDelta = delta
+	}
+	add(i, len(password)-1, lastDelta)
+
+	return matches
+}
+
+func allRunes(runes []rune, is func(rune) bool) bool {
+	for _, r := range runes {
+		if !is(r) {
+			return false
+		}
+	}
+	return true
+}
+
+const regexRecentYear = "recent_year"
+
+// regexMatch finds years between 1900 and 2099, which people add to passwords a lot.
+func regexMatch(password []rune) []*match {
+	var matches []*match
+	for i := 0; i+4 <= len(password); i++ {
+		token := password[i : i+4]
+		if !allRunes(token, isASCIIDigit) || !(token[0] == '1' && token[1] == '9' || token[0] == '2' && token[1] == '0') {
+			continue
+		}
+
+		year, _ := strconv.Atoi(string(token))
+		matches = append(matches, &match{
+			pattern:   patternRegex,
+			i:         i,
+			j:         i + 3,
+			token:     string(token),
+			regexName: regexRecentYear,
+			year:      year,
+		})
+	}
+	return matches
+}
+
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+const (
+	minYear = 1000
+	maxYear = 2050
+)
+
+func isDateSeparator(r rune) bool {
+	switch r {
+	case ' ', '/', '\\', '_', '.', '-':
+		return true
+	}
+	return false
+}
+
+// dateMatch finds dates written with or without separators: "13/05/1990", "1990-05-13",
+// "130590", "5.13.90". Dates contained in a longer date are dropped.
+func dateMatch(password []rune) []*match {
+	var matches []*match
+
+	// without separators, 4 to 8 digits
+	for i := 0; i+4 <= len(password); i++ {
+		for j := i + 3; j < i+8 && j < len(password); j++ {
+			token := password[i : j+1]
+			if !allRunes(token, isASCIIDigit) {
+				break
+			}
+			if year, ok := bestDateSplit(string(token)); ok {
+				matches = append(matches, &match{pattern: patternDate, i: i, j: j, token: string(token), year: year})
+			}
+		}
+	}
+
+	// with the same separator twice, 6 to 10 characters
+	for i := 0; i+6 <= len(password); i++ {
+		for j := i + 5; j < i+10 && j < len(password); j++ {
+			token := password[i : j+1]
+			year, separator, ok := separatedDate(token)
+			if ok {
+				matches = append(matches, &match{pattern: patternDate, i: i, j: j, token: string(token), year: year, separator: separator})
+			}
+		}
+	}
+
+	var filtered []*match
+	for _, m := range matches {
+		contained := false
+		for _, other := range matches {
+			if m != other && other.i <= m.i && other.j >= m.j && (other.i != m.i || other.j != m.j) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// bestDateSplit tries every way of splitting digits into day, month and year and keeps
+// the year closest to referenceYear.
+func bestDateSplit(digits string) (int, bool) {
+	splits := map[int][][2]int{
+		4: {{1, 2}, {2, 3}},
+		5: {{1, 3}, {2, 3}},
+		6: {{1, 2}, {2, 4}, {4, 5}},
+		7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+		8: {{2, 4}, {4, 6}},
+	}
+
+	best, found := 0, false
+	for _, split := range splits[len(digits)] {
+		k, l := split[0], split[1]
+		year, ok := mapIntsToYear(atoi(digits[:k]), atoi(digits[k:l]), atoi(digits[l:]))
+		if !ok {
+			continue
+		}
+		if !found || absInt(year-referenceYear) < absInt(best-referenceYear) {
+			best, found = year, true
+		}
+	}
+	return best, found
+}
+
+func separatedDate(token []rune) (int, string, bool) {
+	var parts []string
+	var separators []rune
+	start := 0
+	for k, r := range token {
+		if isASCIIDigit(r) {
+			continue
+		}
+		if !isDateSeparator(r) {
+			return 0, "", false
+		}
+		parts = append(parts, string(token[start:k]))
+		separators = append(separators, r)
+		start = k + 1
+	}
+	parts = append(parts, string(token[start:]))
+
+	if len(parts) != 3 || separators[0] != separators[1] {
+		return 0, "", false
+	}
+	if len(parts[0]) < 1 || len(parts[0]) > 4 || len(parts[1]) < 1 || len(parts[1]) > 2 || len(parts[2]) < 1 || len(parts[2]) > 4 {
+		return 0, "", false
+	}
+
+	year, ok := mapIntsToYear(atoi(parts[0]), atoi(parts[1]), atoi(parts[2]))
+	return year, string(separators[0]), ok
+}
+
+// mapIntsToYear reads three numbers as a date in any common order, day-month-year,
+// month-day-year or year-month-day, and returns the year when one reading is valid.
+// Two digit years are read as the closest century.
+func mapIntsToYear(a, b, c int) (int, bool) {
+	if b > 31 || b <= 0 {
+		return 0, false
+	}
+
+	candidates := [][3]int{
+		{c, b, a}, // day month year
+		{c, a, b}, // month day year
+		{a, b, c}, // year month day
+	}
+	for _, candidate := range candidates {
+		year, month, day := candidate[0], candidate[1], candidate[2]
+		if month < 1 || month > 12 || day < 1 || day > 31 {
+			continue
+		}
+		if year < 100 {
+			year = twoToFourDigitYear(year)
+		}
+		if year >= minYear && year <= maxYear {
+			return year, true
+		}
+	}
+	return 0, false
+}
+
+func twoToFourDigitYear(year int) int {
+	if year > 50 {
+		return year + 1900
+	}
+	return year + 2000
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,332 @@
+package strength
+
+import (
+	"math"
+	"unicode"
+)
+
+const (
+	bruteforceCardinality = 10
+	// every extra match in a sequence costs at least this many guesses, otherwise
+	// splitting a random string into tiny matches would always look cheaper
+	minGuessesBeforeGrowingSequence = 10000
+	minSubmatchGuessesSingleChar    = 10
+	minSubmatchGuessesMultiChar     = 50
+	minYearSpace                    = 20
+)
+
+// mostGuessableSequence finds the sequence of non-overlapping matches, with brute force
+// filling the gaps, that needs the fewest guesses overall. For a sequence of l matches
+// that is l! * product(guesses) + minGuessesBeforeGrowingSequence^(l-1): the attacker
+// knows neither the patterns nor their order. excludeAdditive drops the second term,
+// used for the base token of a repeat.
+func mostGuessableSequence(password []rune, matches []*match, excludeAdditive bool) (float64, []*match) {
+	n := len(password)
+	if n == 0 {
+		return 1, nil
+	}
+
+	byEnd := make([][]*match, n)
+	for _, m := range matches {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	// for each end position k and sequence length l: the last match, the product of
+	// the guesses and the overall guesses of the best sequence found so far
+	type candidate struct {
+		m  *match
+		pi float64
+		g  float64
+	}
+	optimal := make([]map[int]candidate, n)
+	for k := range optimal {
+		optimal[k] = make(map[int]candidate)
+	}
+
+	update := func(m *match, l int) {
+		k := m.j
+		pi := estimateGuesses(m, password)
+		if l > 1 {
+			pi *= optimal[m.i-1][l-1].pi
+		}
+		g := factorial(l) * pi
+		if !excludeAdditive {
+			g += math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+		}
+
+		// a shorter sequence with fewer guesses already reaches k
+		for competingL, competing := range optimal[k] {
+			if competingL <= l && competing.g <= g {
+				return
+			}
+		}
+		optimal[k][l] = candidate{m: m, pi: pi, g: g}
+	}
+
+	bruteforceUpdate := func(k int) {
+		update(bruteforceMatch(password, 0, k), 1)
+		for i := 1; i <= k; i++ {
+			m := bruteforceMatch(password, i, k)
+			for l, last := range optimal[i-1] {
+				// two brute force matches next to each other are one longer brute force match
+				if last.m.pattern == patternBruteforce {
+					continue
+				}
+				update(m, l+1)
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			if m.i > 0 {
+				for l := range optimal[m.i-1] {
+					update(m, l+1)
+				}
+			} else {
+				update(m, 1)
+			}
+		}
+		bruteforceUpdate(k)
+	}
+
+	bestL, best := 0, math.Inf(1)
+	for l, c := range optimal[n-1] {
+		if c.g < best || c.g == best && l < bestL {
+			bestL, best = l, c.g
+		}
+	}
+
+	sequence := make([]*match, bestL)
+	k, l := n-1, bestL
+	for k >= 0 && l > 0 {
+		m := optimal[k][l].m
+		sequence[l-1] = m
+		k = m.i - 1
+		l--
+	}
+
+	return best, sequence
+}
+
+func bruteforceMatch(password []rune, i, j int) *match {
+	return &match{
+		pattern: patternBruteforce,
+		i:       i,
+		j:       j,
+		token:   string(password[i : j+1]),
+	}
+}
+
+func estimateGuesses(m *match, password []rune) float64 {
+	if m.guesses > 0 {
+		return m.guesses
+	}
+
+	length := m.j - m.i + 1
+	minGuesses := 1.0
+	if length < len(password) {
+		minGuesses = minSubmatchGuessesMultiChar
+		if length == 1 {
+			minGuesses = minSubmatchGuessesSingleChar
+		}
+	}
+
+	var guesses float64
+	switch m.pattern {
+	case patternBruteforce:
+		guesses = bruteforceGuesses(m)
+	case patternDictionary:
+		guesses = dictionaryGuesses(m)
+	case patternSpatial:
+		guesses = spatialGuesses(m)
+	case patternRepeat:
+		guesses = m.baseGuesses * float64(m.repeatCount)
+	case patternSequence:
+		guesses = sequenceGuesses(m)
+	case patternRegex:
+		guesses = math.Max(float64(absInt(m.year-referenceYear)), minYearSpace)
+	case patternDate:
+		guesses = dateGuesses(m)
+	}
+
+	m.guesses = math.Max(guesses, minGuesses)
+	return m.guesses
+}
+
+func bruteforceGuesses(m *match) float64 {
+	length := len([]rune(m.token))
+	guesses := math.Pow(bruteforceCardinality, float64(length))
+	if math.IsInf(guesses, 1) {
+		guesses = math.MaxFloat64
+	}
+
+	// a submatch has to cost more than any pattern of the same length, or the
+	// search would brute force everything
+	minGuesses := float64(minSubmatchGuessesSingleChar + 1)
+	if length > 1 {
+		minGuesses = minSubmatchGuessesMultiChar + 1
+	}
+	return math.Max(guesses, minGuesses)
+}
+
+func dictionaryGuesses(m *match) float64 {
+	guesses := float64(m.rank) * uppercaseVariations(m.token) * l33tVariations(m)
+	if m.reversed {
+		guesses *= 2
+	}
+	return guesses
+}
+
+// uppercaseVariations is 1 for all lower case, 2 for the usual capitalizations (first
+// letter, last letter or everything) and the number of ways to pick the upper case
+// letters otherwise.
+func uppercaseVariations(token string) float64 {
+	runes := []rune(token)
+	if allRunes(runes, func(r rune) bool { return !unicode.IsUpper(r) }) {
+		return 1
+	}
+
+	lowerAfterFirst := len(runes) > 1 && allRunes(runes[1:], func(r rune) bool { return !unicode.IsUpper(r) })
+	upperOnlyLast := len(runes) > 1 && unicode.IsUpper(runes[len(runes)-1]) &&
+		allRunes(runes[:len(runes)-1], func(r rune) bool { return !unicode.IsUpper(r) })
+	allUpper := allRunes(runes, func(r rune) bool { return !unicode.IsLower(r) })
+	if unicode.IsUpper(runes[0]) && lowerAfterFirst || upperOnlyLast || allUpper {
+		return 2
+	}
+
+	upper, lower := 0, 0
+	for _, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	var variations float64
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += nCk(upper+lower, k)
+	}
+	return variations
+}
+
+func l33tVariations(m *match) float64 {
+	if !m.l33t {
+		return 1
+	}
+
+	variations := 1.0
+	for subbed, letter := range m.sub {
+		s, u := 0, 0
+		for _, r := range []rune(m.token) {
+			switch unicode.ToLower(r) {
+			case subbed:
+				s++
+			case letter:
+				u++
+			}
+		}
+
+		if s == 0 || u == 0 {
+			// every instance was substituted, or none was, the attacker tries both
+			variations *= 2
+			continue
+		}
+
+		var possibilities float64
+		for k := 1; k <= min(s, u); k++ {
+			possibilities += nCk(s+u, k)
+		}
+		variations *= possibilities
+	}
+	return variations
+}
+
+func spatialGuesses(m *match) float64 {
+	g := keyboardGraph(m.graph)
+	length := len([]rune(m.token))
+
+	var guesses float64
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(m.turns, i-1); j++ {
+			guesses += nCk(i-1, j-1) * g.startingPositions * math.Pow(g.averageDegree, float64(j))
+		}
+	}
+
+	if m.shiftedCount > 0 {
+		s, u := m.shiftedCount, length-m.shiftedCount
+		if u == 0 {
+			guesses *= 2
+		} else {
+			var variations float64
+			for k := 1; k <= min(s, u); k++ {
+				variations += nCk(s+u, k)
+			}
+			guesses *= variations
+		}
+	}
+	return guesses
+}
+
+func keyboardGraph(name string) *adjacencyGraph {
+	for _, g := range keyboardGraphs() {
+		if g.name == name {
+			return g
+		}
+	}
+	return keyboardGraphs()[0]
+}
+
+func sequenceGuesses(m *match) float64 {
+	first := []rune(m.token)[0]
+
+	var base float64
+	switch {
+	case first == 'a' || first == 'A' || first == 'z' || first == 'Z' || first == '0' || first == '1' || first == '9':
+		// the obvious starting points
+		base = 4
+	case m.sequenceSpace == 10:
+		base = 10
+	default:
+		base = 26
+	}
+	if !m.ascending {
+		base *= 2
+	}
+	return base * float64(len([]rune(m.token)))
+}
+
+func dateGuesses(m *match) float64 {
+	guesses := math.Max(float64(absInt(m.year-referenceYear)), minYearSpace) * 365
+	if m.separator != "" {
+		guesses *= 4
+	}
+	return guesses
+}
+
+func nCk(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	if k == 0 {
+		return 1
+	}
+
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r *= float64(n)
+		r /= float64(d)
+		n--
+	}
+	return r
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for k := 2; k <= n; k++ {
+		f *= float64(k)
+	}
+	return f
+}
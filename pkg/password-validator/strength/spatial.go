@@ -0,0 +1,176 @@
+package strength
+
+import (
+	"strings"
+	"sync"
+)
+
+// keyboardLayout lists the keys of each row, every key as its unshifted character
+// followed by its shifted one, and how far each row is indented in half keys.
+type keyboardLayout struct {
+	name    string
+	rows    [][]string
+	indents []int
+}
+
+var keyboardLayouts = []keyboardLayout{
+	{
+		name: "qwerty",
+		rows: [][]string{
+			strings.Fields("`~ 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+"),
+			strings.Fields("qQ wW eE rR tT yY uU iI oO pP [{ ]} \\|"),
+			strings.Fields("aA sS dD fF gG hH jJ kK lL ;: '\""),
+			strings.Fields("zZ xX cC vV bB nN mM ,< .> /?"),
+		},
+		indents: []int{0, 3, 4, 5},
+	},
+	{
+		name: "abnt2",
+		rows: [][]string{
+			strings.Fields("'\" 1! 2@ 3# 4$ 5% 6¨ 7& 8* 9( 0) -_ =+"),
+			strings.Fields("qQ wW eE rR tT yY uU iI oO pP ´` [{"),
+			strings.Fields("aA sS dD fF gG hH jJ kK lL çÇ ~^ ]}"),
+			strings.Fields("\\| zZ xX cC vV bB nN mM ,< .> ;: /?"),
+		},
+		indents: []int{0, 3, 4, 3},
+	},
+}
+
+// adjacencyGraph maps every character to the keys around its own key, in the order
+// left, upper left, upper right, right, lower right, lower left. Missing neighbours
+// are empty strings so the index is the direction of a move.
+type adjacencyGraph struct {
+	name              string
+	adjacent          map[rune][]string
+	shifted           map[rune]bool
+	startingPositions float64
+	averageDegree     float64
+}
+
+var (
+	graphs     []*adjacencyGraph
+	graphsOnce sync.Once
+)
+
+func keyboardGraphs() []*adjacencyGraph {
+	graphsOnce.Do(func() {
+		for _, layout := range keyboardLayouts {
+			graphs = append(graphs, buildGraph(layout))
+		}
+	})
+	return graphs
+}
+
+func buildGraph(layout keyboardLayout) *adjacencyGraph {
+	// positions are in half keys: a row indented by one half key sits between the
+	// two keys above it
+	type position struct{ row, x int }
+	keys := make(map[position]string)
+	for row, rowKeys := range layout.rows {
+		for col, key := range rowKeys {
+			keys[position{row, layout.indents[row] + 2*col}] = key
+		}
+	}
+
+	g := &adjacencyGraph{
+		name:     layout.name,
+		adjacent: make(map[rune][]string),
+		shifted:  make(map[rune]bool),
+	}
+
+	degrees := 0
+	for pos, key := range keys {
+		neighbours := []string{
+			keys[position{pos.row, pos.x - 2}],
+			keys[position{pos.row - 1, pos.x - 1}],
+			keys[position{pos.row - 1, pos.x + 1}],
+			keys[position{pos.row, pos.x + 2}],
+			keys[position{pos.row + 1, pos.x + 1}],
+			keys[position{pos.row + 1, pos.x - 1}],
+		}
+		for _, n := range neighbours {
+			if n != "" {
+				degrees++
+			}
+		}
+
+		runes := []rune(key)
+		g.adjacent[runes[0]] = neighbours
+		g.adjacent[runes[1]] = neighbours
+		g.shifted[runes[1]] = true
+	}
+
+	g.startingPositions = float64(len(keys))
+	g.averageDegree = float64(degrees) / float64(len(keys))
+	return g
+}
+
+// spatialMatch finds keyboard walks of at least three keys, like "qwerty", "asdfgh" or
+// "1qaz2wsx", on every supported layout.
+func spatialMatch(password []rune) []*match {
+	var matches []*match
+	for _, g := range keyboardGraphs() {
+		matches = append(matches, spatialMatchGraph(password, g)...)
+	}
+	return matches
+}
+
+func spatialMatchGraph(password []rune, g *adjacencyGraph) []*match {
+	var matches []*match
+
+	i := 0
+	for i < len(password)-1 {
+		j := i + 1
+		lastDirection := -1
+		turns := 0
+		shiftedCount := 0
+		if g.shifted[password[i]] {
+			shiftedCount = 1
+		}
+
+		for {
+			found := false
+			if j < len(password) {
+				for direction, neighbour := range g.adjacent[password[j-1]] {
+					if neighbour == "" {
+						continue
+					}
+					index := strings.IndexRune(neighbour, password[j])
+					if index < 0 {
+						continue
+					}
+
+					found = true
+					if index > 0 {
+						shiftedCount++
+					}
+					if direction != lastDirection {
+						turns++
+						lastDirection = direction
+					}
+					break
+				}
+			}
+
+			if found {
+				j++
+				continue
+			}
+
+			if j-i > 2 {
+				matches = append(matches, &match{
+					pattern:      patternSpatial,
+					i:            i,
+					j:            j - 1,
+					token:        string(password[i:j]),
+					graph:        g.name,
+					turns:        turns,
+					shiftedCount: shiftedCount,
+				})
+			}
+			i = j
+			break
+		}
+	}
+	return matches
+}
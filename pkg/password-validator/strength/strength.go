@@ -0,0 +1,96 @@
+// Package strength estimates how many guesses an attacker needs to find a password,
+// in the style of Dropbox's zxcvbn: the password is split into the cheapest sequence of
+// known patterns (dictionary words, l33t, keyboard walks, dates, repeats, sequences) and
+// whatever is left is brute forced.
+package strength
+
+import (
+	"math"
+	"time"
+)
+
+// maxLength bounds the work done per estimate, the rest of a longer password only
+// makes it stronger.
+const maxLength = 100
+
+// Result is the outcome of Estimate. Score goes from 0 (too guessable) to 4 (very
+// unguessable), 3 is a reasonable minimum for an account password.
+type Result struct {
+	Guesses           float64           `json:"guesses"`
+	GuessesLog10      float64           `json:"guesses_log10"`
+	Score             int               `json:"score"`
+	CrackTimesSeconds CrackTimesSeconds `json:"crack_times_seconds"`
+	CrackTimesDisplay CrackTimesDisplay `json:"crack_times_display"`
+	Feedback          Feedback          `json:"feedback"`
+}
+
+// CrackTimesSeconds estimates the time to crack the password for a few attack scenarios.
+type CrackTimesSeconds struct {
+	OnlineThrottling   float64 `json:"online_throttling_100_per_hour"`
+	OnlineNoThrottling float64 `json:"online_no_throttling_10_per_second"`
+	OfflineSlowHashing float64 `json:"offline_slow_hashing_1e4_per_second"`
+	OfflineFastHashing float64 `json:"offline_fast_hashing_1e10_per_second"`
+}
+
+// CrackTimesDisplay is CrackTimesSeconds in words, e.g. "3 hours" or "centuries".
+type CrackTimesDisplay struct {
+	OnlineThrottling   string `json:"online_throttling_100_per_hour"`
+	OnlineNoThrottling string `json:"online_no_throttling_10_per_second"`
+	OfflineSlowHashing string `json:"offline_slow_hashing_1e4_per_second"`
+	OfflineFastHashing string `json:"offline_fast_hashing_1e10_per_second"`
+}
+
+// referenceYear anchors date and year guesses, recent years are the most likely.
+var referenceYear = time.Now().Year()
+
+// Estimate rates password. userInputs are strings an attacker targeting this user
+// would try first, such as their name and email.
+func Estimate(password string, userInputs ...string) Result {
+	runes := []rune(password)
+	if len(runes) > maxLength {
+		runes = runes[:maxLength]
+	}
+
+	matches := omnimatch(runes, newUserInputsDictionary(userInputs))
+	guesses, sequence := mostGuessableSequence(runes, matches, false)
+
+	score := scoreFor(guesses)
+	seconds := CrackTimesSeconds{
+		OnlineThrottling:   guesses / (100.0 / 3600),
+		OnlineNoThrottling: guesses / 10,
+		OfflineSlowHashing: guesses / 1e4,
+		OfflineFastHashing: guesses / 1e10,
+	}
+
+	return Result{
+		Guesses:           guesses,
+		GuessesLog10:      math.Log10(guesses),
+		Score:             score,
+		CrackTimesSeconds: seconds,
+		CrackTimesDisplay: CrackTimesDisplay{
+			OnlineThrottling:   displayTime(seconds.OnlineThrottling),
+			OnlineNoThrottling: displayTime(seconds.OnlineNoThrottling),
+			OfflineSlowHashing: displayTime(seconds.OfflineSlowHashing),
+			OfflineFastHashing: displayTime(seconds.OfflineFastHashing),
+		},
+		Feedback: feedbackFor(score, sequence),
+	}
+}
+
+// scoreFor buckets guesses, the small delta keeps a password exactly on a threshold
+// in the lower bucket.
+func scoreFor(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,239 @@
+package strength
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// patternsOf runs the estimator internals and returns the patterns of the cheapest sequence.
+func patternsOf(password string, userInputs ...string) []*match {
+	runes := []rune(password)
+	_, sequence := mostGuessableSequence(runes, omnimatch(runes, newUserInputsDictionary(userInputs)), false)
+	return sequence
+}
+
+func TestEstimate_Score(t *testing.T) {
+	tests := []struct {
+		password string
+		maxScore int
+		minScore int
+	}{
+		{password: "", maxScore: 0},
+		{password: "password", maxScore: 0},
+		{password: "Password2024!", maxScore: 2},
+		{password: "p@ssw0rd", maxScore: 0},
+		{password: "qwertyuiop", maxScore: 0},
+		{password: "senha123", maxScore: 1},
+		{password: "13/05/1990", maxScore: 1},
+		{password: "abcabcabc", maxScore: 0},
+		{password: "987654", maxScore: 0},
+		{password: "xK9#mQ2$vL7!", minScore: 4, maxScore: 4},
+		{password: "V3ry-Str0ng-P@ssw0rd", minScore: 4, maxScore: 4},
+		{password: "correct horse battery staple", minScore: 4, maxScore: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			result := Estimate(tt.password)
+			assert.GreaterOrEqual(t, result.Score, tt.minScore)
+			assert.LessOrEqual(t, result.Score, tt.maxScore)
+		})
+	}
+}
+
+func TestEstimate_Patterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		pattern  string
+		check    func(t *testing.T, m *match)
+	}{
+		{
+			name:     "dictionary word in portuguese",
+			password: "cachorro",
+			pattern:  patternDictionary,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, "portuguese", m.dictionaryName)
+			},
+		},
+		{
+			name:     "surname from the census list",
+			password: "kowalski",
+			pattern:  patternDictionary,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, "names", m.dictionaryName)
+			},
+		},
+		{
+			name:     "common password beyond the hand-picked ones",
+			password: "zaqwsx",
+			pattern:  patternDictionary,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, "passwords", m.dictionaryName)
+			},
+		},
+		{
+			name:     "accents are folded",
+			password: "coração",
+			pattern:  patternDictionary,
+		},
+		{
+			name:     "reversed word",
+			password: "drowssap",
+			pattern:  patternDictionary,
+			check: func(t *testing.T, m *match) {
+				assert.True(t, m.reversed)
+				assert.Equal(t, "password", m.matchedWord)
+			},
+		},
+		{
+			name:     "l33t substitutions",
+			password: "p@ssw0rd",
+			pattern:  patternDictionary,
+			check: func(t *testing.T, m *match) {
+				assert.True(t, m.l33t)
+				assert.Equal(t, map[rune]rune{'@': 'a', '0': 'o'}, m.sub)
+			},
+		},
+		{
+			name:     "qwerty walk",
+			password: "wsxcde",
+			pattern:  patternSpatial,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, "qwerty", m.graph)
+			},
+		},
+		{
+			name:     "abnt2 walk",
+			password: "çlkjhg",
+			pattern:  patternSpatial,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, "abnt2", m.graph)
+				assert.Equal(t, 1, m.turns)
+			},
+		},
+		{
+			name:     "repeat",
+			password: "xyzxyzxyz",
+			pattern:  patternRepeat,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, "xyz", m.baseToken)
+				assert.Equal(t, 3, m.repeatCount)
+			},
+		},
+		{
+			name:     "descending sequence",
+			password: "987654",
+			pattern:  patternSequence,
+			check: func(t *testing.T, m *match) {
+				assert.False(t, m.ascending)
+			},
+		},
+		{
+			name:     "separated date",
+			password: "13/05/1990",
+			pattern:  patternDate,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, 1990, m.year)
+				assert.Equal(t, "/", m.separator)
+			},
+		},
+		{
+			name:     "unseparated date",
+			password: "19900513",
+			pattern:  patternDate,
+			check: func(t *testing.T, m *match) {
+				assert.Equal(t, 1990, m.year)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sequence := patternsOf(tt.password)
+			require.Len(t, sequence, 1)
+			assert.Equal(t, tt.pattern, sequence[0].pattern)
+			if tt.check != nil {
+				tt.check(t, sequence[0])
+			}
+		})
+	}
+}
+
+func TestEstimate_UserInputs(t *testing.T) {
+	const password = "Zebulon.Kravitz!"
+
+	without := Estimate(password)
+	with := Estimate(password, "Zebulon Kravitz", "zebulon.kravitz@example.com")
+
+	assert.Less(t, with.Guesses, without.Guesses)
+	assert.Less(t, with.Score, without.Score)
+	assert.Equal(t, "Avoid your name, email or other personal details.", with.Feedback.Warning)
+
+	sequence := patternsOf(password, "zebulon.kravitz@example.com")
+	for _, m := range sequence {
+		if m.pattern == patternDictionary {
+			assert.Equal(t, userInputsDictionary, m.dictionaryName)
+		}
+	}
+}
+
+func TestEstimate_Feedback(t *testing.T) {
+	weak := Estimate("Password2024!")
+	assert.NotEmpty(t, weak.Feedback.Warning)
+	assert.Contains(t, weak.Feedback.Suggestions, suggestionExtraWord)
+	assert.Contains(t, weak.Feedback.Suggestions, "Capitalization doesn't help very much.")
+
+	strong := Estimate("correct horse battery staple")
+	assert.Empty(t, strong.Feedback.Warning)
+	assert.Empty(t, strong.Feedback.Suggestions)
+
+	empty := Estimate("")
+	assert.Len(t, empty.Feedback.Suggestions, 2)
+}
+
+func TestEstimate_CrackTimes(t *testing.T) {
+	result := Estimate("password")
+	assert.Equal(t, "less than a second", result.CrackTimesDisplay.OfflineFastHashing)
+	assert.InDelta(t, result.Guesses/10, result.CrackTimesSeconds.OnlineNoThrottling, 1e-9)
+
+	result = Estimate("correct horse battery staple")
+	assert.Equal(t, "centuries", result.CrackTimesDisplay.OfflineSlowHashing)
+}
+
+func TestEstimate_LongPassword(t *testing.T) {
+	result := Estimate(strings.Repeat("aB3$", 100))
+	assert.LessOrEqual(t, result.Score, 1)
+}
+
+func TestDisplayTime(t *testing.T) {
+	tests := []struct {
+		seconds  float64
+		expected string
+	}{
+		{0.5, "less than a second"},
+		{1, "1 second"},
+		{45, "45 seconds"},
+		{90, "2 minutes"},
+		{3 * 3600, "3 hours"},
+		{2 * 86400, "2 days"},
+		{40 * 86400, "1 month"},
+		{5 * 372 * 86400, "5 years"},
+		{1e12, "centuries"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, displayTime(tt.seconds))
+	}
+}
+
+func TestUppercaseVariations(t *testing.T) {
+	assert.Equal(t, 1.0, uppercaseVariations("password"))
+	assert.Equal(t, 2.0, uppercaseVariations("Password"))
+	assert.Equal(t, 2.0, uppercaseVariations("passworD"))
+	assert.Equal(t, 2.0, uppercaseVariations("PASSWORD"))
+	assert.Equal(t, nCk(8, 1)+nCk(8, 2), uppercaseVariations("PaSsword"))
+}
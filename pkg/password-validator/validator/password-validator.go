@@ -1,7 +1,6 @@
 package validator
 
 import (
-	"strings"
 	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
 )
 
@@ -11,51 +10,20 @@ func Validate(password string, minEntropy float64) error {
 		return nil
 	}
 
-	hasReplace := false
-	hasSep := false
-	hasOtherSpecial := false
-	hasLower := false
-	hasUpper := false
-	hasDigits := false
-	for _, c := range password {
-		if strings.ContainsRune(replaceChars, c) {
-			hasReplace = true
-			continue
-		}
-		if strings.ContainsRune(sepChars, c) {
-			hasSep = true
-			continue
-		}
-		if strings.ContainsRune(otherSpecialChars, c) {
-			hasOtherSpecial = true
-			continue
-		}
-		if strings.ContainsRune(lowerChars, c) {
-			hasLower = true
-			continue
-		}
-		if strings.ContainsRune(upperChars, c) {
-			hasUpper = true
-			continue
-		}
-		if strings.ContainsRune(digitsChars, c) {
-			hasDigits = true
-			continue
-		}
-	}
+	classes := getCharClasses(password)
 
 	var errs []error
 
-	if !hasOtherSpecial || !hasSep || !hasReplace {
+	if !classes.otherSpecial || !classes.sep || !classes.replace {
 		errs = append(errs, password_errors.ErrNoSpecialChars)
 	}
-	if !hasLower {
+	if !classes.lower {
 		errs = append(errs, password_errors.ErrNoLowercase)
 	}
-	if !hasUpper {
+	if !classes.upper {
 		errs = append(errs, password_errors.ErrNoUppercase)
 	}
-	if !hasDigits {
+	if !classes.digits {
 		errs = append(errs, password_errors.ErrNoDigits)
 	}
 	if entropy < minEntropy {
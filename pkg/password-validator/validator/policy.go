@@ -0,0 +1,126 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/celio001/prodify/pkg/logger"
+	password_errors "github.com/celio001/prodify/pkg/password-validator/erros"
+	"github.com/celio001/prodify/pkg/password-validator/strength"
+	"go.uber.org/zap"
+)
+
+// BreachChecker reports how many times a password appears in known data breaches.
+type BreachChecker interface {
+	Count(password string) (int, error)
+}
+
+// Policy describes the rules a password must follow. Zero values disable a rule.
+// MaxLength counts bytes, bcrypt ignores everything after the 72nd.
+// MinScore is the lowest strength.Estimate score accepted, from 1 to 4.
+// Breaches rejects every password it has seen at least once.
+type Policy struct {
+	MinEntropy     float64
+	MinScore       int
+	MinLength      int
+	MaxLength      int
+	RequireLower   bool
+	RequireUpper   bool
+	RequireDigits  bool
+	RequireSpecial bool
+	Breaches       BreachChecker
+}
+
+// Validate checks every rule and reports all failures at once. userInputs, such as the
+// user's name and email, make passwords built from them score lower.
+func (p Policy) Validate(password string, userInputs ...string) error {
+	var errs []error
+
+	if p.MinLength > 0 && len([]rune(password)) < p.MinLength {
+		errs = append(errs, password_errors.ErrTooShort)
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		errs = append(errs, password_errors.ErrTooLong)
+	}
+
+	classes := getCharClasses(password)
+
+	if p.RequireSpecial && !classes.hasSpecial() {
+		errs = append(errs, password_errors.ErrNoSpecialChars)
+	}
+	if p.RequireLower && !classes.lower {
+		errs = append(errs, password_errors.ErrNoLowercase)
+	}
+	if p.RequireUpper && !classes.upper {
+		errs = append(errs, password_errors.ErrNoUppercase)
+	}
+	if p.RequireDigits && !classes.digits {
+		errs = append(errs, password_errors.ErrNoDigits)
+	}
+	if getEntropy(password) < p.MinEntropy {
+		errs = append(errs, password_errors.ErrLowEntropy)
+	}
+	if p.MinScore > 0 && strength.Estimate(password, userInputs...).Score < p.MinScore {
+		errs = append(errs, password_errors.ErrTooGuessable)
+	}
+	if p.breached(password) {
+		errs = append(errs, password_errors.ErrBreached)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &password_errors.PasswordError{
+		BaseErr: password_errors.ErrPasswordValidator,
+		Reasons: errs,
+	}
+}
+
+// breached fails open: an unreadable corpus is logged and must not block every
+// registration and password change.
+func (p Policy) breached(password string) bool {
+	if p.Breaches == nil {
+		return false
+	}
+
+	count, err := p.Breaches.Count(password)
+	if err != nil {
+		logger.Log.Error("failed to check password against breaches", zap.String("error", err.Error()))
+		return false
+	}
+	return count > 0
+}
+
+type charClasses struct {
+	replace      bool
+	sep          bool
+	otherSpecial bool
+	lower        bool
+	upper        bool
+	digits       bool
+}
+
+func (c charClasses) hasSpecial() bool {
+	return c.replace || c.sep || c.otherSpecial
+}
+
+func getCharClasses(password string) charClasses {
+	var c charClasses
+	for _, r := range password {
+		switch {
+		case strings.ContainsRune(replaceChars, r):
+			c.replace = true
+		case strings.ContainsRune(sepChars, r):
+			c.sep = true
+		case strings.ContainsRune(otherSpecialChars, r):
+			c.otherSpecial = true
+		case strings.ContainsRune(lowerChars, r):
+			c.lower = true
+		case strings.ContainsRune(upperChars, r):
+			c.upper = true
+		case strings.ContainsRune(digitsChars, r):
+			c.digits = true
+		}
+	}
+	return c
+}
@@ -0,0 +1,70 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	TenantSetting      = "app.current_tenant"
+	CurrentUserSetting = "app.current_user"
+
+	// set_config with is_local true is SET LOCAL, in a form that takes parameters
+	setLocalQuery = `SELECT set_config($1, $2, true)`
+)
+
+// WithTenant runs fn in a transaction where the row-level security policies only let
+// through the rows of tenant. The setting ends with the transaction, it never leaks to
+// the next request using the pooled connection. The transaction is committed when fn
+// returns nil and rolled back otherwise.
+func WithTenant(ctx context.Context, db *sql.DB, tenant uuid.UUID, fn func(tx *sql.Tx) error) error {
+	return withLocalSetting(ctx, db, TenantSetting, tenant, fn)
+}
+
+// WithCurrentUser is WithTenant for the policies that give a user access to what they
+// created, across tenants.
+func WithCurrentUser(ctx context.Context, db *sql.DB, userPublicID uuid.UUID, fn func(tx *sql.Tx) error) error {
+	return withLocalSetting(ctx, db, CurrentUserSetting, userPublicID, fn)
+}
+
+func withLocalSetting(ctx context.Context, db *sql.DB, name string, value uuid.UUID, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("error starting transaction", zap.String("setting", name), zap.String("error", err.Error()))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setLocal(ctx, tx, name, value); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetTenant switches the tenant of a transaction already under way, for work that has to
+// visit several organizations in one transaction. Like WithTenant it ends with tx.
+func SetTenant(ctx context.Context, tx *sql.Tx, tenant uuid.UUID) error {
+	return setLocal(ctx, tx, TenantSetting, tenant)
+}
+
+// SetCurrentUser is SetTenant for the policies WithCurrentUser applies.
+func SetCurrentUser(ctx context.Context, tx *sql.Tx, userPublicID uuid.UUID) error {
+	return setLocal(ctx, tx, CurrentUserSetting, userPublicID)
+}
+
+func setLocal(ctx context.Context, tx *sql.Tx, name string, value uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, setLocalQuery, name, value.String()); err != nil {
+		logger.Log.Error("error setting "+name, zap.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package postgress
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/celio001/prodify/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTenant(t *testing.T) {
+	logger.Init("dev")
+
+	tenant := uuid.New()
+
+	tests := []struct {
+		name      string
+		setError  error
+		fnError   error
+		expectRun bool
+	}{
+		{name: "commits", expectRun: true},
+		{name: "rolls back when fn fails", fnError: errors.New("boom"), expectRun: true},
+		{name: "fn never runs without the tenant", setError: errors.New("db error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			expect := mock.ExpectExec(regexp.QuoteMeta(setLocalQuery)).WithArgs(TenantSetting, tenant.String())
+			if tt.setError != nil {
+				expect.WillReturnError(tt.setError)
+				mock.ExpectRollback()
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM product").WillReturnResult(sqlmock.NewResult(0, 1))
+				if tt.fnError != nil {
+					mock.ExpectRollback()
+				} else {
+					mock.ExpectCommit()
+				}
+			}
+
+			ran := false
+			err = WithTenant(context.Background(), db, tenant, func(tx *sql.Tx) error {
+				ran = true
+				if _, err := tx.Exec("DELETE FROM product"); err != nil {
+					return err
+				}
+				return tt.fnError
+			})
+
+			switch {
+			case tt.setError != nil:
+				assert.Equal(t, tt.setError, err)
+			case tt.fnError != nil:
+				assert.Equal(t, tt.fnError, err)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectRun, ran)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithCurrentUser(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	user := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(setLocalQuery)).WithArgs(CurrentUserSetting, user.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = WithCurrentUser(context.Background(), db, user, func(tx *sql.Tx) error { return nil })
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetTenant(t *testing.T) {
+	logger.Init("dev")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	user, orgA, orgB := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(setLocalQuery)).WithArgs(CurrentUserSetting, user.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(setLocalQuery)).WithArgs(TenantSetting, orgA.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(setLocalQuery)).WithArgs(TenantSetting, orgB.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	err = WithCurrentUser(ctx, db, user, func(tx *sql.Tx) error {
+		for _, org := range []uuid.UUID{orgA, orgB} {
+			if err := SetTenant(ctx, tx, org); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStoragePutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStorage(dir, "http://localhost:8080/uploads/")
+
+	err := store.Put("avatars/abc/1.png", strings.NewReader("image"))
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "avatars", "abc", "1.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image", string(content))
+	assert.Equal(t, "http://localhost:8080/uploads/avatars/abc/1.png", store.URL("avatars/abc/1.png"))
+
+	assert.NoError(t, store.Put("avatars/abc/1.png", strings.NewReader("replaced")))
+	content, _ = os.ReadFile(filepath.Join(dir, "avatars", "abc", "1.png"))
+	assert.Equal(t, "replaced", string(content))
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "avatars", "abc"))
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, store.Delete("avatars/abc/1.png"))
+	assert.ErrorIs(t, store.Delete("avatars/abc/1.png"), ErrNotFound)
+}
+
+func TestLocalStorageRejectsKeysOutsideDir(t *testing.T) {
+	store := NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads")
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "avatars/../../secret", "avatars//1.png", `avatars\1.png`, ".."} {
+		assert.ErrorIs(t, store.Put(key, strings.NewReader("x")), ErrInvalidKey, key)
+		assert.ErrorIs(t, store.Delete(key), ErrInvalidKey, key)
+	}
+}
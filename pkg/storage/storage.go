@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/celio001/prodify/config"
+)
+
+var (
+	ErrUnknownDriver = errors.New("unknown storage driver")
+	ErrInvalidKey    = errors.New("invalid storage key")
+	ErrNotFound      = errors.New("file not found")
+)
+
+// Storage keeps files under slash separated keys such as "avatars/<id>/<name>.png".
+type Storage interface {
+	Put(key string, content io.Reader) error
+	Delete(key string) error
+	// URL is where clients fetch the file from, the file does not have to exist.
+	URL(key string) string
+}
+
+// New builds the storage selected by STORAGE_DRIVER (only local for now).
+func New() (Storage, error) {
+	switch driver := config.GetString("STORAGE_DRIVER"); driver {
+	case "local":
+		return NewLocalStorage(config.GetString("STORAGE_LOCAL_DIR"), config.GetString("STORAGE_PUBLIC_URL")), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, driver)
+	}
+}
@@ -0,0 +1,27 @@
+package pkg_token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const tokenBytes = 32
+
+// Generate returns a random url-safe token and the hash that must be stored in its place.
+func Generate() (string, string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	plain := base64.RawURLEncoding.EncodeToString(b)
+	return plain, Hash(plain), nil
+}
+
+// Hash returns the hex encoded sha256 of the token, used to look it up without storing it.
+func Hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
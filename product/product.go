@@ -16,4 +16,5 @@ type Product struct {
 	UpdatedAt   time.Time
 	IsActive    bool
 	UserID      uuid.UUID
+	OrgID       uuid.UUID
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/celio001/prodify/pkg/logger"
+	"github.com/celio001/prodify/pkg/postgress"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -15,19 +16,25 @@ var (
 	ErrProductNotFound = errors.New("product not found")
 )
 
+// Every query is scoped to one organization, a product id from another organization
+// is not found. They also run under postgress.WithTenant, so the row-level security
+// policies hold even if a filter is missing.
 const (
 	createProduct = `INSERT INTO product 
-	(id, name, description, price, stock, createdAt, updatedAt, isActive, userID)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	(id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
-	getProduct = `SELECT * from product WHERE id = $1`
+	getProduct = `SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID 
+	FROM product 
+	WHERE id = $1 AND orgID = $2`
 
-	findAll = `SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID 
+	findAll = `SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID 
 	FROM product 
-	ORDER BY createdAt $1`
+	WHERE orgID = $1 
+	ORDER BY createdAt `
 
 	deleteProduct = `DELETE FROM product
-	WHERE id = $1`
+	WHERE id = $1 AND orgID = $2`
 
 	updateProduct = `UPDATE product 
 	SET name = $1, 
@@ -36,7 +43,7 @@ const (
 		stock = $4, 
 		updatedAt = $5, 
 		isActive = $6 
-	WHERE id = $7`
+	WHERE id = $7 AND orgID = $8`
 )
 
 type repository struct {
@@ -44,11 +51,11 @@ type repository struct {
 }
 
 type Repository interface {
-	CreateProduct(ctx context.Context, id uuid.UUID, name string, description string, price float64, stock int, userID uuid.UUID) error
-	FindByID(ctx context.Context, id string) (*Product, error)
-	FindAll(ctx context.Context, page int, limit int, sort string) ([]Product, error)
-	DeleteProduct(ctx context.Context, id string) error
-	UpdateProduct(ctx context.Context, product *Product) (*Product, error)
+	CreateProduct(ctx context.Context, orgID uuid.UUID, id uuid.UUID, name string, description string, price float64, stock int, userID uuid.UUID) error
+	FindByID(ctx context.Context, orgID uuid.UUID, id string) (*Product, error)
+	FindAll(ctx context.Context, orgID uuid.UUID, page int, limit int, sort string) ([]Product, error)
+	DeleteProduct(ctx context.Context, orgID uuid.UUID, id string) error
+	UpdateProduct(ctx context.Context, orgID uuid.UUID, product *Product) (*Product, error)
 }
 
 func NewRepository(Db *sql.DB) Repository {
@@ -57,21 +64,34 @@ func NewRepository(Db *sql.DB) Repository {
 	}
 }
 
-func (r *repository) CreateProduct(ctx context.Context, id uuid.UUID, name string, description string, price float64, stock int, userID uuid.UUID) error {
+func (r *repository) CreateProduct(ctx context.Context, orgID uuid.UUID, id uuid.UUID, name string, description string, price float64, stock int, userID uuid.UUID) error {
 	date := time.Now()
 
-	_, err := r.Db.ExecContext(ctx, createProduct, id, name, description, price, stock, date, date, true, userID)
+	return postgress.WithTenant(ctx, r.Db, orgID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, createProduct, id, name, description, price, stock, date, date, true, userID, orgID)
+		return err
+	})
+}
 
-	if err != nil {
+func (r *repository) FindByID(ctx context.Context, orgID uuid.UUID, id string) (*Product, error) {
+	var product *Product
+
+	err := postgress.WithTenant(ctx, r.Db, orgID, func(tx *sql.Tx) error {
+		var err error
+		product, err = findByID(ctx, tx, orgID, id)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	return product, nil
 }
 
-func (r *repository) FindByID(ctx context.Context, id string) (*Product, error) {
+func findByID(ctx context.Context, tx *sql.Tx, orgID uuid.UUID, id string) (*Product, error) {
 	var product Product
 
-	sqlErr := r.Db.QueryRowContext(ctx, getProduct, id).Scan(
+	sqlErr := tx.QueryRowContext(ctx, getProduct, id, orgID).Scan(
 		&product.ID,
 		&product.Name,
 		&product.Description,
@@ -81,6 +101,7 @@ func (r *repository) FindByID(ctx context.Context, id string) (*Product, error)
 		&product.UpdatedAt,
 		&product.IsActive,
 		&product.UserID,
+		&product.OrgID,
 	)
 
 	if sqlErr == sql.ErrNoRows {
@@ -92,33 +113,39 @@ func (r *repository) FindByID(ctx context.Context, id string) (*Product, error)
 	return &product, nil
 }
 
-func (r *repository) FindAll(ctx context.Context, page int, limit int, sort string) ([]Product, error) {
+func (r *repository) FindAll(ctx context.Context, orgID uuid.UUID, page int, limit int, sort string) ([]Product, error) {
 
-	if sort != "desc" {
-		sort = "asc"
+	// the direction can't be a query parameter, only these two are ever written into the query
+	query := findAll + "ASC"
+	if sort == "desc" {
+		query = findAll + "DESC"
 	}
 
+	args := []any{orgID}
 	if page != 0 && limit != 0 {
 		offset := (page - 1) * limit
-		query := findAll + ` LIMIT $2 OFFSET $3`
+		query += ` LIMIT $2 OFFSET $3`
+		args = append(args, limit, offset)
+	}
 
-		rows, err := r.Db.QueryContext(ctx, query, sort, limit, offset)
+	var products []Product
+
+	err := postgress.WithTenant(ctx, r.Db, orgID, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
 		if err != nil {
 			logger.Log.Error("error exec QueryContext", zap.String("error", err.Error()))
-			return nil, err
+			return err
 		}
 		defer rows.Close()
 
-		return scanProducts(rows)
-	}
-
-	rows, err := r.Db.QueryContext(ctx, findAll, sort)
+		products, err = scanProducts(rows)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	return scanProducts(rows)
+	return products, nil
 }
 
 func scanProducts(rows *sql.Rows) ([]Product, error) {
@@ -136,6 +163,7 @@ func scanProducts(rows *sql.Rows) ([]Product, error) {
 			&product.UpdatedAt,
 			&product.IsActive,
 			&product.UserID,
+			&product.OrgID,
 		)
 		if err != nil {
 			return nil, err
@@ -151,42 +179,53 @@ func scanProducts(rows *sql.Rows) ([]Product, error) {
 	return products, nil
 }
 
-func (r *repository) DeleteProduct(ctx context.Context, id string) error {
+func (r *repository) DeleteProduct(ctx context.Context, orgID uuid.UUID, id string) error {
 
-	product, err := r.FindByID(ctx, id)
-
-	if err != nil {
-		return err
-	}
+	return postgress.WithTenant(ctx, r.Db, orgID, func(tx *sql.Tx) error {
+		product, err := findByID(ctx, tx, orgID, id)
+		if err != nil {
+			return err
+		}
 
-	_, err = r.Db.ExecContext(ctx, deleteProduct, product.ID.String())
-	if err != nil {
-		logger.Log.Error("error exec ExecContext delete product", zap.String("error", err.Error()))
-		return err
-	}
-	return nil
+		_, err = tx.ExecContext(ctx, deleteProduct, product.ID.String(), orgID)
+		if err != nil {
+			logger.Log.Error("error exec ExecContext delete product", zap.String("error", err.Error()))
+			return err
+		}
+		return nil
+	})
 }
 
-func (r *repository) UpdateProduct(ctx context.Context, product *Product) (*Product, error) {
-	_, err := r.FindByID(ctx, product.ID.String())
-	if err != nil {
-		return nil, err
-	}
-
-	product.UpdatedAt = time.Now()
+func (r *repository) UpdateProduct(ctx context.Context, orgID uuid.UUID, product *Product) (*Product, error) {
 
-	_, err = r.Db.ExecContext(ctx, updateProduct,
-		product.Name,
-		product.Description,
-		product.Price,
-		product.Stock,
-		product.UpdatedAt,
-		product.IsActive,
-		product.ID,
-	)
+	err := postgress.WithTenant(ctx, r.Db, orgID, func(tx *sql.Tx) error {
+		current, err := findByID(ctx, tx, orgID, product.ID.String())
+		if err != nil {
+			return err
+		}
 
+		product.UpdatedAt = time.Now()
+		product.CreatedAt = current.CreatedAt
+		product.UserID = current.UserID
+		product.OrgID = current.OrgID
+
+		_, err = tx.ExecContext(ctx, updateProduct,
+			product.Name,
+			product.Description,
+			product.Price,
+			product.Stock,
+			product.UpdatedAt,
+			product.IsActive,
+			product.ID,
+			orgID,
+		)
+		if err != nil {
+			logger.Log.Error("error exec ExecContext delete update product", zap.String("error", err.Error()))
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		logger.Log.Error("error exec ExecContext delete update product", zap.String("error", err.Error()))
 		return nil, err
 	}
 
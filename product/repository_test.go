@@ -12,6 +12,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var productColumns = []string{"id", "name", "description", "price", "stock", "createdAt", "updatedAt", "isActive", "userID", "orgID"}
+
+// expectTenant expects the transaction every repository call opens for orgID.
+func expectTenant(mock sqlmock.Sqlmock, orgID uuid.UUID) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT set_config($1, $2, true)`)).
+		WithArgs("app.current_tenant", orgID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
 func TestCreateProduct_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -19,16 +29,20 @@ func TestCreateProduct_Success(t *testing.T) {
 
 	product_uuid := uuid.New()
 	userid := uuid.New()
+	orgid := uuid.New()
 
 	repo_product := product.NewRepository(db)
 
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product (id, name, description, price, stock, createdAt, updatedAt, isActive, userID) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")).
-		WithArgs(product_uuid, "product1", "novo produto cadastrado", 200.00, 5, sqlmock.AnyArg(), sqlmock.AnyArg(), true, userid).
+	expectTenant(mock, orgid)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO product (id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)")).
+		WithArgs(product_uuid, "product1", "novo produto cadastrado", 200.00, 5, sqlmock.AnyArg(), sqlmock.AnyArg(), true, userid, orgid).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err = repo_product.CreateProduct(context.Background(), product_uuid, "product1", "novo produto cadastrado", 200.00, 5, userid)
+	err = repo_product.CreateProduct(context.Background(), orgid, product_uuid, "product1", "novo produto cadastrado", 200.00, 5, userid)
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestGetProduct_Success(t *testing.T) {
@@ -38,21 +52,61 @@ func TestGetProduct_Success(t *testing.T) {
 
 	product_uuid := uuid.New()
 	userid := uuid.New()
+	orgid := uuid.New()
 
 	repo_product := product.NewRepository(db)
 
 	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
-	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "stock", "createdAt", "updatedAt", "isActive", "userID"}).
-		AddRow(product_uuid, "product1", "novo produto cadastrado", 200.00, 5, now, now, true, userid)
-
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * from product WHERE id = $1")).
-		WithArgs(product_uuid).
+	rows := sqlmock.NewRows(productColumns).
+		AddRow(product_uuid, "product1", "novo produto cadastrado", 200.00, 5, now, now, true, userid, orgid)
+
+	expectTenant(mock, orgid)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID
+	FROM product
+	WHERE id = $1 AND orgID = $2`)).
+		WithArgs(product_uuid, orgid).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
-	product, err := repo_product.FindByID(context.Background(), product_uuid.String())
+	product, err := repo_product.FindByID(context.Background(), orgid, product_uuid.String())
 
 	assert.NoError(t, err)
 	assert.Equal(t, "product1", product.Name)
+	assert.Equal(t, orgid, product.OrgID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProduct_OtherOrganization(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	product_uuid := uuid.New()
+	otherorg := uuid.New()
+
+	repo_product := product.NewRepository(db)
+
+	for range 3 {
+		expectTenant(mock, otherorg)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID
+	FROM product
+	WHERE id = $1 AND orgID = $2`)).
+			WithArgs(product_uuid, otherorg).
+			WillReturnRows(sqlmock.NewRows(productColumns))
+		mock.ExpectRollback()
+	}
+
+	_, err = repo_product.FindByID(context.Background(), otherorg, product_uuid.String())
+	assert.Equal(t, product.ErrProductNotFound, err)
+
+	err = repo_product.DeleteProduct(context.Background(), otherorg, product_uuid.String())
+	assert.Equal(t, product.ErrProductNotFound, err)
+
+	_, err = repo_product.UpdateProduct(context.Background(), otherorg, &product.Product{ID: product_uuid, Name: "taken over"})
+	assert.Equal(t, product.ErrProductNotFound, err)
+
+	// no DELETE or UPDATE was sent
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestFindAll_WithPagination(t *testing.T) {
@@ -65,19 +119,23 @@ func TestFindAll_WithPagination(t *testing.T) {
 	product1_uuid := uuid.New()
 	product2_uuid := uuid.New()
 	userid := uuid.New()
+	orgid := uuid.New()
 	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
 
-	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "stock", "createdAt", "updatedAt", "isActive", "userID"}).
-		AddRow(product1_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid).
-		AddRow(product2_uuid, "product2", "description 2", 300.00, 10, now, now, true, userid)
+	rows := sqlmock.NewRows(productColumns).
+		AddRow(product1_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid, orgid).
+		AddRow(product2_uuid, "product2", "description 2", 300.00, 10, now, now, true, userid, orgid)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID 
-	FROM product 
-	ORDER BY createdAt $1 LIMIT $2 OFFSET $3`)).
-		WithArgs("asc", 2, 0).
+	expectTenant(mock, orgid)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID
+	FROM product
+	WHERE orgID = $1
+	ORDER BY createdAt ASC LIMIT $2 OFFSET $3`)).
+		WithArgs(orgid, 2, 0).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
-	products, err := repo_product.FindAll(context.Background(), 1, 2, "asc")
+	products, err := repo_product.FindAll(context.Background(), orgid, 1, 2, "asc")
 
 	assert.NoError(t, err)
 	assert.Len(t, products, 2)
@@ -96,20 +154,24 @@ func TestFindAll_WithoutPagination(t *testing.T) {
 	product2_uuid := uuid.New()
 	product3_uuid := uuid.New()
 	userid := uuid.New()
+	orgid := uuid.New()
 	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
 
-	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "stock", "createdAt", "updatedAt", "isActive", "userID"}).
-		AddRow(product1_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid).
-		AddRow(product2_uuid, "product2", "description 2", 300.00, 10, now, now, true, userid).
-		AddRow(product3_uuid, "product3", "description 3", 400.00, 15, now, now, true, userid)
-
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID 
-	FROM product 
-	ORDER BY createdAt $1`)).
-		WithArgs("desc").
+	rows := sqlmock.NewRows(productColumns).
+		AddRow(product1_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid, orgid).
+		AddRow(product2_uuid, "product2", "description 2", 300.00, 10, now, now, true, userid, orgid).
+		AddRow(product3_uuid, "product3", "description 3", 400.00, 15, now, now, true, userid, orgid)
+
+	expectTenant(mock, orgid)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID
+	FROM product
+	WHERE orgID = $1
+	ORDER BY createdAt DESC`)).
+		WithArgs(orgid).
 		WillReturnRows(rows)
+	mock.ExpectCommit()
 
-	products, err := repo_product.FindAll(context.Background(), 0, 0, "desc")
+	products, err := repo_product.FindAll(context.Background(), orgid, 0, 0, "desc")
 
 	assert.NoError(t, err)
 	assert.Len(t, products, 3)
@@ -118,6 +180,26 @@ func TestFindAll_WithoutPagination(t *testing.T) {
 	assert.Equal(t, "product3", products[2].Name)
 }
 
+func TestFindAll_UnknownSortIsAscending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo_product := product.NewRepository(db)
+	orgid := uuid.New()
+
+	expectTenant(mock, orgid)
+	mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY createdAt ASC`)).
+		WithArgs(orgid).
+		WillReturnRows(sqlmock.NewRows(productColumns))
+	mock.ExpectCommit()
+
+	_, err = repo_product.FindAll(context.Background(), orgid, 0, 0, "1; DROP TABLE product")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestDeleteProduct_Success(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -128,23 +210,29 @@ func TestDeleteProduct_Success(t *testing.T) {
 	product1_uuid := uuid.New()
 
 	userid := uuid.New()
+	orgid := uuid.New()
 	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
 
-	rows := sqlmock.NewRows([]string{"id", "name", "description", "price", "stock", "createdAt", "updatedAt", "isActive", "userID"}).
-		AddRow(product1_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid)
-	
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * from product WHERE id = $1")).
-		WithArgs(product1_uuid).
+	rows := sqlmock.NewRows(productColumns).
+		AddRow(product1_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid, orgid)
+
+	expectTenant(mock, orgid)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID
+	FROM product
+	WHERE id = $1 AND orgID = $2`)).
+		WithArgs(product1_uuid, orgid).
 		WillReturnRows(rows)
 
 	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM product
-	WHERE id = $1`)).
-	WithArgs(product1_uuid).
+	WHERE id = $1 AND orgID = $2`)).
+	WithArgs(product1_uuid, orgid).
 	WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	err = repo_product.DeleteProduct(context.Background(), product1_uuid.String())
+	err = repo_product.DeleteProduct(context.Background(), orgid, product1_uuid.String())
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestUpdateProduct_Success(t *testing.T) {
@@ -156,25 +244,30 @@ func TestUpdateProduct_Success(t *testing.T) {
 
 	product_uuid := uuid.New()
 	userid := uuid.New()
+	orgid := uuid.New()
 	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
 
-	findRows := sqlmock.NewRows([]string{"id", "name", "description", "price", "stock", "createdAt", "updatedAt", "isActive", "userID"}).
-		AddRow(product_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid)
+	findRows := sqlmock.NewRows(productColumns).
+		AddRow(product_uuid, "product1", "description 1", 200.00, 5, now, now, true, userid, orgid)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT * from product WHERE id = $1")).
-		WithArgs(product_uuid).
+	expectTenant(mock, orgid)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, stock, createdAt, updatedAt, isActive, userID, orgID
+	FROM product
+	WHERE id = $1 AND orgID = $2`)).
+		WithArgs(product_uuid, orgid).
 		WillReturnRows(findRows)
 
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE product 
-	SET name = $1, 
-		description = $2, 
-		price = $3, 
-		stock = $4, 
-		updatedAt = $5, 
-		isActive = $6 
-	WHERE id = $7`)).
-		WithArgs("Updated Product", "Updated description", 299.99, 10, sqlmock.AnyArg(), true, product_uuid).
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE product
+	SET name = $1,
+		description = $2,
+		price = $3,
+		stock = $4,
+		updatedAt = $5,
+		isActive = $6
+	WHERE id = $7 AND orgID = $8`)).
+		WithArgs("Updated Product", "Updated description", 299.99, 10, sqlmock.AnyArg(), true, product_uuid, orgid).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	productToUpdate := &product.Product{
 		ID:          product_uuid,
@@ -183,14 +276,16 @@ func TestUpdateProduct_Success(t *testing.T) {
 		Price:       299.99,
 		Stock:       10,
 		IsActive:    true,
-		UserID:      userid,
 	}
 
-	updatedProduct, err := repo_product.UpdateProduct(context.Background(), productToUpdate)
+	updatedProduct, err := repo_product.UpdateProduct(context.Background(), orgid, productToUpdate)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Product", updatedProduct.Name)
 	assert.Equal(t, "Updated description", updatedProduct.Description)
 	assert.Equal(t, 299.99, updatedProduct.Price)
 	assert.Equal(t, 10, updatedProduct.Stock)
-}
\ No newline at end of file
+	assert.Equal(t, userid, updatedProduct.UserID)
+	assert.Equal(t, now, updatedProduct.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
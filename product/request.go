@@ -0,0 +1,49 @@
+package product
+
+import "github.com/go-playground/validator/v10"
+
+type CreateProductRequest struct {
+	Name        string  `json:"name" validate:"required,min=2,max=255"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gte=0"`
+	Stock       int     `json:"stock" validate:"gte=0"`
+}
+
+type UpdateProductRequest struct {
+	Name        string  `json:"name" validate:"required,min=2,max=255"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gte=0"`
+	Stock       int     `json:"stock" validate:"gte=0"`
+	IsActive    bool    `json:"isActive"`
+}
+
+func ProductValidateError(err error) map[string]string {
+	errors := make(map[string]string)
+
+	if validationErrs, ok := err.(validator.ValidationErrors); ok {
+		for _, fieldErr := range validationErrs {
+			field := fieldErr.Field()
+			tag := fieldErr.Tag()
+
+			switch field {
+			case "Name":
+				switch tag {
+				case "required":
+					errors[field] = "name is required"
+				case "min":
+					errors[field] = "name must have at least 2 characters"
+				case "max":
+					errors[field] = "name must have at most 255 characters"
+				}
+			case "Description":
+				errors[field] = "description must have at most 2000 characters"
+			case "Price":
+				errors[field] = "price can't be negative"
+			case "Stock":
+				errors[field] = "stock can't be negative"
+			}
+		}
+	}
+
+	return errors
+}